@@ -0,0 +1,85 @@
+// Команда certgen выпускает самоподписанный удостоверяющий центр, сертификат сервера и сертификат клиента
+// для разработки и запуска GophKeeper в docker-compose с шифрованием соединений.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/tlsconfig"
+)
+
+// Имена выпускаемых файлов без расширения.
+const (
+	caName     = "ca"
+	serverName = "server"
+	clientName = "client"
+)
+
+func main() {
+	out := flag.String("out", "certs", "directory for certificates and keys")
+	hosts := flag.String("hosts", "localhost,127.0.0.1", "comma separated DNS names and IP addresses of server certificate")
+	days := flag.Int("days", 365, "validity of certificates in days")
+	force := flag.Bool("force", false, "issue new CA even if it already exists in output directory")
+	flag.Parse()
+
+	if *days <= 0 {
+		log.Fatalf("validity of certificates must be positive")
+	}
+	err := generate(*out, splitHosts(*hosts), time.Duration(*days)*24*time.Hour, *force)
+	if err != nil {
+		log.Fatalf("failed to generate certificates, %v", err)
+	}
+	fmt.Printf("certificates are written to %s\n", *out)
+}
+
+// generate - функция для выпуска сертификатов в директорию dir. Если удостоверяющий центр уже выпущен и force не
+// установлен, он переиспользуется, чтобы ранее выданные клиентам сертификаты оставались действительными.
+func generate(dir string, hosts []string, validity time.Duration, force bool) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s, %w", dir, err)
+	}
+
+	ca, err := tlsconfig.LoadPair(dir, caName)
+	if force || errors.Is(err, fs.ErrNotExist) {
+		ca, err = tlsconfig.NewCA("GophKeeper development CA", validity)
+		if err != nil {
+			return err
+		}
+		if err := ca.WriteFiles(dir, caName); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	server, err := ca.IssueServer("gophkeeper-server", hosts, validity)
+	if err != nil {
+		return err
+	}
+	if err := server.WriteFiles(dir, serverName); err != nil {
+		return err
+	}
+	client, err := ca.IssueClient("gophkeeper-client", validity)
+	if err != nil {
+		return err
+	}
+	return client.WriteFiles(dir, clientName)
+}
+
+// splitHosts - функция для разбора списка имен и адресов сервера.
+func splitHosts(hosts string) []string {
+	var res []string
+	for _, h := range strings.Split(hosts, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			res = append(res, h)
+		}
+	}
+	return res
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/tlsconfig"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, generate(dir, []string{"localhost", "gophkeeper-server-app"}, time.Hour, false))
+
+	ca, err := tlsconfig.LoadPair(dir, caName)
+	require.NoError(t, err)
+	for _, name := range []string{serverName, clientName} {
+		pair, err := tlsconfig.LoadPair(dir, name)
+		require.NoError(t, err)
+		assert.NoError(t, pair.Cert.CheckSignatureFrom(ca.Cert))
+	}
+
+	// Повторный запуск переиспользует удостоверяющий центр
+	require.NoError(t, generate(dir, []string{"localhost"}, time.Hour, false))
+	again, err := tlsconfig.LoadPair(dir, caName)
+	require.NoError(t, err)
+	assert.Equal(t, ca.Cert.Raw, again.Cert.Raw)
+
+	// С force выпускается новый удостоверяющий центр
+	require.NoError(t, generate(dir, []string{"localhost"}, time.Hour, true))
+	forced, err := tlsconfig.LoadPair(dir, caName)
+	require.NoError(t, err)
+	assert.NotEqual(t, ca.Cert.Raw, forced.Cert.Raw)
+}
+
+func TestSplitHosts(t *testing.T) {
+	assert.Equal(t, []string{"localhost", "127.0.0.1"}, splitHosts(" localhost, ,127.0.0.1 "))
+	assert.Nil(t, splitHosts(""))
+}
@@ -5,16 +5,26 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
+	"github.com/abezemskiy/gophkeeper/internal/client/config"
+	"github.com/abezemskiy/gophkeeper/internal/client/rpc"
 	"github.com/abezemskiy/gophkeeper/internal/client/storage/inmemory"
-	"github.com/abezemskiy/gophkeeper/internal/server/config"
+	"github.com/abezemskiy/gophkeeper/internal/common/tracing"
 )
 
 var (
-	netAddr     string // адрес запуска сервиса
-	databaseDsn string // адрес базы данных
-	logLevel    string // уровень логирования
-	configFile  string // путь к файлу конфигурации
+	netAddr       string // адрес запуска сервиса
+	databaseDsn   string // адрес базы данных
+	logLevel      string // уровень логирования
+	configFile    string // путь к файлу конфигурации
+	transport     string // транспорт для обмена данными с сервером: rest или grpc
+	grpcAddr      string // адрес gRPC API сервера
+	tlsCA         string // путь к сертификату удостоверяющего центра, которому доверяет клиент
+	tlsCert       string // путь к сертификату клиента для mutual TLS
+	tlsKey        string // путь к закрытому ключу сертификата клиента
+	traceExporter string // экспортер спанов трассировки: none, stdout или otlp
+	traceEndpoint string // адрес коллектора OTLP
 )
 
 // logFile - файл для сохранения логов работы клиента.
@@ -48,6 +58,13 @@ func parseFlags() {
 
 	flag.StringVar(&logLevel, "l", "", "log level")
 	flag.StringVar(&configFile, "c", "", "name of configuration file")
+	flag.StringVar(&transport, "transport", "", "transport for data exchange with server: rest or grpc (default rest)")
+	flag.StringVar(&grpcAddr, "grpc-address", "", "address and port of server gRPC API, required for grpc transport")
+	flag.StringVar(&tlsCA, "tls-ca", "", "CA certificate file, server certificates of other CAs are rejected")
+	flag.StringVar(&tlsCert, "tls-cert", "", "client TLS certificate file for mutual TLS")
+	flag.StringVar(&tlsKey, "tls-key", "", "client TLS private key file for mutual TLS")
+	flag.StringVar(&traceExporter, "trace-exporter", "", "trace exporter: none, stdout or otlp (default none)")
+	flag.StringVar(&traceEndpoint, "trace-endpoint", "", "address of OTLP collector, OTEL_EXPORTER_OTLP_* variables are used if not set")
 
 	// Вызов flag.Parse() для парсинга аргументов
 	flag.Parse()
@@ -74,6 +91,27 @@ func parseConfigFile() {
 	if databaseDsn == "" {
 		databaseDsn = configs.DatabaseDSN
 	}
+	if transport == "" {
+		transport = configs.Transport
+	}
+	if grpcAddr == "" {
+		grpcAddr = configs.GRPCAddress
+	}
+	if tlsCA == "" {
+		tlsCA = configs.TLSCA
+	}
+	if tlsCert == "" {
+		tlsCert = configs.TLSCert
+	}
+	if tlsKey == "" {
+		tlsKey = configs.TLSKey
+	}
+	if traceExporter == "" {
+		traceExporter = configs.TraceExporter
+	}
+	if traceEndpoint == "" {
+		traceEndpoint = configs.TraceEndpoint
+	}
 }
 
 // parceEnvironment - функция для переопределения конфигурации из глобальных переменных.
@@ -88,6 +126,27 @@ func parseEnvironment() {
 	if logLevel == "" {
 		logLevel = os.Getenv("GOPHKEEPER_CLIENT_LOG_LEVEL")
 	}
+	if transport == "" {
+		transport = os.Getenv("GOPHKEEPER_CLIENT_TRANSPORT")
+	}
+	if grpcAddr == "" {
+		grpcAddr = os.Getenv("GOPHKEEPER_CLIENT_GRPC_ADDRESS")
+	}
+	if tlsCA == "" {
+		tlsCA = os.Getenv("GOPHKEEPER_CLIENT_TLS_CA")
+	}
+	if tlsCert == "" {
+		tlsCert = os.Getenv("GOPHKEEPER_CLIENT_TLS_CERT")
+	}
+	if tlsKey == "" {
+		tlsKey = os.Getenv("GOPHKEEPER_CLIENT_TLS_KEY")
+	}
+	if traceExporter == "" {
+		traceExporter = os.Getenv("GOPHKEEPER_CLIENT_TRACE_EXPORTER")
+	}
+	if traceEndpoint == "" {
+		traceEndpoint = os.Getenv("GOPHKEEPER_CLIENT_TRACE_ENDPOINT")
+	}
 }
 
 // checkVariables - функция для проверки корректности утсановки глобальных переменных.
@@ -101,5 +160,31 @@ func checkVariables() error {
 	if databaseDsn == "" {
 		return fmt.Errorf("database connection address must be set")
 	}
+
+	// По умолчанию клиент обменивается данными с сервером через REST API
+	if transport == "" {
+		transport = rpc.REST
+	}
+	if transport != rpc.REST && transport != rpc.GRPC {
+		return fmt.Errorf("unknown transport %s, must be %s or %s", transport, rpc.REST, rpc.GRPC)
+	}
+	if transport == rpc.GRPC && grpcAddr == "" {
+		return fmt.Errorf("address of server gRPC API must be set for %s transport", rpc.GRPC)
+	}
+	if (tlsCert == "") != (tlsKey == "") {
+		return fmt.Errorf("TLS certificate and private key must be set together")
+	}
+	if err := tracing.CheckExporter(traceExporter); err != nil {
+		return err
+	}
+	// Настройки TLS не применяются к серверу, адрес которого указан без шифрования
+	if useTLS() && !strings.HasPrefix(netAddr, "https://") {
+		return fmt.Errorf("server address must start with https:// when TLS is configured")
+	}
 	return nil
 }
+
+// useTLS - функция для проверки, что клиенту заданы настройки TLS.
+func useTLS() bool {
+	return tlsCA != "" || tlsCert != ""
+}
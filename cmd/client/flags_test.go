@@ -15,6 +15,13 @@ func resetVariables() {
 	databaseDsn = ""
 	logLevel = ""
 	configFile = ""
+	transport = ""
+	grpcAddr = ""
+	tlsCA = ""
+	tlsCert = ""
+	tlsKey = ""
+	traceExporter = ""
+	traceEndpoint = ""
 }
 
 func TestParseFlags(t *testing.T) {
@@ -22,7 +29,9 @@ func TestParseFlags(t *testing.T) {
 	resetVariables()
 	defer resetVariables()
 
-	os.Args = []string{"cmd", "-a", ":9000", "-l", "debug", "-d", "db_dsn", "-c", "/config/file"}
+	os.Args = []string{"cmd", "-a", ":9000", "-l", "debug", "-d", "db_dsn", "-c", "/config/file",
+		"-transport", "grpc", "-grpc-address", ":3200", "-tls-ca", "ca.crt", "-tls-cert", "client.crt", "-tls-key", "client.key",
+		"-trace-exporter", "stdout", "-trace-endpoint", "collector:4317"}
 
 	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	parseFlags()
@@ -31,6 +40,13 @@ func TestParseFlags(t *testing.T) {
 	assert.Equal(t, "debug", logLevel)
 	assert.Equal(t, "db_dsn", databaseDsn)
 	assert.Equal(t, "/config/file", configFile)
+	assert.Equal(t, "grpc", transport)
+	assert.Equal(t, ":3200", grpcAddr)
+	assert.Equal(t, "ca.crt", tlsCA)
+	assert.Equal(t, "client.crt", tlsCert)
+	assert.Equal(t, "client.key", tlsKey)
+	assert.Equal(t, "stdout", traceExporter)
+	assert.Equal(t, "collector:4317", traceEndpoint)
 }
 
 func TestParseFlagsPriority(t *testing.T) {
@@ -82,11 +98,25 @@ func TestParseEnvironment(t *testing.T) {
 	os.Setenv("GOPHKEEPER_CLIENT_ADDRESS", ":8000")
 	os.Setenv("GOPHKEEPER_CLIENT_DATABASE_URL", "env_dsn")
 	os.Setenv("GOPHKEEPER_CLIENT_LOG_LEVEL", "test_info")
+	os.Setenv("GOPHKEEPER_CLIENT_TRANSPORT", "grpc")
+	os.Setenv("GOPHKEEPER_CLIENT_GRPC_ADDRESS", ":3200")
+	os.Setenv("GOPHKEEPER_CLIENT_TLS_CA", "env_ca.crt")
+	os.Setenv("GOPHKEEPER_CLIENT_TLS_CERT", "env.crt")
+	os.Setenv("GOPHKEEPER_CLIENT_TLS_KEY", "env.key")
+	os.Setenv("GOPHKEEPER_CLIENT_TRACE_EXPORTER", "otlp")
+	os.Setenv("GOPHKEEPER_CLIENT_TRACE_ENDPOINT", "env:4317")
 
 	defer func() {
 		os.Unsetenv("GOPHKEEPER_CLIENT_ADDRESS")
 		os.Unsetenv("GOPHKEEPER_CLIENT_DATABASE_URL")
 		os.Unsetenv("GOPHKEEPER_CLIENT_LOG_LEVEL")
+		os.Unsetenv("GOPHKEEPER_CLIENT_TRANSPORT")
+		os.Unsetenv("GOPHKEEPER_CLIENT_GRPC_ADDRESS")
+		os.Unsetenv("GOPHKEEPER_CLIENT_TLS_CA")
+		os.Unsetenv("GOPHKEEPER_CLIENT_TLS_CERT")
+		os.Unsetenv("GOPHKEEPER_CLIENT_TLS_KEY")
+		os.Unsetenv("GOPHKEEPER_CLIENT_TRACE_EXPORTER")
+		os.Unsetenv("GOPHKEEPER_CLIENT_TRACE_ENDPOINT")
 	}()
 
 	parseEnvironment()
@@ -94,6 +124,13 @@ func TestParseEnvironment(t *testing.T) {
 	assert.Equal(t, ":8000", netAddr)
 	assert.Equal(t, "test_info", logLevel)
 	assert.Equal(t, "env_dsn", databaseDsn)
+	assert.Equal(t, "grpc", transport)
+	assert.Equal(t, ":3200", grpcAddr)
+	assert.Equal(t, "env_ca.crt", tlsCA)
+	assert.Equal(t, "env.crt", tlsCert)
+	assert.Equal(t, "env.key", tlsKey)
+	assert.Equal(t, "otlp", traceExporter)
+	assert.Equal(t, "env:4317", traceEndpoint)
 }
 
 func TestParseConfigFile(t *testing.T) {
@@ -104,10 +141,12 @@ func TestParseConfigFile(t *testing.T) {
 	testFlagNetAddr := "localhost:8082"
 	testFlagLogLevel := "info"
 	testFlagDatabaseDsn := "test dsn"
+	testFlagTransport := "grpc"
+	testFlagGRPCAddress := "localhost:3200"
 
 	createFile := func(name string) {
-		data := fmt.Sprintf("{\"address\": \"%s\",\"log_level\": \"%s\",\"database_dsn\": \"%s\"}",
-			testFlagNetAddr, testFlagLogLevel, testFlagDatabaseDsn)
+		data := fmt.Sprintf("{\"address\": \"%s\",\"log_level\": \"%s\",\"database_dsn\": \"%s\",\"transport\": \"%s\",\"grpc_address\": \"%s\",\"tls_ca\": \"file_ca.crt\",\"tls_cert\": \"file.crt\",\"tls_key\": \"file.key\",\"trace_exporter\": \"stdout\",\"trace_endpoint\": \"file:4317\"}",
+			testFlagNetAddr, testFlagLogLevel, testFlagDatabaseDsn, testFlagTransport, testFlagGRPCAddress)
 		f, err := os.Create(name)
 		require.NoError(t, err)
 		_, err = f.Write([]byte(data))
@@ -123,6 +162,13 @@ func TestParseConfigFile(t *testing.T) {
 	assert.Equal(t, testFlagNetAddr, netAddr)
 	assert.Equal(t, testFlagLogLevel, logLevel)
 	assert.Equal(t, testFlagDatabaseDsn, databaseDsn)
+	assert.Equal(t, testFlagTransport, transport)
+	assert.Equal(t, testFlagGRPCAddress, grpcAddr)
+	assert.Equal(t, "file_ca.crt", tlsCA)
+	assert.Equal(t, "file.crt", tlsCert)
+	assert.Equal(t, "file.key", tlsKey)
+	assert.Equal(t, "stdout", traceExporter)
+	assert.Equal(t, "file:4317", traceEndpoint)
 
 	err := os.Remove(nameFile)
 	require.NoError(t, err)
@@ -147,4 +193,37 @@ func TestCheckVariables(t *testing.T) {
 	databaseDsn = "some dsn"
 	err = checkVariables()
 	require.NoError(t, err)
+	// По умолчанию используется REST API
+	assert.Equal(t, "rest", transport)
+
+	transport = "soap"
+	err = checkVariables()
+	require.Error(t, err)
+
+	transport = "grpc"
+	err = checkVariables()
+	require.Error(t, err)
+
+	grpcAddr = "some grpc addr"
+	err = checkVariables()
+	require.NoError(t, err)
+
+	// Сертификат клиента задается вместе с ключом
+	tlsCert = "client.crt"
+	err = checkVariables()
+	require.Error(t, err)
+
+	// Настройки TLS требуют адрес сервера с https
+	tlsKey = "client.key"
+	err = checkVariables()
+	require.Error(t, err)
+
+	netAddr = "https://some addr"
+	err = checkVariables()
+	require.NoError(t, err)
+
+	// Неизвестный экспортер трассировки
+	traceExporter = "jaeger"
+	err = checkVariables()
+	require.Error(t, err)
 }
@@ -2,16 +2,24 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
+	"github.com/abezemskiy/gophkeeper/internal/client/cli"
 	"github.com/abezemskiy/gophkeeper/internal/client/identity"
 	"github.com/abezemskiy/gophkeeper/internal/client/identity/auth"
 	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/rpc"
 	"github.com/abezemskiy/gophkeeper/internal/client/storage"
 	"github.com/abezemskiy/gophkeeper/internal/client/storage/info"
 	"github.com/abezemskiy/gophkeeper/internal/client/storage/inmemory"
@@ -23,32 +31,34 @@ import (
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/add"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/add/bankcard"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/add/binary"
+	addLogin "github.com/abezemskiy/gophkeeper/internal/client/tui/data/add/login"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/add/password"
+	addSSHKey "github.com/abezemskiy/gophkeeper/internal/client/tui/data/add/sshkey"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/add/text"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/collections"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/delete"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/download"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/edit"
 	editBankCard "github.com/abezemskiy/gophkeeper/internal/client/tui/data/edit/bankcard"
 	editBinary "github.com/abezemskiy/gophkeeper/internal/client/tui/data/edit/binary"
+	editLogin "github.com/abezemskiy/gophkeeper/internal/client/tui/data/edit/login"
 	editPass "github.com/abezemskiy/gophkeeper/internal/client/tui/data/edit/password"
+	editSSHKey "github.com/abezemskiy/gophkeeper/internal/client/tui/data/edit/sshkey"
 	editText "github.com/abezemskiy/gophkeeper/internal/client/tui/data/edit/text"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/usage"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/view"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/home"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/ident/account"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/ident/authorize"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/ident/register"
+	"github.com/abezemskiy/gophkeeper/internal/common/tlsconfig"
+	"github.com/abezemskiy/gophkeeper/internal/common/tracing"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
 	repoSynch "github.com/abezemskiy/gophkeeper/internal/repositories/synchronization"
 
 	"github.com/go-resty/resty/v2"
 	"go.uber.org/zap"
-)
-
-const (
-	registerPattern      = "/api/client/register"      // паттерн api для регистрации пользователя
-	authorizationPattern = "/api/client/authorize"     // паттерн api для авторизации пользователя
-	addDataPattern       = "/api/client/data/add"      // паттерн api для добавления новых данных на сервер
-	replaceDataPattern   = "/api/client/data/replace"  // паттерн для замены старых данных на сервере новыми
-	conflictDataPattern  = "/api/client/data/conflict" // паттерн для обработки данных с потенциальным конфликтом
-	deleteDataPattern    = "/api/client/data/delete"   // паттерн для удаления данных
-	getDataPattern       = "/api/client/data/get"      // паттерн для получения данных от сервера
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -58,6 +68,18 @@ func main() {
 	}
 
 	ctx := context.Background()
+
+	// Спаны клиента выводятся в стандартный поток ошибок, так как стандартный поток вывода занят TUI
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		Service:  "gophkeeper-client",
+		Exporter: traceExporter,
+		Endpoint: traceEndpoint,
+		Output:   os.Stderr,
+	})
+	if err != nil {
+		log.Fatalf("Failed to configure tracing: %v\n", err)
+	}
+
 	// создаем экземпляр хранилища pg
 	stor, err := pg.NewStore(ctx, databaseDsn)
 	if err != nil {
@@ -70,15 +92,87 @@ func main() {
 	// Инициализирую хранилище расшифрованных данных пользователя в оперативной памяти
 	decrData := inmemory.NewDecryptedData()
 
-	// Инициализирую resty клиента
+	// Инициализирую resty клиента. Имя устройства передается серверу для журнала аудита
 	client := resty.New()
+	if hostname, err := os.Hostname(); err == nil {
+		client.SetHeader(api.DeviceHeader, hostname)
+	}
+
+	// При заданных настройках TLS клиент доверяет только указанному удостоверяющему центру и предъявляет серверу
+	// свой сертификат
+	var tlsCfg *tls.Config
+	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+	if useTLS() {
+		tlsCfg, err = tlsconfig.ClientConfig(tlsCA, tlsCert, tlsKey)
+		if err != nil {
+			log.Fatalf("Failed to configure TLS: %v\n", err)
+		}
+		httpTransport.TLSClientConfig = tlsCfg
+	}
+	// Контекст трассировки передается серверу в заголовках запросов
+	client.SetTransport(tracing.Transport(httpTransport))
+
+	// При выборе gRPC транспорта запросы к REST API выполняются через gRPC API сервера
+	var conn *grpc.ClientConn
+	if transport == rpc.GRPC {
+		conn, err = rpc.Dial(grpcAddr, tlsCfg)
+		if err != nil {
+			log.Fatalf("Failed to connect to server gRPC API: %v\n", err)
+		}
+		defer conn.Close()
+		client.SetTransport(tracing.Transport(rpc.NewTransport(conn, httpTransport)))
+	}
+
+	// Если передана команда, выполняю ее без запуска TUI
+	if flag.NArg() > 0 {
+		code := runCommand(ctx, stor, info, client, flag.Args())
+		if conn != nil {
+			conn.Close()
+		}
+		flushTracing(shutdownTracing)
+		os.Exit(code)
+	}
 
 	// ------------------------------------------------------------------------------
-	run(ctx, stor, info, client, decrData)
+	run(ctx, stor, info, client, decrData, conn)
+	flushTracing(shutdownTracing)
 }
 
-// run - будет полезна при инициализации зависимостей клиента перед запуском
-func run(ctx context.Context, stor *pg.Store, info identity.IUserInfoStorage, client *resty.Client, decrData storage.IStorage) {
+// flushTracing - функция для отправки накопленных спанов перед завершением клиента.
+func flushTracing(shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		log.Printf("Failed to flush traces: %v\n", err)
+	}
+}
+
+// runCommand - функция для выполнения неинтерактивной команды клиента. Возвращает код завершения процесса.
+func runCommand(ctx context.Context, stor *pg.Store, info identity.IUserInfoStorage, client *resty.Client, args []string) int {
+	// инициализация логера
+	if err := logger.Initialize(logLevel, logFile); err != nil {
+		log.Fatalf("Error starting client: %v", err)
+	}
+
+	// Долгоживущие команды (например, агент) завершаются по сигналу прерывания
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	defer stop()
+
+	err := cli.New(netAddr, stor, stor, stor, info, client, os.Stdin, os.Stdout).Run(ctx, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if errors.Is(err, cli.ErrUnknownCommand) {
+			fmt.Fprint(os.Stderr, cli.Usage)
+		}
+		return 1
+	}
+	return 0
+}
+
+// run - будет полезна при инициализации зависимостей клиента перед запуском. conn - соединение с gRPC API сервера,
+// nil при использовании REST API.
+func run(ctx context.Context, stor *pg.Store, info identity.IUserInfoStorage, client *resty.Client, decrData storage.IStorage,
+	conn *grpc.ClientConn) {
 	// инициализация логера
 	if err := logger.Initialize(logLevel, logFile); err != nil {
 		log.Fatalf("Error starting client: %v", err)
@@ -90,7 +184,7 @@ func run(ctx context.Context, stor *pg.Store, info identity.IUserInfoStorage, cl
 	ctx, cancelCtx := context.WithCancel(ctx)
 
 	// Создаю TUI интерфейс
-	app := createTUI(ctx, stor, stor, info, client, decrData)
+	app := createTUI(ctx, stor, stor, stor, info, client, decrData)
 
 	// Запускаю интерфейс в отдельной горутине
 	go func() {
@@ -113,36 +207,22 @@ func run(ctx context.Context, stor *pg.Store, info identity.IUserInfoStorage, cl
 
 	// Запускаю фоновую синхронизацию данных с сервером
 	wg.Add(1)
-	go func(ctx context.Context, stor storage.IEncryptedClientStorage, info identity.IUserInfoStorage, ident identity.ClientIdentifier,
-		client resty.Client, wg *sync.WaitGroup) {
-
+	go func(client resty.Client) {
 		defer wg.Done()
 
 		// Функция принимает копию resty клиента, чтобы установить на него мидлвари, необходимые только для синхронизации данных
 		// Устанавливаю мидлвари для resty клиента
-		client.OnBeforeRequest(auth.OnBeforeMiddleware(info, ident))
-		client.OnAfterResponse(auth.OnAfterMiddleware(info, ident, netAddr+authorizationPattern))
+		client.OnBeforeRequest(auth.OnBeforeMiddleware(info, stor))
+		client.OnAfterResponse(auth.OnAfterMiddleware(info, stor, netAddr+api.AuthorizationPattern))
 
-		ticker := time.NewTicker(repoSynch.GetPeroidOfSynchr())
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done(): // Проверяю, был ли передан сигнал остановки
-				logger.ClientLog.Info("Stopping data synchronization with server")
-				return
-			case <-ticker.C:
-				logger.ClientLog.Info("Start data synchronization with server")
-
-				err := synchronization.SynchronizeData(ctx, stor, info, &client, netAddr+addDataPattern, netAddr+conflictDataPattern, netAddr+getDataPattern)
-				if err != nil {
-					logger.ClientLog.Error("failed to synchronize data", zap.String("server address", netAddr), zap.String("error", err.Error()))
-				}
-				logger.ClientLog.Debug("Successful data synchronization with server")
-			}
+		// При работе через gRPC API синхронизация также запускается по уведомлениям сервера об изменении данных
+		var changes <-chan struct{}
+		if conn != nil {
+			changes = rpc.Watch(ctx, conn, userToken(info, stor), repoSynch.GetPeroidOfSynchr())
 		}
 
-	}(ctx, stor, info, stor, *client, &wg)
+		synchronization.Run(ctx, stor, info, &client, netAddr, repoSynch.GetPeroidOfSynchr(), changes)
+	}(*client)
 
 	// Запускаю фоновое обновление расшифрованных данных пользователя во временном хранилище
 	wg.Add(1)
@@ -184,9 +264,27 @@ func run(ctx context.Context, stor *pg.Store, info identity.IUserInfoStorage, cl
 	logger.ClientLog.Info("Shutdown the client gracefully")
 }
 
+// userToken - функция для получения токена текущего пользователя из локального хранилища.
+func userToken(info identity.IUserInfoStorage, ident identity.ClientIdentifier) rpc.TokenFunc {
+	return func(ctx context.Context) (string, error) {
+		authData, _ := info.Get()
+		if authData.Login == "" {
+			return "", nil
+		}
+		userData, ok, err := ident.Authorize(ctx, authData.Login)
+		if err != nil {
+			return "", fmt.Errorf("failed to get token from storage of user %s, %w", authData.Login, err)
+		}
+		if !ok {
+			return "", nil
+		}
+		return userData.Token, nil
+	}
+}
+
 // createTUI - функция для создания интерфейса tui.
-func createTUI(ctx context.Context, stor storage.IEncryptedClientStorage, ident identity.ClientIdentifier, info identity.IUserInfoStorage,
-	client *resty.Client, decrData storage.IStorage) *app.App {
+func createTUI(ctx context.Context, stor storage.IEncryptedClientStorage, ident identity.ClientIdentifier, remover identity.AccountRemover,
+	info identity.IUserInfoStorage, client *resty.Client, decrData storage.IStorage) *app.App {
 
 	// Копирую resty клиента и устанавливаю авторизационную middleware для запросов, которые требуют,
 	// чтобы пользователь был авторизирован
@@ -199,12 +297,12 @@ func createTUI(ctx context.Context, stor storage.IEncryptedClientStorage, ident
 	// Добавляю страницу регистрации
 	prims = append(prims, app.Primitives{
 		Name: tui.Register,
-		Prim: register.Page(ctx, ident, netAddr+registerPattern, client),
+		Prim: register.Page(ctx, ident, netAddr+api.RegisterPattern, client),
 	})
 	// Добавляю страницу авторизации
 	prims = append(prims, app.Primitives{
 		Name: tui.Login,
-		Prim: authorize.Page(ctx, ident, info),
+		Prim: authorize.Page(ctx, ident, info, netAddr+api.AuthorizationPattern, client),
 	})
 	// Добавляю страницу для взаимодействия с данными
 	prims = append(prims, app.Primitives{
@@ -224,27 +322,37 @@ func createTUI(ctx context.Context, stor storage.IEncryptedClientStorage, ident
 	// Добавляю страницу для добавления новой банковской карты
 	prims = append(prims, app.Primitives{
 		Name: tui.AddBankCard,
-		Prim: bankcard.AddBankcardPage(ctx, netAddr+addDataPattern, &authClient, stor, info),
+		Prim: bankcard.AddBankcardPage(ctx, netAddr+api.AddDataPattern, &authClient, stor, info),
 	})
 	// Добавляю страницу для добавления новых бинарных данных
 	prims = append(prims, app.Primitives{
 		Name: tui.AddBinary,
-		Prim: binary.AddBinaryPage(ctx, netAddr+addDataPattern, &authClient, stor, info),
+		Prim: binary.AddBinaryPage(ctx, netAddr+api.AddDataPattern, attachment.NewURLs(netAddr), &authClient, stor, info),
 	})
 	// Добавляю страницу для добавления нового пароля
 	prims = append(prims, app.Primitives{
 		Name: tui.AddPassword,
-		Prim: password.AddPasswordPage(ctx, netAddr+addDataPattern, &authClient, stor, info),
+		Prim: password.AddPasswordPage(ctx, netAddr+api.AddDataPattern, &authClient, stor, info),
 	})
 	// Добавляю страницу для добавления новых текстовых данных
 	prims = append(prims, app.Primitives{
 		Name: tui.AddText,
-		Prim: text.AddTextPage(ctx, netAddr+addDataPattern, &authClient, stor, info),
+		Prim: text.AddTextPage(ctx, netAddr+api.AddDataPattern, &authClient, stor, info),
+	})
+	// Добавляю страницу для добавления новой учетной записи
+	prims = append(prims, app.Primitives{
+		Name: tui.AddLogin,
+		Prim: addLogin.AddLoginPage(ctx, netAddr+api.AddDataPattern, &authClient, stor, info),
+	})
+	// Добавляю страницу для добавления нового SSH ключа
+	prims = append(prims, app.Primitives{
+		Name: tui.AddSSHKey,
+		Prim: addSSHKey.AddSSHKeyPage(ctx, netAddr+api.AddDataPattern, &authClient, stor, info),
 	})
 	// Добавляю страницу для удаления данных пользователя
 	prims = append(prims, app.Primitives{
 		Name: tui.Delete,
-		Prim: delete.Delete(ctx, netAddr+deleteDataPattern, &authClient, stor, info),
+		Prim: delete.Delete(ctx, netAddr+api.DeleteDataPattern, attachment.NewURLs(netAddr), &authClient, stor, info),
 	})
 	// Добавляю страницу для изменения данных пользователя
 	prims = append(prims, app.Primitives{
@@ -254,22 +362,52 @@ func createTUI(ctx context.Context, stor storage.IEncryptedClientStorage, ident
 	// Добавляю страницу для изменения данных банковской карты пользователя
 	prims = append(prims, app.Primitives{
 		Name: tui.EditBankCard,
-		Prim: editBankCard.EditBankcardPage(ctx, netAddr+replaceDataPattern, &authClient, stor, info),
+		Prim: editBankCard.EditBankcardPage(ctx, netAddr+api.ReplaceDataPattern, &authClient, stor, info),
 	})
 	// Добавляю страницу для изменения бинарных данных пользователя
 	prims = append(prims, app.Primitives{
 		Name: tui.EditBinary,
-		Prim: editBinary.EditBinaryPage(ctx, netAddr+replaceDataPattern, &authClient, stor, info),
+		Prim: editBinary.EditBinaryPage(ctx, netAddr+api.ReplaceDataPattern, attachment.NewURLs(netAddr), &authClient, stor, info),
 	})
 	// Добавляю страницу для изменения пароля пользователя
 	prims = append(prims, app.Primitives{
 		Name: tui.EditPassword,
-		Prim: editPass.EditPasswordPage(ctx, netAddr+replaceDataPattern, &authClient, stor, info),
+		Prim: editPass.EditPasswordPage(ctx, netAddr+api.ReplaceDataPattern, &authClient, stor, info),
 	})
 	// Добавляю страницу для изменения текста пользователя
 	prims = append(prims, app.Primitives{
 		Name: tui.EditText,
-		Prim: editText.EditTextPage(ctx, netAddr+replaceDataPattern, &authClient, stor, info),
+		Prim: editText.EditTextPage(ctx, netAddr+api.ReplaceDataPattern, &authClient, stor, info),
+	})
+	// Добавляю страницу для изменения учетной записи пользователя
+	prims = append(prims, app.Primitives{
+		Name: tui.EditLogin,
+		Prim: editLogin.EditLoginPage(ctx, netAddr+api.ReplaceDataPattern, &authClient, stor, info),
+	})
+	// Добавляю страницу для изменения SSH ключа пользователя
+	prims = append(prims, app.Primitives{
+		Name: tui.EditSSHKey,
+		Prim: editSSHKey.EditSSHKeyPage(ctx, netAddr+api.ReplaceDataPattern, &authClient, stor, info),
+	})
+	// Добавляю страницу для сохранения файла пользователя на диск
+	prims = append(prims, app.Primitives{
+		Name: tui.Download,
+		Prim: download.Page(ctx, attachment.NewURLs(netAddr), &authClient, decrData),
+	})
+	// Добавляю страницу с информацией об использовании хранилища сервера
+	prims = append(prims, app.Primitives{
+		Name: tui.Usage,
+		Prim: usage.Page(ctx, netAddr+api.UsagePattern, &authClient),
+	})
+	// Добавляю страницу с записями коллекций организаций пользователя
+	prims = append(prims, app.Primitives{
+		Name: tui.Collections,
+		Prim: collections.Page(ctx, netAddr, &authClient, info),
+	})
+	// Добавляю страницу для удаления учетной записи пользователя
+	prims = append(prims, app.Primitives{
+		Name: tui.DeleteAccount,
+		Prim: account.DeletePage(ctx, netAddr+api.AccountPattern, &authClient, remover, info, decrData),
 	})
 	// Добавляю приветственную страницу
 	prims = append(prims, app.Primitives{
@@ -8,16 +8,32 @@ import (
 	"strconv"
 
 	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/token"
+	"github.com/abezemskiy/gophkeeper/internal/common/tracing"
 	"github.com/abezemskiy/gophkeeper/internal/server/config"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/admin"
+	"github.com/abezemskiy/gophkeeper/internal/server/quota"
 )
 
 var (
-	netAddr     string // адрес запуска сервиса
-	databaseDsn string // адрес базы данных
-	logLevel    string // уровень логирования
-	configFile  string // путь к файлу конфигурации
-	secretKey   string // секретный ключ для создания JWT
-	expireToken int    // время действия JWT
+	netAddr       string // адрес запуска сервиса
+	grpcAddr      string // адрес запуска gRPC сервера, пустой адрес отключает gRPC API
+	opsAddr       string // адрес служебных адресов без TLS, по умолчанию они обслуживаются на адресе сервиса
+	databaseDsn   string // адрес базы данных
+	logLevel      string // уровень логирования
+	configFile    string // путь к файлу конфигурации
+	secretKey     string // секретный ключ для создания JWT
+	expireToken   int    // время действия JWT
+	demo          bool   // демонстрационный режим с хранением данных в оперативной памяти
+	maxBytes      int64  // квота объема данных пользователя в байтах
+	maxRecords    int    // квота количества записей пользователя
+	maxVersions   int    // квота количества версий одной записи
+	maxBodySize   int64  // ограничение размера тела запроса с данными в байтах
+	tlsCert       string // путь к сертификату сервера, без сертификата сервер работает без TLS
+	tlsKey        string // путь к закрытому ключу сертификата сервера
+	tlsClientCA   string // путь к сертификату удостоверяющего центра для проверки сертификатов клиентов
+	traceExporter string // экспортер спанов трассировки: none, stdout или otlp
+	traceEndpoint string // адрес коллектора OTLP
+	adminToken    string // токен администратора сервера, без токена адреса администратора отключены
 )
 
 // parseVariables - функция для установки конфигурационных параметров приложения.
@@ -27,6 +43,11 @@ func parseVariables() error {
 	parseConfigFile()
 	parseEnvironment()
 
+	// Если ограничение размера тела запроса не задано, использую значение по умолчанию
+	if maxBodySize == 0 {
+		maxBodySize = quota.DefaultMaxBodySize
+	}
+
 	// Проверяю корректность установки глобальных переменных
 	err := checkVariables()
 	if err != nil {
@@ -36,12 +57,21 @@ func parseVariables() error {
 	// Устанавливаю полученные значения глобальных переменных
 	token.SetSecretKey(secretKey)
 	token.SerExpireHour(expireToken)
+	admin.SetToken(adminToken)
+	quota.SetLimits(quota.Limits{
+		MaxBytes:    maxBytes,
+		MaxRecords:  maxRecords,
+		MaxVersions: maxVersions,
+		MaxBodySize: maxBodySize,
+	})
 	return nil
 }
 
 // parseFlags - функция для определения параметров конфигурации из флагов.
 func parseFlags() {
 	flag.StringVar(&netAddr, "a", "", "address and port to run server")
+	flag.StringVar(&grpcAddr, "grpc-address", "", "address and port to run gRPC server, gRPC API is disabled if not set")
+	flag.StringVar(&opsAddr, "ops-address", "", "address and port of health and metrics endpoints without TLS, served on main address if not set")
 
 	// настройка флага для хранения метрик в базе данных
 	flag.StringVar(&databaseDsn, "d", "", "database connection address") // по умолчанию адрес не задан
@@ -50,6 +80,17 @@ func parseFlags() {
 	flag.StringVar(&configFile, "c", "", "name of configuration file")
 	flag.StringVar(&secretKey, "secret-key", "", "secret key for generating JWT")
 	flagExpireToken := flag.Int("expire-token", 0, "JWT expiration date in hours")
+	flag.BoolVar(&demo, "demo", false, "run server in demo mode with in-memory storage")
+	flag.Int64Var(&maxBytes, "max-bytes", 0, "per-user quota of stored bytes, 0 means unlimited")
+	flag.IntVar(&maxRecords, "max-records", 0, "per-user quota of records, 0 means unlimited")
+	flag.IntVar(&maxVersions, "max-versions", 0, "quota of versions per record, 0 means unlimited")
+	flag.Int64Var(&maxBodySize, "max-body-size", 0, "limit of data request body size in bytes")
+	flag.StringVar(&tlsCert, "tls-cert", "", "server TLS certificate file, TLS is disabled if not set")
+	flag.StringVar(&tlsKey, "tls-key", "", "server TLS private key file")
+	flag.StringVar(&tlsClientCA, "tls-client-ca", "", "CA certificate file to verify client certificates, enables mutual TLS")
+	flag.StringVar(&traceExporter, "trace-exporter", "", "trace exporter: none, stdout or otlp (default none)")
+	flag.StringVar(&traceEndpoint, "trace-endpoint", "", "address of OTLP collector, OTEL_EXPORTER_OTLP_* variables are used if not set")
+	flag.StringVar(&adminToken, "admin-token", "", "token of server administrator, admin API is disabled if not set")
 
 	// Вызов flag.Parse() для парсинга аргументов
 	flag.Parse()
@@ -71,6 +112,12 @@ func parseConfigFile() {
 	if netAddr == "" {
 		netAddr = configs.Address
 	}
+	if grpcAddr == "" {
+		grpcAddr = configs.GRPCAddress
+	}
+	if opsAddr == "" {
+		opsAddr = configs.OpsAddress
+	}
 	if logLevel == "" {
 		logLevel = configs.LogLevel
 	}
@@ -83,6 +130,39 @@ func parseConfigFile() {
 	if expireToken == 0 {
 		expireToken = configs.ExpireToken
 	}
+	if !demo {
+		demo = configs.Demo
+	}
+	if maxBytes == 0 {
+		maxBytes = configs.MaxBytes
+	}
+	if maxRecords == 0 {
+		maxRecords = configs.MaxRecords
+	}
+	if maxVersions == 0 {
+		maxVersions = configs.MaxVersions
+	}
+	if maxBodySize == 0 {
+		maxBodySize = configs.MaxBodySize
+	}
+	if tlsCert == "" {
+		tlsCert = configs.TLSCert
+	}
+	if tlsKey == "" {
+		tlsKey = configs.TLSKey
+	}
+	if tlsClientCA == "" {
+		tlsClientCA = configs.TLSClientCA
+	}
+	if traceExporter == "" {
+		traceExporter = configs.TraceExporter
+	}
+	if traceEndpoint == "" {
+		traceEndpoint = configs.TraceEndpoint
+	}
+	if adminToken == "" {
+		adminToken = configs.AdminToken
+	}
 }
 
 // parceEnvironment - функция для переопределения конфигурации из глобальных переменных.
@@ -91,6 +171,12 @@ func parseEnvironment() {
 	if netAddr == "" {
 		netAddr = os.Getenv("GOPHKEEPER_SERVER_ADDRESS")
 	}
+	if grpcAddr == "" {
+		grpcAddr = os.Getenv("GOPHKEEPER_SERVER_GRPC_ADDRESS")
+	}
+	if opsAddr == "" {
+		opsAddr = os.Getenv("GOPHKEEPER_SERVER_OPS_ADDRESS")
+	}
 	if databaseDsn == "" {
 		databaseDsn = os.Getenv("GOPHKEEPER_SERVER_DATABASE_URL")
 	}
@@ -109,6 +195,53 @@ func parseEnvironment() {
 			}
 		}
 	}
+	if !demo {
+		envDemo := os.Getenv("GOPHKEEPER_SERVER_DEMO")
+		if envDemo != "" {
+			d, err := strconv.ParseBool(envDemo)
+			if err == nil {
+				demo = d
+			}
+		}
+	}
+	if maxBytes == 0 {
+		if v, err := strconv.ParseInt(os.Getenv("GOPHKEEPER_SERVER_MAX_BYTES"), 10, 64); err == nil {
+			maxBytes = v
+		}
+	}
+	if maxRecords == 0 {
+		if v, err := strconv.Atoi(os.Getenv("GOPHKEEPER_SERVER_MAX_RECORDS")); err == nil {
+			maxRecords = v
+		}
+	}
+	if maxVersions == 0 {
+		if v, err := strconv.Atoi(os.Getenv("GOPHKEEPER_SERVER_MAX_VERSIONS")); err == nil {
+			maxVersions = v
+		}
+	}
+	if maxBodySize == 0 {
+		if v, err := strconv.ParseInt(os.Getenv("GOPHKEEPER_SERVER_MAX_BODY_SIZE"), 10, 64); err == nil {
+			maxBodySize = v
+		}
+	}
+	if tlsCert == "" {
+		tlsCert = os.Getenv("GOPHKEEPER_SERVER_TLS_CERT")
+	}
+	if tlsKey == "" {
+		tlsKey = os.Getenv("GOPHKEEPER_SERVER_TLS_KEY")
+	}
+	if tlsClientCA == "" {
+		tlsClientCA = os.Getenv("GOPHKEEPER_SERVER_TLS_CLIENT_CA")
+	}
+	if traceExporter == "" {
+		traceExporter = os.Getenv("GOPHKEEPER_SERVER_TRACE_EXPORTER")
+	}
+	if traceEndpoint == "" {
+		traceEndpoint = os.Getenv("GOPHKEEPER_SERVER_TRACE_ENDPOINT")
+	}
+	if adminToken == "" {
+		adminToken = os.Getenv("GOPHKEEPER_SERVER_ADMIN_TOKEN")
+	}
 }
 
 // checkVariables - функция для проверки корректности утсановки глобальных переменных.
@@ -119,7 +252,8 @@ func checkVariables() error {
 	if logLevel == "" {
 		return fmt.Errorf("log level must be set")
 	}
-	if databaseDsn == "" {
+	// В демонстрационном режиме база данных не используется
+	if databaseDsn == "" && !demo {
 		return fmt.Errorf("database connection address must be set")
 	}
 	if secretKey == "" {
@@ -128,5 +262,20 @@ func checkVariables() error {
 	if expireToken == 0 {
 		return fmt.Errorf("expire token must be set")
 	}
+	if maxBytes < 0 || maxRecords < 0 || maxVersions < 0 || maxBodySize < 0 {
+		return fmt.Errorf("quotas must not be negative")
+	}
+	if (tlsCert == "") != (tlsKey == "") {
+		return fmt.Errorf("TLS certificate and private key must be set together")
+	}
+	if tlsClientCA != "" && tlsCert == "" {
+		return fmt.Errorf("TLS certificate must be set to verify client certificates")
+	}
+	if err := tracing.CheckExporter(traceExporter); err != nil {
+		return err
+	}
+	if adminToken != "" && len(adminToken) < admin.MinTokenLength {
+		return fmt.Errorf("admin token must be at least %d characters long", admin.MinTokenLength)
+	}
 	return nil
 }
@@ -12,11 +12,24 @@ import (
 
 func resetVariables() {
 	netAddr = ""
+	grpcAddr = ""
+	opsAddr = ""
 	databaseDsn = ""
 	logLevel = ""
 	configFile = ""
 	secretKey = ""
 	expireToken = 0
+	demo = false
+	maxBytes = 0
+	maxRecords = 0
+	maxVersions = 0
+	maxBodySize = 0
+	tlsCert = ""
+	tlsKey = ""
+	tlsClientCA = ""
+	traceExporter = ""
+	traceEndpoint = ""
+	adminToken = ""
 }
 
 func TestParseFlags(t *testing.T) {
@@ -24,18 +37,34 @@ func TestParseFlags(t *testing.T) {
 	resetVariables()
 	defer resetVariables()
 
-	os.Args = []string{"cmd", "-a", ":9000", "-l", "debug", "-d", "db_dsn", "-c", "/config/file",
-		"-secret-key", "test_secret_key", "-expire-token", "45"}
+	os.Args = []string{"cmd", "-a", ":9000", "-grpc-address", ":3200", "-ops-address", ":9090", "-l", "debug", "-d", "db_dsn", "-c", "/config/file",
+		"-secret-key", "test_secret_key", "-expire-token", "45", "-demo",
+		"-max-bytes", "1000", "-max-records", "10", "-max-versions", "3", "-max-body-size", "500",
+		"-tls-cert", "server.crt", "-tls-key", "server.key", "-tls-client-ca", "ca.crt",
+		"-trace-exporter", "otlp", "-trace-endpoint", "collector:4317", "-admin-token", "flag admin token"}
 
 	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 	parseFlags()
 
 	assert.Equal(t, ":9000", netAddr)
+	assert.Equal(t, ":3200", grpcAddr)
+	assert.Equal(t, ":9090", opsAddr)
 	assert.Equal(t, "debug", logLevel)
 	assert.Equal(t, "db_dsn", databaseDsn)
 	assert.Equal(t, "/config/file", configFile)
 	assert.Equal(t, "test_secret_key", secretKey)
 	assert.Equal(t, 45, expireToken)
+	assert.Equal(t, true, demo)
+	assert.Equal(t, int64(1000), maxBytes)
+	assert.Equal(t, 10, maxRecords)
+	assert.Equal(t, 3, maxVersions)
+	assert.Equal(t, int64(500), maxBodySize)
+	assert.Equal(t, "server.crt", tlsCert)
+	assert.Equal(t, "server.key", tlsKey)
+	assert.Equal(t, "ca.crt", tlsClientCA)
+	assert.Equal(t, "otlp", traceExporter)
+	assert.Equal(t, "collector:4317", traceEndpoint)
+	assert.Equal(t, "flag admin token", adminToken)
 }
 
 func TestParseFlagsPriority(t *testing.T) {
@@ -94,10 +123,23 @@ func TestParseEnvironment(t *testing.T) {
 
 	// Устанавливаем переменные окружения
 	os.Setenv("GOPHKEEPER_SERVER_ADDRESS", ":8000")
+	os.Setenv("GOPHKEEPER_SERVER_GRPC_ADDRESS", ":3300")
+	os.Setenv("GOPHKEEPER_SERVER_OPS_ADDRESS", ":9190")
 	os.Setenv("GOPHKEEPER_SERVER_DATABASE_URL", "env_dsn")
 	os.Setenv("GOPHKEEPER_SERVER_LOG_LEVEL", "test_info")
 	os.Setenv("GOPHKEEPER_SERVER_SECRET_KEY", "test_secret_key")
 	os.Setenv("GOPHKEEPER_SERVER_EXPIRE_TOKEN", "85")
+	os.Setenv("GOPHKEEPER_SERVER_DEMO", "true")
+	os.Setenv("GOPHKEEPER_SERVER_MAX_BYTES", "2000")
+	os.Setenv("GOPHKEEPER_SERVER_MAX_RECORDS", "20")
+	os.Setenv("GOPHKEEPER_SERVER_MAX_VERSIONS", "4")
+	os.Setenv("GOPHKEEPER_SERVER_MAX_BODY_SIZE", "600")
+	os.Setenv("GOPHKEEPER_SERVER_TLS_CERT", "env.crt")
+	os.Setenv("GOPHKEEPER_SERVER_TLS_KEY", "env.key")
+	os.Setenv("GOPHKEEPER_SERVER_TLS_CLIENT_CA", "env_ca.crt")
+	os.Setenv("GOPHKEEPER_SERVER_TRACE_EXPORTER", "stdout")
+	os.Setenv("GOPHKEEPER_SERVER_TRACE_ENDPOINT", "env:4317")
+	os.Setenv("GOPHKEEPER_SERVER_ADMIN_TOKEN", "env admin token")
 
 	defer func() {
 		os.Unsetenv("GOPHKEEPER_SERVER_ADDRESS")
@@ -105,15 +147,41 @@ func TestParseEnvironment(t *testing.T) {
 		os.Unsetenv("GOPHKEEPER_SERVER_LOG_LEVEL")
 		os.Unsetenv("GOPHKEEPER_SERVER_SECRET_KEY")
 		os.Unsetenv("GOPHKEEPER_SERVER_EXPIRE_TOKEN")
+		os.Unsetenv("GOPHKEEPER_SERVER_DEMO")
+		os.Unsetenv("GOPHKEEPER_SERVER_MAX_BYTES")
+		os.Unsetenv("GOPHKEEPER_SERVER_MAX_RECORDS")
+		os.Unsetenv("GOPHKEEPER_SERVER_MAX_VERSIONS")
+		os.Unsetenv("GOPHKEEPER_SERVER_MAX_BODY_SIZE")
+		os.Unsetenv("GOPHKEEPER_SERVER_GRPC_ADDRESS")
+		os.Unsetenv("GOPHKEEPER_SERVER_OPS_ADDRESS")
+		os.Unsetenv("GOPHKEEPER_SERVER_TLS_CERT")
+		os.Unsetenv("GOPHKEEPER_SERVER_TLS_KEY")
+		os.Unsetenv("GOPHKEEPER_SERVER_TLS_CLIENT_CA")
+		os.Unsetenv("GOPHKEEPER_SERVER_TRACE_EXPORTER")
+		os.Unsetenv("GOPHKEEPER_SERVER_TRACE_ENDPOINT")
+		os.Unsetenv("GOPHKEEPER_SERVER_ADMIN_TOKEN")
 	}()
 
 	parseEnvironment()
 
 	assert.Equal(t, ":8000", netAddr)
+	assert.Equal(t, ":3300", grpcAddr)
+	assert.Equal(t, ":9190", opsAddr)
 	assert.Equal(t, "test_info", logLevel)
 	assert.Equal(t, "env_dsn", databaseDsn)
 	assert.Equal(t, "test_secret_key", secretKey)
 	assert.Equal(t, 85, expireToken)
+	assert.Equal(t, true, demo)
+	assert.Equal(t, int64(2000), maxBytes)
+	assert.Equal(t, 20, maxRecords)
+	assert.Equal(t, 4, maxVersions)
+	assert.Equal(t, int64(600), maxBodySize)
+	assert.Equal(t, "env.crt", tlsCert)
+	assert.Equal(t, "env.key", tlsKey)
+	assert.Equal(t, "env_ca.crt", tlsClientCA)
+	assert.Equal(t, "stdout", traceExporter)
+	assert.Equal(t, "env:4317", traceEndpoint)
+	assert.Equal(t, "env admin token", adminToken)
 }
 
 func TestParseConfigFile(t *testing.T) {
@@ -128,7 +196,7 @@ func TestParseConfigFile(t *testing.T) {
 	testExpireToken := 12
 
 	createFile := func(name string) {
-		data := fmt.Sprintf("{\"address\": \"%s\",\"log_level\": \"%s\",\"database_dsn\": \"%s\",\"secret_key\":\"%s\", \"expire_token\":%d}",
+		data := fmt.Sprintf("{\"address\": \"%s\",\"grpc_address\": \":3400\",\"ops_address\": \":9290\",\"log_level\": \"%s\",\"database_dsn\": \"%s\",\"secret_key\":\"%s\", \"expire_token\":%d, \"tls_cert\":\"file.crt\", \"tls_key\":\"file.key\", \"tls_client_ca\":\"file_ca.crt\", \"trace_exporter\":\"otlp\", \"trace_endpoint\":\"file:4317\", \"admin_token\":\"file admin token\"}",
 			testFlagNetAddr, testFlagLogLevel, testFlagDatabaseDsn, testSecretKey, testExpireToken)
 		f, err := os.Create(name)
 		require.NoError(t, err)
@@ -143,10 +211,18 @@ func TestParseConfigFile(t *testing.T) {
 	parseConfigFile()
 
 	assert.Equal(t, testFlagNetAddr, netAddr)
+	assert.Equal(t, ":3400", grpcAddr)
+	assert.Equal(t, ":9290", opsAddr)
 	assert.Equal(t, testFlagLogLevel, logLevel)
 	assert.Equal(t, testFlagDatabaseDsn, databaseDsn)
 	assert.Equal(t, testSecretKey, secretKey)
 	assert.Equal(t, testExpireToken, expireToken)
+	assert.Equal(t, "file.crt", tlsCert)
+	assert.Equal(t, "file.key", tlsKey)
+	assert.Equal(t, "file_ca.crt", tlsClientCA)
+	assert.Equal(t, "otlp", traceExporter)
+	assert.Equal(t, "file:4317", traceEndpoint)
+	assert.Equal(t, "file admin token", adminToken)
 
 	err := os.Remove(nameFile)
 	require.NoError(t, err)
@@ -179,4 +255,53 @@ func TestCheckVariables(t *testing.T) {
 	expireToken = 5
 	err = checkVariables()
 	require.NoError(t, err)
+
+	// В демонстрационном режиме адрес базы данных не требуется
+	databaseDsn = ""
+	err = checkVariables()
+	require.Error(t, err)
+
+	demo = true
+	err = checkVariables()
+	require.NoError(t, err)
+
+	// Сертификат и ключ задаются вместе
+	tlsCert = "server.crt"
+	err = checkVariables()
+	require.Error(t, err)
+
+	tlsKey = "server.key"
+	err = checkVariables()
+	require.NoError(t, err)
+
+	// Проверка сертификатов клиентов требует TLS
+	tlsClientCA = "ca.crt"
+	err = checkVariables()
+	require.NoError(t, err)
+
+	tlsCert, tlsKey = "", ""
+	err = checkVariables()
+	require.Error(t, err)
+	tlsClientCA = ""
+
+	// Неизвестный экспортер трассировки
+	traceExporter = "jaeger"
+	err = checkVariables()
+	require.Error(t, err)
+	traceExporter = "otlp"
+	err = checkVariables()
+	require.NoError(t, err)
+
+	// Токен администратора не может быть коротким
+	adminToken = "short"
+	err = checkVariables()
+	require.Error(t, err)
+	adminToken = "long enough admin token"
+	err = checkVariables()
+	require.NoError(t, err)
+
+	// Квоты не могут быть отрицательными
+	maxRecords = -1
+	err = checkVariables()
+	require.Error(t, err)
 }
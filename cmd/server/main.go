@@ -2,25 +2,54 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	"github.com/abezemskiy/gophkeeper/internal/server/handlers"
-	"github.com/abezemskiy/gophkeeper/internal/server/identity/auth"
+	"github.com/abezemskiy/gophkeeper/internal/common/tlsconfig"
+	"github.com/abezemskiy/gophkeeper/internal/common/tracing"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/identity"
+	"github.com/abezemskiy/gophkeeper/internal/server/admincli"
+	"github.com/abezemskiy/gophkeeper/internal/server/audit"
+	"github.com/abezemskiy/gophkeeper/internal/server/health"
 	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/metrics"
+	"github.com/abezemskiy/gophkeeper/internal/server/notify"
+	"github.com/abezemskiy/gophkeeper/internal/server/router"
+	"github.com/abezemskiy/gophkeeper/internal/server/rpc"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage/memory"
 	"github.com/abezemskiy/gophkeeper/internal/server/storage/pg"
 
-	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 const shutdownWaitPeriod = 20 * time.Second // для установки в контекст для реализаации graceful shutdown
 
+// Параметры удаления частей незавершенных загрузок вложений.
+const (
+	unusedChunkTTL  = 24 * time.Hour // время хранения части, на которую не сослалось ни одно вложение
+	chunkGCInterval = time.Hour      // период запуска удаления частей без ссылок
+)
+
 func main() {
+	// Подкоманды администратора работают с запущенным сервером через REST API и не читают конфигурацию сервера
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		if err := admincli.Main(context.Background(), os.Args[2:], os.Stdout, os.Stderr); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	err := parseVariables()
 	if err != nil {
 		log.Fatalf("failed to set global variables, %v", err)
@@ -28,44 +57,133 @@ func main() {
 
 	ctx := context.Background()
 
+	// В демонстрационном режиме данные хранятся только в оперативной памяти и теряются при остановке сервера
+	if demo {
+		stor := memory.NewStore()
+		run(ctx, stor, stor, stor, stor, stor, stor, stor)
+		return
+	}
+
 	// создаем экземпляр хранилища pg
 	stor, err := pg.NewStore(ctx, databaseDsn)
 	if err != nil {
 		log.Fatalf("Failed to create storage: %v\n", err)
 	}
+	if err := metrics.RegisterDBStats(stor.Stats); err != nil {
+		log.Fatalf("Failed to register database metrics: %v\n", err)
+	}
 	// ------------------------------------------------------------------------------
 
-	run(ctx, stor)
+	run(ctx, stor, stor, stor, stor, stor, stor, stor, health.Check{Name: "database", Ping: stor.Ping})
 }
 
-// функция run будет необходима для инициализации зависимостей сервера перед запуском
-func run(ctx context.Context, stor *pg.Store) {
+// функция run будет необходима для инициализации зависимостей сервера перед запуском.
+// События учетных записей и изменения данных записываются в журнал аудита events, учетными записями accounts
+// управляет администратор сервера, ключевые пары и общие записи хранятся в shares, организации и их коллекции -
+// в orgs. Проверки ready выполняются при запросе готовности сервера.
+func run(ctx context.Context, ident identity.Identifier, stor storage.IEncryptedServerStorage, attach storage.IAttachmentStorage,
+	events storage.IAuditStorage, accounts storage.IAccountStorage, shares storage.IShareStorage,
+	orgs storage.IOrgStorage, ready ...health.Check) {
 	// Инициализация логера
 	if err := logger.Initialize(logLevel); err != nil {
 		log.Fatalf("Error starting server: %v", err)
 	}
 
-	logger.ServerLog.Info("Running gophkeeper", zap.String("address", netAddr))
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		Service:  "gophkeeper-server",
+		Exporter: traceExporter,
+		Endpoint: traceEndpoint,
+		Output:   os.Stdout,
+	})
+	if err != nil {
+		log.Fatalf("Error starting server: %v", err)
+	}
+
+	logger.ServerLog.Info("Running gophkeeper", zap.String("address", netAddr), zap.String("grpc address", grpcAddr),
+		zap.String("ops address", opsAddr), zap.String("trace exporter", traceExporter), zap.Bool("demo", demo),
+		zap.Bool("tls", tlsCert != ""), zap.Bool("mutual tls", tlsClientCA != ""))
+
+	// При заданном сертификате REST и gRPC API обслуживаются только по TLS
+	var tlsCfg *tls.Config
+	if tlsCert != "" {
+		tlsCfg, err = tlsconfig.ServerConfig(tlsCert, tlsKey, tlsClientCA)
+		if err != nil {
+			log.Fatalf("Error starting server: %v", err)
+		}
+	}
+
+	// Изменения данных через REST и gRPC API записываются в журнал аудита и рассылаются подписчикам
+	// gRPC потока уведомлений
+	hub := notify.NewHub()
+	stor = notify.NewStorage(audit.NewStorage(stor), hub)
+
+	// Служебные адреса обслуживаются на отдельном порту без TLS, если он задан: проверки оркестратора и Prometheus
+	// не предъявляют сертификат клиента при взаимном TLS
+	handler := router.MetricRouter(ident, stor, attach, events, accounts, shares, orgs)
+	var opsSrv *http.Server
+	if opsAddr != "" {
+		opsSrv = &http.Server{Addr: opsAddr, Handler: router.OpsRouter(ready...)}
+		go func() {
+			if err := opsSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Error starting ops server: %v", err)
+			}
+		}()
+	} else {
+		router.OpsRoutes(handler, ready...)
+	}
 
 	// запускаю сам сервис с проверкой отмены контекста для реализации graceful shutdown--------------
 	srv := &http.Server{
-		Addr:    netAddr,
-		Handler: MetricRouter(stor),
+		Addr:      netAddr,
+		Handler:   handler,
+		TLSConfig: tlsCfg,
 	}
+	// Части незавершенных загрузок вложений удаляются в фоне до остановки сервера
+	gcCtx, stopGC := context.WithCancel(ctx)
+	defer stopGC()
+	go collectChunks(gcCtx, attach)
+
 	// Канал для получения сигнала прерывания
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
 	// Горутина для запуска сервера
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if tlsCfg != nil {
+			// Сертификат уже загружен в TLSConfig
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Error starting server: %v", err)
 		}
 	}()
 
+	// Горутина для запуска gRPC сервера на отдельном порту
+	var grpcSrv *grpc.Server
+	if grpcAddr != "" {
+		listener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			log.Fatalf("Error starting gRPC server: %v", err)
+		}
+		var opts []grpc.ServerOption
+		if tlsCfg != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+		}
+		grpcSrv = rpc.NewServer(ident, stor, hub, events, accounts, opts...)
+		go func() {
+			if err := grpcSrv.Serve(listener); err != nil {
+				log.Fatalf("Error starting gRPC server: %v", err)
+			}
+		}()
+	}
+
 	// Блокирование до тех пор, пока не поступит сигнал о прерывании
 	<-quit
 	logger.ServerLog.Info("Shutting down server...", zap.String("address", netAddr))
+	stopGC()
 
 	// Create a context with timeout for graceful shutdown
 	ctx, cancel := context.WithTimeout(ctx, shutdownWaitPeriod)
@@ -76,28 +194,54 @@ func run(ctx context.Context, stor *pg.Store) {
 		log.Fatalf("Stopping server error: %v", err)
 	}
 
-	logger.ServerLog.Info("Shutdown the server gracefully", zap.String("address", netAddr))
-}
+	// останавливаю gRPC сервер. Потоки уведомлений не завершаются сами, поэтому по истечении времени ожидания
+	// соединения закрываются принудительно
+	if grpcSrv != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcSrv.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcSrv.Stop()
+		}
+	}
 
-// MetricRouter - дирежирует обработку http запросов к серверу.
-func MetricRouter(stor *pg.Store) chi.Router {
-	r := chi.NewRouter()
-
-	r.Route("/api/client", func(r chi.Router) {
-		r.Post("/register", logger.RequestLogger(handlers.RegisterHandler(stor)))
-		r.Post("/authorize", logger.RequestLogger(handlers.AuthorizeHandler(stor)))
-
-		r.Route("/data", func(r chi.Router) {
-			r.Post("/add", logger.RequestLogger(auth.Middleware(handlers.AddEncryptedDataHandler(stor))))
-			r.Post("/replace", logger.RequestLogger(auth.Middleware(handlers.ReplaceEncryptedDataHandler(stor))))
-			r.Get("/get", logger.RequestLogger(auth.Middleware(handlers.GetAllEncryptedDataHandler(stor))))
-			r.Delete("/delete", logger.RequestLogger(auth.Middleware(handlers.DeleteEncryptedDataHandler(stor))))
-			r.Post("/conflict", logger.RequestLogger(auth.Middleware(handlers.HandleConflictDataHandler(stor))))
-		})
-	})
+	// служебные адреса останавливаются последними, чтобы оркестратор видел сервер живым до завершения запросов
+	if opsSrv != nil {
+		if err := opsSrv.Shutdown(ctx); err != nil {
+			log.Fatalf("Stopping ops server error: %v", err)
+		}
+	}
 
-	// Определяем маршрут по умолчанию для некорректных запросов
-	r.NotFound(logger.RequestLogger(handlers.HandleOtherRequest()))
+	// отправляю спаны завершенных запросов
+	if err := shutdownTracing(ctx); err != nil {
+		logger.ServerLog.Error("failed to flush traces", zap.String("error", err.Error()))
+	}
+
+	logger.ServerLog.Info("Shutdown the server gracefully", zap.String("address", netAddr))
+}
 
-	return r
+// collectChunks - функция для периодического удаления частей вложений, на которые не сослалось ни одно вложение
+// в течение unusedChunkTTL. Такие части остаются от прерванных и не возобновленных загрузок и иначе занимали бы
+// квоту пользователя навсегда. Работает до отмены контекста.
+func collectChunks(ctx context.Context, attach storage.IAttachmentStorage) {
+	ticker := time.NewTicker(chunkGCInterval)
+	defer ticker.Stop()
+	for {
+		deleted, err := attach.DeleteUnusedChunks(ctx, time.Now().Add(-unusedChunkTTL))
+		if err != nil && ctx.Err() == nil {
+			logger.ServerLog.Error("delete unused chunks error", zap.String("error", err.Error()))
+		}
+		if deleted > 0 {
+			logger.ServerLog.Info("deleted unused chunks", zap.Int64("count", deleted))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
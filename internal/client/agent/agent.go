@@ -0,0 +1,321 @@
+// Пакет agent содержит локального агента клиента. Агент хранит разблокированную сессию пользователя (мастер пароль и id),
+// выполняет фоновую синхронизацию данных с сервером и предоставляет API поверх Unix-сокета, чтобы короткоживущие
+// команды и плагины редакторов получали данные пользователя без повторного ввода мастер пароля.
+// Запросы к агенту авторизуются токеном, который агент записывает в файл рядом с сокетом с правами только для владельца.
+// По умолчанию сокет и файл токена располагаются в приватной директории пользователя.
+// Агент блокирует сессию после периода бездействия.
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/handlers"
+	"github.com/abezemskiy/gophkeeper/internal/client/identity"
+	"github.com/abezemskiy/gophkeeper/internal/client/identity/auth"
+	"github.com/abezemskiy/gophkeeper/internal/client/localsocket"
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/info"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/inmemory"
+	"github.com/abezemskiy/gophkeeper/internal/client/synchronization"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+// SocketEnv - переменная окружения с путем к сокету агента.
+const SocketEnv = "GOPHKEEPER_AGENT_SOCKET"
+
+// DefaultIdleTimeout - период бездействия по умолчанию, после которого агент блокирует сессию.
+const DefaultIdleTimeout = 15 * time.Minute
+
+// Пути API агента.
+const (
+	StatusPath = "/status" // состояние сессии
+	UnlockPath = "/unlock" // разблокировка сессии
+	LockPath   = "/lock"   // блокировка сессии
+	DataPath   = "/data"   // расшифрованные данные пользователя
+	SyncPath   = "/sync"   // синхронизация данных с сервером
+)
+
+// tokenSuffix - суффикс файла с токеном агента, который располагается рядом с сокетом.
+const tokenSuffix = ".token"
+
+// Status - состояние сессии агента.
+type Status struct {
+	Locked bool   `json:"locked"`
+	Login  string `json:"login,omitempty"`
+}
+
+// SocketPath - функция для получения пути к сокету агента. Путь задается переменной окружения SocketEnv,
+// по умолчанию сокет располагается в приватной директории пользователя (см. localsocket.DefaultPath).
+func SocketPath() string {
+	if path := os.Getenv(SocketEnv); path != "" {
+		return path
+	}
+	return localsocket.DefaultPath("agent.sock")
+}
+
+// TokenPath - функция для получения пути к файлу с токеном агента по пути к сокету.
+func TokenPath(socket string) string {
+	return socket + tokenSuffix
+}
+
+// Agent - локальный агент клиента.
+type Agent struct {
+	addr       string
+	stor       storage.IEncryptedClientStorage
+	ident      identity.ClientIdentifier
+	info       *info.UserInfoStorage
+	authClient *resty.Client
+	idle       time.Duration
+	token      string
+
+	mu       sync.Mutex
+	lastUsed time.Time // время последнего обращения к данным пользователя
+}
+
+// New - фабричная функция агента. addr - адрес сервера, idle - период бездействия до блокировки сессии,
+// при неположительном значении используется DefaultIdleTimeout.
+func New(addr string, stor storage.IEncryptedClientStorage, ident identity.ClientIdentifier, client *resty.Client,
+	idle time.Duration) *Agent {
+
+	if idle <= 0 {
+		idle = DefaultIdleTimeout
+	}
+	userInfo := info.NewUserInfoStorage()
+
+	// Копирую resty клиента и устанавливаю мидлвари для запросов, которые требуют, чтобы пользователь был авторизирован
+	authClient := *client
+	authClient.OnBeforeRequest(auth.OnBeforeMiddleware(userInfo, ident))
+	authClient.OnAfterResponse(auth.OnAfterMiddleware(userInfo, ident, addr+api.AuthorizationPattern))
+
+	return &Agent{
+		addr:       addr,
+		stor:       stor,
+		ident:      ident,
+		info:       userInfo,
+		authClient: &authClient,
+		idle:       idle,
+		lastUsed:   time.Now(),
+	}
+}
+
+// Serve - запускает агента на Unix-сокете socket и блокируется до завершения контекста.
+// Вместе с агентом запускаются фоновая синхронизация данных с периодом syncPeriod и автоматическая блокировка сессии.
+func (a *Agent) Serve(ctx context.Context, socket string, syncPeriod time.Duration) error {
+	token, err := newToken()
+	if err != nil {
+		return err
+	}
+	a.token = token
+
+	listener, err := localsocket.Listen(socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(TokenPath(socket))
+	if err := localsocket.WriteSecret(TokenPath(socket), []byte(token)); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to write agent token, %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		synchronization.Run(ctx, a.stor, a.info, a.authClient, a.addr, syncPeriod, nil)
+	}()
+	go func() {
+		defer wg.Done()
+		a.autoLock(ctx)
+	}()
+
+	srv := &http.Server{Handler: a.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	logger.ClientLog.Info("agent started", zap.String("socket", socket))
+	err = srv.Serve(listener)
+	cancel()
+	wg.Wait()
+	a.Lock()
+
+	if errors.Is(err, http.ErrServerClosed) {
+		logger.ClientLog.Info("agent stopped")
+		return nil
+	}
+	return fmt.Errorf("agent stopped with error, %w", err)
+}
+
+// Handler - возвращает обработчик API агента.
+func (a *Agent) Handler() http.Handler {
+	r := chi.NewRouter()
+	r.Use(a.authorize)
+	r.Get(StatusPath, a.status)
+	r.Post(UnlockPath, a.unlock)
+	r.Post(LockPath, a.lock)
+	r.Get(DataPath, a.data)
+	r.Post(SyncPath, a.sync)
+	return r
+}
+
+// Lock - блокирует сессию: мастер пароль пользователя удаляется из памяти агента.
+func (a *Agent) Lock() {
+	a.info.Set(identity.AuthData{}, "")
+}
+
+// locked - проверяет, заблокирована ли сессия.
+func (a *Agent) locked() bool {
+	authData, _ := a.info.Get()
+	return authData.Login == ""
+}
+
+// autoLock - блокирует сессию после периода бездействия до завершения контекста.
+func (a *Agent) autoLock(ctx context.Context) {
+	ticker := time.NewTicker(a.idle / 10)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.mu.Lock()
+			idle := time.Since(a.lastUsed)
+			a.mu.Unlock()
+			if idle >= a.idle && !a.locked() {
+				a.Lock()
+				logger.ClientLog.Info("agent session locked after idle timeout")
+			}
+		}
+	}
+}
+
+// touch - продлевает сессию. Сессию продлевают только разблокировка и успешные обращения к данным, иначе
+// периодический опрос состояния не давал бы сессии заблокироваться.
+func (a *Agent) touch() {
+	a.mu.Lock()
+	a.lastUsed = time.Now()
+	a.mu.Unlock()
+}
+
+// authorize - мидлварь для проверки токена агента.
+func (a *Agent) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if a.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+			http.Error(res, "invalid agent token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(res, req)
+	})
+}
+
+// status - хэндлер для получения состояния сессии.
+func (a *Agent) status(res http.ResponseWriter, _ *http.Request) {
+	authData, _ := a.info.Get()
+	writeJSON(res, Status{Locked: authData.Login == "", Login: authData.Login})
+}
+
+// unlock - хэндлер для разблокировки сессии. Пользователь должен предварительно выполнить вход с данного устройства.
+func (a *Agent) unlock(res http.ResponseWriter, req *http.Request) {
+	var authData identity.AuthData
+	if err := json.NewDecoder(req.Body).Decode(&authData); err != nil {
+		http.Error(res, fmt.Sprintf("decode request error, %v", err), http.StatusBadRequest)
+		return
+	}
+	passOK, registered, err := handlers.Authorize(req.Context(), &authData, a.ident, a.info)
+	if err != nil {
+		logger.ClientLog.Error("agent unlock error", zap.String("error", err.Error()))
+		http.Error(res, fmt.Sprintf("authorization error, %v", err), http.StatusBadRequest)
+		return
+	}
+	if !registered {
+		http.Error(res, fmt.Sprintf("user %s has not logged in on this device", authData.Login), http.StatusNotFound)
+		return
+	}
+	if !passOK {
+		http.Error(res, "wrong password", http.StatusForbidden)
+		return
+	}
+	a.touch()
+	logger.ClientLog.Info("agent session unlocked", zap.String("login", authData.Login))
+	res.WriteHeader(http.StatusOK)
+}
+
+// lock - хэндлер для блокировки сессии.
+func (a *Agent) lock(res http.ResponseWriter, _ *http.Request) {
+	a.Lock()
+	logger.ClientLog.Info("agent session locked")
+	res.WriteHeader(http.StatusOK)
+}
+
+// data - хэндлер для получения всех расшифрованных данных пользователя из локального хранилища.
+func (a *Agent) data(res http.ResponseWriter, req *http.Request) {
+	if a.locked() {
+		http.Error(res, "agent is locked", http.StatusLocked)
+		return
+	}
+	decrData := inmemory.NewDecryptedData()
+	if err := decrData.Update(req.Context(), a.stor, a.info); err != nil {
+		logger.ClientLog.Error("agent decrypt data error", zap.String("error", err.Error()))
+		http.Error(res, fmt.Sprintf("failed to decrypt data, %v", err), http.StatusInternalServerError)
+		return
+	}
+	a.touch()
+	writeJSON(res, decrData.GetAll())
+}
+
+// sync - хэндлер для немедленной синхронизации данных с сервером.
+func (a *Agent) sync(res http.ResponseWriter, req *http.Request) {
+	if a.locked() {
+		http.Error(res, "agent is locked", http.StatusLocked)
+		return
+	}
+	err := synchronization.SynchronizeData(req.Context(), a.stor, a.info, a.authClient,
+		a.addr+api.AddDataPattern, a.addr+api.ConflictDataPattern, a.addr+api.GetDataPattern)
+	if err != nil {
+		logger.ClientLog.Error("agent synchronize data error", zap.String("error", err.Error()))
+		http.Error(res, fmt.Sprintf("failed to synchronize data, %v", err), http.StatusBadGateway)
+		return
+	}
+	a.touch()
+	res.WriteHeader(http.StatusOK)
+}
+
+// writeJSON - функция для отправки ответа в формате JSON.
+func writeJSON(res http.ResponseWriter, v any) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(v); err != nil {
+		logger.ClientLog.Error("encoding response error", zap.String("error", err.Error()))
+	}
+}
+
+// newToken - функция для генерации токена агента.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate agent token, %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,182 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/handlers"
+	"github.com/abezemskiy/gophkeeper/internal/client/identity"
+	"github.com/abezemskiy/gophkeeper/internal/client/identity/auth"
+	clientData "github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/info"
+	clientMemory "github.com/abezemskiy/gophkeeper/internal/client/storage/memory"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/testutil"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testLogin    = "agent login"
+	testPassword = "agent password"
+)
+
+// setup - запускает тестовый сервер, регистрирует пользователя и сохраняет его данные в локальном хранилище.
+// Возвращает адрес сервера, локальное хранилище и путь к сокету агента во временной директории.
+func setup(t *testing.T) (string, *clientMemory.Store, string) {
+	t.Helper()
+	ctx := context.Background()
+	srv := testutil.NewServer(t)
+
+	stor := clientMemory.NewStore()
+	authData := &identity.AuthData{Login: testLogin, Password: testPassword}
+	ok, err := handlers.Register(ctx, srv.URL+api.RegisterPattern, authData, resty.New(), stor)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	userInfo := info.NewUserInfoStorage()
+	passOK, _, err := handlers.Authorize(ctx, authData, stor, userInfo)
+	require.NoError(t, err)
+	require.True(t, passOK)
+	_, id := userInfo.Get()
+	payload, err := json.Marshal(clientData.Text{Text: "secret text"})
+	require.NoError(t, err)
+	authClient := resty.New().OnBeforeRequest(auth.OnBeforeMiddleware(userInfo, stor))
+	ok, err = handlers.SaveData(ctx, id, srv.URL+api.AddDataPattern, testPassword, authClient, stor,
+		&data.Data{Data: payload, Type: data.TEXT, Name: "note"})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Путь к Unix-сокету ограничен по длине, поэтому использую короткую временную директорию
+	dir, err := os.MkdirTemp("", "gk")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return srv.URL, stor, filepath.Join(dir, "agent.sock")
+}
+
+// start - запускает агента и ожидает его готовности. Возвращает функцию остановки агента.
+func start(t *testing.T, a *Agent, socket string) func() error {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Serve(ctx, socket, time.Hour) }()
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(TokenPath(socket))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return func() error {
+		cancel()
+		return <-done
+	}
+}
+
+func TestAgent(t *testing.T) {
+	ctx := context.Background()
+	addr, stor, socket := setup(t)
+
+	// Агент не запущен
+	_, err := NewClient(socket)
+	assert.ErrorIs(t, err, ErrNotRunning)
+
+	stop := start(t, New(addr, stor, stor, resty.New(), time.Hour), socket)
+
+	// Файл токена доступен только владельцу
+	stat, err := os.Stat(TokenPath(socket))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+
+	client, err := NewClient(socket)
+	require.NoError(t, err)
+
+	// Сессия заблокирована после запуска
+	status, err := client.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Status{Locked: true}, status)
+	_, err = client.Data(ctx)
+	assert.ErrorIs(t, err, ErrLocked)
+	assert.ErrorIs(t, client.Sync(ctx), ErrLocked)
+
+	// Неверный мастер пароль и незнакомый пользователь
+	assert.Error(t, client.Unlock(ctx, identity.AuthData{Login: testLogin, Password: "wrong password"}))
+	assert.Error(t, client.Unlock(ctx, identity.AuthData{Login: "unknown login", Password: testPassword}))
+
+	// Разблокировка сессии
+	require.NoError(t, client.Unlock(ctx, identity.AuthData{Login: testLogin, Password: testPassword}))
+	status, err = client.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Status{Locked: false, Login: testLogin}, status)
+
+	userData, err := client.Data(ctx)
+	require.NoError(t, err)
+	require.Len(t, userData, 1)
+	require.Len(t, userData[0], 1)
+	assert.Equal(t, "note", userData[0][0].Name)
+	assert.JSONEq(t, `{"text":"secret text"}`, string(userData[0][0].Data))
+
+	assert.NoError(t, client.Sync(ctx))
+
+	// Запрос с неверным токеном отклоняется
+	wrongClient, err := NewClient(socket)
+	require.NoError(t, err)
+	wrongClient.client.SetAuthToken("wrong token")
+	_, err = wrongClient.Status(ctx)
+	assert.Error(t, err)
+
+	// Второй агент на том же сокете не запускается
+	err = New(addr, stor, stor, resty.New(), time.Hour).Serve(ctx, socket, time.Hour)
+	assert.Error(t, err)
+
+	// Блокировка сессии
+	require.NoError(t, client.Lock(ctx))
+	_, err = client.Data(ctx)
+	assert.ErrorIs(t, err, ErrLocked)
+
+	// После остановки агента сокет и токен удаляются
+	require.NoError(t, stop())
+	_, err = os.Stat(socket)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(TokenPath(socket))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestAutoLock(t *testing.T) {
+	ctx := context.Background()
+	addr, stor, socket := setup(t)
+
+	a := New(addr, stor, stor, resty.New(), 200*time.Millisecond)
+	stop := start(t, a, socket)
+	defer func() { assert.NoError(t, stop()) }()
+
+	client, err := NewClient(socket)
+	require.NoError(t, err)
+	require.NoError(t, client.Unlock(ctx, identity.AuthData{Login: testLogin, Password: testPassword}))
+	assert.False(t, a.locked())
+
+	// Без обращений к данным сессия блокируется после периода бездействия, опрос состояния ее не продлевает
+	assert.Eventually(t, func() bool {
+		status, err := client.Status(ctx)
+		return err == nil && status.Locked
+	}, 5*time.Second, 20*time.Millisecond)
+
+	_, err = client.Data(ctx)
+	assert.ErrorIs(t, err, ErrLocked)
+}
+
+func TestStaleSocket(t *testing.T) {
+	addr, stor, socket := setup(t)
+
+	// Файл сокета, оставшийся после аварийного завершения агента, удаляется при запуске
+	require.NoError(t, os.WriteFile(socket, nil, 0600))
+	stop := start(t, New(addr, stor, stor, resty.New(), time.Hour), socket)
+	assert.NoError(t, stop())
+}
@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/identity"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Ошибки клиента агента.
+var (
+	ErrNotRunning = errors.New("agent is not running")
+	ErrLocked     = errors.New("agent is locked")
+)
+
+// baseURL - адрес, используемый в запросах к агенту. Запросы передаются через Unix-сокет, поэтому хост не используется.
+const baseURL = "http://agent"
+
+// Client - клиент API агента.
+type Client struct {
+	client *resty.Client
+}
+
+// NewClient - фабричная функция клиента агента. Токен агента читается из файла рядом с сокетом.
+// Если агент не запущен, возвращается ошибка ErrNotRunning.
+func NewClient(socket string) (*Client, error) {
+	token, err := os.ReadFile(TokenPath(socket))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotRunning
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agent token, %w", err)
+	}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}
+	client := resty.New().
+		SetTransport(transport).
+		SetBaseURL(baseURL).
+		SetAuthToken(strings.TrimSpace(string(token)))
+	return &Client{client: client}, nil
+}
+
+// Status - возвращает состояние сессии агента.
+func (c *Client) Status(ctx context.Context) (Status, error) {
+	var status Status
+	resp, err := c.client.R().SetContext(ctx).SetResult(&status).Get(StatusPath)
+	if err := checkResponse(resp, err); err != nil {
+		return Status{}, err
+	}
+	return status, nil
+}
+
+// Unlock - разблокирует сессию агента мастер паролем пользователя.
+func (c *Client) Unlock(ctx context.Context, authData identity.AuthData) error {
+	resp, err := c.client.R().SetContext(ctx).SetBody(authData).Post(UnlockPath)
+	return checkResponse(resp, err)
+}
+
+// Lock - блокирует сессию агента.
+func (c *Client) Lock(ctx context.Context) error {
+	resp, err := c.client.R().SetContext(ctx).Post(LockPath)
+	return checkResponse(resp, err)
+}
+
+// Data - возвращает все расшифрованные данные пользователя. Если сессия заблокирована, возвращается ошибка ErrLocked.
+func (c *Client) Data(ctx context.Context) ([][]data.Data, error) {
+	var userData [][]data.Data
+	resp, err := c.client.R().SetContext(ctx).SetResult(&userData).Get(DataPath)
+	if err := checkResponse(resp, err); err != nil {
+		return nil, err
+	}
+	return userData, nil
+}
+
+// Sync - выполняет синхронизацию данных пользователя с сервером силами агента.
+func (c *Client) Sync(ctx context.Context) error {
+	resp, err := c.client.R().SetContext(ctx).Post(SyncPath)
+	return checkResponse(resp, err)
+}
+
+// checkResponse - функция для проверки ответа агента.
+func checkResponse(resp *resty.Response, err error) error {
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) {
+			return fmt.Errorf("%w, %v", ErrNotRunning, err)
+		}
+		return fmt.Errorf("agent request error, %w", err)
+	}
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		return nil
+	case http.StatusLocked:
+		return ErrLocked
+	default:
+		return fmt.Errorf("agent error, status %d, %s", resp.StatusCode(), strings.TrimSpace(resp.String()))
+	}
+}
@@ -0,0 +1,244 @@
+// Пакет apiclient содержит клиент REST API сервера, написанный по спецификации OpenAPI версии api.Prefix.
+// Методы клиента возвращают ошибку *api.Error, если сервер ответил ошибкой, что позволяет принимать решения
+// по стабильному коду ошибки с помощью api.IsCode.
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/header"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/account"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/identity"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Client - клиент REST API сервера.
+type Client struct {
+	client *resty.Client
+	addr   string
+
+	mu         sync.RWMutex
+	token      string
+	adminToken string
+}
+
+// New - конструктор клиента REST API сервера с адресом addr, например "http://localhost:8080".
+// Если client уже устанавливает токен пользователя в запросы, токен клиента можно не задавать.
+func New(client *resty.Client, addr string) *Client {
+	return &Client{client: client, addr: addr}
+}
+
+// SetToken - метод для установки JWT, который передается в запросах к серверу.
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// Token - метод для получения JWT, полученного при последней регистрации или авторизации.
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// SetAdminToken - метод для установки токена администратора сервера, который передается в запросах к адресам
+// администратора вместо JWT пользователя.
+func (c *Client) SetAdminToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.adminToken = token
+}
+
+// Register - метод для регистрации пользователя. В случае успеха возвращается JWT пользователя, который также
+// устанавливается в клиент.
+func (c *Client) Register(ctx context.Context, login, hash string) (string, error) {
+	return c.identify(ctx, api.RegisterPattern, login, hash)
+}
+
+// Authorize - метод для авторизации пользователя. В случае успеха возвращается JWT пользователя, который также
+// устанавливается в клиент.
+func (c *Client) Authorize(ctx context.Context, login, hash string) (string, error) {
+	return c.identify(ctx, api.AuthorizationPattern, login, hash)
+}
+
+// AddData - метод для добавления новых зашифрованных данных на сервер.
+func (c *Client) AddData(ctx context.Context, encrData data.EncryptedData) error {
+	_, err := c.do(c.request(ctx).SetBody(encrData), http.MethodPost, api.AddDataPattern)
+	return err
+}
+
+// ReplaceData - метод для замены всех версий данных на сервере новыми данными.
+func (c *Client) ReplaceData(ctx context.Context, encrData data.EncryptedData) error {
+	_, err := c.do(c.request(ctx).SetBody(encrData), http.MethodPost, api.ReplaceDataPattern)
+	return err
+}
+
+// AppendConflictData - метод для добавления конфликтующей версии к существующим данным на сервере.
+func (c *Client) AppendConflictData(ctx context.Context, encrData data.EncryptedData) error {
+	_, err := c.do(c.request(ctx).SetBody(encrData), http.MethodPost, api.ConflictDataPattern)
+	return err
+}
+
+// DeleteData - метод для удаления данных с именем name со всеми версиями.
+func (c *Client) DeleteData(ctx context.Context, name string) error {
+	_, err := c.do(c.request(ctx).SetBody(data.MetaInfo{Name: name}), http.MethodDelete, api.DeleteDataPattern)
+	return err
+}
+
+// GetAllData - метод для получения всех данных пользователя. Каждый элемент результата содержит версии одной записи.
+func (c *Client) GetAllData(ctx context.Context) ([][]data.EncryptedData, error) {
+	var res [][]data.EncryptedData
+	if _, err := c.do(c.request(ctx).SetResult(&res), http.MethodGet, api.GetDataPattern); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetUsage - метод для получения информации об использовании хранилища пользователем и его квотах.
+func (c *Client) GetUsage(ctx context.Context) (data.Usage, error) {
+	var usage data.Usage
+	if _, err := c.do(c.request(ctx).SetResult(&usage), http.MethodGet, api.UsagePattern); err != nil {
+		return data.Usage{}, err
+	}
+	return usage, nil
+}
+
+// GetAuditEvents - метод для получения журнала аудита пользователя от новых событий к старым. Нулевое время since
+// и until не ограничивает выборку, при нулевом limit сервер возвращает количество событий по умолчанию.
+func (c *Client) GetAuditEvents(ctx context.Context, since, until time.Time, limit int) ([]audit.Event, error) {
+	req := c.request(ctx)
+	if !since.IsZero() {
+		req.SetQueryParam("since", since.Format(time.RFC3339))
+	}
+	if !until.IsZero() {
+		req.SetQueryParam("until", until.Format(time.RFC3339))
+	}
+	if limit > 0 {
+		req.SetQueryParam("limit", strconv.Itoa(limit))
+	}
+	var events []audit.Event
+	if _, err := c.do(req.SetResult(&events), http.MethodGet, api.AuditPattern); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// DeleteAccount - метод для удаления учетной записи пользователя со всеми данными. Для повторной аутентификации
+// передаются логин и хэш от суммы логин+пароль владельца токена. После удаления токен клиента сбрасывается.
+func (c *Client) DeleteAccount(ctx context.Context, login, hash string) error {
+	body := identity.Data{Login: login, Hash: hash}
+	if _, err := c.do(c.request(ctx).SetBody(body), http.MethodDelete, api.AccountPattern); err != nil {
+		return err
+	}
+	c.SetToken("")
+	return nil
+}
+
+// ListUsers - метод для получения администратором учетных записей пользователей, упорядоченных по логину.
+func (c *Client) ListUsers(ctx context.Context) ([]account.User, error) {
+	var users []account.User
+	if _, err := c.do(c.adminRequest(ctx).SetResult(&users), http.MethodGet, api.AdminUsersPattern); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetStats - метод для получения администратором статистики сервера.
+func (c *Client) GetStats(ctx context.Context) (account.Stats, error) {
+	var stats account.Stats
+	if _, err := c.do(c.adminRequest(ctx).SetResult(&stats), http.MethodGet, api.AdminStatsPattern); err != nil {
+		return account.Stats{}, err
+	}
+	return stats, nil
+}
+
+// DisableUser - метод для блокировки администратором учетной записи пользователя с идентификатором id.
+func (c *Client) DisableUser(ctx context.Context, id string) error {
+	_, err := c.do(c.adminRequest(ctx), http.MethodPost, userPattern(id)+"/disable")
+	return err
+}
+
+// EnableUser - метод для разблокировки администратором учетной записи пользователя с идентификатором id.
+func (c *Client) EnableUser(ctx context.Context, id string) error {
+	_, err := c.do(c.adminRequest(ctx), http.MethodPost, userPattern(id)+"/enable")
+	return err
+}
+
+// LogoutUser - метод для отзыва администратором всех токенов пользователя с идентификатором id.
+func (c *Client) LogoutUser(ctx context.Context, id string) error {
+	_, err := c.do(c.adminRequest(ctx), http.MethodPost, userPattern(id)+"/logout")
+	return err
+}
+
+// DeleteUser - метод для удаления администратором учетной записи пользователя с идентификатором id вместе
+// со всеми данными пользователя.
+func (c *Client) DeleteUser(ctx context.Context, id string) error {
+	_, err := c.do(c.adminRequest(ctx), http.MethodDelete, userPattern(id))
+	return err
+}
+
+// identify - метод для регистрации или авторизации пользователя по адресу pattern.
+func (c *Client) identify(ctx context.Context, pattern, login, hash string) (string, error) {
+	req := c.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(identity.Data{Login: login, Hash: hash})
+	resp, err := c.do(req, http.MethodPost, pattern)
+	if err != nil {
+		return "", err
+	}
+	token, err := header.GetTokenFromRestyResponseHeader(resp)
+	if err != nil {
+		return "", fmt.Errorf("failed to get token from server response, %w", err)
+	}
+	c.SetToken(token)
+	return token, nil
+}
+
+// request - метод для создания запроса с токеном пользователя.
+func (c *Client) request(ctx context.Context) *resty.Request {
+	req := c.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json")
+	if token := c.Token(); token != "" {
+		req.SetHeader("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// adminRequest - метод для создания запроса с токеном администратора сервера.
+func (c *Client) adminRequest(ctx context.Context) *resty.Request {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.client.R().
+		SetContext(ctx).
+		SetHeader(api.AdminTokenHeader, c.adminToken)
+}
+
+// userPattern - функция для получения адреса учетной записи пользователя с идентификатором id.
+func userPattern(id string) string {
+	return api.AdminUsersPattern + "/" + url.PathEscape(id)
+}
+
+// do - метод для отправки запроса req. Если сервер ответил статусом, отличным от 200 и 204, возвращается *api.Error.
+func (c *Client) do(req *resty.Request, method, pattern string) (*resty.Response, error) {
+	resp, err := req.Execute(method, c.addr+pattern)
+	if err != nil {
+		return nil, fmt.Errorf("send request %s %s error, %w", method, pattern, err)
+	}
+	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusNoContent {
+		return nil, api.ParseError(resp.StatusCode(), resp.Body())
+	}
+	return resp, nil
+}
@@ -0,0 +1,146 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/token"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/account"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/admin"
+	"github.com/abezemskiy/gophkeeper/internal/testutil"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	srv := testutil.NewServer(t)
+
+	ctx := context.Background()
+	c := New(resty.New(), srv.URL)
+
+	// Запрос без токена
+	_, err := c.GetAllData(ctx)
+	require.Error(t, err)
+	assert.True(t, api.IsCode(err, api.CodeUnauthorized))
+
+	// Авторизация незарегистрированного пользователя
+	_, err = c.Authorize(ctx, "login", "hash")
+	assert.True(t, api.IsCode(err, api.CodeInvalidCredentials))
+
+	jwt, err := c.Register(ctx, "login", "hash")
+	require.NoError(t, err)
+	assert.Equal(t, jwt, c.Token())
+
+	_, err = c.Register(ctx, "login", "hash")
+	var apiErr *api.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.Status)
+	assert.Equal(t, api.CodeLoginExists, apiErr.Code)
+
+	_, err = c.Authorize(ctx, "login", "hash")
+	require.NoError(t, err)
+
+	first := data.EncryptedData{EncryptedData: []byte("first"), Name: "name"}
+	second := data.EncryptedData{EncryptedData: []byte("second"), Name: "name"}
+	require.NoError(t, c.AddData(ctx, first))
+	assert.True(t, api.IsCode(c.AddData(ctx, first), api.CodeDataExists))
+	require.NoError(t, c.AppendConflictData(ctx, second))
+
+	all, err := c.GetAllData(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Len(t, all[0], 2)
+
+	require.NoError(t, c.ReplaceData(ctx, first))
+	all, err = c.GetAllData(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, []data.EncryptedData{first}, all[0])
+
+	usage, err := c.GetUsage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, usage.Records)
+
+	// Журнал аудита возвращается от новых событий к старым, первое событие пользователя - регистрация
+	events, err := c.GetAuditEvents(ctx, time.Time{}, time.Time{}, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	assert.Equal(t, audit.Register, events[len(events)-1].Type)
+	events, err = c.GetAuditEvents(ctx, time.Time{}, time.Time{}, 1)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	require.NoError(t, c.DeleteData(ctx, "name"))
+	assert.True(t, api.IsCode(c.DeleteData(ctx, "name"), api.CodeDataNotFound))
+	assert.True(t, api.IsCode(c.ReplaceData(ctx, first), api.CodeDataNotFound))
+
+	// Удаление учетной записи требует повторной аутентификации
+	jwt = c.Token()
+	assert.True(t, api.IsCode(c.DeleteAccount(ctx, "login", "wrong"), api.CodeInvalidCredentials))
+	require.NoError(t, c.DeleteAccount(ctx, "login", "hash"))
+	assert.Empty(t, c.Token())
+	c.SetToken(jwt)
+	_, err = c.GetUsage(ctx)
+	assert.True(t, api.IsCode(err, api.CodeUnauthorized))
+	_, err = c.Authorize(ctx, "login", "hash")
+	assert.True(t, api.IsCode(err, api.CodeInvalidCredentials))
+
+	// Недействительный токен
+	c.SetToken("invalid")
+	_, err = c.GetUsage(ctx)
+	assert.True(t, api.IsCode(err, api.CodeUnauthorized))
+}
+
+func TestAdmin(t *testing.T) {
+	defer admin.SetToken("")
+	admin.SetToken("admin secret token")
+	srv := testutil.NewServer(t)
+
+	ctx := context.Background()
+	c := New(resty.New(), srv.URL)
+	jwt, err := c.Register(ctx, "login", "hash")
+	require.NoError(t, err)
+	id, err := token.GetIDFromToken(jwt)
+	require.NoError(t, err)
+
+	// Токен пользователя не заменяет токен администратора
+	_, err = c.ListUsers(ctx)
+	assert.True(t, api.IsCode(err, api.CodeUnauthorized))
+
+	c.SetAdminToken("admin secret token")
+	users, err := c.ListUsers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []account.User{{ID: id, Login: "login"}}, users)
+
+	require.NoError(t, c.DisableUser(ctx, id))
+	_, err = c.GetAllData(ctx)
+	assert.True(t, api.IsCode(err, api.CodeAccountDisabled))
+	stats, err := c.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.DisabledUsers)
+	require.NoError(t, c.EnableUser(ctx, id))
+	require.NoError(t, c.LogoutUser(ctx, id))
+	require.NoError(t, c.DeleteUser(ctx, id))
+
+	assert.True(t, api.IsCode(c.DeleteUser(ctx, id), api.CodeUserNotFound))
+	assert.True(t, api.IsCode(c.DisableUser(ctx, "../../data/get"), api.CodeUserNotFound))
+}
+
+func TestClientTransportError(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	_, err := New(resty.New(), ts.URL).Register(context.Background(), "login", "hash")
+	require.Error(t, err)
+	var apiErr *api.Error
+	assert.False(t, errors.As(err, &apiErr))
+}
@@ -0,0 +1,527 @@
+// Пакет attachment реализует передачу больших файлов на сервер и с сервера зашифрованными частями.
+// Части шифруются детерминированно конвергентным ключом пользователя и адресуются хэшем зашифрованного содержимого,
+// поэтому одинаковые части файлов пользователя передаются и хранятся на сервере один раз. Для передачи файла
+// не требуется держать его в памяти целиком. Прерванная передача возобновляется с недостающей части.
+package attachment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/encr"
+	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/encryption"
+	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/key"
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	clientData "github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	repoStorage "github.com/abezemskiy/gophkeeper/internal/repositories/storage"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+// Параметры вложения.
+const (
+	keySize = 32 // размер конвергентного ключа для AES256
+
+	convergentPurpose = "gophkeeper attachment convergent key" // назначение конвергентного ключа
+	encryptionPurpose = "chunk encryption"                     // назначение ключа шифрования частей
+	noncePurpose      = "chunk nonce"                          // назначение ключа для вычисления nonce частей
+	idPurpose         = "attachment id"                        // назначение ключа для вычисления идентификатора вложения
+	recordIDSize      = 16                                     // размер случайного идентификатора ссылки записи в байтах
+)
+
+// Ошибки передачи вложений.
+var (
+	ErrFileChanged    = errors.New("file changed after attachment was created")
+	ErrHashMismatch   = errors.New("hash of downloaded file does not match attachment")
+	ErrChunkNotFound  = errors.New("chunk not found on server")
+	ErrChunkCorrupted = errors.New("hash of downloaded chunk does not match attachment")
+	ErrBadAttachment  = errors.New("attachment manifest is not valid")
+	ErrUnexpectedResp = errors.New("unexpected server response")
+)
+
+// Progress - функция для отображения прогресса передачи вложения. done - количество переданных частей, total - общее количество частей.
+type Progress func(done, total int)
+
+// URLs - адреса ресурсов сервера для передачи вложений.
+type URLs struct {
+	Attachment string // адрес ресурса ссылок на вложения
+	Chunk      string // адрес ресурса частей вложений
+}
+
+// NewURLs - функция для формирования адресов ресурсов вложений по адресу сервера.
+func NewURLs(addr string) URLs {
+	return URLs{
+		Attachment: addr + api.AttachmentPattern,
+		Chunk:      addr + api.ChunkPattern,
+	}
+}
+
+// ConvergentKey - функция для получения конвергентного ключа пользователя из мастер пароля.
+// Ключ одинаков на всех устройствах пользователя и не зависит от содержимого файла.
+func ConvergentKey(masterPass string) []byte {
+	return key.DeriveSubKey(key.DeriveKey(masterPass, keySize), convergentPurpose)
+}
+
+// New - функция для создания манифеста вложения из файла path.
+func New(path, masterPass string) (*clientData.Attachment, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file, %w", err)
+	}
+	defer file.Close()
+	return NewFromReader(file, masterPass)
+}
+
+// NewFromReader - функция для создания манифеста вложения из содержимого r.
+func NewFromReader(r io.Reader, masterPass string) (*clientData.Attachment, error) {
+	return scan(r, masterPass, nil)
+}
+
+// scan - функция для создания манифеста вложения из содержимого r. Содержимое читается потоково по частям:
+// вычисляются размер и хэш файла и хэши зашифрованных частей. Идентификатор вложения вычисляется из хэша файла,
+// поэтому одинаковые файлы пользователя получают одинаковый идентификатор. Идентификатор ссылки записи случаен:
+// каждая запись, созданная из манифеста, владеет своей ссылкой на вложение. Если задана функция chunkFn,
+// она вызывается для каждой зашифрованной части.
+func scan(r io.Reader, masterPass string, chunkFn func(index int, hash string, encrChunk []byte) error) (*clientData.Attachment, error) {
+	convKey := ConvergentKey(masterPass)
+	att := &clientData.Attachment{
+		ChunkSize: data.ChunkSize,
+		Key:       convKey,
+	}
+
+	h := sha256.New()
+	buf := make([]byte, data.ChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("failed to read file, %w", err)
+		}
+		// Пустой файл состоит из одной пустой части
+		if n == 0 && len(att.Chunks) > 0 {
+			break
+		}
+
+		h.Write(buf[:n])
+		att.Size += int64(n)
+		encrChunk, err := encryptChunk(convKey, buf[:n])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt chunk %d, %w", len(att.Chunks), err)
+		}
+		hash := hashOf(encrChunk)
+		if chunkFn != nil {
+			if err := chunkFn(len(att.Chunks), hash, encrChunk); err != nil {
+				return nil, err
+			}
+		}
+		att.Chunks = append(att.Chunks, hash)
+
+		if n < len(buf) {
+			break
+		}
+	}
+	att.Hash = hex.EncodeToString(h.Sum(nil))
+
+	mac := hmac.New(sha256.New, key.DeriveSubKey(convKey, idPurpose))
+	mac.Write([]byte(att.Hash))
+	att.ID = hex.EncodeToString(mac.Sum(nil))
+
+	record := make([]byte, recordIDSize)
+	if _, err := rand.Read(record); err != nil {
+		return nil, fmt.Errorf("failed to generate record id, %w", err)
+	}
+	att.Record = hex.EncodeToString(record)
+	return att, nil
+}
+
+// Upload - функция для загрузки файла path на сервер зашифрованными частями и добавления ссылки на вложение.
+func Upload(ctx context.Context, client *resty.Client, urls URLs, att *clientData.Attachment, path string, progress Progress) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file, %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file info, %w", err)
+	}
+	return UploadFromReader(ctx, client, urls, att, file, stat.Size(), progress)
+}
+
+// UploadFromReader - функция для загрузки на сервер зашифрованными частями содержимого r размером size и добавления
+// ссылки на вложение. Части, которые уже сохранены на сервере, в том числе в составе других файлов пользователя,
+// повторно не передаются.
+func UploadFromReader(ctx context.Context, client *resty.Client, urls URLs, att *clientData.Attachment, r io.ReaderAt,
+	size int64, progress Progress) error {
+	if err := validate(att); err != nil {
+		return err
+	}
+	if size != att.Size {
+		return ErrFileChanged
+	}
+
+	// Получаю хэши частей, которых нет на сервере, для возобновления загрузки и дедупликации
+	missing, err := Missing(ctx, client, urls, att.Chunks)
+	if err != nil {
+		return err
+	}
+
+	total := len(att.Chunks)
+	done := 0
+	buf := make([]byte, att.ChunkSize)
+	for index, hash := range att.Chunks {
+		if !missing[hash] {
+			done++
+			report(progress, done, total)
+			continue
+		}
+
+		n, err := r.ReadAt(buf, int64(index)*int64(att.ChunkSize))
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read chunk %d, %w", index, err)
+		}
+		encrChunk, err := encryptChunk(att.Key, buf[:n])
+		if err != nil {
+			return fmt.Errorf("failed to encrypt chunk %d, %w", index, err)
+		}
+		if hashOf(encrChunk) != hash {
+			return ErrFileChanged
+		}
+
+		if err := pushChunk(ctx, client, urls, index, hash, encrChunk); err != nil {
+			return err
+		}
+		// Одинаковые части файла передаются один раз
+		delete(missing, hash)
+
+		done++
+		report(progress, done, total)
+	}
+	return addRef(ctx, client, urls, att)
+}
+
+// UploadStream - функция для загрузки на сервер содержимого r за один проход, когда содержимое нельзя прочитать
+// повторно, например при чтении из архива. Манифест вложения вычисляется по мере чтения, а каждая зашифрованная
+// часть сразу передается на сервер, поэтому содержимое не сохраняется на диск. Ссылка на вложение добавляется
+// только после успешного чтения всего содержимого, переданные до ошибки части сервер удалит как неиспользуемые.
+func UploadStream(ctx context.Context, client *resty.Client, urls URLs, r io.Reader, masterPass string) (*clientData.Attachment, error) {
+	att, err := scan(r, masterPass, func(index int, hash string, encrChunk []byte) error {
+		return pushChunk(ctx, client, urls, index, hash, encrChunk)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := addRef(ctx, client, urls, att); err != nil {
+		return nil, err
+	}
+	return att, nil
+}
+
+// pushChunk - функция для передачи на сервер зашифрованной части index с хэшем hash.
+func pushChunk(ctx context.Context, client *resty.Client, urls URLs, index int, hash string, encrChunk []byte) error {
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/octet-stream").
+		SetBody(encrChunk).
+		Put(urls.Chunk + "/" + hash)
+	if err != nil {
+		logger.ClientLog.Error("push chunk to server error", zap.String("error", err.Error()))
+		return fmt.Errorf("push chunk %d to server error, %w", index, err)
+	}
+	// Превышена квота хранилища пользователя, сервер сообщает подробности в теле ответа
+	if resp.StatusCode() == http.StatusInsufficientStorage {
+		logger.ClientLog.Error("push chunk to server error", zap.String("error", resp.String()))
+		return fmt.Errorf("push chunk %d to server error, %w", index, api.ParseError(resp.StatusCode(), resp.Body()))
+	}
+	if resp.StatusCode() != http.StatusOK {
+		logger.ClientLog.Error("push chunk to server error", zap.Int("status", resp.StatusCode()))
+		return fmt.Errorf("push chunk %d to server error, status %d, %w", index, resp.StatusCode(), ErrUnexpectedResp)
+	}
+	return nil
+}
+
+// addRef - функция для добавления на сервере ссылки записи на вложение, все части которого уже переданы.
+// Сервер учитывает ссылку одной записи один раз, поэтому повторная передача после сбоя не создает лишних ссылок.
+func addRef(ctx context.Context, client *resty.Client, urls URLs, att *clientData.Attachment) error {
+	if att.Record == "" {
+		return ErrBadAttachment
+	}
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetQueryParam("record", att.Record).
+		SetBody(data.ChunkList{Chunks: att.Chunks}).
+		Post(urls.Attachment + "/" + att.ID)
+	if err != nil {
+		logger.ClientLog.Error("add attachment reference error", zap.String("error", err.Error()))
+		return fmt.Errorf("add attachment reference error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		logger.ClientLog.Error("add attachment reference error", zap.Int("status", resp.StatusCode()))
+		return fmt.Errorf("add attachment reference error, status %d, %w", resp.StatusCode(), ErrUnexpectedResp)
+	}
+
+	logger.ClientLog.Debug("successful upload attachment", zap.String("attachment", att.ID))
+	return nil
+}
+
+// Missing - функция для получения хэшей частей из списка chunks, которые не сохранены на сервере.
+func Missing(ctx context.Context, client *resty.Client, urls URLs, chunks []string) (map[string]bool, error) {
+	var list data.ChunkList
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(data.ChunkList{Chunks: chunks}).
+		SetResult(&list).
+		Post(urls.Chunk + "/missing")
+	if err != nil {
+		logger.ClientLog.Error("get missing chunks from server error", zap.String("error", err.Error()))
+		return nil, fmt.Errorf("get missing chunks from server error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		logger.ClientLog.Error("get missing chunks from server error", zap.Int("status", resp.StatusCode()))
+		return nil, fmt.Errorf("get missing chunks from server error, status %d, %w", resp.StatusCode(), ErrUnexpectedResp)
+	}
+
+	missing := make(map[string]bool, len(list.Chunks))
+	for _, hash := range list.Chunks {
+		missing[hash] = true
+	}
+	return missing, nil
+}
+
+// Download - функция для скачивания вложения с сервера в файл path. Данные записываются во временный файл с суффиксом .part,
+// который переименовывается после проверки хэша. Если временный файл уже существует, скачивание продолжается с первой
+// недостающей части.
+func Download(ctx context.Context, client *resty.Client, urls URLs, att *clientData.Attachment, path string, progress Progress) error {
+	if err := validate(att); err != nil {
+		return err
+	}
+
+	partPath := path + ".part"
+	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open file, %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file info, %w", err)
+	}
+
+	// Продолжаю скачивание с начала первой неполной части
+	total := len(att.Chunks)
+	start := int(stat.Size() / int64(att.ChunkSize))
+	if stat.Size() > att.Size {
+		start = 0
+	}
+	if err := file.Truncate(int64(start) * int64(att.ChunkSize)); err != nil {
+		return fmt.Errorf("failed to truncate file, %w", err)
+	}
+	report(progress, start, total)
+
+	encrKey := key.DeriveSubKey(att.Key, encryptionPurpose)
+	for index := start; index < total; index++ {
+		chunk, err := getChunk(ctx, client, urls, encrKey, index, att.Chunks[index])
+		if err != nil {
+			return err
+		}
+		if _, err := file.WriteAt(chunk, int64(index)*int64(att.ChunkSize)); err != nil {
+			return fmt.Errorf("failed to write chunk %d, %w", index, err)
+		}
+		report(progress, index+1, total)
+	}
+
+	// Проверяю целостность скачанного файла
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek file, %w", err)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return fmt.Errorf("failed to read file, %w", err)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != att.Hash {
+		// Поврежденный файл удаляю, чтобы следующая попытка скачивания началась заново
+		_ = os.Remove(partPath)
+		return ErrHashMismatch
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close file, %w", err)
+	}
+	if err := os.Rename(partPath, path); err != nil {
+		return fmt.Errorf("failed to rename file, %w", err)
+	}
+
+	logger.ClientLog.Debug("successful download attachment", zap.String("attachment", att.ID))
+	return nil
+}
+
+// DownloadTo - функция для потоковой записи расшифрованного содержимого вложения в w без сохранения на диск.
+// Хэш содержимого проверяется после записи последней части, поэтому при ошибке вызывающий код должен отбросить
+// уже записанные данные.
+func DownloadTo(ctx context.Context, client *resty.Client, urls URLs, att *clientData.Attachment, w io.Writer, progress Progress) error {
+	if err := validate(att); err != nil {
+		return err
+	}
+
+	encrKey := key.DeriveSubKey(att.Key, encryptionPurpose)
+	h := sha256.New()
+	total := len(att.Chunks)
+	for index, hash := range att.Chunks {
+		chunk, err := getChunk(ctx, client, urls, encrKey, index, hash)
+		if err != nil {
+			return err
+		}
+		h.Write(chunk)
+		if _, err := w.Write(chunk); err != nil {
+			return fmt.Errorf("failed to write chunk %d, %w", index, err)
+		}
+		report(progress, index+1, total)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != att.Hash {
+		return ErrHashMismatch
+	}
+
+	logger.ClientLog.Debug("successful download attachment", zap.String("attachment", att.ID))
+	return nil
+}
+
+// getChunk - функция для скачивания с сервера части index с хэшем hash и ее расшифровывания ключом encrKey.
+func getChunk(ctx context.Context, client *resty.Client, urls URLs, encrKey []byte, index int, hash string) ([]byte, error) {
+	resp, err := client.R().
+		SetContext(ctx).
+		Get(urls.Chunk + "/" + hash)
+	if err != nil {
+		logger.ClientLog.Error("get chunk from server error", zap.String("error", err.Error()))
+		return nil, fmt.Errorf("get chunk %d from server error, %w", index, err)
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return nil, fmt.Errorf("get chunk %d from server error, %w", index, ErrChunkNotFound)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		logger.ClientLog.Error("get chunk from server error", zap.Int("status", resp.StatusCode()))
+		return nil, fmt.Errorf("get chunk %d from server error, status %d, %w", index, resp.StatusCode(), ErrUnexpectedResp)
+	}
+	if hashOf(resp.Body()) != hash {
+		return nil, fmt.Errorf("chunk %d, %w", index, ErrChunkCorrupted)
+	}
+
+	chunk, err := encryption.DecryptAES256(encrKey, resp.Body())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt chunk %d, %w", index, err)
+	}
+	return chunk, nil
+}
+
+// Release - функция для удаления ссылки записи на вложение att на сервере. Сервер удаляет вложение и части,
+// на которые больше нет ссылок. Отсутствие вложения на сервере не считается ошибкой.
+func Release(ctx context.Context, client *resty.Client, urls URLs, att *clientData.Attachment) error {
+	resp, err := client.R().
+		SetContext(ctx).
+		SetQueryParam("record", att.Record).
+		Delete(urls.Attachment + "/" + att.ID)
+	if err != nil {
+		logger.ClientLog.Error("release attachment on server error", zap.String("error", err.Error()))
+		return fmt.Errorf("release attachment on server error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusNotFound {
+		logger.ClientLog.Error("release attachment on server error", zap.Int("status", resp.StatusCode()))
+		return fmt.Errorf("release attachment on server error, status %d, %w", resp.StatusCode(), ErrUnexpectedResp)
+	}
+	return nil
+}
+
+// Manifests - функция для получения манифестов вложений всех версий данных пользователя с именем dataName.
+// Каждая версия данных владеет своей ссылкой на вложение, поэтому идентификаторы вложений могут повторяться.
+func Manifests(ctx context.Context, stor repoStorage.EncryptedDataReader, userID, masterPass, dataName string) ([]*clientData.Attachment, error) {
+	encrData, err := stor.GetAllEncryptedData(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get encrypted data from storage, %w", err)
+	}
+
+	var atts []*clientData.Attachment
+	for _, versions := range encrData {
+		for _, v := range versions {
+			if v.Name != dataName {
+				continue
+			}
+			userData, err := encr.DecryptData(ctx, masterPass, &v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt data, %w", err)
+			}
+			if att, ok := FromData(userData); ok {
+				atts = append(atts, att)
+			}
+		}
+	}
+	return atts, nil
+}
+
+// FromData - функция для получения манифеста вложения из расшифрованных данных пользователя.
+// Возвращает false, если данные не являются бинарными или файл хранится непосредственно в данных.
+func FromData(userData *data.Data) (*clientData.Attachment, bool) {
+	if userData.Type != data.BINARY {
+		return nil, false
+	}
+	var binary clientData.Binary
+	if err := json.Unmarshal(userData.Data, &binary); err != nil || binary.Attachment == nil {
+		return nil, false
+	}
+	return binary.Attachment, true
+}
+
+// encryptChunk - функция для детерминированного шифрования части файла конвергентным ключом.
+// Nonce вычисляется из содержимого части, поэтому одинаковые части дают одинаковый шифротекст.
+func encryptChunk(convKey, chunk []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, key.DeriveSubKey(convKey, noncePurpose))
+	mac.Write(chunk)
+	return encryption.EncryptAES256WithNonce(key.DeriveSubKey(convKey, encryptionPurpose), mac.Sum(nil), chunk)
+}
+
+// hashOf - функция для вычисления SHA-256 хэша в шестнадцатеричном виде.
+func hashOf(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// chunksCount - функция для вычисления количества частей файла. Пустой файл состоит из одной пустой части.
+func chunksCount(size int64, chunkSize int) int {
+	if size == 0 {
+		return 1
+	}
+	return int((size + int64(chunkSize) - 1) / int64(chunkSize))
+}
+
+// validate - функция для проверки корректности манифеста вложения.
+func validate(att *clientData.Attachment) error {
+	if att == nil || att.ID == "" || att.ChunkSize <= 0 || att.ChunkSize > data.ChunkSize || att.Size < 0 ||
+		len(att.Chunks) != chunksCount(att.Size, att.ChunkSize) || len(att.Key) != keySize {
+		return ErrBadAttachment
+	}
+	for _, hash := range att.Chunks {
+		if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size {
+			return ErrBadAttachment
+		}
+	}
+	return nil
+}
+
+// report - функция для вызова функции отображения прогресса, если она задана.
+func report(progress Progress, done, total int) {
+	if progress != nil {
+		progress(done, total)
+	}
+}
@@ -0,0 +1,354 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/encr"
+	clientData "github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	clientMemory "github.com/abezemskiy/gophkeeper/internal/client/storage/memory"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage/memory"
+	"github.com/abezemskiy/gophkeeper/internal/testutil"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const masterPass = "master password"
+
+// limitedTransport - транспорт, который перестает передавать запросы на сохранение частей после limit успешных запросов.
+type limitedTransport struct {
+	limit int32
+	count atomic.Int32
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPut && t.count.Add(1) > t.limit {
+		return nil, errors.New("connection lost")
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// setup - запускает сервер и возвращает адреса ресурсов вложений, хранилище сервера и клиент с токеном пользователя.
+func setup(t *testing.T) (URLs, *memory.Store, *resty.Client) {
+	t.Helper()
+	srv := testutil.NewServer(t)
+	return NewURLs(srv.URL), srv.Store, srv.NewClient(t, "user", "user id")
+}
+
+// writeFile - создает во временной директории файл заданного размера.
+func writeFile(t *testing.T, size int) (string, []byte) {
+	t.Helper()
+	content := make([]byte, size)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	path := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	return path, content
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		chunks int
+	}{
+		{name: "empty file", size: 0, chunks: 1},
+		{name: "small file", size: 10, chunks: 1},
+		{name: "exactly one chunk", size: data.ChunkSize, chunks: 1},
+		{name: "several chunks", size: 2*data.ChunkSize + 1, chunks: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, _ := writeFile(t, tt.size)
+			att, err := New(path, masterPass)
+			require.NoError(t, err)
+			assert.Equal(t, int64(tt.size), att.Size)
+			assert.Len(t, att.Chunks, tt.chunks)
+			assert.Equal(t, data.ChunkSize, att.ChunkSize)
+			assert.Len(t, att.Key, keySize)
+			assert.NotEmpty(t, att.ID)
+			assert.NoError(t, validate(att))
+		})
+	}
+
+	_, err := New(filepath.Join(t.TempDir(), "missing"), masterPass)
+	assert.Error(t, err)
+}
+
+func TestUploadAndDownload(t *testing.T) {
+	ctx := context.Background()
+	urls, stor, client := setup(t)
+
+	for _, size := range []int{0, 100, data.ChunkSize, 2*data.ChunkSize + 17} {
+		path, content := writeFile(t, size)
+		att, err := New(path, masterPass)
+		require.NoError(t, err)
+
+		var uploaded int
+		require.NoError(t, Upload(ctx, client, urls, att, path, func(done, total int) {
+			uploaded = done
+			assert.Equal(t, len(att.Chunks), total)
+		}))
+		assert.Equal(t, len(att.Chunks), uploaded)
+
+		// Сервер хранит только зашифрованные части
+		chunk, ok, err := stor.GetChunk(ctx, "user id", att.Chunks[0])
+		require.NoError(t, err)
+		require.True(t, ok)
+		if size > 0 {
+			assert.False(t, bytes.Contains(chunk, content[:min(size, 64)]))
+		}
+
+		out := filepath.Join(t.TempDir(), "out")
+		require.NoError(t, Download(ctx, client, urls, att, out, nil))
+		got, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Equal(t, content, got)
+		_, err = os.Stat(out + ".part")
+		assert.True(t, os.IsNotExist(err))
+	}
+}
+
+func TestUploadFromReader(t *testing.T) {
+	ctx := context.Background()
+	urls, _, client := setup(t)
+
+	// Манифест содержимого в памяти совпадает с манифестом такого же файла, кроме ссылки записи
+	path, content := writeFile(t, 2*data.ChunkSize+17)
+	fromFile, err := New(path, masterPass)
+	require.NoError(t, err)
+	att, err := NewFromReader(bytes.NewReader(content), masterPass)
+	require.NoError(t, err)
+	assert.NotEqual(t, fromFile.Record, att.Record)
+	fromFile.Record = att.Record
+	assert.Equal(t, fromFile, att)
+
+	assert.ErrorIs(t, UploadFromReader(ctx, client, urls, att, bytes.NewReader(content), int64(len(content))-1, nil),
+		ErrFileChanged)
+	require.NoError(t, UploadFromReader(ctx, client, urls, att, bytes.NewReader(content), int64(len(content)), nil))
+
+	out := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, Download(ctx, client, urls, att, out, nil))
+	got, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+}
+
+func TestResumeUpload(t *testing.T) {
+	ctx := context.Background()
+	urls, stor, client := setup(t)
+
+	path, content := writeFile(t, 3*data.ChunkSize)
+	att, err := New(path, masterPass)
+	require.NoError(t, err)
+
+	// Соединение обрывается после загрузки первой части
+	transport := &limitedTransport{limit: 1}
+	require.Error(t, Upload(ctx, client.Clone().SetTransport(transport), urls, att, path, nil))
+	missing, err := stor.GetMissingChunks(ctx, "user id", att.Chunks)
+	require.NoError(t, err)
+	assert.Equal(t, att.Chunks[1:], missing)
+	_, ok, err := stor.GetAttachment(ctx, "user id", att.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Повторная загрузка передает только недостающие части
+	transport = &limitedTransport{limit: 2}
+	require.NoError(t, Upload(ctx, client.Clone().SetTransport(transport), urls, att, path, nil))
+	assert.Equal(t, int32(2), transport.count.Load())
+
+	out := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, Download(ctx, client, urls, att, out, nil))
+	got, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// Файл изменился после создания манифеста
+	require.NoError(t, os.WriteFile(path, []byte("changed"), 0o600))
+	assert.ErrorIs(t, Upload(ctx, client, urls, att, path, nil), ErrFileChanged)
+}
+
+func TestResumeDownload(t *testing.T) {
+	ctx := context.Background()
+	urls, _, client := setup(t)
+
+	path, content := writeFile(t, 2*data.ChunkSize+5)
+	att, err := New(path, masterPass)
+	require.NoError(t, err)
+	require.NoError(t, Upload(ctx, client, urls, att, path, nil))
+
+	// Предыдущая попытка скачивания прервалась в середине второй части
+	out := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, os.WriteFile(out+".part", content[:data.ChunkSize+10], 0o600))
+
+	var first int
+	require.NoError(t, Download(ctx, client, urls, att, out, func(done, _ int) {
+		if first == 0 {
+			first = done
+		}
+	}))
+	assert.Equal(t, 1, first)
+	got, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// Поврежденные данные в начале временного файла обнаруживаются проверкой хэша
+	corrupted := bytes.Clone(content[:data.ChunkSize])
+	corrupted[0] ^= 0xff
+	require.NoError(t, os.WriteFile(out+".part", corrupted, 0o600))
+	assert.ErrorIs(t, Download(ctx, client, urls, att, out, nil), ErrHashMismatch)
+	_, err = os.Stat(out + ".part")
+	assert.True(t, os.IsNotExist(err))
+
+	// Следующая попытка скачивает файл заново
+	require.NoError(t, Download(ctx, client, urls, att, out, nil))
+}
+
+func TestDeduplication(t *testing.T) {
+	ctx := context.Background()
+	urls, stor, client := setup(t)
+
+	path, content := writeFile(t, 2*data.ChunkSize+1)
+	att, err := New(path, masterPass)
+	require.NoError(t, err)
+	require.NoError(t, Upload(ctx, client, urls, att, path, nil))
+
+	// Тот же файл под другим именем не передает ни одной части, но добавляет ссылку на вложение
+	copyPath := filepath.Join(t.TempDir(), "copy")
+	require.NoError(t, os.WriteFile(copyPath, content, 0o600))
+	copyAtt, err := New(copyPath, masterPass)
+	require.NoError(t, err)
+	assert.Equal(t, att.ID, copyAtt.ID)
+
+	transport := &limitedTransport{limit: 0}
+	require.NoError(t, Upload(ctx, client.Clone().SetTransport(transport), urls, copyAtt, copyPath, nil))
+	assert.Equal(t, int32(0), transport.count.Load())
+	info, ok, err := stor.GetAttachment(ctx, "user id", att.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, 2, info.Refs)
+
+	// Файл с общими частями передает только отличающиеся части
+	changed := bytes.Clone(content)
+	changed[len(changed)-1] ^= 0xff
+	changedPath := filepath.Join(t.TempDir(), "changed")
+	require.NoError(t, os.WriteFile(changedPath, changed, 0o600))
+	changedAtt, err := New(changedPath, masterPass)
+	require.NoError(t, err)
+	assert.NotEqual(t, att.ID, changedAtt.ID)
+
+	transport = &limitedTransport{limit: 1}
+	require.NoError(t, Upload(ctx, client.Clone().SetTransport(transport), urls, changedAtt, changedPath, nil))
+	assert.Equal(t, int32(1), transport.count.Load())
+}
+
+func TestRelease(t *testing.T) {
+	ctx := context.Background()
+	urls, stor, client := setup(t)
+
+	path, _ := writeFile(t, data.ChunkSize+1)
+	att, err := New(path, masterPass)
+	require.NoError(t, err)
+	other, err := New(path, masterPass)
+	require.NoError(t, err)
+	assert.Equal(t, att.ID, other.ID)
+	assert.NotEqual(t, att.Record, other.Record)
+
+	// Повторная загрузка той же записи не добавляет ссылку
+	require.NoError(t, Upload(ctx, client, urls, att, path, nil))
+	require.NoError(t, Upload(ctx, client, urls, att, path, nil))
+	require.NoError(t, Upload(ctx, client, urls, other, path, nil))
+	info, ok, err := stor.GetAttachment(ctx, "user id", att.ID)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, 2, info.Refs)
+
+	// Вложение удаляется только после освобождения ссылок всех записей
+	require.NoError(t, Release(ctx, client, urls, att))
+	require.NoError(t, Release(ctx, client, urls, att))
+	out := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, Download(ctx, client, urls, att, out, nil))
+
+	require.NoError(t, Release(ctx, client, urls, other))
+	_, ok, err = stor.GetAttachment(ctx, "user id", att.ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	missing, err := stor.GetMissingChunks(ctx, "user id", att.Chunks)
+	require.NoError(t, err)
+	assert.Equal(t, att.Chunks, missing)
+
+	// Повторное освобождение не является ошибкой
+	require.NoError(t, Release(ctx, client, urls, other))
+
+	// Скачивание удаленного вложения
+	err = Download(ctx, client, urls, att, filepath.Join(t.TempDir(), "other"), nil)
+	assert.ErrorIs(t, err, ErrChunkNotFound)
+}
+
+func TestValidate(t *testing.T) {
+	path, _ := writeFile(t, 10)
+	att, err := New(path, masterPass)
+	require.NoError(t, err)
+
+	bad := *att
+	bad.Key = []byte("short")
+	assert.ErrorIs(t, validate(&bad), ErrBadAttachment)
+
+	bad = *att
+	bad.Chunks = []string{att.Chunks[0], att.Chunks[0]}
+	assert.ErrorIs(t, validate(&bad), ErrBadAttachment)
+
+	bad = *att
+	bad.Chunks = []string{"not a hash"}
+	assert.ErrorIs(t, validate(&bad), ErrBadAttachment)
+
+	bad = *att
+	bad.ChunkSize = data.ChunkSize + 1
+	assert.ErrorIs(t, validate(&bad), ErrBadAttachment)
+
+	assert.ErrorIs(t, validate(nil), ErrBadAttachment)
+}
+
+func TestManifests(t *testing.T) {
+	ctx := context.Background()
+	stor := clientMemory.NewStore()
+
+	save := func(name string, payload any, dataType int) {
+		b, err := json.Marshal(payload)
+		require.NoError(t, err)
+		encrData, err := encr.EncryptData(context.Background(), masterPass, &data.Data{Name: name, Type: dataType, Data: b})
+		require.NoError(t, err)
+		ok, err := stor.AddEncryptedData(ctx, "user id", *encrData, data.SAVED)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+	save("large", clientData.Binary{Attachment: &clientData.Attachment{ID: "attachment", Record: "record"}}, data.BINARY)
+	save("small", clientData.Binary{Binary: []byte("inline")}, data.BINARY)
+	save("text", clientData.Text{Text: "text"}, data.TEXT)
+
+	atts, err := Manifests(ctx, stor, "user id", masterPass, "large")
+	require.NoError(t, err)
+	assert.Equal(t, []*clientData.Attachment{{ID: "attachment", Record: "record"}}, atts)
+
+	atts, err = Manifests(ctx, stor, "user id", masterPass, "small")
+	require.NoError(t, err)
+	assert.Empty(t, atts)
+
+	atts, err = Manifests(ctx, stor, "user id", masterPass, "text")
+	require.NoError(t, err)
+	assert.Empty(t, atts)
+
+	_, err = Manifests(ctx, stor, "user id", "wrong password", "large")
+	assert.Error(t, err)
+}
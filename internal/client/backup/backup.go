@@ -0,0 +1,336 @@
+// Пакет backup реализует переносимый зашифрованный архив всех данных пользователя для резервного копирования
+// и переноса данных между серверами. Архив содержит расшифрованные записи всех версий и содержимое вложений
+// и защищен отдельной парольной фразой, поэтому его можно восстановить в учетную запись с другим мастер паролем.
+//
+// Формат архива версии 1: заголовок (сигнатура, версия, параметры PBKDF2 и соль), за которым следуют фреймы,
+// зашифрованные AES256-GCM. Расшифрованный поток - tar архив, сжатый gzip, с файлом manifest.json и файлами
+// attachments/<идентификатор вложения>.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
+	"github.com/abezemskiy/gophkeeper/internal/client/importer"
+	clientData "github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Параметры формата архива.
+const (
+	Format       = "gophkeeper" // имя формата архива в команде импорта
+	Version byte = 1            // текущая версия формата архива
+
+	manifestName   = "manifest.json"
+	attachmentsDir = "attachments/"
+)
+
+// ErrEmptyPassphrase - ошибка создания или чтения архива без парольной фразы.
+var ErrEmptyPassphrase = errors.New("archive passphrase is empty")
+
+// manifest - список записей архива.
+type manifest struct {
+	Version int           `json:"version"`
+	Created time.Time     `json:"created"`
+	Records [][]data.Data `json:"records"` // версии данных, несколько версий сохраняются при конфликте
+}
+
+// Fetch - функция для записи расшифрованного содержимого вложения в w.
+type Fetch func(ctx context.Context, att *clientData.Attachment, w io.Writer) error
+
+// Stats - количество записей и вложений в архиве.
+type Stats struct {
+	Records int `json:"records"`
+	Files   int `json:"files"`
+}
+
+// Write - функция для записи архива данных пользователя. records - расшифрованные данные пользователя,
+// fetch - функция для скачивания вложений, содержимое которых хранится на сервере.
+// Вложения скачиваются по одному прямо в архив, их содержимое не сохраняется на диск.
+func Write(ctx context.Context, w io.Writer, passphrase string, records [][]data.Data, fetch Fetch) (Stats, error) {
+	var stats Stats
+	if passphrase == "" {
+		return stats, ErrEmptyPassphrase
+	}
+	ew, err := newWriter(w, passphrase)
+	if err != nil {
+		return stats, err
+	}
+	gz := gzip.NewWriter(ew)
+	tw := tar.NewWriter(gz)
+
+	now := time.Now()
+	m, err := json.Marshal(manifest{Version: int(Version), Created: now, Records: records})
+	if err != nil {
+		return stats, fmt.Errorf("failed to marshal manifest, %w", err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: manifestName, Mode: 0o600, Size: int64(len(m)), ModTime: now}); err != nil {
+		return stats, fmt.Errorf("failed to write manifest, %w", err)
+	}
+	if _, err := tw.Write(m); err != nil {
+		return stats, fmt.Errorf("failed to write manifest, %w", err)
+	}
+	stats.Records = len(records)
+
+	written := make(map[string]bool)
+	for _, versions := range records {
+		for i := range versions {
+			att, ok := attachment.FromData(&versions[i])
+			if !ok || written[att.ID] {
+				continue
+			}
+			if err := writeAttachment(ctx, tw, att, fetch); err != nil {
+				return stats, fmt.Errorf("failed to export file %s, %w", versions[i].Name, err)
+			}
+			written[att.ID] = true
+			stats.Files++
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return stats, fmt.Errorf("failed to close archive, %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return stats, fmt.Errorf("failed to close archive, %w", err)
+	}
+	if err := ew.Close(); err != nil {
+		return stats, fmt.Errorf("failed to close archive, %w", err)
+	}
+	return stats, nil
+}
+
+// writeAttachment - функция для записи содержимого вложения в архив. Размер файла в архиве берется из манифеста,
+// поэтому содержимое, не совпадающее с манифестом, прерывает запись архива.
+func writeAttachment(ctx context.Context, tw *tar.Writer, att *clientData.Attachment, fetch Fetch) error {
+	if err := tw.WriteHeader(&tar.Header{Name: attachmentsDir + att.ID, Mode: 0o600, Size: att.Size, ModTime: time.Now()}); err != nil {
+		return fmt.Errorf("failed to write file header, %w", err)
+	}
+	d := newDigest()
+	if err := fetch(ctx, att, io.MultiWriter(tw, d)); err != nil {
+		return err
+	}
+	if !d.matches(att) {
+		return fmt.Errorf("file %s does not match manifest", att.ID)
+	}
+	return nil
+}
+
+// Contents - содержимое прочитанного архива. Вложения остаются в потоке архива и читаются методом Prepare.
+type Contents struct {
+	Created     time.Time
+	Records     [][]data.Data
+	tr          *tar.Reader
+	attachments map[string]*clientData.Attachment // манифесты вложений по идентификатору вложения
+}
+
+// Read - функция для чтения манифеста архива. Вложения не распаковываются: они читаются из r методом Prepare
+// после построения плана импорта, поэтому r должен оставаться открытым до восстановления данных.
+func Read(r io.Reader, passphrase string) (*Contents, error) {
+	if passphrase == "" {
+		return nil, ErrEmptyPassphrase
+	}
+	er, err := newReader(r, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	gz, err := gzip.NewReader(er)
+	if err != nil {
+		return nil, archiveError(err)
+	}
+	tr := tar.NewReader(gz)
+
+	// Манифест записывается в архив первым
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, archiveError(err)
+	}
+	if hdr.Name != manifestName {
+		return nil, fmt.Errorf("%w, manifest not found", ErrCorrupted)
+	}
+	var m manifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return nil, fmt.Errorf("%w, failed to decode manifest, %v", ErrCorrupted, err)
+	}
+	if m.Version != int(Version) {
+		return nil, fmt.Errorf("%w %d", ErrUnsupportedVersion, m.Version)
+	}
+
+	attachments := make(map[string]*clientData.Attachment)
+	for _, versions := range m.Records {
+		for i := range versions {
+			att, ok := attachment.FromData(&versions[i])
+			if !ok {
+				continue
+			}
+			// Идентификатор вложения - хэш в шестнадцатеричном виде, он используется как имя файла в архиве
+			if _, err := hex.DecodeString(att.ID); err != nil || att.ID == "" {
+				return nil, fmt.Errorf("%w, invalid attachment id of %s", ErrCorrupted, versions[i].Name)
+			}
+			attachments[att.ID] = att
+		}
+	}
+
+	return &Contents{Created: m.Created, Records: m.Records, tr: tr, attachments: attachments}, nil
+}
+
+// digest - размер и хэш содержимого вложения для проверки по манифесту.
+type digest struct {
+	h hash.Hash
+	n int64
+}
+
+// newDigest - фабричная функция digest.
+func newDigest() *digest {
+	return &digest{h: sha256.New()}
+}
+
+// Write - метод для учета очередной порции содержимого.
+func (d *digest) Write(p []byte) (int, error) {
+	d.h.Write(p)
+	d.n += int64(len(p))
+	return len(p), nil
+}
+
+// matches - метод для проверки, что содержимое совпадает с манифестом вложения att.
+func (d *digest) matches(att *clientData.Attachment) bool {
+	return d.n == att.Size && hex.EncodeToString(d.h.Sum(nil)) == att.Hash
+}
+
+// verifiedReader - чтение содержимого вложения из архива, которое вместо конца потока возвращает ErrCorrupted,
+// если содержимое не совпадает с манифестом.
+type verifiedReader struct {
+	r   io.Reader
+	att *clientData.Attachment
+	d   *digest
+}
+
+// Read - метод для чтения содержимого вложения.
+func (v *verifiedReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.d.Write(p[:n])
+	if errors.Is(err, io.EOF) && !v.d.matches(v.att) {
+		return n, fmt.Errorf("%w, file %s does not match manifest", ErrCorrupted, v.att.ID)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, archiveError(err)
+	}
+	return n, err
+}
+
+// archiveError - функция для преобразования ошибки чтения распакованного потока. Ошибки расшифровывания
+// возвращаются без изменений, ошибки формата tar и gzip означают поврежденный архив.
+func archiveError(err error) error {
+	if errors.Is(err, ErrTruncated) || errors.Is(err, ErrCorrupted) {
+		return err
+	}
+	return fmt.Errorf("%w, %v", ErrCorrupted, err)
+}
+
+// Items - функция для получения записей архива для импорта. Версии данных, сохраненные при конфликте,
+// импортируются как отдельные записи с тем же именем и обрабатываются политикой разрешения конфликтов имен.
+func (c *Contents) Items() []data.Data {
+	var items []data.Data
+	for _, versions := range c.Records {
+		for _, v := range versions {
+			v.Status = data.NEW
+			items = append(items, v)
+		}
+	}
+	return items
+}
+
+// Prepare - функция для подготовки файлов плана импорта к сохранению. Вложения читаются из потока архива
+// без сохранения на диск: содержимое небольших файлов сохраняется в самой записи, большие файлы загружаются
+// на сервер частями по мере чтения с ключом, полученным из мастер пароля учетной записи, в которую восстанавливаются
+// данные. Поток архива читается один раз, поэтому Prepare вызывается не более одного раза.
+func (c *Contents) Prepare(ctx context.Context, actions []importer.Action, masterPass string, client *resty.Client,
+	urls attachment.URLs) error {
+
+	// Записи плана импорта, которым нужно содержимое вложений
+	need := make(map[string][]*data.Data)
+	for i := range actions {
+		if actions[i].Op == importer.OpSkip {
+			continue
+		}
+		if att, ok := attachment.FromData(&actions[i].Data); ok {
+			need[att.ID] = append(need[att.ID], &actions[i].Data)
+		}
+	}
+
+	for len(need) > 0 {
+		hdr, err := c.tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return archiveError(err)
+		}
+		// Принимаются только вложения из манифеста
+		att, ok := c.attachments[strings.TrimPrefix(hdr.Name, attachmentsDir)]
+		if !ok || !strings.HasPrefix(hdr.Name, attachmentsDir) {
+			return fmt.Errorf("%w, unexpected file %s", ErrCorrupted, hdr.Name)
+		}
+		if hdr.Size != att.Size {
+			return fmt.Errorf("%w, file %s does not match manifest", ErrCorrupted, att.ID)
+		}
+		records, ok := need[att.ID]
+		if !ok {
+			continue
+		}
+		if err := restore(ctx, &verifiedReader{r: c.tr, att: att, d: newDigest()}, att, records, masterPass, client, urls); err != nil {
+			return err
+		}
+		delete(need, att.ID)
+	}
+
+	for i := range actions {
+		if att, ok := attachment.FromData(&actions[i].Data); ok && len(need[att.ID]) > 0 {
+			return fmt.Errorf("content of file %s is missing in archive", actions[i].Data.Name)
+		}
+	}
+	return nil
+}
+
+// restore - функция для восстановления содержимого вложения att из r в записи records.
+func restore(ctx context.Context, r io.Reader, att *clientData.Attachment, records []*data.Data, masterPass string,
+	client *resty.Client, urls attachment.URLs) error {
+
+	var content []byte
+	var newAtt *clientData.Attachment
+	var err error
+	if att.Size <= data.ChunkSize {
+		content, err = io.ReadAll(r)
+	} else {
+		newAtt, err = attachment.UploadStream(ctx, client, urls, r, masterPass)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore file %s, %w", records[0].Name, err)
+	}
+
+	for _, userData := range records {
+		var b clientData.Binary
+		if err := json.Unmarshal(userData.Data, &b); err != nil {
+			return fmt.Errorf("failed to unmarshal file %s, %w", userData.Name, err)
+		}
+		b.Binary, b.Attachment = content, newAtt
+		payload, err := json.Marshal(b)
+		if err != nil {
+			return fmt.Errorf("failed to marshal file %s, %w", userData.Name, err)
+		}
+		userData.Data = payload
+	}
+	return nil
+}
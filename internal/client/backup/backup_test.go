@@ -0,0 +1,291 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
+	"github.com/abezemskiy/gophkeeper/internal/client/importer"
+	clientData "github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	content := make([]byte, 3*frameSize+123)
+	_, err := rand.Read(content)
+	require.NoError(t, err)
+
+	write := func(t *testing.T, content []byte) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		w, err := newWriter(&buf, "passphrase")
+		require.NoError(t, err)
+		_, err = w.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+	read := func(archive []byte, passphrase string) ([]byte, error) {
+		r, err := newReader(bytes.NewReader(archive), passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	}
+	archive := write(t, content)
+
+	tests := []struct {
+		name       string
+		archive    func() []byte
+		passphrase string
+		want       []byte
+		wantErr    error
+	}{
+		{
+			name:       "success",
+			archive:    func() []byte { return archive },
+			passphrase: "passphrase",
+			want:       content,
+		},
+		{
+			name:       "empty content",
+			archive:    func() []byte { return write(t, nil) },
+			passphrase: "passphrase",
+			want:       []byte{},
+		},
+		{
+			name:       "wrong passphrase",
+			archive:    func() []byte { return archive },
+			passphrase: "wrong",
+			wantErr:    ErrWrongPassphrase,
+		},
+		{
+			name:       "not archive",
+			archive:    func() []byte { return []byte("plain text file content") },
+			passphrase: "passphrase",
+			wantErr:    ErrNotArchive,
+		},
+		{
+			name: "unsupported version",
+			archive: func() []byte {
+				a := bytes.Clone(archive)
+				a[len(magic)] = Version + 1
+				return a
+			},
+			passphrase: "passphrase",
+			wantErr:    ErrUnsupportedVersion,
+		},
+		{
+			name: "truncated archive",
+			archive: func() []byte {
+				// Архив обрезан по границе фрейма, поэтому без проверки последнего фрейма усечение было бы незаметно
+				return archive[:headerSize+2*(5+frameSize+28)]
+			},
+			passphrase: "passphrase",
+			wantErr:    ErrTruncated,
+		},
+		{
+			name: "tampered frame",
+			archive: func() []byte {
+				a := bytes.Clone(archive)
+				a[len(a)-10] ^= 1
+				return a
+			},
+			passphrase: "passphrase",
+			wantErr:    ErrCorrupted,
+		},
+		{
+			name: "frame marked as final",
+			archive: func() []byte {
+				a := bytes.Clone(archive)
+				a[headerSize] = frameFinal
+				return a
+			},
+			passphrase: "passphrase",
+			wantErr:    ErrWrongPassphrase,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := read(tt.archive(), tt.passphrase)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	large := []byte("large file content")
+	sum := sha256.Sum256(large)
+	att := &clientData.Attachment{ID: "0a1b", Size: int64(len(large)), Hash: hex.EncodeToString(sum[:])}
+
+	records := [][]data.Data{
+		{testutil.NewData(t, "db", data.PASSWORD, "", clientData.Password{Login: "admin", Password: "secret"})},
+		{
+			testutil.NewData(t, "note", data.TEXT, "", clientData.Text{Text: "first"}),
+			testutil.NewData(t, "note", data.TEXT, "", clientData.Text{Text: "second"}),
+		},
+		{testutil.NewData(t, "video", data.BINARY, "", clientData.Binary{Type: "video/mp4", Attachment: att})},
+		{testutil.NewData(t, "copy", data.BINARY, "", clientData.Binary{Type: "video/mp4", Attachment: att})},
+	}
+
+	fetched := 0
+	fetch := func(_ context.Context, a *clientData.Attachment, w io.Writer) error {
+		fetched++
+		assert.Equal(t, att.ID, a.ID)
+		_, err := w.Write(large)
+		return err
+	}
+
+	var buf bytes.Buffer
+	stats, err := Write(context.Background(), &buf, "passphrase", records, fetch)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Records: 4, Files: 1}, stats)
+	assert.Equal(t, 1, fetched)
+	assert.NotContains(t, buf.String(), "secret")
+
+	_, err = Read(bytes.NewReader(buf.Bytes()), "wrong")
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+
+	contents, err := Read(bytes.NewReader(buf.Bytes()), "passphrase")
+	require.NoError(t, err)
+	assert.Equal(t, records, contents.Records)
+
+	items := contents.Items()
+	require.Len(t, items, 5)
+	actions := make([]importer.Action, 0, len(items))
+	for _, item := range items {
+		assert.Equal(t, data.NEW, item.Status)
+		actions = append(actions, importer.Action{Op: importer.OpAdd, Name: item.Name, Type: item.Type, Data: item})
+	}
+	// Небольшой файл восстанавливается в саму запись без обращения к серверу
+	require.NoError(t, contents.Prepare(context.Background(), actions, "master", nil, attachment.URLs{}))
+	for _, a := range actions[3:] {
+		var b clientData.Binary
+		require.NoError(t, json.Unmarshal(a.Data.Data, &b))
+		assert.Equal(t, large, b.Binary)
+		assert.Nil(t, b.Attachment)
+	}
+
+	// Содержимое файла не совпадает с манифестом
+	badFetch := func(_ context.Context, _ *clientData.Attachment, w io.Writer) error {
+		_, err := w.Write([]byte("other content"))
+		return err
+	}
+	buf.Reset()
+	_, err = Write(context.Background(), &buf, "passphrase", records, badFetch)
+	assert.Error(t, err)
+
+	_, err = Write(context.Background(), &buf, "", records, fetch)
+	assert.ErrorIs(t, err, ErrEmptyPassphrase)
+}
+
+func TestPrepare(t *testing.T) {
+	large := []byte("large file content")
+	sum := sha256.Sum256(large)
+	att := &clientData.Attachment{ID: "0a1b", Size: int64(len(large)), Hash: hex.EncodeToString(sum[:])}
+	video := testutil.NewData(t, "video", data.BINARY, "", clientData.Binary{Type: "video/mp4", Attachment: att})
+
+	tests := []struct {
+		name    string
+		records [][]data.Data
+		files   map[string][]byte
+		op      string
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name:    "content does not match manifest",
+			records: [][]data.Data{{video}},
+			files:   map[string][]byte{att.ID: []byte("other file content")},
+			op:      importer.OpAdd,
+			wantErr: ErrCorrupted,
+		},
+		{
+			name:    "unexpected file",
+			records: [][]data.Data{{video}},
+			files:   map[string][]byte{"../escape": large},
+			op:      importer.OpAdd,
+			wantErr: ErrCorrupted,
+		},
+		{
+			name:    "missing content",
+			records: [][]data.Data{{video}},
+			op:      importer.OpAdd,
+			wantMsg: "content of file video is missing in archive",
+		},
+		{
+			name:    "skipped record",
+			records: [][]data.Data{{video}},
+			op:      importer.OpSkip,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents, err := Read(bytes.NewReader(writeArchive(t, tt.records, tt.files)), "passphrase")
+			require.NoError(t, err)
+			items := contents.Items()
+			actions := make([]importer.Action, 0, len(items))
+			for _, item := range items {
+				actions = append(actions, importer.Action{Op: tt.op, Name: item.Name, Type: item.Type, Data: item})
+			}
+			err = contents.Prepare(context.Background(), actions, "master", nil, attachment.URLs{})
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.wantMsg != "":
+				assert.EqualError(t, err, tt.wantMsg)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// Идентификатор вложения в манифесте должен быть хэшем
+	escape := &clientData.Attachment{ID: "../escape", Size: att.Size, Hash: att.Hash}
+	archive := writeArchive(t, [][]data.Data{{testutil.NewData(t, "escape", data.BINARY, "", clientData.Binary{Attachment: escape})}}, nil)
+	_, err := Read(bytes.NewReader(archive), "passphrase")
+	assert.ErrorIs(t, err, ErrCorrupted)
+}
+
+// writeArchive - вспомогательная функция для записи архива с произвольными файлами вложений files, которые
+// не проверяются по манифесту.
+func writeArchive(t *testing.T, records [][]data.Data, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	ew, err := newWriter(&buf, "passphrase")
+	require.NoError(t, err)
+	gz := gzip.NewWriter(ew)
+	tw := tar.NewWriter(gz)
+	write := func(name string, content []byte) {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(content))}))
+		_, err := tw.Write(content)
+		require.NoError(t, err)
+	}
+	m, err := json.Marshal(manifest{Version: int(Version), Records: records})
+	require.NoError(t, err)
+	write(manifestName, m)
+	for id, content := range files {
+		write(attachmentsDir+id, content)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, ew.Close())
+	return buf.Bytes()
+}
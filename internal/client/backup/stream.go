@@ -0,0 +1,244 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/random"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Параметры шифрования архива.
+const (
+	magic      = "GKBACKUP" // сигнатура файла архива
+	saltSize   = 16         // размер соли для получения ключа из парольной фразы
+	keySize    = 32         // размер ключа AES256
+	iterations = 600_000    // количество итераций PBKDF2 для новых архивов
+	frameSize  = 64 << 10   // максимальный размер открытых данных в одном фрейме
+	headerSize = len(magic) + 1 + 4 + saltSize
+
+	frameData  byte = 0 // фрейм с данными, за которым следуют другие фреймы
+	frameFinal byte = 1 // последний фрейм архива
+)
+
+// Ошибки чтения архива.
+var (
+	ErrNotArchive         = errors.New("file is not a gophkeeper archive")
+	ErrUnsupportedVersion = errors.New("unsupported archive version")
+	ErrWrongPassphrase    = errors.New("wrong passphrase or corrupted archive")
+	ErrCorrupted          = errors.New("archive is corrupted")
+	ErrTruncated          = errors.New("archive is truncated")
+)
+
+// header - заголовок архива: сигнатура, версия формата, количество итераций PBKDF2 и соль.
+// Заголовок не шифруется, но аутентифицируется вместе с каждым фреймом.
+type header [headerSize]byte
+
+// newHeader - функция для создания заголовка нового архива со случайной солью.
+func newHeader() (header, error) {
+	var h header
+	salt, err := random.GenerateCryptoRandom(saltSize)
+	if err != nil {
+		return h, fmt.Errorf("failed to generate salt, %w", err)
+	}
+	copy(h[:], magic)
+	h[len(magic)] = Version
+	binary.BigEndian.PutUint32(h[len(magic)+1:], iterations)
+	copy(h[len(magic)+5:], salt)
+	return h, nil
+}
+
+// aead - функция для получения шифра архива из парольной фразы и параметров заголовка.
+func (h header) aead(passphrase string) (cipher.AEAD, error) {
+	iter := int(binary.BigEndian.Uint32(h[len(magic)+1:]))
+	key := pbkdf2.Key([]byte(passphrase), h[len(magic)+5:], iter, keySize, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new cipher.Block, %w", err)
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new gcm, %w", err)
+	}
+	return aesgcm, nil
+}
+
+// additionalData - функция для получения аутентифицируемых данных фрейма. Номер и тип фрейма защищают архив
+// от перестановки, удаления и усечения фреймов.
+func (h header) additionalData(index uint64, kind byte) []byte {
+	ad := make([]byte, 0, headerSize+9)
+	ad = append(ad, h[:]...)
+	ad = binary.BigEndian.AppendUint64(ad, index)
+	return append(ad, kind)
+}
+
+// writer - шифрующий поток архива. Данные делятся на фреймы, каждый фрейм шифруется AES256-GCM отдельно,
+// поэтому архив любого размера пишется и читается без загрузки в память целиком.
+type writer struct {
+	w      io.Writer
+	h      header
+	aesgcm cipher.AEAD
+	buf    []byte
+	index  uint64
+	closed bool
+}
+
+// newWriter - функция для создания шифрующего потока архива. Заголовок записывается сразу.
+// Close записывает последний фрейм, без него архив считается усеченным.
+func newWriter(w io.Writer, passphrase string) (*writer, error) {
+	h, err := newHeader()
+	if err != nil {
+		return nil, err
+	}
+	aesgcm, err := h.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(h[:]); err != nil {
+		return nil, fmt.Errorf("failed to write archive header, %w", err)
+	}
+	return &writer{w: w, h: h, aesgcm: aesgcm, buf: make([]byte, 0, frameSize)}, nil
+}
+
+// Write - записывает данные в архив. Фрейм шифруется, когда накоплено больше данных, чем помещается в него.
+func (w *writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write to closed archive")
+	}
+	written := 0
+	for len(p) > 0 {
+		if len(w.buf) == frameSize {
+			if err := w.flush(frameData); err != nil {
+				return written, err
+			}
+		}
+		n := copy(w.buf[len(w.buf):frameSize], p)
+		w.buf = w.buf[:len(w.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+// Close - записывает последний фрейм архива.
+func (w *writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.flush(frameFinal)
+}
+
+// flush - функция для шифрования и записи накопленных данных одним фреймом.
+// Формат фрейма: тип (1 байт), длина шифротекста (4 байта), вектор инициализации и шифротекст.
+func (w *writer) flush(kind byte) error {
+	nonce, err := random.GenerateCryptoRandom(w.aesgcm.NonceSize())
+	if err != nil {
+		return fmt.Errorf("failed to create new initialization vector, %w", err)
+	}
+	sealed := w.aesgcm.Seal(nonce, nonce, w.buf, w.h.additionalData(w.index, kind))
+
+	var prefix [5]byte
+	prefix[0] = kind
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(sealed)))
+	if _, err := w.w.Write(prefix[:]); err != nil {
+		return fmt.Errorf("failed to write archive frame, %w", err)
+	}
+	if _, err := w.w.Write(sealed); err != nil {
+		return fmt.Errorf("failed to write archive frame, %w", err)
+	}
+	w.index++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// reader - расшифровывающий поток архива.
+type reader struct {
+	r      io.Reader
+	h      header
+	aesgcm cipher.AEAD
+	buf    *bytes.Reader
+	index  uint64
+	final  bool
+}
+
+// newReader - функция для создания расшифровывающего потока архива. Проверяет заголовок и парольную фразу
+// по первому фрейму.
+func newReader(r io.Reader, passphrase string) (*reader, error) {
+	var h header
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		return nil, ErrNotArchive
+	}
+	if string(h[:len(magic)]) != magic {
+		return nil, ErrNotArchive
+	}
+	if h[len(magic)] != Version {
+		return nil, fmt.Errorf("%w %d", ErrUnsupportedVersion, h[len(magic)])
+	}
+	aesgcm, err := h.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	rd := &reader{r: r, h: h, aesgcm: aesgcm, buf: bytes.NewReader(nil)}
+	if err := rd.next(); err != nil {
+		return nil, err
+	}
+	return rd, nil
+}
+
+// Read - читает расшифрованные данные архива. Возвращает ErrTruncated, если архив закончился без последнего фрейма.
+func (r *reader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 {
+		if r.final {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	return r.buf.Read(p)
+}
+
+// next - функция для чтения и расшифровывания следующего фрейма.
+func (r *reader) next() error {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r.r, prefix[:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrTruncated
+		}
+		return fmt.Errorf("failed to read archive frame, %w", err)
+	}
+	kind, size := prefix[0], binary.BigEndian.Uint32(prefix[1:])
+	if (kind != frameData && kind != frameFinal) || int(size) > frameSize+r.aesgcm.NonceSize()+r.aesgcm.Overhead() ||
+		int(size) < r.aesgcm.NonceSize() {
+		return ErrCorrupted
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(r.r, sealed); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrTruncated
+		}
+		return fmt.Errorf("failed to read archive frame, %w", err)
+	}
+
+	nonce := sealed[:r.aesgcm.NonceSize()]
+	plain, err := r.aesgcm.Open(nil, nonce, sealed[len(nonce):], r.h.additionalData(r.index, kind))
+	if err != nil {
+		// Ошибка первого фрейма, скорее всего, означает неверную парольную фразу
+		if r.index == 0 {
+			return ErrWrongPassphrase
+		}
+		return ErrCorrupted
+	}
+	r.index++
+	r.final = kind == frameFinal
+	r.buf.Reset(plain)
+	return nil
+}
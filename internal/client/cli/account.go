@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/handlers"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+)
+
+// ErrAccountNotConfirmed - ошибка удаления учетной записи без подтверждения пользователя.
+var ErrAccountNotConfirmed = errors.New("account deletion is not confirmed")
+
+// deleteAccount - команда для удаления учетной записи пользователя на сервере со всеми данными и вложениями.
+// Пользователь подтверждает удаление вводом своего логина в опции -confirm или в следующей строке стандартного
+// потока ввода. После удаления на сервере пользователь и его данные удаляются и с данного устройства.
+func (c *CLI) deleteAccount(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("delete-account", &opts)
+	confirm := fs.String("confirm", "", "login of the user to confirm account deletion, default from the next line of stdin")
+	if _, err := parse(fs, &opts, args); err != nil {
+		return err
+	}
+	if _, _, err := c.authorize(ctx, &opts); err != nil {
+		return err
+	}
+	authData, _ := c.info.Get()
+
+	if *confirm == "" {
+		if opts.format != JSON {
+			fmt.Fprintf(c.out, "All data of user %s will be deleted from the server and this device.\nType the login to continue: ", authData.Login)
+		}
+		line, err := c.in.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read confirmation from stdin, %w", err)
+		}
+		*confirm = line
+		if opts.format != JSON {
+			fmt.Fprintln(c.out)
+		}
+	}
+	if strings.TrimSpace(*confirm) != authData.Login {
+		return fmt.Errorf("%w, type login %s to confirm", ErrAccountNotConfirmed, authData.Login)
+	}
+
+	if err := handlers.DeleteAccount(ctx, c.addr+api.AccountPattern, &authData, c.authClient, c.remover, c.info); err != nil {
+		return fmt.Errorf("delete account error, %w", err)
+	}
+	return c.print(opts.format, result{Status: "account deleted", Name: authData.Login})
+}
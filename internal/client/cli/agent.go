@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/agent"
+	"github.com/abezemskiy/gophkeeper/internal/client/identity"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	repoSynch "github.com/abezemskiy/gophkeeper/internal/repositories/synchronization"
+)
+
+// agent - команда для запуска локального агента. Команда блокируется до завершения контекста.
+func (c *CLI) agent(ctx context.Context, args []string) error {
+	var opts options
+	var idle time.Duration
+	fs := newFlagSet("agent", &opts)
+	fs.DurationVar(&idle, "idle", agent.DefaultIdleTimeout, "idle timeout after which the agent locks the session")
+	if _, err := parse(fs, &opts, args); err != nil {
+		return err
+	}
+	return agent.New(c.addr, c.stor, c.ident, c.client, idle).Serve(ctx, opts.socket, repoSynch.GetPeroidOfSynchr())
+}
+
+// unlock - команда для разблокировки сессии агента мастер паролем пользователя.
+func (c *CLI) unlock(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("unlock", &opts)
+	if _, err := parse(fs, &opts, args); err != nil {
+		return err
+	}
+	authData, err := c.credentials(&opts)
+	if err != nil {
+		return err
+	}
+	client, err := agent.NewClient(opts.socket)
+	if err != nil {
+		return err
+	}
+	if err := client.Unlock(ctx, *authData); err != nil {
+		return fmt.Errorf("unlock agent error, %w", err)
+	}
+	return c.print(opts.format, result{Status: "unlocked", Name: authData.Login})
+}
+
+// lock - команда для блокировки сессии агента.
+func (c *CLI) lock(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("lock", &opts)
+	if _, err := parse(fs, &opts, args); err != nil {
+		return err
+	}
+	client, err := agent.NewClient(opts.socket)
+	if err != nil {
+		return err
+	}
+	if err := client.Lock(ctx); err != nil {
+		return fmt.Errorf("lock agent error, %w", err)
+	}
+	return c.print(opts.format, result{Status: "locked"})
+}
+
+// status - команда для вывода состояния сессии агента.
+func (c *CLI) status(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("status", &opts)
+	if _, err := parse(fs, &opts, args); err != nil {
+		return err
+	}
+	client, err := agent.NewClient(opts.socket)
+	if err != nil {
+		return err
+	}
+	status, err := client.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Locked {
+		return c.print(opts.format, result{Status: "locked"})
+	}
+	return c.print(opts.format, result{Status: "unlocked", Name: status.Login})
+}
+
+// agentData - функция для получения расшифрованных данных пользователя от агента. Логин пользователя из сессии агента
+// устанавливается в текущую сессию, чтобы запросы к серверу (например, скачивание файлов) использовали его токен.
+func (c *CLI) agentData(ctx context.Context, opts *options) ([][]repoData.Data, error) {
+	client, err := agent.NewClient(opts.socket)
+	if errors.Is(err, agent.ErrNotRunning) {
+		return nil, fmt.Errorf("password is not set, use -password-stdin, %s or unlocked agent", PasswordEnv)
+	}
+	if err != nil {
+		return nil, err
+	}
+	status, err := client.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status.Locked {
+		return nil, fmt.Errorf("password is not set and %w, run unlock", agent.ErrLocked)
+	}
+	all, err := client.Data(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.info.Set(identity.AuthData{Login: status.Login}, "")
+	return all, nil
+}
@@ -0,0 +1,284 @@
+// Пакет cli содержит неинтерактивные команды клиента для использования в скриптах и CI.
+// Команды используют те же хэндлеры и синхронизацию, что и TUI, выводят результат в виде текста или JSON,
+// а секреты читают из стандартного потока ввода или переменных окружения.
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/agent"
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
+	"github.com/abezemskiy/gophkeeper/internal/client/handlers"
+	"github.com/abezemskiy/gophkeeper/internal/client/identity"
+	"github.com/abezemskiy/gophkeeper/internal/client/identity/auth"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Переменные окружения, из которых читаются секреты.
+const (
+	LoginEnv    = "GOPHKEEPER_LOGIN"    // логин пользователя
+	PasswordEnv = "GOPHKEEPER_PASSWORD" // мастер пароль пользователя
+	SecretEnv   = "GOPHKEEPER_SECRET"   // секрет сохраняемых данных
+
+	PassphraseEnv = "GOPHKEEPER_BACKUP_PASSPHRASE" // парольная фраза архива данных
+)
+
+// Форматы вывода результата команд.
+const (
+	Plain = "plain"
+	JSON  = "json"
+)
+
+// Usage - справка по командам клиента.
+const Usage = `usage: client [flags] <command> [options] [args]
+
+commands:
+  register                       регистрация нового пользователя на сервере
+  login                          вход с данного устройства через сервер
+  list                           список данных пользователя, принятых общих записей и записей организаций,
+                                 фильтры -tag, -folder, -favorite
+  get <name>                     данные по имени, с опцией -org <org> - запись коллекции организации
+  get -share <id>                общая запись, которой поделился другой пользователь
+  add <type>                     добавление новых данных типа password, text, card, file, login или ssh,
+                                 с опцией -org <org> - в коллекцию организации
+  edit <type>                    замена существующих данных, с опцией -share <id> - изменение общей записи,
+                                 с опцией -org <org> - изменение записи коллекции организации
+  rm <name>                      удаление данных, с опцией -org <org> - из коллекции организации
+  sync                           синхронизация данных с сервером
+  share <name> -to <login>       передача записи другому пользователю, с опцией -write получатель может её изменять,
+                                 опция -accept-key подтверждает изменение ключа получателя
+  shares                         записи, которыми пользователь поделился, и записи, которыми поделились с ним
+  accept <id>                    принятие записи, которой поделился другой пользователь
+  revoke <id>                    отзыв записи владельцем или отказ получателя от записи
+  org create <name>              создание организации, создатель становится её владельцем
+  org list                       организации пользователя и его роли в них
+  org members <org>              участники организации
+  org add <org> <login>          добавление участника, опция -role admin|editor|viewer (по умолчанию viewer)
+  org role <org> <login> <role>  изменение роли участника
+  org remove <org> <login>       удаление участника с заменой ключа коллекции
+  org rotate <org>               замена ключа коллекции организации
+  org delete <org>               удаление организации владельцем
+  audit                          журнал аудита учетной записи на сервере, опции -since, -limit
+  delete-account                 удаление учетной записи на сервере и на устройстве (требует подтверждения логином)
+  import -format <format> <path> импорт из KeePass, Bitwarden, 1Password или архива gophkeeper
+  export <path>                  зашифрованный архив всех данных и файлов
+  export -format csv|json <dir>  выгрузка данных в открытом виде (требует подтверждения)
+  agent                          запуск локального агента
+  unlock                         разблокировка сессии агента
+  lock                           блокировка сессии агента
+  status                         состояние сессии агента
+  ssh-agent                      SSH агент с ключами хранилища, каждое использование ключа требует подтверждения
+
+Логин и мастер пароль читаются из переменных окружения GOPHKEEPER_LOGIN и GOPHKEEPER_PASSWORD.
+С опцией -password-stdin мастер пароль читается из первой строки стандартного потока ввода.
+Секрет сохраняемых данных (пароль, текст, "номер CVV" карты, пароль учетной записи, парольная фраза SSH ключа)
+читается из переменной окружения GOPHKEEPER_SECRET, а с опцией -secret-stdin - из оставшейся части стандартного потока ввода.
+Парольная фраза архива читается из переменной окружения GOPHKEEPER_BACKUP_PASSPHRASE,
+а с опцией -passphrase-stdin - из следующей строки стандартного потока ввода.
+Если мастер пароль не задан, команды list, get и ssh-agent получают данные от разблокированного агента.
+Общие записи и коллекции организаций расшифровываются ключевой парой пользователя, поэтому доступны только
+с мастер паролем и в режиме онлайн.
+Без команды клиент запускает TUI.
+`
+
+// ErrUnknownCommand - ошибка вызова неизвестной команды.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// CLI - неинтерактивный клиент. Каждая команда выполняется как отдельная сессия пользователя.
+type CLI struct {
+	addr       string
+	stor       storage.IEncryptedClientStorage
+	ident      identity.ClientIdentifier
+	remover    identity.AccountRemover
+	info       identity.IUserInfoStorage
+	client     *resty.Client // клиент для входа пользователя
+	authClient *resty.Client // клиент с авторизационными мидлварями для работы с данными
+	attachURLs attachment.URLs
+
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// New - фабричная функция неинтерактивного клиента. addr - адрес сервера, remover - локальное хранилище, из которого
+// удаляется пользователь после удаления учетной записи, in и out - потоки ввода и вывода команд.
+func New(addr string, stor storage.IEncryptedClientStorage, ident identity.ClientIdentifier, remover identity.AccountRemover,
+	info identity.IUserInfoStorage, client *resty.Client, in io.Reader, out io.Writer) *CLI {
+
+	// Копирую resty клиента и устанавливаю мидлвари для запросов, которые требуют, чтобы пользователь был авторизирован
+	authClient := *client
+	authClient.OnBeforeRequest(auth.OnBeforeMiddleware(info, ident))
+	authClient.OnAfterResponse(auth.OnAfterMiddleware(info, ident, addr+api.AuthorizationPattern))
+
+	return &CLI{
+		addr:       addr,
+		stor:       stor,
+		ident:      ident,
+		remover:    remover,
+		info:       info,
+		client:     client,
+		authClient: &authClient,
+		attachURLs: attachment.NewURLs(addr),
+		in:         bufio.NewReader(in),
+		out:        out,
+	}
+}
+
+// Run - выполняет команду клиента. args - имя команды и ее аргументы.
+func (c *CLI) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w, command is not set", ErrUnknownCommand)
+	}
+
+	commands := map[string]func(ctx context.Context, args []string) error{
+		"register": c.register,
+		"login":    c.login,
+		"list":     c.list,
+		"get":      c.get,
+		"add":      c.add,
+		"edit":     c.edit,
+		"rm":       c.rm,
+		"sync":     c.sync,
+		"audit":    c.audit,
+
+		"share":  c.share,
+		"shares": c.shares,
+		"accept": c.accept,
+		"revoke": c.revoke,
+
+		"org": c.organization,
+
+		"delete-account": c.deleteAccount,
+
+		"import": c.importData,
+		"export": c.exportData,
+
+		"agent":  c.agent,
+		"unlock": c.unlock,
+		"lock":   c.lock,
+		"status": c.status,
+
+		"ssh-agent": c.sshAgent,
+	}
+	if args[0] == "help" {
+		_, err := io.WriteString(c.out, Usage)
+		return err
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("%w %s", ErrUnknownCommand, args[0])
+	}
+	return command(ctx, args[1:])
+}
+
+// options - общие опции команд.
+type options struct {
+	format        string // формат вывода
+	user          string // логин пользователя
+	passwordStdin bool   // читать мастер пароль из стандартного потока ввода
+	socket        string // путь к сокету агента
+}
+
+// newFlagSet - функция для создания набора флагов команды с общими опциями.
+func newFlagSet(name string, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&opts.format, "o", Plain, "output format: plain or json")
+	fs.StringVar(&opts.user, "user", "", "user login, default from "+LoginEnv)
+	fs.BoolVar(&opts.passwordStdin, "password-stdin", false, "read master password from the first line of stdin")
+	fs.StringVar(&opts.socket, "socket", agent.SocketPath(), "path to agent socket, default from "+agent.SocketEnv)
+	return fs
+}
+
+// parse - функция для разбора аргументов команды. Флаги могут располагаться как до, так и после позиционных аргументов.
+func parse(fs *flag.FlagSet, opts *options, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, fmt.Errorf("%s: %w", fs.Name(), err)
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if opts.format != Plain && opts.format != JSON {
+		return nil, fmt.Errorf("%s: unknown output format %s", fs.Name(), opts.format)
+	}
+	return positional, nil
+}
+
+// credentials - функция для получения логина и мастер пароля пользователя.
+func (c *CLI) credentials(opts *options) (*identity.AuthData, error) {
+	login := opts.user
+	if login == "" {
+		login = os.Getenv(LoginEnv)
+	}
+	if login == "" {
+		return nil, fmt.Errorf("login is not set, use -user or %s", LoginEnv)
+	}
+
+	var password string
+	if opts.passwordStdin {
+		line, err := c.in.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read password from stdin, %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	} else {
+		password = os.Getenv(PasswordEnv)
+	}
+	if password == "" {
+		return nil, fmt.Errorf("password is not set, use -password-stdin or %s", PasswordEnv)
+	}
+	return &identity.AuthData{Login: login, Password: password}, nil
+}
+
+// hasPassword - функция для проверки, задан ли мастер пароль для команды.
+func hasPassword(opts *options) bool {
+	return opts.passwordStdin || os.Getenv(PasswordEnv) != ""
+}
+
+// secret - функция для получения секрета сохраняемых данных из оставшейся части стандартного потока ввода
+// или из переменной окружения.
+func (c *CLI) secret(fromStdin bool) (string, error) {
+	if !fromStdin {
+		return os.Getenv(SecretEnv), nil
+	}
+	b, err := io.ReadAll(c.in)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret from stdin, %w", err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// authorize - функция для авторизации пользователя по локальному хранилищу. Возвращает мастер пароль и id пользователя.
+// Пользователь должен предварительно выполнить вход с данного устройства командой login или через TUI.
+func (c *CLI) authorize(ctx context.Context, opts *options) (string, string, error) {
+	authData, err := c.credentials(opts)
+	if err != nil {
+		return "", "", err
+	}
+	passOK, registered, err := handlers.Authorize(ctx, authData, c.ident, c.info)
+	if err != nil {
+		return "", "", fmt.Errorf("authorization error, %w", err)
+	}
+	if !registered {
+		return "", "", fmt.Errorf("user %s has not logged in on this device, run login first", authData.Login)
+	}
+	if !passOK {
+		return "", "", fmt.Errorf("wrong password of user %s", authData.Login)
+	}
+	_, id := c.info.Get()
+	return authData.Password, id, nil
+}
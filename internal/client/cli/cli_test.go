@@ -0,0 +1,915 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/agent"
+	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/seal"
+	"github.com/abezemskiy/gophkeeper/internal/client/handlers"
+	"github.com/abezemskiy/gophkeeper/internal/client/identity"
+	"github.com/abezemskiy/gophkeeper/internal/client/orgs"
+	"github.com/abezemskiy/gophkeeper/internal/client/plaintext"
+	"github.com/abezemskiy/gophkeeper/internal/client/sharing"
+	clientData "github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/info"
+	clientMemory "github.com/abezemskiy/gophkeeper/internal/client/storage/memory"
+	"github.com/abezemskiy/gophkeeper/internal/client/totp"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/share"
+	"github.com/abezemskiy/gophkeeper/internal/testutil"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	sshAgent "golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	testLogin    = "cli login"
+	testPassword = "cli password"
+)
+
+// device - локальное хранилище устройства пользователя, общее для всех запусков команд на этом устройстве.
+type device struct {
+	addr string
+	stor *clientMemory.Store
+}
+
+// run - выполняет команду клиента на устройстве, как при отдельном запуске процесса. Возвращает вывод команды.
+func (d *device) run(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	c := New(d.addr, d.stor, d.stor, d.stor, info.NewUserInfoStorage(), resty.New(), strings.NewReader(stdin), &out)
+	err := c.Run(context.Background(), args)
+	return out.String(), err
+}
+
+// setup - запускает тестовый сервер и регистрирует пользователя на первом устройстве.
+func setup(t *testing.T) (*device, *device) {
+	t.Helper()
+	srv := testutil.NewServer(t)
+	// Закрепленные ключи получателей сохраняются в каталоге настроек пользователя
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	first := &device{addr: srv.URL, stor: clientMemory.NewStore()}
+	ok, err := handlers.Register(context.Background(), srv.URL+api.RegisterPattern,
+		&identity.AuthData{Login: testLogin, Password: testPassword}, resty.New(), first.stor)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	return first, &device{addr: srv.URL, stor: clientMemory.NewStore()}
+}
+
+func TestRun(t *testing.T) {
+	first, second := setup(t)
+	t.Setenv(LoginEnv, testLogin)
+	t.Setenv(PasswordEnv, testPassword)
+
+	// Добавление данных всех типов
+	t.Setenv(SecretEnv, "db password")
+	out, err := first.run(t, "", "add", "password", "-name", "db", "-login", "admin", "-meta", "database",
+		"-tags", "work, servers", "-folder", "/work/db/", "-favorite")
+	require.NoError(t, err)
+	assert.Equal(t, "saved: db\n", out)
+
+	_, err = first.run(t, "note line 1\nnote line 2\n", "add", "text", "-name", "note", "-secret-stdin")
+	require.NoError(t, err)
+
+	_, err = first.run(t, "4111111111111111 123", "add", "card", "-name", "card", "-month", "12", "-year", "30",
+		"-owner", "IVAN IVANOV", "-secret-stdin", "-tags", "work", "-folder", "work")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte("file content"), 0600))
+	_, err = first.run(t, "", "add", "file", "-name", "file", "-path", path)
+	require.NoError(t, err)
+
+	// Повторное добавление данных с тем же именем
+	_, err = first.run(t, "", "add", "password", "-name", "db")
+	assert.Error(t, err)
+
+	// Список данных в формате JSON
+	out, err = first.run(t, "", "list", "-o", "json")
+	require.NoError(t, err)
+	var entries []Entry
+	require.NoError(t, json.Unmarshal([]byte(out), &entries))
+	assert.ElementsMatch(t, []Entry{
+		{Name: "db", Type: "password", Versions: 1, Metainfo: "database", Tags: []string{"servers", "work"}, Folder: "work/db", Favorite: true},
+		{Name: "note", Type: "text", Versions: 1},
+		{Name: "card", Type: "card", Versions: 1, Tags: []string{"work"}, Folder: "work"},
+		{Name: "file", Type: "file", Versions: 1},
+	}, entries)
+
+	// Фильтры списка по тегам, папке и избранному
+	filters := []struct {
+		args []string
+		want []string
+	}{
+		{args: []string{"-tag", "WORK"}, want: []string{"db", "card"}},
+		{args: []string{"-tag", "work,servers"}, want: []string{"db"}},
+		{args: []string{"-folder", "work"}, want: []string{"db", "card"}},
+		{args: []string{"-folder", "work/db"}, want: []string{"db"}},
+		{args: []string{"-folder", "wor"}, want: nil},
+		{args: []string{"-favorite"}, want: []string{"db"}},
+	}
+	for _, f := range filters {
+		out, err = first.run(t, "", append([]string{"list", "-o", "json"}, f.args...)...)
+		require.NoError(t, err)
+		var filtered []Entry
+		require.NoError(t, json.Unmarshal([]byte(out), &filtered))
+		var names []string
+		for _, e := range filtered {
+			names = append(names, e.Name)
+		}
+		assert.ElementsMatch(t, f.want, names, f.args)
+	}
+
+	// Значения отдельных полей, флаги могут следовать за именем данных
+	out, err = first.run(t, "", "get", "db", "-field", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "db password\n", out)
+	out, err = first.run(t, "", "get", "-field", "text", "note")
+	require.NoError(t, err)
+	assert.Equal(t, "note line 1\nnote line 2\n", out)
+	out, err = first.run(t, "", "get", "card", "-field", "cvv")
+	require.NoError(t, err)
+	assert.Equal(t, "123\n", out)
+
+	// Данные в формате JSON
+	out, err = first.run(t, "", "get", "db", "-o", "json")
+	require.NoError(t, err)
+	var records []struct {
+		Name    string `json:"name"`
+		Type    string `json:"type"`
+		Version int    `json:"version"`
+		Data    struct {
+			Login    string `json:"login"`
+			Password string `json:"password"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &records))
+	require.Len(t, records, 1)
+	assert.Equal(t, "password", records[0].Type)
+	assert.Equal(t, 1, records[0].Version)
+	assert.Equal(t, "admin", records[0].Data.Login)
+	assert.Equal(t, "db password", records[0].Data.Password)
+
+	// Сохранение файла на диск
+	restored := filepath.Join(t.TempDir(), "restored.txt")
+	_, err = first.run(t, "", "get", "file", "-file", restored)
+	require.NoError(t, err)
+	content, err := os.ReadFile(restored)
+	require.NoError(t, err)
+	assert.Equal(t, "file content", string(content))
+
+	// Изменение данных
+	t.Setenv(SecretEnv, "new password")
+	out, err = first.run(t, "", "edit", "password", "-name", "db", "-login", "root", "-o", "json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":"replaced","name":"db"}`, out)
+	_, err = first.run(t, "", "edit", "password", "-name", "missing")
+	assert.Error(t, err)
+
+	// Удаление данных
+	_, err = first.run(t, "", "rm", "note")
+	require.NoError(t, err)
+	_, err = first.run(t, "", "get", "note")
+	assert.Error(t, err)
+
+	// Вход со второго устройства с мастер паролем из потока ввода и синхронизация
+	t.Setenv(PasswordEnv, "")
+	_, err = second.run(t, "", "list")
+	assert.Error(t, err, "user must login on new device first")
+	out, err = second.run(t, testPassword+"\n", "login", "-password-stdin")
+	require.NoError(t, err)
+	assert.Equal(t, "logged in: "+testLogin+"\n", out)
+	out, err = second.run(t, testPassword+"\n", "sync", "-password-stdin")
+	require.NoError(t, err)
+	assert.Equal(t, "synchronized\n", out)
+	out, err = second.run(t, testPassword+"\n", "get", "db", "-field", "login", "-password-stdin")
+	require.NoError(t, err)
+	assert.Equal(t, "root\n", out)
+
+	// Журнал аудита содержит регистрацию пользователя и вход со второго устройства
+	out, err = second.run(t, testPassword+"\n", "audit", "-o", "json", "-password-stdin")
+	require.NoError(t, err)
+	var events []audit.Event
+	require.NoError(t, json.Unmarshal([]byte(out), &events))
+	require.NotEmpty(t, events)
+	assert.Equal(t, audit.Register, events[len(events)-1].Type)
+	assert.Equal(t, audit.LoginSuccess, events[0].Type)
+	out, err = second.run(t, testPassword+"\n", "audit", "-limit", "1", "-since", "1h", "-password-stdin")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "TIME"))
+	assert.Contains(t, lines[1], audit.LoginSuccess)
+
+	// Неверный мастер пароль
+	_, err = second.run(t, "wrong password\n", "list", "-password-stdin")
+	assert.Error(t, err)
+}
+
+func TestAgentCommands(t *testing.T) {
+	first, _ := setup(t)
+	t.Setenv(LoginEnv, testLogin)
+	t.Setenv(PasswordEnv, testPassword)
+	t.Setenv(SecretEnv, "db password")
+	_, err := first.run(t, "", "add", "password", "-name", "db", "-login", "admin")
+	require.NoError(t, err)
+
+	// Путь к Unix-сокету ограничен по длине, поэтому использую короткую временную директорию
+	dir, err := os.MkdirTemp("", "gk")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	t.Setenv(agent.SocketEnv, filepath.Join(dir, "agent.sock"))
+
+	// Команды агента без запущенного агента
+	_, err = first.run(t, "", "status")
+	assert.ErrorIs(t, err, agent.ErrNotRunning)
+
+	// Запуск агента командой agent
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		c := New(first.addr, first.stor, first.stor, first.stor, info.NewUserInfoStorage(), resty.New(), strings.NewReader(""), io.Discard)
+		done <- c.Run(ctx, []string{"agent", "-idle", "1h"})
+	}()
+	defer func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}()
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(agent.TokenPath(agent.SocketPath()))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	out, err := first.run(t, "", "status")
+	require.NoError(t, err)
+	assert.Equal(t, "locked\n", out)
+
+	// Без мастер пароля данные запрашиваются у агента
+	t.Setenv(PasswordEnv, "")
+	_, err = first.run(t, "", "get", "db", "-field", "password")
+	assert.ErrorIs(t, err, agent.ErrLocked)
+
+	out, err = first.run(t, testPassword+"\n", "unlock", "-password-stdin")
+	require.NoError(t, err)
+	assert.Equal(t, "unlocked: "+testLogin+"\n", out)
+	out, err = first.run(t, "", "status", "-o", "json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":"unlocked","name":"`+testLogin+`"}`, out)
+
+	out, err = first.run(t, "", "get", "db", "-field", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "db password\n", out)
+	out, err = first.run(t, "", "list")
+	require.NoError(t, err)
+	assert.Contains(t, out, "db")
+
+	_, err = first.run(t, "", "lock")
+	require.NoError(t, err)
+	_, err = first.run(t, "", "list")
+	assert.ErrorIs(t, err, agent.ErrLocked)
+}
+
+func TestImport(t *testing.T) {
+	first, second := setup(t)
+	t.Setenv(LoginEnv, testLogin)
+	t.Setenv(PasswordEnv, testPassword)
+
+	t.Setenv(SecretEnv, "old password")
+	_, err := first.run(t, "", "add", "password", "-name", "github", "-login", "old")
+	require.NoError(t, err)
+
+	export := filepath.Join(t.TempDir(), "bitwarden.json")
+	require.NoError(t, os.WriteFile(export, []byte(`{"encrypted": false, "items": [
+		{"type": 1, "name": "github", "login": {"username": "octocat", "password": "hunter2"}},
+		{"type": 2, "name": "recovery", "notes": "1111 2222"},
+		{"type": 3, "name": "broken", "card": {"number": "x"}}
+	]}`), 0600))
+
+	// Предпросмотр не изменяет данные
+	out, err := first.run(t, "", "import", "-format", "bitwarden-json", "-dry-run", "-o", "json", export)
+	require.NoError(t, err)
+	var report ImportReport
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	assert.Equal(t, ImportReport{
+		DryRun: true,
+		Items: []ImportItem{
+			{Op: "skip", Name: "github", Type: "password"},
+			{Op: "add", Name: "recovery", Type: "text"},
+		},
+		Warnings: report.Warnings,
+		Imported: 1,
+	}, report)
+	assert.Len(t, report.Warnings, 1)
+	out, err = first.run(t, "", "get", "recovery")
+	assert.Error(t, err, out)
+
+	// Импорт с переименованием, файл экспорта читается из стандартного потока ввода после мастер пароля
+	content, err := os.ReadFile(export)
+	require.NoError(t, err)
+	t.Setenv(PasswordEnv, "")
+	out, err = first.run(t, testPassword+"\n"+string(content), "import", "-format", "bitwarden-json", "-policy", "rename",
+		"-password-stdin", "-")
+	require.NoError(t, err)
+	assert.Contains(t, out, "imported: 2 of 2\n")
+	t.Setenv(PasswordEnv, testPassword)
+
+	out, err = first.run(t, "", "get", "github (2)", "-field", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2\n", out)
+	out, err = first.run(t, "", "get", "github", "-field", "login")
+	require.NoError(t, err)
+	assert.Equal(t, "old\n", out)
+
+	// Импорт с заменой существующих данных, данные доступны на другом устройстве после синхронизации
+	_, err = first.run(t, "", "import", "-format", "bitwarden-json", "-policy", "overwrite", export)
+	require.NoError(t, err)
+	_, err = second.run(t, "", "login")
+	require.NoError(t, err)
+	_, err = second.run(t, "", "sync")
+	require.NoError(t, err)
+	out, err = second.run(t, "", "get", "github", "-field", "login")
+	require.NoError(t, err)
+	assert.Equal(t, "octocat\n", out)
+	out, err = second.run(t, "", "get", "recovery", "-field", "text")
+	require.NoError(t, err)
+	assert.Equal(t, "1111 2222\n", out)
+}
+
+func TestExport(t *testing.T) {
+	first, _ := setup(t)
+	t.Setenv(LoginEnv, testLogin)
+	t.Setenv(PasswordEnv, testPassword)
+	t.Setenv(PassphraseEnv, "backup passphrase")
+
+	t.Setenv(SecretEnv, "db password")
+	_, err := first.run(t, "", "add", "password", "-name", "db", "-login", "admin")
+	require.NoError(t, err)
+	large := bytes.Repeat([]byte("large file "), repoData.ChunkSize/5)
+	path := filepath.Join(t.TempDir(), "large.bin")
+	require.NoError(t, os.WriteFile(path, large, 0600))
+	_, err = first.run(t, "", "add", "file", "-name", "large", "-path", path)
+	require.NoError(t, err)
+
+	archive := filepath.Join(t.TempDir(), "vault.gkb")
+	out, err := first.run(t, "", "export", archive)
+	require.NoError(t, err)
+	assert.Equal(t, "exported: 2 records, 1 files to "+archive+"\n", out)
+	content, err := os.ReadFile(archive)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "db password")
+
+	// Выгрузка в открытом виде требует подтверждения
+	dump := filepath.Join(t.TempDir(), "dump")
+	out, err = first.run(t, "yes\n", "export", "-format", "csv", dump)
+	assert.ErrorIs(t, err, plaintext.ErrNotConfirmed)
+	assert.Contains(t, out, "UNENCRYPTED")
+	assert.NoDirExists(t, dump)
+
+	out, err = first.run(t, plaintext.Confirmation+"\n", "export", "-format", "csv", dump)
+	require.NoError(t, err)
+	assert.Contains(t, out, "exported UNENCRYPTED: 2 records, 1 files")
+	csvContent, err := os.ReadFile(filepath.Join(dump, "export.csv"))
+	require.NoError(t, err)
+	assert.Contains(t, string(csvContent), "admin,db password")
+	got, err := os.ReadFile(filepath.Join(dump, "files", "large.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, large, got)
+
+	out, err = first.run(t, "", "export", "-format", "json", "-confirm", plaintext.Confirmation, "-o", "json", dump)
+	require.NoError(t, err)
+	var stats struct {
+		Records int    `json:"records"`
+		Warning string `json:"warning"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &stats))
+	assert.Equal(t, 2, stats.Records)
+	assert.Equal(t, plaintext.Warning, stats.Warning)
+
+	// Восстановление в новую учетную запись с другим мастер паролем
+	fresh := &device{addr: first.addr, stor: clientMemory.NewStore()}
+	t.Setenv(LoginEnv, "fresh login")
+	t.Setenv(PasswordEnv, "fresh password")
+	_, err = fresh.run(t, "", "register")
+	require.NoError(t, err)
+
+	t.Setenv(PassphraseEnv, "wrong passphrase")
+	_, err = fresh.run(t, "", "import", "-format", "gophkeeper", archive)
+	assert.ErrorContains(t, err, "wrong passphrase")
+
+	t.Setenv(PassphraseEnv, "")
+	out, err = fresh.run(t, "backup passphrase\n", "import", "-format", "gophkeeper", "-passphrase-stdin", "-o", "json", archive)
+	require.NoError(t, err)
+	var report ImportReport
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	assert.Equal(t, 2, report.Imported)
+
+	out, err = fresh.run(t, "", "get", "db", "-field", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "db password\n", out)
+	restored := filepath.Join(t.TempDir(), "restored.bin")
+	_, err = fresh.run(t, "", "get", "large", "-file", restored)
+	require.NoError(t, err)
+	got, err = os.ReadFile(restored)
+	require.NoError(t, err)
+	assert.Equal(t, large, got)
+
+	// Повторное восстановление с переименованием записей
+	t.Setenv(PassphraseEnv, "backup passphrase")
+	out, err = fresh.run(t, "", "import", "-format", "gophkeeper", "-policy", "rename", archive)
+	require.NoError(t, err)
+	assert.Contains(t, out, "large (2)")
+}
+
+func TestLoginData(t *testing.T) {
+	first, _ := setup(t)
+	t.Setenv(LoginEnv, testLogin)
+	t.Setenv(PasswordEnv, testPassword)
+	t.Setenv(SecretEnv, "vpn password")
+
+	const secret = "JBSWY3DPEHPK3PXP"
+	_, err := first.run(t, "", "add", "login", "-name", "vpn", "-login", "ops", "-url", "https://vpn.example.com",
+		"-url", "https://backup.example.com", "-notes", "office", "-totp", secret, "-field", "hidden:pin=1234",
+		"-field", "boolean:admin=1")
+	require.NoError(t, err)
+
+	out, err := first.run(t, "", "get", "vpn", "-field", "totp")
+	require.NoError(t, err)
+	code, _, err := totp.Now(secret)
+	require.NoError(t, err)
+	// Код мог смениться между вызовами на границе периода
+	if strings.TrimSpace(out) != code {
+		time.Sleep(time.Second)
+		code, _, err = totp.Now(secret)
+		require.NoError(t, err)
+		out, err = first.run(t, "", "get", "vpn", "-field", "totp")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, code+"\n", out)
+
+	out, err = first.run(t, "", "get", "vpn", "-field", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, "true\n", out)
+
+	out, err = first.run(t, "", "get", "vpn", "-o", "json")
+	require.NoError(t, err)
+	var records []struct {
+		Type string           `json:"type"`
+		Data clientData.Login `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &records))
+	require.Len(t, records, 1)
+	assert.Equal(t, "login", records[0].Type)
+	assert.Equal(t, clientData.Login{
+		Login:    "ops",
+		Password: "vpn password",
+		URLs:     []string{"https://vpn.example.com", "https://backup.example.com"},
+		Notes:    "office",
+		TOTP:     secret,
+		Fields: []clientData.Field{
+			{Name: "pin", Value: "1234", Type: clientData.FieldHidden},
+			{Name: "admin", Value: "true", Type: clientData.FieldBoolean},
+		},
+	}, records[0].Data)
+
+	// Некорректный секрет TOTP и дополнительное поле
+	_, err = first.run(t, "", "add", "login", "-name", "bad", "-totp", "not a secret!")
+	assert.Error(t, err)
+	_, err = first.run(t, "", "add", "login", "-name", "bad", "-field", "boolean:admin=maybe")
+	assert.Error(t, err)
+}
+
+func TestSSHKeyData(t *testing.T) {
+	first, _ := setup(t)
+	t.Setenv(LoginEnv, testLogin)
+	t.Setenv(PasswordEnv, testPassword)
+	t.Setenv(SecretEnv, "key passphrase")
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte("key passphrase"))
+	require.NoError(t, err)
+	sshPub, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+
+	// Путь к Unix-сокету ограничен по длине, поэтому использую короткую временную директорию
+	dir, err := os.MkdirTemp("", "gk")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	keyPath := filepath.Join(dir, "id_ed25519")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(block), 0600))
+
+	_, err = first.run(t, "", "add", "ssh", "-name", "github", "-key", keyPath, "-comment", "user@host")
+	require.NoError(t, err)
+	out, err := first.run(t, "", "get", "github", "-field", "fingerprint")
+	require.NoError(t, err)
+	assert.Equal(t, ssh.FingerprintSHA256(sshPub)+"\n", out)
+	out, err = first.run(t, "", "list")
+	require.NoError(t, err)
+	assert.Contains(t, out, "ssh")
+
+	// Неверная парольная фраза и отсутствующий ключ
+	t.Setenv(SecretEnv, "wrong")
+	_, err = first.run(t, "", "add", "ssh", "-name", "bad", "-key", keyPath)
+	assert.Error(t, err)
+	_, err = first.run(t, "", "add", "ssh", "-name", "bad")
+	assert.Error(t, err)
+
+	// startSSHAgent - запускает SSH агента с программой подтверждения askpass и возвращает клиента ssh-agent
+	startSSHAgent := func(t *testing.T, askpass string) sshAgent.ExtendedAgent {
+		socket := filepath.Join(dir, askpass+".sock")
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			c := New(first.addr, first.stor, first.stor, first.stor, info.NewUserInfoStorage(), resty.New(), strings.NewReader(""), io.Discard)
+			done <- c.Run(ctx, []string{"ssh-agent", "-ssh-socket", socket, "-askpass", askpass})
+		}()
+		var conn net.Conn
+		require.Eventually(t, func() bool {
+			conn, err = net.Dial("unix", socket)
+			return err == nil
+		}, 5*time.Second, 10*time.Millisecond)
+		t.Cleanup(func() {
+			conn.Close()
+			cancel()
+			assert.NoError(t, <-done)
+		})
+		return sshAgent.NewClient(conn)
+	}
+
+	message := []byte("session")
+	t.Run("confirmed", func(t *testing.T) {
+		client := startSSHAgent(t, "true")
+		keys, err := client.List()
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.Equal(t, "user@host", keys[0].Comment)
+		sig, err := client.Sign(keys[0], message)
+		require.NoError(t, err)
+		assert.NoError(t, sshPub.Verify(message, sig))
+	})
+	t.Run("denied", func(t *testing.T) {
+		client := startSSHAgent(t, "false")
+		_, err := client.Sign(sshPub, message)
+		assert.Error(t, err)
+	})
+}
+
+func TestDeleteAccount(t *testing.T) {
+	first, second := setup(t)
+	t.Setenv(LoginEnv, testLogin)
+	t.Setenv(PasswordEnv, testPassword)
+	t.Setenv(SecretEnv, "db password")
+
+	_, err := first.run(t, "", "add", "password", "-name", "db")
+	require.NoError(t, err)
+	_, err = second.run(t, "", "login")
+	require.NoError(t, err)
+
+	// Удаление требует подтверждения логином
+	_, err = first.run(t, "", "delete-account")
+	assert.ErrorIs(t, err, ErrAccountNotConfirmed)
+	_, err = first.run(t, "other login\n", "delete-account")
+	assert.ErrorIs(t, err, ErrAccountNotConfirmed)
+	t.Setenv(PasswordEnv, "wrong password")
+	_, err = first.run(t, testLogin+"\n", "delete-account")
+	assert.Error(t, err)
+	t.Setenv(PasswordEnv, testPassword)
+
+	out, err := first.run(t, testLogin+"\n", "delete-account")
+	require.NoError(t, err)
+	assert.Contains(t, out, "account deleted: "+testLogin)
+
+	// Пользователь удален с устройства и с сервера
+	_, ok, err := first.stor.Authorize(context.Background(), testLogin)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, err = first.run(t, "", "list")
+	assert.Error(t, err)
+	third := &device{addr: first.addr, stor: clientMemory.NewStore()}
+	_, err = third.run(t, "", "login")
+	assert.Error(t, err)
+
+	// Логин освобождается для новой регистрации, данные удаленной учетной записи не восстанавливаются
+	out, err = first.run(t, "", "register", "-o", "json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"status":"registered","name":"`+testLogin+`"}`, out)
+	out, err = first.run(t, "", "list", "-o", "json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[]`, out)
+}
+
+func TestShare(t *testing.T) {
+	first, second := setup(t)
+	t.Setenv(LoginEnv, testLogin)
+	t.Setenv(PasswordEnv, testPassword)
+	t.Setenv(SecretEnv, "db password")
+
+	_, err := first.run(t, "", "add", "password", "-name", "db", "-login", "admin", "-tags", "work")
+	require.NoError(t, err)
+	_, err = second.run(t, "", "register", "-user", "bob")
+	require.NoError(t, err)
+
+	// Поделиться можно только с пользователем, у которого есть ключевая пара
+	_, err = first.run(t, "", "share", "db", "-to", "bob")
+	assert.True(t, api.IsCode(err, api.CodeKeyNotFound))
+	out, err := second.run(t, "", "list", "-user", "bob", "-o", "json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[]`, out)
+
+	out, err = first.run(t, "", "share", "db", "-to", "bob", "-write", "-o", "json")
+	require.NoError(t, err)
+	var shares []struct {
+		ID         string `json:"id"`
+		Name       string `json:"name"`
+		Owner      string `json:"owner"`
+		Recipient  string `json:"recipient"`
+		Permission string `json:"permission"`
+		Accepted   bool   `json:"accepted"`
+		Key        string `json:"recipient_key"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &shares))
+	require.Len(t, shares, 1)
+	id := shares[0].ID
+	assert.Equal(t, "db", shares[0].Name)
+	assert.Equal(t, testLogin, shares[0].Owner)
+	assert.Equal(t, "bob", shares[0].Recipient)
+	assert.Equal(t, "write", shares[0].Permission)
+	assert.False(t, shares[0].Accepted)
+	assert.True(t, strings.HasPrefix(shares[0].Key, "SHA256:"), shares[0].Key)
+
+	// Запись появляется в списке получателя после принятия, теги владельца получателю не передаются
+	out, err = second.run(t, "", "accept", id, "-user", "bob")
+	require.NoError(t, err)
+	assert.Equal(t, "accepted: "+id+"\n", out)
+	out, err = second.run(t, "", "list", "-user", "bob", "-o", "json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"name":"db","type":"password","versions":1,"metainfo":"","share":"`+id+`","owner":"`+testLogin+`","permission":"write"}]`, out)
+	out, err = second.run(t, "", "list", "-user", "bob")
+	require.NoError(t, err)
+	assert.Contains(t, out, "db@"+testLogin+" (write)")
+	out, err = second.run(t, "", "get", "-share", id, "-user", "bob", "-field", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "db password\n", out)
+
+	// Изменение получателя попадает к владельцу при синхронизации, организация данных владельца сохраняется
+	t.Setenv(SecretEnv, "changed by bob")
+	out, err = second.run(t, "", "edit", "password", "-share", id, "-user", "bob", "-login", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, "replaced: db\n", out)
+	_, err = first.run(t, "", "sync")
+	require.NoError(t, err)
+	out, err = first.run(t, "", "get", "db", "-field", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "changed by bob\n", out)
+	out, err = first.run(t, "", "list", "-tag", "work", "-o", "json")
+	require.NoError(t, err)
+	assert.Contains(t, out, `"name":"db"`)
+
+	// Владелец отзывает запись, и она становится недоступна получателю
+	out, err = first.run(t, "", "shares")
+	require.NoError(t, err)
+	assert.Contains(t, out, id)
+	_, err = first.run(t, "", "revoke", id)
+	require.NoError(t, err)
+	_, err = second.run(t, "", "get", "-share", id, "-user", "bob")
+	assert.Error(t, err)
+	out, err = second.run(t, "", "shares", "-user", "bob", "-o", "json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[]`, out)
+
+	// Ключ получателя закреплен при первой передаче: измененный ключ принимается только с подтверждением
+	bobInfo, ok, err := second.stor.Authorize(context.Background(), "bob")
+	require.NoError(t, err)
+	require.True(t, ok)
+	public, _, err := seal.GenerateKeyPair()
+	require.NoError(t, err)
+	resp, err := resty.New().SetAuthToken(bobInfo.Token).R().SetHeader("Content-Type", "application/json").
+		SetBody(share.KeyPair{PublicKey: public, EncryptedPrivateKey: []byte("private")}).
+		Put(sharing.NewURLs(first.addr).Keys)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode())
+
+	_, err = first.run(t, "", "share", "db", "-to", "bob")
+	require.ErrorIs(t, err, sharing.ErrKeyChanged)
+	assert.Contains(t, err.Error(), "-accept-key")
+	out, err = first.run(t, "", "share", "db", "-to", "bob", "-accept-key")
+	require.NoError(t, err)
+	assert.Contains(t, out, "recipient key: "+sharing.Fingerprint(public)+"\n")
+}
+
+func TestOrg(t *testing.T) {
+	first, second := setup(t)
+	t.Setenv(LoginEnv, testLogin)
+	t.Setenv(PasswordEnv, testPassword)
+	t.Setenv(SecretEnv, "db password")
+
+	// Участник должен создать ключевую пару, она создается при первом получении общих записей
+	_, err := second.run(t, "", "register", "-user", "bob")
+	require.NoError(t, err)
+	out, err := first.run(t, "", "org", "create", "team", "-o", "json")
+	require.NoError(t, err)
+	assert.Contains(t, out, `"name":"team","role":"owner","key_version":1`)
+	_, err = first.run(t, "", "org", "add", "team", "bob", "-role", "editor")
+	assert.True(t, api.IsCode(err, api.CodeKeyNotFound))
+	_, err = second.run(t, "", "list", "-user", "bob")
+	require.NoError(t, err)
+	out, err = first.run(t, "", "org", "add", "team", "bob", "-role", "editor")
+	require.NoError(t, err)
+	assert.Equal(t, "added: bob\n", out)
+
+	// Запись коллекции видна всем участникам рядом с их собственными данными
+	out, err = first.run(t, "", "add", "password", "-name", "db", "-login", "admin", "-org", "team")
+	require.NoError(t, err)
+	assert.Equal(t, "saved: db\n", out)
+	_, err = first.run(t, "", "add", "password", "-name", "db", "-login", "admin", "-org", "team")
+	require.Error(t, err)
+	out, err = second.run(t, "", "list", "-user", "bob", "-o", "json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"name":"db","type":"password","versions":1,"metainfo":"","org":"team","permission":"write"}]`, out)
+	out, err = second.run(t, "", "list", "-user", "bob")
+	require.NoError(t, err)
+	assert.Contains(t, out, "[team] db (write)")
+	out, err = second.run(t, "", "get", "db", "-org", "team", "-user", "bob", "-field", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "db password\n", out)
+
+	// Редактор изменяет запись, читатель не может её удалить
+	t.Setenv(SecretEnv, "changed by bob")
+	out, err = second.run(t, "", "edit", "password", "-name", "db", "-login", "admin", "-org", "team", "-user", "bob")
+	require.NoError(t, err)
+	assert.Equal(t, "replaced: db\n", out)
+	out, err = first.run(t, "", "org", "role", "team", "bob", "viewer")
+	require.NoError(t, err)
+	assert.Equal(t, "role changed to viewer: bob\n", out)
+	out, err = first.run(t, "", "org", "members", "team")
+	require.NoError(t, err)
+	assert.Contains(t, out, "bob")
+	assert.Contains(t, out, "viewer")
+	_, err = second.run(t, "", "rm", "db", "-org", "team", "-user", "bob")
+	assert.True(t, api.IsCode(err, api.CodeOrgForbidden))
+
+	// Удаленный участник теряет доступ к коллекции, записи шифруются новым ключом
+	out, err = first.run(t, "", "org", "remove", "team", "bob")
+	require.NoError(t, err)
+	assert.Equal(t, "removed: bob\n", out)
+	out, err = second.run(t, "", "org", "list", "-user", "bob", "-o", "json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[]`, out)
+	out, err = first.run(t, "", "org", "list")
+	require.NoError(t, err)
+	assert.Contains(t, out, "team")
+	out, err = first.run(t, "", "get", "db", "-org", "team", "-field", "password")
+	require.NoError(t, err)
+	assert.Equal(t, "changed by bob\n", out)
+
+	out, err = first.run(t, "", "rm", "db", "-org", "team")
+	require.NoError(t, err)
+	assert.Equal(t, "deleted: db\n", out)
+	out, err = first.run(t, "", "org", "delete", "team")
+	require.NoError(t, err)
+	assert.Equal(t, "deleted: team\n", out)
+	_, err = first.run(t, "", "org", "members", "team")
+	require.ErrorIs(t, err, orgs.ErrNotFound)
+}
+
+func TestRunErrors(t *testing.T) {
+	first, _ := setup(t)
+	t.Setenv(LoginEnv, testLogin)
+	t.Setenv(PasswordEnv, testPassword)
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "no command", args: nil},
+		{name: "unknown command", args: []string{"unknown"}},
+		{name: "unknown flag", args: []string{"list", "-unknown"}},
+		{name: "unknown output format", args: []string{"list", "-o", "xml"}},
+		{name: "get without name", args: []string{"get"}},
+		{name: "get missing data", args: []string{"get", "missing"}},
+		{name: "add without type", args: []string{"add", "-name", "x"}},
+		{name: "add unknown type", args: []string{"add", "unknown", "-name", "x"}},
+		{name: "add without name", args: []string{"add", "text"}},
+		{name: "add card with bad secret", args: []string{"add", "card", "-name", "x"}},
+		{name: "rm missing data", args: []string{"rm", "missing"}},
+		{name: "import without format", args: []string{"import", "-"}},
+		{name: "import unknown format", args: []string{"import", "-format", "unknown", "-"}},
+		{name: "export without path", args: []string{"export"}},
+		{name: "export without passphrase", args: []string{"export", "archive"}},
+		{name: "register existing user", args: []string{"register"}},
+		{name: "audit with bad since", args: []string{"audit", "-since", "yesterday"}},
+		{name: "audit with negative limit", args: []string{"audit", "-limit", "-1"}},
+		{name: "import unknown policy", args: []string{"import", "-format", "keepass-csv", "-policy", "merge", "-"}},
+		{name: "get with name and share", args: []string{"get", "db", "-share", "id"}},
+		{name: "share without recipient", args: []string{"share", "db"}},
+		{name: "share missing data", args: []string{"share", "missing", "-to", "bob"}},
+		{name: "accept without id", args: []string{"accept"}},
+		{name: "revoke missing share", args: []string{"revoke", "missing"}},
+		{name: "org without action", args: []string{"org"}},
+		{name: "org unknown action", args: []string{"org", "rename", "team"}},
+		{name: "org add without login", args: []string{"org", "add", "team"}},
+		{name: "get missing org data", args: []string{"get", "db", "-org", "missing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := first.run(t, "", tt.args...)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := first.run(t, "", "unknown")
+	assert.ErrorIs(t, err, ErrUnknownCommand)
+
+	// Справка выводится без ошибки
+	out, err := first.run(t, "", "help")
+	require.NoError(t, err)
+	assert.Equal(t, Usage, out)
+}
+
+func TestField(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    repoData.Data
+		field   string
+		want    string
+		wantErr bool
+	}{
+		{name: "password", data: repoData.Data{Type: repoData.PASSWORD, Data: []byte(`{"login":"l","password":"p"}`)},
+			field: "password", want: "p"},
+		{name: "card number", data: repoData.Data{Type: repoData.BANKCARD, Data: []byte(`{"number":4111111111111111}`)},
+			field: "number", want: "4111111111111111"},
+		{name: "inline file size", data: repoData.Data{Type: repoData.BINARY, Data: []byte(`{"binary":"AQID","type":"text/plain"}`)},
+			field: "size", want: "3"},
+		{name: "unknown field", data: repoData.Data{Type: repoData.TEXT, Data: []byte(`{"text":"t"}`)},
+			field: "password", wantErr: true},
+		{name: "login urls", data: repoData.Data{Type: repoData.LOGIN, Data: []byte(`{"login":"l","urls":["a","b"]}`)},
+			field: "urls", want: "a\nb"},
+		{name: "login custom field", data: repoData.Data{Type: repoData.LOGIN,
+			Data: []byte(`{"login":"l","fields":[{"name":"pin","value":"1234","type":"hidden"}]}`)}, field: "pin", want: "1234"},
+		{name: "login invalid totp", data: repoData.Data{Type: repoData.LOGIN, Data: []byte(`{"totp":"!"}`)},
+			field: "login", wantErr: true},
+		{name: "ssh private key", data: repoData.Data{Type: repoData.SSHKEY, Data: []byte(`{"private_key":"key\n","fingerprint":"f"}`)},
+			field: "private_key", want: "key"},
+		{name: "ssh key without passphrase", data: repoData.Data{Type: repoData.SSHKEY, Data: []byte(`{"fingerprint":"f"}`)},
+			field: "passphrase", wantErr: true},
+		{name: "unknown type", data: repoData.Data{Type: 100}, field: "text", wantErr: true},
+		{name: "broken data", data: repoData.Data{Type: repoData.TEXT, Data: []byte(`{`)}, field: "text", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Field(tt.data, tt.field)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "empty", value: "", want: time.Time{}},
+		{name: "duration", value: "24h", want: now.Add(-24 * time.Hour)},
+		{name: "RFC 3339", value: "2024-05-01T00:00:00Z", want: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "invalid", value: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSince(tt.value, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(got))
+		})
+	}
+}
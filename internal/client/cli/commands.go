@@ -0,0 +1,640 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
+	"github.com/abezemskiy/gophkeeper/internal/client/handlers"
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/orgs"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/inmemory"
+	"github.com/abezemskiy/gophkeeper/internal/client/synchronization"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/bankcard"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/binary"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/login"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/organize"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/password"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/sshkey"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/text"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/org"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/share"
+
+	"go.uber.org/zap"
+)
+
+// register - команда для регистрации нового пользователя на сервере. Пользователь регистрируется и на данном устройстве,
+// поэтому повторный вход с него не требуется.
+func (c *CLI) register(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("register", &opts)
+	if _, err := parse(fs, &opts, args); err != nil {
+		return err
+	}
+	authData, err := c.credentials(&opts)
+	if err != nil {
+		return err
+	}
+
+	ok, err := handlers.Register(ctx, c.addr+api.RegisterPattern, authData, c.client, c.ident)
+	if err != nil {
+		return fmt.Errorf("register error, %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("user %s is already registered", authData.Login)
+	}
+	return c.print(opts.format, result{Status: "registered", Name: authData.Login})
+}
+
+// login - команда для входа пользователя с данного устройства через сервер. После входа команды работы с данными
+// доступны в том числе и в режиме офлайн.
+func (c *CLI) login(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("login", &opts)
+	if _, err := parse(fs, &opts, args); err != nil {
+		return err
+	}
+	authData, err := c.credentials(&opts)
+	if err != nil {
+		return err
+	}
+
+	ok, err := handlers.Login(ctx, c.addr+api.AuthorizationPattern, authData, c.client, c.ident, c.info)
+	if err != nil {
+		return fmt.Errorf("login error, %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("server rejected login of user %s", authData.Login)
+	}
+	return c.print(opts.format, result{Status: "logged in", Name: authData.Login})
+}
+
+// list - команда для вывода списка данных пользователя из локального хранилища. Опции -tag, -folder и -favorite
+// ограничивают список данными с указанными тегами, данными из папки (включая вложенные папки) и избранными данными.
+func (c *CLI) list(ctx context.Context, args []string) error {
+	var opts options
+	var tags, folder string
+	var favorite bool
+	fs := newFlagSet("list", &opts)
+	fs.StringVar(&tags, "tag", "", "comma separated tags, all of them must be set on data")
+	fs.StringVar(&folder, "folder", "", "folder of data including subfolders")
+	fs.BoolVar(&favorite, "favorite", false, "list only favorite data")
+	if _, err := parse(fs, &opts, args); err != nil {
+		return err
+	}
+	wantTags, wantFolder := data.ParseTags(tags), data.CleanFolder(folder)
+	all, err := c.decrypt(ctx, &opts)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]Entry, 0, len(all))
+	for _, versions := range all {
+		if len(versions) == 0 {
+			continue
+		}
+		first := versions[0]
+		if (favorite && !first.Favorite) || !data.InFolder(first.Folder, wantFolder) || !data.HasTags(first.Tags, wantTags) {
+			continue
+		}
+		entries = append(entries, Entry{
+			Name:     first.Name,
+			Type:     TypeName(first.Type),
+			Versions: len(versions),
+			Metainfo: first.Metainfo,
+			Tags:     first.Tags,
+			Folder:   first.Folder,
+			Favorite: first.Favorite,
+		})
+	}
+	if !hasPassword(&opts) {
+		return c.printEntries(opts.format, entries)
+	}
+
+	// Добавляю записи, которыми с пользователем поделились другие пользователи
+	authData, _ := c.info.Get()
+	for _, record := range c.received(ctx) {
+		shared := record.Data
+		if favorite || !data.InFolder(shared.Folder, wantFolder) || !data.HasTags(shared.Tags, wantTags) {
+			continue
+		}
+		permission := share.Read
+		if record.Writable(authData.Login) {
+			permission = share.Write
+		}
+		entries = append(entries, Entry{
+			Name:       shared.Name,
+			Type:       TypeName(shared.Type),
+			Versions:   1,
+			Metainfo:   shared.Metainfo,
+			Share:      record.Share.ID,
+			Owner:      record.Share.Owner,
+			Permission: permission,
+		})
+	}
+
+	// Добавляю записи коллекций организаций, участником которых является пользователь
+	for _, item := range c.orgItems(ctx) {
+		if (favorite && !item.Data.Favorite) || !data.InFolder(item.Data.Folder, wantFolder) || !data.HasTags(item.Data.Tags, wantTags) {
+			continue
+		}
+		permission := share.Read
+		if org.CanWrite(item.Org.Role) {
+			permission = share.Write
+		}
+		entries = append(entries, Entry{
+			Name:       item.Data.Name,
+			Type:       TypeName(item.Data.Type),
+			Versions:   1,
+			Metainfo:   item.Data.Metainfo,
+			Tags:       item.Data.Tags,
+			Folder:     item.Data.Folder,
+			Favorite:   item.Data.Favorite,
+			Org:        item.Org.Name,
+			Permission: permission,
+		})
+	}
+	return c.printEntries(opts.format, entries)
+}
+
+// get - команда для вывода данных пользователя по имени. Опция -field выводит значение одного поля без форматирования,
+// опция -file сохраняет файл на диск. Для данных с несколькими версиями эти опции требуют указания версии.
+// С опцией -share выводится общая запись, которой поделился другой пользователь, а с опцией -org - запись коллекции
+// организации.
+func (c *CLI) get(ctx context.Context, args []string) error {
+	var opts options
+	var field, file, shareID, orgName string
+	var version int
+	fs := newFlagSet("get", &opts)
+	fs.StringVar(&field, "field", "", "print only the value of the field")
+	fs.StringVar(&file, "file", "", "save file data to the path")
+	fs.IntVar(&version, "version", 0, "version of data starting with 1, all versions by default")
+	fs.StringVar(&shareID, "share", "", "id of shared data instead of data name")
+	fs.StringVar(&orgName, "org", "", "name or id of organisation to get data from its collection")
+	positional, err := parse(fs, &opts, args)
+	if err != nil {
+		return err
+	}
+
+	var versions []repoData.Data
+	var first int
+	switch {
+	case shareID != "" && len(positional) == 0:
+		if _, _, err := c.authorize(ctx, &opts); err != nil {
+			return err
+		}
+		record, err := c.sharedRecord(ctx, shareID)
+		if err != nil {
+			return err
+		}
+		versions, first = []repoData.Data{record.Data}, 1
+	case shareID == "" && orgName != "" && len(positional) == 1:
+		if _, _, err := c.authorize(ctx, &opts); err != nil {
+			return err
+		}
+		coll, err := c.collection(ctx, orgName)
+		if err != nil {
+			return err
+		}
+		item, err := orgs.FindItem(ctx, c.authClient, orgs.NewURLs(c.addr), coll, positional[0])
+		if err != nil {
+			return fmt.Errorf("get org data error, %w", err)
+		}
+		versions, first = []repoData.Data{item.Data}, 1
+	case shareID == "" && len(positional) == 1:
+		all, err := c.decrypt(ctx, &opts)
+		if err != nil {
+			return err
+		}
+		versions, first, err = find(all, positional[0], version)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("get: either data name or -share must be set")
+	}
+	dataName := versions[0].Name
+
+	if field == "" && file == "" {
+		return c.printRecords(opts.format, versions, first)
+	}
+	if len(versions) > 1 {
+		return fmt.Errorf("data %s has %d versions, set -version", dataName, len(versions))
+	}
+	if file != "" {
+		if err := c.saveFile(ctx, versions[0], file); err != nil {
+			return err
+		}
+		return c.print(opts.format, result{Status: "file saved to " + file, Name: dataName})
+	}
+
+	value, err := Field(versions[0], field)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.out, value)
+	return err
+}
+
+// add - команда для добавления новых данных.
+func (c *CLI) add(ctx context.Context, args []string) error {
+	return c.write(ctx, "add", args, false)
+}
+
+// edit - команда для замены всех версий существующих данных новыми данными.
+func (c *CLI) edit(ctx context.Context, args []string) error {
+	return c.write(ctx, "edit", args, true)
+}
+
+// dataOptions - опции команд добавления и изменения данных.
+type dataOptions struct {
+	name        string
+	metaInfo    string
+	login       string // логин в данных типа password
+	month       int
+	year        int
+	owner       string
+	path        string       // путь к файлу
+	urls        []string     // адреса учетной записи
+	notes       string       // заметки учетной записи
+	totp        string       // секрет TOTP учетной записи
+	fields      []data.Field // дополнительные поля учетной записи
+	key         string       // путь к закрытому SSH ключу
+	cert        string       // путь к сертификату SSH ключа
+	comment     string       // комментарий SSH ключа
+	secretStdin bool
+	organize    organize.Info // теги, папка и отметка избранного
+	share       string        // id изменяемой общей записи
+	org         string        // название или id организации, в коллекцию которой сохраняются данные
+}
+
+// write - функция для добавления или замены данных пользователя. Тип данных передается первым позиционным аргументом.
+func (c *CLI) write(ctx context.Context, name string, args []string, replace bool) error {
+	var opts options
+	var dOpts dataOptions
+	fs := newFlagSet(name, &opts)
+	fs.StringVar(&dOpts.name, "name", "", "data name")
+	fs.StringVar(&dOpts.metaInfo, "meta", "", "data description")
+	fs.StringVar(&dOpts.login, "login", "", "login of password or login data")
+	fs.IntVar(&dOpts.month, "month", 0, "expiration month of card")
+	fs.IntVar(&dOpts.year, "year", 0, "expiration year of card")
+	fs.StringVar(&dOpts.owner, "owner", "", "owner of card")
+	fs.StringVar(&dOpts.path, "path", "", "path to file")
+	fs.Func("url", "URL of login, can be repeated", func(v string) error {
+		dOpts.urls = append(dOpts.urls, v)
+		return nil
+	})
+	fs.StringVar(&dOpts.notes, "notes", "", "notes of login")
+	fs.StringVar(&dOpts.totp, "totp", "", "TOTP secret of login in base32 or otpauth:// URI")
+	fs.Func("field", "custom field of login as [text|hidden|boolean:]name=value, can be repeated", func(v string) error {
+		f, err := data.ParseField(v)
+		if err != nil {
+			return err
+		}
+		dOpts.fields = append(dOpts.fields, f)
+		return nil
+	})
+	fs.StringVar(&dOpts.key, "key", "", "path to SSH private key")
+	fs.StringVar(&dOpts.cert, "cert", "", "path to SSH certificate of the key")
+	fs.StringVar(&dOpts.comment, "comment", "", "comment of SSH key")
+	fs.BoolVar(&dOpts.secretStdin, "secret-stdin", false, "read secret from the rest of stdin, default from "+SecretEnv)
+	fs.StringVar(&dOpts.organize.Tags, "tags", "", "comma separated tags of data")
+	fs.StringVar(&dOpts.organize.Folder, "folder", "", "folder of data, subfolders are separated by /")
+	fs.BoolVar(&dOpts.organize.Favorite, "favorite", false, "mark data as favorite")
+	if replace {
+		fs.StringVar(&dOpts.share, "share", "", "id of shared data to edit instead of own data")
+	}
+	fs.StringVar(&dOpts.org, "org", "", "name or id of organisation to save data to its collection")
+	positional, err := parse(fs, &opts, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("%s: data type must be set: password, text, card, file, login or ssh", name)
+	}
+
+	// Авторизация выполняется до чтения секрета, так как мастер пароль читается из первой строки потока ввода
+	masterPass, id, err := c.authorize(ctx, &opts)
+	if err != nil {
+		return err
+	}
+	if dOpts.share != "" {
+		return c.writeShared(ctx, &opts, positional[0], &dOpts, masterPass)
+	}
+	if dOpts.org != "" {
+		return c.writeOrg(ctx, &opts, positional[0], &dOpts, masterPass, replace)
+	}
+	userData, err := c.encode(ctx, positional[0], &dOpts, masterPass)
+	if err != nil {
+		return err
+	}
+
+	if !replace {
+		ok, err := handlers.SaveData(ctx, id, c.addr+api.AddDataPattern, masterPass, c.authClient, c.stor, userData)
+		if err != nil {
+			return fmt.Errorf("save data error, %w", err)
+		}
+		if !ok {
+			return fmt.Errorf("data %s already exists", userData.Name)
+		}
+		return c.print(opts.format, result{Status: "saved", Name: userData.Name})
+	}
+
+	// Запоминаю вложения заменяемых версий, чтобы удалить их с сервера после успешной замены данных
+	oldAtts, err := attachment.Manifests(ctx, c.stor, id, masterPass, userData.Name)
+	if err != nil {
+		logger.ClientLog.Error("get attachments of data error", zap.String("error", error.Error(err)))
+	}
+	ok, err := handlers.ReplaceData(ctx, id, c.addr+api.ReplaceDataPattern, masterPass, c.authClient, c.stor, userData)
+	if err != nil {
+		return fmt.Errorf("replace data error, %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("data %s does not exist", userData.Name)
+	}
+	// При замене в режиме офлайн предыдущая версия остается на сервере вместе со своими вложениями
+	status, ok, err := c.stor.GetStatus(ctx, id, userData.Name)
+	if err == nil && ok && status == repoData.SAVED {
+		c.release(ctx, oldAtts)
+	}
+	return c.print(opts.format, result{Status: "replaced", Name: userData.Name})
+}
+
+// encode - функция для сериализации данных указанного типа. Большие файлы загружаются на сервер частями.
+func (c *CLI) encode(ctx context.Context, dataType string, dOpts *dataOptions, masterPass string) (*repoData.Data, error) {
+	now := time.Now()
+
+	switch dataType {
+	case "password":
+		secret, err := c.secret(dOpts.secretStdin)
+		if err != nil {
+			return nil, err
+		}
+		return password.JSONEncode(&password.DataInfo{
+			Pass:     data.Password{Login: dOpts.login, Password: secret},
+			MetaInfo: dOpts.metaInfo, Name: dOpts.name, CreateDate: now, EditDate: now, Organize: dOpts.organize,
+		})
+	case "text":
+		secret, err := c.secret(dOpts.secretStdin)
+		if err != nil {
+			return nil, err
+		}
+		return text.JSONEncode(&text.DataInfo{
+			Text:     data.Text{Text: secret},
+			MetaInfo: dOpts.metaInfo, Name: dOpts.name, CreateDate: now, EditDate: now, Organize: dOpts.organize,
+		})
+	case "card":
+		secret, err := c.secret(dOpts.secretStdin)
+		if err != nil {
+			return nil, err
+		}
+		number, cvv, err := parseCard(secret)
+		if err != nil {
+			return nil, err
+		}
+		return bankcard.JSONEncode(&bankcard.DataInfo{
+			Bank:     data.Bank{Number: number, Mounth: dOpts.month, Year: dOpts.year, CVV: cvv, Owner: dOpts.owner},
+			MetaInfo: dOpts.metaInfo, Name: dOpts.name, CreateDate: now, EditDate: now, Organize: dOpts.organize,
+		})
+	case "login":
+		secret, err := c.secret(dOpts.secretStdin)
+		if err != nil {
+			return nil, err
+		}
+		dataInfo := &login.DataInfo{
+			Login:    data.Login{Login: dOpts.login, Password: secret, Notes: dOpts.notes, TOTP: dOpts.totp},
+			URLs:     strings.Join(dOpts.urls, "\n"),
+			MetaInfo: dOpts.metaInfo, Name: dOpts.name, CreateDate: now, EditDate: now, Organize: dOpts.organize,
+		}
+		for _, f := range dOpts.fields {
+			dataInfo.Fields += f.String() + "\n"
+		}
+		return login.JSONEncode(dataInfo)
+	case "ssh":
+		// Секретом SSH ключа служит парольная фраза закрытого ключа, она может быть пустой
+		passphrase, err := c.secret(dOpts.secretStdin)
+		if err != nil {
+			return nil, err
+		}
+		return sshkey.JSONEncode(&sshkey.DataInfo{
+			KeyPath: dOpts.key, CertPath: dOpts.cert, Passphrase: passphrase, Comment: dOpts.comment,
+			MetaInfo: dOpts.metaInfo, Name: dOpts.name, CreateDate: now, EditDate: now, Organize: dOpts.organize,
+		})
+	case "file":
+		dataInfo := &binary.DataInfo{Path: dOpts.path, MetaInfo: dOpts.metaInfo, Name: dOpts.name, CreateDate: now, EditDate: now,
+			Organize: dOpts.organize}
+		large, err := binary.IsLarge(dataInfo)
+		if err != nil {
+			return nil, fmt.Errorf("read file error, %w", err)
+		}
+		if large {
+			if err := binary.UploadLargeFile(ctx, c.authClient, c.attachURLs, masterPass, dataInfo, nil); err != nil {
+				return nil, fmt.Errorf("upload file error, %w", err)
+			}
+		}
+		return binary.JSONEncode(dataInfo)
+	default:
+		return nil, fmt.Errorf("unknown data type %s", dataType)
+	}
+}
+
+// parseCard - функция для разбора секрета банковской карты в формате "номер CVV".
+func parseCard(secret string) (int64, int, error) {
+	fields := strings.Fields(secret)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("card secret must contain number and CVV separated by space")
+	}
+	number, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("card number is not valid, %w", err)
+	}
+	cvv, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("card CVV is not valid, %w", err)
+	}
+	return number, cvv, nil
+}
+
+// rm - команда для удаления данных пользователя по имени. Удаление возможно только в режиме онлайн. С опцией -org
+// удаляется запись коллекции организации.
+func (c *CLI) rm(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("rm", &opts)
+	orgName := fs.String("org", "", "name or id of organisation to delete data from its collection")
+	positional, err := parse(fs, &opts, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("rm: data name must be set")
+	}
+	dataName := positional[0]
+
+	masterPass, id, err := c.authorize(ctx, &opts)
+	if err != nil {
+		return err
+	}
+	if *orgName != "" {
+		coll, err := c.collection(ctx, *orgName)
+		if err != nil {
+			return err
+		}
+		item, err := orgs.FindItem(ctx, c.authClient, orgs.NewURLs(c.addr), coll, dataName)
+		if err != nil {
+			return fmt.Errorf("get org data error, %w", err)
+		}
+		if err := orgs.DeleteItem(ctx, c.authClient, orgs.NewURLs(c.addr), coll, dataName, item.Version); err != nil {
+			return fmt.Errorf("delete org data error, %w", err)
+		}
+		return c.print(opts.format, result{Status: "deleted", Name: dataName})
+	}
+
+	// Запоминаю вложения удаляемых данных до их удаления из локального хранилища
+	atts, err := attachment.Manifests(ctx, c.stor, id, masterPass, dataName)
+	if err != nil {
+		logger.ClientLog.Error("get attachments of data error", zap.String("error", error.Error(err)))
+	}
+	ok, err := handlers.DeleteEncryptedData(ctx, id, c.addr+api.DeleteDataPattern, dataName, c.authClient, c.stor)
+	if err != nil {
+		return fmt.Errorf("delete data error, %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("data %s does not exist", dataName)
+	}
+	c.release(ctx, atts)
+	return c.print(opts.format, result{Status: "deleted", Name: dataName})
+}
+
+// sync - команда для однократной синхронизации данных пользователя с сервером.
+func (c *CLI) sync(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("sync", &opts)
+	if _, err := parse(fs, &opts, args); err != nil {
+		return err
+	}
+	if _, _, err := c.authorize(ctx, &opts); err != nil {
+		return err
+	}
+
+	err := synchronization.SynchronizeData(ctx, c.stor, c.info, c.authClient,
+		c.addr+api.AddDataPattern, c.addr+api.ConflictDataPattern, c.addr+api.GetDataPattern)
+	if err != nil {
+		return fmt.Errorf("failed to synchronize data, %w", err)
+	}
+	if err := synchronization.SynchronizeShares(ctx, c.stor, c.info, c.authClient, c.addr); err != nil {
+		return fmt.Errorf("failed to synchronize shared data, %w", err)
+	}
+	return c.print(opts.format, result{Status: "synchronized"})
+}
+
+// audit - команда для вывода журнала аудита пользователя с сервера от новых событий к старым. Опция -since задает
+// начало периода в формате RFC 3339 или как длительность до текущего момента, например 24h.
+func (c *CLI) audit(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("audit", &opts)
+	sinceFlag := fs.String("since", "", "show events since time in RFC 3339 format or duration before now, e.g. 24h")
+	limit := fs.Int("limit", 0, "maximum number of events, default is set by server")
+	if _, err := parse(fs, &opts, args); err != nil {
+		return err
+	}
+	since, err := parseSince(*sinceFlag, time.Now())
+	if err != nil {
+		return err
+	}
+	if *limit < 0 {
+		return fmt.Errorf("audit: limit must not be negative")
+	}
+	if _, _, err := c.authorize(ctx, &opts); err != nil {
+		return err
+	}
+
+	events, err := handlers.GetAuditEvents(ctx, c.addr+api.AuditPattern, c.authClient, since, *limit)
+	if err != nil {
+		return fmt.Errorf("failed to get audit events, %w", err)
+	}
+	return c.printEvents(opts.format, events)
+}
+
+// parseSince - функция для разбора начала периода журнала аудита: времени в формате RFC 3339 или длительности
+// до момента now. Пустая строка не ограничивает период.
+func parseSince(v string, now time.Time) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("audit: since must be a time in RFC 3339 format or a duration, got %s", v)
+	}
+	return t, nil
+}
+
+// decrypt - функция для авторизации пользователя и получения всех его расшифрованных данных из локального хранилища.
+// Если мастер пароль не задан, данные запрашиваются у разблокированного агента.
+func (c *CLI) decrypt(ctx context.Context, opts *options) ([][]repoData.Data, error) {
+	if !hasPassword(opts) {
+		return c.agentData(ctx, opts)
+	}
+	if _, _, err := c.authorize(ctx, opts); err != nil {
+		return nil, err
+	}
+	decrData := inmemory.NewDecryptedData()
+	if err := decrData.Update(ctx, c.stor, c.info); err != nil {
+		return nil, fmt.Errorf("failed to decrypt data, %w", err)
+	}
+	return decrData.GetAll(), nil
+}
+
+// saveFile - функция для сохранения файла пользователя на диск. Большие файлы скачиваются с сервера частями.
+func (c *CLI) saveFile(ctx context.Context, userData repoData.Data, path string) error {
+	b, err := decodeBinary(userData)
+	if err != nil {
+		return err
+	}
+	if b.Attachment == nil {
+		if err := os.WriteFile(path, b.Binary, 0600); err != nil {
+			return fmt.Errorf("failed to write file, %w", err)
+		}
+		return nil
+	}
+	if err := attachment.Download(ctx, c.authClient, c.attachURLs, b.Attachment, path, nil); err != nil {
+		return fmt.Errorf("download file error, %w", err)
+	}
+	return nil
+}
+
+// release - функция для удаления ссылок на вложения, которые больше не используются данными пользователя.
+func (c *CLI) release(ctx context.Context, atts []*data.Attachment) {
+	for _, att := range atts {
+		if err := attachment.Release(ctx, c.authClient, c.attachURLs, att); err != nil {
+			logger.ClientLog.Error("release attachment error", zap.String("error", error.Error(err)))
+		}
+	}
+}
+
+// find - функция для поиска версий данных по имени. version - номер версии начиная с 1, 0 означает все версии.
+// Возвращает найденные версии и номер первой из них.
+func find(all [][]repoData.Data, dataName string, version int) ([]repoData.Data, int, error) {
+	for _, versions := range all {
+		if len(versions) == 0 || versions[0].Name != dataName {
+			continue
+		}
+		if version == 0 {
+			return versions, 1, nil
+		}
+		if version < 0 || version > len(versions) {
+			return nil, 0, fmt.Errorf("data %s has no version %d", dataName, version)
+		}
+		return versions[version-1 : version], version, nil
+	}
+	return nil, 0, fmt.Errorf("data %s does not exist", dataName)
+}
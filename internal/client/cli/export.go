@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
+	"github.com/abezemskiy/gophkeeper/internal/client/backup"
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/plaintext"
+	clientData "github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/inmemory"
+
+	"go.uber.org/zap"
+)
+
+// exportData - команда для выгрузки всех данных пользователя, включая содержимое файлов. По умолчанию данные
+// записываются в зашифрованный архив, который защищен отдельной парольной фразой и восстанавливается командой import
+// с форматом gophkeeper. Путь "-" означает запись архива в стандартный поток вывода. Форматы csv и json выгружают
+// данные в открытом виде в каталог и требуют подтверждения.
+func (c *CLI) exportData(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("export", &opts)
+	format := fs.String("format", backup.Format, "export format: "+backup.Format+" (encrypted archive), "+plaintext.CSV+" or "+plaintext.JSON)
+	passphraseStdin := fs.Bool("passphrase-stdin", false, "read archive passphrase from the next line of stdin, default from "+PassphraseEnv)
+	confirm := fs.String("confirm", "", "confirmation of plaintext export, default from the next line of stdin")
+	positional, err := parse(fs, &opts, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("export: path to archive or output directory must be set")
+	}
+	if *format != backup.Format && *format != plaintext.CSV && *format != plaintext.JSON {
+		return fmt.Errorf("export: unknown format %s", *format)
+	}
+
+	if _, _, err := c.authorize(ctx, &opts); err != nil {
+		return err
+	}
+	if *format != backup.Format {
+		return c.exportPlaintext(ctx, &opts, *format, positional[0], *confirm)
+	}
+	passphrase, err := c.passphrase(*passphraseStdin)
+	if err != nil {
+		return err
+	}
+	decrData := inmemory.NewDecryptedData()
+	if err := decrData.Update(ctx, c.stor, c.info); err != nil {
+		return fmt.Errorf("failed to decrypt data, %w", err)
+	}
+
+	path := positional[0]
+	var w io.Writer = c.out
+	var f *os.File
+	if path != "-" {
+		// Архив сначала пишется во временный файл, чтобы прерванный экспорт не оставил неполный архив
+		if f, err = os.OpenFile(path+".part", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600); err != nil {
+			return fmt.Errorf("failed to create archive, %w", err)
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		w = f
+	}
+
+	stats, err := backup.Write(ctx, w, passphrase, decrData.GetAll(), c.fetch)
+	if err != nil {
+		return fmt.Errorf("export error, %w", err)
+	}
+	if f == nil {
+		return nil
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close archive, %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("failed to rename archive, %w", err)
+	}
+
+	if opts.format == JSON {
+		return json.NewEncoder(c.out).Encode(stats)
+	}
+	_, err = fmt.Fprintf(c.out, "exported: %d records, %d files to %s\n", stats.Records, stats.Files, path)
+	return err
+}
+
+// exportPlaintext - функция для выгрузки данных пользователя в открытом виде в каталог dir. Перед выгрузкой выводится
+// предупреждение, а пользователь должен ввести фразу подтверждения в опции -confirm или в следующей строке
+// стандартного потока ввода.
+func (c *CLI) exportPlaintext(ctx context.Context, opts *options, format, dir, confirm string) error {
+	// При выводе в формате JSON предупреждение включается в результат команды
+	if opts.format != JSON {
+		if _, err := fmt.Fprintln(c.out, plaintext.Warning); err != nil {
+			return err
+		}
+	}
+	if confirm == "" {
+		if opts.format != JSON {
+			fmt.Fprintf(c.out, "Type %q to continue: ", plaintext.Confirmation)
+		}
+		line, err := c.in.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read confirmation from stdin, %w", err)
+		}
+		confirm = line
+		if opts.format != JSON {
+			fmt.Fprintln(c.out)
+		}
+	}
+	if err := plaintext.Confirm(confirm); err != nil {
+		return err
+	}
+
+	decrData := inmemory.NewDecryptedData()
+	if err := decrData.Update(ctx, c.stor, c.info); err != nil {
+		return fmt.Errorf("failed to decrypt data, %w", err)
+	}
+	stats, err := plaintext.Write(ctx, dir, format, decrData.GetAll(), c.fetch)
+	if err != nil {
+		return fmt.Errorf("export error, %w", err)
+	}
+	logger.ClientLog.Warn("plaintext export of user data", zap.String("path", stats.Path))
+
+	if opts.format == JSON {
+		return json.NewEncoder(c.out).Encode(struct {
+			plaintext.Stats
+			Warning string `json:"warning"`
+		}{stats, plaintext.Warning})
+	}
+	_, err = fmt.Fprintf(c.out, "exported UNENCRYPTED: %d records, %d files to %s\n", stats.Records, stats.Files, stats.Path)
+	return err
+}
+
+// fetch - функция для скачивания с сервера в w файла, который хранится частями.
+func (c *CLI) fetch(ctx context.Context, att *clientData.Attachment, w io.Writer) error {
+	return attachment.DownloadTo(ctx, c.authClient, c.attachURLs, att, w, nil)
+}
+
+// passphrase - функция для получения парольной фразы архива. С опцией -passphrase-stdin фраза читается из следующей
+// строки стандартного потока ввода (после мастер пароля, если он тоже читается из потока ввода).
+func (c *CLI) passphrase(fromStdin bool) (string, error) {
+	passphrase := os.Getenv(PassphraseEnv)
+	if fromStdin {
+		line, err := c.in.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("failed to read passphrase from stdin, %w", err)
+		}
+		passphrase = strings.TrimRight(line, "\r\n")
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("archive passphrase is not set, use -passphrase-stdin or %s", PassphraseEnv)
+	}
+	return passphrase, nil
+}
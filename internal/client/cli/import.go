@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/backup"
+	"github.com/abezemskiy/gophkeeper/internal/client/importer"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/inmemory"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+)
+
+// ImportItem - элемент плана импорта.
+type ImportItem struct {
+	Op           string `json:"op"`
+	Name         string `json:"name"`
+	OriginalName string `json:"original_name,omitempty"`
+	Type         string `json:"type"`
+}
+
+// ImportReport - результат команды импорта.
+type ImportReport struct {
+	DryRun   bool         `json:"dry_run"`
+	Items    []ImportItem `json:"items"`
+	Warnings []string     `json:"warnings"`
+	Imported int          `json:"imported"` // количество сохраненных записей, при предпросмотре - количество записей к сохранению
+}
+
+// importData - команда для импорта данных из файла экспорта другого менеджера паролей или из архива,
+// созданного командой export. С опцией -dry-run выводится только план импорта. Путь "-" означает чтение
+// файла экспорта из стандартного потока ввода.
+func (c *CLI) importData(ctx context.Context, args []string) error {
+	formats := strings.Join(importer.Formats, ", ") + ", " + backup.Format
+	var opts options
+	fs := newFlagSet("import", &opts)
+	format := fs.String("format", "", "format of export file: "+formats)
+	policy := fs.String("policy", string(importer.Skip), "action for data with existing name: skip, rename or overwrite")
+	dryRun := fs.Bool("dry-run", false, "print import plan without saving data")
+	passphraseStdin := fs.Bool("passphrase-stdin", false, "read archive passphrase from the next line of stdin, default from "+PassphraseEnv)
+	positional, err := parse(fs, &opts, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("import: path to export file must be set")
+	}
+	if *format == "" {
+		return fmt.Errorf("import: format must be set, supported formats: %s", formats)
+	}
+
+	masterPass, id, err := c.authorize(ctx, &opts)
+	if err != nil {
+		return err
+	}
+
+	// Файл экспорта читается после мастер пароля, который может быть передан первой строкой стандартного потока ввода
+	var r io.Reader = c.in
+	if path := positional[0]; path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open export file, %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var parsed importer.Result
+	var archive *backup.Contents
+	if *format == backup.Format {
+		passphrase, err := c.passphrase(*passphraseStdin)
+		if err != nil {
+			return err
+		}
+		// Читается только манифест, вложения читаются из архива при восстановлении данных
+		if archive, err = backup.Read(r, passphrase); err != nil {
+			return fmt.Errorf("failed to read archive, %w", err)
+		}
+		parsed.Items = archive.Items()
+	} else if parsed, err = importer.Parse(*format, r); err != nil {
+		return err
+	}
+
+	decrData := inmemory.NewDecryptedData()
+	if err := decrData.Update(ctx, c.stor, c.info); err != nil {
+		return fmt.Errorf("failed to decrypt data, %w", err)
+	}
+	existing := make([]string, 0)
+	for _, versions := range decrData.GetAll() {
+		if len(versions) > 0 {
+			existing = append(existing, versions[0].Name)
+		}
+	}
+	actions, err := importer.Plan(parsed.Items, existing, importer.Policy(*policy))
+	if err != nil {
+		return err
+	}
+
+	report := ImportReport{DryRun: *dryRun, Items: make([]ImportItem, 0, len(actions)), Warnings: parsed.Warnings}
+	for _, a := range actions {
+		report.Items = append(report.Items, ImportItem{Op: a.Op, Name: a.Name, OriginalName: a.OriginalName, Type: TypeName(a.Type)})
+		if a.Op != importer.OpSkip {
+			report.Imported++
+		}
+	}
+	if report.Warnings == nil {
+		report.Warnings = []string{}
+	}
+
+	if !*dryRun {
+		if archive != nil {
+			if err := archive.Prepare(ctx, actions, masterPass, c.authClient, c.attachURLs); err != nil {
+				return err
+			}
+		}
+		report.Imported, err = importer.Apply(ctx, actions, importer.Target{
+			UserID:     id,
+			MasterPass: masterPass,
+			AddURL:     c.addr + api.AddDataPattern,
+			ReplaceURL: c.addr + api.ReplaceDataPattern,
+			AttachURLs: c.attachURLs,
+			Client:     c.authClient,
+			Stor:       c.stor,
+		})
+		if err != nil {
+			return fmt.Errorf("import stopped after %d records, %w", report.Imported, err)
+		}
+	}
+	return c.printImport(opts.format, report)
+}
+
+// printImport - функция для вывода результата импорта.
+func (c *CLI) printImport(format string, report ImportReport) error {
+	if format == JSON {
+		return json.NewEncoder(c.out).Encode(report)
+	}
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "OP\tNAME\tTYPE\tORIGINAL NAME")
+	for _, item := range report.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", item.Op, item.Name, item.Type, item.OriginalName)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(c.out, "warning: %s\n", warning)
+	}
+	status := "imported"
+	if report.DryRun {
+		status = "to import"
+	}
+	_, err := fmt.Fprintf(c.out, "%s: %d of %d\n", status, report.Imported, len(report.Items))
+	return err
+}
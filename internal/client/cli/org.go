@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/orgs"
+	"github.com/abezemskiy/gophkeeper/internal/client/sharing"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/org"
+
+	"go.uber.org/zap"
+)
+
+// organization - команда для управления организациями. Первый аргумент - действие: create, list, members, add,
+// role, remove, rotate или delete. Записи коллекций организаций изменяются командами add, edit и rm с опцией -org.
+func (c *CLI) organization(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("org: action must be set: create, list, members, add, role, remove, rotate or delete")
+	}
+	actions := map[string]func(ctx context.Context, args []string) error{
+		"create":  c.orgCreate,
+		"list":    c.orgList,
+		"members": c.orgMembers,
+		"add":     c.orgAdd,
+		"role":    c.orgRole,
+		"remove":  c.orgRemove,
+		"rotate":  c.orgRotate,
+		"delete":  c.orgDelete,
+	}
+	action, ok := actions[args[0]]
+	if !ok {
+		return fmt.Errorf("org: unknown action %s", args[0])
+	}
+	return action(ctx, args[1:])
+}
+
+// orgCreate - действие для создания организации. Пользователь становится её владельцем.
+func (c *CLI) orgCreate(ctx context.Context, args []string) error {
+	var opts options
+	positional, err := c.orgArgs(ctx, newFlagSet("org create", &opts), &opts, args, "org name")
+	if err != nil {
+		return err
+	}
+	keys, err := c.keys(ctx)
+	if err != nil {
+		return err
+	}
+	created, err := orgs.Create(ctx, c.authClient, orgs.NewURLs(c.addr), keys, positional[0])
+	if err != nil {
+		return fmt.Errorf("create org error, %w", err)
+	}
+	return c.printOrgs(opts.format, []org.Org{created})
+}
+
+// orgList - действие для вывода организаций, участником которых является пользователь.
+func (c *CLI) orgList(ctx context.Context, args []string) error {
+	var opts options
+	if _, err := c.orgArgs(ctx, newFlagSet("org list", &opts), &opts, args); err != nil {
+		return err
+	}
+	list, err := orgs.List(ctx, c.authClient, orgs.NewURLs(c.addr))
+	if err != nil {
+		return fmt.Errorf("get orgs error, %w", err)
+	}
+	return c.printOrgs(opts.format, list)
+}
+
+// orgMembers - действие для вывода участников организации.
+func (c *CLI) orgMembers(ctx context.Context, args []string) error {
+	var opts options
+	positional, err := c.orgArgs(ctx, newFlagSet("org members", &opts), &opts, args, "org name")
+	if err != nil {
+		return err
+	}
+	coll, err := c.collection(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	members, err := orgs.Members(ctx, c.authClient, orgs.NewURLs(c.addr), coll.Org.ID)
+	if err != nil {
+		return fmt.Errorf("get members error, %w", err)
+	}
+	return c.printMembers(opts.format, members)
+}
+
+// orgAdd - действие для добавления участника в организацию. Роль задается опцией -role.
+func (c *CLI) orgAdd(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("org add", &opts)
+	role := fs.String("role", org.Viewer, "role of the member: admin, editor or viewer")
+	positional, err := c.orgArgs(ctx, fs, &opts, args, "org name", "member login")
+	if err != nil {
+		return err
+	}
+	coll, err := c.collection(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	if err := orgs.AddMember(ctx, c.authClient, orgs.NewURLs(c.addr), coll, positional[1], *role); err != nil {
+		return fmt.Errorf("add member error, %w", err)
+	}
+	return c.print(opts.format, result{Status: "added", Name: positional[1]})
+}
+
+// orgRole - действие для изменения роли участника организации.
+func (c *CLI) orgRole(ctx context.Context, args []string) error {
+	var opts options
+	positional, err := c.orgArgs(ctx, newFlagSet("org role", &opts), &opts, args, "org name", "member login", "role")
+	if err != nil {
+		return err
+	}
+	coll, err := c.collection(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	if err := orgs.SetRole(ctx, c.authClient, orgs.NewURLs(c.addr), coll.Org.ID, positional[1], positional[2]); err != nil {
+		return fmt.Errorf("set role error, %w", err)
+	}
+	return c.print(opts.format, result{Status: "role changed to " + positional[2], Name: positional[1]})
+}
+
+// orgRemove - действие для удаления участника из организации. Ключ коллекции при этом заменяется новым.
+func (c *CLI) orgRemove(ctx context.Context, args []string) error {
+	var opts options
+	positional, err := c.orgArgs(ctx, newFlagSet("org remove", &opts), &opts, args, "org name", "member login")
+	if err != nil {
+		return err
+	}
+	coll, err := c.collection(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	if err := orgs.Rotate(ctx, c.authClient, orgs.NewURLs(c.addr), coll, positional[1]); err != nil {
+		return fmt.Errorf("remove member error, %w", err)
+	}
+	return c.print(opts.format, result{Status: "removed", Name: positional[1]})
+}
+
+// orgRotate - действие для замены ключа коллекции организации без удаления участников.
+func (c *CLI) orgRotate(ctx context.Context, args []string) error {
+	var opts options
+	positional, err := c.orgArgs(ctx, newFlagSet("org rotate", &opts), &opts, args, "org name")
+	if err != nil {
+		return err
+	}
+	coll, err := c.collection(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	if err := orgs.Rotate(ctx, c.authClient, orgs.NewURLs(c.addr), coll, ""); err != nil {
+		return fmt.Errorf("rotate org key error, %w", err)
+	}
+	return c.print(opts.format, result{Status: "key rotated", Name: coll.Org.Name})
+}
+
+// orgDelete - действие для удаления организации вместе с коллекцией.
+func (c *CLI) orgDelete(ctx context.Context, args []string) error {
+	var opts options
+	positional, err := c.orgArgs(ctx, newFlagSet("org delete", &opts), &opts, args, "org name")
+	if err != nil {
+		return err
+	}
+	coll, err := c.collection(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	if err := orgs.Delete(ctx, c.authClient, orgs.NewURLs(c.addr), coll.Org.ID); err != nil {
+		return fmt.Errorf("delete org error, %w", err)
+	}
+	return c.print(opts.format, result{Status: "deleted", Name: coll.Org.Name})
+}
+
+// orgArgs - функция для разбора аргументов действия с организацией и авторизации пользователя. names - имена
+// обязательных позиционных аргументов. Ключи коллекций расшифровываются ключевой парой пользователя, поэтому
+// мастер пароль обязателен и данные агента не используются.
+func (c *CLI) orgArgs(ctx context.Context, fs *flag.FlagSet, opts *options, args []string, names ...string) ([]string, error) {
+	positional, err := parse(fs, opts, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) != len(names) {
+		return nil, fmt.Errorf("%s: %d arguments expected: %v", fs.Name(), len(names), names)
+	}
+	if !hasPassword(opts) {
+		return nil, fmt.Errorf("%s: password is not set, use -password-stdin or %s", fs.Name(), PasswordEnv)
+	}
+	if _, _, err := c.authorize(ctx, opts); err != nil {
+		return nil, err
+	}
+	return positional, nil
+}
+
+// collection - функция для получения организации по id или названию с ключом коллекции, расшифрованным ключевой
+// парой авторизованного пользователя.
+func (c *CLI) collection(ctx context.Context, idOrName string) (orgs.Collection, error) {
+	keys, err := c.keys(ctx)
+	if err != nil {
+		return orgs.Collection{}, err
+	}
+	coll, err := orgs.Find(ctx, c.authClient, orgs.NewURLs(c.addr), keys, idOrName)
+	if err != nil {
+		return orgs.Collection{}, fmt.Errorf("get org error, %w", err)
+	}
+	return coll, nil
+}
+
+// orgItems - функция для получения записей коллекций всех организаций авторизованного пользователя. В режиме офлайн
+// коллекции недоступны, поэтому ошибка только записывается в лог.
+func (c *CLI) orgItems(ctx context.Context) []orgs.Item {
+	keys, err := c.keys(ctx)
+	if err != nil {
+		logger.ClientLog.Error("get org items error", zap.String("error", err.Error()))
+		return nil
+	}
+	items, err := orgs.All(ctx, c.authClient, orgs.NewURLs(c.addr), keys)
+	if err != nil {
+		logger.ClientLog.Error("get org items error", zap.String("error", err.Error()))
+		return nil
+	}
+	return items
+}
+
+// writeOrg - функция для добавления или замены записи коллекции организации, название которой задано в опциях
+// команды. Файлы, загруженные на сервер частями, в коллекцию не сохраняются.
+func (c *CLI) writeOrg(ctx context.Context, opts *options, dataType string, dOpts *dataOptions, masterPass string, replace bool) error {
+	coll, err := c.collection(ctx, dOpts.org)
+	if err != nil {
+		return err
+	}
+	userData, err := c.encode(ctx, dataType, dOpts, masterPass)
+	if err != nil {
+		return err
+	}
+	if att, ok := attachment.FromData(userData); ok {
+		c.release(ctx, []*data.Attachment{att})
+		return sharing.ErrAttachment
+	}
+
+	var version int64
+	item, err := orgs.FindItem(ctx, c.authClient, orgs.NewURLs(c.addr), coll, userData.Name)
+	switch {
+	case err == nil && !replace:
+		return fmt.Errorf("data %s already exists in org %s", userData.Name, coll.Org.Name)
+	case err == nil:
+		version = item.Version
+	case !errors.Is(err, orgs.ErrItemNotFound):
+		return fmt.Errorf("get org data error, %w", err)
+	case replace:
+		return fmt.Errorf("data %s does not exist in org %s", userData.Name, coll.Org.Name)
+	}
+	if _, err := orgs.Put(ctx, c.authClient, orgs.NewURLs(c.addr), coll, *userData, version); err != nil {
+		return fmt.Errorf("save org data error, %w", err)
+	}
+	status := "saved"
+	if replace {
+		status = "replaced"
+	}
+	return c.print(opts.format, result{Status: status, Name: userData.Name})
+}
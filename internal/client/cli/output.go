@@ -0,0 +1,400 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/totp"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/org"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/share"
+)
+
+// result - результат команды, изменяющей данные пользователя.
+type result struct {
+	Status string `json:"status"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Entry - элемент списка данных пользователя.
+type Entry struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Versions int      `json:"versions"` // количество версий, больше одной при конфликте данных
+	Metainfo string   `json:"metainfo"`
+	Tags     []string `json:"tags,omitempty"`
+	Folder   string   `json:"folder,omitempty"`
+	Favorite bool     `json:"favorite,omitempty"`
+
+	Share      string `json:"share,omitempty"`      // id общей записи, которой поделился другой пользователь
+	Owner      string `json:"owner,omitempty"`      // логин владельца общей записи
+	Permission string `json:"permission,omitempty"` // права пользователя на общую запись или запись организации
+	Org        string `json:"org,omitempty"`        // название организации, в коллекции которой находится запись
+}
+
+// Record - расшифрованная версия данных пользователя.
+type Record struct {
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Version    int       `json:"version"`
+	Metainfo   string    `json:"metainfo"`
+	Tags       []string  `json:"tags,omitempty"`
+	Folder     string    `json:"folder,omitempty"`
+	Favorite   bool      `json:"favorite,omitempty"`
+	CreateDate time.Time `json:"create_date"`
+	EditDate   time.Time `json:"edit_date"`
+	Data       any       `json:"data"`
+}
+
+// TypeName - функция для получения имени типа данных, которое используется в командах клиента.
+func TypeName(dataType int) string {
+	switch dataType {
+	case repoData.PASSWORD:
+		return "password"
+	case repoData.TEXT:
+		return "text"
+	case repoData.BINARY:
+		return "file"
+	case repoData.BANKCARD:
+		return "card"
+	case repoData.LOGIN:
+		return "login"
+	case repoData.SSHKEY:
+		return "ssh"
+	default:
+		return "unknown"
+	}
+}
+
+// Field - функция для получения значения поля данных пользователя по имени поля.
+func Field(userData repoData.Data, name string) (string, error) {
+	fields, err := fieldsOf(userData)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range fields {
+		if f[0] == name {
+			return f[1], nil
+		}
+	}
+	return "", fmt.Errorf("data %s of type %s has no field %s", userData.Name, TypeName(userData.Type), name)
+}
+
+// fieldsOf - функция для получения упорядоченного списка полей данных пользователя в текстовом виде.
+func fieldsOf(userData repoData.Data) ([][2]string, error) {
+	payload, err := payloadOf(userData)
+	if err != nil {
+		return nil, err
+	}
+	switch p := payload.(type) {
+	case data.Password:
+		return [][2]string{{"login", p.Login}, {"password", p.Password}}, nil
+	case data.Text:
+		return [][2]string{{"text", p.Text}}, nil
+	case data.Bank:
+		return [][2]string{
+			{"number", strconv.FormatInt(p.Number, 10)},
+			{"month", strconv.Itoa(p.Mounth)},
+			{"year", strconv.Itoa(p.Year)},
+			{"cvv", strconv.Itoa(p.CVV)},
+			{"owner", p.Owner},
+		}, nil
+	case data.Binary:
+		size, stored := int64(len(p.Binary)), "local"
+		if p.Attachment != nil {
+			size, stored = p.Attachment.Size, "server"
+		}
+		return [][2]string{{"mime", p.Type}, {"size", strconv.FormatInt(size, 10)}, {"stored", stored}}, nil
+	case data.Login:
+		return loginFields(p)
+	case data.SSHKey:
+		return sshKeyFields(p), nil
+	default:
+		return nil, fmt.Errorf("unknown data type %d", userData.Type)
+	}
+}
+
+// loginFields - функция для получения полей учетной записи. Вместо секрета TOTP выводится текущий код,
+// дополнительные поля следуют за основными полями.
+func loginFields(l data.Login) ([][2]string, error) {
+	fields := [][2]string{{"login", l.Login}, {"password", l.Password}}
+	if len(l.URLs) > 0 {
+		fields = append(fields, [2]string{"urls", strings.Join(l.URLs, "\n")})
+	}
+	if l.Notes != "" {
+		fields = append(fields, [2]string{"notes", l.Notes})
+	}
+	if l.TOTP != "" {
+		code, _, err := totp.Now(l.TOTP)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, [2]string{"totp", code})
+	}
+	for _, f := range l.Fields {
+		fields = append(fields, [2]string{f.Name, f.Value})
+	}
+	return fields, nil
+}
+
+// sshKeyFields - функция для получения полей SSH ключа. Закрытый ключ следует за открытыми сведениями о ключе.
+func sshKeyFields(k data.SSHKey) [][2]string {
+	fields := [][2]string{{"fingerprint", k.Fingerprint}, {"public_key", k.PublicKey}}
+	if k.Comment != "" {
+		fields = append(fields, [2]string{"comment", k.Comment})
+	}
+	if k.Certificate != "" {
+		fields = append(fields, [2]string{"certificate", k.Certificate})
+	}
+	fields = append(fields, [2]string{"private_key", strings.TrimSpace(k.PrivateKey)})
+	if k.Passphrase != "" {
+		fields = append(fields, [2]string{"passphrase", k.Passphrase})
+	}
+	return fields
+}
+
+// payloadOf - функция для десериализации полезной нагрузки данных пользователя по типу данных.
+func payloadOf(userData repoData.Data) (any, error) {
+	var (
+		payload any
+		err     error
+	)
+	switch userData.Type {
+	case repoData.PASSWORD:
+		var p data.Password
+		err = json.Unmarshal(userData.Data, &p)
+		payload = p
+	case repoData.TEXT:
+		var t data.Text
+		err = json.Unmarshal(userData.Data, &t)
+		payload = t
+	case repoData.BINARY:
+		b, err := decodeBinary(userData)
+		if err != nil {
+			return nil, err
+		}
+		payload = b
+	case repoData.BANKCARD:
+		var b data.Bank
+		err = json.Unmarshal(userData.Data, &b)
+		payload = b
+	case repoData.LOGIN:
+		l, err := data.DecodeLogin(userData)
+		if err != nil {
+			return nil, err
+		}
+		payload = l
+	case repoData.SSHKEY:
+		k, err := data.DecodeSSHKey(userData)
+		if err != nil {
+			return nil, err
+		}
+		payload = k
+	default:
+		return nil, fmt.Errorf("unknown data type %d", userData.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal data %s, %w", userData.Name, err)
+	}
+	return payload, nil
+}
+
+// decodeBinary - функция для десериализации файла пользователя.
+func decodeBinary(userData repoData.Data) (data.Binary, error) {
+	if userData.Type != repoData.BINARY {
+		return data.Binary{}, fmt.Errorf("data %s is not a file", userData.Name)
+	}
+	var b data.Binary
+	if err := json.Unmarshal(userData.Data, &b); err != nil {
+		return data.Binary{}, fmt.Errorf("failed to unmarshal data %s, %w", userData.Name, err)
+	}
+	return b, nil
+}
+
+// print - функция для вывода результата команды, изменяющей данные пользователя.
+func (c *CLI) print(format string, res result) error {
+	if format == JSON {
+		return json.NewEncoder(c.out).Encode(res)
+	}
+	if res.Name == "" {
+		_, err := fmt.Fprintln(c.out, res.Status)
+		return err
+	}
+	_, err := fmt.Fprintf(c.out, "%s: %s\n", res.Status, res.Name)
+	return err
+}
+
+// printEntries - функция для вывода списка данных пользователя.
+func (c *CLI) printEntries(format string, entries []Entry) error {
+	if format == JSON {
+		return json.NewEncoder(c.out).Encode(entries)
+	}
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tVERSIONS\tFOLDER\tTAGS\tMETAINFO")
+	for _, e := range entries {
+		name := e.Name
+		if e.Favorite {
+			name = "*" + name
+		}
+		if e.Owner != "" {
+			name = fmt.Sprintf("%s@%s (%s)", name, e.Owner, e.Permission)
+		}
+		if e.Org != "" {
+			name = fmt.Sprintf("[%s] %s (%s)", e.Org, name, e.Permission)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", name, e.Type, e.Versions, e.Folder, strings.Join(e.Tags, ","), e.Metainfo)
+	}
+	return w.Flush()
+}
+
+// printRecords - функция для вывода версий данных пользователя. first - номер первой из выводимых версий.
+func (c *CLI) printRecords(format string, versions []repoData.Data, first int) error {
+	if format == JSON {
+		records := make([]Record, 0, len(versions))
+		for i, v := range versions {
+			payload, err := payloadOf(v)
+			if err != nil {
+				return err
+			}
+			records = append(records, Record{
+				Name:       v.Name,
+				Type:       TypeName(v.Type),
+				Version:    first + i,
+				Metainfo:   v.Metainfo,
+				Tags:       v.Tags,
+				Folder:     v.Folder,
+				Favorite:   v.Favorite,
+				CreateDate: v.CreateDate,
+				EditDate:   v.EditDate,
+				Data:       payload,
+			})
+		}
+		return json.NewEncoder(c.out).Encode(records)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 4, 1, ' ', 0)
+	for i, v := range versions {
+		fields, err := fieldsOf(v)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "name:\t%s\n", v.Name)
+		fmt.Fprintf(w, "type:\t%s\n", TypeName(v.Type))
+		fmt.Fprintf(w, "version:\t%d\n", first+i)
+		fmt.Fprintf(w, "metainfo:\t%s\n", v.Metainfo)
+		if len(v.Tags) > 0 {
+			fmt.Fprintf(w, "tags:\t%s\n", strings.Join(v.Tags, ","))
+		}
+		if v.Folder != "" {
+			fmt.Fprintf(w, "folder:\t%s\n", v.Folder)
+		}
+		if v.Favorite {
+			fmt.Fprintf(w, "favorite:\t%t\n", v.Favorite)
+		}
+		for _, f := range fields {
+			fmt.Fprintf(w, "%s:\t%s\n", f[0], f[1])
+		}
+	}
+	return w.Flush()
+}
+
+// printShares - функция для вывода общих записей без их содержимого. Непустой fingerprint - отпечаток ключа получателя
+// созданной общей записи.
+func (c *CLI) printShares(format string, shares []share.Share, fingerprint string) error {
+	if format == JSON {
+		type shareInfo struct {
+			ID         string    `json:"id"`
+			Name       string    `json:"name"`
+			Owner      string    `json:"owner"`
+			Recipient  string    `json:"recipient"`
+			Permission string    `json:"permission"`
+			Accepted   bool      `json:"accepted"`
+			Version    int64     `json:"version"`
+			UpdatedAt  time.Time `json:"updated_at"`
+			Key        string    `json:"recipient_key,omitempty"`
+		}
+		list := make([]shareInfo, 0, len(shares))
+		for _, sh := range shares {
+			list = append(list, shareInfo{ID: sh.ID, Name: sh.Name, Owner: sh.Owner, Recipient: sh.Recipient,
+				Permission: sh.Permission, Accepted: sh.Accepted, Version: sh.Version, UpdatedAt: sh.UpdatedAt, Key: fingerprint})
+		}
+		return json.NewEncoder(c.out).Encode(list)
+	}
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tOWNER\tRECIPIENT\tPERMISSION\tACCEPTED")
+	for _, sh := range shares {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\n", sh.ID, sh.Name, sh.Owner, sh.Recipient, sh.Permission, sh.Accepted)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if fingerprint != "" {
+		fmt.Fprintf(c.out, "recipient key: %s\n", fingerprint)
+	}
+	return nil
+}
+
+// printOrgs - функция для вывода организаций пользователя. Ключи коллекций не выводятся.
+func (c *CLI) printOrgs(format string, list []org.Org) error {
+	if format == JSON {
+		type orgInfo struct {
+			ID         string    `json:"id"`
+			Name       string    `json:"name"`
+			Role       string    `json:"role"`
+			KeyVersion int64     `json:"key_version"`
+			CreatedAt  time.Time `json:"created_at"`
+		}
+		infos := make([]orgInfo, 0, len(list))
+		for _, o := range list {
+			infos = append(infos, orgInfo{ID: o.ID, Name: o.Name, Role: o.Role, KeyVersion: o.KeyVersion, CreatedAt: o.CreatedAt})
+		}
+		return json.NewEncoder(c.out).Encode(infos)
+	}
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tROLE\tKEY VERSION")
+	for _, o := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", o.ID, o.Name, o.Role, o.KeyVersion)
+	}
+	return w.Flush()
+}
+
+// printMembers - функция для вывода участников организации.
+func (c *CLI) printMembers(format string, members []org.Member) error {
+	if format == JSON {
+		if members == nil {
+			members = []org.Member{}
+		}
+		return json.NewEncoder(c.out).Encode(members)
+	}
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "LOGIN\tROLE\tKEY VERSION")
+	for _, m := range members {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", m.Login, m.Role, m.KeyVersion)
+	}
+	return w.Flush()
+}
+
+// printEvents - функция для вывода событий журнала аудита.
+func (c *CLI) printEvents(format string, events []audit.Event) error {
+	if format == JSON {
+		if events == nil {
+			events = []audit.Event{}
+		}
+		return json.NewEncoder(c.out).Encode(events)
+	}
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tEVENT\tRECORD\tDEVICE\tIP")
+	for _, e := range events {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Time.Local().Format(time.DateTime), e.Type, e.RecordID, e.Device, e.IP)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/sharing"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/share"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+// share - команда для передачи записи другому пользователю. Получатель указывается опцией -to, опция -write
+// разрешает получателю изменять запись. Запись станет доступна получателю после принятия командой accept.
+// Отпечаток открытого ключа получателя выводится и закрепляется при первой передаче; если ключ изменился,
+// запись передается только с опцией -accept-key.
+func (c *CLI) share(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("share", &opts)
+	recipient := fs.String("to", "", "login of the user to share data with")
+	write := fs.Bool("write", false, "allow the recipient to edit data")
+	acceptKey := fs.Bool("accept-key", false, "confirm that the public key of the recipient has changed and pin the new key")
+	positional, err := parse(fs, &opts, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("share: data name must be set")
+	}
+	if *recipient == "" {
+		return fmt.Errorf("share: recipient must be set with -to")
+	}
+	permission := share.Read
+	if *write {
+		permission = share.Write
+	}
+	// Ключевая пара пользователя расшифровывается мастер паролем, поэтому данные агента не используются
+	if !hasPassword(&opts) {
+		return fmt.Errorf("share: password is not set, use -password-stdin or %s", PasswordEnv)
+	}
+
+	all, err := c.decrypt(ctx, &opts)
+	if err != nil {
+		return err
+	}
+	versions, _, err := find(all, positional[0], 0)
+	if err != nil {
+		return err
+	}
+	keys, err := c.keys(ctx)
+	if err != nil {
+		return err
+	}
+	path, err := sharing.DefaultKnownKeysPath()
+	if err != nil {
+		return err
+	}
+	to := sharing.Recipient{Login: *recipient, Known: sharing.NewKnownKeys(path, c.addr), AcceptChanged: *acceptKey}
+	sh, fingerprint, err := sharing.Create(ctx, c.authClient, sharing.NewURLs(c.addr), keys, versions, to, permission)
+	if errors.Is(err, sharing.ErrKeyChanged) {
+		return fmt.Errorf("share data error, %w; verify the fingerprint with %s and run again with -accept-key", err, *recipient)
+	}
+	if err != nil {
+		return fmt.Errorf("share data error, %w", err)
+	}
+	return c.printShares(opts.format, []share.Share{sh}, fingerprint)
+}
+
+// shares - команда для вывода записей, которыми пользователь поделился, и записей, которыми поделились с ним.
+func (c *CLI) shares(ctx context.Context, args []string) error {
+	var opts options
+	fs := newFlagSet("shares", &opts)
+	if _, err := parse(fs, &opts, args); err != nil {
+		return err
+	}
+	if _, _, err := c.authorize(ctx, &opts); err != nil {
+		return err
+	}
+
+	list, err := sharing.List(ctx, c.authClient, sharing.NewURLs(c.addr))
+	if err != nil {
+		return fmt.Errorf("get shares error, %w", err)
+	}
+	return c.printShares(opts.format, list, "")
+}
+
+// accept - команда для принятия записи, которой поделился другой пользователь.
+func (c *CLI) accept(ctx context.Context, args []string) error {
+	return c.shareAction(ctx, "accept", args, sharing.Accept, "accepted")
+}
+
+// revoke - команда для отзыва записи владельцем или отказа получателя от записи.
+func (c *CLI) revoke(ctx context.Context, args []string) error {
+	return c.shareAction(ctx, "revoke", args, sharing.Delete, "revoked")
+}
+
+// shareAction - функция для выполнения действия action с общей записью, id которой передается позиционным аргументом.
+func (c *CLI) shareAction(ctx context.Context, name string, args []string,
+	action func(context.Context, *resty.Client, sharing.URLs, string) error, status string) error {
+	var opts options
+	fs := newFlagSet(name, &opts)
+	positional, err := parse(fs, &opts, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("%s: share id must be set", name)
+	}
+	if _, _, err := c.authorize(ctx, &opts); err != nil {
+		return err
+	}
+
+	if err := action(ctx, c.authClient, sharing.NewURLs(c.addr), positional[0]); err != nil {
+		return fmt.Errorf("%s share error, %w", name, err)
+	}
+	return c.print(opts.format, result{Status: status, Name: positional[0]})
+}
+
+// keys - функция для получения ключевой пары авторизованного пользователя.
+func (c *CLI) keys(ctx context.Context) (sharing.Keys, error) {
+	authData, _ := c.info.Get()
+	keys, err := sharing.KeyPair(ctx, c.authClient, sharing.NewURLs(c.addr), authData.Password)
+	if err != nil {
+		return sharing.Keys{}, fmt.Errorf("get key pair error, %w", err)
+	}
+	return keys, nil
+}
+
+// sharedRecord - функция для получения общей записи с указанным id, расшифрованной ключевой парой авторизованного
+// пользователя.
+func (c *CLI) sharedRecord(ctx context.Context, id string) (sharing.Record, error) {
+	keys, err := c.keys(ctx)
+	if err != nil {
+		return sharing.Record{}, err
+	}
+	authData, _ := c.info.Get()
+	record, err := sharing.Find(ctx, c.authClient, sharing.NewURLs(c.addr), authData.Login, keys, id)
+	if err != nil {
+		return sharing.Record{}, fmt.Errorf("get shared record error, %w", err)
+	}
+	return record, nil
+}
+
+// received - функция для получения принятых записей, которыми с авторизованным пользователем поделились другие
+// пользователи. В режиме офлайн общие записи недоступны, поэтому ошибка только записывается в лог.
+func (c *CLI) received(ctx context.Context) []sharing.Record {
+	keys, err := c.keys(ctx)
+	if err != nil {
+		logger.ClientLog.Error("get shared records error", zap.String("error", err.Error()))
+		return nil
+	}
+	authData, _ := c.info.Get()
+	records, err := sharing.Received(ctx, c.authClient, sharing.NewURLs(c.addr), authData.Login, keys)
+	if err != nil {
+		logger.ClientLog.Error("get shared records error", zap.String("error", err.Error()))
+		return nil
+	}
+	return records
+}
+
+// writeShared - функция для замены содержимого общей записи, id которой задан в опциях команды. Имя общей записи
+// задает владелец, поэтому имя из опций команды не используется.
+func (c *CLI) writeShared(ctx context.Context, opts *options, dataType string, dOpts *dataOptions, masterPass string) error {
+	record, err := c.sharedRecord(ctx, dOpts.share)
+	if err != nil {
+		return err
+	}
+	dOpts.name = record.Share.Name
+	userData, err := c.encode(ctx, dataType, dOpts, masterPass)
+	if err != nil {
+		return err
+	}
+	authData, _ := c.info.Get()
+	if _, err := sharing.Update(ctx, c.authClient, sharing.NewURLs(c.addr), authData.Login, record, *userData); err != nil {
+		return fmt.Errorf("edit shared data error, %w", err)
+	}
+	return c.print(opts.format, result{Status: "replaced", Name: record.Share.Name})
+}
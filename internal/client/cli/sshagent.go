@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/sshagent"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/inmemory"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"go.uber.org/zap"
+)
+
+// sshAgent - команда для запуска SSH агента, который подписывает запросы ssh ключами хранилища. Если мастер пароль
+// задан, ключи расшифровываются из локального хранилища, иначе запрашиваются у разблокированного локального агента.
+// Каждое использование ключа подтверждается в терминале или программой, указанной опцией -askpass.
+// Команда блокируется до завершения контекста.
+func (c *CLI) sshAgent(ctx context.Context, args []string) error {
+	var opts options
+	var socket, askpass string
+	fs := newFlagSet("ssh-agent", &opts)
+	fs.StringVar(&socket, "ssh-socket", sshagent.SocketPath(), "path to SSH agent socket, default from "+sshagent.SocketEnv)
+	fs.StringVar(&askpass, "askpass", "", "ssh-askpass style program to confirm each use of key instead of the terminal")
+	if _, err := parse(fs, &opts, args); err != nil {
+		return err
+	}
+
+	source := func(ctx context.Context) ([]sshagent.Key, error) {
+		all, err := c.agentData(ctx, &opts)
+		if err != nil {
+			return nil, err
+		}
+		return sshKeys(all), nil
+	}
+	if hasPassword(&opts) {
+		// Мастер пароль читается один раз при запуске, ключи расшифровываются при каждом запросе
+		if _, _, err := c.authorize(ctx, &opts); err != nil {
+			return err
+		}
+		source = func(ctx context.Context) ([]sshagent.Key, error) {
+			decrData := inmemory.NewDecryptedData()
+			if err := decrData.Update(ctx, c.stor, c.info); err != nil {
+				return nil, fmt.Errorf("failed to decrypt data, %w", err)
+			}
+			return sshKeys(decrData.GetAll()), nil
+		}
+	}
+	confirm := sshagent.TTY()
+	if askpass != "" {
+		confirm = sshagent.Askpass(ctx, askpass)
+	}
+
+	if err := c.print(opts.format, result{Status: "ssh agent listening, set SSH_AUTH_SOCK to", Name: socket}); err != nil {
+		return err
+	}
+	return sshagent.New(ctx, source, confirm).Serve(ctx, socket)
+}
+
+// sshKeys - функция для получения SSH ключей из всех версий данных пользователя.
+func sshKeys(all [][]repoData.Data) []sshagent.Key {
+	var keys []sshagent.Key
+	for _, versions := range all {
+		for _, v := range versions {
+			if v.Type != repoData.SSHKEY {
+				continue
+			}
+			key, err := data.DecodeSSHKey(v)
+			if err != nil {
+				logger.ClientLog.Error("decode ssh key error", zap.String("name", v.Name), zap.String("error", err.Error()))
+				continue
+			}
+			keys = append(keys, sshagent.Key{Name: v.Name, Key: key})
+		}
+	}
+	return keys
+}
@@ -9,9 +9,16 @@ import (
 
 // Configs представляет структуру конфигурации.
 type Configs struct {
-	Address     string `json:"address"`      // аналог переменной окружения GOPHKEEPER_CLIENT_ADDRESS или флага -a
-	LogLevel    string `json:"log_level"`    // аналог переменной окружения GOPHKEEPER_CLIENT_LOG_LEVEL или флага -l
-	DatabaseDSN string `json:"database_dsn"` // аналог переменной окружения GOPHKEEPER_CLIENT_DATABASE_URL или флага -d
+	Address       string `json:"address"`        // аналог переменной окружения GOPHKEEPER_CLIENT_ADDRESS или флага -a
+	LogLevel      string `json:"log_level"`      // аналог переменной окружения GOPHKEEPER_CLIENT_LOG_LEVEL или флага -l
+	DatabaseDSN   string `json:"database_dsn"`   // аналог переменной окружения GOPHKEEPER_CLIENT_DATABASE_URL или флага -d
+	Transport     string `json:"transport"`      // аналог переменной окружения GOPHKEEPER_CLIENT_TRANSPORT или флага -transport
+	GRPCAddress   string `json:"grpc_address"`   // аналог переменной окружения GOPHKEEPER_CLIENT_GRPC_ADDRESS или флага -grpc-address
+	TLSCA         string `json:"tls_ca"`         // аналог переменной окружения GOPHKEEPER_CLIENT_TLS_CA или флага -tls-ca
+	TLSCert       string `json:"tls_cert"`       // аналог переменной окружения GOPHKEEPER_CLIENT_TLS_CERT или флага -tls-cert
+	TLSKey        string `json:"tls_key"`        // аналог переменной окружения GOPHKEEPER_CLIENT_TLS_KEY или флага -tls-key
+	TraceExporter string `json:"trace_exporter"` // аналог переменной окружения GOPHKEEPER_CLIENT_TRACE_EXPORTER или флага -trace-exporter
+	TraceEndpoint string `json:"trace_endpoint"` // аналог переменной окружения GOPHKEEPER_CLIENT_TRACE_ENDPOINT или флага -trace-endpoint
 }
 
 // ParseConfigFile - функция для переопределения параметров конфигурации из файла конфигурации.
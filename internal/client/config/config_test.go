@@ -13,10 +13,12 @@ func TestParseConfigFile(t *testing.T) {
 	testFlagNetAddr := "localhost:8082"
 	testFlagDatabaseDsn := "test dsn"
 	testFlagLogLevel := "test info"
+	testFlagTransport := "grpc"
+	testFlagGRPCAddress := "localhost:3200"
 
 	createFile := func(name string) {
-		data := fmt.Sprintf("{\"address\": \"%s\",\"database_dsn\": \"%s\",\"log_level\": \"%s\"}",
-			testFlagNetAddr, testFlagDatabaseDsn, testFlagLogLevel)
+		data := fmt.Sprintf("{\"address\": \"%s\",\"database_dsn\": \"%s\",\"log_level\": \"%s\",\"transport\": \"%s\",\"grpc_address\": \"%s\",\"tls_ca\": \"ca.crt\",\"tls_cert\": \"client.crt\",\"tls_key\": \"client.key\",\"trace_exporter\": \"otlp\",\"trace_endpoint\": \"collector:4317\"}",
+			testFlagNetAddr, testFlagDatabaseDsn, testFlagLogLevel, testFlagTransport, testFlagGRPCAddress)
 		f, err := os.Create(name)
 		require.NoError(t, err)
 		_, err = f.Write([]byte(data))
@@ -31,6 +33,13 @@ func TestParseConfigFile(t *testing.T) {
 	assert.Equal(t, testFlagNetAddr, configs.Address)
 	assert.Equal(t, testFlagDatabaseDsn, configs.DatabaseDSN)
 	assert.Equal(t, testFlagLogLevel, configs.LogLevel)
+	assert.Equal(t, testFlagTransport, configs.Transport)
+	assert.Equal(t, testFlagGRPCAddress, configs.GRPCAddress)
+	assert.Equal(t, "ca.crt", configs.TLSCA)
+	assert.Equal(t, "client.crt", configs.TLSCert)
+	assert.Equal(t, "client.key", configs.TLSKey)
+	assert.Equal(t, "otlp", configs.TraceExporter)
+	assert.Equal(t, "collector:4317", configs.TraceEndpoint)
 
 	err = os.Remove(nameFile)
 	require.NoError(t, err)
@@ -3,18 +3,23 @@ package encr
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/encryption"
 	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/key"
+	"github.com/abezemskiy/gophkeeper/internal/common/tracing"
 	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
 )
 
 // EncryptData - функция для шифрования пользовательских данных.
-func EncryptData(passwrod string, userData *data.Data) (*data.EncryptedData, error) {
+func EncryptData(ctx context.Context, passwrod string, userData *data.Data) (*data.EncryptedData, error) {
+	ctx, span := tracing.Start(ctx, "encr.EncryptData")
+	defer span.End()
+
 	// создаю ключ шифрования из мастер пароля пользователя
-	key := key.DeriveKey(passwrod, 32)
+	key := deriveKey(ctx, passwrod)
 
 	// Сериализую данные пользователя в массив байт
 	var bufEncode bytes.Buffer
@@ -25,21 +30,24 @@ func EncryptData(passwrod string, userData *data.Data) (*data.EncryptedData, err
 	// Шифрую данные
 	encrDta, err := encryption.EncryptAES256(key, bufEncode.Bytes())
 	if err != nil {
-		return nil, fmt.Errorf("failed to encrypt data, %w", err)
+		return nil, tracing.Error(span, fmt.Errorf("failed to encrypt data, %w", err))
 	}
 
 	return &data.EncryptedData{EncryptedData: encrDta, Name: userData.Name}, nil
 }
 
 // DecryptData - функция для шифрования пользовательских данных.
-func DecryptData(passwrod string, encrData *data.EncryptedData) (*data.Data, error) {
+func DecryptData(ctx context.Context, passwrod string, encrData *data.EncryptedData) (*data.Data, error) {
+	ctx, span := tracing.Start(ctx, "encr.DecryptData")
+	defer span.End()
+
 	// создаю ключ шифрования из мастер пароля пользователя
-	key := key.DeriveKey(passwrod, 32)
+	key := deriveKey(ctx, passwrod)
 
 	// Расшифровываю данные
 	res, err := encryption.DecryptAES256(key, encrData.EncryptedData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt data, %w", err)
+		return nil, tracing.Error(span, fmt.Errorf("failed to decrypt data, %w", err))
 	}
 
 	// Десериализую расшифрованные данные в структуру
@@ -51,3 +59,11 @@ func DecryptData(passwrod string, encrData *data.EncryptedData) (*data.Data, err
 
 	return &userData, nil
 }
+
+// deriveKey - функция для создания ключа шифрования из мастер пароля. Создание ключа выполняется в отдельном спане,
+// так как PBKDF2 занимает основное время шифрования.
+func deriveKey(ctx context.Context, password string) []byte {
+	_, span := tracing.Start(ctx, "key.DeriveKey")
+	defer span.End()
+	return key.DeriveKey(password, 32)
+}
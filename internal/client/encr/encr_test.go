@@ -1,6 +1,7 @@
 package encr
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -37,11 +38,11 @@ func TestEncryptData(t *testing.T) {
 		testPass := "some strong master password of user"
 
 		// Шифрую данные
-		testEncrData, err := EncryptData(testPass, &testData)
+		testEncrData, err := EncryptData(context.Background(), testPass, &testData)
 		require.NoError(t, err)
 
 		// расшифровываю данные
-		testDecrData, err := DecryptData(testPass, testEncrData)
+		testDecrData, err := DecryptData(context.Background(), testPass, testEncrData)
 		require.NoError(t, err)
 
 		assert.Equal(t, true, CompareData(&testData, testDecrData))
@@ -63,11 +64,11 @@ func TestDecryptData(t *testing.T) {
 		testPass := "some strong master password of user"
 
 		// Шифрую данные
-		testEncrData, err := EncryptData(testPass, &testData)
+		testEncrData, err := EncryptData(context.Background(), testPass, &testData)
 		require.NoError(t, err)
 
 		// расшифровываю данные
-		testDecrData, err := DecryptData(testPass, testEncrData)
+		testDecrData, err := DecryptData(context.Background(), testPass, testEncrData)
 		require.NoError(t, err)
 
 		assert.Equal(t, true, CompareData(&testData, testDecrData))
@@ -86,11 +87,11 @@ func TestDecryptData(t *testing.T) {
 		testPass := "some strong master password of user"
 
 		// Шифрую данные
-		testEncrData, err := EncryptData(testPass, &testData)
+		testEncrData, err := EncryptData(context.Background(), testPass, &testData)
 		require.NoError(t, err)
 
 		// расшифровываю данные
-		_, err = DecryptData("wrong strong password", testEncrData)
+		_, err = DecryptData(context.Background(), "wrong strong password", testEncrData)
 		require.Error(t, err)
 
 	}
@@ -44,6 +44,33 @@ func EncryptAES256(key []byte, data []byte) ([]byte, error) {
 	return result, nil
 }
 
+// EncryptAES256WithNonce - функция для шифрования данных с помощью алгоритма AES256 с заданным вектором инициализации.
+// Используется для детерминированного шифрования, когда одинаковые данные должны давать одинаковый результат.
+// Вектор инициализации не должен повторяться для разных данных, зашифрованных одним ключом.
+// Результат расшифровывается функцией DecryptAES256.
+func EncryptAES256WithNonce(key, nonce, data []byte) ([]byte, error) {
+	// Проверка длины ключа для соответстия алгоритму AES256
+	if len(key) < 32 {
+		return nil, errors.New("lenth of key is not equal 32 for AES256")
+	}
+
+	aesblock, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new cipher.Block, %w", err)
+	}
+	aesgcm, err := cipher.NewGCM(aesblock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new gcm, %w", err)
+	}
+	if len(nonce) < aesgcm.NonceSize() {
+		return nil, errors.New("initialization vector is too short")
+	}
+	nonce = nonce[:aesgcm.NonceSize()]
+
+	result := append([]byte{}, nonce...)
+	return aesgcm.Seal(result, nonce, data, nil), nil
+}
+
 // DecryptAES256 - функция для расшифровывания данных с помощью алгоритма AES256.
 func DecryptAES256(key []byte, encrData []byte) ([]byte, error) {
 	// Проверка длины ключа для соответстия алгоритму AES256
@@ -66,6 +93,9 @@ func DecryptAES256(key []byte, encrData []byte) ([]byte, error) {
 	}
 
 	// извлекаю вектор инициализации из полученных данных
+	if len(encrData) < aesgcm.NonceSize() {
+		return nil, errors.New("encrypted data is too short")
+	}
 	nonce := encrData[:aesgcm.NonceSize()]
 
 	data, err := aesgcm.Open(nil, nonce, encrData[aesgcm.NonceSize():], nil) // расшифровываем
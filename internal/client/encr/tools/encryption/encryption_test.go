@@ -68,3 +68,39 @@ func TestDecryptAES256(t *testing.T) {
 		require.Error(t, err)
 	}
 }
+
+func TestEncryptAES256WithNonce(t *testing.T) {
+	key, err := random.GenerateCryptoRandom(32)
+	require.NoError(t, err)
+	nonce, err := random.GenerateCryptoRandom(32)
+	require.NoError(t, err)
+	testData := []byte("some test data")
+	{
+		// Одинаковые данные с одинаковым вектором инициализации дают одинаковый результат
+		encrData1, err := EncryptAES256WithNonce(key, nonce, testData)
+		require.NoError(t, err)
+		encrData2, err := EncryptAES256WithNonce(key, nonce, testData)
+		require.NoError(t, err)
+		assert.Equal(t, encrData1, encrData2)
+
+		// Результат расшифровывается функцией DecryptAES256
+		decrData, err := DecryptAES256(key, encrData1)
+		require.NoError(t, err)
+		assert.Equal(t, testData, decrData)
+	}
+	{
+		// Тест с ключем неподходящей длины
+		_, err := EncryptAES256WithNonce(key[:16], nonce, testData)
+		require.Error(t, err)
+	}
+	{
+		// Тест с коротким вектором инициализации
+		_, err := EncryptAES256WithNonce(key, nonce[:4], testData)
+		require.Error(t, err)
+	}
+	{
+		// Расшифровка слишком коротких данных
+		_, err := DecryptAES256(key, []byte("short"))
+		require.Error(t, err)
+	}
+}
@@ -2,6 +2,7 @@
 package key
 
 import (
+	"crypto/hmac"
 	"crypto/sha256"
 
 	"golang.org/x/crypto/pbkdf2"
@@ -13,3 +14,11 @@ func DeriveKey(password string, len int) []byte {
 	passwordByte := []byte(password)
 	return pbkdf2.Key(passwordByte, passwordByte, iterations, len, sha256.New)
 }
+
+// DeriveSubKey - функция для получения из ключа master независимого ключа длиной 32 байта для указанного назначения
+// с помощью HMAC-SHA256. Позволяет не использовать один ключ для разных алгоритмов.
+func DeriveSubKey(master []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, master)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
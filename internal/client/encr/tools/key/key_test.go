@@ -19,3 +19,15 @@ func TestDeriveKey(t *testing.T) {
 		assert.Equal(t, key1, key2)
 	}
 }
+
+func TestDeriveSubKey(t *testing.T) {
+	master := DeriveKey("some strong password", 32)
+
+	key1 := DeriveSubKey(master, "first")
+	assert.Equal(t, 32, len(key1))
+	assert.Equal(t, key1, DeriveSubKey(master, "first"))
+
+	// ключи для разных назначений различаются
+	assert.NotEqual(t, key1, DeriveSubKey(master, "second"))
+	assert.NotEqual(t, master, key1)
+}
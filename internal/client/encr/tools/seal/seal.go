@@ -0,0 +1,87 @@
+// Пакет seal реализует шифрование данных открытым ключом получателя по схеме ECIES: для каждого сообщения создается
+// временная ключевая пара X25519, из общего секрета временного ключа и ключа получателя вычисляется ключ AES256.
+// Расшифровать сообщение может только владелец закрытого ключа получателя.
+package seal
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/encryption"
+	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/key"
+)
+
+// purpose - назначение ключа шифрования сообщения, вычисляемого из общего секрета.
+const purpose = "gophkeeper sealed box"
+
+// keySize - размер открытого ключа X25519.
+const keySize = 32
+
+// ErrShortMessage - ошибка расшифровывания сообщения, которое короче временного открытого ключа.
+var ErrShortMessage = errors.New("sealed message is too short")
+
+// GenerateKeyPair - функция для создания ключевой пары X25519.
+func GenerateKeyPair() (public, private []byte, err error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate key pair, %w", err)
+	}
+	return priv.PublicKey().Bytes(), priv.Bytes(), nil
+}
+
+// Seal - функция для шифрования сообщения открытым ключом получателя. Результат содержит временный открытый ключ
+// и зашифрованное сообщение.
+func Seal(publicKey, message []byte) ([]byte, error) {
+	recipient, err := ecdh.X25519().NewPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key, %w", err)
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ephemeral key, %w", err)
+	}
+	secret, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute shared secret, %w", err)
+	}
+
+	ephemeralPublic := ephemeral.PublicKey().Bytes()
+	encrMessage, err := encryption.EncryptAES256(deriveKey(secret, ephemeralPublic, publicKey), message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt message, %w", err)
+	}
+	return append(ephemeralPublic, encrMessage...), nil
+}
+
+// Open - функция для расшифровывания сообщения, зашифрованного функцией Seal, закрытым ключом получателя.
+func Open(privateKey, sealed []byte) ([]byte, error) {
+	if len(sealed) < keySize {
+		return nil, ErrShortMessage
+	}
+	priv, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key, %w", err)
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(sealed[:keySize])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ephemeral key, %w", err)
+	}
+	secret, err := priv.ECDH(ephemeral)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute shared secret, %w", err)
+	}
+
+	message, err := encryption.DecryptAES256(deriveKey(secret, sealed[:keySize], priv.PublicKey().Bytes()), sealed[keySize:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt message, %w", err)
+	}
+	return message, nil
+}
+
+// deriveKey - функция для вычисления ключа шифрования сообщения из общего секрета. Ключ привязан к временному
+// ключу и ключу получателя, поэтому сообщение нельзя переадресовать другому получателю.
+func deriveKey(secret, ephemeralPublic, recipientPublic []byte) []byte {
+	return key.DeriveSubKey(secret, purpose+string(ephemeralPublic)+string(recipientPublic))
+}
@@ -0,0 +1,48 @@
+package seal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	public, private, err := GenerateKeyPair()
+	require.NoError(t, err)
+	assert.Len(t, public, 32)
+	assert.Len(t, private, 32)
+
+	message := []byte("record key")
+	sealed, err := Seal(public, message)
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), string(message))
+
+	// каждое шифрование использует новый временный ключ
+	other, err := Seal(public, message)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, other)
+
+	opened, err := Open(private, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, message, opened)
+
+	// чужой закрытый ключ не расшифровывает сообщение
+	_, otherPrivate, err := GenerateKeyPair()
+	require.NoError(t, err)
+	_, err = Open(otherPrivate, sealed)
+	require.Error(t, err)
+
+	// измененное сообщение не расшифровывается
+	sealed[len(sealed)-1] ^= 0xff
+	_, err = Open(private, sealed)
+	require.Error(t, err)
+
+	// некорректные ключи и сообщения
+	_, err = Seal([]byte("short"), message)
+	require.Error(t, err)
+	_, err = Open(private, []byte("short"))
+	require.ErrorIs(t, err, ErrShortMessage)
+	_, err = Open([]byte("short"), other)
+	require.Error(t, err)
+}
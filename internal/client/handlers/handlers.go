@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/abezemskiy/gophkeeper/internal/client/encr"
 	"github.com/abezemskiy/gophkeeper/internal/client/identity"
@@ -15,6 +16,9 @@ import (
 	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/hasher"
 	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/header"
 	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/id"
+	"github.com/abezemskiy/gophkeeper/internal/common/tracing"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/audit"
 	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
 	repoIdent "github.com/abezemskiy/gophkeeper/internal/repositories/identity"
 
@@ -45,9 +49,12 @@ func SaveEncryptedDataToLocalStorage(ctx context.Context, userID string, stor st
 // и происходит попытка отправки данных на сервер.
 func SaveEncryptedData(ctx context.Context, userID, url string, client *resty.Client, stor storage.IEncryptedClientStorage,
 	encrData *data.EncryptedData) (bool, error) {
+	ctx, span := tracing.Start(ctx, "handlers.SaveEncryptedData")
+	defer span.End()
 
 	// попытка отправить новые данные на сервер
 	resp, err := client.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(*encrData).
 		Post(url)
@@ -78,6 +85,12 @@ func SaveEncryptedData(ctx context.Context, userID, url string, client *resty.Cl
 		return SaveEncryptedDataToLocalStorage(ctx, userID, stor, *encrData, data.NEW)
 	}
 
+	// Превышена квота пользователя на сервере
+	if err := quotaError(resp); err != nil {
+		logger.ClientLog.Error("push json encrypted to server error", zap.String("error", err.Error()))
+		return false, err
+	}
+
 	// Сервер вернул иной статус
 	logger.ClientLog.Error("push json encrypted to server error", zap.String("status", fmt.Sprintf("%d", resp.StatusCode())))
 	return false, fmt.Errorf("push json encrypted to server error, status %d", resp.StatusCode())
@@ -87,9 +100,11 @@ func SaveEncryptedData(ctx context.Context, userID, url string, client *resty.Cl
 // и происходит попытка отправки данных на сервер.
 func SaveData(ctx context.Context, userID, url, masterPass string, client *resty.Client, stor storage.IEncryptedClientStorage,
 	userData *data.Data) (bool, error) {
+	ctx, span := tracing.Start(ctx, "handlers.SaveData")
+	defer span.End()
 
 	// шифрую данные с помощью мастер пароля пользователя
-	encrData, err := encr.EncryptData(masterPass, userData)
+	encrData, err := encr.EncryptData(ctx, masterPass, userData)
 	if err != nil {
 		logger.ClientLog.Error("failed to encrypt data", zap.String("error", error.Error(err)))
 		return false, fmt.Errorf("failed to encrypt data, %w", err)
@@ -108,6 +123,9 @@ func SaveData(ctx context.Context, userID, url, masterPass string, client *resty
 
 // Register - хэндлер для регистрации нового пользователя.
 func Register(ctx context.Context, url string, authData *identity.AuthData, client *resty.Client, ident identity.ClientIdentifier) (bool, error) {
+	ctx, span := tracing.Start(ctx, "handlers.Register")
+	defer span.End()
+
 	// проверяю корректность логина
 	ok := checker.CheckLogin(authData.Login)
 	if !ok {
@@ -135,6 +153,7 @@ func Register(ctx context.Context, url string, authData *identity.AuthData, clie
 
 	// Отправляю запрос регистрации пользователя на сервер
 	resp, err := client.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(regData).
 		Post(url)
@@ -198,6 +217,9 @@ func Register(ctx context.Context, url string, authData *identity.AuthData, clie
 // После успешной авторизации данные пользователя устанавливаются в хранилище на время сесси для использования в других методах.
 func Authorize(ctx context.Context, authData *identity.AuthData, ident identity.ClientIdentifier,
 	info identity.IUserInfoStorage) (passIsCorrect bool, registered bool, err error) {
+	ctx, span := tracing.Start(ctx, "handlers.Authorize")
+	defer span.End()
+
 	// проверяю корректность логина
 	ok := checker.CheckLogin(authData.Login)
 	if !ok {
@@ -244,6 +266,113 @@ func Authorize(ctx context.Context, authData *identity.AuthData, ident identity.
 	return true, true, nil
 }
 
+// Login - хэндлер для входа пользователя, который зарегистрирован на сервере, но ещё не входил с данного устройства.
+// Пользователь авторизуется на сервере, полученный токен сохраняется в локальном хранилище.
+// Если пользователь не зарегистрирован на сервере или пароль неверный, возвращается false.
+// После успешного входа данные пользователя устанавливаются в хранилище на время сесси.
+func Login(ctx context.Context, url string, authData *identity.AuthData, client *resty.Client, ident identity.ClientIdentifier,
+	info identity.IUserInfoStorage) (bool, error) {
+	ctx, span := tracing.Start(ctx, "handlers.Login")
+	defer span.End()
+
+	// проверяю корректность логина
+	ok := checker.CheckLogin(authData.Login)
+	if !ok {
+		return false, fmt.Errorf("login is not valid")
+	}
+
+	// проверяю корректность пароля
+	ok = checker.CheckPassword(authData.Password)
+	if !ok {
+		return false, fmt.Errorf("password is not valid")
+	}
+
+	// вычисляю хэш на основе логина и пароля
+	hash, err := hasher.CalkHash(authData.Login + authData.Password)
+	if err != nil {
+		logger.ClientLog.Error("failed to calculate hash", zap.String("error", error.Error(err)))
+		return false, fmt.Errorf("failed to calculate hash, %w", err)
+	}
+
+	// Отправляю запрос авторизации пользователя на сервер
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(repoIdent.Data{
+			Login: authData.Login,
+			Hash:  hash,
+		}).
+		Post(url)
+
+	// Вход с нового устройства в состоянии офлайн невозможен, возвращаю ошибку.
+	if err != nil {
+		logger.ClientLog.Error("sending authorization request failed", zap.String("error", error.Error(err)))
+		return false, fmt.Errorf("sending authorization request failed, %w", err)
+	}
+
+	// Пользователь не зарегистрирован на сервере или пароль неверный
+	if resp.StatusCode() == http.StatusBadRequest {
+		logger.ClientLog.Error("server rejected authorization", zap.String("login", authData.Login))
+		return false, nil
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		logger.ClientLog.Error("bad server status", zap.String("status", fmt.Sprint(resp.StatusCode())))
+		return false, fmt.Errorf("bad server status %d", resp.StatusCode())
+	}
+
+	// Получаю токен из заголовка, который отправил сервер.
+	token, err := header.GetTokenFromRestyResponseHeader(resp)
+	if err != nil {
+		logger.ClientLog.Error("failed to get JWT from server responce", zap.String("error", error.Error(err)))
+		return false, fmt.Errorf("failed to get JWT from server responce, %w", err)
+	}
+
+	// Проверяю, входил ли пользователь ранее с данного устройства
+	userInfo, ok, err := ident.Authorize(ctx, authData.Login)
+	if err != nil {
+		logger.ClientLog.Error("failed to getting user info from storage", zap.String("error", error.Error(err)))
+		return false, fmt.Errorf("failed to getting user info from storage, %w", err)
+	}
+
+	if ok {
+		// Пользователь уже сохранен локально, хэш должен совпадать с тем, что принял сервер
+		if userInfo.Hash != hash {
+			logger.ClientLog.Error("local user data differs from server", zap.String("login", authData.Login))
+			return false, fmt.Errorf("local data of user %s differs from server", authData.Login)
+		}
+		ok, err = ident.SetToken(ctx, authData.Login, token)
+		if err != nil {
+			logger.ClientLog.Error("failed to set token in local storage", zap.String("error", error.Error(err)))
+			return false, fmt.Errorf("failed to set token of user %s in local storage, %w", authData.Login, err)
+		}
+		if !ok {
+			return false, fmt.Errorf("user %s not register in local storage", authData.Login)
+		}
+	} else {
+		// вычисляю локальный идентификатор пользователя для нового устройства
+		userInfo.ID, err = id.GenerateID()
+		if err != nil {
+			logger.ClientLog.Error("failed to generate id", zap.String("error", error.Error(err)))
+			return false, fmt.Errorf("failed to generate id, %w", err)
+		}
+		ok, err = ident.Register(ctx, authData.Login, hash, userInfo.ID, token)
+		if err != nil {
+			logger.ClientLog.Error("failed to register user in local storage", zap.String("error", error.Error(err)))
+			return false, fmt.Errorf("failed to register user %s in local storage, %w", authData.Login, err)
+		}
+		if !ok {
+			return false, fmt.Errorf("user %s already register in local storage", authData.Login)
+		}
+	}
+
+	// Устанавливаю данные пользователя в хранилище
+	info.Set(*authData, userInfo.ID)
+
+	logger.ClientLog.Info("user successfully logged in", zap.String("login", authData.Login))
+	return true, nil
+}
+
 // DeleteEncryptedDataFromLocalStorage - функция для удаления данных пользователя в локальном хранилище.
 func DeleteEncryptedDataFromLocalStorage(ctx context.Context, userID, dataName string, stor storage.IEncryptedClientStorage) (bool, error) {
 
@@ -265,9 +394,12 @@ func DeleteEncryptedDataFromLocalStorage(ctx context.Context, userID, dataName s
 // DeleteEncryptedData - хэндлер для удаления данных пользователя на сервере и из локального хранилища по имени этих данных.
 // Удаление данных разрешено только в статусе онлайн.
 func DeleteEncryptedData(ctx context.Context, userID, url, dataName string, client *resty.Client, stor storage.IEncryptedClientStorage) (bool, error) {
+	ctx, span := tracing.Start(ctx, "handlers.DeleteEncryptedData")
+	defer span.End()
 
 	// попытка удалить данные пользователя на сервере
 	resp, err := client.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(data.MetaInfo{
 			Name: dataName,
@@ -340,9 +472,12 @@ func OfflineReplaceEncryptedData(ctx context.Context, userID string, stor storag
 // вместо старых и происходит попытка отправки данных на сервер.
 func ReplaceEncryptedData(ctx context.Context, userID, url string, client *resty.Client, stor storage.IEncryptedClientStorage,
 	encrData *data.EncryptedData) (bool, error) {
+	ctx, span := tracing.Start(ctx, "handlers.ReplaceEncryptedData")
+	defer span.End()
 
 	// попытка отправить новые данные на сервер
 	resp, err := client.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(*encrData).
 		Post(url)
@@ -408,6 +543,12 @@ func ReplaceEncryptedData(ctx context.Context, userID, url string, client *resty
 		return true, nil
 	}
 
+	// Превышена квота пользователя на сервере
+	if err := quotaError(resp); err != nil {
+		logger.ClientLog.Error("push json encrypted to server error", zap.String("error", err.Error()))
+		return false, err
+	}
+
 	// Сервер вернул иной статус
 	logger.ClientLog.Error("push json encrypted to server error", zap.String("status", fmt.Sprintf("%d", resp.StatusCode())))
 	return false, fmt.Errorf("push json encrypted to server error with status %d", resp.StatusCode())
@@ -417,9 +558,11 @@ func ReplaceEncryptedData(ctx context.Context, userID, url string, client *resty
 // вместо старых данных и происходит попытка отправки данных на сервер.
 func ReplaceData(ctx context.Context, userID, url, masterPass string, client *resty.Client, stor storage.IEncryptedClientStorage,
 	userData *data.Data) (bool, error) {
+	ctx, span := tracing.Start(ctx, "handlers.ReplaceData")
+	defer span.End()
 
 	// шифрую данные с помощью мастер пароля пользователя
-	encrData, err := encr.EncryptData(masterPass, userData)
+	encrData, err := encr.EncryptData(ctx, masterPass, userData)
 	if err != nil {
 		logger.ClientLog.Error("failed to encrypt data", zap.String("error", error.Error(err)))
 		return false, fmt.Errorf("failed to encrypt data, %w", err)
@@ -435,3 +578,111 @@ func ReplaceData(ctx context.Context, userID, url, masterPass string, client *re
 	}
 	return true, nil
 }
+
+// ErrQuotaExceeded - ошибка превышения квоты пользователя на сервере.
+var ErrQuotaExceeded = errors.New("server quota exceeded")
+
+// quotaError - функция для получения ошибки превышения квоты пользователя из ответа сервера.
+// Сервер возвращает статус 413, если запрос слишком большой, и статус 507, если превышена квота хранилища.
+// Если ответ не связан с квотой, возвращается nil.
+func quotaError(resp *resty.Response) error {
+	if resp.StatusCode() != http.StatusRequestEntityTooLarge && resp.StatusCode() != http.StatusInsufficientStorage {
+		return nil
+	}
+	return fmt.Errorf("%w, %s", ErrQuotaExceeded, api.ParseError(resp.StatusCode(), resp.Body()).Message)
+}
+
+// GetUsage - функция для получения информации об использовании хранилища пользователем на сервере и его квотах.
+func GetUsage(ctx context.Context, url string, client *resty.Client) (data.Usage, error) {
+	ctx, span := tracing.Start(ctx, "handlers.GetUsage")
+	defer span.End()
+
+	var usage data.Usage
+	resp, err := client.R().
+		SetContext(ctx).
+		SetResult(&usage).
+		Get(url)
+	if err != nil {
+		logger.ClientLog.Error("get usage from server error", zap.String("error", error.Error(err)))
+		return data.Usage{}, fmt.Errorf("get usage from server error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		logger.ClientLog.Error("get usage from server error", zap.String("status", strconv.Itoa(resp.StatusCode())))
+		return data.Usage{}, fmt.Errorf("get usage from server error, %w", api.ParseError(resp.StatusCode(), resp.Body()))
+	}
+	return usage, nil
+}
+
+// GetAuditEvents - функция для получения журнала аудита пользователя с сервера от новых событий к старым.
+// Нулевое время since не ограничивает выборку, при нулевом limit сервер возвращает количество событий по умолчанию.
+func GetAuditEvents(ctx context.Context, url string, client *resty.Client, since time.Time, limit int) ([]audit.Event, error) {
+	ctx, span := tracing.Start(ctx, "handlers.GetAuditEvents")
+	defer span.End()
+
+	req := client.R().SetContext(ctx)
+	if !since.IsZero() {
+		req.SetQueryParam("since", since.Format(time.RFC3339))
+	}
+	if limit > 0 {
+		req.SetQueryParam("limit", strconv.Itoa(limit))
+	}
+	var events []audit.Event
+	resp, err := req.SetResult(&events).Get(url)
+	if err != nil {
+		logger.ClientLog.Error("get audit events from server error", zap.String("error", error.Error(err)))
+		return nil, fmt.Errorf("get audit events from server error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		logger.ClientLog.Error("get audit events from server error", zap.String("status", strconv.Itoa(resp.StatusCode())))
+		return nil, fmt.Errorf("get audit events from server error, %w", api.ParseError(resp.StatusCode(), resp.Body()))
+	}
+	return events, nil
+}
+
+// DeleteAccount - хэндлер для удаления учетной записи пользователя на сервере вместе со всеми его данными.
+// Для повторной аутентификации серверу передается хэш от пары логин+пароль authData. После удаления на сервере
+// пользователь и его данные удаляются из локального хранилища, а данные пользователя в info сбрасываются.
+// Удаление учетной записи в состоянии офлайн невозможно.
+func DeleteAccount(ctx context.Context, url string, authData *identity.AuthData, client *resty.Client, remover identity.AccountRemover,
+	info identity.IUserInfoStorage) error {
+	ctx, span := tracing.Start(ctx, "handlers.DeleteAccount")
+	defer span.End()
+
+	// вычисляю хэш на основе логина и пароля для повторной аутентификации
+	hash, err := hasher.CalkHash(authData.Login + authData.Password)
+	if err != nil {
+		logger.ClientLog.Error("failed to calculate hash", zap.String("error", error.Error(err)))
+		return fmt.Errorf("failed to calculate hash, %w", err)
+	}
+
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(repoIdent.Data{
+			Login: authData.Login,
+			Hash:  hash,
+		}).
+		Delete(url)
+	if err != nil {
+		logger.ClientLog.Error("sending delete account request failed", zap.String("error", error.Error(err)))
+		return fmt.Errorf("sending delete account request failed, %w", err)
+	}
+	if resp.StatusCode() != http.StatusNoContent {
+		logger.ClientLog.Error("delete account on server error", zap.String("status", strconv.Itoa(resp.StatusCode())))
+		return fmt.Errorf("delete account on server error, %w", api.ParseError(resp.StatusCode(), resp.Body()))
+	}
+
+	// Учетная запись удалена на сервере, удаляю пользователя и его данные с данного устройства
+	ok, err := remover.DeleteUser(ctx, authData.Login)
+	if err != nil {
+		logger.ClientLog.Error("failed to delete user from local storage", zap.String("error", error.Error(err)))
+		return fmt.Errorf("account is deleted on server, but failed to delete user %s from local storage, %w", authData.Login, err)
+	}
+	if !ok {
+		logger.ClientLog.Warn("user not register in local storage", zap.String("login", authData.Login))
+	}
+	info.Set(identity.AuthData{}, "")
+
+	logger.ClientLog.Info("user account successfully deleted", zap.String("login", authData.Login))
+	return nil
+}
@@ -7,11 +7,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/abezemskiy/gophkeeper/internal/client/identity"
 	"github.com/abezemskiy/gophkeeper/internal/client/storage"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/info"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/memory"
 	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/hasher"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/audit"
 	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	repoIdent "github.com/abezemskiy/gophkeeper/internal/repositories/identity"
 	"github.com/abezemskiy/gophkeeper/internal/repositories/mocks"
 
 	"github.com/go-chi/chi"
@@ -178,6 +184,20 @@ func TestSaveEncryptedData(t *testing.T) {
 				err: true,
 			},
 		},
+		{
+			name: "quota exceeded",
+			req: request{
+				userID:      userID,
+				encrData:    encrData,
+				startServer: true,
+				stor:        m,
+				httpStatus:  http.StatusInsufficientStorage,
+			},
+			want: want{
+				ok:  false,
+				err: true,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1143,3 +1163,338 @@ func TestReplaceEncryptedData(t *testing.T) {
 		})
 	}
 }
+
+func TestLogin(t *testing.T) {
+	// Вспомогательная функция---------------------------------------
+	testHandler := func(status int, token string) http.HandlerFunc {
+		return func(res http.ResponseWriter, _ *http.Request) {
+			// Если ожидается успешный запрос, то устанавливаю токен в заголовок
+			if status == http.StatusOK {
+				res.Header().Set("Authorization", "Bearer "+token)
+			}
+			// устанавливаю нужный статус в ответ
+			res.WriteHeader(status)
+		}
+	}
+
+	authData := identity.AuthData{
+		Login:    "login",
+		Password: "password",
+	}
+	hash, err := hasher.CalkHash(authData.Login + authData.Password)
+	require.NoError(t, err)
+
+	// Пользователь, который ранее входил с данного устройства, но с другим паролем
+	changedAuthData := identity.AuthData{
+		Login:    "changed login",
+		Password: "password",
+	}
+
+	// регистрирую мок хранилища аутентификационных данных клиента для проверки ошибок хранилища
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := mocks.NewMockClientIdentifier(ctrl)
+	errorAuthData := identity.AuthData{
+		Login:    "error login",
+		Password: "error password",
+	}
+	m.EXPECT().Authorize(gomock.Any(), errorAuthData.Login).Return(identity.UserInfo{}, false, errors.New("some error"))
+
+	// Локальное хранилище устройства, общее для всех тестов
+	stor := memory.NewStore()
+	_, err = stor.Register(context.Background(), changedAuthData.Login, "old hash", "changed id", "old token")
+	require.NoError(t, err)
+
+	type request struct {
+		wrongURL bool
+		authData identity.AuthData
+		ident    identity.ClientIdentifier
+		status   int
+		token    string
+	}
+	type want struct {
+		err bool
+		ok  bool
+	}
+	tests := []struct {
+		name string
+		req  request
+		want want
+	}{
+		{
+			name: "first login from new device",
+			req: request{
+				authData: authData,
+				ident:    stor,
+				status:   200,
+				token:    "first-token",
+			},
+			want: want{
+				err: false,
+				ok:  true,
+			},
+		},
+		{
+			name: "repeated login from same device",
+			req: request{
+				authData: authData,
+				ident:    stor,
+				status:   200,
+				token:    "second-token",
+			},
+			want: want{
+				err: false,
+				ok:  true,
+			},
+		},
+		{
+			name: "invalid login",
+			req: request{
+				authData: identity.AuthData{Login: "", Password: "password"},
+				ident:    stor,
+				status:   200,
+			},
+			want: want{
+				err: true,
+			},
+		},
+		{
+			name: "server rejected authorization",
+			req: request{
+				authData: authData,
+				ident:    stor,
+				status:   400,
+			},
+			want: want{
+				err: false,
+				ok:  false,
+			},
+		},
+		{
+			name: "bad server status",
+			req: request{
+				authData: authData,
+				ident:    stor,
+				status:   500,
+			},
+			want: want{
+				err: true,
+			},
+		},
+		{
+			name: "server not available",
+			req: request{
+				wrongURL: true,
+				authData: authData,
+				ident:    stor,
+				status:   200,
+			},
+			want: want{
+				err: true,
+			},
+		},
+		{
+			name: "local data differs from server",
+			req: request{
+				authData: changedAuthData,
+				ident:    stor,
+				status:   200,
+				token:    "token",
+			},
+			want: want{
+				err: true,
+			},
+		},
+		{
+			name: "local storage error",
+			req: request{
+				authData: errorAuthData,
+				ident:    m,
+				status:   200,
+				token:    "token",
+			},
+			want: want{
+				err: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// создаю тестовый http сервер
+			r := chi.NewRouter()
+			r.Post("/test", testHandler(tt.req.status, tt.req.token))
+
+			// Запускаю тестовый сервер
+			ts := httptest.NewServer(r)
+			defer ts.Close()
+
+			var url string
+			if !tt.req.wrongURL {
+				url = ts.URL + "/test"
+			} else {
+				// устанавливаю невалидный url, иммитирую недоступность сервера
+				url = "http://wrong.address.com" + "/test"
+			}
+
+			userInfo := info.NewUserInfoStorage()
+			ok, err := Login(context.Background(), url, &tt.req.authData, resty.New(), tt.req.ident, userInfo)
+			if tt.want.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.ok, ok)
+			if !ok {
+				return
+			}
+
+			// Проверяю, что пользователь сохранен локально с актуальным токеном
+			local, exist, err := stor.Authorize(context.Background(), tt.req.authData.Login)
+			require.NoError(t, err)
+			require.Equal(t, true, exist)
+			assert.Equal(t, hash, local.Hash)
+			assert.Equal(t, tt.req.token, local.Token)
+
+			gotAuthData, gotID := userInfo.Get()
+			assert.Equal(t, tt.req.authData, gotAuthData)
+			assert.Equal(t, local.ID, gotID)
+		})
+	}
+}
+
+func TestQuotaError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "success", status: http.StatusOK, wantErr: false},
+		{name: "not found", status: http.StatusNotFound, wantErr: false},
+		{name: "request too large", status: http.StatusRequestEntityTooLarge, wantErr: true},
+		{name: "storage quota exceeded", status: http.StatusInsufficientStorage, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, _ *http.Request) {
+				http.Error(res, "storage quota exceeded: used 10 of 10 bytes", tt.status)
+			}))
+			defer ts.Close()
+
+			resp, err := resty.New().R().Get(ts.URL)
+			require.NoError(t, err)
+			err = quotaError(resp)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrQuotaExceeded)
+				assert.Contains(t, err.Error(), "used 10 of 10 bytes")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetUsage(t *testing.T) {
+	usage := data.Usage{Bytes: 10, Records: 1, Versions: 1, MaxBytes: 100}
+
+	r := chi.NewRouter()
+	r.Get("/usage", func(res http.ResponseWriter, _ *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(res).Encode(usage)
+	})
+	r.Get("/error", func(res http.ResponseWriter, _ *http.Request) {
+		res.WriteHeader(http.StatusInternalServerError)
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	got, err := GetUsage(context.Background(), ts.URL+"/usage", resty.New())
+	require.NoError(t, err)
+	assert.Equal(t, usage, got)
+
+	_, err = GetUsage(context.Background(), ts.URL+"/error", resty.New())
+	assert.Error(t, err)
+
+	_, err = GetUsage(context.Background(), "http://wrong.address.com/usage", resty.New())
+	assert.Error(t, err)
+}
+
+func TestGetAuditEvents(t *testing.T) {
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	events := []audit.Event{{ID: 2, Time: since.Add(time.Hour), Type: audit.LoginSuccess, UserID: "id", Device: "laptop", IP: "127.0.0.1"}}
+
+	r := chi.NewRouter()
+	r.Get("/audit", func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, since.Format(time.RFC3339), req.URL.Query().Get("since"))
+		assert.Equal(t, "10", req.URL.Query().Get("limit"))
+		res.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(res).Encode(events)
+	})
+	r.Get("/error", func(res http.ResponseWriter, _ *http.Request) {
+		res.WriteHeader(http.StatusInternalServerError)
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	got, err := GetAuditEvents(context.Background(), ts.URL+"/audit", resty.New(), since, 10)
+	require.NoError(t, err)
+	assert.Equal(t, events, got)
+
+	_, err = GetAuditEvents(context.Background(), ts.URL+"/error", resty.New(), time.Time{}, 0)
+	assert.Error(t, err)
+
+	_, err = GetAuditEvents(context.Background(), "http://wrong.address.com/audit", resty.New(), time.Time{}, 0)
+	assert.Error(t, err)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	authData := identity.AuthData{Login: "login", Password: "password"}
+	hash, err := hasher.CalkHash(authData.Login + authData.Password)
+	require.NoError(t, err)
+
+	// Сервер удаляет учетную запись только при совпадении логина и хэша
+	r := chi.NewRouter()
+	r.Delete("/account", func(res http.ResponseWriter, req *http.Request) {
+		var regData repoIdent.Data
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&regData))
+		if regData.Login != authData.Login || regData.Hash != hash {
+			api.WriteError(res, http.StatusBadRequest, api.CodeInvalidCredentials, "login or password is wrong")
+			return
+		}
+		res.WriteHeader(http.StatusNoContent)
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx := context.Background()
+	stor := memory.NewStore()
+	_, err = stor.Register(ctx, authData.Login, hash, "id", "token")
+	require.NoError(t, err)
+	_, err = stor.AddEncryptedData(ctx, "id", data.EncryptedData{EncryptedData: []byte("data"), Name: "name"}, data.SAVED)
+	require.NoError(t, err)
+	userInfo := info.NewUserInfoStorage()
+	userInfo.Set(authData, "id")
+
+	// Неверный пароль, локальные данные не удаляются
+	err = DeleteAccount(ctx, ts.URL+"/account", &identity.AuthData{Login: "login", Password: "wrong"}, resty.New(), stor, userInfo)
+	require.Error(t, err)
+	assert.True(t, api.IsCode(err, api.CodeInvalidCredentials))
+	_, ok, err := stor.Authorize(ctx, authData.Login)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Сервер недоступен
+	err = DeleteAccount(ctx, "http://wrong.address.com/account", &authData, resty.New(), stor, userInfo)
+	require.Error(t, err)
+
+	// Успешное удаление учетной записи на сервере и на устройстве
+	require.NoError(t, DeleteAccount(ctx, ts.URL+"/account", &authData, resty.New(), stor, userInfo))
+	_, ok, err = stor.Authorize(ctx, authData.Login)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	all, err := stor.GetAllEncryptedData(ctx, "id")
+	require.NoError(t, err)
+	assert.Empty(t, all)
+	gotAuth, id := userInfo.Get()
+	assert.Equal(t, identity.AuthData{}, gotAuth)
+	assert.Empty(t, id)
+}
@@ -55,6 +55,7 @@ func OnAfterMiddleware(info identity.IUserInfoStorage, ident identity.ClientIden
 
 			// Отправляю запрос на авторизацию пользователя на сервере
 			resp, err := c.R().
+				SetContext(res.Request.Context()).
 				SetHeader("Content-Type", "application/json").
 				SetBody(repoIdent.Data{
 					Login: authData.Login,
@@ -11,6 +11,11 @@ type ClientIdentifier interface {
 	SetToken(ctx context.Context, login, token string) (ok bool, err error)          // Метод для установки токена для определенного пользователя.
 }
 
+// AccountRemover - интерфейс для удаления учетной записи пользователя из локального хранилища.
+type AccountRemover interface {
+	DeleteUser(ctx context.Context, login string) (ok bool, err error) // Метод для удаления пользователя и всех его данных.
+}
+
 // UserInfo - структура для авторизационных данных пользователя.
 type UserInfo struct {
 	ID    string
@@ -0,0 +1,110 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	clientData "github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+)
+
+// Типы записей Bitwarden.
+const (
+	bitwardenLogin = iota + 1
+	bitwardenNote
+	bitwardenCard
+	bitwardenIdentity
+)
+
+// bitwardenExport - JSON экспорт Bitwarden без шифрования.
+type bitwardenExport struct {
+	Encrypted bool            `json:"encrypted"`
+	Items     []bitwardenItem `json:"items"`
+}
+
+// bitwardenItem - запись Bitwarden.
+type bitwardenItem struct {
+	Type  int    `json:"type"`
+	Name  string `json:"name"`
+	Notes string `json:"notes"`
+	Login *struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		URIs     []struct {
+			URI string `json:"uri"`
+		} `json:"uris"`
+	} `json:"login"`
+	Card *struct {
+		CardholderName string `json:"cardholderName"`
+		Number         string `json:"number"`
+		ExpMonth       string `json:"expMonth"`
+		ExpYear        string `json:"expYear"`
+		Code           string `json:"code"`
+	} `json:"card"`
+	Identity map[string]any `json:"identity"`
+}
+
+// parseBitwarden - функция для разбора JSON экспорта Bitwarden. Заметки импортируются как текст,
+// данные личности - как текст из пар "поле: значение". Зашифрованный экспорт не поддерживается.
+func parseBitwarden(r io.Reader) (Result, error) {
+	var export bitwardenExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return Result{}, fmt.Errorf("failed to decode Bitwarden JSON, %w", err)
+	}
+	if export.Encrypted {
+		return Result{}, fmt.Errorf("encrypted Bitwarden export is not supported, export vault as unencrypted JSON")
+	}
+
+	var result Result
+	for _, item := range export.Items {
+		name := title(item.Name)
+		switch {
+		case item.Type == bitwardenLogin && item.Login != nil:
+			l := login{title: name, username: item.Login.Username, password: item.Login.Password, notes: item.Notes}
+			uris := make([]string, 0, len(item.Login.URIs))
+			for _, u := range item.Login.URIs {
+				uris = append(uris, u.URI)
+			}
+			l.url = strings.Join(uris, "\n")
+			addItem(&result, name, l.toData)
+		case item.Type == bitwardenCard && item.Card != nil:
+			c := card{
+				title:  name,
+				number: item.Card.Number,
+				month:  item.Card.ExpMonth,
+				year:   item.Card.ExpYear,
+				cvv:    item.Card.Code,
+				owner:  item.Card.CardholderName,
+				notes:  item.Notes,
+			}
+			addItem(&result, name, c.toData)
+		case item.Type == bitwardenIdentity && item.Identity != nil:
+			addItem(&result, name, func() (data.Data, error) {
+				return newData(name, data.TEXT, clientData.Text{Text: identityText(item.Identity)}, item.Notes)
+			})
+		case item.Type == bitwardenNote || item.Notes != "":
+			addItem(&result, name, func() (data.Data, error) {
+				return newData(name, data.TEXT, clientData.Text{Text: item.Notes}, "")
+			})
+		default:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: unsupported Bitwarden item type %d", name, item.Type))
+		}
+	}
+	return result, nil
+}
+
+// identityText - функция для преобразования данных личности Bitwarden в текст из пар "поле: значение".
+// Поля выводятся в порядке, принятом в Bitwarden, пустые поля пропускаются.
+func identityText(identity map[string]any) string {
+	fields := []string{"title", "firstName", "middleName", "lastName", "username", "company", "email", "phone",
+		"address1", "address2", "address3", "city", "state", "postalCode", "country", "ssn", "passportNumber", "licenseNumber"}
+	lines := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if v, ok := identity[f].(string); ok && v != "" {
+			lines = append(lines, f+": "+v)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
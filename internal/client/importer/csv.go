@@ -0,0 +1,61 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvRow - строка CSV файла с доступом к значениям по названиям колонок.
+type csvRow struct {
+	columns map[string]int
+	values  []string
+}
+
+// get - функция для получения значения первой из найденных колонок. Названия колонок сравниваются без учета регистра.
+func (r csvRow) get(names ...string) string {
+	for _, name := range names {
+		if i, ok := r.columns[name]; ok && i < len(r.values) {
+			return r.values[i]
+		}
+	}
+	return ""
+}
+
+// has - функция для проверки наличия непустого значения в одной из колонок.
+func (r csvRow) has(names ...string) bool {
+	return strings.TrimSpace(r.get(names...)) != ""
+}
+
+// readCSV - функция для чтения CSV файла с заголовком. Поддерживается BOM в начале файла.
+func readCSV(r io.Reader) ([]csvRow, error) {
+	// Менеджеры паролей в Windows сохраняют CSV с BOM, который мешает разбору заголовка в кавычках
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\ufeff" {
+		_, _ = br.Discard(3)
+	}
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV, %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV file is empty")
+	}
+
+	columns := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+	rows := make([]csvRow, 0, len(records)-1)
+	for _, values := range records[1:] {
+		rows = append(rows, csvRow{columns: columns, values: values})
+	}
+	return rows, nil
+}
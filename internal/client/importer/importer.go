@@ -0,0 +1,343 @@
+// Пакет importer содержит импорт данных из форматов экспорта других менеджеров паролей: KeePass (XML и CSV),
+// Bitwarden (JSON) и 1Password (CSV). Записи преобразуются в данные клиента (пароли, тексты, банковские карты и файлы),
+// для них строится план импорта с учетом политики разрешения конфликтов имен, после чего данные сохраняются
+// обычным путем через handlers.SaveData.
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
+	"github.com/abezemskiy/gophkeeper/internal/client/handlers"
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage"
+	clientData "github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+// Поддерживаемые форматы импорта.
+const (
+	KeePassXML    = "keepass-xml"
+	KeePassCSV    = "keepass-csv"
+	BitwardenJSON = "bitwarden-json"
+	OnePassword   = "1password-csv"
+)
+
+// Formats - список поддерживаемых форматов импорта.
+var Formats = []string{KeePassXML, KeePassCSV, BitwardenJSON, OnePassword}
+
+// Policy - политика разрешения конфликтов, когда данные с таким именем уже существуют.
+type Policy string
+
+// Политики разрешения конфликтов имен.
+const (
+	Skip      Policy = "skip"      // импортируемая запись пропускается
+	Rename    Policy = "rename"    // к имени импортируемой записи добавляется номер
+	Overwrite Policy = "overwrite" // существующие данные заменяются импортируемой записью
+)
+
+// Операции плана импорта.
+const (
+	OpAdd     = "add"
+	OpReplace = "replace"
+	OpSkip    = "skip"
+)
+
+// Result - результат разбора файла экспорта.
+type Result struct {
+	Items    []data.Data // записи, готовые к импорту
+	Warnings []string    // сообщения о записях, которые не удалось импортировать
+}
+
+// Action - действие плана импорта для одной записи.
+type Action struct {
+	Op           string    `json:"op"`                      // операция: add, replace или skip
+	Name         string    `json:"name"`                    // имя данных после импорта
+	OriginalName string    `json:"original_name,omitempty"` // имя записи в файле экспорта, если оно изменено
+	Type         int       `json:"type"`                    // тип данных
+	Data         data.Data `json:"-"`
+}
+
+// Parse - функция для разбора файла экспорта в указанном формате.
+func Parse(format string, r io.Reader) (Result, error) {
+	switch format {
+	case KeePassXML:
+		return parseKeePassXML(r)
+	case KeePassCSV:
+		return parseKeePassCSV(r)
+	case BitwardenJSON:
+		return parseBitwarden(r)
+	case OnePassword:
+		return parseOnePassword(r)
+	default:
+		return Result{}, fmt.Errorf("unknown import format %s, supported formats: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// Plan - функция для построения плана импорта. existing - имена данных пользователя, которые уже сохранены.
+// Конфликтом считается совпадение имени как с существующими данными, так и с ранее импортированной записью.
+func Plan(items []data.Data, existing []string, policy Policy) ([]Action, error) {
+	if policy != Skip && policy != Rename && policy != Overwrite {
+		return nil, fmt.Errorf("unknown conflict policy %s", policy)
+	}
+	taken := make(map[string]bool, len(existing)+len(items))
+	for _, name := range existing {
+		taken[name] = true
+	}
+
+	actions := make([]Action, 0, len(items))
+	for _, item := range items {
+		action := Action{Op: OpAdd, Name: item.Name, Type: item.Type, Data: item}
+		if taken[item.Name] {
+			switch policy {
+			case Skip:
+				action.Op = OpSkip
+			case Overwrite:
+				action.Op = OpReplace
+			case Rename:
+				action.Name = freeName(item.Name, taken)
+				action.OriginalName = item.Name
+				action.Data.Name = action.Name
+			}
+		}
+		taken[action.Name] = true
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// freeName - функция для подбора свободного имени вида "имя (N)".
+func freeName(name string, taken map[string]bool) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)", name, i)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
+
+// Target - параметры сохранения импортируемых данных.
+type Target struct {
+	UserID     string
+	MasterPass string
+	AddURL     string          // адрес добавления новых данных на сервере
+	ReplaceURL string          // адрес замены данных на сервере
+	AttachURLs attachment.URLs // адреса ресурсов вложений на сервере
+	Client     *resty.Client   // клиент с авторизационными мидлварями
+	Stor       storage.IEncryptedClientStorage
+}
+
+// Apply - функция для выполнения плана импорта. Данные сохраняются через handlers.SaveData и handlers.ReplaceData,
+// поэтому в режиме офлайн они сохраняются локально и отправляются на сервер при синхронизации.
+// Большие файлы предварительно загружаются на сервер частями. Возвращает количество сохраненных записей.
+func Apply(ctx context.Context, actions []Action, target Target) (int, error) {
+	saved := 0
+	for _, action := range actions {
+		if action.Op == OpSkip {
+			continue
+		}
+		userData := action.Data
+		if err := uploadLarge(ctx, &userData, target); err != nil {
+			return saved, fmt.Errorf("failed to upload file %s, %w", action.Name, err)
+		}
+
+		if action.Op == OpReplace {
+			// Запоминаю вложения заменяемых версий, чтобы удалить их с сервера после успешной замены данных
+			oldAtts, err := attachment.Manifests(ctx, target.Stor, target.UserID, target.MasterPass, userData.Name)
+			if err != nil {
+				logger.ClientLog.Error("get attachments of data error", zap.String("error", error.Error(err)))
+			}
+			ok, err := handlers.ReplaceData(ctx, target.UserID, target.ReplaceURL, target.MasterPass, target.Client, target.Stor, &userData)
+			if err != nil {
+				return saved, fmt.Errorf("failed to replace data %s, %w", action.Name, err)
+			}
+			if !ok {
+				return saved, fmt.Errorf("data %s does not exist", action.Name)
+			}
+			status, ok, err := target.Stor.GetStatus(ctx, target.UserID, userData.Name)
+			if err == nil && ok && status == data.SAVED {
+				for _, att := range oldAtts {
+					if err := attachment.Release(ctx, target.Client, target.AttachURLs, att); err != nil {
+						logger.ClientLog.Error("release attachment error", zap.String("error", error.Error(err)))
+					}
+				}
+			}
+		} else {
+			ok, err := handlers.SaveData(ctx, target.UserID, target.AddURL, target.MasterPass, target.Client, target.Stor, &userData)
+			if err != nil {
+				return saved, fmt.Errorf("failed to save data %s, %w", action.Name, err)
+			}
+			if !ok {
+				return saved, fmt.Errorf("data %s already exists", action.Name)
+			}
+		}
+		saved++
+	}
+	return saved, nil
+}
+
+// uploadLarge - функция для загрузки на сервер частями файла, который превышает размер хранения в самой записи.
+// Содержимое файла в записи заменяется манифестом вложения.
+func uploadLarge(ctx context.Context, userData *data.Data, target Target) error {
+	if userData.Type != data.BINARY {
+		return nil
+	}
+	var b clientData.Binary
+	if err := json.Unmarshal(userData.Data, &b); err != nil {
+		return fmt.Errorf("failed to unmarshal file, %w", err)
+	}
+	// Файлы до размера одной части хранятся в самой записи, как и при добавлении файла в TUI
+	if b.Attachment != nil || len(b.Binary) <= data.ChunkSize {
+		return nil
+	}
+
+	// Расшифрованное содержимое не записывается на диск, вложение создается прямо из памяти
+	att, err := attachment.NewFromReader(bytes.NewReader(b.Binary), target.MasterPass)
+	if err != nil {
+		return fmt.Errorf("failed to create attachment, %w", err)
+	}
+	err = attachment.UploadFromReader(ctx, target.Client, target.AttachURLs, att, bytes.NewReader(b.Binary),
+		int64(len(b.Binary)), nil)
+	if err != nil {
+		return err
+	}
+	b.Binary, b.Attachment = nil, att
+	payload, err := json.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("failed to marshal file, %w", err)
+	}
+	userData.Data = payload
+	return nil
+}
+
+// addItem - функция для добавления записи в результат разбора. Ошибка преобразования записи не прерывает импорт,
+// а сохраняется в результате как предупреждение.
+func addItem(result *Result, name string, convert func() (data.Data, error)) {
+	item, err := convert()
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %v", name, err))
+		return
+	}
+	result.Items = append(result.Items, item)
+}
+
+// newData - функция для создания данных пользователя с сериализованной полезной нагрузкой.
+func newData(name string, dataType int, payload any, metainfo string) (data.Data, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return data.Data{}, fmt.Errorf("encode data error, %w", err)
+	}
+	now := time.Now()
+	return data.Data{
+		Data:       b,
+		Type:       dataType,
+		Name:       name,
+		Metainfo:   metainfo,
+		Status:     data.NEW,
+		CreateDate: now,
+		EditDate:   now,
+	}, nil
+}
+
+// newFile - функция для создания файла пользователя. Тип файла определяется по содержимому.
+func newFile(name string, content []byte, metainfo string) (data.Data, error) {
+	return newData(name, data.BINARY, clientData.Binary{Binary: content, Type: http.DetectContentType(content)}, metainfo)
+}
+
+// login - вспомогательная структура с полями записи типа логин/пароль, общими для всех форматов.
+type login struct {
+	title    string
+	username string
+	password string
+	url      string
+	notes    string
+}
+
+// toData - функция для преобразования записи типа логин/пароль. Запись без логина и пароля сохраняется как текст.
+func (l login) toData() (data.Data, error) {
+	if l.username == "" && l.password == "" {
+		return newData(l.title, data.TEXT, clientData.Text{Text: l.notes}, l.url)
+	}
+	return newData(l.title, data.PASSWORD, clientData.Password{Login: l.username, Password: l.password}, joinNonEmpty(l.url, l.notes))
+}
+
+// card - вспомогательная структура с полями банковской карты в текстовом виде, общими для всех форматов.
+type card struct {
+	title  string
+	number string
+	month  string
+	year   string
+	cvv    string
+	owner  string
+	notes  string
+}
+
+// toData - функция для преобразования банковской карты. Год срока действия сохраняется двумя цифрами, как в TUI.
+func (c card) toData() (data.Data, error) {
+	number, err := strconv.ParseInt(digits(c.number), 10, 64)
+	if err != nil {
+		return data.Data{}, fmt.Errorf("card number is not valid")
+	}
+	bank := clientData.Bank{Number: number, Owner: c.owner}
+	if bank.Mounth, err = atoiOrZero(c.month); err != nil {
+		return data.Data{}, fmt.Errorf("card month is not valid")
+	}
+	if bank.Year, err = atoiOrZero(c.year); err != nil {
+		return data.Data{}, fmt.Errorf("card year is not valid")
+	}
+	bank.Year %= 100
+	if bank.CVV, err = atoiOrZero(c.cvv); err != nil {
+		return data.Data{}, fmt.Errorf("card CVV is not valid")
+	}
+	return newData(c.title, data.BANKCARD, bank, c.notes)
+}
+
+// digits - функция для удаления из строки всех символов, кроме цифр (пробелов и дефисов в номере карты).
+func digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// atoiOrZero - функция для преобразования строки в число. Пустая строка преобразуется в ноль.
+func atoiOrZero(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// joinNonEmpty - функция для объединения непустых строк через перевод строки.
+func joinNonEmpty(parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, "\n")
+}
+
+// title - функция для получения имени записи. Записи без названия получают имя "untitled".
+func title(s string) string {
+	if s = strings.TrimSpace(s); s != "" {
+		return s
+	}
+	return "untitled"
+}
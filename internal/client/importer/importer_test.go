@@ -0,0 +1,282 @@
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	clientData "github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// item - упрощенное представление импортированной записи для сравнения в тестах.
+type item struct {
+	name     string
+	dataType int
+	metainfo string
+	payload  any
+}
+
+// simplify - функция для преобразования импортированных записей с расшифровкой полезной нагрузки.
+func simplify(t *testing.T, items []data.Data) []item {
+	t.Helper()
+	res := make([]item, 0, len(items))
+	for _, d := range items {
+		var payload any
+		switch d.Type {
+		case data.PASSWORD:
+			var p clientData.Password
+			require.NoError(t, json.Unmarshal(d.Data, &p))
+			payload = p
+		case data.TEXT:
+			var p clientData.Text
+			require.NoError(t, json.Unmarshal(d.Data, &p))
+			payload = p
+		case data.BANKCARD:
+			var p clientData.Bank
+			require.NoError(t, json.Unmarshal(d.Data, &p))
+			payload = p
+		case data.BINARY:
+			var p clientData.Binary
+			require.NoError(t, json.Unmarshal(d.Data, &p))
+			payload = p
+		}
+		assert.Equal(t, data.NEW, d.Status)
+		res = append(res, item{name: d.Name, dataType: d.Type, metainfo: d.Metainfo, payload: payload})
+	}
+	return res
+}
+
+func gzipBase64(t *testing.T, content string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestParse(t *testing.T) {
+	keePassXML := `<?xml version="1.0" encoding="utf-8"?>
+<KeePassFile>
+	<Meta>
+		<RecycleBinUUID>bin</RecycleBinUUID>
+		<Binaries>
+			<Binary ID="0" Compressed="True">` + gzipBase64(t, "key content") + `</Binary>
+			<Binary ID="1">` + base64.StdEncoding.EncodeToString([]byte("plain content")) + `</Binary>
+		</Binaries>
+	</Meta>
+	<Root>
+		<Group>
+			<UUID>root</UUID>
+			<Name>Root</Name>
+			<Entry>
+				<String><Key>Title</Key><Value>mail</Value></String>
+				<String><Key>UserName</Key><Value>user</Value></String>
+				<String><Key>Password</Key><Value>secret</Value></String>
+				<String><Key>URL</Key><Value>https://mail.example.com</Value></String>
+				<String><Key>Notes</Key><Value>work</Value></String>
+				<Binary><Key>key.txt</Key><Value Ref="0"/></Binary>
+			</Entry>
+			<Group>
+				<UUID>sub</UUID>
+				<Name>Files</Name>
+				<Entry>
+					<String><Key>Title</Key><Value>docs</Value></String>
+					<Binary><Key>doc.txt</Key><Value Ref="1"/></Binary>
+					<Binary><Key>lost.txt</Key><Value Ref="7"/></Binary>
+				</Entry>
+			</Group>
+			<Group>
+				<UUID>bin</UUID>
+				<Name>Recycle Bin</Name>
+				<Entry>
+					<String><Key>Title</Key><Value>deleted</Value></String>
+					<String><Key>Password</Key><Value>old</Value></String>
+				</Entry>
+			</Group>
+		</Group>
+	</Root>
+</KeePassFile>`
+
+	keePassCSV := "\ufeff\"Account\",\"Login Name\",\"Password\",\"Web Site\",\"Comments\"\n" +
+		"\"bank\",\"client\",\"p@ss\",\"https://bank.example.com\",\"\"\n" +
+		"\"\",\"\",\"\",\"\",\"just a note\"\n"
+
+	bitwarden := `{
+		"encrypted": false,
+		"items": [
+			{"type": 1, "name": "github", "notes": "2fa enabled",
+			 "login": {"username": "octocat", "password": "hunter2", "uris": [{"uri": "https://github.com"}]}},
+			{"type": 2, "name": "recovery codes", "notes": "1111 2222"},
+			{"type": 3, "name": "visa",
+			 "card": {"cardholderName": "IVAN IVANOV", "number": "4111 1111 1111 1111", "expMonth": "7", "expYear": "2031", "code": "123"}},
+			{"type": 3, "name": "broken card", "card": {"number": "not a number"}},
+			{"type": 4, "name": "passport", "identity": {"firstName": "Ivan", "lastName": "Ivanov", "passportNumber": "1234"}},
+			{"type": 9, "name": "unknown"}
+		]
+	}`
+
+	onePassword := "Title,Website,Username,Password,Notes,Card Number,Cardholder Name,Expiry Date,Verification Number\n" +
+		"forum,https://forum.example.com,nick,pwd,,,,,\n" +
+		"mastercard,,,,main card,5500-0000-0000-0004,PETR PETROV,03/2029,987\n"
+
+	tests := []struct {
+		name     string
+		format   string
+		input    string
+		want     []item
+		warnings int
+		wantErr  bool
+	}{
+		{
+			name:   "KeePass XML",
+			format: KeePassXML,
+			input:  keePassXML,
+			want: []item{
+				{name: "mail", dataType: data.PASSWORD, metainfo: "https://mail.example.com\nwork",
+					payload: clientData.Password{Login: "user", Password: "secret"}},
+				{name: "mail/key.txt", dataType: data.BINARY, metainfo: "https://mail.example.com",
+					payload: clientData.Binary{Binary: []byte("key content"), Type: "text/plain; charset=utf-8"}},
+				{name: "docs/doc.txt", dataType: data.BINARY,
+					payload: clientData.Binary{Binary: []byte("plain content"), Type: "text/plain; charset=utf-8"}},
+			},
+			warnings: 1,
+		},
+		{
+			name:   "KeePass CSV",
+			format: KeePassCSV,
+			input:  keePassCSV,
+			want: []item{
+				{name: "bank", dataType: data.PASSWORD, metainfo: "https://bank.example.com",
+					payload: clientData.Password{Login: "client", Password: "p@ss"}},
+				{name: "untitled", dataType: data.TEXT, payload: clientData.Text{Text: "just a note"}},
+			},
+		},
+		{
+			name:   "Bitwarden JSON",
+			format: BitwardenJSON,
+			input:  bitwarden,
+			want: []item{
+				{name: "github", dataType: data.PASSWORD, metainfo: "https://github.com\n2fa enabled",
+					payload: clientData.Password{Login: "octocat", Password: "hunter2"}},
+				{name: "recovery codes", dataType: data.TEXT, payload: clientData.Text{Text: "1111 2222"}},
+				{name: "visa", dataType: data.BANKCARD,
+					payload: clientData.Bank{Number: 4111111111111111, Mounth: 7, Year: 31, CVV: 123, Owner: "IVAN IVANOV"}},
+				{name: "passport", dataType: data.TEXT,
+					payload: clientData.Text{Text: "firstName: Ivan\nlastName: Ivanov\npassportNumber: 1234"}},
+			},
+			warnings: 2,
+		},
+		{
+			name:   "1Password CSV",
+			format: OnePassword,
+			input:  onePassword,
+			want: []item{
+				{name: "forum", dataType: data.PASSWORD, metainfo: "https://forum.example.com",
+					payload: clientData.Password{Login: "nick", Password: "pwd"}},
+				{name: "mastercard", dataType: data.BANKCARD, metainfo: "main card",
+					payload: clientData.Bank{Number: 5500000000000004, Mounth: 3, Year: 29, CVV: 987, Owner: "PETR PETROV"}},
+			},
+		},
+		{
+			name:    "encrypted Bitwarden JSON",
+			format:  BitwardenJSON,
+			input:   `{"encrypted": true, "items": []}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid XML",
+			format:  KeePassXML,
+			input:   "<KeePassFile>",
+			wantErr: true,
+		},
+		{
+			name:    "unknown format",
+			format:  "lastpass",
+			input:   "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Parse(tt.format, strings.NewReader(tt.input))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, simplify(t, res.Items))
+			assert.Len(t, res.Warnings, tt.warnings)
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	items := []data.Data{{Name: "mail"}, {Name: "bank"}, {Name: "mail"}}
+	existing := []string{"mail", "mail (2)"}
+
+	tests := []struct {
+		name    string
+		policy  Policy
+		want    []Action
+		wantErr bool
+	}{
+		{
+			name:   "skip",
+			policy: Skip,
+			want: []Action{
+				{Op: OpSkip, Name: "mail"},
+				{Op: OpAdd, Name: "bank"},
+				{Op: OpSkip, Name: "mail"},
+			},
+		},
+		{
+			name:   "rename",
+			policy: Rename,
+			want: []Action{
+				{Op: OpAdd, Name: "mail (3)", OriginalName: "mail"},
+				{Op: OpAdd, Name: "bank"},
+				{Op: OpAdd, Name: "mail (4)", OriginalName: "mail"},
+			},
+		},
+		{
+			name:   "overwrite",
+			policy: Overwrite,
+			want: []Action{
+				{Op: OpReplace, Name: "mail"},
+				{Op: OpAdd, Name: "bank"},
+				{Op: OpReplace, Name: "mail"},
+			},
+		},
+		{
+			name:    "unknown policy",
+			policy:  "merge",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions, err := Plan(items, existing, tt.policy)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, actions, len(tt.want))
+			for i, a := range actions {
+				assert.Equal(t, tt.want[i].Op, a.Op)
+				assert.Equal(t, tt.want[i].Name, a.Name)
+				assert.Equal(t, tt.want[i].OriginalName, a.OriginalName)
+				assert.Equal(t, a.Name, a.Data.Name)
+			}
+		})
+	}
+}
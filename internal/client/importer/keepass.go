@@ -0,0 +1,157 @@
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+)
+
+// keePassFile - корневой элемент XML экспорта KeePass 2.x.
+type keePassFile struct {
+	Meta struct {
+		RecycleBinUUID string          `xml:"RecycleBinUUID"`
+		Binaries       []keePassBinary `xml:"Binaries>Binary"`
+	} `xml:"Meta"`
+	Root struct {
+		Groups []keePassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+// keePassBinary - содержимое вложения, на которое ссылаются записи.
+type keePassBinary struct {
+	ID         string `xml:"ID,attr"`
+	Compressed bool   `xml:"Compressed,attr"`
+	Value      string `xml:",chardata"`
+}
+
+// keePassGroup - группа записей. Группы могут быть вложенными.
+type keePassGroup struct {
+	UUID    string         `xml:"UUID"`
+	Name    string         `xml:"Name"`
+	Entries []keePassEntry `xml:"Entry"`
+	Groups  []keePassGroup `xml:"Group"`
+}
+
+// keePassEntry - запись KeePass. История изменений записи не импортируется.
+type keePassEntry struct {
+	Strings []struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	} `xml:"String"`
+	Binaries []struct {
+		Key   string `xml:"Key"`
+		Value struct {
+			Ref string `xml:"Ref,attr"`
+		} `xml:"Value"`
+	} `xml:"Binary"`
+}
+
+// field - функция для получения значения строкового поля записи по ключу.
+func (e keePassEntry) field(key string) string {
+	for _, s := range e.Strings {
+		if s.Key == key {
+			return s.Value
+		}
+	}
+	return ""
+}
+
+// parseKeePassXML - функция для разбора XML экспорта KeePass 2.x. Вложения записей импортируются как файлы
+// с именем вида "название записи/имя файла". Записи из корзины не импортируются.
+func parseKeePassXML(r io.Reader) (Result, error) {
+	var file keePassFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return Result{}, fmt.Errorf("failed to decode KeePass XML, %w", err)
+	}
+
+	binaries := make(map[string][]byte, len(file.Meta.Binaries))
+	for _, b := range file.Meta.Binaries {
+		content, err := decodeKeePassBinary(b)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to decode KeePass attachment %s, %w", b.ID, err)
+		}
+		binaries[b.ID] = content
+	}
+
+	var result Result
+	var walk func(groups []keePassGroup)
+	walk = func(groups []keePassGroup) {
+		for _, g := range groups {
+			if file.Meta.RecycleBinUUID != "" && g.UUID == file.Meta.RecycleBinUUID {
+				continue
+			}
+			for _, e := range g.Entries {
+				addKeePassEntry(&result, e, binaries)
+			}
+			walk(g.Groups)
+		}
+	}
+	walk(file.Root.Groups)
+	return result, nil
+}
+
+// addKeePassEntry - функция для добавления записи KeePass и ее вложений в результат разбора.
+func addKeePassEntry(result *Result, e keePassEntry, binaries map[string][]byte) {
+	l := login{
+		title:    title(e.field("Title")),
+		username: e.field("UserName"),
+		password: e.field("Password"),
+		url:      e.field("URL"),
+		notes:    e.field("Notes"),
+	}
+	if l.username != "" || l.password != "" || l.notes != "" || len(e.Binaries) == 0 {
+		addItem(result, l.title, l.toData)
+	}
+	for _, b := range e.Binaries {
+		content, ok := binaries[b.Value.Ref]
+		if !ok {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: attachment %s not found", l.title, b.Key))
+			continue
+		}
+		name := l.title + "/" + b.Key
+		addItem(result, name, func() (data.Data, error) { return newFile(name, content, l.url) })
+	}
+}
+
+// decodeKeePassBinary - функция для декодирования содержимого вложения KeePass.
+func decodeKeePassBinary(b keePassBinary) ([]byte, error) {
+	content, err := base64.StdEncoding.DecodeString(strings.TrimSpace(b.Value))
+	if err != nil {
+		return nil, err
+	}
+	if !b.Compressed {
+		return content, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
+// parseKeePassCSV - функция для разбора CSV экспорта KeePass 2.x и KeePassXC.
+func parseKeePassCSV(r io.Reader) (Result, error) {
+	rows, err := readCSV(r)
+	if err != nil {
+		return Result{}, err
+	}
+	var result Result
+	for _, row := range rows {
+		l := login{
+			title:    title(row.get("title", "account")),
+			username: row.get("username", "login name", "user name"),
+			password: row.get("password"),
+			url:      row.get("url", "web site"),
+			notes:    row.get("notes", "comments"),
+		}
+		addItem(&result, l.title, l.toData)
+	}
+	return result, nil
+}
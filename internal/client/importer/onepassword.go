@@ -0,0 +1,67 @@
+package importer
+
+import (
+	"io"
+	"strings"
+)
+
+// parseOnePassword - функция для разбора CSV экспорта 1Password. Строки с номером карты импортируются
+// как банковские карты, остальные - как пары логин/пароль или заметки.
+func parseOnePassword(r io.Reader) (Result, error) {
+	rows, err := readCSV(r)
+	if err != nil {
+		return Result{}, err
+	}
+	var result Result
+	for _, row := range rows {
+		name := title(row.get("title", "name"))
+		notes := row.get("notes", "notesplain")
+
+		if row.has("card number", "number", "ccnum") {
+			month, year := splitExpiry(row.get("expiry date", "expires", "expiry"))
+			c := card{
+				title:  name,
+				number: row.get("card number", "number", "ccnum"),
+				month:  firstNonEmpty(row.get("expiry month", "expmonth"), month),
+				year:   firstNonEmpty(row.get("expiry year", "expyear"), year),
+				cvv:    row.get("verification number", "cvv", "cvc"),
+				owner:  row.get("cardholder name", "cardholder"),
+				notes:  notes,
+			}
+			addItem(&result, name, c.toData)
+			continue
+		}
+
+		l := login{
+			title:    name,
+			username: row.get("username"),
+			password: row.get("password"),
+			url:      row.get("url", "website", "urls"),
+			notes:    notes,
+		}
+		addItem(&result, name, l.toData)
+	}
+	return result, nil
+}
+
+// splitExpiry - функция для разбора срока действия карты в формате "MM/YYYY" или "YYYYMM".
+func splitExpiry(expiry string) (string, string) {
+	expiry = strings.TrimSpace(expiry)
+	if month, year, ok := strings.Cut(expiry, "/"); ok {
+		return strings.TrimSpace(month), strings.TrimSpace(year)
+	}
+	if len(expiry) == 6 {
+		return expiry[4:], expiry[:4]
+	}
+	return "", ""
+}
+
+// firstNonEmpty - функция для получения первой непустой строки.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
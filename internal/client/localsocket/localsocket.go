@@ -0,0 +1,117 @@
+// Пакет localsocket содержит функции для запуска локальных агентов клиента на Unix-сокетах, доступных только
+// владельцу. Сокеты и файлы с секретами агентов располагаются в приватной директории пользователя, поэтому другие
+// пользователи системы не могут подключиться к агенту или подменить его файлы.
+package localsocket
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// runtimeDirEnv - переменная окружения с приватной директорией пользователя для сокетов и других файлов времени
+// выполнения.
+const runtimeDirEnv = "XDG_RUNTIME_DIR"
+
+// ErrRunning - ошибка запуска агента на сокете, к которому уже подключается другой процесс.
+var ErrRunning = errors.New("agent is already running on socket")
+
+// DefaultPath - функция для получения пути к сокету или файлу name в приватной директории пользователя:
+// $XDG_RUNTIME_DIR/gophkeeper, а если переменная не задана - gophkeeper-<uid> во временной директории.
+// Директория создается функцией Listen.
+func DefaultPath(name string) string {
+	if dir := os.Getenv(runtimeDirEnv); dir != "" {
+		return filepath.Join(dir, "gophkeeper", name)
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("gophkeeper-%d", os.Getuid()), name)
+}
+
+// Listen - функция для создания Unix-сокета path, доступного только владельцу. Если директории сокета нет,
+// она создается с правами 0700; директория, в которую могут писать другие пользователи, отклоняется, если у неё
+// не установлен sticky бит. Сокет создается с umask 0177, поэтому к нему нельзя подключиться до установки прав.
+// Сокет, оставшийся после аварийного завершения предыдущего агента, удаляется; если к сокету удается
+// подключиться, возвращается ErrRunning.
+func Listen(path string) (net.Listener, error) {
+	if err := privateDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	listener, err := listenUnix(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen socket %s, %w", path, err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions, %w", err)
+	}
+	return listener, nil
+}
+
+// WriteSecret - функция для записи секрета data в новый файл path с правами 0600. Файл, оставшийся после
+// предыдущего агента, удаляется, а новый файл создается без перехода по символическим ссылкам и только если его
+// не успел создать другой процесс.
+func WriteSecret(path string, data []byte) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale file %s, %w", path, err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY|noFollow, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create file %s, %w", path, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(path)
+		return fmt.Errorf("failed to write file %s, %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to close file %s, %w", path, err)
+	}
+	return nil
+}
+
+// privateDir - функция для создания директории dir с правами 0700 и проверки, что другие пользователи не могут
+// создавать и удалять в ней файлы.
+func privateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create directory %s, %w", dir, err)
+	}
+	stat, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to get directory info %s, %w", dir, err)
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	// В директории со sticky битом, например /tmp, пользователи не могут удалять и переименовывать чужие файлы
+	if stat.Mode()&os.ModeSticky != 0 {
+		return nil
+	}
+	if stat.Mode().Perm()&0o022 != 0 {
+		return fmt.Errorf("directory %s is writable by other users", dir)
+	}
+	return checkOwner(dir, stat)
+}
+
+// removeStaleSocket - функция для удаления сокета, оставшегося после предыдущего агента.
+// Если к сокету удается подключиться, значит агент уже запущен, и возвращается ErrRunning.
+func removeStaleSocket(path string) error {
+	if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("%w %s", ErrRunning, path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale socket %s, %w", path, err)
+	}
+	return nil
+}
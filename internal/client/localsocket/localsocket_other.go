@@ -0,0 +1,21 @@
+//go:build !unix
+
+package localsocket
+
+import (
+	"net"
+	"os"
+)
+
+// noFollow - на системах без O_NOFOLLOW файл открывается с обычными флагами.
+const noFollow = 0
+
+// listenUnix - функция для создания Unix-сокета path. На системах без umask права сокета устанавливает Listen.
+func listenUnix(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
+
+// checkOwner - на системах без uid владелец директории не проверяется.
+func checkOwner(string, os.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,103 @@
+package localsocket
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shortDir - создает короткую временную директорию, путь к Unix-сокету ограничен по длине.
+func shortDir(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "gk")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv(runtimeDirEnv, "/run/user/1000")
+	assert.Equal(t, "/run/user/1000/gophkeeper/agent.sock", DefaultPath("agent.sock"))
+
+	t.Setenv(runtimeDirEnv, "")
+	path := DefaultPath("agent.sock")
+	assert.Equal(t, os.TempDir(), filepath.Dir(filepath.Dir(path)))
+	assert.Equal(t, "agent.sock", filepath.Base(path))
+}
+
+func TestListen(t *testing.T) {
+	dir := shortDir(t)
+
+	// Директория сокета создается с правами только для владельца
+	socket := filepath.Join(dir, "private", "agent.sock")
+	listener, err := Listen(socket)
+	require.NoError(t, err)
+	stat, err := os.Stat(filepath.Dir(socket))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o700), stat.Mode().Perm())
+	stat, err = os.Stat(socket)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), stat.Mode().Perm())
+
+	// Второй агент на том же сокете не запускается
+	_, err = Listen(socket)
+	assert.ErrorIs(t, err, ErrRunning)
+
+	// Сокет, оставшийся после аварийного завершения агента, удаляется
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, listener.Close())
+	listener, err = Listen(socket)
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+
+	// Директория, в которую могут писать другие пользователи, отклоняется
+	shared := filepath.Join(dir, "shared")
+	require.NoError(t, os.Mkdir(shared, 0o700))
+	require.NoError(t, os.Chmod(shared, 0o777))
+	_, err = Listen(filepath.Join(shared, "agent.sock"))
+	assert.Error(t, err)
+
+	// Символическая ссылка вместо директории отклоняется
+	link := filepath.Join(dir, "link")
+	require.NoError(t, os.Symlink(filepath.Dir(socket), link))
+	_, err = Listen(filepath.Join(link, "agent.sock"))
+	assert.Error(t, err)
+}
+
+func TestWriteSecret(t *testing.T) {
+	dir := shortDir(t)
+	path := filepath.Join(dir, "agent.token")
+
+	require.NoError(t, WriteSecret(path, []byte("first")))
+	stat, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), stat.Mode().Perm())
+
+	// Файл предыдущего агента заменяется
+	require.NoError(t, WriteSecret(path, []byte("second")))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(content))
+
+	// Символическая ссылка заменяется файлом, файл, на который она указывает, не изменяется
+	target := filepath.Join(dir, "target")
+	require.NoError(t, os.WriteFile(target, []byte("target"), 0o644))
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, os.Symlink(target, path))
+	require.NoError(t, WriteSecret(path, []byte("third")))
+	content, err = os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "target", string(content))
+	stat, err = os.Lstat(path)
+	require.NoError(t, err)
+	assert.True(t, stat.Mode().IsRegular())
+
+	// Ошибка записи в несуществующую директорию
+	err = WriteSecret(filepath.Join(dir, "missing", "agent.token"), []byte("data"))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
@@ -0,0 +1,33 @@
+//go:build unix
+
+package localsocket
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// noFollow - флаг открытия файла без перехода по символической ссылке.
+const noFollow = syscall.O_NOFOLLOW
+
+// listenUnix - функция для создания Unix-сокета path с umask 0177, чтобы сокет был доступен только владельцу
+// с момента создания.
+func listenUnix(path string) (net.Listener, error) {
+	mask := syscall.Umask(0o177)
+	defer syscall.Umask(mask)
+	return net.Listen("unix", path)
+}
+
+// checkOwner - функция для проверки, что директория dir принадлежит текущему пользователю.
+func checkOwner(dir string, stat os.FileInfo) error {
+	sys, ok := stat.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if int(sys.Uid) != os.Getuid() {
+		return fmt.Errorf("directory %s is owned by another user", dir)
+	}
+	return nil
+}
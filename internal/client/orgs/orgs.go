@@ -0,0 +1,443 @@
+// Пакет orgs реализует работу клиента с коллекциями организаций. Записи коллекции шифруются случайным ключом
+// коллекции, а ключ коллекции - открытым ключом каждого участника из его ключевой пары обмена записями, поэтому
+// сервер не может прочитать ни ключ, ни записи. При удалении участника клиент создает новый ключ коллекции,
+// шифрует им все записи заново и передает новый ключ оставшимся участникам, так что удаленный участник не может
+// прочитать записи, измененные после его удаления.
+package orgs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
+	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/encryption"
+	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/random"
+	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/seal"
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/sharing"
+	"github.com/abezemskiy/gophkeeper/internal/common/tracing"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/org"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/share"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+// collectionKeySize - размер ключа коллекции для AES256.
+const collectionKeySize = 32
+
+// Ошибки работы с организациями.
+var (
+	ErrNotFound     = errors.New("organisation not found")
+	ErrItemNotFound = errors.New("item of organisation not found")
+	ErrForbidden    = errors.New("role of member doesn't allow the action")
+	ErrConflict     = errors.New("organisation has been changed, try again")
+)
+
+// URLs - адреса ресурсов сервера для работы с организациями.
+type URLs struct {
+	Orgs string // адрес ресурса организаций
+	Keys string // адрес ресурса ключевых пар
+}
+
+// NewURLs - функция для формирования адресов ресурсов организаций по адресу сервера.
+func NewURLs(addr string) URLs {
+	return URLs{
+		Orgs: addr + api.OrgsPattern,
+		Keys: addr + api.KeysPattern,
+	}
+}
+
+// Collection - организация с ключом коллекции, расшифрованным ключевой парой пользователя.
+type Collection struct {
+	Org org.Org // организация на сервере
+	key []byte  // ключ коллекции
+}
+
+// Item - запись коллекции организации в расшифрованном виде.
+type Item struct {
+	Org     org.Org   // организация, которой принадлежит запись
+	Version int64     // версия записи на сервере
+	Data    data.Data // расшифрованное содержимое
+}
+
+// Create - функция для создания организации с названием name. Пользователь становится её владельцем.
+func Create(ctx context.Context, client *resty.Client, urls URLs, keys sharing.Keys, name string) (org.Org, error) {
+	ctx, span := tracing.Start(ctx, "orgs.Create")
+	defer span.End()
+
+	collectionKey, err := random.GenerateCryptoRandom(collectionKeySize)
+	if err != nil {
+		return org.Org{}, fmt.Errorf("failed to generate collection key, %w", err)
+	}
+	sealedKey, err := seal.Seal(keys.Public, collectionKey)
+	if err != nil {
+		return org.Org{}, fmt.Errorf("failed to seal collection key, %w", err)
+	}
+
+	var created org.Org
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(org.New{Name: name, Key: sealedKey}).
+		SetResult(&created).
+		Post(urls.Orgs)
+	if err != nil {
+		return org.Org{}, fmt.Errorf("create org error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusCreated {
+		return org.Org{}, fmt.Errorf("create org error, %w", orgError(resp))
+	}
+	logger.ClientLog.Info("org is created", zap.String("name", name))
+	return created, nil
+}
+
+// List - функция для получения с сервера организаций, участником которых является пользователь.
+func List(ctx context.Context, client *resty.Client, urls URLs) ([]org.Org, error) {
+	ctx, span := tracing.Start(ctx, "orgs.List")
+	defer span.End()
+
+	var list []org.Org
+	resp, err := client.R().SetContext(ctx).SetResult(&list).Get(urls.Orgs)
+	if err != nil {
+		return nil, fmt.Errorf("get orgs from server error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("get orgs from server error, %w", orgError(resp))
+	}
+	return list, nil
+}
+
+// Open - функция для расшифровывания ключа коллекции организации ключевой парой пользователя.
+func Open(o org.Org, keys sharing.Keys) (Collection, error) {
+	collectionKey, err := seal.Open(keys.Private, o.Key)
+	if err != nil {
+		return Collection{}, fmt.Errorf("failed to open collection key of org %s, %w", o.Name, err)
+	}
+	return Collection{Org: o, key: collectionKey}, nil
+}
+
+// Find - функция для получения организации по id или названию с расшифрованным ключом коллекции.
+func Find(ctx context.Context, client *resty.Client, urls URLs, keys sharing.Keys, idOrName string) (Collection, error) {
+	list, err := List(ctx, client, urls)
+	if err != nil {
+		return Collection{}, err
+	}
+	for _, o := range list {
+		if o.ID == idOrName || o.Name == idOrName {
+			return Open(o, keys)
+		}
+	}
+	return Collection{}, fmt.Errorf("%w, %s", ErrNotFound, idOrName)
+}
+
+// Delete - функция для удаления организации с идентификатором id владельцем.
+func Delete(ctx context.Context, client *resty.Client, urls URLs, id string) error {
+	ctx, span := tracing.Start(ctx, "orgs.Delete")
+	defer span.End()
+
+	resp, err := client.R().SetContext(ctx).Delete(urls.Orgs + "/" + id)
+	if err != nil {
+		return fmt.Errorf("delete org error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusNoContent {
+		return fmt.Errorf("delete org error, %w", orgError(resp))
+	}
+	return nil
+}
+
+// Members - функция для получения участников организации с идентификатором id.
+func Members(ctx context.Context, client *resty.Client, urls URLs, id string) ([]org.Member, error) {
+	ctx, span := tracing.Start(ctx, "orgs.Members")
+	defer span.End()
+
+	var members []org.Member
+	resp, err := client.R().SetContext(ctx).SetResult(&members).Get(urls.Orgs + "/" + id + "/members")
+	if err != nil {
+		return nil, fmt.Errorf("get members from server error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("get members from server error, %w", orgError(resp))
+	}
+	return members, nil
+}
+
+// AddMember - функция для добавления в организацию пользователя login с ролью role. Ключ коллекции шифруется
+// открытым ключом нового участника, поэтому он должен заранее создать ключевую пару.
+func AddMember(ctx context.Context, client *resty.Client, urls URLs, c Collection, login, role string) error {
+	ctx, span := tracing.Start(ctx, "orgs.AddMember")
+	defer span.End()
+
+	if !org.ValidRole(role) {
+		return fmt.Errorf("role must be %s, %s or %s, got %s", org.Admin, org.Editor, org.Viewer, role)
+	}
+	sealedKey, err := sealFor(ctx, client, urls, login, c.key)
+	if err != nil {
+		return err
+	}
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(org.Member{Login: login, Role: role, Key: sealedKey, KeyVersion: c.Org.KeyVersion}).
+		Post(urls.Orgs + "/" + c.Org.ID + "/members")
+	if err != nil {
+		return fmt.Errorf("add member error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusNoContent {
+		return fmt.Errorf("add member error, %w", orgError(resp))
+	}
+	logger.ClientLog.Info("member is added to org", zap.String("org", c.Org.Name), zap.String("login", login))
+	return nil
+}
+
+// SetRole - функция для изменения роли участника login организации с идентификатором id.
+func SetRole(ctx context.Context, client *resty.Client, urls URLs, id, login, role string) error {
+	ctx, span := tracing.Start(ctx, "orgs.SetRole")
+	defer span.End()
+
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(org.Role{Role: role}).
+		Put(urls.Orgs + "/" + id + "/members/" + login)
+	if err != nil {
+		return fmt.Errorf("set role error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusNoContent {
+		return fmt.Errorf("set role error, %w", orgError(resp))
+	}
+	return nil
+}
+
+// Rotate - функция для замены ключа коллекции организации и удаления участника remove, если он задан. Новый ключ
+// шифруется открытыми ключами оставшихся участников, а все записи коллекции шифруются новым ключом. Если участники
+// или записи изменены во время замены ключа, сервер отклоняет замену и функция возвращает ErrConflict.
+func Rotate(ctx context.Context, client *resty.Client, urls URLs, c Collection, remove string) error {
+	ctx, span := tracing.Start(ctx, "orgs.Rotate")
+	defer span.End()
+
+	members, err := Members(ctx, client, urls, c.Org.ID)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+	items, err := getItems(ctx, client, urls, c.Org.ID)
+	if err != nil {
+		return tracing.Error(span, err)
+	}
+
+	collectionKey, err := random.GenerateCryptoRandom(collectionKeySize)
+	if err != nil {
+		return fmt.Errorf("failed to generate collection key, %w", err)
+	}
+	rotation := org.Rotation{
+		Remove:     remove,
+		KeyVersion: c.Org.KeyVersion + 1,
+		Keys:       make(map[string][]byte, len(members)),
+		Items:      make([]org.Item, 0, len(items)),
+	}
+	for _, m := range members {
+		if m.Login == remove {
+			continue
+		}
+		sealedKey, err := sealFor(ctx, client, urls, m.Login, collectionKey)
+		if err != nil {
+			return tracing.Error(span, err)
+		}
+		rotation.Keys[m.Login] = sealedKey
+	}
+	for _, item := range items {
+		plain, err := encryption.DecryptAES256(c.key, item.Data)
+		if err != nil {
+			return tracing.Error(span, fmt.Errorf("failed to decrypt item %s, %w", item.Name, err))
+		}
+		encrData, err := encryption.EncryptAES256(collectionKey, plain)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt item %s, %w", item.Name, err)
+		}
+		rotation.Items = append(rotation.Items, org.Item{Name: item.Name, Data: encrData, Version: item.Version})
+	}
+
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(rotation).
+		Post(urls.Orgs + "/" + c.Org.ID + "/rotate")
+	if err != nil {
+		return fmt.Errorf("rotate org key error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusNoContent {
+		return fmt.Errorf("rotate org key error, %w", orgError(resp))
+	}
+	logger.ClientLog.Info("org key is rotated", zap.String("org", c.Org.Name), zap.String("removed", remove))
+	return nil
+}
+
+// Items - функция для получения записей коллекции организации в расшифрованном виде. Записи, которые не удалось
+// расшифровать, пропускаются.
+func Items(ctx context.Context, client *resty.Client, urls URLs, c Collection) ([]Item, error) {
+	items, err := getItems(ctx, client, urls, c.Org.ID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Item, 0, len(items))
+	for _, item := range items {
+		plain, err := encryption.DecryptAES256(c.key, item.Data)
+		if err != nil {
+			logger.ClientLog.Error("decrypt org item error", zap.String("name", item.Name), zap.String("error", err.Error()))
+			continue
+		}
+		var record data.Data
+		if err := json.Unmarshal(plain, &record); err != nil {
+			logger.ClientLog.Error("decode org item error", zap.String("name", item.Name), zap.String("error", err.Error()))
+			continue
+		}
+		record.Name = item.Name
+		result = append(result, Item{Org: c.Org, Version: item.Version, Data: record})
+	}
+	return result, nil
+}
+
+// All - функция для получения записей коллекций всех организаций пользователя в расшифрованном виде.
+// Организации, ключ коллекции которых не удалось расшифровать, пропускаются.
+func All(ctx context.Context, client *resty.Client, urls URLs, keys sharing.Keys) ([]Item, error) {
+	list, err := List(ctx, client, urls)
+	if err != nil {
+		return nil, err
+	}
+	var result []Item
+	for _, o := range list {
+		c, err := Open(o, keys)
+		if err != nil {
+			logger.ClientLog.Error("open org error", zap.String("error", err.Error()))
+			continue
+		}
+		items, err := Items(ctx, client, urls, c)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, items...)
+	}
+	return result, nil
+}
+
+// FindItem - функция для получения записи коллекции организации по имени в расшифрованном виде.
+func FindItem(ctx context.Context, client *resty.Client, urls URLs, c Collection, name string) (Item, error) {
+	items, err := Items(ctx, client, urls, c)
+	if err != nil {
+		return Item{}, err
+	}
+	for _, item := range items {
+		if item.Data.Name == name {
+			return item, nil
+		}
+	}
+	return Item{}, fmt.Errorf("%w, %s", ErrItemNotFound, name)
+}
+
+// Put - функция для сохранения записи в коллекции организации. version - версия записи на сервере, от которой
+// сделано изменение, 0 для новой записи. Записи с вложениями в коллекцию не сохраняются. Возвращает новую версию
+// записи.
+func Put(ctx context.Context, client *resty.Client, urls URLs, c Collection, record data.Data, version int64) (int64, error) {
+	ctx, span := tracing.Start(ctx, "orgs.Put")
+	defer span.End()
+
+	if _, ok := attachment.FromData(&record); ok {
+		return 0, sharing.ErrAttachment
+	}
+	record.Status = 0
+	b, err := json.Marshal(record)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode record, %w", err)
+	}
+	encrData, err := encryption.EncryptAES256(c.key, b)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encrypt record, %w", err)
+	}
+
+	var saved org.Item
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(org.Item{Name: record.Name, Data: encrData, KeyVersion: c.Org.KeyVersion, Version: version}).
+		SetResult(&saved).
+		Put(urls.Orgs + "/" + c.Org.ID + "/items")
+	if err != nil {
+		return 0, fmt.Errorf("put org item error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return 0, fmt.Errorf("put org item error, %w", orgError(resp))
+	}
+	return saved.Version, nil
+}
+
+// DeleteItem - функция для удаления записи name версии version из коллекции организации.
+func DeleteItem(ctx context.Context, client *resty.Client, urls URLs, c Collection, name string, version int64) error {
+	ctx, span := tracing.Start(ctx, "orgs.DeleteItem")
+	defer span.End()
+
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(org.Item{Name: name, Version: version}).
+		Delete(urls.Orgs + "/" + c.Org.ID + "/items")
+	if err != nil {
+		return fmt.Errorf("delete org item error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusNoContent {
+		return fmt.Errorf("delete org item error, %w", orgError(resp))
+	}
+	return nil
+}
+
+// getItems - функция для получения с сервера записей коллекции организации с идентификатором id в зашифрованном виде.
+func getItems(ctx context.Context, client *resty.Client, urls URLs, id string) ([]org.Item, error) {
+	ctx, span := tracing.Start(ctx, "orgs.Items")
+	defer span.End()
+
+	var items []org.Item
+	resp, err := client.R().SetContext(ctx).SetResult(&items).Get(urls.Orgs + "/" + id + "/items")
+	if err != nil {
+		return nil, fmt.Errorf("get org items from server error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("get org items from server error, %w", orgError(resp))
+	}
+	return items, nil
+}
+
+// sealFor - функция для шифрования ключа коллекции открытым ключом пользователя login.
+func sealFor(ctx context.Context, client *resty.Client, urls URLs, login string, collectionKey []byte) ([]byte, error) {
+	var publicKey share.PublicKey
+	resp, err := client.R().SetContext(ctx).SetResult(&publicKey).Get(urls.Keys + "/" + login)
+	if err != nil {
+		return nil, fmt.Errorf("get public key of %s error, %w", login, err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("get public key of %s error, %w", login, api.ParseError(resp.StatusCode(), resp.Body()))
+	}
+	sealedKey, err := seal.Seal(publicKey.PublicKey, collectionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal collection key for %s, %w", login, err)
+	}
+	return sealedKey, nil
+}
+
+// orgError - функция для получения ошибки из ответа сервера. Отсутствующая организация, запрет действия и конфликт
+// изменений возвращаются как ErrNotFound, ErrForbidden и ErrConflict.
+func orgError(resp *resty.Response) error {
+	apiErr := api.ParseError(resp.StatusCode(), resp.Body())
+	switch apiErr.Code {
+	case api.CodeOrgNotFound:
+		return fmt.Errorf("%w, %w", ErrNotFound, apiErr)
+	case api.CodeOrgForbidden:
+		return fmt.Errorf("%w, %w", ErrForbidden, apiErr)
+	case api.CodeOrgConflict:
+		return fmt.Errorf("%w, %w", ErrConflict, apiErr)
+	}
+	return apiErr
+}
@@ -0,0 +1,109 @@
+package orgs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/sharing"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/org"
+	"github.com/abezemskiy/gophkeeper/internal/testutil"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// user - клиент и ключевая пара пользователя.
+type user struct {
+	client *resty.Client
+	keys   sharing.Keys
+}
+
+// setup - запускает сервер и возвращает адреса ресурсов организаций и пользователей alice, bob и carol.
+func setup(t *testing.T) (URLs, user, user, user) {
+	t.Helper()
+	srv := testutil.NewServer(t)
+	newUser := func(login, id string) user {
+		client := srv.NewClient(t, login, id)
+		keys, err := sharing.KeyPair(context.Background(), client, sharing.NewURLs(srv.URL), login+" password")
+		require.NoError(t, err)
+		return user{client: client, keys: keys}
+	}
+	return NewURLs(srv.URL), newUser("alice", "first"), newUser("bob", "second"), newUser("carol", "third")
+}
+
+func TestOrgs(t *testing.T) {
+	ctx := context.Background()
+	urls, alice, bob, carol := setup(t)
+
+	created, err := Create(ctx, alice.client, urls, alice.keys, "team")
+	require.NoError(t, err)
+	assert.Equal(t, org.Owner, created.Role)
+	team, err := Find(ctx, alice.client, urls, alice.keys, "team")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, team.Org.ID)
+
+	// Участники получают ключ коллекции, зашифрованный их открытыми ключами
+	require.NoError(t, AddMember(ctx, alice.client, urls, team, "bob", org.Editor))
+	require.NoError(t, AddMember(ctx, alice.client, urls, team, "carol", org.Viewer))
+	require.Error(t, AddMember(ctx, alice.client, urls, team, "carol", "guest"))
+	assert.True(t, api.IsCode(AddMember(ctx, alice.client, urls, team, "dave", org.Viewer), api.CodeKeyNotFound))
+	members, err := Members(ctx, bob.client, urls, team.Org.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 3)
+
+	// Редактор изменяет записи коллекции, читатель только читает их
+	record := data.Data{Data: []byte("secret"), Type: data.PASSWORD, Name: "db", CreateDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	bobTeam, err := Find(ctx, bob.client, urls, bob.keys, team.Org.ID)
+	require.NoError(t, err)
+	version, err := Put(ctx, bob.client, urls, bobTeam, record, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), version)
+
+	carolTeam, err := Find(ctx, carol.client, urls, carol.keys, "team")
+	require.NoError(t, err)
+	item, err := FindItem(ctx, carol.client, urls, carolTeam, "db")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), item.Data.Data)
+	assert.Equal(t, "team", item.Org.Name)
+	_, err = Put(ctx, carol.client, urls, carolTeam, record, item.Version)
+	require.ErrorIs(t, err, ErrForbidden)
+	_, err = FindItem(ctx, carol.client, urls, carolTeam, "unknown")
+	require.ErrorIs(t, err, ErrItemNotFound)
+
+	// Удаление участника заменяет ключ коллекции, старый ключ больше не принимается сервером
+	require.ErrorIs(t, Rotate(ctx, bob.client, urls, bobTeam, "carol"), ErrForbidden)
+	require.NoError(t, Rotate(ctx, alice.client, urls, team, "bob"))
+	_, err = Find(ctx, bob.client, urls, bob.keys, team.Org.ID)
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = Items(ctx, bob.client, urls, bobTeam)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	_, err = Put(ctx, alice.client, urls, team, record, 2)
+	require.ErrorIs(t, err, ErrConflict)
+	team, err = Find(ctx, alice.client, urls, alice.keys, "team")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), team.Org.KeyVersion)
+	all, err := All(ctx, carol.client, urls, carol.keys)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, []byte("secret"), all[0].Data.Data)
+	assert.Equal(t, int64(2), all[0].Version)
+
+	// Изменение роли и удаление записей
+	require.NoError(t, SetRole(ctx, alice.client, urls, team.Org.ID, "carol", org.Editor))
+	carolTeam, err = Find(ctx, carol.client, urls, carol.keys, "team")
+	require.NoError(t, err)
+	require.ErrorIs(t, DeleteItem(ctx, carol.client, urls, carolTeam, "db", 1), ErrConflict)
+	require.NoError(t, DeleteItem(ctx, carol.client, urls, carolTeam, "db", 2))
+
+	// Удалить организацию может только владелец
+	require.ErrorIs(t, Delete(ctx, carol.client, urls, team.Org.ID), ErrForbidden)
+	require.NoError(t, Delete(ctx, alice.client, urls, team.Org.ID))
+	list, err := List(ctx, carol.client, urls)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
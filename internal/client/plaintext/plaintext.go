@@ -0,0 +1,354 @@
+// Пакет plaintext реализует выгрузку расшифрованных данных пользователя в открытом виде в CSV или JSON
+// для аудита. Файлы пользователя сохраняются рядом с выгрузкой отдельными файлами. Выгрузка нарушает гарантии
+// шифрования, поэтому выполняется только после явного подтверждения пользователя.
+package plaintext
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/backup"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data/tools/binary"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+)
+
+// Форматы выгрузки.
+const (
+	CSV  = "csv"
+	JSON = "json"
+)
+
+// Confirmation - фраза, которую пользователь должен ввести для подтверждения выгрузки в открытом виде.
+const Confirmation = "EXPORT PLAINTEXT"
+
+// Warning - предупреждение, которое выводится перед выгрузкой в открытом виде.
+const Warning = `WARNING: plaintext export writes all your passwords, card numbers, notes and files UNENCRYPTED to disk.
+Anyone with access to the output directory can read them. Delete the export as soon as it is no longer needed.`
+
+// filesDir - каталог для файлов пользователя внутри каталога выгрузки.
+const filesDir = "files"
+
+// ErrNotConfirmed - ошибка выгрузки без подтверждения пользователя.
+var ErrNotConfirmed = errors.New("plaintext export is not confirmed")
+
+// Confirm - функция для проверки подтверждения выгрузки в открытом виде. Пользователь должен ввести фразу Confirmation.
+func Confirm(typed string) error {
+	if strings.TrimSpace(typed) != Confirmation {
+		return fmt.Errorf("%w, type %q to confirm", ErrNotConfirmed, Confirmation)
+	}
+	return nil
+}
+
+// Record - версия данных пользователя в выгрузке. Заполнено только поле, соответствующее типу данных.
+type Record struct {
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Version    int       `json:"version"`
+	Metainfo   string    `json:"metainfo"`
+	Tags       []string  `json:"tags,omitempty"`
+	Folder     string    `json:"folder,omitempty"`
+	Favorite   bool      `json:"favorite,omitempty"`
+	CreateDate time.Time `json:"create_date"`
+	EditDate   time.Time `json:"edit_date"`
+
+	Password *data.Password `json:"password,omitempty"`
+	Login    *data.Login    `json:"login,omitempty"`
+	SSHKey   *data.SSHKey   `json:"ssh_key,omitempty"`
+	Text     *data.Text     `json:"text,omitempty"`
+	Card     *data.Bank     `json:"card,omitempty"`
+	File     *File          `json:"file,omitempty"`
+}
+
+// File - файл пользователя, сохраненный рядом с выгрузкой.
+type File struct {
+	Path string `json:"path"` // путь к файлу относительно каталога выгрузки
+	MIME string `json:"mime"`
+}
+
+// Колонки CSV, общие для всех типов данных.
+var commonColumns = []string{"name", "type", "version", "metainfo", "tags", "folder", "favorite", "create_date", "edit_date"}
+
+// typeColumns - колонки CSV для каждого типа данных. Колонки других типов остаются пустыми.
+// Учетные записи используют колонки логина и пароля вместе с данными типа password.
+var typeColumns = []struct {
+	dataTypes []int
+	columns   []string
+	values    func(r Record) []string
+}{
+	{[]int{repoData.PASSWORD, repoData.LOGIN}, []string{"login", "password"}, func(r Record) []string {
+		if r.Login != nil {
+			return []string{r.Login.Login, r.Login.Password}
+		}
+		return []string{r.Password.Login, r.Password.Password}
+	}},
+	{[]int{repoData.LOGIN}, []string{"urls", "notes", "totp", "fields"}, func(r Record) []string {
+		fields := make([]string, 0, len(r.Login.Fields))
+		for _, f := range r.Login.Fields {
+			fields = append(fields, f.String())
+		}
+		return []string{strings.Join(r.Login.URLs, "\n"), r.Login.Notes, r.Login.TOTP, strings.Join(fields, "\n")}
+	}},
+	{[]int{repoData.TEXT}, []string{"text"}, func(r Record) []string {
+		return []string{r.Text.Text}
+	}},
+	{[]int{repoData.BANKCARD}, []string{"card_number", "card_month", "card_year", "card_cvv", "card_owner"}, func(r Record) []string {
+		return []string{strconv.FormatInt(r.Card.Number, 10), strconv.Itoa(r.Card.Mounth), strconv.Itoa(r.Card.Year),
+			strconv.Itoa(r.Card.CVV), r.Card.Owner}
+	}},
+	{[]int{repoData.BINARY}, []string{"file", "mime"}, func(r Record) []string {
+		return []string{r.File.Path, r.File.MIME}
+	}},
+	{[]int{repoData.SSHKEY}, []string{"ssh_private_key", "ssh_passphrase", "ssh_public_key", "ssh_fingerprint", "ssh_comment",
+		"ssh_certificate"}, func(r Record) []string {
+		return []string{r.SSHKey.PrivateKey, r.SSHKey.Passphrase, r.SSHKey.PublicKey, r.SSHKey.Fingerprint, r.SSHKey.Comment,
+			r.SSHKey.Certificate}
+	}},
+}
+
+// Stats - количество выгруженных версий данных и файлов.
+type Stats struct {
+	Path    string `json:"path"` // путь к файлу выгрузки
+	Records int    `json:"records"`
+	Files   int    `json:"files"`
+}
+
+// Write - функция для выгрузки данных пользователя в каталог dir в формате CSV или JSON. Каталог создается
+// с правами только для владельца, права существующего каталога ограничиваются так же. Небольшие файлы
+// записываются из данных с правами 0600, большие файлы скачиваются функцией fetch.
+func Write(ctx context.Context, dir, format string, records [][]repoData.Data, fetch backup.Fetch) (Stats, error) {
+	var stats Stats
+	if format != CSV && format != JSON {
+		return stats, fmt.Errorf("unknown plaintext format %s, supported formats: %s, %s", format, CSV, JSON)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, filesDir), 0o700); err != nil {
+		return stats, fmt.Errorf("failed to create output directory, %w", err)
+	}
+	// MkdirAll не меняет права уже существующих каталогов
+	for _, d := range []string{dir, filepath.Join(dir, filesDir)} {
+		if err := os.Chmod(d, 0o700); err != nil {
+			return stats, fmt.Errorf("failed to set output directory permissions, %w", err)
+		}
+	}
+
+	used := make(map[string]bool)
+	var out []Record
+	for _, versions := range records {
+		for i, v := range versions {
+			r := Record{
+				Name:       v.Name,
+				Type:       typeName(v.Type),
+				Version:    i + 1,
+				Metainfo:   v.Metainfo,
+				Tags:       v.Tags,
+				Folder:     v.Folder,
+				Favorite:   v.Favorite,
+				CreateDate: v.CreateDate,
+				EditDate:   v.EditDate,
+			}
+			if err := fill(ctx, &r, v, dir, used, len(versions) > 1, fetch); err != nil {
+				return stats, fmt.Errorf("failed to export %s, %w", v.Name, err)
+			}
+			if r.File != nil {
+				stats.Files++
+			}
+			out = append(out, r)
+		}
+	}
+	stats.Records = len(out)
+
+	stats.Path = filepath.Join(dir, "export."+format)
+	f, err := os.OpenFile(stats.Path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return stats, fmt.Errorf("failed to create export file, %w", err)
+	}
+	defer f.Close()
+	if err := f.Chmod(0o600); err != nil {
+		return stats, fmt.Errorf("failed to set export file permissions, %w", err)
+	}
+
+	if format == JSON {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return stats, fmt.Errorf("failed to write export file, %w", err)
+		}
+	} else if err := writeCSV(f, out); err != nil {
+		return stats, err
+	}
+	if err := f.Close(); err != nil {
+		return stats, fmt.Errorf("failed to close export file, %w", err)
+	}
+	return stats, nil
+}
+
+// fill - функция для заполнения полезной нагрузки записи выгрузки. Файлы сохраняются в подкаталог files,
+// версии данных, сохраненные при конфликте, получают суффикс с номером версии.
+func fill(ctx context.Context, r *Record, v repoData.Data, dir string, used map[string]bool, versioned bool,
+	fetch backup.Fetch) error {
+
+	var err error
+	switch v.Type {
+	case repoData.PASSWORD:
+		r.Password = &data.Password{}
+		err = json.Unmarshal(v.Data, r.Password)
+	case repoData.TEXT:
+		r.Text = &data.Text{}
+		err = json.Unmarshal(v.Data, r.Text)
+	case repoData.BANKCARD:
+		r.Card = &data.Bank{}
+		err = json.Unmarshal(v.Data, r.Card)
+	case repoData.LOGIN:
+		r.Login = &data.Login{}
+		err = json.Unmarshal(v.Data, r.Login)
+	case repoData.SSHKEY:
+		r.SSHKey = &data.SSHKey{}
+		err = json.Unmarshal(v.Data, r.SSHKey)
+	case repoData.BINARY:
+		var b data.Binary
+		if err := json.Unmarshal(v.Data, &b); err != nil {
+			return fmt.Errorf("failed to unmarshal data, %w", err)
+		}
+		base := fileBase(v.Name, r.Version, versioned, used)
+		path := binary.FileName(b, base, filepath.Join(dir, filesDir))
+		err = writePrivate(path, func(w io.Writer) error {
+			if b.Attachment == nil {
+				_, err := w.Write(b.Binary)
+				return err
+			}
+			return fetch(ctx, b.Attachment, w)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save file, %w", err)
+		}
+		r.File = &File{Path: filepath.ToSlash(filepath.Join(filesDir, filepath.Base(path))), MIME: b.Type}
+	default:
+		return fmt.Errorf("unknown data type %d", v.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal data, %w", err)
+	}
+	return nil
+}
+
+// writePrivate - функция для записи файла path функцией write с правами только для владельца, в том числе поверх
+// существующего файла.
+func writePrivate(path string, write func(w io.Writer) error) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := f.Chmod(0o600); err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// fileBase - функция для получения уникального имени файла без расширения из имени данных. Разделители пути
+// заменяются, поэтому файл всегда сохраняется в каталоге files.
+func fileBase(name string, version int, versioned bool, used map[string]bool) string {
+	base := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator || r < ' ' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if base == "" || base == "." || base == ".." {
+		base = "file"
+	}
+	if versioned {
+		base = fmt.Sprintf("%s.v%d", base, version)
+	}
+	candidate := base
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s (%d)", base, i)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// writeCSV - функция для записи выгрузки в формате CSV. Колонки каждого типа данных заполняются
+// по таблице typeColumns.
+func writeCSV(f *os.File, records []Record) error {
+	w := csv.NewWriter(f)
+	header := append([]string{}, commonColumns...)
+	for _, tc := range typeColumns {
+		header = append(header, tc.columns...)
+	}
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to write export file, %w", err)
+	}
+
+	for _, r := range records {
+		dataType := typeOf(r)
+		row := []string{r.Name, r.Type, strconv.Itoa(r.Version), r.Metainfo, strings.Join(r.Tags, ","), r.Folder,
+			strconv.FormatBool(r.Favorite), r.CreateDate.Format(time.RFC3339), r.EditDate.Format(time.RFC3339)}
+		for _, tc := range typeColumns {
+			if slices.Contains(tc.dataTypes, dataType) {
+				row = append(row, tc.values(r)...)
+			} else {
+				row = append(row, make([]string, len(tc.columns))...)
+			}
+		}
+		if err := w.Write(row); err != nil {
+			return fmt.Errorf("failed to write export file, %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write export file, %w", err)
+	}
+	return nil
+}
+
+// typeName - функция для получения имени типа данных в выгрузке. Имена совпадают с именами типов в командах клиента.
+func typeName(dataType int) string {
+	switch dataType {
+	case repoData.PASSWORD:
+		return "password"
+	case repoData.TEXT:
+		return "text"
+	case repoData.BANKCARD:
+		return "card"
+	case repoData.BINARY:
+		return "file"
+	case repoData.LOGIN:
+		return "login"
+	case repoData.SSHKEY:
+		return "ssh"
+	default:
+		return strconv.Itoa(dataType)
+	}
+}
+
+// typeOf - функция для получения типа данных записи выгрузки по заполненному полю.
+func typeOf(r Record) int {
+	switch {
+	case r.Password != nil:
+		return repoData.PASSWORD
+	case r.Login != nil:
+		return repoData.LOGIN
+	case r.SSHKey != nil:
+		return repoData.SSHKEY
+	case r.Text != nil:
+		return repoData.TEXT
+	case r.Card != nil:
+		return repoData.BANKCARD
+	default:
+		return repoData.BINARY
+	}
+}
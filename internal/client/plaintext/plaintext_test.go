@@ -0,0 +1,161 @@
+package plaintext
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRecords(t *testing.T) [][]repoData.Data {
+	t.Helper()
+	db := testutil.NewData(t, "db", repoData.PASSWORD, "db info", data.Password{Login: "admin", Password: "secret"})
+	db.Tags, db.Folder, db.Favorite = []string{"servers", "work"}, "work/db", true
+	return [][]repoData.Data{
+		{db},
+		{
+			testutil.NewData(t, "note", repoData.TEXT, "note info", data.Text{Text: "first"}),
+			testutil.NewData(t, "note", repoData.TEXT, "note info", data.Text{Text: "second"}),
+		},
+		{testutil.NewData(t, "card", repoData.BANKCARD, "card info", data.Bank{Number: 4111111111111111, Mounth: 12, Year: 30, CVV: 123, Owner: "IVAN"})},
+		{testutil.NewData(t, "../scan", repoData.BINARY, "../scan info", data.Binary{Binary: []byte("png content"), Type: "image/png"})},
+		{testutil.NewData(t, "video", repoData.BINARY, "video info", data.Binary{Type: "video/mp4", Attachment: &data.Attachment{ID: "id"}})},
+		{testutil.NewData(t, "vpn", repoData.LOGIN, "vpn info", data.Login{Login: "ops", Password: "pass", URLs: []string{"https://a", "https://b"},
+			Notes: "office", TOTP: "JBSWY3DPEHPK3PXP", Fields: []data.Field{{Name: "pin", Value: "1", Type: data.FieldHidden}}})},
+		{testutil.NewData(t, "github", repoData.SSHKEY, "github info", data.SSHKey{PrivateKey: "private", Passphrase: "phrase", PublicKey: "ssh-ed25519 AAAA",
+			Fingerprint: "SHA256:abc", Comment: "user@host"})},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	fetch := func(_ context.Context, att *data.Attachment, w io.Writer) error {
+		assert.Equal(t, "id", att.ID)
+		_, err := w.Write([]byte("large content"))
+		return err
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "export")
+		stats, err := Write(context.Background(), dir, CSV, testRecords(t), fetch)
+		require.NoError(t, err)
+		assert.Equal(t, Stats{Path: filepath.Join(dir, "export.csv"), Records: 8, Files: 2}, stats)
+
+		f, err := os.Open(stats.Path)
+		require.NoError(t, err)
+		defer f.Close()
+		rows, err := csv.NewReader(f).ReadAll()
+		require.NoError(t, err)
+		date := "2024-05-01T10:00:00Z"
+		assert.Equal(t, [][]string{
+			{"name", "type", "version", "metainfo", "tags", "folder", "favorite", "create_date", "edit_date", "login", "password",
+				"urls", "notes", "totp", "fields", "text", "card_number", "card_month", "card_year", "card_cvv", "card_owner", "file", "mime",
+				"ssh_private_key", "ssh_passphrase", "ssh_public_key", "ssh_fingerprint", "ssh_comment", "ssh_certificate"},
+			{"db", "password", "1", "db info", "servers,work", "work/db", "true", date, date, "admin", "secret", "", "", "", "", "", "", "", "",
+				"", "", "", "", "", "", "", "", "", ""},
+			{"note", "text", "1", "note info", "", "", "false", date, date, "", "", "", "", "", "", "first", "", "", "", "", "", "", "",
+				"", "", "", "", "", ""},
+			{"note", "text", "2", "note info", "", "", "false", date, date, "", "", "", "", "", "", "second", "", "", "", "", "", "", "",
+				"", "", "", "", "", ""},
+			{"card", "card", "1", "card info", "", "", "false", date, date, "", "", "", "", "", "", "", "4111111111111111", "12", "30", "123",
+				"IVAN", "", "", "", "", "", "", "", ""},
+			{"../scan", "file", "1", "../scan info", "", "", "false", date, date, "", "", "", "", "", "", "", "", "", "", "", "",
+				"files/.._scan.png", "image/png", "", "", "", "", "", ""},
+			{"video", "file", "1", "video info", "", "", "false", date, date, "", "", "", "", "", "", "", "", "", "", "", "",
+				"files/video.bin", "video/mp4", "", "", "", "", "", ""},
+			{"vpn", "login", "1", "vpn info", "", "", "false", date, date, "ops", "pass", "https://a\nhttps://b", "office", "JBSWY3DPEHPK3PXP",
+				"hidden:pin=1", "", "", "", "", "", "", "", "", "", "", "", "", "", ""},
+			{"github", "ssh", "1", "github info", "", "", "false", date, date, "", "", "", "", "", "", "", "", "", "", "", "", "", "",
+				"private", "phrase", "ssh-ed25519 AAAA", "SHA256:abc", "user@host", ""},
+		}, rows)
+
+		content, err := os.ReadFile(filepath.Join(dir, "files", ".._scan.png"))
+		require.NoError(t, err)
+		assert.Equal(t, "png content", string(content))
+		content, err = os.ReadFile(filepath.Join(dir, "files", "video.bin"))
+		require.NoError(t, err)
+		assert.Equal(t, "large content", string(content))
+
+		// Выгрузка доступна только владельцу
+		for path, perm := range map[string]os.FileMode{
+			dir:                         0o700,
+			filepath.Join(dir, "files"): 0o700,
+			stats.Path:                  0o600,
+			filepath.Join(dir, "files", ".._scan.png"): 0o600,
+		} {
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, perm, info.Mode().Perm(), path)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		// Права существующего каталога ограничиваются
+		dir := t.TempDir()
+		require.NoError(t, os.Chmod(dir, 0o755))
+		stats, err := Write(context.Background(), dir, JSON, testRecords(t), fetch)
+		require.NoError(t, err)
+		info, err := os.Stat(dir)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+
+		content, err := os.ReadFile(stats.Path)
+		require.NoError(t, err)
+		var records []Record
+		require.NoError(t, json.Unmarshal(content, &records))
+		require.Len(t, records, 8)
+		assert.Equal(t, &data.Password{Login: "admin", Password: "secret"}, records[0].Password)
+		assert.Equal(t, []string{"servers", "work"}, records[0].Tags)
+		assert.Equal(t, "work/db", records[0].Folder)
+		assert.True(t, records[0].Favorite)
+		assert.Equal(t, 2, records[2].Version)
+		assert.Equal(t, &data.Text{Text: "second"}, records[2].Text)
+		assert.Equal(t, &data.Bank{Number: 4111111111111111, Mounth: 12, Year: 30, CVV: 123, Owner: "IVAN"}, records[3].Card)
+		assert.Equal(t, &File{Path: "files/.._scan.png", MIME: "image/png"}, records[4].File)
+		assert.Nil(t, records[4].Password)
+		assert.Equal(t, "login", records[6].Type)
+		assert.Equal(t, []string{"https://a", "https://b"}, records[6].Login.URLs)
+		assert.Equal(t, "JBSWY3DPEHPK3PXP", records[6].Login.TOTP)
+		assert.Equal(t, "ssh", records[7].Type)
+		assert.Equal(t, "SHA256:abc", records[7].SSHKey.Fingerprint)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := Write(context.Background(), t.TempDir(), "xml", testRecords(t), fetch)
+		assert.Error(t, err)
+	})
+}
+
+func TestFileBase(t *testing.T) {
+	used := make(map[string]bool)
+	tests := []struct {
+		name      string
+		version   int
+		versioned bool
+		want      string
+	}{
+		{name: "photo", version: 1, want: "photo"},
+		{name: "photo", version: 1, want: "photo (2)"},
+		{name: "docs/passport", version: 1, want: "docs_passport"},
+		{name: "..", version: 1, want: "file"},
+		{name: "scan", version: 2, versioned: true, want: "scan.v2"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, fileBase(tt.name, tt.version, tt.versioned, used))
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	assert.NoError(t, Confirm(Confirmation+"\n"))
+	assert.ErrorIs(t, Confirm("yes"), ErrNotConfirmed)
+	assert.ErrorIs(t, Confirm(""), ErrNotConfirmed)
+}
@@ -0,0 +1,294 @@
+// Пакет rpc содержит gRPC транспорт клиента. Транспорт реализует http.RoundTripper и выполняет запросы к REST API
+// сервера через gRPC API, поэтому хэндлеры, синхронизация и мидлвари клиента работают без изменений при любом
+// выбранном транспорте. Запросы, для которых нет gRPC метода (вложения, информация об использовании хранилища),
+// передаются REST транспорту.
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/identity"
+	pb "github.com/abezemskiy/gophkeeper/internal/repositories/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Транспорты клиента.
+const (
+	REST = "rest"
+	GRPC = "grpc"
+)
+
+// AuthorizationMetadata - ключ метаданных запроса с токеном пользователя.
+const AuthorizationMetadata = "authorization"
+
+// DeviceMetadata - ключ метаданных запроса с именем устройства клиента, аналог заголовка api.DeviceHeader.
+const DeviceMetadata = "x-gophkeeper-device"
+
+// Dial - функция для создания соединения с gRPC сервером по адресу addr. Если tlsCfg равен nil, соединение
+// устанавливается без шифрования.
+func Dial(addr string, tlsCfg *tls.Config) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if tlsCfg != nil {
+		creds = credentials.NewTLS(tlsCfg)
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client for %s, %w", addr, err)
+	}
+	return conn, nil
+}
+
+// call - функция вызова gRPC метода по телу REST запроса. Возвращает тело и заголовки ответа REST API.
+type call func(ctx context.Context, client pb.GophKeeperClient, body []byte) ([]byte, http.Header, error)
+
+// route - gRPC метод, соответствующий адресу REST API.
+type route struct {
+	method string // HTTP метод запроса
+	call   call
+}
+
+// routes - соответствие адресов REST API методам gRPC API.
+var routes = map[string]route{
+	api.RegisterPattern:      {method: http.MethodPost, call: register},
+	api.AuthorizationPattern: {method: http.MethodPost, call: authorize},
+	api.AddDataPattern: {method: http.MethodPost, call: withData(func(ctx context.Context, c pb.GophKeeperClient, d *pb.EncryptedData) error {
+		_, err := c.AddData(ctx, d)
+		return err
+	})},
+	api.ReplaceDataPattern: {method: http.MethodPost, call: withData(func(ctx context.Context, c pb.GophKeeperClient, d *pb.EncryptedData) error {
+		_, err := c.ReplaceData(ctx, d)
+		return err
+	})},
+	api.ConflictDataPattern: {method: http.MethodPost, call: withData(func(ctx context.Context, c pb.GophKeeperClient, d *pb.EncryptedData) error {
+		_, err := c.AppendConflictData(ctx, d)
+		return err
+	})},
+	api.GetDataPattern:    {method: http.MethodGet, call: getAll},
+	api.DeleteDataPattern: {method: http.MethodDelete, call: deleteData},
+}
+
+// Transport - транспорт клиента, выполняющий запросы к REST API через gRPC API.
+type Transport struct {
+	client   pb.GophKeeperClient
+	fallback http.RoundTripper // транспорт для запросов, у которых нет gRPC метода
+}
+
+// NewTransport - фабричная функция gRPC транспорта. fallback выполняет запросы, для которых нет gRPC метода.
+func NewTransport(conn grpc.ClientConnInterface, fallback http.RoundTripper) *Transport {
+	return &Transport{client: pb.NewGophKeeperClient(conn), fallback: fallback}
+}
+
+// RoundTrip - метод для выполнения запроса. Ошибки соединения с gRPC сервером возвращаются как ошибки транспорта,
+// чтобы клиент перешел в офлайн режим так же, как при недоступности REST API.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r, ok := routes[req.URL.Path]
+	if !ok || r.method != req.Method {
+		return t.fallback.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body, %w", err)
+		}
+	}
+
+	// Токен пользователя передается в метаданных так же, как в заголовке REST запроса
+	ctx := req.Context()
+	if authHeader := req.Header.Get("Authorization"); authHeader != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, AuthorizationMetadata, authHeader)
+	}
+	if device := req.Header.Get(api.DeviceHeader); device != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, DeviceMetadata, device)
+	}
+
+	respBody, header, err := r.call(ctx, t.client, body)
+	if err != nil {
+		code := status.Code(err)
+		if code == codes.Unavailable || code == codes.DeadlineExceeded || code == codes.Canceled {
+			return nil, fmt.Errorf("gRPC request %s failed, %w", req.URL.Path, err)
+		}
+		return errorResponse(req, code, status.Convert(err).Message()), nil
+	}
+	return response(req, http.StatusOK, header, respBody), nil
+}
+
+// httpStatus - функция для получения статуса ответа REST API, соответствующего коду gRPC.
+func httpStatus(path string, code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		// REST API отвечает на неверный логин или пароль статусом 400. Статус 401 означает истекший токен,
+		// на который мидлварь клиента отвечает повторной авторизацией.
+		if path == api.AuthorizationPattern {
+			return http.StatusBadRequest
+		}
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.AlreadyExists:
+		return http.StatusConflict
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.ResourceExhausted:
+		return http.StatusInsufficientStorage
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorCode - функция для получения кода ошибки REST API, соответствующего коду gRPC.
+func errorCode(path string, code codes.Code) string {
+	switch code {
+	case codes.InvalidArgument:
+		return api.CodeInvalidRequest
+	case codes.Unauthenticated:
+		if path == api.AuthorizationPattern {
+			return api.CodeInvalidCredentials
+		}
+		return api.CodeUnauthorized
+	case codes.PermissionDenied:
+		return api.CodeAccountDisabled
+	case codes.AlreadyExists:
+		if path == api.RegisterPattern {
+			return api.CodeLoginExists
+		}
+		return api.CodeDataExists
+	case codes.NotFound:
+		return api.CodeDataNotFound
+	case codes.ResourceExhausted:
+		return api.CodeQuotaExceeded
+	default:
+		return api.CodeInternal
+	}
+}
+
+// errorResponse - функция для создания ответа REST API с ошибкой в том же виде, в котором ее возвращает сервер.
+func errorResponse(req *http.Request, code codes.Code, message string) *http.Response {
+	body, _ := json.Marshal(api.ErrorResponse{Error: &api.Error{Code: errorCode(req.URL.Path, code), Message: message}})
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	return response(req, httpStatus(req.URL.Path, code), header, body)
+}
+
+// response - функция для создания ответа REST API.
+func response(req *http.Request, code int, header http.Header, body []byte) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// register - вызов метода регистрации пользователя.
+func register(ctx context.Context, client pb.GophKeeperClient, body []byte) ([]byte, http.Header, error) {
+	creds, err := parseCredentials(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	token, err := client.Register(ctx, creds)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, tokenHeader(token), nil
+}
+
+// authorize - вызов метода авторизации пользователя.
+func authorize(ctx context.Context, client pb.GophKeeperClient, body []byte) ([]byte, http.Header, error) {
+	creds, err := parseCredentials(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	token, err := client.Authorize(ctx, creds)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, tokenHeader(token), nil
+}
+
+// withData - функция для создания вызова метода, который принимает зашифрованные данные.
+func withData(fn func(ctx context.Context, client pb.GophKeeperClient, d *pb.EncryptedData) error) call {
+	return func(ctx context.Context, client pb.GophKeeperClient, body []byte) ([]byte, http.Header, error) {
+		var encrData data.EncryptedData
+		if err := json.Unmarshal(body, &encrData); err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "can't parse data from request, %v", err)
+		}
+		return nil, nil, fn(ctx, client, &pb.EncryptedData{EncryptedData: encrData.EncryptedData, Name: encrData.Name})
+	}
+}
+
+// getAll - вызов метода получения всех данных пользователя. Ответ кодируется в JSON, как в REST API.
+func getAll(ctx context.Context, client pb.GophKeeperClient, _ []byte) ([]byte, http.Header, error) {
+	resp, err := client.GetAllData(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	allData := make([][]data.EncryptedData, 0, len(resp.GetData()))
+	for _, d := range resp.GetData() {
+		versions := make([]data.EncryptedData, 0, len(d.GetVersions()))
+		for _, v := range d.GetVersions() {
+			versions = append(versions, data.EncryptedData{EncryptedData: v.GetEncryptedData(), Name: v.GetName()})
+		}
+		allData = append(allData, versions)
+	}
+	body, err := json.Marshal(allData)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "encoding response error, %v", err)
+	}
+	return body, http.Header{"Content-Type": []string{"application/json"}}, nil
+}
+
+// deleteData - вызов метода удаления данных пользователя.
+func deleteData(ctx context.Context, client pb.GophKeeperClient, body []byte) ([]byte, http.Header, error) {
+	var metaInfo data.MetaInfo
+	if err := json.Unmarshal(body, &metaInfo); err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "decoding request error, %v", err)
+	}
+	_, err := client.DeleteData(ctx, &pb.DataName{Name: metaInfo.Name})
+	return nil, nil, err
+}
+
+// parseCredentials - функция для получения логина и хэша пользователя из тела запроса.
+func parseCredentials(body []byte) (*pb.Credentials, error) {
+	var regData identity.Data
+	if err := json.Unmarshal(body, &regData); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse identity data to structer, %v", err)
+	}
+	return &pb.Credentials{Login: regData.Login, Hash: regData.Hash}, nil
+}
+
+// tokenHeader - функция для создания заголовка ответа с токеном пользователя.
+func tokenHeader(token *pb.Token) http.Header {
+	return http.Header{"Authorization": []string{"Bearer " + token.GetToken()}}
+}
@@ -0,0 +1,289 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/header"
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/token"
+	"github.com/abezemskiy/gophkeeper/internal/common/tlsconfig"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/identity"
+	"github.com/abezemskiy/gophkeeper/internal/server/notify"
+	serverRPC "github.com/abezemskiy/gophkeeper/internal/server/rpc"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage/memory"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const addr = "http://gophkeeper"
+
+// roundTripFunc - REST транспорт для запросов, у которых нет gRPC метода.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// newConn - функция для запуска gRPC сервера с хранилищем в оперативной памяти и подключения к нему.
+// Возвращает также хранилище журнала аудита сервера.
+func newConn(t *testing.T) (*grpc.ClientConn, *notify.Storage, *memory.Store) {
+	t.Helper()
+	token.SetSecretKey("test key")
+	token.SerExpireHour(1)
+
+	store, hub := memory.NewStore(), notify.NewHub()
+	stor := notify.NewStorage(store, hub)
+	srv := serverRPC.NewServer(store, stor, hub, store, store)
+
+	listener := bufconn.Listen(1 << 20)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn, stor, store
+}
+
+func TestTransport(t *testing.T) {
+	conn, _, _ := newConn(t)
+	fallback := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return response(req, http.StatusTeapot, nil, nil), nil
+	})
+	client := resty.New().SetTransport(NewTransport(conn, fallback))
+	creds := identity.Data{Login: "user", Hash: "hash"}
+
+	// Регистрирую пользователя и получаю токен из заголовка ответа, как при работе с REST API
+	resp, err := client.R().SetBody(creds).Post(addr + api.RegisterPattern)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode())
+	jwt, err := header.GetTokenFromRestyResponseHeader(resp)
+	require.NoError(t, err)
+	require.NotEmpty(t, jwt)
+
+	encrData := data.EncryptedData{EncryptedData: []byte("secret"), Name: "card"}
+	conflictData := data.EncryptedData{EncryptedData: []byte("conflict"), Name: "card"}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		auth   bool
+		status int
+		code   string
+	}{
+		{name: "register again", method: http.MethodPost, path: api.RegisterPattern, body: creds, status: http.StatusConflict, code: api.CodeLoginExists},
+		{name: "authorize", method: http.MethodPost, path: api.AuthorizationPattern, body: creds, status: http.StatusOK},
+		{name: "wrong password", method: http.MethodPost, path: api.AuthorizationPattern,
+			body: identity.Data{Login: "user", Hash: "other"}, status: http.StatusBadRequest, code: api.CodeInvalidCredentials},
+		{name: "add without token", method: http.MethodPost, path: api.AddDataPattern, body: encrData, status: http.StatusUnauthorized, code: api.CodeUnauthorized},
+		{name: "add", method: http.MethodPost, path: api.AddDataPattern, body: encrData, auth: true, status: http.StatusOK},
+		{name: "add again", method: http.MethodPost, path: api.AddDataPattern, body: encrData, auth: true, status: http.StatusConflict, code: api.CodeDataExists},
+		{name: "replace", method: http.MethodPost, path: api.ReplaceDataPattern, body: encrData, auth: true, status: http.StatusOK},
+		{name: "append", method: http.MethodPost, path: api.ConflictDataPattern, body: conflictData, auth: true, status: http.StatusOK},
+		{name: "replace unknown", method: http.MethodPost, path: api.ReplaceDataPattern,
+			body: data.EncryptedData{Name: "unknown"}, auth: true, status: http.StatusNotFound, code: api.CodeDataNotFound},
+		{name: "invalid body", method: http.MethodPost, path: api.AddDataPattern, body: "not json", auth: true, status: http.StatusBadRequest, code: api.CodeInvalidRequest},
+		{name: "fallback", method: http.MethodGet, path: api.UsagePattern, auth: true, status: http.StatusTeapot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := client.R().SetBody(tt.body)
+			if tt.auth {
+				req.SetHeader("Authorization", "Bearer "+jwt)
+			}
+			resp, err := req.Execute(tt.method, addr+tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode())
+			if tt.code != "" {
+				assert.Equal(t, tt.code, api.ParseError(resp.StatusCode(), resp.Body()).Code)
+			}
+		})
+	}
+
+	t.Run("get all", func(t *testing.T) {
+		resp, err := client.R().SetHeader("Authorization", "Bearer "+jwt).Get(addr + api.GetDataPattern)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode())
+
+		var allData [][]data.EncryptedData
+		require.NoError(t, json.Unmarshal(resp.Body(), &allData))
+		require.Len(t, allData, 1)
+		assert.Equal(t, []data.EncryptedData{encrData, conflictData}, allData[0])
+	})
+	t.Run("delete", func(t *testing.T) {
+		for _, status := range []int{http.StatusOK, http.StatusNotFound} {
+			resp, err := client.R().SetHeader("Authorization", "Bearer "+jwt).SetBody(data.MetaInfo{Name: encrData.Name}).
+				Delete(addr + api.DeleteDataPattern)
+			require.NoError(t, err)
+			assert.Equal(t, status, resp.StatusCode())
+		}
+	})
+}
+
+func TestTransportDevice(t *testing.T) {
+	conn, _, store := newConn(t)
+	client := resty.New().SetTransport(NewTransport(conn, http.DefaultTransport)).SetHeader(api.DeviceHeader, "laptop")
+
+	// Имя устройства передается в метаданных запроса и попадает в журнал аудита сервера
+	resp, err := client.R().SetBody(identity.Data{Login: "user", Hash: "hash"}).Post(addr + api.RegisterPattern)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode())
+
+	events, err := store.GetAuditEvents(context.Background(), audit.Filter{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, audit.Register, events[0].Type)
+	assert.Equal(t, "laptop", events[0].Device)
+}
+
+func TestTransportDisabled(t *testing.T) {
+	conn, _, store := newConn(t)
+	client := resty.New().SetTransport(NewTransport(conn, http.DefaultTransport))
+	creds := identity.Data{Login: "user", Hash: "hash"}
+
+	resp, err := client.R().SetBody(creds).Post(addr + api.RegisterPattern)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode())
+	jwt, err := header.GetTokenFromRestyResponseHeader(resp)
+	require.NoError(t, err)
+	id, err := token.GetIDFromToken(jwt)
+	require.NoError(t, err)
+	ok, err := store.SetDisabled(context.Background(), id, true)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Блокировка учетной записи возвращается так же, как ее возвращает REST API
+	resp, err = client.R().SetHeader("Authorization", "Bearer "+jwt).SetBody(data.EncryptedData{Name: "card"}).
+		Post(addr + api.AddDataPattern)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+	assert.Equal(t, api.CodeAccountDisabled, api.ParseError(resp.StatusCode(), resp.Body()).Code)
+
+	resp, err = client.R().SetBody(creds).Post(addr + api.AuthorizationPattern)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode())
+	assert.Equal(t, api.CodeAccountDisabled, api.ParseError(resp.StatusCode(), resp.Body()).Code)
+}
+
+func TestTransportUnavailable(t *testing.T) {
+	conn, err := Dial("localhost:1", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Недоступный сервер - ошибка транспорта, по которой клиент переходит в офлайн режим
+	client := resty.New().SetTransport(NewTransport(conn, http.DefaultTransport)).SetTimeout(time.Second)
+	_, err = client.R().SetBody(data.EncryptedData{Name: "card"}).Post(addr + api.AddDataPattern)
+	assert.Error(t, err)
+}
+
+func TestWatch(t *testing.T) {
+	conn, stor, _ := newConn(t)
+	client := resty.New().SetTransport(NewTransport(conn, http.DefaultTransport))
+	resp, err := client.R().SetBody(identity.Data{Login: "user", Hash: "hash"}).Post(addr + api.RegisterPattern)
+	require.NoError(t, err)
+	jwt, err := header.GetTokenFromRestyResponseHeader(resp)
+	require.NoError(t, err)
+	userID, err := token.GetIDFromToken(jwt)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := Watch(ctx, conn, func(context.Context) (string, error) { return jwt, nil }, 10*time.Millisecond)
+
+	// Изменяю данные, пока подписка не получит уведомление
+	timeout := time.After(5 * time.Second)
+	for i := 0; ; i++ {
+		encrData := data.EncryptedData{EncryptedData: []byte("secret"), Name: "card"}
+		_, err := stor.AddEncryptedData(ctx, userID, encrData, data.SAVED)
+		require.NoError(t, err)
+		_, err = stor.DeleteEncryptedData(ctx, userID, encrData.Name)
+		require.NoError(t, err)
+
+		select {
+		case <-changes:
+		case <-time.After(20 * time.Millisecond):
+			continue
+		case <-timeout:
+			t.Fatal("change notification was not received")
+		}
+		break
+	}
+
+	// После завершения контекста канал закрывается
+	cancel()
+	for range changes {
+	}
+}
+
+func TestDialTLS(t *testing.T) {
+	token.SetSecretKey("test key")
+	token.SerExpireHour(1)
+
+	// Выпускаю сертификаты и запускаю сервер, который требует сертификат клиента
+	dir := t.TempDir()
+	ca, err := tlsconfig.NewCA("test CA", time.Hour)
+	require.NoError(t, err)
+	server, err := ca.IssueServer("server", []string{"127.0.0.1"}, time.Hour)
+	require.NoError(t, err)
+	client, err := ca.IssueClient("client", time.Hour)
+	require.NoError(t, err)
+	for name, pair := range map[string]*tlsconfig.Pair{"ca": ca, "server": server, "client": client} {
+		require.NoError(t, pair.WriteFiles(dir, name))
+	}
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	serverCfg, err := tlsconfig.ServerConfig(path("server.crt"), path("server.key"), path("ca.crt"))
+	require.NoError(t, err)
+	store, hub := memory.NewStore(), notify.NewHub()
+	srv := serverRPC.NewServer(store, notify.NewStorage(store, hub), hub, store, store, grpc.Creds(credentials.NewTLS(serverCfg)))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go srv.Serve(listener)
+	defer srv.Stop()
+
+	tests := []struct {
+		name    string
+		cert    string
+		key     string
+		wantErr bool
+	}{
+		{name: "client certificate", cert: path("client.crt"), key: path("client.key")},
+		{name: "without client certificate", wantErr: true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCfg, err := tlsconfig.ClientConfig(path("ca.crt"), tt.cert, tt.key)
+			require.NoError(t, err)
+			conn, err := Dial(listener.Addr().String(), clientCfg)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			restyClient := resty.New().SetTransport(NewTransport(conn, http.DefaultTransport)).SetTimeout(5 * time.Second)
+			resp, err := restyClient.R().SetBody(identity.Data{Login: fmt.Sprintf("user%d", i), Hash: "hash"}).
+				Post(addr + api.RegisterPattern)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode())
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	pb "github.com/abezemskiy/gophkeeper/internal/repositories/proto"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// TokenFunc - функция для получения токена текущего пользователя. Пустой токен означает, что пользователь не авторизован.
+type TokenFunc func(ctx context.Context) (string, error)
+
+// Watch - функция для подписки на уведомления сервера об изменении данных пользователя. Уведомления передаются в
+// возвращаемый канал без блокировки: если предыдущее уведомление еще не обработано, новое с ним объединяется.
+// При разрыве потока, ошибке авторизации или отсутствии пользователя подписка возобновляется через retry.
+// Канал закрывается после завершения контекста.
+func Watch(ctx context.Context, conn grpc.ClientConnInterface, token TokenFunc, retry time.Duration) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	client := pb.NewGophKeeperClient(conn)
+
+	go func() {
+		defer close(changes)
+		for {
+			if err := watch(ctx, client, token, changes); err != nil {
+				logger.ClientLog.Debug("watching changes stopped", zap.String("error", err.Error()))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retry):
+			}
+		}
+	}()
+	return changes
+}
+
+// watch - функция для получения уведомлений из одного потока до его завершения.
+func watch(ctx context.Context, client pb.GophKeeperClient, token TokenFunc, changes chan<- struct{}) error {
+	jwt, err := token(ctx)
+	if err != nil || jwt == "" {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := client.WatchChanges(metadata.AppendToOutgoingContext(ctx, AuthorizationMetadata, "Bearer "+jwt), &emptypb.Empty{})
+	if err != nil {
+		return err
+	}
+	for {
+		change, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		logger.ClientLog.Debug("got change notification", zap.String("name", change.GetName()), zap.String("kind", change.GetKind().String()))
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+}
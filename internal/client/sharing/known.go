@@ -0,0 +1,99 @@
+package sharing
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrKeyChanged - ошибка несовпадения открытого ключа получателя с ключом, закрепленным при первой передаче записи.
+var ErrKeyChanged = errors.New("public key of recipient has changed")
+
+// Fingerprint - функция для получения отпечатка открытого ключа, который пользователи могут сверить между собой.
+func Fingerprint(publicKey []byte) string {
+	sum := sha256.Sum256(publicKey)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// KnownKeys - отпечатки открытых ключей получателей, закрепленные при первой передаче им записи (TOFU).
+// Отпечатки хранятся локально в JSON файле с правами только для владельца отдельно для каждого сервера,
+// поэтому сервер не может незаметно подменить ключ получателя.
+type KnownKeys struct {
+	path   string
+	server string
+}
+
+// NewKnownKeys - фабричная функция закрепленных ключей получателей на сервере server, которые хранятся в файле path.
+func NewKnownKeys(path, server string) *KnownKeys {
+	return &KnownKeys{path: path, server: server}
+}
+
+// DefaultKnownKeysPath - функция для получения пути к файлу закрепленных ключей по умолчанию:
+// gophkeeper/known_keys.json в каталоге настроек пользователя.
+func DefaultKnownKeysPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user config directory, %w", err)
+	}
+	return filepath.Join(dir, "gophkeeper", "known_keys.json"), nil
+}
+
+// Get - метод для получения закрепленного отпечатка ключа получателя login. Если ключ не закреплен,
+// возвращается false.
+func (k *KnownKeys) Get(login string) (string, bool, error) {
+	known, err := k.load()
+	if err != nil {
+		return "", false, err
+	}
+	fingerprint, ok := known[k.server][login]
+	return fingerprint, ok, nil
+}
+
+// Pin - метод для закрепления отпечатка ключа получателя login. Ранее закрепленный отпечаток заменяется.
+func (k *KnownKeys) Pin(login, fingerprint string) error {
+	known, err := k.load()
+	if err != nil {
+		return err
+	}
+	if known[k.server] == nil {
+		known[k.server] = make(map[string]string)
+	}
+	known[k.server][login] = fingerprint
+
+	content, err := json.MarshalIndent(known, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal known keys, %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(k.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create known keys directory, %w", err)
+	}
+	// Файл заменяется целиком, чтобы прерванная запись не повредила закрепленные ключи
+	tmp := k.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write known keys, %w", err)
+	}
+	if err := os.Rename(tmp, k.path); err != nil {
+		return fmt.Errorf("failed to write known keys, %w", err)
+	}
+	return nil
+}
+
+// load - метод для чтения закрепленных отпечатков ключей по адресу сервера и логину получателя.
+func (k *KnownKeys) load() (map[string]map[string]string, error) {
+	known := make(map[string]map[string]string)
+	content, err := os.ReadFile(k.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return known, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known keys, %w", err)
+	}
+	if err := json.Unmarshal(content, &known); err != nil {
+		return nil, fmt.Errorf("failed to parse known keys %s, %w", k.path, err)
+	}
+	return known, nil
+}
@@ -0,0 +1,442 @@
+// Пакет sharing реализует обмен записями между пользователями. У каждого пользователя есть ключевая пара X25519,
+// закрытый ключ которой хранится на сервере зашифрованным ключом хранилища пользователя. Содержимое общей записи
+// шифруется случайным ключом записи, а ключ записи - открытыми ключами владельца и получателя, поэтому сервер
+// не может прочитать ни ключи, ни данные. Теги, папка и отметка избранного владельца получателю не передаются.
+package sharing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
+	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/encryption"
+	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/key"
+	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/random"
+	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/seal"
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/common/tracing"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/share"
+
+	"github.com/go-resty/resty/v2"
+	"go.uber.org/zap"
+)
+
+// Параметры шифрования общих записей.
+const (
+	recordKeySize     = 32                             // размер ключа записи для AES256
+	privateKeyPurpose = "gophkeeper share private key" // назначение ключа шифрования закрытого ключа пользователя
+)
+
+// Ошибки обмена записями.
+var (
+	ErrAttachment = errors.New("records with attachments can't be shared")
+	ErrConflict   = errors.New("record with several versions can't be shared, resolve the conflict first")
+	ErrReadOnly   = errors.New("shared record is read-only")
+	ErrNotFound   = errors.New("shared record not found")
+)
+
+// URLs - адреса ресурсов сервера для обмена записями.
+type URLs struct {
+	Keys   string // адрес ресурса ключевых пар
+	Shares string // адрес ресурса общих записей
+}
+
+// NewURLs - функция для формирования адресов ресурсов обмена записями по адресу сервера.
+func NewURLs(addr string) URLs {
+	return URLs{
+		Keys:   addr + api.KeysPattern,
+		Shares: addr + api.SharesPattern,
+	}
+}
+
+// Keys - ключевая пара пользователя с расшифрованным закрытым ключом.
+type Keys struct {
+	Public  []byte
+	Private []byte
+}
+
+// Record - общая запись, расшифрованная ключевой парой пользователя.
+type Record struct {
+	Share share.Share // общая запись на сервере
+	Data  data.Data   // расшифрованное содержимое
+	key   []byte      // ключ записи
+}
+
+// Writable - метод для проверки, что пользователь login может изменять запись.
+func (r Record) Writable(login string) bool {
+	return r.Share.Owner == login || (r.Share.Accepted && r.Share.Permission == share.Write)
+}
+
+// KeyPair - функция для получения ключевой пары пользователя с сервера. Если пользователь ещё не сохранил ключевую
+// пару, она создается и сохраняется на сервере. Закрытый ключ шифруется ключом, производным от мастер пароля.
+func KeyPair(ctx context.Context, client *resty.Client, urls URLs, masterPass string) (Keys, error) {
+	ctx, span := tracing.Start(ctx, "sharing.KeyPair")
+	defer span.End()
+
+	vaultKey := key.DeriveSubKey(key.DeriveKey(masterPass, recordKeySize), privateKeyPurpose)
+
+	var keys share.KeyPair
+	resp, err := client.R().SetContext(ctx).SetResult(&keys).Get(urls.Keys)
+	if err != nil {
+		return Keys{}, fmt.Errorf("get key pair from server error, %w", err)
+	}
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		private, err := encryption.DecryptAES256(vaultKey, keys.EncryptedPrivateKey)
+		if err != nil {
+			return Keys{}, tracing.Error(span, fmt.Errorf("failed to decrypt private key, %w", err))
+		}
+		return Keys{Public: keys.PublicKey, Private: private}, nil
+	case http.StatusNotFound:
+	default:
+		return Keys{}, fmt.Errorf("get key pair from server error, %w", api.ParseError(resp.StatusCode(), resp.Body()))
+	}
+
+	// Ключевая пара не сохранена, создаю новую
+	public, private, err := seal.GenerateKeyPair()
+	if err != nil {
+		return Keys{}, err
+	}
+	encrPrivate, err := encryption.EncryptAES256(vaultKey, private)
+	if err != nil {
+		return Keys{}, fmt.Errorf("failed to encrypt private key, %w", err)
+	}
+	resp, err = client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(share.KeyPair{PublicKey: public, EncryptedPrivateKey: encrPrivate}).
+		Put(urls.Keys)
+	if err != nil {
+		return Keys{}, fmt.Errorf("save key pair on server error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusNoContent {
+		return Keys{}, fmt.Errorf("save key pair on server error, %w", api.ParseError(resp.StatusCode(), resp.Body()))
+	}
+	logger.ClientLog.Info("key pair for sharing is created")
+	return Keys{Public: public, Private: private}, nil
+}
+
+// Recipient - получатель общей записи.
+type Recipient struct {
+	Login         string     // логин получателя
+	Known         *KnownKeys // закрепленные ключи получателей
+	AcceptChanged bool       // пользователь подтвердил, что ключ получателя изменился, и новый ключ нужно закрепить
+}
+
+// Create - функция для передачи записи, версии которой versions, получателю recipient с правами permission.
+// Записи с вложениями и записи с несколькими версиями передать нельзя. Открытый ключ получателя закрепляется
+// при первой передаче ему записи; если ключ, полученный с сервера, отличается от закрепленного, запись передается
+// только с подтверждением пользователя, иначе возвращается ErrKeyChanged. Возвращает созданную общую запись
+// и отпечаток ключа получателя.
+func Create(ctx context.Context, client *resty.Client, urls URLs, keys Keys, versions []data.Data, recipient Recipient,
+	permission string) (share.Share, string, error) {
+	ctx, span := tracing.Start(ctx, "sharing.Create")
+	defer span.End()
+
+	if len(versions) != 1 {
+		return share.Share{}, "", ErrConflict
+	}
+	record := versions[0]
+	if _, ok := attachment.FromData(&record); ok {
+		return share.Share{}, "", ErrAttachment
+	}
+	if !share.ValidPermission(permission) {
+		return share.Share{}, "", fmt.Errorf("permission must be %s or %s, got %s", share.Read, share.Write, permission)
+	}
+
+	var recipientKey share.PublicKey
+	resp, err := client.R().SetContext(ctx).SetResult(&recipientKey).Get(urls.Keys + "/" + recipient.Login)
+	if err != nil {
+		return share.Share{}, "", fmt.Errorf("get public key of recipient error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return share.Share{}, "", fmt.Errorf("get public key of recipient %s error, %w", recipient.Login,
+			api.ParseError(resp.StatusCode(), resp.Body()))
+	}
+
+	// Сервер может подменить открытый ключ получателя, поэтому ключ сверяется с закрепленным
+	fingerprint := Fingerprint(recipientKey.PublicKey)
+	pinned, ok, err := recipient.Known.Get(recipient.Login)
+	if err != nil {
+		return share.Share{}, "", err
+	}
+	if ok && pinned != fingerprint && !recipient.AcceptChanged {
+		return share.Share{}, fingerprint, fmt.Errorf("%w, recipient %s: pinned %s, got %s", ErrKeyChanged,
+			recipient.Login, pinned, fingerprint)
+	}
+	if !ok || pinned != fingerprint {
+		if err := recipient.Known.Pin(recipient.Login, fingerprint); err != nil {
+			return share.Share{}, "", err
+		}
+		logger.ClientLog.Info("public key of recipient is pinned", zap.String("recipient", recipient.Login),
+			zap.String("fingerprint", fingerprint))
+	}
+
+	recordKey, err := random.GenerateCryptoRandom(recordKeySize)
+	if err != nil {
+		return share.Share{}, "", fmt.Errorf("failed to generate record key, %w", err)
+	}
+	encrData, err := encryptRecord(recordKey, record)
+	if err != nil {
+		return share.Share{}, "", err
+	}
+	ownerKey, err := seal.Seal(keys.Public, recordKey)
+	if err != nil {
+		return share.Share{}, "", fmt.Errorf("failed to seal record key for owner, %w", err)
+	}
+	sealedKey, err := seal.Seal(recipientKey.PublicKey, recordKey)
+	if err != nil {
+		return share.Share{}, "", fmt.Errorf("failed to seal record key for recipient, %w", err)
+	}
+
+	var created share.Share
+	resp, err = client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(share.New{
+			Name:         record.Name,
+			Recipient:    recipient.Login,
+			Permission:   permission,
+			OwnerKey:     ownerKey,
+			RecipientKey: sealedKey,
+			Data:         encrData,
+		}).
+		SetResult(&created).
+		Post(urls.Shares)
+	if err != nil {
+		return share.Share{}, "", fmt.Errorf("create share error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusCreated {
+		return share.Share{}, "", fmt.Errorf("create share error, %w", api.ParseError(resp.StatusCode(), resp.Body()))
+	}
+	logger.ClientLog.Info("record is shared", zap.String("name", record.Name), zap.String("recipient", recipient.Login))
+	return created, fingerprint, nil
+}
+
+// List - функция для получения с сервера общих записей, которыми пользователь поделился или которые переданы ему.
+func List(ctx context.Context, client *resty.Client, urls URLs) ([]share.Share, error) {
+	ctx, span := tracing.Start(ctx, "sharing.List")
+	defer span.End()
+
+	var shares []share.Share
+	resp, err := client.R().SetContext(ctx).SetResult(&shares).Get(urls.Shares)
+	if err != nil {
+		return nil, fmt.Errorf("get shares from server error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("get shares from server error, %w", api.ParseError(resp.StatusCode(), resp.Body()))
+	}
+	return shares, nil
+}
+
+// Accept - функция для принятия получателем общей записи с идентификатором id.
+func Accept(ctx context.Context, client *resty.Client, urls URLs, id string) error {
+	ctx, span := tracing.Start(ctx, "sharing.Accept")
+	defer span.End()
+
+	resp, err := client.R().SetContext(ctx).Post(urls.Shares + "/" + id + "/accept")
+	if err != nil {
+		return fmt.Errorf("accept share error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusNoContent {
+		return fmt.Errorf("accept share error, %w", shareError(resp))
+	}
+	return nil
+}
+
+// Delete - функция для удаления общей записи с идентификатором id: владелец отзывает доступ получателя,
+// а получатель отказывается от записи. Запись владельца не изменяется.
+func Delete(ctx context.Context, client *resty.Client, urls URLs, id string) error {
+	ctx, span := tracing.Start(ctx, "sharing.Delete")
+	defer span.End()
+
+	resp, err := client.R().SetContext(ctx).Delete(urls.Shares + "/" + id)
+	if err != nil {
+		return fmt.Errorf("delete share error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusNoContent {
+		return fmt.Errorf("delete share error, %w", shareError(resp))
+	}
+	return nil
+}
+
+// Open - функция для расшифровывания общей записи ключевой парой пользователя login.
+func Open(sh share.Share, login string, keys Keys) (Record, error) {
+	sealedKey := sh.RecipientKey
+	if sh.Owner == login {
+		sealedKey = sh.OwnerKey
+	}
+	recordKey, err := seal.Open(keys.Private, sealedKey)
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to open record key of share %s, %w", sh.ID, err)
+	}
+	res, err := encryption.DecryptAES256(recordKey, sh.Data)
+	if err != nil {
+		return Record{}, fmt.Errorf("failed to decrypt share %s, %w", sh.ID, err)
+	}
+	var record data.Data
+	if err := json.Unmarshal(res, &record); err != nil {
+		return Record{}, fmt.Errorf("failed to decode share %s, %w", sh.ID, err)
+	}
+	return Record{Share: sh, Data: record, key: recordKey}, nil
+}
+
+// Received - функция для получения принятых пользователем login общих записей других пользователей
+// в расшифрованном виде. Записи, которые не удалось расшифровать, пропускаются.
+func Received(ctx context.Context, client *resty.Client, urls URLs, login string, keys Keys) ([]Record, error) {
+	shares, err := List(ctx, client, urls)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(shares))
+	for _, sh := range shares {
+		if sh.Owner == login || !sh.Accepted {
+			continue
+		}
+		record, err := Open(sh, login, keys)
+		if err != nil {
+			logger.ClientLog.Error("open shared record error", zap.String("error", err.Error()))
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Find - функция для получения общей записи с идентификатором id в расшифрованном виде.
+func Find(ctx context.Context, client *resty.Client, urls URLs, login string, keys Keys, id string) (Record, error) {
+	shares, err := List(ctx, client, urls)
+	if err != nil {
+		return Record{}, err
+	}
+	for _, sh := range shares {
+		if sh.ID == id {
+			return Open(sh, login, keys)
+		}
+	}
+	return Record{}, fmt.Errorf("%w, id %s", ErrNotFound, id)
+}
+
+// Update - функция для изменения содержимого общей записи пользователем login. Изменение отклоняется сервером,
+// если запись изменена после её получения. Возвращает новую версию записи.
+func Update(ctx context.Context, client *resty.Client, urls URLs, login string, record Record, userData data.Data) (int64, error) {
+	ctx, span := tracing.Start(ctx, "sharing.Update")
+	defer span.End()
+
+	if !record.Writable(login) {
+		return 0, ErrReadOnly
+	}
+	if _, ok := attachment.FromData(&userData); ok {
+		return 0, ErrAttachment
+	}
+	userData.Name = record.Share.Name
+	encrData, err := encryptRecord(record.key, userData)
+	if err != nil {
+		return 0, err
+	}
+
+	var updated share.Update
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(share.Update{Data: encrData, Version: record.Share.Version}).
+		SetResult(&updated).
+		Put(urls.Shares + "/" + record.Share.ID + "/data")
+	if err != nil {
+		return 0, fmt.Errorf("update share error, %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		logger.ClientLog.Error("update share error", zap.String("status", strconv.Itoa(resp.StatusCode())))
+		if resp.StatusCode() == http.StatusForbidden {
+			return 0, fmt.Errorf("%w, %w", ErrReadOnly, api.ParseError(resp.StatusCode(), resp.Body()))
+		}
+		return 0, fmt.Errorf("update share error, %w", shareError(resp))
+	}
+	return updated.Version, nil
+}
+
+// Sync - функция для синхронизации записей владельца login с записями, которыми он поделился. Если содержимое общей
+// записи изменено получателем позже локальной записи, функция возвращает обновленную запись с тегами, папкой
+// и отметкой избранного локальной записи, которую нужно сохранить в хранилище владельца. Если позже изменена
+// локальная запись, её содержимое отправляется на сервер. Записи с несколькими версиями и вложениями пропускаются.
+func Sync(ctx context.Context, client *resty.Client, urls URLs, login string, keys Keys, all [][]data.Data) ([]data.Data, error) {
+	ctx, span := tracing.Start(ctx, "sharing.Sync")
+	defer span.End()
+
+	shares, err := List(ctx, client, urls)
+	if err != nil {
+		return nil, tracing.Error(span, err)
+	}
+	local := make(map[string][]data.Data, len(all))
+	for _, versions := range all {
+		if len(versions) > 0 {
+			local[versions[0].Name] = versions
+		}
+	}
+
+	var updated []data.Data
+	for _, sh := range shares {
+		versions, ok := local[sh.Name]
+		if sh.Owner != login || !ok || len(versions) != 1 {
+			continue
+		}
+		record, err := Open(sh, login, keys)
+		if err != nil {
+			logger.ClientLog.Error("open shared record error", zap.String("error", err.Error()))
+			continue
+		}
+		own := versions[0]
+		switch {
+		case changedAt(record.Data).After(changedAt(own)):
+			record.Data.Name = own.Name
+			record.Data.Tags, record.Data.Folder, record.Data.Favorite = own.Tags, own.Folder, own.Favorite
+			updated = append(updated, record.Data)
+			// обработка общих записей, которыми поделились с несколькими получателями, идет по тем же данным
+			local[sh.Name] = []data.Data{record.Data}
+		case changedAt(own).After(changedAt(record.Data)):
+			if _, err := Update(ctx, client, urls, login, record, own); err != nil {
+				logger.ClientLog.Error("push record to share error", zap.String("name", own.Name), zap.String("error", err.Error()))
+			}
+		}
+	}
+	return updated, nil
+}
+
+// encryptRecord - функция для шифрования записи ключом записи. Теги, папка, отметка избранного и статус владельца
+// в общую запись не попадают.
+func encryptRecord(recordKey []byte, record data.Data) ([]byte, error) {
+	record.Tags, record.Folder, record.Favorite, record.Status = nil, "", false, 0
+	b, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode record, %w", err)
+	}
+	encrData, err := encryption.EncryptAES256(recordKey, b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt record, %w", err)
+	}
+	return encrData, nil
+}
+
+// changedAt - функция для получения времени последнего изменения записи.
+func changedAt(record data.Data) time.Time {
+	if record.EditDate.IsZero() {
+		return record.CreateDate
+	}
+	return record.EditDate
+}
+
+// shareError - функция для получения ошибки из ответа сервера. Отсутствующая общая запись возвращается как ErrNotFound.
+func shareError(resp *resty.Response) error {
+	apiErr := api.ParseError(resp.StatusCode(), resp.Body())
+	if apiErr.Code == api.CodeShareNotFound {
+		return fmt.Errorf("%w, %w", ErrNotFound, apiErr)
+	}
+	return apiErr
+}
@@ -0,0 +1,234 @@
+package sharing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/encr/tools/seal"
+	clientData "github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/share"
+	"github.com/abezemskiy/gophkeeper/internal/testutil"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setup - запускает сервер и возвращает адреса ресурсов обмена записями и клиентов пользователей alice и bob.
+func setup(t *testing.T) (URLs, *resty.Client, *resty.Client) {
+	t.Helper()
+	srv := testutil.NewServer(t)
+	return NewURLs(srv.URL), srv.NewClient(t, "alice", "first"), srv.NewClient(t, "bob", "second")
+}
+
+func TestKeyPair(t *testing.T) {
+	ctx := context.Background()
+	urls, alice, _ := setup(t)
+
+	// Ключевая пара создается при первом обращении и не изменяется при следующих
+	keys, err := KeyPair(ctx, alice, urls, "alice password")
+	require.NoError(t, err)
+	assert.Len(t, keys.Public, 32)
+	again, err := KeyPair(ctx, alice, urls, "alice password")
+	require.NoError(t, err)
+	assert.Equal(t, keys, again)
+
+	// Закрытый ключ не расшифровывается другим паролем
+	_, err = KeyPair(ctx, alice, urls, "wrong password")
+	require.Error(t, err)
+}
+
+func TestSharing(t *testing.T) {
+	ctx := context.Background()
+	urls, alice, bob := setup(t)
+	aliceKeys, err := KeyPair(ctx, alice, urls, "alice password")
+	require.NoError(t, err)
+	bobKeys, err := KeyPair(ctx, bob, urls, "bob password")
+	require.NoError(t, err)
+
+	known := NewKnownKeys(filepath.Join(t.TempDir(), "known_keys.json"), urls.Keys)
+	recipient := func(login string) Recipient {
+		return Recipient{Login: login, Known: known}
+	}
+
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	github := data.Data{Data: []byte("secret"), Type: data.PASSWORD, Name: "github", CreateDate: created,
+		Tags: []string{"work"}, Folder: "dev", Favorite: true, Status: data.SAVED}
+
+	{
+		// Записи с несколькими версиями и вложениями передать нельзя, получатель должен иметь ключевую пару
+		_, _, err := Create(ctx, alice, urls, aliceKeys, []data.Data{github, github}, recipient("bob"), share.Write)
+		require.ErrorIs(t, err, ErrConflict)
+
+		b, err := json.Marshal(clientData.Binary{Attachment: &clientData.Attachment{ID: "attachment"}})
+		require.NoError(t, err)
+		_, _, err = Create(ctx, alice, urls, aliceKeys, []data.Data{{Data: b, Type: data.BINARY, Name: "file"}}, recipient("bob"), share.Write)
+		require.ErrorIs(t, err, ErrAttachment)
+
+		_, _, err = Create(ctx, alice, urls, aliceKeys, []data.Data{github}, recipient("carol"), share.Write)
+		assert.True(t, api.IsCode(err, api.CodeKeyNotFound))
+		_, _, err = Create(ctx, alice, urls, aliceKeys, []data.Data{github}, recipient("bob"), "admin")
+		require.Error(t, err)
+	}
+
+	sh, fingerprint, err := Create(ctx, alice, urls, aliceKeys, []data.Data{github}, recipient("bob"), share.Write)
+	require.NoError(t, err)
+	assert.Equal(t, Fingerprint(bobKeys.Public), fingerprint)
+	assert.Equal(t, "alice", sh.Owner)
+	assert.Equal(t, "bob", sh.Recipient)
+	assert.NotContains(t, string(sh.Data), "secret")
+
+	{
+		// Запись доступна получателю после принятия, организация данных владельца не передается
+		received, err := Received(ctx, bob, urls, "bob", bobKeys)
+		require.NoError(t, err)
+		assert.Empty(t, received)
+
+		require.NoError(t, Accept(ctx, bob, urls, sh.ID))
+		received, err = Received(ctx, bob, urls, "bob", bobKeys)
+		require.NoError(t, err)
+		require.Len(t, received, 1)
+		assert.Equal(t, []byte("secret"), received[0].Data.Data)
+		assert.Equal(t, "github", received[0].Data.Name)
+		assert.Empty(t, received[0].Data.Tags)
+		assert.Empty(t, received[0].Data.Folder)
+		assert.False(t, received[0].Data.Favorite)
+		assert.True(t, received[0].Writable("bob"))
+
+		// Владелец не видит своих записей среди полученных
+		received, err = Received(ctx, alice, urls, "alice", aliceKeys)
+		require.NoError(t, err)
+		assert.Empty(t, received)
+	}
+	{
+		// Изменение получателя попадает в хранилище владельца с сохранением его организации данных
+		record, err := Find(ctx, bob, urls, "bob", bobKeys, sh.ID)
+		require.NoError(t, err)
+		changed := record.Data
+		changed.Data = []byte("changed by bob")
+		changed.EditDate = created.Add(time.Hour)
+		version, err := Update(ctx, bob, urls, "bob", record, changed)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), version)
+
+		// Изменение устаревшей версии отклоняется
+		_, err = Update(ctx, bob, urls, "bob", record, changed)
+		assert.True(t, api.IsCode(err, api.CodeShareConflict))
+
+		updated, err := Sync(ctx, alice, urls, "alice", aliceKeys, [][]data.Data{{github}})
+		require.NoError(t, err)
+		require.Len(t, updated, 1)
+		assert.Equal(t, []byte("changed by bob"), updated[0].Data)
+		assert.Equal(t, github.Tags, updated[0].Tags)
+		assert.Equal(t, github.Folder, updated[0].Folder)
+		assert.True(t, updated[0].Favorite)
+		github = updated[0]
+
+		// Повторная синхронизация ничего не изменяет
+		updated, err = Sync(ctx, alice, urls, "alice", aliceKeys, [][]data.Data{{github}})
+		require.NoError(t, err)
+		assert.Empty(t, updated)
+	}
+	{
+		// Изменение владельца отправляется получателю при синхронизации
+		github.Data = []byte("changed by alice")
+		github.EditDate = created.Add(2 * time.Hour)
+		updated, err := Sync(ctx, alice, urls, "alice", aliceKeys, [][]data.Data{{github}})
+		require.NoError(t, err)
+		assert.Empty(t, updated)
+
+		record, err := Find(ctx, bob, urls, "bob", bobKeys, sh.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("changed by alice"), record.Data.Data)
+		assert.Equal(t, int64(3), record.Share.Version)
+	}
+	{
+		// Запись только для чтения получатель изменить не может
+		note := data.Data{Data: []byte("note"), Type: data.TEXT, Name: "note", CreateDate: created}
+		readOnly, _, err := Create(ctx, alice, urls, aliceKeys, []data.Data{note}, recipient("bob"), share.Read)
+		require.NoError(t, err)
+		require.NoError(t, Accept(ctx, bob, urls, readOnly.ID))
+		record, err := Find(ctx, bob, urls, "bob", bobKeys, readOnly.ID)
+		require.NoError(t, err)
+		assert.False(t, record.Writable("bob"))
+		_, err = Update(ctx, bob, urls, "bob", record, note)
+		require.ErrorIs(t, err, ErrReadOnly)
+
+		// Владелец отзывает запись
+		require.NoError(t, Delete(ctx, alice, urls, readOnly.ID))
+		_, err = Find(ctx, bob, urls, "bob", bobKeys, readOnly.ID)
+		require.ErrorIs(t, err, ErrNotFound)
+	}
+	{
+		// Получатель отказывается от записи
+		require.NoError(t, Delete(ctx, bob, urls, sh.ID))
+		require.ErrorIs(t, Delete(ctx, bob, urls, sh.ID), ErrNotFound)
+		require.ErrorIs(t, Accept(ctx, bob, urls, sh.ID), ErrNotFound)
+		shares, err := List(ctx, alice, urls)
+		require.NoError(t, err)
+		assert.Empty(t, shares)
+	}
+}
+
+func TestKnownKeys(t *testing.T) {
+	ctx := context.Background()
+	urls, alice, bob := setup(t)
+	aliceKeys, err := KeyPair(ctx, alice, urls, "alice password")
+	require.NoError(t, err)
+	bobKeys, err := KeyPair(ctx, bob, urls, "bob password")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "gophkeeper", "known_keys.json")
+	known := NewKnownKeys(path, "server")
+	record := func(name string) []data.Data {
+		return []data.Data{{Data: []byte("secret"), Type: data.PASSWORD, Name: name}}
+	}
+
+	// Ключ получателя закрепляется при первой передаче записи
+	_, ok, err := known.Get("bob")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, fingerprint, err := Create(ctx, alice, urls, aliceKeys, record("github"), Recipient{Login: "bob", Known: known}, share.Read)
+	require.NoError(t, err)
+	assert.Equal(t, Fingerprint(bobKeys.Public), fingerprint)
+	pinned, ok, err := known.Get("bob")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, fingerprint, pinned)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// Ключи закрепляются отдельно для каждого сервера
+	_, ok, err = NewKnownKeys(path, "other server").Get("bob")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Сервер отдает другой ключ получателя: запись не передается без подтверждения пользователя
+	public, _, err := seal.GenerateKeyPair()
+	require.NoError(t, err)
+	resp, err := bob.R().SetHeader("Content-Type", "application/json").
+		SetBody(share.KeyPair{PublicKey: public, EncryptedPrivateKey: []byte("private")}).Put(urls.Keys)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode())
+
+	_, _, err = Create(ctx, alice, urls, aliceKeys, record("gitlab"), Recipient{Login: "bob", Known: known}, share.Read)
+	require.ErrorIs(t, err, ErrKeyChanged)
+	pinned, _, err = known.Get("bob")
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint, pinned)
+
+	_, changed, err := Create(ctx, alice, urls, aliceKeys, record("gitlab"), Recipient{Login: "bob", Known: known, AcceptChanged: true}, share.Read)
+	require.NoError(t, err)
+	assert.Equal(t, Fingerprint(public), changed)
+	pinned, _, err = known.Get("bob")
+	require.NoError(t, err)
+	assert.Equal(t, changed, pinned)
+}
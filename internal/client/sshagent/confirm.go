@@ -0,0 +1,68 @@
+package sshagent
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// TTYPath - путь к управляющему терминалу, в котором запрашивается подтверждение использования ключа.
+const TTYPath = "/dev/tty"
+
+// Prompt - функция для создания подтверждения, которое задает вопрос в out и читает ответ из in.
+// Использование ключа разрешается ответом "y" или "yes" без учета регистра.
+func Prompt(in io.Reader, out io.Writer) Confirm {
+	reader := bufio.NewReader(in)
+	return func(key Key) (bool, error) {
+		if _, err := fmt.Fprintf(out, "%s [y/N] ", question(key)); err != nil {
+			return false, err
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return false, err
+		}
+		answer := strings.ToLower(strings.TrimSpace(line))
+		return answer == "y" || answer == "yes", nil
+	}
+}
+
+// TTY - функция для создания подтверждения в управляющем терминале. Терминал открывается при каждом запросе,
+// поэтому SSH агент может работать в фоне, пока пользователь работает в терминале.
+func TTY() Confirm {
+	return func(key Key) (bool, error) {
+		tty, err := os.OpenFile(TTYPath, os.O_RDWR, 0)
+		if err != nil {
+			return false, fmt.Errorf("failed to open terminal, %w", err)
+		}
+		defer tty.Close()
+		return Prompt(tty, tty)(key)
+	}
+}
+
+// Askpass - функция для создания подтверждения программой в стиле ssh-askpass. Программа получает вопрос первым
+// аргументом и переменную окружения SSH_ASKPASS_PROMPT=confirm, нулевой код завершения разрешает использование ключа.
+func Askpass(ctx context.Context, program string) Confirm {
+	return func(key Key) (bool, error) {
+		cmd := exec.CommandContext(ctx, program, question(key))
+		cmd.Env = append(os.Environ(), "SSH_ASKPASS_PROMPT=confirm")
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to run %s, %w", program, err)
+		}
+		return true, nil
+	}
+}
+
+// question - функция для получения вопроса о разрешении использовать ключ.
+func question(key Key) string {
+	return fmt.Sprintf("Allow use of SSH key %s (%s)?", key.Name, key.Key.Fingerprint)
+}
@@ -0,0 +1,251 @@
+// Пакет sshagent содержит SSH агента клиента. Агент работает по протоколу ssh-agent поверх Unix-сокета и подписывает
+// запросы ssh ключами из хранилища пользователя, поэтому закрытые ключи не записываются на диск. Ключи запрашиваются
+// заново при каждом запросе, так что после блокировки сессии агент перестает их выдавать, а каждое использование
+// ключа требует подтверждения пользователя.
+package sshagent
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/localsocket"
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// Ошибки SSH агента.
+var (
+	ErrDenied   = errors.New("use of key is denied by user")
+	ErrNotFound = errors.New("key not found")
+	ErrReadOnly = errors.New("keys are managed by gophkeeper, add them to the vault")
+)
+
+// Key - SSH ключ из хранилища пользователя.
+type Key struct {
+	Name string // имя данных пользователя
+	Key  data.SSHKey
+}
+
+// Source - функция для получения SSH ключей пользователя.
+type Source func(ctx context.Context) ([]Key, error)
+
+// Confirm - функция для подтверждения использования ключа пользователем. Возвращает true, если использование разрешено.
+type Confirm func(key Key) (bool, error)
+
+// SocketEnv - переменная окружения с путем к сокету SSH агента.
+const SocketEnv = "GOPHKEEPER_SSH_AGENT_SOCKET"
+
+// SocketPath - функция для получения пути к сокету SSH агента. Путь задается переменной окружения SocketEnv,
+// по умолчанию сокет располагается в приватной директории пользователя (см. localsocket.DefaultPath).
+func SocketPath() string {
+	if path := os.Getenv(SocketEnv); path != "" {
+		return path
+	}
+	return localsocket.DefaultPath("ssh.sock")
+}
+
+// Agent - SSH агент, реализует интерфейс agent.ExtendedAgent. Агент только подписывает данные ключами хранилища:
+// добавление и удаление ключей, а также блокировка агента средствами ssh-add не поддерживаются.
+type Agent struct {
+	ctx     context.Context
+	source  Source
+	confirm Confirm
+
+	mu sync.Mutex // запросы подтверждения выполняются по одному
+}
+
+// New - фабричная функция SSH агента. ctx - контекст, в котором выполняются запросы ключей к source.
+func New(ctx context.Context, source Source, confirm Confirm) *Agent {
+	return &Agent{ctx: ctx, source: source, confirm: confirm}
+}
+
+// List - метод для получения открытых ключей и сертификатов хранилища. Комментарием ключа служит комментарий
+// SSH ключа или, если он не задан, имя данных.
+func (a *Agent) List() ([]*agent.Key, error) {
+	keys, err := a.source(a.ctx)
+	if err != nil {
+		return nil, err
+	}
+	var list []*agent.Key
+	for _, k := range keys {
+		pub, cert, err := publicKeys(k.Key)
+		if err != nil {
+			logger.ClientLog.Error("parse ssh key error", zap.String("name", k.Name), zap.String("error", err.Error()))
+			continue
+		}
+		comment := k.Key.Comment
+		if comment == "" {
+			comment = k.Name
+		}
+		list = append(list, &agent.Key{Format: pub.Type(), Blob: pub.Marshal(), Comment: comment})
+		if cert != nil {
+			list = append(list, &agent.Key{Format: cert.Type(), Blob: cert.Marshal(), Comment: comment})
+		}
+	}
+	return list, nil
+}
+
+// Sign - метод для подписи сообщения ключом key.
+func (a *Agent) Sign(key ssh.PublicKey, message []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, message, 0)
+}
+
+// SignWithFlags - метод для подписи сообщения ключом key. Перед подписью у пользователя запрашивается подтверждение.
+func (a *Agent) SignWithFlags(key ssh.PublicKey, message []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	k, err := a.find(key)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	allowed, err := a.confirm(k)
+	a.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm use of key %s, %w", k.Name, err)
+	}
+	if !allowed {
+		logger.ClientLog.Warn("use of ssh key denied", zap.String("name", k.Name), zap.String("fingerprint", k.Key.Fingerprint))
+		return nil, ErrDenied
+	}
+
+	signer, err := k.Key.Signer()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := sign(signer, message, flags)
+	if err != nil {
+		return nil, err
+	}
+	logger.ClientLog.Info("ssh key used", zap.String("name", k.Name), zap.String("fingerprint", k.Key.Fingerprint))
+	return sig, nil
+}
+
+// find - метод для поиска ключа хранилища по открытому ключу или сертификату.
+func (a *Agent) find(key ssh.PublicKey) (Key, error) {
+	keys, err := a.source(a.ctx)
+	if err != nil {
+		return Key{}, err
+	}
+	blob := key.Marshal()
+	for _, k := range keys {
+		pub, cert, err := publicKeys(k.Key)
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(pub.Marshal(), blob) || (cert != nil && bytes.Equal(cert.Marshal(), blob)) {
+			return k, nil
+		}
+	}
+	return Key{}, ErrNotFound
+}
+
+// Signers - ключи хранилища не передаются за пределы агента.
+func (a *Agent) Signers() ([]ssh.Signer, error) {
+	return nil, ErrReadOnly
+}
+
+// Add - добавление ключей не поддерживается.
+func (a *Agent) Add(agent.AddedKey) error {
+	return ErrReadOnly
+}
+
+// Remove - удаление ключей не поддерживается.
+func (a *Agent) Remove(ssh.PublicKey) error {
+	return ErrReadOnly
+}
+
+// RemoveAll - удаление ключей не поддерживается.
+func (a *Agent) RemoveAll() error {
+	return ErrReadOnly
+}
+
+// Lock - блокировка агента не поддерживается, сессия блокируется командой lock локального агента.
+func (a *Agent) Lock([]byte) error {
+	return ErrReadOnly
+}
+
+// Unlock - разблокировка агента не поддерживается, сессия разблокируется командой unlock локального агента.
+func (a *Agent) Unlock([]byte) error {
+	return ErrReadOnly
+}
+
+// Extension - расширения протокола не поддерживаются.
+func (a *Agent) Extension(string, []byte) ([]byte, error) {
+	return nil, agent.ErrExtensionUnsupported
+}
+
+// Serve - запускает SSH агента на Unix-сокете socket и блокируется до завершения контекста.
+func (a *Agent) Serve(ctx context.Context, socket string) error {
+	listener, err := localsocket.Listen(socket)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	logger.ClientLog.Info("ssh agent started", zap.String("socket", socket))
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				logger.ClientLog.Info("ssh agent stopped")
+				return nil
+			}
+			return fmt.Errorf("ssh agent stopped with error, %w", err)
+		}
+		go func() {
+			defer conn.Close()
+			if err := agent.ServeAgent(a, conn); err != nil && !errors.Is(err, io.EOF) {
+				logger.ClientLog.Debug("ssh agent connection closed", zap.String("error", err.Error()))
+			}
+		}()
+	}
+}
+
+// publicKeys - функция для получения открытого ключа и сертификата SSH ключа.
+func publicKeys(key data.SSHKey) (ssh.PublicKey, *ssh.Certificate, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key.PublicKey))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse public key, %w", err)
+	}
+	cert, err := key.Cert()
+	if err != nil {
+		return nil, nil, err
+	}
+	return pub, cert, nil
+}
+
+// sign - функция для подписи сообщения с учетом флагов алгоритма подписи RSA.
+func sign(signer ssh.Signer, message []byte, flags agent.SignatureFlags) (*ssh.Signature, error) {
+	if flags == 0 {
+		return signer.Sign(rand.Reader, message)
+	}
+	algorithmSigner, ok := signer.(ssh.AlgorithmSigner)
+	if !ok {
+		return nil, fmt.Errorf("key %s does not support signature flags", signer.PublicKey().Type())
+	}
+	var algorithm string
+	switch flags {
+	case agent.SignatureFlagRsaSha256:
+		algorithm = ssh.KeyAlgoRSASHA256
+	case agent.SignatureFlagRsaSha512:
+		algorithm = ssh.KeyAlgoRSASHA512
+	default:
+		return nil, fmt.Errorf("unsupported signature flags %d", flags)
+	}
+	return algorithmSigner.SignWithAlgorithm(rand.Reader, message, algorithm)
+}
@@ -0,0 +1,207 @@
+package sshagent
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// newKey - функция для создания SSH ключа хранилища из закрытого ключа priv. Если withCert, на ключ выпускается
+// сертификат пользователя.
+func newKey(t *testing.T, name string, priv any, withCert bool) Key {
+	t.Helper()
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	require.NoError(t, err)
+
+	var certificate string
+	if withCert {
+		signer, err := ssh.NewSignerFromKey(priv)
+		require.NoError(t, err)
+		_, caKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		ca, err := ssh.NewSignerFromKey(caKey)
+		require.NoError(t, err)
+		cert := &ssh.Certificate{Key: signer.PublicKey(), CertType: ssh.UserCert, ValidBefore: ssh.CertTimeInfinity}
+		require.NoError(t, cert.SignCert(rand.Reader, ca))
+		certificate = string(ssh.MarshalAuthorizedKey(cert))
+	}
+
+	key, err := data.NewSSHKey(string(pem.EncodeToMemory(block)), "", "", certificate)
+	require.NoError(t, err)
+	return Key{Name: name, Key: key}
+}
+
+// testKeys - функция для создания ключей ed25519 с сертификатом и RSA без сертификата.
+func testKeys(t *testing.T) []Key {
+	t.Helper()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return []Key{newKey(t, "github", edKey, true), newKey(t, "legacy", rsaKey, false)}
+}
+
+// newClient - функция для подключения клиента ssh-agent к агенту через канал в памяти.
+func newClient(t *testing.T, a *Agent) agent.ExtendedAgent {
+	t.Helper()
+	server, client := net.Pipe()
+	go agent.ServeAgent(a, server)
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return agent.NewClient(client)
+}
+
+func TestAgent(t *testing.T) {
+	keys := testKeys(t)
+	source := func(context.Context) ([]Key, error) { return keys, nil }
+
+	var asked []string
+	allow := true
+	confirm := func(key Key) (bool, error) {
+		asked = append(asked, key.Name)
+		return allow, nil
+	}
+	client := newClient(t, New(context.Background(), source, confirm))
+
+	list, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, ssh.KeyAlgoED25519, list[0].Format)
+	assert.Equal(t, ssh.CertAlgoED25519v01, list[1].Format)
+	assert.Equal(t, ssh.KeyAlgoRSA, list[2].Format)
+	assert.Equal(t, "legacy", list[2].Comment)
+
+	message := []byte("session data")
+	t.Run("key", func(t *testing.T) {
+		sig, err := client.Sign(list[0], message)
+		require.NoError(t, err)
+		assert.NoError(t, list[0].Verify(message, sig))
+	})
+	t.Run("certificate", func(t *testing.T) {
+		sig, err := client.Sign(list[1], message)
+		require.NoError(t, err)
+		cert, err := ssh.ParsePublicKey(list[1].Blob)
+		require.NoError(t, err)
+		assert.NoError(t, cert.(*ssh.Certificate).Key.Verify(message, sig))
+	})
+	t.Run("rsa sha256", func(t *testing.T) {
+		sig, err := client.SignWithFlags(list[2], message, agent.SignatureFlagRsaSha256)
+		require.NoError(t, err)
+		assert.Equal(t, ssh.KeyAlgoRSASHA256, sig.Format)
+		assert.NoError(t, list[2].Verify(message, sig))
+	})
+	assert.Equal(t, []string{"github", "github", "legacy"}, asked)
+
+	t.Run("denied", func(t *testing.T) {
+		allow = false
+		_, err := client.Sign(list[0], message)
+		assert.Error(t, err)
+		allow = true
+	})
+	t.Run("unknown key", func(t *testing.T) {
+		other := testKeys(t)[0]
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(other.Key.PublicKey))
+		require.NoError(t, err)
+		_, err = client.Sign(pub, message)
+		assert.Error(t, err)
+	})
+	t.Run("read only", func(t *testing.T) {
+		_, edKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		assert.Error(t, client.Add(agent.AddedKey{PrivateKey: edKey}))
+		assert.Error(t, client.RemoveAll())
+	})
+}
+
+func TestAgentSourceError(t *testing.T) {
+	a := New(context.Background(), func(context.Context) ([]Key, error) { return nil, errors.New("agent is locked") },
+		func(Key) (bool, error) { return true, nil })
+	_, err := a.List()
+	assert.Error(t, err)
+	_, err = a.Sign(nil, []byte("message"))
+	assert.Error(t, err)
+}
+
+func TestPrompt(t *testing.T) {
+	key := Key{Name: "github", Key: data.SSHKey{Fingerprint: "SHA256:abc"}}
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{answer: "y\n", want: true},
+		{answer: " YES \r\n", want: true},
+		{answer: "n\n", want: false},
+		{answer: "\n", want: false},
+		{answer: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.answer), func(t *testing.T) {
+			var out bytes.Buffer
+			allowed, err := Prompt(strings.NewReader(tt.answer), &out)(key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, allowed)
+			assert.Equal(t, "Allow use of SSH key github (SHA256:abc)? [y/N] ", out.String())
+		})
+	}
+}
+
+func TestServe(t *testing.T) {
+	// Путь к Unix-сокету ограничен по длине, поэтому используется короткий путь во временной директории
+	dir, err := os.MkdirTemp("", "ssh")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "agent.sock")
+
+	keys := testKeys(t)
+	a := New(context.Background(), func(context.Context) ([]Key, error) { return keys, nil },
+		func(Key) (bool, error) { return true, nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Serve(ctx, socket) }()
+
+	var conn net.Conn
+	require.Eventually(t, func() bool {
+		conn, err = net.Dial("unix", socket)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	defer conn.Close()
+
+	info, err := os.Stat(socket)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	list, err := agent.NewClient(conn).List()
+	require.NoError(t, err)
+	assert.Len(t, list, 3)
+
+	// Второй агент на том же сокете не запускается
+	assert.Error(t, a.Serve(context.Background(), socket))
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("ssh agent is not stopped")
+	}
+}
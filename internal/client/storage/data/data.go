@@ -12,9 +12,25 @@ type Text struct {
 }
 
 // Binary - структура для хранения бинарных данных.
+// Небольшие файлы хранятся непосредственно в поле Binary. Содержимое больших файлов хранится на сервере
+// зашифрованными частями, а в записи сохраняется только манифест вложения.
 type Binary struct {
-	Binary []byte `json:"binary"`
-	Type   string `json:"type"` // MIME-тип (например, "image/png")
+	Binary     []byte      `json:"binary"`
+	Type       string      `json:"type"`                 // MIME-тип (например, "image/png")
+	Attachment *Attachment `json:"attachment,omitempty"` // манифест вложения, если файл хранится частями
+}
+
+// Attachment - манифест вложения. Манифест хранится внутри зашифрованной записи, поэтому ключ шифрования
+// частей недоступен серверу. Части шифруются детерминированно конвергентным ключом пользователя, поэтому одинаковые
+// файлы пользователя дают одинаковые части и одинаковый идентификатор вложения и хранятся на сервере один раз.
+type Attachment struct {
+	ID        string   `json:"id"`         // идентификатор вложения на сервере
+	Record    string   `json:"record"`     // идентификатор ссылки записи на вложение на сервере
+	Size      int64    `json:"size"`       // размер исходного файла в байтах
+	ChunkSize int      `json:"chunk_size"` // размер части до шифрования в байтах
+	Chunks    []string `json:"chunks"`     // SHA-256 хэши зашифрованных частей в порядке следования
+	Key       []byte   `json:"key"`        // конвергентный ключ пользователя для шифрования частей
+	Hash      string   `json:"hash"`       // SHA-256 исходного файла в шестнадцатеричном виде
 }
 
 // Bank - структура для хранения данных банковской карты.
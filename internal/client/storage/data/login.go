@@ -0,0 +1,98 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+)
+
+// Типы дополнительных полей учетной записи.
+const (
+	FieldText    = "text"    // обычный текст
+	FieldHidden  = "hidden"  // скрытое значение, например PIN-код
+	FieldBoolean = "boolean" // логическое значение true или false
+)
+
+// Login - структура для хранения учетной записи. Поля Login и Password совпадают с полями Password,
+// поэтому данные типа PASSWORD читаются как учетная запись без дополнительных сведений.
+type Login struct {
+	Login    string   `json:"login"`
+	Password string   `json:"password"`
+	URLs     []string `json:"urls,omitempty"`
+	Notes    string   `json:"notes,omitempty"`
+	TOTP     string   `json:"totp,omitempty"`   // секрет TOTP в виде строки base32 или URI otpauth://
+	Fields   []Field  `json:"fields,omitempty"` // дополнительные поля
+}
+
+// Field - дополнительное поле учетной записи.
+type Field struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  string `json:"type"` // FieldText, FieldHidden или FieldBoolean
+}
+
+// String - метод для получения поля в виде "тип:имя=значение", который принимает ParseField.
+func (f Field) String() string {
+	return f.Type + ":" + f.Name + "=" + f.Value
+}
+
+// ParseField - функция для разбора дополнительного поля в виде "[тип:]имя=значение". Тип по умолчанию - text,
+// значение поля типа boolean приводится к виду true или false.
+func ParseField(s string) (Field, error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return Field{}, fmt.Errorf("field %q must be set as [type:]name=value", s)
+	}
+	f := Field{Name: strings.TrimSpace(name), Value: value, Type: FieldText}
+	if fieldType, fieldName, ok := strings.Cut(f.Name, ":"); ok {
+		f.Type, f.Name = strings.ToLower(strings.TrimSpace(fieldType)), strings.TrimSpace(fieldName)
+	}
+	if f.Name == "" {
+		return Field{}, fmt.Errorf("field %q has empty name", s)
+	}
+
+	switch f.Type {
+	case FieldText, FieldHidden:
+	case FieldBoolean:
+		b, err := strconv.ParseBool(strings.TrimSpace(f.Value))
+		if err != nil {
+			return Field{}, fmt.Errorf("value of boolean field %s is not valid, %w", f.Name, err)
+		}
+		f.Value = strconv.FormatBool(b)
+	default:
+		return Field{}, fmt.Errorf("field %s has unknown type %s, supported types: %s, %s, %s",
+			f.Name, f.Type, FieldText, FieldHidden, FieldBoolean)
+	}
+	return f, nil
+}
+
+// ParseFields - функция для разбора дополнительных полей, записанных по одному в строке. Пустые строки пропускаются.
+func ParseFields(text string) ([]Field, error) {
+	var fields []Field
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		f, err := ParseField(strings.TrimRight(line, "\r"))
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// DecodeLogin - функция для чтения учетной записи из данных типа LOGIN или PASSWORD.
+func DecodeLogin(userData repoData.Data) (Login, error) {
+	if userData.Type != repoData.LOGIN && userData.Type != repoData.PASSWORD {
+		return Login{}, fmt.Errorf("data %s is not a login", userData.Name)
+	}
+	var l Login
+	if err := json.Unmarshal(userData.Data, &l); err != nil {
+		return Login{}, fmt.Errorf("failed to unmarshal data %s, %w", userData.Name, err)
+	}
+	return l, nil
+}
@@ -0,0 +1,70 @@
+package data
+
+import (
+	"encoding/json"
+	"testing"
+
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseField(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Field
+		wantErr bool
+	}{
+		{input: "account=42", want: Field{Name: "account", Value: "42", Type: FieldText}},
+		{input: "hidden:pin=1234", want: Field{Name: "pin", Value: "1234", Type: FieldHidden}},
+		{input: "Boolean: admin = 1", want: Field{Name: "admin", Value: "true", Type: FieldBoolean}},
+		{input: "url=https://example.com/?a=b", want: Field{Name: "url", Value: "https://example.com/?a=b", Type: FieldText}},
+		{input: "no value", wantErr: true},
+		{input: "=value", wantErr: true},
+		{input: "boolean:admin=maybe", wantErr: true},
+		{input: "date:expires=2030", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseField(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			// Строковое представление поля разбирается в то же поле
+			again, err := ParseField(got.String())
+			require.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+
+	fields, err := ParseFields("a=1\r\n\nhidden:b=2\n")
+	require.NoError(t, err)
+	assert.Equal(t, []Field{{Name: "a", Value: "1", Type: FieldText}, {Name: "b", Value: "2", Type: FieldHidden}}, fields)
+	_, err = ParseFields("a=1\nbad")
+	assert.Error(t, err)
+}
+
+func TestDecodeLogin(t *testing.T) {
+	// Данные типа PASSWORD, сохраненные до появления учетных записей
+	old, err := json.Marshal(Password{Login: "admin", Password: "secret"})
+	require.NoError(t, err)
+	l, err := DecodeLogin(repoData.Data{Name: "old", Type: repoData.PASSWORD, Data: old})
+	require.NoError(t, err)
+	assert.Equal(t, Login{Login: "admin", Password: "secret"}, l)
+
+	login := Login{Login: "admin", Password: "secret", URLs: []string{"https://example.com"}, TOTP: "JBSWY3DPEHPK3PXP",
+		Fields: []Field{{Name: "pin", Value: "1234", Type: FieldHidden}}}
+	b, err := json.Marshal(login)
+	require.NoError(t, err)
+	l, err = DecodeLogin(repoData.Data{Name: "new", Type: repoData.LOGIN, Data: b})
+	require.NoError(t, err)
+	assert.Equal(t, login, l)
+
+	_, err = DecodeLogin(repoData.Data{Name: "text", Type: repoData.TEXT, Data: b})
+	assert.Error(t, err)
+}
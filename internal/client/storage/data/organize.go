@@ -0,0 +1,71 @@
+package data
+
+import (
+	"sort"
+	"strings"
+)
+
+// FolderSeparator - разделитель папок в пути папки данных.
+const FolderSeparator = "/"
+
+// ParseTags - функция для разбора списка тегов, разделенных запятыми. Пустые теги и повторы без учета регистра
+// отбрасываются, результат отсортирован без учета регистра.
+func ParseTags(s string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		tags = append(tags, tag)
+	}
+	SortTags(tags)
+	return tags
+}
+
+// SortTags - функция для сортировки тегов без учета регистра.
+func SortTags(tags []string) {
+	sort.SliceStable(tags, func(i, j int) bool { return strings.ToLower(tags[i]) < strings.ToLower(tags[j]) })
+}
+
+// CleanFolder - функция для приведения пути папки к каноническому виду "a/b/c". Пустые элементы пути,
+// а также элементы "." и ".." отбрасываются.
+func CleanFolder(folder string) string {
+	var parts []string
+	for _, part := range strings.Split(folder, FolderSeparator) {
+		part = strings.TrimSpace(part)
+		if part == "" || part == "." || part == ".." {
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, FolderSeparator)
+}
+
+// InFolder - функция для проверки, что папка folder совпадает с папкой parent или вложена в нее.
+// Пустая папка parent содержит все папки.
+func InFolder(folder, parent string) bool {
+	if parent == "" || folder == parent {
+		return true
+	}
+	return strings.HasPrefix(folder, parent+FolderSeparator)
+}
+
+// HasTags - функция для проверки, что среди тегов данных есть все теги want. Теги сравниваются без учета регистра.
+func HasTags(tags, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, tag := range tags {
+			if strings.EqualFold(tag, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
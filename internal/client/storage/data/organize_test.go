@@ -0,0 +1,41 @@
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTags(t *testing.T) {
+	assert.Equal(t, []string{"bank", "Work"}, ParseTags(" Work, bank,,work "))
+	assert.Nil(t, ParseTags(""))
+}
+
+func TestCleanFolder(t *testing.T) {
+	tests := []struct {
+		folder string
+		want   string
+	}{
+		{folder: "", want: ""},
+		{folder: "work", want: "work"},
+		{folder: "/work//servers/ ", want: "work/servers"},
+		{folder: "../work/./db", want: "work/db"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CleanFolder(tt.folder))
+	}
+}
+
+func TestInFolder(t *testing.T) {
+	assert.True(t, InFolder("work/db", ""))
+	assert.True(t, InFolder("work/db", "work"))
+	assert.True(t, InFolder("work", "work"))
+	assert.False(t, InFolder("workshop", "work"))
+	assert.False(t, InFolder("", "work"))
+}
+
+func TestHasTags(t *testing.T) {
+	assert.True(t, HasTags([]string{"Work", "bank"}, []string{"work"}))
+	assert.True(t, HasTags([]string{"work"}, nil))
+	assert.False(t, HasTags([]string{"work"}, []string{"work", "bank"}))
+}
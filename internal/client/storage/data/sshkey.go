@@ -0,0 +1,128 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrPassphraseRequired - ошибка чтения зашифрованного закрытого ключа без парольной фразы.
+var ErrPassphraseRequired = errors.New("private key is encrypted, passphrase is required")
+
+// SSHKey - структура для хранения SSH ключа. Открытый ключ и отпечаток вычисляются из закрытого ключа при сохранении,
+// поэтому их можно показывать и искать без разбора закрытого ключа.
+type SSHKey struct {
+	PrivateKey  string `json:"private_key"`           // закрытый ключ в формате PEM
+	Passphrase  string `json:"passphrase,omitempty"`  // парольная фраза закрытого ключа
+	PublicKey   string `json:"public_key"`            // открытый ключ в формате authorized_keys
+	Fingerprint string `json:"fingerprint"`           // отпечаток открытого ключа в виде SHA256:...
+	Comment     string `json:"comment,omitempty"`     // комментарий ключа
+	Certificate string `json:"certificate,omitempty"` // сертификат ключа в формате authorized_keys
+}
+
+// NewSSHKey - функция для создания SSH ключа из закрытого ключа в формате PEM. Закрытый ключ проверяется парольной
+// фразой, сертификат, если он задан, должен быть выдан на этот же ключ. Если комментарий не задан, используется
+// комментарий сертификата.
+func NewSSHKey(privateKey, passphrase, comment, certificate string) (SSHKey, error) {
+	key := SSHKey{
+		PrivateKey:  strings.TrimSpace(privateKey) + "\n",
+		Passphrase:  passphrase,
+		Comment:     strings.TrimSpace(comment),
+		Certificate: strings.TrimSpace(certificate),
+	}
+	signer, err := key.Signer()
+	if err != nil {
+		return SSHKey{}, err
+	}
+	pub := signer.PublicKey()
+	key.PublicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub)))
+	key.Fingerprint = ssh.FingerprintSHA256(pub)
+
+	if key.Certificate == "" {
+		return key, nil
+	}
+	cert, certComment, err := parseCertificate(key.Certificate)
+	if err != nil {
+		return SSHKey{}, err
+	}
+	if !bytes.Equal(cert.Key.Marshal(), pub.Marshal()) {
+		return SSHKey{}, fmt.Errorf("certificate is not issued for key %s", key.Fingerprint)
+	}
+	if key.Comment == "" {
+		key.Comment = certComment
+	}
+	return key, nil
+}
+
+// Signer - метод для получения подписывающего ключа из закрытого ключа.
+func (k SSHKey) Signer() (ssh.Signer, error) {
+	raw, err := k.RawKey()
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.NewSignerFromKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported private key, %w", err)
+	}
+	return signer, nil
+}
+
+// RawKey - метод для получения закрытого ключа в виде ключа стандартной библиотеки (*rsa.PrivateKey,
+// *ecdsa.PrivateKey или *ed25519.PrivateKey). Зашифрованный ключ расшифровывается парольной фразой.
+func (k SSHKey) RawKey() (any, error) {
+	var raw any
+	var err error
+	if k.Passphrase != "" {
+		raw, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(k.PrivateKey), []byte(k.Passphrase))
+	} else {
+		raw, err = ssh.ParseRawPrivateKey([]byte(k.PrivateKey))
+	}
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		return nil, ErrPassphraseRequired
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key, %w", err)
+	}
+	return raw, nil
+}
+
+// Cert - метод для получения сертификата ключа. Если сертификат не задан, возвращается nil.
+func (k SSHKey) Cert() (*ssh.Certificate, error) {
+	if k.Certificate == "" {
+		return nil, nil
+	}
+	cert, _, err := parseCertificate(k.Certificate)
+	return cert, err
+}
+
+// DecodeSSHKey - функция для чтения SSH ключа из данных типа SSHKEY.
+func DecodeSSHKey(userData repoData.Data) (SSHKey, error) {
+	if userData.Type != repoData.SSHKEY {
+		return SSHKey{}, fmt.Errorf("data %s is not an SSH key", userData.Name)
+	}
+	var k SSHKey
+	if err := json.Unmarshal(userData.Data, &k); err != nil {
+		return SSHKey{}, fmt.Errorf("failed to unmarshal data %s, %w", userData.Name, err)
+	}
+	return k, nil
+}
+
+// parseCertificate - функция для разбора сертификата в формате authorized_keys. Возвращает сертификат и его комментарий.
+func parseCertificate(certificate string) (*ssh.Certificate, string, error) {
+	pub, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(certificate))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse certificate, %w", err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, "", fmt.Errorf("%s is not a certificate", pub.Type())
+	}
+	return cert, comment, nil
+}
@@ -0,0 +1,120 @@
+package data
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// newPrivateKey - функция для создания закрытого ключа ed25519 в формате PEM, зашифрованного парольной фразой,
+// если она задана.
+func newPrivateKey(t *testing.T, passphrase string) (string, ssh.PublicKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	var block *pem.Block
+	if passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "user@host", []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(priv, "user@host")
+	}
+	require.NoError(t, err)
+	sshPub, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(block)), sshPub
+}
+
+// newCertificate - функция для выпуска сертификата пользователя на открытый ключ pub.
+func newCertificate(t *testing.T, pub ssh.PublicKey) string {
+	t.Helper()
+	_, caKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ca, err := ssh.NewSignerFromKey(caKey)
+	require.NoError(t, err)
+	cert := &ssh.Certificate{Key: pub, CertType: ssh.UserCert, KeyId: "user", ValidPrincipals: []string{"user"},
+		ValidBefore: ssh.CertTimeInfinity}
+	require.NoError(t, cert.SignCert(rand.Reader, ca))
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(cert))) + " cert@ca"
+}
+
+func TestNewSSHKey(t *testing.T) {
+	plain, plainPub := newPrivateKey(t, "")
+	encrypted, encryptedPub := newPrivateKey(t, "secret")
+	cert := newCertificate(t, plainPub)
+	otherCert := newCertificate(t, encryptedPub)
+
+	tests := []struct {
+		name        string
+		privateKey  string
+		passphrase  string
+		comment     string
+		certificate string
+		wantPub     ssh.PublicKey
+		wantComment string
+		wantErr     error
+		anyErr      bool
+	}{
+		{name: "plain key", privateKey: plain, comment: " work ", wantPub: plainPub, wantComment: "work"},
+		{name: "encrypted key", privateKey: encrypted, passphrase: "secret", wantPub: encryptedPub},
+		{name: "certificate comment", privateKey: plain, certificate: cert, wantPub: plainPub, wantComment: "cert@ca"},
+		{name: "missing passphrase", privateKey: encrypted, wantErr: ErrPassphraseRequired},
+		{name: "wrong passphrase", privateKey: encrypted, passphrase: "wrong", anyErr: true},
+		{name: "not a key", privateKey: "key", anyErr: true},
+		{name: "foreign certificate", privateKey: plain, certificate: otherCert, anyErr: true},
+		{name: "public key instead of certificate", privateKey: plain,
+			certificate: string(ssh.MarshalAuthorizedKey(plainPub)), anyErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := NewSSHKey(tt.privateKey, tt.passphrase, tt.comment, tt.certificate)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if tt.anyErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, ssh.FingerprintSHA256(tt.wantPub), key.Fingerprint)
+			assert.Equal(t, string(ssh.MarshalAuthorizedKey(tt.wantPub)), key.PublicKey+"\n")
+			assert.Equal(t, tt.wantComment, key.Comment)
+
+			signer, err := key.Signer()
+			require.NoError(t, err)
+			sig, err := signer.Sign(rand.Reader, []byte("message"))
+			require.NoError(t, err)
+			assert.NoError(t, tt.wantPub.Verify([]byte("message"), sig))
+
+			c, err := key.Cert()
+			require.NoError(t, err)
+			assert.Equal(t, tt.certificate != "", c != nil)
+		})
+	}
+}
+
+func TestDecodeSSHKey(t *testing.T) {
+	privateKey, _ := newPrivateKey(t, "")
+	key, err := NewSSHKey(privateKey, "", "", "")
+	require.NoError(t, err)
+	b, err := json.Marshal(key)
+	require.NoError(t, err)
+
+	got, err := DecodeSSHKey(repoData.Data{Name: "key", Type: repoData.SSHKEY, Data: b})
+	require.NoError(t, err)
+	assert.Equal(t, key, got)
+
+	_, err = DecodeSSHKey(repoData.Data{Name: "key", Type: repoData.PASSWORD, Data: b})
+	assert.Error(t, err)
+	_, err = DecodeSSHKey(repoData.Data{Name: "key", Type: repoData.SSHKEY, Data: []byte("{")})
+	assert.Error(t, err)
+}
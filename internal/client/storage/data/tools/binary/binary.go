@@ -39,6 +39,11 @@ func GetFileType(filename string) (string, error) {
 
 // RestoreFile - функция для восстановления файла из слайса байт.
 func RestoreFile(rData data.Binary, dataName, outputDir string) error {
+	return os.WriteFile(FileName(rData, dataName, outputDir), rData.Binary, 0644)
+}
+
+// FileName - функция для формирования пути восстанавливаемого файла с расширением, соответствующим типу файла.
+func FileName(rData data.Binary, dataName, outputDir string) string {
 	ext := ""
 	// Устанавливаю расширение итогового файла.
 	switch rData.Type {
@@ -52,6 +57,5 @@ func RestoreFile(rData data.Binary, dataName, outputDir string) error {
 		ext = ".bin" // Неизвестный формат
 	}
 
-	filename := filepath.Join(outputDir, dataName+ext)
-	return os.WriteFile(filename, rData.Binary, 0644)
+	return filepath.Join(outputDir, dataName+ext)
 }
@@ -2,13 +2,18 @@ package inmemory
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/abezemskiy/gophkeeper/internal/client/encr"
 	"github.com/abezemskiy/gophkeeper/internal/client/identity"
 	"github.com/abezemskiy/gophkeeper/internal/client/storage"
+	clientData "github.com/abezemskiy/gophkeeper/internal/client/storage/data"
 	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
 )
 
@@ -26,9 +31,22 @@ func GetUpdatingPeriod() time.Duration {
 }
 
 // DecryptedData - потокобезопасное хранилище расшифрованных данных пользователя в оперативной памяти.
+// Вместе с данными хранится поисковый индекс, который перестраивается при каждом обновлении.
 type DecryptedData struct {
-	mu   sync.RWMutex
-	data [][]data.Data
+	mu    sync.RWMutex
+	data  [][]data.Data
+	index []entry // записи индекса, отсортированные по имени данных
+}
+
+// entry - запись поискового индекса.
+type entry struct {
+	versions []data.Data
+	text     string // имя, метаинформация и текстовые поля всех версий данных в нижнем регистре
+	dataType int
+	status   int
+	tags     []string
+	folder   string
+	favorite bool
 }
 
 // Update - метод для актуализации данных пользователя.
@@ -53,7 +71,7 @@ func (d *DecryptedData) Update(ctx context.Context, stor storage.IEncryptedClien
 		// Итерируюсь по всем версиям одних данных
 		for j, d := range dataEncrVirsions {
 			// Расшифровываю данные
-			decr, err := encr.DecryptData(authData.Password, &d)
+			decr, err := encr.DecryptData(ctx, authData.Password, &d)
 			if err != nil {
 				return fmt.Errorf("failed to decrypt data, %w", err)
 			}
@@ -64,14 +82,30 @@ func (d *DecryptedData) Update(ctx context.Context, stor storage.IEncryptedClien
 		decrData[i] = dataDecrVersions
 	}
 
+	// Статус данных хранится в постоянном хранилище, статус внутри зашифрованных данных может быть устаревшим
+	statuses, err := getStatuses(ctx, stor, id)
+	if err != nil {
+		return err
+	}
+	index := buildIndex(decrData, statuses)
+
 	// Сохраняю расшифрованные данные в хранилище
 	d.mu.Lock()
 	defer d.mu.Unlock()
 	d.data = decrData
+	d.index = index
 
 	return nil
 }
 
+// Clear - метод для удаления всех расшифрованных данных пользователя, например, после удаления учетной записи.
+func (d *DecryptedData) Clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.data = nil
+	d.index = nil
+}
+
 // GetAll - метод для получения расшифрованных данных пользователя.
 func (d *DecryptedData) GetAll() [][]data.Data {
 	d.mu.RLock()
@@ -87,6 +121,177 @@ func (d *DecryptedData) GetAll() [][]data.Data {
 	return copiedData
 }
 
+// Search - метод для поиска по расшифрованным данным пользователя. Данные удовлетворяют запросу, если каждое слово
+// запроса встречается в имени, метаинформации, логине, тексте или имени владельца карты одной из версий данных.
+func (d *DecryptedData) Search(q storage.Query) storage.SearchResult {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	words := strings.Fields(strings.ToLower(q.Text))
+	var res storage.SearchResult
+	for _, e := range d.index {
+		if !e.match(words, q) {
+			continue
+		}
+		res.Total++
+		if res.Total <= q.Offset || (q.Limit > 0 && len(res.Items) >= q.Limit) {
+			continue
+		}
+		res.Items = append(res.Items, slices.Clone(e.versions))
+		res.Statuses = append(res.Statuses, e.status)
+	}
+	return res
+}
+
+// Folders - метод для получения списка всех папок данных пользователя, включая родительские папки.
+func (d *DecryptedData) Folders() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var folders []string
+	for _, e := range d.index {
+		parts := strings.Split(e.folder, clientData.FolderSeparator)
+		for i := range parts {
+			folder := strings.Join(parts[:i+1], clientData.FolderSeparator)
+			if folder == "" || seen[folder] {
+				continue
+			}
+			seen[folder] = true
+			folders = append(folders, folder)
+		}
+	}
+	sort.Strings(folders)
+	return folders
+}
+
+// Tags - метод для получения списка всех тегов данных пользователя.
+func (d *DecryptedData) Tags() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var tags []string
+	for _, e := range d.index {
+		for _, tag := range e.tags {
+			if !seen[strings.ToLower(tag)] {
+				seen[strings.ToLower(tag)] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	clientData.SortTags(tags)
+	return tags
+}
+
+// match - метод для проверки соответствия записи индекса запросу.
+func (e *entry) match(words []string, q storage.Query) bool {
+	if len(q.Types) > 0 && !slices.Contains(q.Types, e.dataType) {
+		return false
+	}
+	if len(q.Statuses) > 0 && !slices.Contains(q.Statuses, e.status) {
+		return false
+	}
+	if (q.Favorite && !e.favorite) || !clientData.InFolder(e.folder, q.Folder) || !clientData.HasTags(e.tags, q.Tags) {
+		return false
+	}
+	for _, w := range words {
+		if !strings.Contains(e.text, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// getStatuses - функция для получения статусов данных пользователя, отличных от SAVED, по имени данных.
+func getStatuses(ctx context.Context, stor storage.IEncryptedClientStorage, id string) (map[string]int, error) {
+	statuses := make(map[string]int)
+	for _, status := range []int{data.NEW, data.CHANGED, data.CONFLICT} {
+		encrData, err := stor.GetEncryptedDataByStatus(ctx, id, status)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status of user data from storage, %w", err)
+		}
+		for _, versions := range encrData {
+			if len(versions) > 0 {
+				statuses[versions[0].Name] = status
+			}
+		}
+	}
+	return statuses, nil
+}
+
+// buildIndex - функция для построения поискового индекса по расшифрованным данным пользователя.
+func buildIndex(decrData [][]data.Data, statuses map[string]int) []entry {
+	index := make([]entry, 0, len(decrData))
+	for _, versions := range decrData {
+		if len(versions) == 0 {
+			continue
+		}
+		status, ok := statuses[versions[0].Name]
+		if !ok {
+			status = data.SAVED
+		}
+
+		var text strings.Builder
+		for _, v := range versions {
+			fields := append([]string{v.Name, v.Metainfo, v.Folder}, v.Tags...)
+			for _, field := range append(fields, searchFields(v)...) {
+				text.WriteString(strings.ToLower(field))
+				text.WriteByte('\n')
+			}
+		}
+		// Организация данных с несколькими версиями берется из первой версии
+		index = append(index, entry{versions: versions, text: text.String(), dataType: versions[0].Type, status: status,
+			tags: versions[0].Tags, folder: versions[0].Folder, favorite: versions[0].Favorite})
+	}
+	sort.SliceStable(index, func(i, j int) bool {
+		return strings.ToLower(index[i].versions[0].Name) < strings.ToLower(index[j].versions[0].Name)
+	})
+	return index
+}
+
+// searchFields - функция для получения текстовых полей полезной нагрузки данных, по которым выполняется поиск.
+// Пароли, секреты TOTP, значения скрытых полей, номера карт и CVV, закрытые ключи и парольные фразы
+// в индекс не попадают.
+func searchFields(d data.Data) []string {
+	switch d.Type {
+	case data.PASSWORD:
+		var p clientData.Password
+		if json.Unmarshal(d.Data, &p) == nil {
+			return []string{p.Login}
+		}
+	case data.TEXT:
+		var t clientData.Text
+		if json.Unmarshal(d.Data, &t) == nil {
+			return []string{t.Text}
+		}
+	case data.BANKCARD:
+		var b clientData.Bank
+		if json.Unmarshal(d.Data, &b) == nil {
+			return []string{b.Owner}
+		}
+	case data.LOGIN:
+		l, err := clientData.DecodeLogin(d)
+		if err != nil {
+			return nil
+		}
+		fields := append([]string{l.Login, l.Notes}, l.URLs...)
+		for _, f := range l.Fields {
+			fields = append(fields, f.Name)
+			if f.Type != clientData.FieldHidden {
+				fields = append(fields, f.Value)
+			}
+		}
+		return fields
+	case data.SSHKEY:
+		k, err := clientData.DecodeSSHKey(d)
+		if err == nil {
+			return []string{k.Fingerprint, k.Comment}
+		}
+	}
+	return nil
+}
+
 // NewDecryptedData - фабричная функция для создания временного хранилища расшифрованных данных пользователя.
 func NewDecryptedData() *DecryptedData {
 	return &DecryptedData{}
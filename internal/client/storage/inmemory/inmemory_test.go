@@ -8,8 +8,11 @@ import (
 
 	"github.com/abezemskiy/gophkeeper/internal/client/encr"
 	"github.com/abezemskiy/gophkeeper/internal/client/identity"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage"
+	clientData "github.com/abezemskiy/gophkeeper/internal/client/storage/data"
 	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
 	"github.com/abezemskiy/gophkeeper/internal/repositories/mocks"
+	"github.com/abezemskiy/gophkeeper/internal/testutil"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -79,7 +82,7 @@ func TestUpdate(t *testing.T) {
 		for i, testVersions := range testData {
 			encrForSave := make([]data.EncryptedData, len(testVersions))
 			for j, d := range testVersions {
-				e, err := encr.EncryptData(pass, &d)
+				e, err := encr.EncryptData(context.Background(), pass, &d)
 				require.NoError(t, err)
 				encrForSave[j] = *e
 			}
@@ -87,6 +90,7 @@ func TestUpdate(t *testing.T) {
 		}
 
 		stor.EXPECT().GetAllEncryptedData(gomock.Any(), id).Return(testEncrData, nil)
+		stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), id, gomock.Any()).Return(nil, nil).Times(3)
 
 		// Сохраняю данные в хранилище
 		err := inmemo.Update(context.Background(), stor, info)
@@ -97,6 +101,11 @@ func TestUpdate(t *testing.T) {
 
 		// Проверяю на равенство данные полученные из хранилища с теми, которые изначально туда загружались
 		assert.Equal(t, true, DataIsEqual(testData, getData))
+
+		// После очистки данные и поисковый индекс пусты
+		inmemo.Clear()
+		assert.Empty(t, inmemo.GetAll())
+		assert.Equal(t, 0, inmemo.Search(storage.Query{}).Total)
 	}
 	{
 		// Возвращение пустых данных
@@ -112,6 +121,7 @@ func TestUpdate(t *testing.T) {
 		info.EXPECT().Get().Return(authData, id)
 
 		stor.EXPECT().GetAllEncryptedData(gomock.Any(), id).Return([][]data.EncryptedData{}, nil)
+		stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), id, gomock.Any()).Return(nil, nil).Times(3)
 
 		err := inmemo.Update(context.Background(), stor, info)
 		require.NoError(t, err)
@@ -138,4 +148,116 @@ func TestUpdate(t *testing.T) {
 		err := inmemo.Update(context.Background(), stor, info)
 		require.Error(t, err)
 	}
+	{
+		// Ошибка получения статусов данных
+		inmemo := NewDecryptedData()
+
+		info := mocks.NewMockIUserInfoStorage(ctrl)
+		id := "status error id"
+		info.EXPECT().Get().Return(identity.AuthData{Login: "login", Password: "password"}, id)
+
+		stor.EXPECT().GetAllEncryptedData(gomock.Any(), id).Return([][]data.EncryptedData{}, nil)
+		stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), id, data.NEW).Return(nil, errors.New("some error"))
+
+		err := inmemo.Update(context.Background(), stor, info)
+		require.Error(t, err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	stor := mocks.NewMockIEncryptedClientStorage(ctrl)
+	info := mocks.NewMockIUserInfoStorage(ctrl)
+
+	pass := "password"
+	id := "id"
+	info.EXPECT().Get().Return(identity.AuthData{Login: "login", Password: pass}, id)
+
+	mail := testutil.NewData(t, "Mail", data.PASSWORD, "https://mail.example.com", clientData.Password{Login: "ivan", Password: "secret"})
+	mail.Tags, mail.Folder, mail.Favorite = []string{"Personal"}, "home/web", true
+	bank := testutil.NewData(t, "bank", data.BANKCARD, "", clientData.Bank{Number: 4111111111111111, Owner: "IVAN PETROV"})
+	bank.Tags, bank.Folder = []string{"finance", "personal"}, "home"
+	testData := [][]data.Data{
+		{mail},
+		{bank},
+		{
+			testutil.NewData(t, "diary", data.TEXT, "", clientData.Text{Text: "first version"}),
+			testutil.NewData(t, "diary", data.TEXT, "", clientData.Text{Text: "second version"}),
+		},
+		{testutil.NewData(t, "git", data.PASSWORD, "https://github.com", clientData.Password{Login: "dev", Password: "token"})},
+		{testutil.NewData(t, "vpn", data.LOGIN, "", clientData.Login{Login: "ops", Password: "vpn secret", URLs: []string{"https://vpn.corp"},
+			TOTP: "JBSWY3DPEHPK3PXP", Fields: []clientData.Field{{Name: "pin", Value: "9876", Type: clientData.FieldHidden}}})},
+	}
+	encrData := make([][]data.EncryptedData, len(testData))
+	for i, versions := range testData {
+		for _, d := range versions {
+			e, err := encr.EncryptData(context.Background(), pass, &d)
+			require.NoError(t, err)
+			encrData[i] = append(encrData[i], *e)
+		}
+	}
+	stor.EXPECT().GetAllEncryptedData(gomock.Any(), id).Return(encrData, nil)
+	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), id, data.NEW).Return(encrData[3:4], nil)
+	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), id, data.CHANGED).Return(nil, nil)
+	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), id, data.CONFLICT).Return(encrData[2:3], nil)
+
+	inmemo := NewDecryptedData()
+	require.NoError(t, inmemo.Update(context.Background(), stor, info))
+
+	tests := []struct {
+		name      string
+		query     storage.Query
+		wantNames []string
+		wantTotal int
+	}{
+		{name: "empty query", query: storage.Query{}, wantNames: []string{"bank", "diary", "git", "Mail", "vpn"}, wantTotal: 5},
+		{name: "login by url", query: storage.Query{Text: "vpn.corp"}, wantNames: []string{"vpn"}, wantTotal: 1},
+		{name: "login by field name", query: storage.Query{Text: "pin"}, wantNames: []string{"vpn"}, wantTotal: 1},
+		{name: "hidden field value is not indexed", query: storage.Query{Text: "9876"}, wantTotal: 0},
+		{name: "totp secret is not indexed", query: storage.Query{Text: "jbswy3"}, wantTotal: 0},
+		{name: "by name case insensitive", query: storage.Query{Text: "MAIL"}, wantNames: []string{"Mail"}, wantTotal: 1},
+		{name: "by url", query: storage.Query{Text: "github"}, wantNames: []string{"git"}, wantTotal: 1},
+		{name: "by login and card owner", query: storage.Query{Text: "ivan"}, wantNames: []string{"bank", "Mail"}, wantTotal: 2},
+		{name: "by text of any version", query: storage.Query{Text: "second"}, wantNames: []string{"diary"}, wantTotal: 1},
+		{name: "all words must match", query: storage.Query{Text: "ivan petrov"}, wantNames: []string{"bank"}, wantTotal: 1},
+		{name: "password is not indexed", query: storage.Query{Text: "secret"}, wantTotal: 0},
+		{name: "by type", query: storage.Query{Types: []int{data.PASSWORD}}, wantNames: []string{"git", "Mail"}, wantTotal: 2},
+		{name: "by status", query: storage.Query{Statuses: []int{data.NEW, data.CONFLICT}}, wantNames: []string{"diary", "git"}, wantTotal: 2},
+		{name: "saved status by default", query: storage.Query{Statuses: []int{data.SAVED}}, wantNames: []string{"bank", "Mail", "vpn"}, wantTotal: 3},
+		{name: "page", query: storage.Query{Offset: 1, Limit: 2}, wantNames: []string{"diary", "git"}, wantTotal: 5},
+		{name: "offset out of range", query: storage.Query{Offset: 10}, wantTotal: 5},
+		{name: "by tag", query: storage.Query{Tags: []string{"personal"}}, wantNames: []string{"bank", "Mail"}, wantTotal: 2},
+		{name: "by all tags", query: storage.Query{Tags: []string{"personal", "finance"}}, wantNames: []string{"bank"}, wantTotal: 1},
+		{name: "by folder with subfolders", query: storage.Query{Folder: "home"}, wantNames: []string{"bank", "Mail"}, wantTotal: 2},
+		{name: "by subfolder", query: storage.Query{Folder: "home/web"}, wantNames: []string{"Mail"}, wantTotal: 1},
+		{name: "favorites", query: storage.Query{Favorite: true}, wantNames: []string{"Mail"}, wantTotal: 1},
+		{name: "text by tag", query: storage.Query{Text: "finance"}, wantNames: []string{"bank"}, wantTotal: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := inmemo.Search(tt.query)
+			var names []string
+			for _, versions := range res.Items {
+				names = append(names, versions[0].Name)
+			}
+			assert.Equal(t, tt.wantNames, names)
+			assert.Equal(t, tt.wantTotal, res.Total)
+			assert.Len(t, res.Statuses, len(res.Items))
+		})
+	}
+
+	res := inmemo.Search(storage.Query{Text: "diary"})
+	require.Len(t, res.Items, 1)
+	assert.Len(t, res.Items[0], 2)
+	assert.Equal(t, []int{data.CONFLICT}, res.Statuses)
+
+	assert.Equal(t, []string{"home", "home/web"}, inmemo.Folders())
+	assert.Equal(t, []string{"finance", "personal"}, inmemo.Tags())
+}
+
+func TestSearchFieldsSSHKey(t *testing.T) {
+	key := clientData.SSHKey{PrivateKey: "private key", Passphrase: "secret", Fingerprint: "SHA256:abc", Comment: "user@host"}
+	assert.Equal(t, []string{"SHA256:abc", "user@host"}, searchFields(testutil.NewData(t, "github", data.SSHKEY, "", key)))
+	assert.Nil(t, searchFields(data.Data{Type: data.SSHKEY, Data: []byte("{")}))
 }
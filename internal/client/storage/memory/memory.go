@@ -0,0 +1,289 @@
+// Пакет memory реализует постоянное хранилище клиента в оперативной памяти.
+// Хранилище не требует СУБД и используется в тестах и демонстрационных сценариях.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/identity"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+)
+
+// record - все версии одних данных пользователя.
+type record struct {
+	versions [][]byte // зашифрованные версии данных
+	status   int      // статус данных
+	seq      uint64   // порядковый номер добавления, нужен для стабильного порядка выдачи
+}
+
+// Store - потокобезопасное хранилище клиента в оперативной памяти.
+// Реализует интерфейсы storage.IEncryptedClientStorage и identity.ClientIdentifier.
+type Store struct {
+	mu      sync.RWMutex
+	auth    map[string]identity.UserInfo  // данные пользователей по логину
+	data    map[string]map[string]*record // данные пользователей по id пользователя и имени данных
+	nextSeq uint64
+}
+
+// NewStore - фабричная функция хранилища в оперативной памяти.
+func NewStore() *Store {
+	return &Store{
+		auth: make(map[string]identity.UserInfo),
+		data: make(map[string]map[string]*record),
+	}
+}
+
+// Disable - очищает хранилище. Аналог pg.Store.Disable.
+func (s *Store) Disable(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.auth = make(map[string]identity.UserInfo)
+	s.data = make(map[string]map[string]*record)
+	return nil
+}
+
+// Register - сохраняет данные нового пользователя. Если такой пользователь уже зарегистрирован, вернется false.
+func (s *Store) Register(ctx context.Context, login, hash, id, token string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.auth[login]; ok {
+		return false, nil
+	}
+	s.auth[login] = identity.UserInfo{ID: id, Token: token, Hash: hash}
+	return true, nil
+}
+
+// Authorize - возвращает данные пользователя по логину. Если пользователь не найден, возвращается false.
+func (s *Store) Authorize(ctx context.Context, login string) (identity.UserInfo, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return identity.UserInfo{}, false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	info, ok := s.auth[login]
+	return info, ok, nil
+}
+
+// SetToken - устанавливает новый токен пользователя. Если пользователь не найден, возвращается false.
+func (s *Store) SetToken(ctx context.Context, login, token string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, ok := s.auth[login]
+	if !ok {
+		return false, nil
+	}
+	info.Token = token
+	s.auth[login] = info
+	return true, nil
+}
+
+// DeleteUser - удаляет данные пользователя и все его зашифрованные данные. Если пользователь не найден,
+// возвращается false.
+func (s *Store) DeleteUser(ctx context.Context, login string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, ok := s.auth[login]
+	if !ok {
+		return false, nil
+	}
+	delete(s.data, info.ID)
+	delete(s.auth, login)
+	return true, nil
+}
+
+// AddEncryptedData - добавляет уникальные зашифрованные данные пользователя.
+// В случае если данные не уникальны, возвращается false.
+func (s *Store) AddEncryptedData(ctx context.Context, idUser string, userData data.EncryptedData, status int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userRecords, ok := s.data[idUser]
+	if !ok {
+		userRecords = make(map[string]*record)
+		s.data[idUser] = userRecords
+	}
+	if _, ok := userRecords[userData.Name]; ok {
+		return false, nil
+	}
+
+	s.nextSeq++
+	userRecords[userData.Name] = &record{
+		versions: [][]byte{clone(userData.EncryptedData)},
+		status:   status,
+		seq:      s.nextSeq,
+	}
+	return true, nil
+}
+
+// ReplaceEncryptedData - заменяет все версии существующих данных одной новой версией с указанным статусом.
+// Если данных не существует, возвращается false.
+func (s *Store) ReplaceEncryptedData(ctx context.Context, idUser string, userData data.EncryptedData, status int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.get(idUser, userData.Name)
+	if !ok {
+		return false, nil
+	}
+	r.versions = [][]byte{clone(userData.EncryptedData)}
+	r.status = status
+	return true, nil
+}
+
+// ReplaceDataWithMultiVersionData - заменяет существующие данные на данные с несколькими версиями.
+// Если данных не существует, возвращается false.
+func (s *Store) ReplaceDataWithMultiVersionData(ctx context.Context, idUser string, userData []data.EncryptedData,
+	status int) (bool, error) {
+	// проверяю, что существует как минимум одна версия данных
+	if len(userData) == 0 {
+		return false, fmt.Errorf("no one version of data is exists")
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.get(idUser, userData[0].Name)
+	if !ok {
+		return false, nil
+	}
+	versions := make([][]byte, len(userData))
+	for i, d := range userData {
+		versions[i] = clone(d.EncryptedData)
+	}
+	r.versions = versions
+	r.status = status
+	return true, nil
+}
+
+// GetAllEncryptedData - возвращает все версии всех данных пользователя в порядке их добавления.
+func (s *Store) GetAllEncryptedData(ctx context.Context, idUser string) ([][]data.EncryptedData, error) {
+	return s.filter(ctx, idUser, func(*record) bool { return true })
+}
+
+// GetEncryptedDataByStatus - возвращает все данные пользователя с указанным статусом.
+func (s *Store) GetEncryptedDataByStatus(ctx context.Context, idUser string, status int) ([][]data.EncryptedData, error) {
+	return s.filter(ctx, idUser, func(r *record) bool { return r.status == status })
+}
+
+// DeleteEncryptedData - удаляет данные пользователя по имени.
+// Если данных не существует, возвращается false.
+func (s *Store) DeleteEncryptedData(ctx context.Context, idUser, dataName string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.get(idUser, dataName); !ok {
+		return false, nil
+	}
+	delete(s.data[idUser], dataName)
+	return true, nil
+}
+
+// ChangeStatusOfEncryptedData - изменяет статус существующих данных.
+// В случае, если пользователь или данные не найдены, возвращается false.
+func (s *Store) ChangeStatusOfEncryptedData(ctx context.Context, userID, dataName string, newStatus int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.get(userID, dataName)
+	if !ok {
+		return false, nil
+	}
+	r.status = newStatus
+	return true, nil
+}
+
+// GetStatus - возвращает текущий статус данных. В случае, если данных не существует, возвращается false.
+func (s *Store) GetStatus(ctx context.Context, userID, dataName string) (int, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.get(userID, dataName)
+	if !ok {
+		return 0, false, nil
+	}
+	return r.status, true, nil
+}
+
+// filter - возвращает копии данных пользователя, удовлетворяющих условию, в порядке их добавления.
+func (s *Store) filter(ctx context.Context, idUser string, match func(*record) bool) ([][]data.EncryptedData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userRecords := s.data[idUser]
+	names := make([]string, 0, len(userRecords))
+	for name, r := range userRecords {
+		if match(r) {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return userRecords[names[i]].seq < userRecords[names[j]].seq
+	})
+
+	result := make([][]data.EncryptedData, 0, len(names))
+	for _, name := range names {
+		r := userRecords[name]
+		versions := make([]data.EncryptedData, 0, len(r.versions))
+		for _, v := range r.versions {
+			versions = append(versions, data.EncryptedData{EncryptedData: clone(v), Name: name})
+		}
+		result = append(result, versions)
+	}
+	return result, nil
+}
+
+// get - возвращает запись пользователя по имени данных. Вызывающий должен удерживать мьютекс.
+func (s *Store) get(idUser, dataName string) (*record, bool) {
+	r, ok := s.data[idUser][dataName]
+	return r, ok
+}
+
+// clone - копирует слайс байт, чтобы хранилище не разделяло память с вызывающим кодом.
+func clone(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
@@ -0,0 +1,247 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterAuthorizeSetToken(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+
+	// Успешная регистрация
+	ok, err := stor.Register(ctx, "login", "hash", "id", "token")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+
+	// Повторная регистрация
+	ok, err = stor.Register(ctx, "login", "new hash", "new id", "token")
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+
+	info, ok, err := stor.Authorize(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "hash", info.Hash)
+	assert.Equal(t, "id", info.ID)
+	assert.Equal(t, "token", info.Token)
+
+	// Обновление токена
+	ok, err = stor.SetToken(ctx, "login", "new token")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+	info, _, err = stor.Authorize(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, "new token", info.Token)
+
+	// Пользователь не зарегистрирован
+	_, ok, err = stor.Authorize(ctx, "not register")
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+	ok, err = stor.SetToken(ctx, "not register", "token")
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+
+	// Контекст уже отменен
+	ctxExc, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = stor.Register(ctxExc, "exceeded", "hash", "id", "token")
+	require.Error(t, err)
+	_, _, err = stor.Authorize(ctxExc, "login")
+	require.Error(t, err)
+	_, err = stor.SetToken(ctxExc, "login", "token")
+	require.Error(t, err)
+}
+
+func TestAddAndReplaceEncryptedData(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+	userID := "user id"
+	userData := data.EncryptedData{EncryptedData: []byte("v1"), Name: "name"}
+
+	ok, err := stor.AddEncryptedData(ctx, userID, userData, data.NEW)
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+
+	ok, err = stor.AddEncryptedData(ctx, userID, userData, data.NEW)
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+
+	// Замена устанавливает переданный статус
+	ok, err = stor.ReplaceEncryptedData(ctx, userID, data.EncryptedData{EncryptedData: []byte("v2"), Name: "name"}, data.SAVED)
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+
+	status, ok, err := stor.GetStatus(ctx, userID, "name")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, data.SAVED, status)
+
+	get, err := stor.GetAllEncryptedData(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(get))
+	assert.Equal(t, "v2", string(get[0][0].EncryptedData))
+
+	// Замена несуществующих данных
+	ok, err = stor.ReplaceEncryptedData(ctx, userID, data.EncryptedData{Name: "not exist"}, data.SAVED)
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+}
+
+func TestReplaceDataWithMultiVersionData(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+	userID := "user id"
+
+	// Пустой набор версий
+	_, err := stor.ReplaceDataWithMultiVersionData(ctx, userID, nil, data.CONFLICT)
+	require.Error(t, err)
+
+	versions := []data.EncryptedData{
+		{EncryptedData: []byte("v1"), Name: "name"},
+		{EncryptedData: []byte("v2"), Name: "name"},
+	}
+
+	// Данных ещё нет
+	ok, err := stor.ReplaceDataWithMultiVersionData(ctx, userID, versions, data.CONFLICT)
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+
+	_, err = stor.AddEncryptedData(ctx, userID, versions[0], data.SAVED)
+	require.NoError(t, err)
+
+	ok, err = stor.ReplaceDataWithMultiVersionData(ctx, userID, versions, data.CONFLICT)
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+
+	get, err := stor.GetEncryptedDataByStatus(ctx, userID, data.CONFLICT)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(get))
+	require.Equal(t, 2, len(get[0]))
+	assert.Equal(t, "v1", string(get[0][0].EncryptedData))
+	assert.Equal(t, "v2", string(get[0][1].EncryptedData))
+}
+
+func TestGetEncryptedDataByStatus(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+	userID := "user id"
+
+	statuses := []int{data.NEW, data.SAVED, data.CHANGED, data.NEW, data.SAVED}
+	for i, st := range statuses {
+		_, err := stor.AddEncryptedData(ctx, userID, data.EncryptedData{
+			EncryptedData: []byte(fmt.Sprintf("data %d", i)),
+			Name:          fmt.Sprintf("name %d", i),
+		}, st)
+		require.NoError(t, err)
+	}
+
+	get, err := stor.GetEncryptedDataByStatus(ctx, userID, data.NEW)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(get))
+	assert.Equal(t, "name 0", get[0][0].Name)
+	assert.Equal(t, "name 3", get[1][0].Name)
+
+	get, err = stor.GetEncryptedDataByStatus(ctx, userID, data.CONFLICT)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(get))
+
+	all, err := stor.GetAllEncryptedData(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, len(statuses), len(all))
+
+	// Данные другого пользователя не возвращаются
+	get, err = stor.GetEncryptedDataByStatus(ctx, "another user", data.NEW)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(get))
+}
+
+func TestChangeStatusAndDelete(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+	userID := "user id"
+
+	ok, err := stor.ChangeStatusOfEncryptedData(ctx, userID, "name", data.SAVED)
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+
+	_, ok, err = stor.GetStatus(ctx, userID, "name")
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+
+	_, err = stor.AddEncryptedData(ctx, userID, data.EncryptedData{EncryptedData: []byte("data"), Name: "name"}, data.NEW)
+	require.NoError(t, err)
+
+	ok, err = stor.ChangeStatusOfEncryptedData(ctx, userID, "name", data.SAVED)
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+
+	status, _, err := stor.GetStatus(ctx, userID, "name")
+	require.NoError(t, err)
+	assert.Equal(t, data.SAVED, status)
+
+	ok, err = stor.DeleteEncryptedData(ctx, userID, "name")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+
+	ok, err = stor.DeleteEncryptedData(ctx, userID, "name")
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+
+	// Контекст уже отменен
+	ctxExc, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = stor.ChangeStatusOfEncryptedData(ctxExc, userID, "name", data.SAVED)
+	require.Error(t, err)
+	_, _, err = stor.GetStatus(ctxExc, userID, "name")
+	require.Error(t, err)
+	_, err = stor.DeleteEncryptedData(ctxExc, userID, "name")
+	require.Error(t, err)
+	_, err = stor.GetEncryptedDataByStatus(ctxExc, userID, data.NEW)
+	require.Error(t, err)
+}
+
+func TestDeleteUser(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+
+	ok, err := stor.DeleteUser(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+
+	_, err = stor.Register(ctx, "login", "hash", "first", "token")
+	require.NoError(t, err)
+	_, err = stor.Register(ctx, "other", "hash", "second", "token")
+	require.NoError(t, err)
+	_, err = stor.AddEncryptedData(ctx, "first", data.EncryptedData{EncryptedData: []byte("data"), Name: "name"}, data.SAVED)
+	require.NoError(t, err)
+	_, err = stor.AddEncryptedData(ctx, "second", data.EncryptedData{EncryptedData: []byte("data"), Name: "name"}, data.SAVED)
+	require.NoError(t, err)
+
+	ok, err = stor.DeleteUser(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+
+	// Данные пользователя удалены, данные другого пользователя сохранены
+	_, ok, err = stor.Authorize(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+	all, err := stor.GetAllEncryptedData(ctx, "first")
+	require.NoError(t, err)
+	assert.Empty(t, all)
+	all, err = stor.GetAllEncryptedData(ctx, "second")
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+
+	// Контекст уже отменен
+	ctxExc, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = stor.DeleteUser(ctxExc, "other")
+	require.Error(t, err)
+}
@@ -185,7 +185,7 @@ func (s Store) AddEncryptedData(ctx context.Context, idUser string, userData dat
 // ReplaceEncryptedData - метод для замены старых данных значениями новых.
 // В случае попытки заменить данные, когда данные с текущим id полязователя и именем ещё не загружены в хранилище
 // возвращается false.
-func (s Store) ReplaceEncryptedData(ctx context.Context, idUser string, userData data.EncryptedData, _ int) (bool, error) {
+func (s Store) ReplaceEncryptedData(ctx context.Context, idUser string, userData data.EncryptedData, status int) (bool, error) {
 	query := `
 	UPDATE user_data
 	SET encrypted_data = $3, status = $4
@@ -196,7 +196,7 @@ func (s Store) ReplaceEncryptedData(ctx context.Context, idUser string, userData
 		return false, fmt.Errorf("prepare context error, %w", err)
 	}
 	defer stmt.Close()
-	result, err := stmt.ExecContext(ctx, idUser, userData.Name, [][]byte{userData.EncryptedData}, data.CHANGED)
+	result, err := stmt.ExecContext(ctx, idUser, userData.Name, [][]byte{userData.EncryptedData}, status)
 
 	if err != nil {
 		return false, fmt.Errorf("query execution error, %w", err)
@@ -363,6 +363,46 @@ func (s Store) SetToken(ctx context.Context, login, token string) (bool, error)
 	return true, nil
 }
 
+// DeleteUser - метод для удаления пользователя и всех его данных из хранилища в одной транзакции.
+// В случае, если не найден пользователь по данному логину, возвращается false.
+func (s Store) DeleteUser(ctx context.Context, login string) (bool, error) {
+	// запускаем транзакцию
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction error, %w", err)
+	}
+	// в случае неупешного коммита все изменения транзакции будут отменены
+	defer tx.Rollback()
+
+	var id string
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM auth
+		WHERE login = $1
+		RETURNING id
+	`, login).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		// пользователь с данным логином не зарегистрирован
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("delete user error, %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM user_data
+		WHERE user_id = $1
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("delete user data error, %w", err)
+	}
+
+	// коммитим транзакцию
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction error, %w", err)
+	}
+	return true, nil
+}
+
 // ChangeStatusOfEncryptedData - метод для изменения статуса существующих данных у пользователя по его ID.
 // В случае, если пользователь или данные не найдены, возвращается false.
 func (s Store) ChangeStatusOfEncryptedData(ctx context.Context, userID, dataName string, newStatus int) (ok bool, err error) {
@@ -784,6 +784,59 @@ func TestSetToken(t *testing.T) {
 	}
 }
 
+func TestDeleteUser(t *testing.T) {
+	// беру адрес тестовой БД
+	databaseDsn := getDSN()
+
+	ctx := context.Background()
+	// создаю экземпляр хранилища
+	stor, err := NewStore(ctx, databaseDsn)
+	require.NoError(t, err)
+
+	// очищаю данные в БД от предыдущих запусков
+	cleanBD(t, databaseDsn, stor)
+	defer cleanBD(t, databaseDsn, stor)
+
+	{
+		// Удаление незарегистрированного пользователя
+		ok, err := stor.DeleteUser(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, false, ok)
+	}
+	{
+		// Удаление пользователя вместе с данными, данные другого пользователя сохраняются
+		for _, u := range []struct{ login, id string }{{"login", "first"}, {"other", "second"}} {
+			ok, err := stor.Register(ctx, u.login, "hash", u.id, "token")
+			require.NoError(t, err)
+			require.True(t, ok)
+			ok, err = stor.AddEncryptedData(ctx, u.id, data.EncryptedData{EncryptedData: []byte("data"), Name: "name"}, data.SAVED)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+
+		ok, err := stor.DeleteUser(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, true, ok)
+
+		_, ok, err = stor.Authorize(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, false, ok)
+		all, err := stor.GetAllEncryptedData(ctx, "first")
+		require.NoError(t, err)
+		assert.Empty(t, all)
+		all, err = stor.GetAllEncryptedData(ctx, "second")
+		require.NoError(t, err)
+		assert.Len(t, all, 1)
+	}
+	{
+		// Тест с попыткой удалить пользователя когда контекст уже завершен
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := stor.DeleteUser(ctx, "other")
+		require.Error(t, err)
+	}
+}
+
 func TestChangeStatusOfEncryptedData(t *testing.T) {
 	// беру адрес тестовой БД
 	databaseDsn := getDSN()
@@ -37,6 +37,7 @@ type (
 	// DataWriter - интерфейс для добавления данных во временное хранилище.
 	DataWriter interface {
 		Update(ctx context.Context, stor IEncryptedClientStorage, info identity.IUserInfoStorage) error // обновляю данные пользователя из постоянного хранилища.
+		Clear()                                                                                         // удаляю все расшифрованные данные пользователя.
 	}
 
 	// DataReader - интерфейс для выгрузки данных у конкретного пользователя по его id.
@@ -44,9 +45,36 @@ type (
 		GetAll() [][]data.Data // Возвращает слайс расшифрованных данных.
 	}
 
+	// DataSearcher - интерфейс для поиска по расшифрованным данным пользователя.
+	DataSearcher interface {
+		Search(q Query) SearchResult // Возвращает страницу данных, удовлетворяющих запросу.
+		Folders() []string           // Возвращает отсортированный список всех папок, включая родительские.
+		Tags() []string              // Возвращает отсортированный список всех тегов.
+	}
+
 	// IStorage - интерфейс хранения данных пользователей в незашифрованном виде.
 	IStorage interface {
 		DataWriter
 		DataReader
+		DataSearcher
 	}
 )
+
+// Query - параметры поиска по расшифрованным данным пользователя. Пустые поля не ограничивают результат.
+type Query struct {
+	Text     string   // слова для поиска по имени, метаинформации, логину, URL, тексту, тегам и папке без учета регистра
+	Types    []int    // допустимые типы данных
+	Statuses []int    // допустимые статусы данных
+	Tags     []string // теги, которые должны быть у данных
+	Folder   string   // папка данных, включая вложенные папки
+	Favorite bool     // только избранные данные
+	Offset   int      // количество пропускаемых результатов
+	Limit    int      // наибольшее количество результатов, 0 - без ограничения
+}
+
+// SearchResult - страница результатов поиска.
+type SearchResult struct {
+	Items    [][]data.Data // версии найденных данных, отсортированные по имени
+	Statuses []int         // статусы найденных данных в том же порядке
+	Total    int           // количество данных, удовлетворяющих запросу, без учета страницы
+}
@@ -6,10 +6,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/abezemskiy/gophkeeper/internal/client/handlers"
 	"github.com/abezemskiy/gophkeeper/internal/client/identity"
 	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/sharing"
 	"github.com/abezemskiy/gophkeeper/internal/client/storage"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/inmemory"
+	"github.com/abezemskiy/gophkeeper/internal/common/tracing"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
 	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
 
 	"github.com/go-resty/resty/v2"
@@ -20,6 +26,9 @@ import (
 // URL представляет собой адрес до хэндлера сервера для добавления новых данных.
 func SynchronizeNewLocalData(ctx context.Context, stor storage.IEncryptedClientStorage, info identity.IUserInfoStorage,
 	client *resty.Client, url string) error {
+	ctx, span := tracing.Start(ctx, "synchronization.SynchronizeNewLocalData")
+	defer span.End()
+
 	// Извлекаю данные пользователя
 	authData, id := info.Get()
 
@@ -41,6 +50,7 @@ func SynchronizeNewLocalData(ctx context.Context, stor storage.IEncryptedClientS
 
 		// Отправляю данные на сервер
 		resp, err := client.R().
+			SetContext(ctx).
 			SetHeader("Content-Type", "application/json").
 			SetBody(d[0]).
 			Post(url)
@@ -78,6 +88,9 @@ func SynchronizeNewLocalData(ctx context.Context, stor storage.IEncryptedClientS
 // URL представляет собой адрес до хэндлера сервера для созранения дополнительной версии уже существующих данных.
 func SynchronizeChangedLocalData(ctx context.Context, stor storage.IEncryptedClientStorage, info identity.IUserInfoStorage,
 	client *resty.Client, url string) error {
+	ctx, span := tracing.Start(ctx, "synchronization.SynchronizeChangedLocalData")
+	defer span.End()
+
 	// Извлекаю данные пользователя
 	authData, id := info.Get()
 
@@ -99,6 +112,7 @@ func SynchronizeChangedLocalData(ctx context.Context, stor storage.IEncryptedCli
 
 		// Отправляю данные на сервер
 		resp, err := client.R().
+			SetContext(ctx).
 			SetHeader("Content-Type", "application/json").
 			SetBody(d[0]).
 			Post(url)
@@ -108,8 +122,16 @@ func SynchronizeChangedLocalData(ctx context.Context, stor storage.IEncryptedCli
 		}
 
 		// Обновляю статус данных в хранилище --------------------
-		if resp.StatusCode() == http.StatusOK {
-			ok, err := stor.ChangeStatusOfEncryptedData(ctx, id, d[0].Name, data.SAVED)
+		// Статус 404 означает, что данные были удалены на сервере с другого устройства. Локальные изменения пользователя
+		// не теряю: данные получают статус NEW и будут повторно добавлены на сервер при следующей синхронизации.
+		if resp.StatusCode() == http.StatusOK || resp.StatusCode() == http.StatusNotFound {
+			newStatus := data.SAVED
+			if resp.StatusCode() == http.StatusNotFound {
+				logger.ClientLog.Info("changed data was deleted on server", zap.String("login", authData.Login),
+					zap.String("data name", d[0].Name))
+				newStatus = data.NEW
+			}
+			ok, err := stor.ChangeStatusOfEncryptedData(ctx, id, d[0].Name, newStatus)
 			if err != nil {
 				return fmt.Errorf("failed to change status from data %s of user %s, %w", authData.Login, d[0].Name, err)
 			}
@@ -129,11 +151,15 @@ func SynchronizeChangedLocalData(ctx context.Context, stor storage.IEncryptedCli
 // URL представляет собой адрес до хэндлера сервера для сохранения дополнительной версии уже существующих данных.
 func SynchronizeDataFromServer(ctx context.Context, stor storage.IEncryptedClientStorage, info identity.IUserInfoStorage,
 	client *resty.Client, url string) error {
+	ctx, span := tracing.Start(ctx, "synchronization.SynchronizeDataFromServer")
+	defer span.End()
+
 	// Извлекаю данные текущего пользователя
 	authData, id := info.Get()
 
 	// Отправляю запрос на сервер для получения актуальных данных пользователя
 	resp, err := client.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		Get(url)
 
@@ -185,6 +211,40 @@ func SynchronizeDataFromServer(ctx context.Context, stor storage.IEncryptedClien
 			}
 		}
 	}
+
+	// Удаляю из локального хранилища данные, которые были удалены на сервере
+	return removeDataDeletedOnServer(ctx, stor, id, dataFromServer)
+}
+
+// removeDataDeletedOnServer - функция для удаления из локального хранилища данных, которых больше нет на сервере.
+// Удаляются только данные со статусами SAVED и CONFLICT. Данные со статусами NEW и CHANGED содержат локальные
+// изменения пользователя, которые ещё не отправлены на сервер, поэтому не удаляются.
+func removeDataDeletedOnServer(ctx context.Context, stor storage.IEncryptedClientStorage, id string,
+	dataFromServer [][]data.EncryptedData) error {
+	// Имена данных, которые хранятся на сервере
+	onServer := make(map[string]struct{}, len(dataFromServer))
+	for _, d := range dataFromServer {
+		onServer[d[0].Name] = struct{}{}
+	}
+
+	for _, status := range []int{data.SAVED, data.CONFLICT} {
+		localData, err := stor.GetEncryptedDataByStatus(ctx, id, status)
+		if err != nil {
+			return fmt.Errorf("failed to get encrypted data from storage with status %d, %w", status, err)
+		}
+		for _, d := range localData {
+			if len(d) == 0 {
+				continue
+			}
+			if _, ok := onServer[d[0].Name]; ok {
+				continue
+			}
+			logger.ClientLog.Info("removing data deleted on server", zap.String("data name", d[0].Name))
+			if _, err := stor.DeleteEncryptedData(ctx, id, d[0].Name); err != nil {
+				return fmt.Errorf("failed to delete data %s from storage, %w", d[0].Name, err)
+			}
+		}
+	}
 	return nil
 }
 
@@ -193,24 +253,108 @@ func SynchronizeDataFromServer(ctx context.Context, stor storage.IEncryptedClien
 // addAdditionVersionDataURL представляет собой адрес до хэндлера сервера для сохранения дополнительной версии уже существующих данных.
 func SynchronizeData(ctx context.Context, stor storage.IEncryptedClientStorage, info identity.IUserInfoStorage,
 	client *resty.Client, addNewDataURL, addAdditionVersionDataURL, getServerDataURL string) error {
+	ctx, span := tracing.Start(ctx, "synchronization.SynchronizeData")
+	defer span.End()
 
 	// Отправляю на сервер локальные изменения пользователя: новые данные
 	err := SynchronizeNewLocalData(ctx, stor, info, client, addNewDataURL)
 	if err != nil {
-		return fmt.Errorf("failed to post local data to server, %w", err)
+		return tracing.Error(span, fmt.Errorf("failed to post local data to server, %w", err))
 	}
 
 	// Отправляю на сервер локальные изменения пользователя: измененные данные
 	err = SynchronizeChangedLocalData(ctx, stor, info, client, addAdditionVersionDataURL)
 	if err != nil {
-		return fmt.Errorf("failed to post changed data to server, %w", err)
+		return tracing.Error(span, fmt.Errorf("failed to post changed data to server, %w", err))
 	}
 
 	// Получаю от сервера актуальные версии данных и устанавливаю их в локальном хранилище
 	err = SynchronizeDataFromServer(ctx, stor, info, client, getServerDataURL)
 	if err != nil {
-		return fmt.Errorf("failed to update actual data from server in local storage, %w", err)
+		return tracing.Error(span, fmt.Errorf("failed to update actual data from server in local storage, %w", err))
 	}
 
 	return nil
 }
+
+// SynchronizeShares - функция для синхронизации данных пользователя с записями, которыми он поделился с другими
+// пользователями. Изменения получателей сохраняются в локальном хранилище и на сервере, локальные изменения
+// отправляются получателям. При первой синхронизации для пользователя создается ключевая пара, после чего
+// с ним могут делиться записями другие пользователи. addr - адрес сервера.
+func SynchronizeShares(ctx context.Context, stor storage.IEncryptedClientStorage, info identity.IUserInfoStorage,
+	client *resty.Client, addr string) error {
+	ctx, span := tracing.Start(ctx, "synchronization.SynchronizeShares")
+	defer span.End()
+
+	authData, id := info.Get()
+	urls := sharing.NewURLs(addr)
+	keys, err := sharing.KeyPair(ctx, client, urls, authData.Password)
+	if err != nil {
+		return tracing.Error(span, fmt.Errorf("failed to get key pair, %w", err))
+	}
+	decrData := inmemory.NewDecryptedData()
+	if err := decrData.Update(ctx, stor, info); err != nil {
+		return tracing.Error(span, fmt.Errorf("failed to decrypt data, %w", err))
+	}
+	updated, err := sharing.Sync(ctx, client, urls, authData.Login, keys, decrData.GetAll())
+	if err != nil {
+		return tracing.Error(span, fmt.Errorf("failed to synchronize shared records, %w", err))
+	}
+
+	for i := range updated {
+		ok, err := handlers.ReplaceData(ctx, id, addr+api.ReplaceDataPattern, authData.Password, client, stor, &updated[i])
+		if err != nil {
+			return tracing.Error(span, fmt.Errorf("failed to save shared record %s, %w", updated[i].Name, err))
+		}
+		if !ok {
+			logger.ClientLog.Error("shared record is deleted from storage", zap.String("name", updated[i].Name))
+		}
+	}
+	return nil
+}
+
+// Run - функция для периодической синхронизации данных пользователя с сервером до завершения контекста.
+// Пока пользователь не авторизован, синхронизация пропускается. client должен содержать авторизационные мидлвари,
+// addr - адрес сервера. Каждое значение из changes (уведомление сервера об изменении данных) запускает синхронизацию
+// без ожидания периода, nil канал отключает синхронизацию по уведомлениям.
+func Run(ctx context.Context, stor storage.IEncryptedClientStorage, info identity.IUserInfoStorage, client *resty.Client,
+	addr string, period time.Duration, changes <-chan struct{}) {
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done(): // Проверяю, был ли передан сигнал остановки
+			logger.ClientLog.Info("Stopping data synchronization with server")
+			return
+		case <-ticker.C:
+			synchronize(ctx, stor, info, client, addr)
+		case _, ok := <-changes:
+			if !ok {
+				changes = nil
+				continue
+			}
+			synchronize(ctx, stor, info, client, addr)
+		}
+	}
+}
+
+// synchronize - функция для однократной синхронизации данных авторизованного пользователя с сервером.
+func synchronize(ctx context.Context, stor storage.IEncryptedClientStorage, info identity.IUserInfoStorage, client *resty.Client, addr string) {
+	if authData, _ := info.Get(); authData.Login == "" {
+		return
+	}
+	logger.ClientLog.Info("Start data synchronization with server")
+
+	err := SynchronizeData(ctx, stor, info, client, addr+api.AddDataPattern, addr+api.ConflictDataPattern, addr+api.GetDataPattern)
+	if err != nil {
+		logger.ClientLog.Error("failed to synchronize data", zap.String("server address", addr), zap.String("error", err.Error()))
+		return
+	}
+	if err := SynchronizeShares(ctx, stor, info, client, addr); err != nil {
+		logger.ClientLog.Error("failed to synchronize shared records", zap.String("server address", addr), zap.String("error", err.Error()))
+		return
+	}
+	logger.ClientLog.Debug("Successful data synchronization with server")
+}
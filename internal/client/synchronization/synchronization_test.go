@@ -374,6 +374,16 @@ func TestSynchronizeChangedLocalData(t *testing.T) {
 	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), notFoundID, data.CHANGED).Return(notFoundData, nil)
 	stor.EXPECT().ChangeStatusOfEncryptedData(gomock.Any(), notFoundID, "not found data name", data.SAVED).Return(false, nil)
 
+	// Тест - данные были удалены на сервере с другого устройства ----------------------------------------------------
+	deletedOnServerID := "deleted on server id"
+	deletedOnServerInfo := mocks.NewMockIUserInfoStorage(ctrl)
+	deletedOnServerInfo.EXPECT().Get().Return(identity.AuthData{}, deletedOnServerID)
+	deletedOnServerData := [][]data.EncryptedData{
+		{{EncryptedData: []byte("changed encr data"), Name: "deleted on server data name"}},
+	}
+	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), deletedOnServerID, data.CHANGED).Return(deletedOnServerData, nil)
+	stor.EXPECT().ChangeStatusOfEncryptedData(gomock.Any(), deletedOnServerID, "deleted on server data name", data.NEW).Return(true, nil)
+
 	type request struct {
 		stor        storage.IEncryptedClientStorage
 		info        identity.IUserInfoStorage
@@ -484,6 +494,18 @@ func TestSynchronizeChangedLocalData(t *testing.T) {
 				err: true,
 			},
 		},
+		{
+			name: "data deleted on server",
+			req: request{
+				stor:        stor,
+				info:        deletedOnServerInfo,
+				setValidURL: true,
+				status:      404,
+			},
+			want: want{
+				err: false,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -543,6 +565,8 @@ func TestSynchronizeDataFromServer(t *testing.T) {
 			{EncryptedData: []byte("first encr data version 2"), Name: "first encr data name"}},
 	}
 	stor.EXPECT().ReplaceDataWithMultiVersionData(gomock.Any(), successID, successWantData[0], data.CONFLICT).Return(true, nil)
+	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), successID, data.SAVED).Return(nil, nil)
+	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), successID, data.CONFLICT).Return(successWantData, nil)
 
 	// Тест с успешным изменением существующих локальных данных на актуальные от сервера -------------------------------
 	newSuccessOneVirsionID := "new success one version id"
@@ -553,6 +577,8 @@ func TestSynchronizeDataFromServer(t *testing.T) {
 	}
 	stor.EXPECT().ReplaceDataWithMultiVersionData(gomock.Any(), newSuccessOneVirsionID,
 		newSuccessOneVirsionWantData[0], data.SAVED).Return(true, nil)
+	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), newSuccessOneVirsionID, data.SAVED).Return(newSuccessOneVirsionWantData, nil)
+	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), newSuccessOneVirsionID, data.CONFLICT).Return(nil, nil)
 
 	// Тест с добавлением новых данных в локальное хранилище, полученных от сервера -------------------------------
 	newSuccessID := "new success id"
@@ -563,6 +589,37 @@ func TestSynchronizeDataFromServer(t *testing.T) {
 	}
 	stor.EXPECT().ReplaceDataWithMultiVersionData(gomock.Any(), newSuccessID, newSuccessWantData[0], data.SAVED).Return(false, nil)
 	stor.EXPECT().AddEncryptedData(gomock.Any(), newSuccessID, newSuccessWantData[0][0], data.SAVED).Return(true, nil)
+	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), newSuccessID, data.SAVED).Return(newSuccessWantData, nil)
+	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), newSuccessID, data.CONFLICT).Return(nil, nil)
+
+	// Тест с удалением локальных данных, которые были удалены на сервере -------------------------------
+	deletedOnServerID := "deleted on server id"
+	deletedOnServerInfo := mocks.NewMockIUserInfoStorage(ctrl)
+	deletedOnServerInfo.EXPECT().Get().Return(identity.AuthData{}, deletedOnServerID)
+	deletedOnServerLocalData := [][]data.EncryptedData{
+		{{EncryptedData: []byte("saved encr data"), Name: "saved deleted encr data name"}},
+	}
+	deletedOnServerConflictData := [][]data.EncryptedData{
+		{{EncryptedData: []byte("conflict encr data version 1"), Name: "conflict deleted encr data name"},
+			{EncryptedData: []byte("conflict encr data version 2"), Name: "conflict deleted encr data name"}},
+	}
+	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), deletedOnServerID, data.SAVED).Return(deletedOnServerLocalData, nil)
+	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), deletedOnServerID, data.CONFLICT).Return(deletedOnServerConflictData, nil)
+	stor.EXPECT().DeleteEncryptedData(gomock.Any(), deletedOnServerID, "saved deleted encr data name").Return(true, nil)
+	stor.EXPECT().DeleteEncryptedData(gomock.Any(), deletedOnServerID, "conflict deleted encr data name").Return(true, nil)
+
+	// Тест - ошибка получения локальных данных при удалении данных, удаленных на сервере ---------------------------
+	getLocalErrorID := "get local error id"
+	getLocalErrorInfo := mocks.NewMockIUserInfoStorage(ctrl)
+	getLocalErrorInfo.EXPECT().Get().Return(identity.AuthData{}, getLocalErrorID)
+	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), getLocalErrorID, data.SAVED).Return(nil, errors.New("some error"))
+
+	// Тест - ошибка удаления локальных данных, которые были удалены на сервере ---------------------------
+	deleteErrorID := "delete error id"
+	deleteErrorInfo := mocks.NewMockIUserInfoStorage(ctrl)
+	deleteErrorInfo.EXPECT().Get().Return(identity.AuthData{}, deleteErrorID)
+	stor.EXPECT().GetEncryptedDataByStatus(gomock.Any(), deleteErrorID, data.SAVED).Return(deletedOnServerLocalData, nil)
+	stor.EXPECT().DeleteEncryptedData(gomock.Any(), deleteErrorID, "saved deleted encr data name").Return(false, errors.New("some error"))
 
 	// Тест с неспешной попыткой выполнить запрос на сервер ------------------------------------------
 	connectionErrorID := "connection error id"
@@ -666,6 +723,45 @@ func TestSynchronizeDataFromServer(t *testing.T) {
 				err: false,
 			},
 		},
+		{
+			name: "success delete data deleted on server",
+			req: request{
+				stor:        stor,
+				info:        deletedOnServerInfo,
+				setValidURL: true,
+				status:      200,
+				serverData:  [][]data.EncryptedData{},
+			},
+			want: want{
+				err: false,
+			},
+		},
+		{
+			name: "get local data error",
+			req: request{
+				stor:        stor,
+				info:        getLocalErrorInfo,
+				setValidURL: true,
+				status:      200,
+				serverData:  [][]data.EncryptedData{},
+			},
+			want: want{
+				err: true,
+			},
+		},
+		{
+			name: "delete local data error",
+			req: request{
+				stor:        stor,
+				info:        deleteErrorInfo,
+				setValidURL: true,
+				status:      200,
+				serverData:  [][]data.EncryptedData{},
+			},
+			want: want{
+				err: true,
+			},
+		},
 		{
 			name: "connection error",
 			req: request{
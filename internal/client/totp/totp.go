@@ -0,0 +1,133 @@
+// Пакет totp реализует одноразовые пароли на основе времени (RFC 6238), которые клиент вычисляет из секрета,
+// сохраненного в данных пользователя. Секрет задается строкой base32 или URI otpauth://totp/...
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Параметры по умолчанию, которые используют приложения-аутентификаторы.
+const (
+	DefaultDigits = 6
+	DefaultPeriod = 30 // период действия кода в секундах
+
+	minDigits = 6
+	maxDigits = 8 // наибольшая длина кода по RFC 4226, 31-битное значение усечения не дает больше цифр
+)
+
+// ErrInvalidSecret - ошибка разбора секрета.
+var ErrInvalidSecret = errors.New("invalid TOTP secret")
+
+// Key - параметры генерации одноразовых паролей.
+type Key struct {
+	Secret    []byte
+	Digits    int
+	Period    int
+	Algorithm string // SHA1, SHA256 или SHA512
+}
+
+// Parse - функция для разбора секрета в виде строки base32 (регистр, пробелы и дополнение "=" не важны)
+// или URI otpauth://totp/... с параметрами secret, digits, period и algorithm.
+func Parse(seed string) (*Key, error) {
+	seed = strings.TrimSpace(seed)
+	key := &Key{Digits: DefaultDigits, Period: DefaultPeriod, Algorithm: "SHA1"}
+
+	if strings.HasPrefix(strings.ToLower(seed), "otpauth://") {
+		u, err := url.Parse(seed)
+		if err != nil {
+			return nil, fmt.Errorf("%w, %v", ErrInvalidSecret, err)
+		}
+		if !strings.EqualFold(u.Host, "totp") {
+			return nil, fmt.Errorf("%w, unsupported otpauth type %s", ErrInvalidSecret, u.Host)
+		}
+		q := u.Query()
+		seed = q.Get("secret")
+		if v := q.Get("digits"); v != "" {
+			if key.Digits, err = strconv.Atoi(v); err != nil || key.Digits < minDigits || key.Digits > maxDigits {
+				return nil, fmt.Errorf("%w, digits must be from %d to %d", ErrInvalidSecret, minDigits, maxDigits)
+			}
+		}
+		if v := q.Get("period"); v != "" {
+			if key.Period, err = strconv.Atoi(v); err != nil || key.Period <= 0 {
+				return nil, fmt.Errorf("%w, period must be positive", ErrInvalidSecret)
+			}
+		}
+		if v := q.Get("algorithm"); v != "" {
+			key.Algorithm = strings.ToUpper(v)
+		}
+	}
+	if newHash(key.Algorithm) == nil {
+		return nil, fmt.Errorf("%w, unsupported algorithm %s", ErrInvalidSecret, key.Algorithm)
+	}
+
+	seed = strings.ToUpper(strings.TrimRight(strings.ReplaceAll(seed, " ", ""), "="))
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(seed)
+	if err != nil || len(secret) == 0 {
+		return nil, fmt.Errorf("%w, secret must be base32 encoded", ErrInvalidSecret)
+	}
+	key.Secret = secret
+	return key, nil
+}
+
+// Code - метод для получения одноразового пароля, действующего в момент t.
+func (k *Key) Code(t time.Time) string {
+	counter := uint64(t.Unix() / int64(k.Period))
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(newHash(k.Algorithm), k.Secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Динамическое усечение по RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < k.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", k.Digits, value%mod)
+}
+
+// Remaining - метод для получения времени, в течение которого действует одноразовый пароль момента t.
+func (k *Key) Remaining(t time.Time) time.Duration {
+	period := int64(k.Period)
+	return time.Duration(period-t.Unix()%period) * time.Second
+}
+
+// Now - функция для получения текущего одноразового пароля и времени его действия по секрету seed.
+func Now(seed string) (string, time.Duration, error) {
+	key, err := Parse(seed)
+	if err != nil {
+		return "", 0, err
+	}
+	now := time.Now()
+	return key.Code(now), key.Remaining(now), nil
+}
+
+// newHash - функция для получения хэш-функции HMAC по имени алгоритма.
+func newHash(algorithm string) func() hash.Hash {
+	switch algorithm {
+	case "SHA1":
+		return sha1.New
+	case "SHA256":
+		return sha256.New
+	case "SHA512":
+		return sha512.New
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,88 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCode(t *testing.T) {
+	// Тестовые векторы RFC 6238
+	secrets := map[string]string{
+		"SHA1":   "12345678901234567890",
+		"SHA256": "12345678901234567890123456789012",
+		"SHA512": "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	tests := []struct {
+		unix      int64
+		algorithm string
+		want      string
+	}{
+		{unix: 59, algorithm: "SHA1", want: "94287082"},
+		{unix: 59, algorithm: "SHA256", want: "46119246"},
+		{unix: 59, algorithm: "SHA512", want: "90693936"},
+		{unix: 1111111109, algorithm: "SHA1", want: "07081804"},
+		{unix: 1234567890, algorithm: "SHA256", want: "91819424"},
+		{unix: 20000000000, algorithm: "SHA512", want: "47863826"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm+"/"+tt.want, func(t *testing.T) {
+			key := &Key{Secret: []byte(secrets[tt.algorithm]), Digits: 8, Period: DefaultPeriod, Algorithm: tt.algorithm}
+			assert.Equal(t, tt.want, key.Code(time.Unix(tt.unix, 0)))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		name    string
+		seed    string
+		want    *Key
+		wantErr bool
+	}{
+		{
+			name: "base32 secret",
+			seed: secret,
+			want: &Key{Secret: []byte("12345678901234567890"), Digits: 6, Period: 30, Algorithm: "SHA1"},
+		},
+		{
+			name: "lower case with spaces and without padding",
+			seed: "gezd gnbv gy3t qojq gezd gnbv gy3t qojq",
+			want: &Key{Secret: []byte("12345678901234567890"), Digits: 6, Period: 30, Algorithm: "SHA1"},
+		},
+		{
+			name: "otpauth uri",
+			seed: "otpauth://totp/Example:alice?secret=" + secret + "&issuer=Example&digits=8&period=60&algorithm=sha256",
+			want: &Key{Secret: []byte("12345678901234567890"), Digits: 8, Period: 60, Algorithm: "SHA256"},
+		},
+		{name: "not base32", seed: "not a secret!", wantErr: true},
+		{name: "empty", seed: "", wantErr: true},
+		{name: "hotp uri", seed: "otpauth://hotp/alice?secret=" + secret, wantErr: true},
+		{name: "wrong digits", seed: "otpauth://totp/alice?digits=3&secret=" + secret, wantErr: true},
+		{name: "9 digits", seed: "otpauth://totp/alice?digits=9&secret=" + secret, wantErr: true},
+		{name: "10 digits", seed: "otpauth://totp/alice?digits=10&secret=" + secret, wantErr: true},
+		{name: "unknown algorithm", seed: "otpauth://totp/alice?algorithm=MD5&secret=" + secret, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := Parse(tt.seed)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidSecret)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, key)
+		})
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	key := &Key{Period: 30}
+	assert.Equal(t, 30*time.Second, key.Remaining(time.Unix(60, 0)))
+	assert.Equal(t, time.Second, key.Remaining(time.Unix(89, 0)))
+}
@@ -11,7 +11,7 @@ import (
 func Data(app *app.App) tview.Primitive {
 	form := tview.NewForm()
 
-	form.AddDropDown("Тип данных", []string{"PASSWORD", "TEXT", "BINARY", "BANKCARD"}, 0, func(option string, _ int) {
+	form.AddDropDown("Тип данных", []string{"PASSWORD", "TEXT", "BINARY", "BANKCARD", "LOGIN", "SSHKEY"}, 0, func(option string, _ int) {
 		switch option {
 		case "PASSWORD":
 			app.SwitchTo(tui.AddPassword)
@@ -21,6 +21,10 @@ func Data(app *app.App) tview.Primitive {
 			app.SwitchTo(tui.AddBinary)
 		case "BANKCARD":
 			app.SwitchTo(tui.AddBankCard)
+		case "LOGIN":
+			app.SwitchTo(tui.AddLogin)
+		case "SSHKEY":
+			app.SwitchTo(tui.AddSSHKey)
 		}
 	})
 
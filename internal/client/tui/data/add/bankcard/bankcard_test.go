@@ -22,7 +22,12 @@ func TestAddPasswordPage(t *testing.T) {
 	assert.True(t, ok, "AddPasswordPage must return *tview.Form")
 
 	// Проверяем количество полей в форме (6 полей)
-	assert.Equal(t, 7, form.GetFormItemCount(), "Form must containe 6 fields and 2 buttons")
+	assert.Equal(t, 10, form.GetFormItemCount(), "Form must containe 10 fields and 2 buttons")
+
+	// Последние поля формы - теги, папка и отметка избранного
+	assert.Equal(t, "Теги", form.GetFormItem(7).GetLabel())
+	assert.Equal(t, "Папка", form.GetFormItem(8).GetLabel())
+	assert.Equal(t, "Избранное", form.GetFormItem(9).GetLabel())
 
 	// Проверяю названия элементов--------------------------------------------------------------
 	label := form.GetFormItem(0).GetLabel()
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
 	"github.com/abezemskiy/gophkeeper/internal/client/handlers"
 	"github.com/abezemskiy/gophkeeper/internal/client/identity"
 	"github.com/abezemskiy/gophkeeper/internal/client/logger"
 	"github.com/abezemskiy/gophkeeper/internal/client/storage"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
 	input "github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/binary"
@@ -20,7 +22,7 @@ import (
 )
 
 // AddBinaryPage - TUI страница добавления нового файла.
-func AddBinaryPage(ctx context.Context, url string, client *resty.Client, stor storage.IEncryptedClientStorage,
+func AddBinaryPage(ctx context.Context, url string, attachURLs attachment.URLs, client *resty.Client, stor storage.IEncryptedClientStorage,
 	info identity.IUserInfoStorage) func(app *app.App) tview.Primitive {
 
 	return func(app *app.App) tview.Primitive {
@@ -34,22 +36,15 @@ func AddBinaryPage(ctx context.Context, url string, client *resty.Client, stor s
 		// Создаю поля для заполенения данных
 		input.Fields(form, dataInfo)
 
-		form.AddButton("Сохранить", func() {
-			// проверяю наличие в приложении мастер пароля
-			authData, id := info.Get()
-			if authData.Password == "" {
-				// мастер пароль не установлен, возвращаю пользователя на страницу аутентификации.
-				app.SwitchTo(tui.Login)
-				return
-			}
-
+		// save - сохраняет данные в сервисе. Вызывается в цикле событий интерфейса.
+		save := func(authData identity.AuthData, id string) {
 			// Валидирую и сериализую данные для сохранения в сервисе
 			userData, err := input.JSONEncode(dataInfo)
 			if err != nil {
 				logger.ClientLog.Error("encode data error", zap.String("error", error.Error(err)))
 				printer.Error(app, fmt.Sprintf("encode data error, %v", err))
 
-				app.SwitchTo(tui.AddBankCard)
+				app.SwitchTo(tui.AddBinary)
 				return
 			}
 
@@ -75,6 +70,45 @@ func AddBinaryPage(ctx context.Context, url string, client *resty.Client, stor s
 
 			// перенаправляю пользователя на страницу данных
 			app.SwitchTo(tui.Data)
+		}
+
+		form.AddButton("Сохранить", func() {
+			// проверяю наличие в приложении мастер пароля
+			authData, id := info.Get()
+			if authData.Password == "" {
+				// мастер пароль не установлен, возвращаю пользователя на страницу аутентификации.
+				app.SwitchTo(tui.Login)
+				return
+			}
+
+			dataInfo.Binary = data.Binary{}
+			large, err := input.IsLarge(dataInfo)
+			if err != nil {
+				logger.ClientLog.Error("read file error", zap.String("error", error.Error(err)))
+				printer.Error(app, fmt.Sprintf("read file error, %v", err))
+
+				app.SwitchTo(tui.AddBinary)
+				return
+			}
+			if !large {
+				save(authData, id)
+				return
+			}
+
+			// Большой файл загружаю на сервер частями в отдельной горутине, чтобы не блокировать интерфейс
+			go func() {
+				err := input.UploadLargeFile(ctx, client, attachURLs, authData.Password, dataInfo, func(done, total int) {
+					printer.Progress(app, "Загрузка файла", done, total)
+				})
+				if err != nil {
+					logger.ClientLog.Error("upload file error", zap.String("error", error.Error(err)))
+					printer.Error(app, fmt.Sprintf("upload file error, %v", err))
+					// закрываю окно прогресса
+					printer.Progress(app, "", 0, 0)
+					return
+				}
+				app.App.QueueUpdateDraw(func() { save(authData, id) })
+			}()
 		})
 		form.AddButton("Отмена", func() { app.SwitchTo(tui.Add) })
 
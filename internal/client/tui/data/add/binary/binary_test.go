@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
 
 	"github.com/rivo/tview"
@@ -15,14 +16,19 @@ func TestAddBinaryPage(t *testing.T) {
 	testApp := &app.App{}
 
 	// Создаем страницу ввода пароля
-	passwordPage := AddBinaryPage(context.Background(), "some/url", nil, nil, nil)(testApp)
+	passwordPage := AddBinaryPage(context.Background(), "some/url", attachment.NewURLs("some/url"), nil, nil, nil)(testApp)
 
 	// Проверяем, что это форма
 	form, ok := passwordPage.(*tview.Form)
 	assert.True(t, ok, "TestAddBinaryPage must return *tview.Form")
 
 	// Проверяем количество полей в форме (3 поля)
-	assert.Equal(t, 3, form.GetFormItemCount(), "Form must containe 3 fields and 2 buttons")
+	assert.Equal(t, 6, form.GetFormItemCount(), "Form must containe 6 fields and 2 buttons")
+
+	// Последние поля формы - теги, папка и отметка избранного
+	assert.Equal(t, "Теги", form.GetFormItem(3).GetLabel())
+	assert.Equal(t, "Папка", form.GetFormItem(4).GetLabel())
+	assert.Equal(t, "Избранное", form.GetFormItem(5).GetLabel())
 
 	// Проверяю названия элементов--------------------------------------------------------------
 	label := form.GetFormItem(0).GetLabel()
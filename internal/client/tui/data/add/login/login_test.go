@@ -0,0 +1,33 @@
+package login
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
+
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddLoginPage(t *testing.T) {
+	// Создаем тестовое приложение
+	testApp := &app.App{}
+
+	page := AddLoginPage(context.Background(), "some/url", nil, nil, nil)(testApp)
+
+	// Проверяем, что это форма
+	form, ok := page.(*tview.Form)
+	assert.True(t, ok, "AddLoginPage must return *tview.Form")
+
+	// Проверяю названия элементов
+	labels := []string{"Имя данных", "Логин", "Пароль", "URL", "Заметки", "Секрет TOTP", "Доп. поля", "Описание",
+		"Теги", "Папка", "Избранное"}
+	assert.Equal(t, len(labels), form.GetFormItemCount())
+	for i, label := range labels {
+		assert.Equal(t, label, form.GetFormItem(i).GetLabel())
+	}
+
+	assert.Equal(t, "Сохранить", form.GetButton(0).GetLabel())
+	assert.Equal(t, "Отмена", form.GetButton(1).GetLabel())
+}
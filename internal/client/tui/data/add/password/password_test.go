@@ -22,7 +22,12 @@ func TestAddPasswordPage(t *testing.T) {
 	assert.True(t, ok, "AddPasswordPage must return *tview.Form")
 
 	// Проверяем количество полей в форме (4 поля)
-	assert.Equal(t, 4, form.GetFormItemCount(), "Form must containe 4 fields and 2 buttons")
+	assert.Equal(t, 7, form.GetFormItemCount(), "Form must containe 7 fields and 2 buttons")
+
+	// Последние поля формы - теги, папка и отметка избранного
+	assert.Equal(t, "Теги", form.GetFormItem(4).GetLabel())
+	assert.Equal(t, "Папка", form.GetFormItem(5).GetLabel())
+	assert.Equal(t, "Избранное", form.GetFormItem(6).GetLabel())
 
 	// Проверяю названия элементов--------------------------------------------------------------
 	label := form.GetFormItem(0).GetLabel()
@@ -77,6 +77,7 @@ func AddTextPage(ctx context.Context, url string, client *resty.Client, stor sto
 			app.SwitchTo(tui.Data)
 		})
 		form.AddButton("Отмена", func() { app.SwitchTo(tui.Add) })
+		input.Buttons(app, form, dataInfo)
 
 		form.SetBorder(true).SetTitle("Добавить текст")
 		return form
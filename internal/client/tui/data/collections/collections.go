@@ -0,0 +1,73 @@
+// Пакет collections содержит TUI страницу с записями коллекций организаций, участником которых является пользователь.
+// Коллекции хранятся только на сервере и расшифровываются ключевой парой пользователя, поэтому доступны в режиме
+// онлайн.
+package collections
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/identity"
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/orgs"
+	"github.com/abezemskiy/gophkeeper/internal/client/sharing"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/tools/printer"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/org"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rivo/tview"
+	"go.uber.org/zap"
+)
+
+// Page - TUI страница с записями коллекций организаций пользователя. addr - адрес сервера.
+func Page(ctx context.Context, addr string, client *resty.Client, info identity.IUserInfoStorage) func(app *app.App) tview.Primitive {
+	return func(app *app.App) tview.Primitive {
+		form := tview.NewForm()
+		form.AddTextView("Записи", "нажмите \"Обновить\"", 60, 10, false, true)
+		view, _ := form.GetFormItem(0).(*tview.TextView)
+
+		form.AddButton("Обновить", func() {
+			// Запрос к серверу выполняю в отдельной горутине, чтобы не блокировать интерфейс
+			go func() {
+				authData, _ := info.Get()
+				keys, err := sharing.KeyPair(ctx, client, sharing.NewURLs(addr), authData.Password)
+				if err != nil {
+					logger.ClientLog.Error("get key pair error", zap.String("error", error.Error(err)))
+					printer.Error(app, fmt.Sprintf("get key pair error, %v", err))
+					return
+				}
+				items, err := orgs.All(ctx, client, orgs.NewURLs(addr), keys)
+				if err != nil {
+					logger.ClientLog.Error("get org items error", zap.String("error", error.Error(err)))
+					printer.Error(app, fmt.Sprintf("get org items error, %v", err))
+					return
+				}
+				app.App.QueueUpdateDraw(func() { view.SetText(Format(items)) })
+			}()
+		})
+		form.AddButton("Назад", func() { app.SwitchTo(tui.Data) })
+
+		form.SetBorder(true).SetTitle("Коллекции организаций")
+		return form
+	}
+}
+
+// Format - функция для представления записей коллекций в виде текста, по одной записи в строке с названием
+// организации и правами пользователя.
+func Format(items []orgs.Item) string {
+	if len(items) == 0 {
+		return "Нет записей в коллекциях организаций"
+	}
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		permission := "чтение"
+		if org.CanWrite(item.Org.Role) {
+			permission = "изменение"
+		}
+		lines = append(lines, fmt.Sprintf("[%s] %s (%s)", item.Org.Name, item.Data.Name, permission))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,59 @@
+package collections
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/orgs"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/org"
+
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPage(t *testing.T) {
+	// Создаем тестовое приложение
+	testApp := &app.App{}
+
+	page := Page(context.Background(), "some/url", nil, nil)(testApp)
+
+	// Проверяем, что это форма
+	form, ok := page.(*tview.Form)
+	assert.True(t, ok, "Page must return *tview.Form")
+
+	assert.Equal(t, 1, form.GetFormItemCount())
+	assert.Equal(t, "Записи", form.GetFormItem(0).GetLabel())
+
+	assert.Equal(t, "Обновить", form.GetButton(0).GetLabel())
+	assert.Equal(t, "Назад", form.GetButton(1).GetLabel())
+}
+
+func TestFormat(t *testing.T) {
+	team := org.Org{Name: "team", Role: org.Editor}
+	ops := org.Org{Name: "ops", Role: org.Viewer}
+	tests := []struct {
+		name  string
+		items []orgs.Item
+		want  string
+	}{
+		{
+			name: "empty",
+			want: "Нет записей в коллекциях организаций",
+		},
+		{
+			name: "with items",
+			items: []orgs.Item{
+				{Org: team, Data: data.Data{Name: "db"}},
+				{Org: ops, Data: data.Data{Name: "vpn"}},
+			},
+			want: "[team] db (изменение)\n[ops] vpn (чтение)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Format(tt.items))
+		})
+	}
+}
@@ -14,6 +14,10 @@ func Page(app *app.App) tview.Primitive {
 		AddItem("Посмотреть данные", "", 'b', func() { app.SwitchTo(tui.View) }).
 		AddItem("Удалить данные", "", 'c', func() { app.SwitchTo(tui.Delete) }).
 		AddItem("Изменить данные", "", 'd', func() { app.SwitchTo(tui.Edit) }).
+		AddItem("Скачать файл", "", 'e', func() { app.SwitchTo(tui.Download) }).
+		AddItem("Использование хранилища", "", 'f', func() { app.SwitchTo(tui.Usage) }).
+		AddItem("Коллекции организаций", "", 'g', func() { app.SwitchTo(tui.Collections) }).
+		AddItem("Удалить учетную запись", "", 'x', func() { app.SwitchTo(tui.DeleteAccount) }).
 		AddItem("Выйти", "", 'q', func() { app.SwitchTo(tui.Login) })
 
 	list.SetBorder(true).SetTitle("Ваши данные")
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
 	"github.com/abezemskiy/gophkeeper/internal/client/handlers"
 	"github.com/abezemskiy/gophkeeper/internal/client/identity"
 	"github.com/abezemskiy/gophkeeper/internal/client/logger"
@@ -17,8 +18,9 @@ import (
 	"go.uber.org/zap"
 )
 
-// Delete - TUI страница для удаления данных пользователя по имени данных.
-func Delete(ctx context.Context, url string, client *resty.Client, stor storage.IEncryptedClientStorage,
+// Delete - TUI страница для удаления данных пользователя по имени данных. Вместе с данными с сервера удаляются
+// ссылки на вложения всех версий данных. attachURLs - адреса ресурсов вложений на сервере.
+func Delete(ctx context.Context, url string, attachURLs attachment.URLs, client *resty.Client, stor storage.IEncryptedClientStorage,
 	info identity.IUserInfoStorage) func(app *app.App) tview.Primitive {
 
 	return func(app *app.App) tview.Primitive {
@@ -46,6 +48,12 @@ func Delete(ctx context.Context, url string, client *resty.Client, stor storage.
 				return
 			}
 
+			// Запоминаю вложения удаляемых данных до их удаления из локального хранилища
+			atts, err := attachment.Manifests(ctx, stor, id, authData.Password, dataName)
+			if err != nil {
+				logger.ClientLog.Error("get attachments of data error", zap.String("error", error.Error(err)))
+			}
+
 			// Удаляю данные
 			ok, err := handlers.DeleteEncryptedData(ctx, id, url, dataName, client, stor)
 
@@ -64,6 +72,12 @@ func Delete(ctx context.Context, url string, client *resty.Client, stor storage.
 				return
 			}
 
+			for _, att := range atts {
+				if err := attachment.Release(ctx, client, attachURLs, att); err != nil {
+					logger.ClientLog.Error("release attachment error", zap.String("error", error.Error(err)))
+				}
+			}
+
 			// Печатаю сообщение об успешном удалении данных
 			printer.Message(app, "data delete successfully")
 
@@ -0,0 +1,104 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data/tools/binary"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/tools/printer"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rivo/tview"
+	"go.uber.org/zap"
+)
+
+// Page - TUI страница для сохранения файла пользователя на диск. Небольшие файлы восстанавливаются из локальных данных,
+// большие файлы скачиваются с сервера частями. urls - адреса ресурсов вложений на сервере.
+func Page(ctx context.Context, urls attachment.URLs, client *resty.Client, decrData storage.IStorage) func(app *app.App) tview.Primitive {
+	return func(app *app.App) tview.Primitive {
+		form := tview.NewForm()
+		var dataName, outputDir string
+
+		form.AddInputField("Имя данных", "", 20, nil, func(text string) { dataName = text })
+		form.AddInputField("Директория", "", 20, nil, func(text string) { outputDir = text })
+
+		form.AddButton("Скачать", func() {
+			// Имя данных не задано
+			if dataName == "" {
+				printer.Error(app, "data name is not set")
+
+				app.SwitchTo(tui.Download)
+				return
+			}
+
+			b, err := findBinary(decrData, dataName)
+			if err != nil {
+				logger.ClientLog.Error("find file error", zap.String("error", error.Error(err)))
+				printer.Error(app, fmt.Sprintf("find file error, %v", err))
+
+				app.SwitchTo(tui.Download)
+				return
+			}
+			path := binary.FileName(*b, dataName, outputDir)
+
+			// Файл хранится непосредственно в данных пользователя
+			if b.Attachment == nil {
+				if err := binary.RestoreFile(*b, dataName, outputDir); err != nil {
+					logger.ClientLog.Error("restore file error", zap.String("error", error.Error(err)))
+					printer.Error(app, fmt.Sprintf("restore file error, %v", err))
+
+					app.SwitchTo(tui.Download)
+					return
+				}
+				printer.Message(app, fmt.Sprintf("file saved to %s", path))
+				app.SwitchTo(tui.Data)
+				return
+			}
+
+			// Большой файл скачиваю с сервера в отдельной горутине, чтобы не блокировать интерфейс
+			go func() {
+				err := attachment.Download(ctx, client, urls, b.Attachment, path, func(done, total int) {
+					printer.Progress(app, "Скачивание файла", done, total)
+				})
+				if err != nil {
+					logger.ClientLog.Error("download file error", zap.String("error", error.Error(err)))
+					printer.Error(app, fmt.Sprintf("download file error, %v", err))
+					// закрываю окно прогресса
+					printer.Progress(app, "", 0, 0)
+					return
+				}
+				printer.Message(app, fmt.Sprintf("file saved to %s", path))
+			}()
+		})
+		form.AddButton("Отмена", func() { app.SwitchTo(tui.Data) })
+
+		form.SetBorder(true).SetTitle("Скачать файл")
+		return form
+	}
+}
+
+// findBinary - функция для поиска файла пользователя по имени данных. Если данные имеют несколько версий, используется первая.
+func findBinary(decrData storage.IStorage, dataName string) (*data.Binary, error) {
+	for _, versions := range decrData.GetAll() {
+		if len(versions) == 0 || versions[0].Name != dataName {
+			continue
+		}
+		if versions[0].Type != repoData.BINARY {
+			return nil, fmt.Errorf("data %s is not a file", dataName)
+		}
+		var b data.Binary
+		if err := json.Unmarshal(versions[0].Data, &b); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal data, %w", err)
+		}
+		return &b, nil
+	}
+	return nil, fmt.Errorf("data %s does not exist", dataName)
+}
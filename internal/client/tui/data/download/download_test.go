@@ -0,0 +1,70 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/inmemory"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPage(t *testing.T) {
+	// Создаем тестовое приложение
+	testApp := &app.App{}
+
+	page := Page(context.Background(), attachment.NewURLs("some/url"), nil, nil)(testApp)
+
+	// Проверяем, что это форма
+	form, ok := page.(*tview.Form)
+	assert.True(t, ok, "Page must return *tview.Form")
+
+	// Проверяем количество полей в форме (2 поля)
+	assert.Equal(t, 2, form.GetFormItemCount(), "Form must containe 2 fields and 2 buttons")
+	assert.Equal(t, "Имя данных", form.GetFormItem(0).GetLabel())
+	assert.Equal(t, "Директория", form.GetFormItem(1).GetLabel())
+
+	assert.Equal(t, "Скачать", form.GetButton(0).GetLabel())
+	assert.Equal(t, "Отмена", form.GetButton(1).GetLabel())
+}
+
+// testStorage - временное хранилище расшифрованных данных с заданным содержимым.
+type testStorage struct {
+	*inmemory.DecryptedData
+	data [][]repoData.Data
+}
+
+func (s testStorage) GetAll() [][]repoData.Data {
+	return s.data
+}
+
+func TestFindBinary(t *testing.T) {
+	file, err := json.Marshal(data.Binary{Type: "application/pdf", Attachment: &data.Attachment{ID: "attachment"}})
+	require.NoError(t, err)
+	text, err := json.Marshal(data.Text{Text: "text"})
+	require.NoError(t, err)
+
+	stor := testStorage{data: [][]repoData.Data{
+		{{Name: "file", Type: repoData.BINARY, Data: file}},
+		{{Name: "text", Type: repoData.TEXT, Data: text}},
+	}}
+
+	b, err := findBinary(stor, "file")
+	require.NoError(t, err)
+	assert.Equal(t, "attachment", b.Attachment.ID)
+
+	// Данные не являются файлом
+	_, err = findBinary(stor, "text")
+	assert.Error(t, err)
+
+	// Данные не существуют
+	_, err = findBinary(stor, "missing")
+	assert.Error(t, err)
+}
@@ -5,14 +5,17 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
 	"github.com/abezemskiy/gophkeeper/internal/client/handlers"
 	"github.com/abezemskiy/gophkeeper/internal/client/identity"
 	"github.com/abezemskiy/gophkeeper/internal/client/logger"
 	"github.com/abezemskiy/gophkeeper/internal/client/storage"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
 	input "github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/binary"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/tools/printer"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/rivo/tview"
@@ -20,7 +23,7 @@ import (
 )
 
 // EditBinaryPage - TUI страница изменения файла.
-func EditBinaryPage(ctx context.Context, url string, client *resty.Client, stor storage.IEncryptedClientStorage,
+func EditBinaryPage(ctx context.Context, url string, attachURLs attachment.URLs, client *resty.Client, stor storage.IEncryptedClientStorage,
 	info identity.IUserInfoStorage) func(app *app.App) tview.Primitive {
 
 	return func(app *app.App) tview.Primitive {
@@ -34,15 +37,8 @@ func EditBinaryPage(ctx context.Context, url string, client *resty.Client, stor
 		// Создаю поля для заполенения данных
 		input.Fields(form, dataInfo)
 
-		form.AddButton("Изменить", func() {
-			// проверяю наличие в приложении мастер пароля
-			authData, id := info.Get()
-			if authData.Password == "" {
-				// мастер пароль не установлен, возвращаю пользователя на страницу аутентификации.
-				app.SwitchTo(tui.Login)
-				return
-			}
-
+		// save - сохраняет данные в сервисе. Вызывается в цикле событий интерфейса.
+		save := func(authData identity.AuthData, id string) {
 			// Валидирую и сериализую данные для сохранения в сервисе
 			userData, err := input.JSONEncode(dataInfo)
 			if err != nil {
@@ -53,6 +49,12 @@ func EditBinaryPage(ctx context.Context, url string, client *resty.Client, stor
 				return
 			}
 
+			// Запоминаю вложения заменяемых версий, чтобы удалить их с сервера после успешной замены данных
+			oldAtts, err := attachment.Manifests(ctx, stor, id, authData.Password, dataInfo.Name)
+			if err != nil {
+				logger.ClientLog.Error("get attachments of data error", zap.String("error", error.Error(err)))
+			}
+
 			// Меняю данные в хранилище на новые
 			ok, err := handlers.ReplaceData(ctx, id, url, authData.Password, client, stor, userData)
 			if err != nil {
@@ -70,11 +72,61 @@ func EditBinaryPage(ctx context.Context, url string, client *resty.Client, stor
 				return
 			}
 
+			// Если данные заменены на сервере, вложения предыдущих версий больше не используются.
+			// При замене в режиме офлайн предыдущая версия остается на сервере вместе со своими вложениями.
+			status, ok, err := stor.GetStatus(ctx, id, dataInfo.Name)
+			if err == nil && ok && status == repoData.SAVED {
+				for _, att := range oldAtts {
+					if err := attachment.Release(ctx, client, attachURLs, att); err != nil {
+						logger.ClientLog.Error("release attachment error", zap.String("error", error.Error(err)))
+					}
+				}
+			}
+
 			// Печатаю сообщение об успешном сохранении данных
 			printer.Message(app, "data replace successfully")
 
 			// перенаправляю пользователя на страницу данных
 			app.SwitchTo(tui.Edit)
+		}
+
+		form.AddButton("Изменить", func() {
+			// проверяю наличие в приложении мастер пароля
+			authData, id := info.Get()
+			if authData.Password == "" {
+				// мастер пароль не установлен, возвращаю пользователя на страницу аутентификации.
+				app.SwitchTo(tui.Login)
+				return
+			}
+
+			dataInfo.Binary = data.Binary{}
+			large, err := input.IsLarge(dataInfo)
+			if err != nil {
+				logger.ClientLog.Error("read file error", zap.String("error", error.Error(err)))
+				printer.Error(app, fmt.Sprintf("read file error, %v", err))
+
+				app.SwitchTo(tui.EditBinary)
+				return
+			}
+			if !large {
+				save(authData, id)
+				return
+			}
+
+			// Большой файл загружаю на сервер частями в отдельной горутине, чтобы не блокировать интерфейс
+			go func() {
+				err := input.UploadLargeFile(ctx, client, attachURLs, authData.Password, dataInfo, func(done, total int) {
+					printer.Progress(app, "Загрузка файла", done, total)
+				})
+				if err != nil {
+					logger.ClientLog.Error("upload file error", zap.String("error", error.Error(err)))
+					printer.Error(app, fmt.Sprintf("upload file error, %v", err))
+					// закрываю окно прогресса
+					printer.Progress(app, "", 0, 0)
+					return
+				}
+				app.App.QueueUpdateDraw(func() { save(authData, id) })
+			}()
 		})
 		form.AddButton("Отмена", func() { app.SwitchTo(tui.Edit) })
 
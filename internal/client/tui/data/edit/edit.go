@@ -11,7 +11,7 @@ import (
 func Edit(app *app.App) tview.Primitive {
 	form := tview.NewForm()
 
-	form.AddDropDown("Тип данных", []string{"PASSWORD", "TEXT", "BINARY", "BANKCARD"}, 0, func(option string, _ int) {
+	form.AddDropDown("Тип данных", []string{"PASSWORD", "TEXT", "BINARY", "BANKCARD", "LOGIN", "SSHKEY"}, 0, func(option string, _ int) {
 		switch option {
 		case "PASSWORD":
 			app.SwitchTo(tui.EditPassword)
@@ -21,6 +21,10 @@ func Edit(app *app.App) tview.Primitive {
 			app.SwitchTo(tui.EditBinary)
 		case "BANKCARD":
 			app.SwitchTo(tui.EditBankCard)
+		case "LOGIN":
+			app.SwitchTo(tui.EditLogin)
+		case "SSHKEY":
+			app.SwitchTo(tui.EditSSHKey)
 		}
 	})
 
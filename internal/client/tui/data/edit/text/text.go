@@ -77,6 +77,7 @@ func EditTextPage(ctx context.Context, url string, client *resty.Client, stor st
 			app.SwitchTo(tui.Edit)
 		})
 		form.AddButton("Отмена", func() { app.SwitchTo(tui.Edit) })
+		input.Buttons(app, form, dataInfo)
 
 		form.SetBorder(true).SetTitle("Изменить текст")
 		return form
@@ -22,7 +22,12 @@ func TestAddPasswordPage(t *testing.T) {
 	assert.True(t, ok, "AddPasswordPage must return *tview.Form")
 
 	// Проверяем количество полей в форме (3 поля)
-	assert.Equal(t, 3, form.GetFormItemCount(), "Form must containe 3 fields and 2 buttons")
+	assert.Equal(t, 6, form.GetFormItemCount(), "Form must containe 6 fields and 4 buttons")
+
+	// Последние поля формы - теги, папка и отметка избранного
+	assert.Equal(t, "Теги", form.GetFormItem(3).GetLabel())
+	assert.Equal(t, "Папка", form.GetFormItem(4).GetLabel())
+	assert.Equal(t, "Избранное", form.GetFormItem(5).GetLabel())
 
 	// Проверяю названия элементов--------------------------------------------------------------
 	label := form.GetFormItem(0).GetLabel()
@@ -41,9 +46,10 @@ func TestAddPasswordPage(t *testing.T) {
 	// Проверяю, что пароль сохранился в поле
 	assert.Equal(t, message0, field0.GetText())
 
-	field1 := form.GetFormItem(1).(*tview.InputField)
-	message1 := "some text"
-	field1.SetText(message1)
+	// Текст вводится в многострочном поле
+	field1 := form.GetFormItem(1).(*tview.TextArea)
+	message1 := "# some text\n\n- first line\n- second line"
+	field1.SetText(message1, true)
 	// Проверяю, что пароль сохранился в поле
 	assert.Equal(t, message1, field1.GetText())
 
@@ -59,4 +65,9 @@ func TestAddPasswordPage(t *testing.T) {
 
 	assert.Equal(t, "Изменить", saveButton.GetLabel(), "Первая кнопка должна быть 'Изменить'")
 	assert.Equal(t, "Отмена", cancelButton.GetLabel(), "Вторая кнопка должна быть 'Отмена'")
+
+	// Кнопки редактирования во внешнем редакторе и предпросмотра Markdown
+	assert.Equal(t, 4, form.GetButtonCount())
+	assert.Equal(t, "Редактор", form.GetButton(2).GetLabel())
+	assert.Equal(t, "Предпросмотр", form.GetButton(3).GetLabel())
 }
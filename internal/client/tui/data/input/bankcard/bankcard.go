@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/organize"
 	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
 
 	"github.com/rivo/tview"
@@ -20,6 +21,7 @@ type DataInfo struct {
 	Name       string
 	CreateDate time.Time
 	EditDate   time.Time
+	Organize   organize.Info // теги, папка и отметка избранного
 }
 
 // Fields - функция для заполения данных банковской карты пользователем.
@@ -81,6 +83,7 @@ func Fields(form *tview.Form, dataInfo *DataInfo) {
 	form.AddInputField("Описание", "", 20, nil, func(text string) {
 		dataInfo.MetaInfo = text
 	})
+	organize.Fields(form, &dataInfo.Organize)
 }
 
 // JSONEncode - функция для сериализации данных банковской карты.
@@ -98,7 +101,7 @@ func JSONEncode(dataInfo *DataInfo) (*repoData.Data, error) {
 	}
 
 	// Создаю структуру типа data.Data
-	return &repoData.Data{
+	userData := &repoData.Data{
 		Data:       buf.Bytes(),
 		Type:       repoData.BANKCARD,
 		Name:       dataInfo.Name,
@@ -106,7 +109,9 @@ func JSONEncode(dataInfo *DataInfo) (*repoData.Data, error) {
 		Status:     repoData.NEW,
 		CreateDate: dataInfo.CreateDate,
 		EditDate:   dataInfo.EditDate,
-	}, nil
+	}
+	dataInfo.Organize.Apply(userData)
+	return userData, nil
 }
 
 // validateCardData - функция для проверки корректности установленных данных банковской карты.
@@ -2,18 +2,26 @@ package binary
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"time"
 
+	"github.com/abezemskiy/gophkeeper/internal/client/attachment"
 	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
 	"github.com/abezemskiy/gophkeeper/internal/client/storage/data/tools/binary"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/organize"
 	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/rivo/tview"
 )
 
+// InlineLimit - максимальный размер файла в байтах, который хранится непосредственно в данных пользователя.
+// Такие файлы доступны в режиме офлайн. Файлы большего размера загружаются на сервер зашифрованными частями.
+const InlineLimit = repoData.ChunkSize
+
 // DataInfo - вспомогательная структура для передачи полученных от пользоавтеля данных в функцию сохранения данных в сервисе.
 type DataInfo struct {
 	Binary     data.Binary
@@ -22,6 +30,7 @@ type DataInfo struct {
 	Name       string
 	CreateDate time.Time
 	EditDate   time.Time
+	Organize   organize.Info // теги, папка и отметка избранного
 }
 
 // Fields - функция для заполения установки бинарных данных пользователем.
@@ -37,9 +46,44 @@ func Fields(form *tview.Form, dataInfo *DataInfo) {
 		}
 	})
 	form.AddInputField("Описание", "", 20, nil, func(text string) { dataInfo.MetaInfo = text })
+	organize.Fields(form, &dataInfo.Organize)
+}
+
+// IsLarge - функция для проверки, требуется ли загружать указанный пользователем файл частями.
+// Перед проверкой размера файла проверяется корректность введенных данных, чтобы не загружать файл напрасно.
+func IsLarge(dataInfo *DataInfo) (bool, error) {
+	if err := validateCardData(dataInfo); err != nil {
+		return false, fmt.Errorf("invalid binary data, %w", err)
+	}
+	stat, err := os.Stat(dataInfo.Path)
+	if err != nil {
+		return false, fmt.Errorf("failed to get file info, %w", err)
+	}
+	return stat.Size() > InlineLimit, nil
+}
+
+// UploadLargeFile - функция для загрузки файла на сервер зашифрованными частями. После успешной загрузки в данные
+// пользователя устанавливается манифест вложения. Части шифруются конвергентным ключом, полученным из мастер пароля masterPass.
+func UploadLargeFile(ctx context.Context, client *resty.Client, urls attachment.URLs, masterPass string, dataInfo *DataInfo,
+	progress attachment.Progress) error {
+	fileType, err := binary.GetFileType(dataInfo.Path)
+	if err != nil {
+		return fmt.Errorf("error getting file type, %w", err)
+	}
+	att, err := attachment.New(dataInfo.Path, masterPass)
+	if err != nil {
+		return fmt.Errorf("failed to create attachment, %w", err)
+	}
+	if err := attachment.Upload(ctx, client, urls, att, dataInfo.Path, progress); err != nil {
+		return fmt.Errorf("failed to upload attachment, %w", err)
+	}
+
+	dataInfo.Binary = data.Binary{Type: fileType, Attachment: att}
+	return nil
 }
 
-// JSONEncode - функция для сериализации данных банковской карты.
+// JSONEncode - функция для сериализации бинарных данных. Если файл уже загружен на сервер частями,
+// содержимое файла повторно не читается.
 func JSONEncode(dataInfo *DataInfo) (*repoData.Data, error) {
 	// Проверка валидности введенных данных
 	err := validateCardData(dataInfo)
@@ -48,9 +92,11 @@ func JSONEncode(dataInfo *DataInfo) (*repoData.Data, error) {
 	}
 
 	// преобразую содержимое файла в бинарный вид
-	err = parseFile(dataInfo)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse file to binary, %w", err)
+	if dataInfo.Binary.Attachment == nil {
+		err = parseFile(dataInfo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse file to binary, %w", err)
+		}
 	}
 
 	// сериализую данные типа "BINARY"
@@ -60,7 +106,7 @@ func JSONEncode(dataInfo *DataInfo) (*repoData.Data, error) {
 	}
 
 	// Создаю структуру типа data.Data
-	return &repoData.Data{
+	userData := &repoData.Data{
 		Data:       buf.Bytes(),
 		Type:       repoData.BINARY,
 		Name:       dataInfo.Name,
@@ -68,7 +114,9 @@ func JSONEncode(dataInfo *DataInfo) (*repoData.Data, error) {
 		Status:     repoData.NEW,
 		CreateDate: dataInfo.CreateDate,
 		EditDate:   dataInfo.EditDate,
-	}, nil
+	}
+	dataInfo.Organize.Apply(userData)
+	return userData, nil
 }
 
 // validateCardData - функция для проверки корректности установленных бинарных данных.
@@ -1,9 +1,13 @@
 package binary
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -38,3 +42,43 @@ func TestParseFile(t *testing.T) {
 		require.Error(t, err)
 	}
 }
+
+func TestIsLarge(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small")
+	require.NoError(t, os.WriteFile(small, []byte("some data"), 0o600))
+	large := filepath.Join(dir, "large")
+	require.NoError(t, os.WriteFile(large, make([]byte, InlineLimit+1), 0o600))
+
+	ok, err := IsLarge(&DataInfo{Name: "name", Path: small})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = IsLarge(&DataInfo{Name: "name", Path: large})
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Имя данных не задано
+	_, err = IsLarge(&DataInfo{Path: large})
+	assert.Error(t, err)
+
+	// Файл не существует
+	_, err = IsLarge(&DataInfo{Name: "name", Path: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
+
+func TestJSONEncodeAttachment(t *testing.T) {
+	// Файл уже загружен на сервер частями, содержимое файла не читается
+	att := &data.Attachment{ID: "attachment", Size: 10}
+	userData, err := JSONEncode(&DataInfo{
+		Name:   "name",
+		Path:   "not-exist-file.txt",
+		Binary: data.Binary{Type: "application/pdf", Attachment: att},
+	})
+	require.NoError(t, err)
+
+	var b data.Binary
+	require.NoError(t, json.Unmarshal(userData.Data, &b))
+	assert.Empty(t, b.Binary)
+	assert.Equal(t, att, b.Attachment)
+}
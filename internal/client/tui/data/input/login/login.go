@@ -0,0 +1,95 @@
+package login
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/totp"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/organize"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/rivo/tview"
+)
+
+// DataInfo - вспомогательная структура для передачи полученных от пользоавтеля данных в функцию сохранения данных в сервисе.
+type DataInfo struct {
+	Login      data.Login
+	URLs       string // адреса, по одному в строке
+	Fields     string // дополнительные поля в виде "[тип:]имя=значение", по одному в строке
+	MetaInfo   string
+	Name       string
+	CreateDate time.Time
+	EditDate   time.Time
+	Organize   organize.Info // теги, папка и отметка избранного
+}
+
+// Fields - функция для заполения данных учетной записи пользователем.
+func Fields(form *tview.Form, dataInfo *DataInfo) {
+	form.AddInputField("Имя данных", "", 20, nil, func(text string) { dataInfo.Name = text })
+	form.AddInputField("Логин", "", 20, nil, func(text string) { dataInfo.Login.Login = text })
+	form.AddPasswordField("Пароль", "", 20, '*', func(text string) { dataInfo.Login.Password = text })
+	form.AddTextArea("URL", "", 40, 2, 0, func(text string) { dataInfo.URLs = text })
+	form.AddTextArea("Заметки", "", 40, 3, 0, func(text string) { dataInfo.Login.Notes = text })
+	form.AddPasswordField("Секрет TOTP", "", 40, '*', func(text string) { dataInfo.Login.TOTP = text })
+	form.AddTextArea("Доп. поля", "", 40, 3, 0, func(text string) { dataInfo.Fields = text })
+	form.AddInputField("Описание", "", 20, nil, func(text string) { dataInfo.MetaInfo = text })
+	organize.Fields(form, &dataInfo.Organize)
+}
+
+// JSONEncode - функция для сериализации учетной записи. Секрет TOTP и дополнительные поля проверяются
+// перед сохранением.
+func JSONEncode(dataInfo *DataInfo) (*repoData.Data, error) {
+	// Проверка валидности введенных данных
+	err := validateLoginData(dataInfo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid login data, %w", err)
+	}
+
+	login := dataInfo.Login
+	login.TOTP = strings.TrimSpace(login.TOTP)
+	login.URLs = nil
+	for _, u := range strings.Split(dataInfo.URLs, "\n") {
+		if u = strings.TrimSpace(u); u != "" {
+			login.URLs = append(login.URLs, u)
+		}
+	}
+	if login.Fields, err = data.ParseFields(dataInfo.Fields); err != nil {
+		return nil, fmt.Errorf("invalid login data, %w", err)
+	}
+
+	// сериализую данные типа "LOGIN"
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(login); err != nil {
+		return nil, fmt.Errorf("encode data error, %w", err)
+	}
+
+	// Создаю структуру типа data.Data
+	userData := &repoData.Data{
+		Data:       buf.Bytes(),
+		Type:       repoData.LOGIN,
+		Name:       dataInfo.Name,
+		Metainfo:   dataInfo.MetaInfo,
+		Status:     repoData.NEW,
+		CreateDate: dataInfo.CreateDate,
+		EditDate:   dataInfo.EditDate,
+	}
+	dataInfo.Organize.Apply(userData)
+	return userData, nil
+}
+
+// validateLoginData - функция для проверки корректности установленных данных учетной записи.
+func validateLoginData(dataInfo *DataInfo) error {
+	if dataInfo.Name == "" {
+		return fmt.Errorf("name can't be empty")
+	}
+	if strings.TrimSpace(dataInfo.Login.TOTP) != "" {
+		if _, err := totp.Parse(dataInfo.Login.TOTP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package login
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/totp"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONEncode(t *testing.T) {
+	dataInfo := &DataInfo{
+		Login:  data.Login{Login: "admin", Password: "secret", Notes: "note", TOTP: " JBSWY3DPEHPK3PXP "},
+		URLs:   "https://example.com\n\n https://login.example.com \n",
+		Fields: "hidden:pin=1234\nboolean:admin=1",
+		Name:   "example",
+	}
+	dataInfo.Organize.Tags = "work"
+
+	userData, err := JSONEncode(dataInfo)
+	require.NoError(t, err)
+	assert.Equal(t, repoData.LOGIN, userData.Type)
+	assert.Equal(t, "example", userData.Name)
+	assert.Equal(t, []string{"work"}, userData.Tags)
+
+	var l data.Login
+	require.NoError(t, json.Unmarshal(userData.Data, &l))
+	assert.Equal(t, data.Login{
+		Login:    "admin",
+		Password: "secret",
+		URLs:     []string{"https://example.com", "https://login.example.com"},
+		Notes:    "note",
+		TOTP:     "JBSWY3DPEHPK3PXP",
+		Fields: []data.Field{
+			{Name: "pin", Value: "1234", Type: data.FieldHidden},
+			{Name: "admin", Value: "true", Type: data.FieldBoolean},
+		},
+	}, l)
+
+	// Пустое имя данных
+	_, err = JSONEncode(&DataInfo{})
+	assert.Error(t, err)
+
+	// Некорректный секрет TOTP
+	_, err = JSONEncode(&DataInfo{Name: "example", Login: data.Login{TOTP: "not a secret!"}})
+	assert.ErrorIs(t, err, totp.ErrInvalidSecret)
+
+	// Некорректное дополнительное поле
+	_, err = JSONEncode(&DataInfo{Name: "example", Fields: "boolean:admin=maybe"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,31 @@
+// Пакет organize содержит общие для всех типов данных поля формы для организации данных пользователя:
+// теги, папку и отметку избранного.
+package organize
+
+import (
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/rivo/tview"
+)
+
+// Info - введенные пользователем теги, папка и отметка избранного.
+type Info struct {
+	Tags     string // теги через запятую
+	Folder   string // путь папки с разделителем "/"
+	Favorite bool
+}
+
+// Fields - функция для добавления в форму полей тегов, папки и отметки избранного.
+func Fields(form *tview.Form, info *Info) {
+	form.AddInputField("Теги", "", 30, nil, func(text string) { info.Tags = text })
+	form.AddInputField("Папка", "", 30, nil, func(text string) { info.Folder = text })
+	form.AddCheckbox("Избранное", false, func(checked bool) { info.Favorite = checked })
+}
+
+// Apply - метод для установки тегов, папки и отметки избранного в данные пользователя.
+func (i *Info) Apply(userData *repoData.Data) {
+	userData.Tags = data.ParseTags(i.Tags)
+	userData.Folder = data.CleanFolder(i.Folder)
+	userData.Favorite = i.Favorite
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/organize"
 	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
 
 	"github.com/rivo/tview"
@@ -19,6 +20,7 @@ type DataInfo struct {
 	Name       string
 	CreateDate time.Time
 	EditDate   time.Time
+	Organize   organize.Info // теги, папка и отметка избранного
 }
 
 // Fields - функция для заполения данных пароля пользователем.
@@ -27,6 +29,7 @@ func Fields(form *tview.Form, dataInfo *DataInfo) {
 	form.AddInputField("Логин", "", 20, nil, func(text string) { dataInfo.Pass.Login = text })
 	form.AddPasswordField("Пароль", "", 20, '*', func(text string) { dataInfo.Pass.Password = text })
 	form.AddInputField("Описание", "", 20, nil, func(text string) { dataInfo.MetaInfo = text })
+	organize.Fields(form, &dataInfo.Organize)
 }
 
 // JSONEncode - функция для сериализации данных банковской карты.
@@ -44,7 +47,7 @@ func JSONEncode(dataInfo *DataInfo) (*repoData.Data, error) {
 	}
 
 	// Создаю структуру типа data.Data
-	return &repoData.Data{
+	userData := &repoData.Data{
 		Data:       buf.Bytes(),
 		Type:       repoData.PASSWORD,
 		Name:       dataInfo.Name,
@@ -52,7 +55,9 @@ func JSONEncode(dataInfo *DataInfo) (*repoData.Data, error) {
 		Status:     repoData.NEW,
 		CreateDate: dataInfo.CreateDate,
 		EditDate:   dataInfo.EditDate,
-	}, nil
+	}
+	dataInfo.Organize.Apply(userData)
+	return userData, nil
 }
 
 // validateCardData - функция для проверки корректности установленных данных пароля.
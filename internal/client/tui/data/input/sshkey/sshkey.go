@@ -0,0 +1,94 @@
+package sshkey
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/organize"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/rivo/tview"
+)
+
+// DataInfo - вспомогательная структура для передачи полученных от пользоавтеля данных в функцию сохранения данных в сервисе.
+type DataInfo struct {
+	KeyPath    string // путь к закрытому ключу
+	CertPath   string // путь к сертификату ключа, необязательный
+	Passphrase string
+	Comment    string
+	MetaInfo   string
+	Name       string
+	CreateDate time.Time
+	EditDate   time.Time
+	Organize   organize.Info // теги, папка и отметка избранного
+}
+
+// Fields - функция для заполения данных SSH ключа пользователем.
+func Fields(form *tview.Form, dataInfo *DataInfo) {
+	form.AddInputField("Имя данных", "", 20, nil, func(text string) { dataInfo.Name = text })
+	form.AddInputField("Путь к ключу", "", 40, nil, func(text string) { dataInfo.KeyPath = text })
+	form.AddPasswordField("Парольная фраза", "", 20, '*', func(text string) { dataInfo.Passphrase = text })
+	form.AddInputField("Комментарий", "", 20, nil, func(text string) { dataInfo.Comment = text })
+	form.AddInputField("Путь к сертификату", "", 40, nil, func(text string) { dataInfo.CertPath = text })
+	form.AddInputField("Описание", "", 20, nil, func(text string) { dataInfo.MetaInfo = text })
+	organize.Fields(form, &dataInfo.Organize)
+}
+
+// JSONEncode - функция для сериализации SSH ключа. Закрытый ключ и сертификат читаются из файлов и проверяются
+// перед сохранением, открытый ключ и отпечаток вычисляются из закрытого ключа.
+func JSONEncode(dataInfo *DataInfo) (*repoData.Data, error) {
+	// Проверка валидности введенных данных
+	err := validateSSHKeyData(dataInfo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh key data, %w", err)
+	}
+
+	privateKey, err := os.ReadFile(dataInfo.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key, %w", err)
+	}
+	var certificate []byte
+	if dataInfo.CertPath != "" {
+		if certificate, err = os.ReadFile(dataInfo.CertPath); err != nil {
+			return nil, fmt.Errorf("failed to read certificate, %w", err)
+		}
+	}
+	key, err := data.NewSSHKey(string(privateKey), dataInfo.Passphrase, dataInfo.Comment, string(certificate))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh key data, %w", err)
+	}
+
+	// сериализую данные типа "SSHKEY"
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(key); err != nil {
+		return nil, fmt.Errorf("encode data error, %w", err)
+	}
+
+	// Создаю структуру типа data.Data
+	userData := &repoData.Data{
+		Data:       buf.Bytes(),
+		Type:       repoData.SSHKEY,
+		Name:       dataInfo.Name,
+		Metainfo:   dataInfo.MetaInfo,
+		Status:     repoData.NEW,
+		CreateDate: dataInfo.CreateDate,
+		EditDate:   dataInfo.EditDate,
+	}
+	dataInfo.Organize.Apply(userData)
+	return userData, nil
+}
+
+// validateSSHKeyData - функция для проверки корректности установленных данных SSH ключа.
+func validateSSHKeyData(dataInfo *DataInfo) error {
+	if dataInfo.Name == "" {
+		return fmt.Errorf("name can't be empty")
+	}
+	if dataInfo.KeyPath == "" {
+		return fmt.Errorf("path to private key can't be empty")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package sshkey
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestJSONEncode(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte("secret"))
+	require.NoError(t, err)
+	sshPub, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "id_ed25519")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(block), 0600))
+
+	dataInfo := &DataInfo{KeyPath: keyPath, Passphrase: "secret", Comment: "user@host", Name: "github"}
+	dataInfo.Organize.Folder = "ssh"
+	userData, err := JSONEncode(dataInfo)
+	require.NoError(t, err)
+	assert.Equal(t, repoData.SSHKEY, userData.Type)
+	assert.Equal(t, "github", userData.Name)
+	assert.Equal(t, "ssh", userData.Folder)
+
+	var key data.SSHKey
+	require.NoError(t, json.Unmarshal(userData.Data, &key))
+	assert.Equal(t, ssh.FingerprintSHA256(sshPub), key.Fingerprint)
+	assert.Equal(t, "user@host", key.Comment)
+	assert.Equal(t, "secret", key.Passphrase)
+
+	// Пустое имя данных и путь к ключу
+	_, err = JSONEncode(&DataInfo{KeyPath: keyPath})
+	assert.Error(t, err)
+	_, err = JSONEncode(&DataInfo{Name: "github"})
+	assert.Error(t, err)
+
+	// Неверная парольная фраза
+	_, err = JSONEncode(&DataInfo{Name: "github", KeyPath: keyPath, Passphrase: "wrong"})
+	assert.Error(t, err)
+
+	// Несуществующий сертификат
+	_, err = JSONEncode(&DataInfo{Name: "github", KeyPath: keyPath, Passphrase: "secret", CertPath: filepath.Join(dir, "cert")})
+	assert.Error(t, err)
+}
@@ -6,12 +6,25 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
 	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/data/input/organize"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/tools/editor"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/tools/markdown"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/tools/printer"
 	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
 
 	"github.com/rivo/tview"
+	"go.uber.org/zap"
 )
 
+// MaxTextSize - максимальный размер текста заметки в байтах.
+const MaxTextSize = 64 * 1024
+
+// textLabel - название поля ввода текста.
+const textLabel = "Текст"
+
 // DataInfo - вспомогательная структура для передачи полученных от пользоавтеля данных в функцию сохранения данных в сервисе.
 type DataInfo struct {
 	Text       data.Text
@@ -19,13 +32,47 @@ type DataInfo struct {
 	Name       string
 	CreateDate time.Time
 	EditDate   time.Time
+	Organize   organize.Info // теги, папка и отметка избранного
 }
 
-// Fields - функция для заполения данных банковской карты пользователем.
+// Fields - функция для заполения текстовых данных пользователем. Текст вводится в многострочном поле
+// и поддерживает разметку Markdown.
 func Fields(form *tview.Form, dataInfo *DataInfo) {
 	form.AddInputField("Имя данных", "", 20, nil, func(text string) { dataInfo.Name = text })
-	form.AddInputField("Текст", "", 100, nil, func(text string) { dataInfo.Text.Text = text })
+	form.AddTextArea(textLabel, "", 100, 10, MaxTextSize, func(text string) { dataInfo.Text.Text = text })
 	form.AddInputField("Описание", "", 20, nil, func(text string) { dataInfo.MetaInfo = text })
+	organize.Fields(form, &dataInfo.Organize)
+}
+
+// Buttons - функция для добавления кнопок редактирования текста во внешнем редакторе и предпросмотра Markdown.
+func Buttons(app *app.App, form *tview.Form, dataInfo *DataInfo) {
+	form.AddButton("Редактор", func() {
+		area, ok := form.GetFormItemByLabel(textLabel).(*tview.TextArea)
+		if !ok {
+			return
+		}
+
+		// Интерфейс приостанавливается, пока пользователь работает в редакторе
+		var text string
+		var err error
+		app.App.Suspend(func() {
+			text, err = editor.Edit(dataInfo.Text.Text, editor.Run)
+		})
+		if err != nil {
+			logger.ClientLog.Error("edit text error", zap.String("error", error.Error(err)))
+			printer.Error(app, fmt.Sprintf("edit text error, %v", err))
+			return
+		}
+		if len(text) > MaxTextSize {
+			printer.Error(app, fmt.Sprintf("text is too large, %d bytes, maximum %d", len(text), MaxTextSize))
+			return
+		}
+		area.SetText(text, false)
+		dataInfo.Text.Text = text
+	})
+	form.AddButton("Предпросмотр", func() {
+		markdown.Show(app, dataInfo.Name, dataInfo.Text.Text)
+	})
 }
 
 // JSONEncode - функция для сериализации текстовых данных.
@@ -43,7 +90,7 @@ func JSONEncode(dataInfo *DataInfo) (*repoData.Data, error) {
 	}
 
 	// Создаю структуру типа data.Data
-	return &repoData.Data{
+	userData := &repoData.Data{
 		Data:       buf.Bytes(),
 		Type:       repoData.TEXT,
 		Name:       dataInfo.Name,
@@ -51,7 +98,9 @@ func JSONEncode(dataInfo *DataInfo) (*repoData.Data, error) {
 		Status:     repoData.NEW,
 		CreateDate: dataInfo.CreateDate,
 		EditDate:   dataInfo.EditDate,
-	}, nil
+	}
+	dataInfo.Organize.Apply(userData)
+	return userData, nil
 }
 
 // validateCardData - функция для проверки корректности установленных текстовых данных.
@@ -60,5 +109,9 @@ func validateCardData(dataInfo *DataInfo) error {
 	if dataInfo.Name == "" {
 		return fmt.Errorf("name can't be empty")
 	}
+	// Проверка размера текста
+	if len(dataInfo.Text.Text) > MaxTextSize {
+		return fmt.Errorf("text is too large, %d bytes, maximum %d", len(dataInfo.Text.Text), MaxTextSize)
+	}
 	return nil
 }
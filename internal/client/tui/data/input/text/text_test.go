@@ -0,0 +1,35 @@
+package text
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONEncode(t *testing.T) {
+	note := "# Recovery codes\n\n- [ ] 1111-2222\n- [x] 3333-4444\n"
+	userData, err := JSONEncode(&DataInfo{Text: data.Text{Text: note}, Name: "github codes"})
+	require.NoError(t, err)
+	assert.Equal(t, repoData.TEXT, userData.Type)
+
+	var text data.Text
+	require.NoError(t, json.Unmarshal(userData.Data, &text))
+	assert.Equal(t, note, text.Text)
+
+	t.Run("empty name", func(t *testing.T) {
+		_, err := JSONEncode(&DataInfo{Text: data.Text{Text: note}})
+		assert.Error(t, err)
+	})
+	t.Run("max size", func(t *testing.T) {
+		_, err := JSONEncode(&DataInfo{Text: data.Text{Text: strings.Repeat("a", MaxTextSize)}, Name: "big"})
+		assert.NoError(t, err)
+		_, err = JSONEncode(&DataInfo{Text: data.Text{Text: strings.Repeat("a", MaxTextSize+1)}, Name: "big"})
+		assert.Error(t, err)
+	})
+}
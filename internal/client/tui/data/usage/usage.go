@@ -0,0 +1,60 @@
+// Пакет usage содержит TUI страницу с информацией об использовании хранилища сервера и квотах пользователя.
+package usage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/handlers"
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/tools/printer"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rivo/tview"
+	"go.uber.org/zap"
+)
+
+// Page - TUI страница с информацией об использовании хранилища сервера. url - адрес ресурса использования хранилища на сервере.
+func Page(ctx context.Context, url string, client *resty.Client) func(app *app.App) tview.Primitive {
+	return func(app *app.App) tview.Primitive {
+		form := tview.NewForm()
+		form.AddTextView("Использование", "нажмите \"Обновить\"", 40, 4, false, false)
+		view, _ := form.GetFormItem(0).(*tview.TextView)
+
+		form.AddButton("Обновить", func() {
+			// Запрос к серверу выполняю в отдельной горутине, чтобы не блокировать интерфейс
+			go func() {
+				usage, err := handlers.GetUsage(ctx, url, client)
+				if err != nil {
+					logger.ClientLog.Error("get usage error", zap.String("error", error.Error(err)))
+					printer.Error(app, fmt.Sprintf("get usage error, %v", err))
+					return
+				}
+				app.App.QueueUpdateDraw(func() { view.SetText(Format(usage)) })
+			}()
+		})
+		form.AddButton("Назад", func() { app.SwitchTo(tui.Data) })
+
+		form.SetBorder(true).SetTitle("Использование хранилища")
+		return form
+	}
+}
+
+// Format - функция для представления информации об использовании хранилища в виде текста.
+func Format(usage data.Usage) string {
+	return fmt.Sprintf("Объем: %s\nЗаписи: %s\nВерсий одной записи: %s",
+		formatLimit(usage.Bytes, usage.MaxBytes, " байт"),
+		formatLimit(int64(usage.Records), int64(usage.MaxRecords), ""),
+		formatLimit(int64(usage.Versions), int64(usage.MaxVersions), ""))
+}
+
+// formatLimit - функция для представления использованного значения и ограничения. Нулевое ограничение означает его отсутствие.
+func formatLimit(used, limit int64, unit string) string {
+	if limit <= 0 {
+		return fmt.Sprintf("%d%s (без ограничений)", used, unit)
+	}
+	return fmt.Sprintf("%d из %d%s (%d%%)", used, limit, unit, used*100/limit)
+}
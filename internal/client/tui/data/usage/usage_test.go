@@ -0,0 +1,53 @@
+package usage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPage(t *testing.T) {
+	// Создаем тестовое приложение
+	testApp := &app.App{}
+
+	page := Page(context.Background(), "some/url", nil)(testApp)
+
+	// Проверяем, что это форма
+	form, ok := page.(*tview.Form)
+	assert.True(t, ok, "Page must return *tview.Form")
+
+	assert.Equal(t, 1, form.GetFormItemCount())
+	assert.Equal(t, "Использование", form.GetFormItem(0).GetLabel())
+
+	assert.Equal(t, "Обновить", form.GetButton(0).GetLabel())
+	assert.Equal(t, "Назад", form.GetButton(1).GetLabel())
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		usage data.Usage
+		want  string
+	}{
+		{
+			name:  "without limits",
+			usage: data.Usage{Bytes: 10, Records: 2, Versions: 1},
+			want:  "Объем: 10 байт (без ограничений)\nЗаписи: 2 (без ограничений)\nВерсий одной записи: 1 (без ограничений)",
+		},
+		{
+			name:  "with limits",
+			usage: data.Usage{Bytes: 50, Records: 2, Versions: 1, MaxBytes: 200, MaxRecords: 10, MaxVersions: 4},
+			want:  "Объем: 50 из 200 байт (25%)\nЗаписи: 2 из 10 (20%)\nВерсий одной записи: 1 из 4 (25%)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Format(tt.usage))
+		})
+	}
+}
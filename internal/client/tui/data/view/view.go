@@ -5,34 +5,150 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/abezemskiy/gophkeeper/internal/client/storage"
 	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/totp"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/tools/markdown"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/tools/printer"
 	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+	"golang.org/x/crypto/ssh"
 )
 
-// Page - страница отображения данных пользователя.
-func Page(_ context.Context, decrData storage.IStorage) func(app *app.App) tview.Primitive {
+// pageSize - количество данных на одной странице списка.
+const pageSize = 50
+
+// Варианты фильтров по типу и статусу данных. Первый вариант означает отсутствие фильтра,
+// индекс остальных вариантов на единицу больше значения константы типа или статуса.
+var (
+	typeOptions   = []string{allOption, "PASSWORD", "TEXT", "BINARY", "BANKCARD", "LOGIN", "SSHKEY"}
+	statusOptions = []string{allOption, "NEW", "SAVED", "CHANGED", "CONFLICT"}
+)
+
+// Подписи элементов страницы.
+const (
+	allOption    = "ALL" // вариант фильтра без ограничения
+	favoriteMark = "★ "  // отметка избранных данных
+)
+
+// Page - страница отображения данных пользователя. Список данных фильтруется по мере ввода поискового запроса,
+// по типу, статусу и тегу данных, а также по папке или избранному, выбранным в дереве папок. Список выводится
+// постранично, страницы переключаются клавишами PgUp и PgDn. Клавиша Ctrl+P открывает предпросмотр Markdown
+// последней версии выбранного текста.
+func Page(ctx context.Context, decrData storage.IStorage) func(app *app.App) tview.Primitive {
 	return func(app *app.App) tview.Primitive {
 		// Создаю элементы интерфейса
+		search := tview.NewInputField().SetLabel("Поиск: ")
+		typeFilter := tview.NewDropDown().SetLabel("Тип: ").SetOptions(typeOptions, nil).SetCurrentOption(0)
+		statusFilter := tview.NewDropDown().SetLabel("Статус: ").SetOptions(statusOptions, nil).SetCurrentOption(0)
+		tagFilter := tview.NewDropDown().SetLabel("Тег: ")
+		tree := tview.NewTreeView()
 		list := tview.NewList()
 		table := tview.NewTable().SetBorders(true)
+		info := tview.NewTextView()
 
-		// Кнопка "Обновить" для обновления данных на странице
-		updateFunc := func() {
+		// Текущая страница списка, количество найденных данных, выбранная в дереве папка и отображаемые в таблице данные
+		page, total := 0, 0
+		var selected folderRef
+		var shown []repoData.Data
+
+		// current - функция для получения выбранных на странице фильтров
+		current := func() filter {
+			f := filter{text: search.GetText(), folder: selected.path, favorite: selected.favorite}
+			f.typeIdx, _ = typeFilter.GetCurrentOption()
+			f.statusIdx, _ = statusFilter.GetCurrentOption()
+			if idx, tag := tagFilter.GetCurrentOption(); idx > 0 {
+				f.tag = tag
+			}
+			return f
+		}
+
+		// render - функция для заполнения списка текущей страницей результатов поиска
+		render := func() {
 			list.Clear()
 			table.Clear()
+			shown = nil
+
+			res := decrData.Search(current().query(page))
+			total = res.Total
 
-			// Извлекаю данные пользователя из inmemory хранилища
-			data := decrData.GetAll()
+			for i, versions := range res.Items {
+				if len(versions) == 0 {
+					continue
+				}
+				name := versions[0].Name
+				if versions[0].Favorite {
+					name = favoriteMark + name
+				}
+				secondary := option(typeOptions, versions[0].Type) + ", " + option(statusOptions, res.Statuses[i])
+				list.AddItem(name, secondary, 0, func() {
+					shown = versions
+					err := updateTable(table, versions)
+					if err != nil {
+						printer.Message(app, fmt.Errorf("failed to update table, %w", err).Error())
+					}
+				})
+			}
+			info.SetText(fmt.Sprintf("Найдено: %d, страница %d из %d", total, page+1, pages(total)))
+		}
+
+		// setPage - функция для перехода на страницу с номером p, если она существует
+		setPage := func(p int) {
+			if p < 0 || p >= pages(total) {
+				return
+			}
+			page = p
+			render()
+		}
+
+		// Любое изменение запроса или фильтров возвращает список на первую страницу
+		resetPage := func() {
+			page = 0
+			render()
+		}
+		search.SetChangedFunc(func(string) { resetPage() })
+		typeFilter.SetSelectedFunc(func(string, int) { resetPage() })
+		statusFilter.SetSelectedFunc(func(string, int) { resetPage() })
+		tree.SetChangedFunc(func(node *tview.TreeNode) {
+			if ref, ok := node.GetReference().(folderRef); ok {
+				selected = ref
+				resetPage()
+			}
+		})
 
-			if len(data) == 0 {
+		// refreshOrganization - функция для обновления дерева папок и списка тегов. Выбранные папка и тег
+		// сохраняются, если они по-прежнему существуют.
+		refreshOrganization := func() {
+			root := buildTree(decrData.Folders())
+			tree.SetRoot(root).SetCurrentNode(root)
+			root.Walk(func(node, _ *tview.TreeNode) bool {
+				if node.GetReference() == selected {
+					tree.SetCurrentNode(node)
+				}
+				return true
+			})
+			selected, _ = tree.GetCurrentNode().GetReference().(folderRef)
+
+			_, tag := tagFilter.GetCurrentOption()
+			options := append([]string{allOption}, decrData.Tags()...)
+			tagFilter.SetOptions(options, nil).SetCurrentOption(max(slices.Index(options, tag), 0))
+			tagFilter.SetSelectedFunc(func(string, int) { resetPage() })
+		}
+		refreshOrganization()
+
+		// Кнопка "Обновить" для обновления данных на странице
+		updateFunc := func() {
+			refreshOrganization()
+			resetPage()
+			if total == 0 && current().empty() {
 				go func() {
 					app.App.QueueUpdateDraw(func() {
 						printer.Message(app, "data not added yet")
@@ -40,33 +156,48 @@ func Page(_ context.Context, decrData storage.IStorage) func(app *app.App) tview
 				}()
 				return
 			}
+			// Устанавливаем фокус на список данных
+			app.App.SetFocus(list)
+		}
 
-			// Заполнение списка имен данных
-			//var firstVersions []repoData.Data
-			for i, versions := range data {
-				if len(versions) > 0 {
-					name := versions[0].Name
-					list.AddItem(name, "", rune('a'+i), func() {
-						err := updateTable(table, versions)
-						if err != nil {
-							printer.Message(app, fmt.Errorf("failed to update table, %w", err).Error())
+		// Код TOTP отображаемой учетной записи обновляется каждую секунду. Данные таблицы проверяются
+		// в потоке интерфейса, поэтому доступ к ним не требует синхронизации.
+		go func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					app.App.QueueUpdate(func() {
+						if hasTOTP(shown) && updateTable(table, shown) == nil {
+							app.App.ForceDraw()
 						}
 					})
 				}
 			}
-			// Устанавливаем фокус на список данных
-			app.App.SetFocus(list)
-		}
+		}()
 
 		// Кнопки
 		backButton := tview.NewButton("Назад")
 		updateButton := tview.NewButton("Обновить")
 
-		// Контейнер с двумя панелями и кнопками
+		// Контейнер с панелью фильтров, деревом папок, двумя панелями данных и кнопками
+		filters := tview.NewFlex().
+			SetDirection(tview.FlexColumn).
+			AddItem(search, 0, 2, false).
+			AddItem(typeFilter, 20, 1, false).
+			AddItem(statusFilter, 20, 1, false).
+			AddItem(tagFilter, 20, 1, false)
+
 		flex := tview.NewFlex().
 			SetDirection(tview.FlexRow).
-			AddItem(tview.NewFlex().AddItem(list, 30, 1, true).
-				AddItem(table, 0, 2, false), 0, 1, true)
+			AddItem(filters, 1, 1, false).
+			AddItem(tview.NewFlex().AddItem(tree, 25, 1, false).
+				AddItem(list, 30, 1, true).
+				AddItem(table, 0, 2, false), 0, 1, true).
+			AddItem(info, 1, 1, false)
 
 		buttons := tview.NewFlex().
 			SetDirection(tview.FlexColumn).
@@ -81,24 +212,35 @@ func Page(_ context.Context, decrData storage.IStorage) func(app *app.App) tview
 		// цвет фона для выделенного элемента списка
 		list.SetSelectedBackgroundColor(tcell.ColorBlue)
 
-		// Переключение фокуса с помощью Tab
+		// Порядок переключения фокуса с помощью Tab
+		focusOrder := []tview.Primitive{search, typeFilter, statusFilter, tagFilter, tree, list, updateButton, backButton}
+
 		flex.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 			switch event.Key() {
 			case tcell.KeyTab: // Циклический переход фокуса между элементами
-				switch app.App.GetFocus() {
-				case updateButton:
-					app.App.SetFocus(backButton)
-				case backButton:
-					app.App.SetFocus(list)
-				case list:
-					app.App.SetFocus(updateButton)
+				for i, p := range focusOrder {
+					if app.App.GetFocus() == p {
+						app.App.SetFocus(focusOrder[(i+1)%len(focusOrder)])
+						return nil
+					}
 				}
+			case tcell.KeyPgDn: // Следующая страница списка
+				setPage(page + 1)
+				return nil
+			case tcell.KeyPgUp: // Предыдущая страница списка
+				setPage(page - 1)
+				return nil
 			case tcell.KeyEnter: // Обработка нажатий кнопок
 				if app.App.GetFocus() == updateButton {
 					updateFunc()
 				} else if app.App.GetFocus() == backButton {
 					app.Pages.SwitchToPage(tui.Data)
 				}
+			case tcell.KeyCtrlP: // Предпросмотр Markdown текста
+				if text, ok := latestText(shown); ok {
+					markdown.Show(app, "Предпросмотр", text)
+					return nil
+				}
 			case tcell.KeyEsc: // Выход на предыдущую страницу
 				app.Pages.SwitchToPage(tui.Data)
 			}
@@ -109,6 +251,81 @@ func Page(_ context.Context, decrData storage.IStorage) func(app *app.App) tview
 	}
 }
 
+// filter - фильтры, выбранные на странице.
+type filter struct {
+	text      string
+	typeIdx   int // индекс варианта в typeOptions
+	statusIdx int // индекс варианта в statusOptions
+	tag       string
+	folder    string
+	favorite  bool
+}
+
+// query - метод для создания поискового запроса по фильтрам для страницы списка с номером page.
+func (f filter) query(page int) storage.Query {
+	q := storage.Query{Text: f.text, Folder: f.folder, Favorite: f.favorite, Offset: page * pageSize, Limit: pageSize}
+	if f.typeIdx > 0 {
+		q.Types = []int{f.typeIdx - 1}
+	}
+	if f.statusIdx > 0 {
+		q.Statuses = []int{f.statusIdx - 1}
+	}
+	if f.tag != "" {
+		q.Tags = []string{f.tag}
+	}
+	return q
+}
+
+// empty - метод для проверки, что фильтры не ограничивают список данных.
+func (f filter) empty() bool {
+	return f.text == "" && f.typeIdx <= 0 && f.statusIdx <= 0 && f.tag == "" && f.folder == "" && !f.favorite
+}
+
+// folderRef - папка или избранное, выбранные в дереве папок.
+type folderRef struct {
+	path     string // путь папки, пустой путь - все данные
+	favorite bool
+}
+
+// buildTree - функция для построения дерева папок. Корень дерева означает все данные, первый дочерний узел -
+// избранное. folders - отсортированный список папок, включая родительские.
+func buildTree(folders []string) *tview.TreeNode {
+	root := tview.NewTreeNode("Все данные").SetReference(folderRef{})
+	root.AddChild(tview.NewTreeNode(favoriteMark + "Избранное").SetReference(folderRef{favorite: true}))
+
+	nodes := map[string]*tview.TreeNode{"": root}
+	for _, folder := range folders {
+		parent, name := "", folder
+		if i := strings.LastIndex(folder, data.FolderSeparator); i >= 0 {
+			parent, name = folder[:i], folder[i+1:]
+		}
+		node := tview.NewTreeNode(name).SetReference(folderRef{path: folder})
+		if p, ok := nodes[parent]; ok {
+			p.AddChild(node)
+		} else {
+			root.AddChild(node)
+		}
+		nodes[folder] = node
+	}
+	return root
+}
+
+// option - функция для получения названия типа или статуса данных по значению константы.
+func option(options []string, value int) string {
+	if value < 0 || value+1 >= len(options) {
+		return fmt.Sprint(value)
+	}
+	return options[value+1]
+}
+
+// pages - функция для получения количества страниц списка. Пустой список занимает одну страницу.
+func pages(total int) int {
+	if total == 0 {
+		return 1
+	}
+	return (total + pageSize - 1) / pageSize
+}
+
 // updateTable - функция для обновления таблицы с версиями данных.
 func updateTable(table *tview.Table, versions []repoData.Data) error {
 	table.Clear()
@@ -132,6 +349,87 @@ func updateTable(table *tview.Table, versions []repoData.Data) error {
 	return nil
 }
 
+// loginString - функция для вывода учетной записи с кодом TOTP, действующим в момент now.
+// Значения скрытых дополнительных полей не выводятся.
+func loginString(l data.Login, now time.Time) string {
+	parts := []string{fmt.Sprintf("Login: %s, Password: %s", l.Login, l.Password)}
+	if len(l.URLs) > 0 {
+		parts = append(parts, "URL: "+strings.Join(l.URLs, ", "))
+	}
+	if l.TOTP != "" {
+		key, err := totp.Parse(l.TOTP)
+		if err != nil {
+			parts = append(parts, "TOTP: invalid secret")
+		} else {
+			parts = append(parts, fmt.Sprintf("TOTP: %s (%ds)", key.Code(now), int(key.Remaining(now).Seconds())))
+		}
+	}
+	for _, f := range l.Fields {
+		value := f.Value
+		if f.Type == data.FieldHidden {
+			value = "******"
+		}
+		parts = append(parts, f.Name+": "+value)
+	}
+	if l.Notes != "" {
+		parts = append(parts, "Notes: "+l.Notes)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sshKeyString - функция для вывода SSH ключа. Закрытый ключ и парольная фраза не выводятся.
+func sshKeyString(k data.SSHKey) string {
+	parts := []string{"Fingerprint: " + k.Fingerprint}
+	if k.Comment != "" {
+		parts = append(parts, "Comment: "+k.Comment)
+	}
+	cert, err := k.Cert()
+	switch {
+	case err != nil:
+		parts = append(parts, "Certificate: invalid")
+	case cert != nil && cert.ValidBefore == ssh.CertTimeInfinity:
+		parts = append(parts, fmt.Sprintf("Certificate: %s, valid forever", cert.KeyId))
+	case cert != nil:
+		parts = append(parts, fmt.Sprintf("Certificate: %s, valid until %s", cert.KeyId,
+			time.Unix(int64(cert.ValidBefore), 0).UTC().Format(time.DateTime)))
+	}
+	return strings.Join(append(parts, "Public key: "+k.PublicKey), ", ")
+}
+
+// textString - функция для вывода текста в ячейке таблицы. У многострочного текста выводится первая строка
+// и количество строк, полный текст доступен в предпросмотре.
+func textString(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) == 1 {
+		return lines[0]
+	}
+	return fmt.Sprintf("%s ... (%d lines, Ctrl+P - preview)", lines[0], len(lines))
+}
+
+// latestText - функция для получения текста последней текстовой версии данных.
+func latestText(versions []repoData.Data) (string, bool) {
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].Type != repoData.TEXT {
+			continue
+		}
+		var t data.Text
+		if err := json.Unmarshal(versions[i].Data, &t); err == nil {
+			return t.Text, true
+		}
+	}
+	return "", false
+}
+
+// hasTOTP - функция для проверки, что среди версий данных есть учетная запись с секретом TOTP.
+func hasTOTP(versions []repoData.Data) bool {
+	for _, v := range versions {
+		if l, err := data.DecodeLogin(v); err == nil && l.TOTP != "" {
+			return true
+		}
+	}
+	return false
+}
+
 // parseData - функция для парсинга данных по типу.
 func parseData(d repoData.Data) (string, error) {
 	switch d.Type {
@@ -148,13 +446,16 @@ func parseData(d repoData.Data) (string, error) {
 		if err != nil {
 			return "", fmt.Errorf("failed to unmarshal data, %w", err)
 		}
-		return t.Text, nil
+		return textString(t.Text), nil
 	case repoData.BINARY:
 		var b data.Binary
 		err := json.Unmarshal(d.Data, &b)
 		if err != nil {
 			return "", fmt.Errorf("failed to unmarshal data, %w", err)
 		}
+		if b.Attachment != nil {
+			return fmt.Sprintf("Binary (%s, %d bytes, stored on server)", b.Type, b.Attachment.Size), nil
+		}
 		return fmt.Sprintf("Binary (%s)", b.Type), nil
 	case repoData.BANKCARD:
 		var b data.Bank
@@ -163,6 +464,18 @@ func parseData(d repoData.Data) (string, error) {
 			return "", fmt.Errorf("failed to unmarshal data, %w", err)
 		}
 		return fmt.Sprintf("Card: %d, Owner: %s", b.Number, b.Owner), nil
+	case repoData.LOGIN:
+		l, err := data.DecodeLogin(d)
+		if err != nil {
+			return "", err
+		}
+		return loginString(l, time.Now()), nil
+	case repoData.SSHKEY:
+		k, err := data.DecodeSSHKey(d)
+		if err != nil {
+			return "", err
+		}
+		return sshKeyString(k), nil
 	default:
 		return "", errors.New("unknown data type")
 	}
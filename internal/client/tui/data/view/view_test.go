@@ -0,0 +1,159 @@
+package view
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/storage"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	repoData "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestFilterQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter filter
+		page   int
+		want   storage.Query
+	}{
+		{
+			name:   "without filters",
+			filter: filter{text: "mail"},
+			want:   storage.Query{Text: "mail", Limit: pageSize},
+		},
+		{
+			name:   "type, status and tag filters",
+			filter: filter{typeIdx: repoData.BANKCARD + 1, statusIdx: repoData.CONFLICT + 1, tag: "work"},
+			page:   2,
+			want: storage.Query{Types: []int{repoData.BANKCARD}, Statuses: []int{repoData.CONFLICT}, Tags: []string{"work"},
+				Offset: 2 * pageSize, Limit: pageSize},
+		},
+		{
+			name:   "folder and favorites",
+			filter: filter{folder: "home/web", favorite: true},
+			want:   storage.Query{Folder: "home/web", Favorite: true, Limit: pageSize},
+		},
+		{
+			name:   "option is not selected",
+			filter: filter{typeIdx: -1, statusIdx: -1},
+			want:   storage.Query{Limit: pageSize},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.query(tt.page))
+		})
+	}
+
+	assert.True(t, filter{typeIdx: -1, statusIdx: 0}.empty())
+	assert.False(t, filter{favorite: true}.empty())
+}
+
+func TestBuildTree(t *testing.T) {
+	root := buildTree([]string{"home", "home/web", "work"})
+	assert.Equal(t, folderRef{}, root.GetReference())
+
+	children := root.GetChildren()
+	require.Len(t, children, 3)
+	assert.Equal(t, folderRef{favorite: true}, children[0].GetReference())
+	assert.Equal(t, "home", children[1].GetText())
+	assert.Equal(t, "work", children[2].GetText())
+
+	sub := children[1].GetChildren()
+	require.Len(t, sub, 1)
+	assert.Equal(t, "web", sub[0].GetText())
+	assert.Equal(t, folderRef{path: "home/web"}, sub[0].GetReference())
+}
+
+func TestPages(t *testing.T) {
+	assert.Equal(t, 1, pages(0))
+	assert.Equal(t, 1, pages(pageSize))
+	assert.Equal(t, 2, pages(pageSize+1))
+}
+
+func TestOption(t *testing.T) {
+	assert.Equal(t, "PASSWORD", option(typeOptions, repoData.PASSWORD))
+	assert.Equal(t, "CONFLICT", option(statusOptions, repoData.CONFLICT))
+	assert.Equal(t, "7", option(typeOptions, 7))
+}
+
+func TestLoginString(t *testing.T) {
+	// Секрет base32 тестового вектора RFC 6238
+	l := data.Login{
+		Login:    "admin",
+		Password: "secret",
+		URLs:     []string{"https://a.example.com", "https://b.example.com"},
+		TOTP:     "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		Fields:   []data.Field{{Name: "pin", Value: "1234", Type: data.FieldHidden}, {Name: "id", Value: "42", Type: data.FieldText}},
+		Notes:    "note",
+	}
+	assert.Equal(t, "Login: admin, Password: secret, URL: https://a.example.com, https://b.example.com, "+
+		"TOTP: 287082 (1s), pin: ******, id: 42, Notes: note", loginString(l, time.Unix(59, 0)))
+
+	assert.Equal(t, "Login: admin, Password: secret, TOTP: invalid secret",
+		loginString(data.Login{Login: "admin", Password: "secret", TOTP: "!"}, time.Unix(59, 0)))
+}
+
+func TestSSHKeyString(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	cert := &ssh.Certificate{Key: signer.PublicKey(), CertType: ssh.UserCert, KeyId: "deploy", ValidBefore: 86400}
+	require.NoError(t, cert.SignCert(rand.Reader, signer))
+
+	k := data.SSHKey{PrivateKey: "private", Passphrase: "secret", PublicKey: "ssh-ed25519 AAAA", Fingerprint: "SHA256:abc"}
+	assert.Equal(t, "Fingerprint: SHA256:abc, Public key: ssh-ed25519 AAAA", sshKeyString(k))
+
+	k.Comment, k.Certificate = "user@host", string(ssh.MarshalAuthorizedKey(cert))
+	assert.Equal(t, "Fingerprint: SHA256:abc, Comment: user@host, Certificate: deploy, valid until 1970-01-02 00:00:00, "+
+		"Public key: ssh-ed25519 AAAA", sshKeyString(k))
+
+	k.Certificate = "not a certificate"
+	assert.Contains(t, sshKeyString(k), "Certificate: invalid")
+}
+
+func TestHasTOTP(t *testing.T) {
+	withTOTP, err := json.Marshal(data.Login{TOTP: "GEZDGNBVGY3TQOJQ"})
+	require.NoError(t, err)
+	withoutTOTP, err := json.Marshal(data.Password{Login: "admin"})
+	require.NoError(t, err)
+
+	assert.True(t, hasTOTP([]repoData.Data{
+		{Type: repoData.PASSWORD, Data: withoutTOTP},
+		{Type: repoData.LOGIN, Data: withTOTP},
+	}))
+	assert.False(t, hasTOTP([]repoData.Data{{Type: repoData.PASSWORD, Data: withoutTOTP}}))
+	assert.False(t, hasTOTP(nil))
+}
+
+func TestTextString(t *testing.T) {
+	assert.Equal(t, "single line", textString("single line\n"))
+	assert.Equal(t, "# Runbook ... (3 lines, Ctrl+P - preview)", textString("# Runbook\n\n1. restart\n"))
+}
+
+func TestLatestText(t *testing.T) {
+	first, err := json.Marshal(data.Text{Text: "first"})
+	require.NoError(t, err)
+	second, err := json.Marshal(data.Text{Text: "second"})
+	require.NoError(t, err)
+	password, err := json.Marshal(data.Password{Login: "admin"})
+	require.NoError(t, err)
+
+	text, ok := latestText([]repoData.Data{
+		{Type: repoData.TEXT, Data: first},
+		{Type: repoData.TEXT, Data: second},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, "second", text)
+
+	_, ok = latestText([]repoData.Data{{Type: repoData.PASSWORD, Data: password}})
+	assert.False(t, ok)
+}
@@ -0,0 +1,69 @@
+// Пакет account содержит TUI страницу для удаления пользователем своей учетной записи.
+package account
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/handlers"
+	"github.com/abezemskiy/gophkeeper/internal/client/identity"
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/tools/printer"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rivo/tview"
+	"go.uber.org/zap"
+)
+
+// DeletePage - TUI страница для удаления учетной записи пользователя на сервере по адресу url. Для подтверждения
+// пользователь повторно вводит логин и пароль. После удаления пользователь и его данные удаляются из локального
+// хранилища remover, расшифрованные данные decrData очищаются, а пользователь возвращается на приветственную страницу.
+func DeletePage(ctx context.Context, url string, client *resty.Client, remover identity.AccountRemover, info identity.IUserInfoStorage,
+	decrData storage.DataWriter) func(app *app.App) tview.Primitive {
+	return func(app *app.App) tview.Primitive {
+		form := tview.NewForm()
+		confirm := &identity.AuthData{}
+
+		form.AddTextView("Внимание", "все данные будут удалены с сервера и с данного устройства", 40, 2, false, false)
+		form.AddInputField("Логин", "", 20, nil, func(text string) { confirm.Login = text })
+		form.AddPasswordField("Пароль", "", 20, '*', func(text string) { confirm.Password = text })
+
+		form.AddButton("Удалить", func() {
+			authData, id := info.Get()
+			if authData.Login == "" || id == "" {
+				printer.Message(app, "password or login not set")
+
+				// пользователь не авторизирован, возвращаю пользователя на страницу аутентификации.
+				app.SwitchTo(tui.Login)
+				return
+			}
+			// Логин и пароль должны совпадать с данными текущего пользователя
+			if *confirm != authData {
+				logger.ClientLog.Error("account deletion is not confirmed", zap.String("login", authData.Login))
+				printer.Error(app, "login or password is wrong")
+
+				app.SwitchTo(tui.DeleteAccount)
+				return
+			}
+
+			if err := handlers.DeleteAccount(ctx, url, &authData, client, remover, info); err != nil {
+				logger.ClientLog.Error("delete account error", zap.String("error", error.Error(err)))
+				printer.Error(app, fmt.Sprintf("delete account error, %v", err))
+
+				app.SwitchTo(tui.DeleteAccount)
+				return
+			}
+			decrData.Clear()
+
+			printer.Message(app, "account deleted successfully")
+			app.SwitchTo(tui.Home)
+		})
+		form.AddButton("Отмена", func() { app.SwitchTo(tui.Data) })
+
+		form.SetBorder(true).SetTitle("Удаление учетной записи")
+		return form
+	}
+}
@@ -11,12 +11,16 @@ import (
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/tools/printer"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/rivo/tview"
 	"go.uber.org/zap"
 )
 
 // Page - страница авторизации пользователя.
-func Page(ctx context.Context, ident identity.ClientIdentifier, info identity.IUserInfoStorage) func(app *app.App) tview.Primitive {
+// Если пользователь не найден в локальном хранилище, производится вход через сервер по адресу url,
+// что позволяет пользователю войти в свой аккаунт с нового устройства.
+func Page(ctx context.Context, ident identity.ClientIdentifier, info identity.IUserInfoStorage, url string,
+	client *resty.Client) func(app *app.App) tview.Primitive {
 	return func(app *app.App) tview.Primitive {
 		form := tview.NewForm()
 		authData := &identity.AuthData{}
@@ -44,13 +48,27 @@ func Page(ctx context.Context, ident identity.ClientIdentifier, info identity.IU
 				app.SwitchTo(tui.Login)
 				return
 			}
-			// Пользователь с данным логином не зарегистрирован
+			// Пользователь с данным логином не зарегистрирован на этом устройстве, пробую войти через сервер
 			if !isRegister {
-				logger.ClientLog.Error("user not register", zap.String("login", authData.Login))
-				printer.Error(app, "user not register")
+				ok, err := handlers.Login(ctx, url, authData, client, ident, info)
+				if err != nil {
+					logger.ClientLog.Error("login client error", zap.String("error", error.Error(err)))
+					printer.Error(app, fmt.Sprintf("login client error, %v", err))
 
-				// Переключаю пользователя обратно на страницу авторизации
-				app.SwitchTo(tui.Login)
+					// Переключаю пользователя обратно на страницу авторизации
+					app.SwitchTo(tui.Login)
+					return
+				}
+				if !ok {
+					logger.ClientLog.Error("user not register", zap.String("login", authData.Login))
+					printer.Error(app, "user not register or wrong password")
+
+					// Переключаю пользователя обратно на страницу авторизации
+					app.SwitchTo(tui.Login)
+					return
+				}
+				// Вход с нового устройства прошел успешно, переключаю пользователя на страницу с его данными
+				app.SwitchTo(tui.Data)
 				return
 			}
 			// Пароль неверный
@@ -0,0 +1,121 @@
+// Пакет editor содержит редактирование текста пользователя во внешнем редакторе ($VISUAL или $EDITOR).
+// Текст передается редактору через временный файл, доступный только владельцу. После редактирования все файлы
+// временной директории, включая резервные копии и swap файлы редактора, перезаписываются нулями и удаляются.
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/logger"
+
+	"go.uber.org/zap"
+)
+
+// DefaultEditor - редактор, который используется, если переменные окружения VISUAL и EDITOR не заданы.
+const DefaultEditor = "vi"
+
+// shmDir - директория в оперативной памяти, в которой по возможности создается временный файл.
+const shmDir = "/dev/shm"
+
+// Runner - функция для запуска редактора. TUI приостанавливает интерфейс на время работы редактора.
+type Runner func(cmd *exec.Cmd) error
+
+// Run - функция для запуска редактора без приостановки интерфейса.
+func Run(cmd *exec.Cmd) error {
+	return cmd.Run()
+}
+
+// Command - функция для получения команды редактора с аргументами из переменных окружения VISUAL и EDITOR.
+func Command() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if args := strings.Fields(os.Getenv(env)); len(args) > 0 {
+			return args
+		}
+	}
+	return []string{DefaultEditor}
+}
+
+// Edit - функция для редактирования текста text во внешнем редакторе. Возвращает отредактированный текст.
+func Edit(text string, run Runner) (string, error) {
+	dir, err := os.MkdirTemp(tempDir(), "gophkeeper-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory, %w", err)
+	}
+	defer wipeDir(dir)
+
+	// os.CreateTemp создает файл с правами 0600, а директория os.MkdirTemp доступна только владельцу
+	file, err := os.CreateTemp(dir, "note-*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file, %w", err)
+	}
+	path := file.Name()
+	_, err = file.WriteString(text)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write temp file, %w", err)
+	}
+
+	args := Command()
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := run(cmd); err != nil {
+		return "", fmt.Errorf("editor %s failed, %w", args[0], err)
+	}
+
+	edited, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read temp file, %w", err)
+	}
+	return string(edited), nil
+}
+
+// tempDir - функция для получения директории временных файлов. Предпочитается директория в оперативной памяти,
+// чтобы текст не попадал на диск.
+func tempDir() string {
+	if info, err := os.Stat(shmDir); err == nil && info.IsDir() {
+		if dir, err := os.MkdirTemp(shmDir, ".probe-"); err == nil {
+			os.Remove(dir)
+			return shmDir
+		}
+	}
+	return os.TempDir()
+}
+
+// wipeDir - функция для перезаписи нулями и удаления всех файлов временной директории.
+func wipeDir(dir string) {
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		return wipe(path)
+	})
+	if err != nil {
+		logger.ClientLog.Error("failed to wipe temp file", zap.String("error", err.Error()))
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		logger.ClientLog.Error("failed to remove temp directory", zap.String("error", err.Error()))
+	}
+}
+
+// wipe - функция для перезаписи файла нулями.
+func wipe(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s, %w", path, err)
+	}
+	info, err := file.Stat()
+	if err == nil {
+		_, err = file.Write(make([]byte, info.Size()))
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	return errors.Join(err, file.Close())
+}
@@ -0,0 +1,87 @@
+package editor
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		visual string
+		editor string
+		want   []string
+	}{
+		{name: "visual", visual: "code --wait", editor: "nano", want: []string{"code", "--wait"}},
+		{name: "editor", visual: "", editor: "nano", want: []string{"nano"}},
+		{name: "default", visual: " ", editor: "", want: []string{DefaultEditor}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("VISUAL", tt.visual)
+			t.Setenv("EDITOR", tt.editor)
+			assert.Equal(t, tt.want, Command())
+		})
+	}
+}
+
+func TestEdit(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "vim -n")
+
+	var path string
+	run := func(cmd *exec.Cmd) error {
+		require.Equal(t, []string{"vim", "-n"}, cmd.Args[:2])
+		path = cmd.Args[len(cmd.Args)-1]
+
+		// Файл и директория доступны только владельцу
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		info, err = os.Stat(filepath.Dir(path))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+		text, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "# Runbook\n", string(text))
+
+		// Редактор оставляет swap файл рядом с заметкой
+		require.NoError(t, os.WriteFile(path+".swp", []byte("secret"), 0600))
+		return os.WriteFile(path, []byte("# Runbook\n\n1. restart\n"), 0600)
+	}
+
+	text, err := Edit("# Runbook\n", run)
+	require.NoError(t, err)
+	assert.Equal(t, "# Runbook\n\n1. restart\n", text)
+
+	// Временная директория удалена вместе со всеми файлами
+	_, err = os.Stat(filepath.Dir(path))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+
+	t.Run("editor error", func(t *testing.T) {
+		_, err := Edit("text", func(cmd *exec.Cmd) error {
+			path = cmd.Args[len(cmd.Args)-1]
+			return errors.New("exit status 1")
+		})
+		assert.Error(t, err)
+		_, err = os.Stat(filepath.Dir(path))
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+	})
+}
+
+func TestWipe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.md")
+	require.NoError(t, os.WriteFile(path, []byte("recovery codes"), 0600))
+
+	require.NoError(t, wipe(path))
+	text, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, len("recovery codes")), text)
+}
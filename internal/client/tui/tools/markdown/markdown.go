@@ -0,0 +1,142 @@
+// Пакет markdown содержит упрощенный рендер Markdown в текст с тегами стилей tview для предпросмотра заметок.
+// Поддерживаются заголовки, списки и списки задач, цитаты, блоки кода, горизонтальные линии, а также выделение
+// полужирным и курсивом, встроенный код и ссылки.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// previewPage - имя страницы предпросмотра.
+const previewPage = "markdown_preview"
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	taskPattern    = regexp.MustCompile(`^(\s*)[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	bulletPattern  = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	orderedPattern = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	rulePattern    = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	linkPattern    = regexp.MustCompile(`^\[([^\]]+)\]\(([^)\s]+)\)`)
+)
+
+// Render - функция для преобразования Markdown в текст с тегами стилей tview. Текст пользователя экранируется,
+// поэтому квадратные скобки в заметке не воспринимаются как теги.
+func Render(src string) string {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	inCode := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			out = append(out, "[aqua]  "+tview.Escape(line)+"[-]")
+			continue
+		}
+		out = append(out, renderLine(line))
+	}
+	return strings.Join(out, "\n")
+}
+
+// renderLine - функция для преобразования одной строки Markdown вне блока кода.
+func renderLine(line string) string {
+	if m := headingPattern.FindStringSubmatch(line); m != nil {
+		return "[yellow::b]" + inline(m[2]) + "[-::-]"
+	}
+	if rulePattern.MatchString(line) {
+		return strings.Repeat("─", 40)
+	}
+	if m := taskPattern.FindStringSubmatch(line); m != nil {
+		box := "☐"
+		if m[2] != " " {
+			box = "☑"
+		}
+		return m[1] + box + " " + inline(m[3])
+	}
+	if m := bulletPattern.FindStringSubmatch(line); m != nil {
+		return m[1] + "• " + inline(m[2])
+	}
+	if m := orderedPattern.FindStringSubmatch(line); m != nil {
+		return m[1] + m[2] + " " + inline(m[3])
+	}
+	if rest, ok := strings.CutPrefix(line, ">"); ok {
+		return "[gray]│ " + inline(strings.TrimSpace(rest)) + "[-]"
+	}
+	return inline(line)
+}
+
+// inline - функция для преобразования строчной разметки: **полужирный**, *курсив* или _курсив_, `код` и [ссылка](url).
+func inline(s string) string {
+	var b, plain strings.Builder
+	flush := func() {
+		b.WriteString(tview.Escape(plain.String()))
+		plain.Reset()
+	}
+
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		switch {
+		case rest[0] == '`':
+			if end := strings.IndexByte(rest[1:], '`'); end >= 0 {
+				flush()
+				b.WriteString("[aqua]" + tview.Escape(rest[1:end+1]) + "[-]")
+				i += end + 2
+				continue
+			}
+		case strings.HasPrefix(rest, "**"):
+			if end := strings.Index(rest[2:], "**"); end > 0 {
+				flush()
+				b.WriteString("[::b]" + inline(rest[2:end+2]) + "[::-]")
+				i += end + 4
+				continue
+			}
+		case rest[0] == '*' || (rest[0] == '_' && (i == 0 || !isWordByte(s[i-1]))):
+			if end := strings.IndexByte(rest[1:], rest[0]); end > 0 && rest[1] != ' ' {
+				flush()
+				b.WriteString("[::i]" + inline(rest[1:end+1]) + "[::-]")
+				i += end + 2
+				continue
+			}
+		case rest[0] == '[':
+			if m := linkPattern.FindStringSubmatch(rest); m != nil {
+				flush()
+				b.WriteString("[::u]" + tview.Escape(m[1]) + "[::-] (" + tview.Escape(m[2]) + ")")
+				i += len(m[0])
+				continue
+			}
+		}
+		plain.WriteByte(s[i])
+		i++
+	}
+	flush()
+	return b.String()
+}
+
+// isWordByte - функция для проверки, что байт является частью слова. Подчеркивание внутри слова (snake_case)
+// не начинает курсив.
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// Show - функция для вывода предпросмотра Markdown поверх текущей страницы. Предпросмотр закрывается клавишей Esc.
+func Show(app *app.App, title, src string) {
+	view := tview.NewTextView().
+		SetDynamicColors(true).
+		SetWordWrap(true).
+		SetText(Render(src))
+	view.SetBorder(true).SetTitle(title + " (Esc - закрыть)")
+	view.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			app.Pages.RemovePage(previewPage)
+		}
+	})
+	app.Pages.AddPage(previewPage, view, true, true)
+	app.App.SetFocus(view)
+}
@@ -0,0 +1,39 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "heading", src: "## Recovery codes ##", want: "[yellow::b]Recovery codes[-::-]"},
+		{name: "bold and italic", src: "**важно** и *курсив* и _тоже_", want: "[::b]важно[::-] и [::i]курсив[::-] и [::i]тоже[::-]"},
+		{name: "snake case", src: "db_user_name", want: "db_user_name"},
+		{name: "inline code", src: "run `make [all]`", want: "run [aqua]make [all[][-]"},
+		{name: "bullet", src: "  - item", want: "  • item"},
+		{name: "ordered", src: "1. first", want: "1. first"},
+		{name: "tasks", src: "- [ ] todo\n- [x] done", want: "☐ todo\n☑ done"},
+		{name: "quote", src: "> note", want: "[gray]│ note[-]"},
+		{name: "link", src: "[wiki](https://example.com)", want: "[::u]wiki[::-] (https://example.com)"},
+		{name: "rule", src: "---", want: strings.Repeat("─", 40)},
+		{name: "escape tags", src: "[red]not a tag", want: "[red[]not a tag"},
+		{name: "unclosed", src: "2 * 3 = 6 `x", want: "2 * 3 = 6 `x"},
+		{
+			name: "code block",
+			src:  "```\n# not heading\n**raw**\n```\ntext",
+			want: "[aqua]  # not heading[-]\n[aqua]  **raw**[-]\ntext",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Render(tt.src))
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package printer
 
 import (
+	"fmt"
+
 	"github.com/abezemskiy/gophkeeper/internal/client/tui/app"
 
 	"github.com/rivo/tview"
@@ -35,3 +37,17 @@ func Message(app *app.App, message string) {
 		})
 	}()
 }
+
+// Progress - функция для вывода прогресса длительной операции на экран пользователя. Окно прогресса закрывается,
+// когда done становится равным total. Функция вызывается из горутины, выполняющей операцию, а не из цикла событий интерфейса.
+func Progress(app *app.App, title string, done, total int) {
+	app.App.QueueUpdateDraw(func() {
+		if done >= total {
+			app.Pages.RemovePage("progress")
+			return
+		}
+		modal := tview.NewModal().SetText(fmt.Sprintf("%s: %d из %d", title, done, total))
+		app.Pages.RemovePage("progress")
+		app.Pages.AddPage("progress", modal, true, true)
+	})
+}
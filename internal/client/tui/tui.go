@@ -12,10 +12,19 @@ const (
 	AddText      = "add_text"      // страница для добавления нового текста
 	AddBinary    = "add_binary"    // страница для добавления бинарных данных
 	AddBankCard  = "add_bankcard"  // страница для добавления новой банковской карты
+	AddLogin     = "add_login"     // страница для добавления новой учетной записи
+	AddSSHKey    = "add_sshkey"    // страница для добавления нового SSH ключа
 	Delete       = "delete"        // страница для удаления данных пользователя по имени данных
 	EditPassword = "edit_password" // страница для изменения существующего пароля пользователя
 	EditText     = "edit_text"     // страница для изменения существующего текста пользователя
 	EditBinary   = "edit_binary"   // страница для изменения существующих бинарных данных пользователя
 	EditBankCard = "edit_bankcard" // страница для изменения существующих данных банковской карты
+	EditLogin    = "edit_login"    // страница для изменения существующей учетной записи
+	EditSSHKey   = "edit_sshkey"   // страница для изменения существующего SSH ключа
 	Edit         = "edit"          // страница для изменения существующих данных
+	Download     = "download"      // страница для сохранения файла пользователя на диск
+	Usage        = "usage"         // страница с информацией об использовании хранилища сервера
+	Collections  = "collections"   // страница с записями коллекций организаций пользователя
+
+	DeleteAccount = "delete_account" // страница для удаления учетной записи пользователя
 )
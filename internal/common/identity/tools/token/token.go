@@ -37,6 +37,8 @@ func BuildJWT(userID string) (string, error) {
 		RegisteredClaims: jwt.RegisteredClaims{
 			// дата истечения токена
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * time.Duration(expireHour))),
+			// дата выдачи токена, по которой сервер отзывает токены при принудительном выходе пользователя
+			IssuedAt: jwt.NewNumericDate(time.Now()),
 		},
 		// собственное утверждение - идентификатор пользователя
 		UserID: userID,
@@ -53,6 +55,15 @@ func BuildJWT(userID string) (string, error) {
 // GetIDFromToken - функция для получения id пользователя из токена с проверкой заголовка алгоритма токена.
 // Заголовок должен совпадать с тем, который сервер использует для подписи и проверки токенов.
 func GetIDFromToken(tokenStr string) (string, error) {
+	claims, err := GetClaims(tokenStr)
+	if err != nil {
+		return "", err
+	}
+	return claims.UserID, nil
+}
+
+// GetClaims - функция для получения утверждений действительного токена, аналог GetIDFromToken.
+func GetClaims(tokenStr string) (*Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenStr, claims,
 		func(t *jwt.Token) (interface{}, error) {
@@ -62,12 +73,37 @@ func GetIDFromToken(tokenStr string) (string, error) {
 			return []byte(secretKey), nil
 		})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if !token.Valid {
-		return "", fmt.Errorf("token is not valid")
+		return nil, fmt.Errorf("token is not valid")
 	}
 
+	return claims, nil
+}
+
+// IssueTime - метод для получения даты выдачи токена. Для токенов без даты выдачи возвращается нулевое время.
+func (c *Claims) IssueTime() time.Time {
+	if c.IssuedAt == nil {
+		return time.Time{}
+	}
+	return c.IssuedAt.Time
+}
+
+// GetIDFromExpiredToken - функция для получения id пользователя из токена с проверкой подписи, но без проверки
+// срока действия. Позволяет отличить повторную авторизацию клиента с истекшим токеном от нового входа пользователя.
+func GetIDFromExpiredToken(tokenStr string) (string, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims,
+		func(t *jwt.Token) (interface{}, error) {
+			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+			}
+			return []byte(secretKey), nil
+		}, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return "", err
+	}
 	return claims.UserID, nil
 }
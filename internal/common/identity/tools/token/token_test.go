@@ -2,6 +2,7 @@ package token
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -48,3 +49,50 @@ func TestBuildJWT(t *testing.T) {
 	_, err = GetIDFromToken(token2)
 	require.Error(t, err)
 }
+
+func TestGetIDFromExpiredToken(t *testing.T) {
+	SetSecretKey("test key")
+	defer SerExpireHour(0)
+
+	// токен, срок действия которого истек час назад
+	SerExpireHour(-1)
+	id := "41614361346161346"
+	expired, err := BuildJWT(id)
+	require.NoError(t, err)
+
+	_, err = GetIDFromToken(expired)
+	require.Error(t, err)
+	getID, err := GetIDFromExpiredToken(expired)
+	require.NoError(t, err)
+	assert.Equal(t, id, getID)
+
+	// Подпись проверяется и у истекшего токена
+	SetSecretKey("wrong key")
+	_, err = GetIDFromExpiredToken(expired)
+	require.Error(t, err)
+
+	_, err = GetIDFromExpiredToken("not a token")
+	require.Error(t, err)
+}
+
+func TestGetClaims(t *testing.T) {
+	SetSecretKey("test key")
+	SerExpireHour(1)
+	defer SerExpireHour(0)
+
+	before := time.Now().Truncate(time.Second)
+	token, err := BuildJWT("id")
+	require.NoError(t, err)
+
+	claims, err := GetClaims(token)
+	require.NoError(t, err)
+	assert.Equal(t, "id", claims.UserID)
+	assert.False(t, claims.IssueTime().Before(before))
+	assert.False(t, claims.IssueTime().After(time.Now()))
+
+	// У токена без даты выдачи нулевое время выдачи
+	assert.True(t, (&Claims{}).IssueTime().IsZero())
+
+	_, err = GetClaims("not a token")
+	require.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package tlsconfig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// organization - организация, указываемая в выпущенных сертификатах.
+const organization = "GophKeeper development"
+
+// Pair - сертификат и его закрытый ключ.
+type Pair struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// NewCA - функция для создания самоподписанного удостоверяющего центра для разработки.
+func NewCA(commonName string, validity time.Duration) (*Pair, error) {
+	template, err := newTemplate(commonName, validity)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	return sign(template, nil)
+}
+
+// IssueServer - метод для выпуска сертификата сервера для имен и IP адресов hosts.
+func (ca *Pair) IssueServer(commonName string, hosts []string, validity time.Duration) (*Pair, error) {
+	template, err := newTemplate(commonName, validity)
+	if err != nil {
+		return nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+	return sign(template, ca)
+}
+
+// IssueClient - метод для выпуска сертификата клиента.
+func (ca *Pair) IssueClient(commonName string, validity time.Duration) (*Pair, error) {
+	template, err := newTemplate(commonName, validity)
+	if err != nil {
+		return nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	return sign(template, ca)
+}
+
+// CertPEM - метод для получения сертификата в формате PEM.
+func (p *Pair) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: p.Cert.Raw})
+}
+
+// KeyPEM - метод для получения закрытого ключа в формате PEM.
+func (p *Pair) KeyPEM() ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(p.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key, %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// WriteFiles - метод для записи сертификата и ключа в файлы <name>.crt и <name>.key директории dir.
+// Ключ доступен только владельцу.
+func (p *Pair) WriteFiles(dir, name string) error {
+	keyPEM, err := p.KeyPEM()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".crt"), p.CertPEM(), 0644); err != nil {
+		return fmt.Errorf("failed to write certificate %s, %w", name, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0600); err != nil {
+		return fmt.Errorf("failed to write private key %s, %w", name, err)
+	}
+	return nil
+}
+
+// LoadPair - функция для загрузки сертификата и ключа из файлов <name>.crt и <name>.key директории dir.
+func LoadPair(dir, name string) (*Pair, error) {
+	tlsCert, err := tls.LoadX509KeyPair(filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s, %w", name, err)
+	}
+	cert, err := x509.ParseCertificate(tlsCert.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate %s, %w", name, err)
+	}
+	key, ok := tlsCert.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+	return &Pair{Cert: cert, Key: key}, nil
+}
+
+// newTemplate - функция для создания шаблона сертификата со случайным серийным номером.
+func newTemplate(commonName string, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number, %w", err)
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{organization}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(validity),
+	}, nil
+}
+
+// sign - функция для создания ключа и подписи сертификата удостоверяющим центром ca. Если ca равен nil,
+// сертификат подписывается собственным ключом.
+func sign(template *x509.Certificate, ca *Pair) (*Pair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key, %w", err)
+	}
+	parent, signer := template, crypto.Signer(key)
+	if ca != nil {
+		parent, signer = ca.Cert, ca.Key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate, %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate, %w", err)
+	}
+	return &Pair{Cert: cert, Key: key}, nil
+}
@@ -0,0 +1,68 @@
+// Пакет tlsconfig содержит настройку TLS соединений между клиентом и сервером и выпуск сертификатов
+// для разработки. Клиент доверяет только указанному удостоверяющему центру (CA pinning), сервер может
+// требовать от клиента сертификат, выпущенный указанным удостоверяющим центром (mutual TLS).
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// ServerConfig - функция для создания TLS конфигурации сервера из файлов сертификата certFile и ключа keyFile.
+// Если задан clientCAFile, сервер требует от клиента сертификат, выпущенный удостоверяющим центром из этого файла.
+func ServerConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate, %w", err)
+	}
+	cfg := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if clientCAFile != "" {
+		pool, err := loadPool(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// ClientConfig - функция для создания TLS конфигурации клиента. Если задан caFile, клиент доверяет только
+// сертификатам сервера, выпущенным удостоверяющим центром из этого файла, иначе используются системные
+// сертификаты. Если заданы certFile и keyFile, клиент предъявляет серверу свой сертификат.
+func ClientConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pool, err := loadPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate, %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// loadPool - функция для загрузки сертификатов удостоверяющих центров из PEM файла.
+func loadPool(caFile string) (*x509.CertPool, error) {
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate %s, %w", caFile, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	return pool, nil
+}
@@ -0,0 +1,112 @@
+package tlsconfig
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCerts - функция для выпуска удостоверяющего центра, сертификатов сервера и клиента в директорию dir.
+func writeCerts(t *testing.T, dir string) {
+	t.Helper()
+	ca, err := NewCA("test CA", time.Hour)
+	require.NoError(t, err)
+	server, err := ca.IssueServer("server", []string{"localhost", "127.0.0.1"}, time.Hour)
+	require.NoError(t, err)
+	client, err := ca.IssueClient("client", time.Hour)
+	require.NoError(t, err)
+
+	require.NoError(t, ca.WriteFiles(dir, "ca"))
+	require.NoError(t, server.WriteFiles(dir, "server"))
+	require.NoError(t, client.WriteFiles(dir, "client"))
+}
+
+func TestLoadPair(t *testing.T) {
+	dir := t.TempDir()
+	writeCerts(t, dir)
+
+	ca, err := LoadPair(dir, "ca")
+	require.NoError(t, err)
+	assert.True(t, ca.Cert.IsCA)
+
+	server, err := LoadPair(dir, "server")
+	require.NoError(t, err)
+	require.NoError(t, server.Cert.CheckSignatureFrom(ca.Cert))
+	assert.Equal(t, []string{"localhost"}, server.Cert.DNSNames)
+	assert.Len(t, server.Cert.IPAddresses, 1)
+
+	_, err = LoadPair(dir, "unknown")
+	assert.Error(t, err)
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, otherDir := t.TempDir(), t.TempDir()
+	writeCerts(t, dir)
+	writeCerts(t, otherDir)
+	path := func(dir, name string) string { return filepath.Join(dir, name) }
+
+	serverCfg, err := ServerConfig(path(dir, "server.crt"), path(dir, "server.key"), path(dir, "ca.crt"))
+	require.NoError(t, err)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	srv.TLS = serverCfg
+	srv.StartTLS()
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		ca       string
+		cert     string
+		key      string
+		wantErr  bool
+		wantCode int
+	}{
+		{name: "client certificate", ca: path(dir, "ca.crt"), cert: path(dir, "client.crt"), key: path(dir, "client.key"),
+			wantCode: http.StatusOK},
+		{name: "without client certificate", ca: path(dir, "ca.crt"), wantErr: true},
+		{name: "client certificate of other CA", ca: path(dir, "ca.crt"), cert: path(otherDir, "client.crt"),
+			key: path(otherDir, "client.key"), wantErr: true},
+		{name: "server of other CA", ca: path(otherDir, "ca.crt"), cert: path(dir, "client.crt"), key: path(dir, "client.key"),
+			wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientCfg, err := ClientConfig(tt.ca, tt.cert, tt.key)
+			require.NoError(t, err)
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientCfg}}
+
+			resp, err := client.Get(srv.URL)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+		})
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeCerts(t, dir)
+
+	_, err := ServerConfig(filepath.Join(dir, "unknown.crt"), filepath.Join(dir, "server.key"), "")
+	assert.Error(t, err)
+	_, err = ServerConfig(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), filepath.Join(dir, "server.key"))
+	assert.Error(t, err)
+	_, err = ClientConfig(filepath.Join(dir, "unknown.crt"), "", "")
+	assert.Error(t, err)
+	_, err = ClientConfig("", filepath.Join(dir, "client.crt"), "")
+	assert.Error(t, err)
+
+	cfg, err := ClientConfig("", "", "")
+	require.NoError(t, err)
+	assert.Nil(t, cfg.RootCAs)
+}
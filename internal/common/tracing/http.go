@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// statusWriter - обертка над http.ResponseWriter для получения статуса ответа.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader - обертка над оригинальным WriteHeader.
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Middleware - middleware сервера, которое продолжает трассу клиента из заголовков запроса и создает спан
+// обработки запроса. Имя спана содержит шаблон маршрута chi, а не адрес запроса, поэтому идентификаторы
+// из адреса не попадают в имена спанов.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		ctx, span := otel.Tracer(instrumentation).Start(ctx, "HTTP "+req.Method, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(req.Method)))
+		defer span.End()
+
+		w := &statusWriter{ResponseWriter: res, status: http.StatusOK}
+		next.ServeHTTP(w, req.WithContext(ctx))
+
+		if rctx := chi.RouteContext(req.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(req.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(semconv.HTTPRouteKey.String(rctx.RoutePattern()))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCodeKey.Int(w.status))
+		if w.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(w.status))
+		}
+	})
+}
+
+// transport - транспорт клиента, создающий спан запроса и передающий контекст трассировки в заголовках.
+type transport struct {
+	base http.RoundTripper
+}
+
+// Transport - функция для создания транспорта клиента поверх транспорта base. Контекст трассировки берется
+// из контекста запроса, поэтому запросы resty должны выполняться с SetContext.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base}
+}
+
+// RoundTrip - метод для выполнения запроса req.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := otel.Tracer(instrumentation).Start(req.Context(), "HTTP "+req.Method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.HTTPRequestMethodKey.String(req.Method), semconv.URLFullKey.String(req.URL.Redacted())))
+	defer span.End()
+
+	// Запрос не изменяется по контракту http.RoundTripper, заголовки передаются в копии
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, Error(span, err)
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCodeKey.Int(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, fmt.Sprintf("server responded with status %d", resp.StatusCode))
+	}
+	return resp, nil
+}
@@ -0,0 +1,98 @@
+// Пакет tracing содержит настройку трассировки OpenTelemetry, общую для клиента и сервера: выбор экспортера,
+// создание спанов, middleware сервера и транспорт клиента для передачи контекста трассировки в заголовках HTTP.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Экспортеры спанов.
+const (
+	ExporterNone   = "none"   // трассировка отключена
+	ExporterStdout = "stdout" // спаны выводятся в формате JSON, для локальной отладки
+	ExporterOTLP   = "otlp"   // спаны отправляются коллектору по протоколу OTLP/gRPC
+)
+
+// instrumentation - имя инструментирования, под которым создаются спаны приложения.
+const instrumentation = "github.com/abezemskiy/gophkeeper"
+
+// Config - параметры трассировки.
+type Config struct {
+	Service  string    // имя сервиса в спанах
+	Exporter string    // экспортер спанов, пустое значение отключает трассировку
+	Endpoint string    // адрес коллектора OTLP, без адреса используются переменные окружения OTEL_EXPORTER_OTLP_*
+	Output   io.Writer // назначение вывода экспортера stdout
+}
+
+// CheckExporter - функция для проверки имени экспортера.
+func CheckExporter(exporter string) error {
+	switch exporter {
+	case "", ExporterNone, ExporterStdout, ExporterOTLP:
+		return nil
+	default:
+		return fmt.Errorf("unknown trace exporter %q, expected %s, %s or %s", exporter, ExporterNone, ExporterStdout, ExporterOTLP)
+	}
+}
+
+// Init - функция для настройки глобального провайдера трассировки. Возвращает функцию, которая отправляет
+// накопленные спаны и останавливает провайдер; ее нужно вызвать при завершении приложения.
+// Если трассировка отключена, спаны не создаются и не экспортируются.
+func Init(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	// Контекст трассировки передается всегда, чтобы не разрывать трассы, начатые другими сервисами
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(cfg.Output))
+	case ExporterOTLP:
+		var opts []otlptracegrpc.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, CheckExporter(cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter, %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.Service)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource, %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start - функция для создания спана с именем name, дочернего по отношению к спану из ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Error - функция для записи ошибки err в спан. Возвращает err, чтобы запись можно было совместить с возвратом ошибки.
+func Error(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
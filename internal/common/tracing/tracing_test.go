@@ -0,0 +1,182 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// recorder - функция для установки глобального провайдера, сохраняющего завершенные спаны в памяти.
+func recorder(t *testing.T) *tracetest.SpanRecorder {
+	rec := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+		otel.SetTracerProvider(noop.NewTracerProvider())
+	})
+	return rec
+}
+
+// spanByName - функция для поиска завершенного спана по имени.
+func spanByName(spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	for _, span := range spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func TestCheckExporter(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter string
+		wantErr  bool
+	}{
+		{name: "empty", exporter: "", wantErr: false},
+		{name: "none", exporter: ExporterNone, wantErr: false},
+		{name: "stdout", exporter: ExporterStdout, wantErr: false},
+		{name: "otlp", exporter: ExporterOTLP, wantErr: false},
+		{name: "unknown", exporter: "jaeger", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckExporter(tt.exporter)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestInit(t *testing.T) {
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	t.Run("stdout", func(t *testing.T) {
+		var out bytes.Buffer
+		shutdown, err := Init(context.Background(), Config{Service: "test", Exporter: ExporterStdout, Output: &out})
+		require.NoError(t, err)
+
+		_, span := Start(context.Background(), "test.Span")
+		span.End()
+
+		// Спаны экспортируются пакетами, при остановке провайдера накопленные спаны отправляются
+		require.NoError(t, shutdown(context.Background()))
+		assert.Contains(t, out.String(), "test.Span")
+		assert.Contains(t, out.String(), "\"Value\":\"test\"")
+	})
+	t.Run("none", func(t *testing.T) {
+		shutdown, err := Init(context.Background(), Config{Service: "test", Exporter: ExporterNone})
+		require.NoError(t, err)
+		require.NoError(t, shutdown(context.Background()))
+	})
+	t.Run("unknown exporter", func(t *testing.T) {
+		_, err := Init(context.Background(), Config{Service: "test", Exporter: "jaeger"})
+		require.Error(t, err)
+	})
+}
+
+func TestError(t *testing.T) {
+	rec := recorder(t)
+
+	_, span := Start(context.Background(), "ok")
+	require.NoError(t, Error(span, nil))
+	span.End()
+
+	testErr := errors.New("test error")
+	_, span = Start(context.Background(), "failed")
+	assert.ErrorIs(t, Error(span, testErr), testErr)
+	span.End()
+
+	spans := rec.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spanByName(spans, "ok").Status().Code)
+	failed := spanByName(spans, "failed")
+	assert.Equal(t, codes.Error, failed.Status().Code)
+	assert.Equal(t, "test error", failed.Status().Description)
+	require.Len(t, failed.Events(), 1)
+}
+
+func TestPropagation(t *testing.T) {
+	rec := recorder(t)
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/data/{id}", func(res http.ResponseWriter, req *http.Request) {
+		// Спан обработчика продолжает трассу запроса
+		_, span := Start(req.Context(), "handlers.Get")
+		span.End()
+		res.WriteHeader(http.StatusOK)
+	})
+	r.Get("/fail", func(res http.ResponseWriter, _ *http.Request) {
+		res.WriteHeader(http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	client := &http.Client{Transport: Transport(http.DefaultTransport)}
+	ctx, parent := Start(context.Background(), "client.Sync")
+
+	tests := []struct {
+		name       string
+		path       string
+		serverSpan string
+		status     codes.Code
+	}{
+		{name: "route pattern in span name", path: "/data/42", serverSpan: "GET /data/{id}", status: codes.Unset},
+		{name: "server error", path: "/fail", serverSpan: "GET /fail", status: codes.Error},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+tt.path, nil)
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			server := spanByName(rec.Ended(), tt.serverSpan)
+			require.NotNil(t, server)
+			assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+			assert.Equal(t, tt.status, server.Status().Code)
+
+			// Спан сервера принадлежит трассе клиента и является дочерним по отношению к спану запроса клиента
+			assert.Equal(t, parent.SpanContext().TraceID(), server.SpanContext().TraceID())
+			assert.True(t, server.Parent().IsRemote())
+		})
+	}
+	parent.End()
+
+	// Спан обработчика вложен в спан запроса сервера
+	handler := spanByName(rec.Ended(), "handlers.Get")
+	require.NotNil(t, handler)
+	server := spanByName(rec.Ended(), "GET /data/{id}")
+	assert.Equal(t, server.SpanContext().SpanID(), handler.Parent().SpanID())
+
+	// Спаны запросов клиента вложены в спан операции клиента
+	var clientSpans int
+	for _, span := range rec.Ended() {
+		if span.SpanKind() == trace.SpanKindClient {
+			clientSpans++
+			assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		}
+	}
+	assert.Equal(t, 2, clientSpans)
+}
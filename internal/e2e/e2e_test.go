@@ -0,0 +1,233 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testLogin    = "e2e login"
+	testPassword = "e2e password"
+)
+
+// setup - запускает сервер, регистрирует пользователя на первом устройстве и выполняет вход на остальных.
+func setup(t *testing.T, names ...string) (*Server, []*Device) {
+	t.Helper()
+	ctx := context.Background()
+
+	srv := NewServer()
+	t.Cleanup(srv.Close)
+
+	devices := make([]*Device, 0, len(names))
+	for i, name := range names {
+		d := srv.NewDevice(name)
+		if i == 0 {
+			require.NoError(t, d.Register(ctx, testLogin, testPassword))
+		} else {
+			require.NoError(t, d.Login(ctx, testLogin, testPassword))
+		}
+		devices = append(devices, d)
+	}
+	return srv, devices
+}
+
+// syncAll - выполняет синхронизацию на всех устройствах.
+func syncAll(t *testing.T, devices ...*Device) {
+	t.Helper()
+	for _, d := range devices {
+		require.NoError(t, d.Sync(context.Background()))
+	}
+}
+
+// assertConverged - проверяет, что данные на всех устройствах совпадают с данными на сервере и с ожидаемыми.
+func assertConverged(t *testing.T, srv *Server, want map[string][]string, devices ...*Device) {
+	t.Helper()
+	ctx := context.Background()
+
+	onServer, err := srv.Records(ctx, testLogin, testPassword)
+	require.NoError(t, err)
+	assert.Equal(t, want, onServer, "server")
+
+	for _, d := range devices {
+		local, err := d.Records(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, want, local, d.Name)
+	}
+}
+
+// assertStatus - проверяет статус данных на устройствах.
+func assertStatus(t *testing.T, name string, want int, devices ...*Device) {
+	t.Helper()
+	for _, d := range devices {
+		status, ok, err := d.Status(context.Background(), name)
+		require.NoError(t, err)
+		require.True(t, ok, d.Name)
+		assert.Equal(t, want, status, d.Name)
+	}
+}
+
+func TestOfflineEdits(t *testing.T) {
+	ctx := context.Background()
+	srv, devices := setup(t, "laptop", "phone")
+	laptop, phone := devices[0], devices[1]
+
+	require.NoError(t, laptop.Save(ctx, "note", "v1"))
+	syncAll(t, phone)
+	assertConverged(t, srv, map[string][]string{"note": {"v1"}}, laptop, phone)
+
+	// Ноутбук работает офлайн: добавляет новые данные и изменяет существующие
+	laptop.Network.Set(Offline)
+	require.NoError(t, laptop.Save(ctx, "offline", "o1"))
+	require.NoError(t, laptop.Replace(ctx, "note", "v2"))
+	assertStatus(t, "offline", data.NEW, laptop)
+	assertStatus(t, "note", data.CHANGED, laptop)
+
+	// Синхронизация в офлайне невозможна, локальные изменения сохраняются
+	require.Error(t, laptop.Sync(ctx))
+	records, err := laptop.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"note": {"v2"}, "offline": {"o1"}}, records)
+
+	// После восстановления сети изменения ноутбука доходят до телефона.
+	// Изменение, сделанное офлайн, сохраняется на сервере как дополнительная версия данных.
+	laptop.Network.Set(Online)
+	syncAll(t, laptop, phone)
+	assertConverged(t, srv, map[string][]string{"note": {"v1", "v2"}, "offline": {"o1"}}, laptop, phone)
+	assertStatus(t, "note", data.CONFLICT, laptop, phone)
+	assertStatus(t, "offline", data.SAVED, laptop, phone)
+
+	// Пользователь разрешает конфликт на телефоне
+	require.NoError(t, phone.Replace(ctx, "note", "v3"))
+	syncAll(t, laptop)
+	assertConverged(t, srv, map[string][]string{"note": {"v3"}, "offline": {"o1"}}, laptop, phone)
+	assertStatus(t, "note", data.SAVED, laptop, phone)
+}
+
+func TestConcurrentReplaces(t *testing.T) {
+	ctx := context.Background()
+	srv, devices := setup(t, "laptop", "phone", "tablet")
+	laptop, phone, tablet := devices[0], devices[1], devices[2]
+
+	require.NoError(t, laptop.Save(ctx, "note", "v1"))
+	syncAll(t, phone, tablet)
+
+	// Устройства онлайн заменяют одни и те же данные без промежуточной синхронизации,
+	// на сервере остается последняя версия.
+	require.NoError(t, phone.Replace(ctx, "note", "phone"))
+	require.NoError(t, tablet.Replace(ctx, "note", "tablet"))
+	syncAll(t, laptop, phone, tablet)
+	assertConverged(t, srv, map[string][]string{"note": {"tablet"}}, laptop, phone, tablet)
+	assertStatus(t, "note", data.SAVED, laptop, phone, tablet)
+
+	// Устройства заменяют одни и те же данные офлайн, все версии сохраняются на сервере
+	phone.Network.Set(Offline)
+	tablet.Network.Set(Offline)
+	require.NoError(t, phone.Replace(ctx, "note", "phone offline"))
+	require.NoError(t, tablet.Replace(ctx, "note", "tablet offline"))
+	phone.Network.Set(Online)
+	tablet.Network.Set(Online)
+	syncAll(t, phone, tablet, laptop, phone)
+	assertConverged(t, srv, map[string][]string{"note": {"tablet", "phone offline", "tablet offline"}}, laptop, phone, tablet)
+	assertStatus(t, "note", data.CONFLICT, laptop, phone, tablet)
+}
+
+func TestConflicts(t *testing.T) {
+	ctx := context.Background()
+	srv, devices := setup(t, "laptop", "phone")
+	laptop, phone := devices[0], devices[1]
+
+	// Оба устройства офлайн добавляют данные с одинаковым именем
+	laptop.Network.Set(Offline)
+	phone.Network.Set(Offline)
+	require.NoError(t, laptop.Save(ctx, "shared", "laptop"))
+	require.NoError(t, phone.Save(ctx, "shared", "phone"))
+	laptop.Network.Set(Online)
+	phone.Network.Set(Online)
+
+	// Данные ноутбука сохраняются первыми, данные телефона становятся дополнительной версией
+	syncAll(t, laptop, phone, laptop)
+	assertConverged(t, srv, map[string][]string{"shared": {"laptop", "phone"}}, laptop, phone)
+	assertStatus(t, "shared", data.CONFLICT, laptop, phone)
+
+	// Конфликт разрешается заменой данных на одном из устройств
+	require.NoError(t, laptop.Replace(ctx, "shared", "merged"))
+	syncAll(t, phone)
+	assertConverged(t, srv, map[string][]string{"shared": {"merged"}}, laptop, phone)
+	assertStatus(t, "shared", data.SAVED, laptop, phone)
+}
+
+func TestDeletes(t *testing.T) {
+	ctx := context.Background()
+	srv, devices := setup(t, "laptop", "phone")
+	laptop, phone := devices[0], devices[1]
+
+	require.NoError(t, laptop.Save(ctx, "first", "1"))
+	require.NoError(t, laptop.Save(ctx, "second", "2"))
+	require.NoError(t, laptop.Save(ctx, "third", "3"))
+	syncAll(t, phone)
+	assertConverged(t, srv, map[string][]string{"first": {"1"}, "second": {"2"}, "third": {"3"}}, laptop, phone)
+
+	// Удаление на одном устройстве распространяется на другие устройства
+	require.NoError(t, laptop.Delete(ctx, "first"))
+	syncAll(t, phone)
+	assertConverged(t, srv, map[string][]string{"second": {"2"}, "third": {"3"}}, laptop, phone)
+
+	// Удаление в офлайне запрещено
+	phone.Network.Set(Offline)
+	require.Error(t, phone.Delete(ctx, "second"))
+
+	// Телефон офлайн изменяет данные, которые ноутбук в это время удаляет.
+	// Изменения пользователя не теряются и повторно добавляются на сервер.
+	require.NoError(t, phone.Replace(ctx, "second", "2 edited"))
+	require.NoError(t, laptop.Delete(ctx, "second"))
+	phone.Network.Set(Online)
+	syncAll(t, phone, phone, laptop)
+	assertConverged(t, srv, map[string][]string{"second": {"2 edited"}, "third": {"3"}}, laptop, phone)
+
+	// Данные в конфликтном состоянии также удаляются на всех устройствах
+	laptop.Network.Set(Offline)
+	require.NoError(t, laptop.Replace(ctx, "third", "3 edited"))
+	laptop.Network.Set(Online)
+	syncAll(t, laptop, phone)
+	assertStatus(t, "third", data.CONFLICT, laptop, phone)
+	require.NoError(t, phone.Delete(ctx, "third"))
+	syncAll(t, laptop)
+	assertConverged(t, srv, map[string][]string{"second": {"2 edited"}}, laptop, phone)
+}
+
+func TestDroppedResponses(t *testing.T) {
+	ctx := context.Background()
+	srv, devices := setup(t, "laptop", "phone")
+	laptop, phone := devices[0], devices[1]
+
+	// Сервер сохраняет данные, но ноутбук не получает ответ и считает данные несохраненными.
+	// Повторная отправка той же версии при синхронизации не должна приводить к конфликту.
+	laptop.Network.Set(DropResponses)
+	require.NoError(t, laptop.Save(ctx, "note", "v1"))
+	assertStatus(t, "note", data.NEW, laptop)
+	laptop.Network.Set(Online)
+	syncAll(t, laptop, phone)
+	assertConverged(t, srv, map[string][]string{"note": {"v1"}}, laptop, phone)
+	assertStatus(t, "note", data.SAVED, laptop, phone)
+
+	// Ответ на замену данных теряется
+	laptop.Network.Set(DropResponses)
+	require.NoError(t, laptop.Replace(ctx, "note", "v2"))
+	assertStatus(t, "note", data.CHANGED, laptop)
+	laptop.Network.Set(Online)
+	syncAll(t, laptop, phone)
+	assertConverged(t, srv, map[string][]string{"note": {"v2"}}, laptop, phone)
+	assertStatus(t, "note", data.SAVED, laptop, phone)
+
+	// Ответ на удаление данных теряется, данные удаляются локально при следующей синхронизации
+	laptop.Network.Set(DropResponses)
+	require.Error(t, laptop.Delete(ctx, "note"))
+	laptop.Network.Set(Online)
+	syncAll(t, laptop, phone)
+	assertConverged(t, srv, map[string][]string{}, laptop, phone)
+}
@@ -0,0 +1,293 @@
+// Пакет e2e содержит стенд для сквозного тестирования синхронизации данных между несколькими устройствами пользователя.
+// Стенд запускает настоящий маршрутизатор сервера поверх httptest с хранилищем в оперативной памяти. Каждое устройство
+// имеет собственное локальное хранилище, хранилище информации о пользователе и сеть, в которую можно внедрять сбои.
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/encr"
+	"github.com/abezemskiy/gophkeeper/internal/client/handlers"
+	"github.com/abezemskiy/gophkeeper/internal/client/identity"
+	"github.com/abezemskiy/gophkeeper/internal/client/identity/auth"
+	clientData "github.com/abezemskiy/gophkeeper/internal/client/storage/data"
+	"github.com/abezemskiy/gophkeeper/internal/client/storage/info"
+	clientMemory "github.com/abezemskiy/gophkeeper/internal/client/storage/memory"
+	"github.com/abezemskiy/gophkeeper/internal/client/synchronization"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/testutil"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Ошибки, которые возвращает сеть устройства при внедрении сбоев.
+var (
+	ErrOffline      = errors.New("network is offline")
+	ErrResponseLost = errors.New("server response is lost")
+)
+
+// Mode - режим работы сети устройства.
+type Mode int
+
+// Режимы работы сети устройства.
+const (
+	Online        Mode = iota // запросы и ответы доставляются
+	Offline                   // запросы не доходят до сервера
+	DropResponses             // запросы доходят до сервера и обрабатываются, но ответы теряются
+)
+
+// Network - сеть устройства с возможностью внедрения сбоев. Реализует интерфейс http.RoundTripper.
+type Network struct {
+	mu        sync.RWMutex
+	mode      Mode
+	transport http.RoundTripper
+}
+
+// NewNetwork - фабричная функция сети устройства.
+func NewNetwork(transport http.RoundTripper) *Network {
+	return &Network{transport: transport}
+}
+
+// Set - устанавливает режим работы сети.
+func (n *Network) Set(mode Mode) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.mode = mode
+}
+
+// RoundTrip - выполняет запрос с учетом текущего режима работы сети.
+func (n *Network) RoundTrip(req *http.Request) (*http.Response, error) {
+	n.mu.RLock()
+	mode := n.mode
+	n.mu.RUnlock()
+
+	switch mode {
+	case Offline:
+		return nil, ErrOffline
+	case DropResponses:
+		resp, err := n.transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		// Дочитываю ответ, чтобы сервер гарантированно завершил обработку запроса
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, ErrResponseLost
+	default:
+		return n.transport.RoundTrip(req)
+	}
+}
+
+// Server - тестовый сервер с хранилищем в оперативной памяти.
+type Server struct {
+	srv *testutil.Server
+}
+
+// NewServer - запускает тестовый сервер. Сервер необходимо остановить методом Close.
+func NewServer() *Server {
+	return &Server{srv: testutil.StartServer()}
+}
+
+// Close - останавливает тестовый сервер.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Records - возвращает расшифрованные данные пользователя, хранящиеся на сервере.
+// Результат содержит все версии данных по имени данных.
+func (s *Server) Records(ctx context.Context, login, password string) (map[string][]string, error) {
+	authData, ok, err := s.srv.Store.Authorize(ctx, login)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authorize user %s on server, %w", login, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("user %s not register on server", login)
+	}
+	encrData, err := s.srv.Store.GetAllEncryptedData(ctx, authData.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data of user %s from server, %w", login, err)
+	}
+	return decrypt(ctx, password, encrData)
+}
+
+// Device - устройство пользователя с собственным локальным хранилищем и сетью.
+type Device struct {
+	Name    string   // имя устройства, используется в сообщениях тестов
+	Network *Network // сеть устройства
+
+	addr       string
+	stor       *clientMemory.Store
+	info       *info.UserInfoStorage
+	client     *resty.Client // клиент для регистрации и авторизации
+	authClient *resty.Client // клиент с авторизационными мидлварями для работы с данными
+}
+
+// NewDevice - создает новое устройство, подключенное к серверу.
+func (s *Server) NewDevice(name string) *Device {
+	network := NewNetwork(http.DefaultTransport)
+	stor := clientMemory.NewStore()
+	userInfo := info.NewUserInfoStorage()
+
+	client := resty.New().SetTransport(network).SetTimeout(5 * time.Second)
+	authClient := resty.New().SetTransport(network).SetTimeout(5 * time.Second)
+	authClient.OnBeforeRequest(auth.OnBeforeMiddleware(userInfo, stor))
+	authClient.OnAfterResponse(auth.OnAfterMiddleware(userInfo, stor, s.srv.URL+api.AuthorizationPattern))
+
+	return &Device{
+		Name:       name,
+		Network:    network,
+		addr:       s.srv.URL,
+		stor:       stor,
+		info:       userInfo,
+		client:     client,
+		authClient: authClient,
+	}
+}
+
+// Register - регистрирует нового пользователя с данного устройства и авторизует его.
+func (d *Device) Register(ctx context.Context, login, password string) error {
+	authData := &identity.AuthData{Login: login, Password: password}
+	ok, err := handlers.Register(ctx, d.addr+api.RegisterPattern, authData, d.client, d.stor)
+	if err != nil {
+		return fmt.Errorf("device %s: failed to register user %s, %w", d.Name, login, err)
+	}
+	if !ok {
+		return fmt.Errorf("device %s: user %s already register", d.Name, login)
+	}
+	correctPass, registered, err := handlers.Authorize(ctx, authData, d.stor, d.info)
+	if err != nil {
+		return fmt.Errorf("device %s: failed to authorize user %s, %w", d.Name, login, err)
+	}
+	if !correctPass || !registered {
+		return fmt.Errorf("device %s: user %s not authorized after registration", d.Name, login)
+	}
+	return nil
+}
+
+// Login - выполняет вход существующего пользователя через сервер.
+func (d *Device) Login(ctx context.Context, login, password string) error {
+	authData := &identity.AuthData{Login: login, Password: password}
+	ok, err := handlers.Login(ctx, d.addr+api.AuthorizationPattern, authData, d.client, d.stor, d.info)
+	if err != nil {
+		return fmt.Errorf("device %s: failed to login user %s, %w", d.Name, login, err)
+	}
+	if !ok {
+		return fmt.Errorf("device %s: server rejected user %s", d.Name, login)
+	}
+	return nil
+}
+
+// Save - сохраняет новые текстовые данные.
+func (d *Device) Save(ctx context.Context, name, text string) error {
+	authData, id := d.info.Get()
+	userData, err := newTextData(name, text)
+	if err != nil {
+		return err
+	}
+	ok, err := handlers.SaveData(ctx, id, d.addr+api.AddDataPattern, authData.Password, d.authClient, d.stor, userData)
+	if err != nil {
+		return fmt.Errorf("device %s: failed to save data %s, %w", d.Name, name, err)
+	}
+	if !ok {
+		return fmt.Errorf("device %s: data %s is already exists", d.Name, name)
+	}
+	return nil
+}
+
+// Replace - заменяет существующие данные новым текстом.
+func (d *Device) Replace(ctx context.Context, name, text string) error {
+	authData, id := d.info.Get()
+	userData, err := newTextData(name, text)
+	if err != nil {
+		return err
+	}
+	ok, err := handlers.ReplaceData(ctx, id, d.addr+api.ReplaceDataPattern, authData.Password, d.authClient, d.stor, userData)
+	if err != nil {
+		return fmt.Errorf("device %s: failed to replace data %s, %w", d.Name, name, err)
+	}
+	if !ok {
+		return fmt.Errorf("device %s: data %s does not exist", d.Name, name)
+	}
+	return nil
+}
+
+// Delete - удаляет данные. Удаление возможно только в режиме онлайн.
+func (d *Device) Delete(ctx context.Context, name string) error {
+	_, id := d.info.Get()
+	ok, err := handlers.DeleteEncryptedData(ctx, id, d.addr+api.DeleteDataPattern, name, d.authClient, d.stor)
+	if err != nil {
+		return fmt.Errorf("device %s: failed to delete data %s, %w", d.Name, name, err)
+	}
+	if !ok {
+		return fmt.Errorf("device %s: data %s does not exist", d.Name, name)
+	}
+	return nil
+}
+
+// Sync - выполняет один цикл синхронизации данных с сервером, как это делает фоновая синхронизация клиента.
+func (d *Device) Sync(ctx context.Context) error {
+	err := synchronization.SynchronizeData(ctx, d.stor, d.info, d.authClient,
+		d.addr+api.AddDataPattern, d.addr+api.ConflictDataPattern, d.addr+api.GetDataPattern)
+	if err != nil {
+		return fmt.Errorf("device %s: failed to synchronize data, %w", d.Name, err)
+	}
+	return nil
+}
+
+// Records - возвращает расшифрованные данные из локального хранилища устройства.
+// Результат содержит все версии данных по имени данных.
+func (d *Device) Records(ctx context.Context) (map[string][]string, error) {
+	authData, id := d.info.Get()
+	encrData, err := d.stor.GetAllEncryptedData(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("device %s: failed to get local data, %w", d.Name, err)
+	}
+	return decrypt(ctx, authData.Password, encrData)
+}
+
+// Status - возвращает статус данных в локальном хранилище устройства.
+func (d *Device) Status(ctx context.Context, name string) (int, bool, error) {
+	_, id := d.info.Get()
+	return d.stor.GetStatus(ctx, id, name)
+}
+
+// newTextData - создает текстовые данные пользователя.
+func newTextData(name, text string) (*data.Data, error) {
+	payload, err := json.Marshal(clientData.Text{Text: text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal text data, %w", err)
+	}
+	return &data.Data{
+		Data:       payload,
+		Type:       data.TEXT,
+		Name:       name,
+		CreateDate: time.Now(),
+	}, nil
+}
+
+// decrypt - расшифровывает все версии данных и возвращает текст каждой версии по имени данных.
+func decrypt(ctx context.Context, password string, encrData [][]data.EncryptedData) (map[string][]string, error) {
+	result := make(map[string][]string, len(encrData))
+	for _, versions := range encrData {
+		for _, v := range versions {
+			userData, err := encr.DecryptData(ctx, password, &v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt data %s, %w", v.Name, err)
+			}
+			var text clientData.Text
+			if err := json.Unmarshal(userData.Data, &text); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal data %s, %w", v.Name, err)
+			}
+			result[v.Name] = append(result[v.Name], text.Text)
+		}
+	}
+	return result, nil
+}
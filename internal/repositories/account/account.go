@@ -0,0 +1,42 @@
+// Пакет account содержит сведения об учетных записях пользователей, которые сервер предоставляет администратору,
+// и состояние сессий пользователя, по которому сервер принимает или отклоняет токены.
+package account
+
+import "time"
+
+// User - учетная запись пользователя в списке администратора. Содержимое данных пользователя администратору
+// недоступно, известны только их количество и объем.
+type User struct {
+	ID       string `json:"id"`       // id пользователя
+	Login    string `json:"login"`    // логин пользователя
+	Disabled bool   `json:"disabled"` // учетная запись заблокирована
+	Records  int    `json:"records"`  // количество записей пользователя
+	Bytes    int64  `json:"bytes"`    // объем зашифрованных данных и частей вложений пользователя в байтах
+}
+
+// Session - состояние сессий пользователя.
+type Session struct {
+	Disabled bool      // учетная запись заблокирована, токены пользователя не принимаются
+	LogoutAt time.Time // токены, выданные до этого момента, не принимаются; нулевое время не ограничивает токены
+}
+
+// Valid - метод для проверки, принимается ли токен, выданный в момент issuedAt. Время выдачи токена хранится
+// с точностью до секунды, поэтому момент выхода сравнивается с той же точностью.
+func (s Session) Valid(issuedAt time.Time) bool {
+	if s.Disabled {
+		return false
+	}
+	return s.LogoutAt.IsZero() || !issuedAt.Before(s.LogoutAt.Truncate(time.Second))
+}
+
+// Stats - статистика сервера.
+type Stats struct {
+	Users         int   `json:"users"`          // количество учетных записей
+	DisabledUsers int   `json:"disabled_users"` // количество заблокированных учетных записей
+	Records       int   `json:"records"`        // количество записей всех пользователей, общих записей и записей организаций
+	Versions      int   `json:"versions"`       // количество версий записей всех пользователей, общих записей и записей организаций
+	Attachments   int   `json:"attachments"`    // количество вложений
+	Chunks        int   `json:"chunks"`         // количество частей вложений
+	Bytes         int64 `json:"bytes"`          // объем зашифрованных данных и частей вложений в байтах
+	AuditEvents   int64 `json:"audit_events"`   // количество событий журнала аудита
+}
@@ -0,0 +1,31 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionValid(t *testing.T) {
+	logoutAt := time.Date(2024, 1, 1, 12, 0, 0, 500_000_000, time.UTC)
+
+	tests := []struct {
+		name     string
+		session  Session
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "without logout", session: Session{}, issuedAt: logoutAt, want: true},
+		{name: "disabled", session: Session{Disabled: true}, issuedAt: logoutAt, want: false},
+		{name: "issued before logout", session: Session{LogoutAt: logoutAt}, issuedAt: logoutAt.Add(-time.Second), want: false},
+		{name: "issued in second of logout", session: Session{LogoutAt: logoutAt}, issuedAt: logoutAt.Truncate(time.Second), want: true},
+		{name: "issued after logout", session: Session{LogoutAt: logoutAt}, issuedAt: logoutAt.Add(time.Second), want: true},
+		{name: "disabled after logout", session: Session{Disabled: true, LogoutAt: logoutAt}, issuedAt: logoutAt.Add(time.Second), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.session.Valid(tt.issuedAt))
+		})
+	}
+}
@@ -0,0 +1,38 @@
+// Пакет api содержит адреса и ошибки REST API сервера, общие для клиента и сервера.
+package api
+
+// Prefix - префикс текущей версии REST API.
+const Prefix = "/api/v1"
+
+// LegacyPrefix - префикс REST API без версии. Адреса с этим префиксом обслуживаются для совместимости со старыми
+// клиентами и считаются устаревшими.
+const LegacyPrefix = "/api/client"
+
+// Паттерны api сервера.
+const (
+	RegisterPattern      = Prefix + "/register"      // паттерн api для регистрации пользователя
+	AuthorizationPattern = Prefix + "/authorize"     // паттерн api для авторизации пользователя
+	AddDataPattern       = Prefix + "/data/add"      // паттерн api для добавления новых данных на сервер
+	ReplaceDataPattern   = Prefix + "/data/replace"  // паттерн для замены старых данных на сервере новыми
+	ConflictDataPattern  = Prefix + "/data/conflict" // паттерн для обработки данных с потенциальным конфликтом
+	DeleteDataPattern    = Prefix + "/data/delete"   // паттерн для удаления данных
+	GetDataPattern       = Prefix + "/data/get"      // паттерн для получения данных от сервера
+	AttachmentPattern    = Prefix + "/attachment"    // паттерн для управления ссылками на вложения
+	ChunkPattern         = Prefix + "/chunk"         // паттерн для потоковой передачи частей вложений
+	UsagePattern         = Prefix + "/usage"         // паттерн для получения информации об использовании хранилища
+	AuditPattern         = Prefix + "/audit"         // паттерн для получения журнала аудита пользователя
+	AccountPattern       = Prefix + "/account"       // паттерн для удаления пользователем своей учетной записи
+	KeysPattern          = Prefix + "/keys"          // паттерн для управления ключевыми парами пользователей
+	SharesPattern        = Prefix + "/shares"        // паттерн для управления общими записями
+	OrgsPattern          = Prefix + "/orgs"          // паттерн для управления организациями и их коллекциями
+	AdminAuditPattern    = Prefix + "/admin/audit"   // паттерн для выгрузки журнала аудита администратором
+	AdminUsersPattern    = Prefix + "/admin/users"   // паттерн для управления учетными записями администратором
+	AdminStatsPattern    = Prefix + "/admin/stats"   // паттерн для получения статистики сервера администратором
+	OpenAPIPattern       = Prefix + "/openapi.json"  // паттерн для получения спецификации OpenAPI
+)
+
+// Заголовки запросов REST API.
+const (
+	DeviceHeader     = "X-Gophkeeper-Device" // имя устройства клиента, которое сохраняется в журнале аудита
+	AdminTokenHeader = "X-Admin-Token"       // токен администратора сервера, не связанный с JWT пользователей
+)
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Коды ошибок REST API. Коды стабильны: клиенты принимают решения по коду ошибки, текст сообщения предназначен
+// только для человека и может меняться.
+const (
+	CodeInvalidRequest     = "invalid_request"      // тело или параметры запроса некорректны
+	CodeInvalidLogin       = "invalid_login"        // логин не удовлетворяет требованиям
+	CodeInvalidHash        = "invalid_hash"         // хэш логина и пароля не удовлетворяет требованиям
+	CodeInvalidCredentials = "invalid_credentials"  // пользователь не зарегистрирован или пароль неверный
+	CodeLoginExists        = "login_exists"         // пользователь с таким логином уже зарегистрирован
+	CodeUnauthorized       = "unauthorized"         // токен не передан, недействителен или истек
+	CodeAccountDisabled    = "account_disabled"     // учетная запись пользователя заблокирована администратором
+	CodeUserNotFound       = "user_not_found"       // пользователь с таким id не существует
+	CodeDataExists         = "data_exists"          // данные с таким именем уже существуют
+	CodeDataNotFound       = "data_not_found"       // данные с таким именем не существуют
+	CodeAttachmentNotFound = "attachment_not_found" // вложение не существует
+	CodeAttachmentConflict = "attachment_conflict"  // вложение с таким id уже содержит другие части
+	CodeChunkNotFound      = "chunk_not_found"      // часть вложения не существует
+	CodeChunksMissing      = "chunks_missing"       // часть вложения еще не загружена на сервер
+	CodeChunkHashMismatch  = "chunk_hash_mismatch"  // хэш содержимого части не совпадает с адресом
+	CodePayloadTooLarge    = "payload_too_large"    // тело запроса превышает ограничение сервера
+	CodeQuotaExceeded      = "quota_exceeded"       // превышена квота пользователя
+	CodeKeyNotFound        = "key_not_found"        // пользователь не найден или не сохранил ключевую пару
+	CodeShareNotFound      = "share_not_found"      // общая запись не существует или недоступна пользователю
+	CodeShareExists        = "share_exists"         // владелец уже поделился записью с этим получателем
+	CodeShareForbidden     = "share_forbidden"      // действие с общей записью не разрешено пользователю
+	CodeShareConflict      = "share_conflict"       // общая запись изменена после получения её версии
+	CodeOrgNotFound        = "org_not_found"        // организация не существует или пользователь не является её участником
+	CodeOrgForbidden       = "org_forbidden"        // роль участника не разрешает действие
+	CodeOrgConflict        = "org_conflict"         // участники, ключ или записи организации изменены после получения
+	CodeMemberExists       = "member_exists"        // пользователь уже является участником организации
+	CodeMemberNotFound     = "member_not_found"     // пользователь не является участником организации
+	CodeNotFound           = "not_found"            // адрес не найден
+	CodeInternal           = "internal"             // внутренняя ошибка сервера
+)
+
+// Error - ошибка REST API. Сервер возвращает ее в теле ответа в виде {"error": {"code": ..., "message": ...}}.
+type Error struct {
+	Status  int    `json:"-"`       // статус ответа сервера
+	Code    string `json:"code"`    // стабильный код ошибки
+	Message string `json:"message"` // описание ошибки для человека
+}
+
+// Error - метод для получения текста ошибки.
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// ErrorResponse - тело ответа сервера с ошибкой.
+type ErrorResponse struct {
+	Error *Error `json:"error"`
+}
+
+// WriteError - функция для записи ответа с ошибкой REST API.
+func WriteError(res http.ResponseWriter, status int, code, message string) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("X-Content-Type-Options", "nosniff")
+	res.WriteHeader(status)
+	_ = json.NewEncoder(res).Encode(ErrorResponse{Error: &Error{Code: code, Message: message}})
+}
+
+// ParseError - функция для получения ошибки REST API из ответа сервера со статусом status и телом body.
+// Если тело ответа не содержит ошибку REST API (например, ответ прокси сервера), код ошибки определяется по статусу.
+func ParseError(status int, body []byte) *Error {
+	var resp ErrorResponse
+	if err := json.Unmarshal(body, &resp); err == nil && resp.Error != nil && resp.Error.Code != "" {
+		resp.Error.Status = status
+		return resp.Error
+	}
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &Error{Status: status, Code: codeByStatus(status), Message: message}
+}
+
+// IsCode - функция для проверки, что err содержит ошибку REST API с кодом code.
+func IsCode(err error, code string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// codeByStatus - функция для получения кода ошибки по статусу ответа.
+func codeByStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeAccountDisabled
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusInsufficientStorage:
+		return CodeQuotaExceeded
+	default:
+		return CodeInternal
+	}
+}
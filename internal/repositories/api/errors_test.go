@@ -0,0 +1,45 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteAndParseError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteError(rec, http.StatusConflict, CodeDataExists, "data is already exist")
+
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	err := ParseError(rec.Code, rec.Body.Bytes())
+	assert.Equal(t, &Error{Status: http.StatusConflict, Code: CodeDataExists, Message: "data is already exist"}, err)
+	assert.True(t, IsCode(fmt.Errorf("wrapped, %w", err), CodeDataExists))
+	assert.False(t, IsCode(err, CodeDataNotFound))
+}
+
+func TestParseErrorWithoutEnvelope(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		code    string
+		message string
+	}{
+		{name: "plain text", status: http.StatusBadRequest, body: "bad request\n", code: CodeInvalidRequest, message: "bad request"},
+		{name: "empty body", status: http.StatusUnauthorized, code: CodeUnauthorized, message: "Unauthorized"},
+		{name: "json without code", status: http.StatusBadGateway, body: `{"error": {}}`, code: CodeInternal, message: `{"error": {}}`},
+		{name: "quota", status: http.StatusInsufficientStorage, body: "quota", code: CodeQuotaExceeded, message: "quota"},
+		{name: "forbidden", status: http.StatusForbidden, code: CodeAccountDisabled, message: "Forbidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseError(tt.status, []byte(tt.body))
+			assert.Equal(t, tt.status, err.Status)
+			assert.Equal(t, tt.code, err.Code)
+			assert.Equal(t, tt.message, err.Message)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Пакет audit содержит события журнала аудита сервера, общие для клиента и сервера.
+// Журнал фиксирует, кто и когда выполнил действие с учетной записью или данными. События не содержат
+// зашифрованных данных пользователя: в журнал попадает только имя записи.
+package audit
+
+import "time"
+
+// Типы событий журнала аудита.
+const (
+	LoginSuccess   = "login_success"   // успешный вход пользователя
+	LoginFailure   = "login_failure"   // вход с неверным логином или паролем
+	Register       = "register"        // регистрация пользователя
+	TokenRefresh   = "token_refresh"   // повторная авторизация клиента после истечения токена
+	RecordCreate   = "record_create"   // добавление новых данных
+	RecordReplace  = "record_replace"  // замена всех версий данных новой версией
+	RecordDelete   = "record_delete"   // удаление данных
+	RecordConflict = "record_conflict" // добавление версии данных при конфликте
+	AccountDisable = "account_disable" // блокировка учетной записи администратором
+	AccountEnable  = "account_enable"  // разблокировка учетной записи администратором
+	ForceLogout    = "force_logout"    // отзыв токенов пользователя администратором
+	AccountDelete  = "account_delete"  // удаление учетной записи со всеми данными администратором
+	ShareCreate    = "share_create"    // владелец поделился записью с другим пользователем
+	ShareAccept    = "share_accept"    // получатель принял общую запись
+	ShareUpdate    = "share_update"    // изменение содержимого общей записи
+	ShareRevoke    = "share_revoke"    // владелец отозвал общую запись или получатель отказался от неё
+	OrgCreate      = "org_create"      // создание организации
+	OrgDelete      = "org_delete"      // удаление организации владельцем
+	OrgMemberAdd   = "org_member_add"  // добавление участника организации
+	OrgMemberRole  = "org_member_role" // изменение роли участника организации
+	OrgKeyRotate   = "org_key_rotate"  // замена ключа коллекции, в том числе при удалении участника
+	OrgItemUpdate  = "org_item_update" // добавление или изменение записи коллекции организации
+	OrgItemDelete  = "org_item_delete" // удаление записи коллекции организации
+)
+
+// DefaultLimit - количество событий, которое возвращается пользователю, если ограничение не задано.
+const DefaultLimit = 100
+
+// MaxLimit - максимальное количество событий в одном ответе пользователю.
+const MaxLimit = 1000
+
+// Event - событие журнала аудита.
+type Event struct {
+	ID       int64     `json:"id"`                  // порядковый номер события
+	Time     time.Time `json:"time"`                // время события на сервере
+	Type     string    `json:"type"`                // тип события
+	UserID   string    `json:"user_id,omitempty"`   // id пользователя, пустой при входе с незарегистрированным логином
+	Login    string    `json:"login,omitempty"`     // логин, указанный при регистрации или входе
+	RecordID string    `json:"record_id,omitempty"` // имя данных, которое служит их идентификатором
+	Device   string    `json:"device,omitempty"`    // устройство клиента
+	IP       string    `json:"ip,omitempty"`        // IP адрес клиента
+}
+
+// Filter - условия выборки событий журнала аудита.
+type Filter struct {
+	UserID string    // id пользователя, пустой id выбирает события всех пользователей
+	Since  time.Time // нижняя граница времени событий включительно, нулевое время не ограничивает выборку
+	Until  time.Time // верхняя граница времени событий не включительно, нулевое время не ограничивает выборку
+	Limit  int       // максимальное количество событий, 0 не ограничивает выборку
+	Desc   bool      // порядок от новых событий к старым
+}
@@ -8,6 +8,8 @@ const (
 	TEXT
 	BINARY
 	BANKCARD
+	LOGIN  // учетная запись с адресами, заметками, секретом TOTP и дополнительными полями
+	SSHKEY // SSH ключ с необязательными сертификатом и парольной фразой
 )
 
 // Возможные статусы данных.
@@ -27,6 +29,12 @@ type Data struct {
 	Status     int       `json:"status,omitempty"`    // статус данных. Новые данные, сохранены на сервере, изменены
 	CreateDate time.Time `json:"create_data"`         // дата создания данных
 	EditDate   time.Time `json:"edit_date,omitempty"` // дата редактирования данных
+
+	// Теги, папка и отметка избранного шифруются вместе с данными, поэтому сервер не получает сведений
+	// о том, как пользователь организует свои данные.
+	Tags     []string `json:"tags,omitempty"`     // теги данных
+	Folder   string   `json:"folder,omitempty"`   // путь папки с разделителем "/", пустой путь - корневая папка
+	Favorite bool     `json:"favorite,omitempty"` // данные отмечены как избранные
 }
 
 // EncryptedData - структура зашифрованных данных для хранения в базе данных.
@@ -35,6 +43,43 @@ type EncryptedData struct {
 	Name          string `json:"name"`           // уникальное имя сохраняемых данных
 }
 
+// Параметры передачи вложений частями.
+const (
+	ChunkSize             = 1 << 20          // размер части вложения до шифрования в байтах
+	MaxEncryptedChunkSize = ChunkSize + 1024 // максимальный размер зашифрованной части, которую принимает сервер
+)
+
+// AttachmentInfo - структура для передачи информации о вложении, сохраненном на сервере.
+// Части вложения адресуются SHA-256 хэшем зашифрованной части в шестнадцатеричном виде.
+type AttachmentInfo struct {
+	ID     string   `json:"id"`     // идентификатор вложения
+	Chunks []string `json:"chunks"` // хэши частей вложения в порядке следования
+	Refs   int      `json:"refs"`   // количество записей, ссылающихся на вложение
+}
+
+// ChunkList - структура для передачи списка хэшей частей вложения.
+// Клиент передает хэши частей для проверки их наличия на сервере и для создания ссылки на вложение.
+type ChunkList struct {
+	Chunks []string `json:"chunks"` // хэши частей вложения
+}
+
+// Usage - структура для передачи информации об использовании хранилища пользователем и его квотах.
+// Нулевое значение ограничения означает отсутствие ограничения.
+type Usage struct {
+	Bytes       int64 `json:"bytes"`        // объем зашифрованных данных и частей вложений пользователя в байтах
+	Records     int   `json:"records"`      // количество записей пользователя
+	Versions    int   `json:"versions"`     // наибольшее количество версий одной записи
+	MaxBytes    int64 `json:"max_bytes"`    // ограничение объема хранимых данных в байтах
+	MaxRecords  int   `json:"max_records"`  // ограничение количества записей
+	MaxVersions int   `json:"max_versions"` // ограничение количества версий одной записи
+}
+
+// RecordUsage - структура с информацией об использовании хранилища одной записью пользователя.
+type RecordUsage struct {
+	Bytes    int64 // объем всех версий записи в байтах
+	Versions int   // количество версий записи
+}
+
 // MetaInfo - структура для передачи метаинформации о данных.
 // Например для удаления данных клиент помещает уникальное имя данных в структуру и передает серверу.
 type MetaInfo struct {
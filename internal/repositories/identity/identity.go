@@ -16,6 +16,7 @@ type Data struct {
 
 // AuthorizationData - структуоа для авторизационных данных пользователя.
 type AuthorizationData struct {
-	Hash string
-	ID   string
+	Hash     string
+	ID       string
+	Disabled bool // учетная запись заблокирована администратором
 }
@@ -0,0 +1,175 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/abezemskiy/gophkeeper/internal/server/storage (interfaces: IAttachmentStorage)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	data "github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIAttachmentStorage is a mock of IAttachmentStorage interface.
+type MockIAttachmentStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockIAttachmentStorageMockRecorder
+}
+
+// MockIAttachmentStorageMockRecorder is the mock recorder for MockIAttachmentStorage.
+type MockIAttachmentStorageMockRecorder struct {
+	mock *MockIAttachmentStorage
+}
+
+// NewMockIAttachmentStorage creates a new mock instance.
+func NewMockIAttachmentStorage(ctrl *gomock.Controller) *MockIAttachmentStorage {
+	mock := &MockIAttachmentStorage{ctrl: ctrl}
+	mock.recorder = &MockIAttachmentStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIAttachmentStorage) EXPECT() *MockIAttachmentStorageMockRecorder {
+	return m.recorder
+}
+
+// AddAttachmentRef mocks base method.
+func (m *MockIAttachmentStorage) AddAttachmentRef(arg0 context.Context, arg1, arg2, arg3 string, arg4 []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAttachmentRef", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAttachmentRef indicates an expected call of AddAttachmentRef.
+func (mr *MockIAttachmentStorageMockRecorder) AddAttachmentRef(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAttachmentRef", reflect.TypeOf((*MockIAttachmentStorage)(nil).AddAttachmentRef), arg0, arg1, arg2, arg3, arg4)
+}
+
+// DeleteUnusedChunks mocks base method.
+func (m *MockIAttachmentStorage) DeleteUnusedChunks(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUnusedChunks", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteUnusedChunks indicates an expected call of DeleteUnusedChunks.
+func (mr *MockIAttachmentStorageMockRecorder) DeleteUnusedChunks(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnusedChunks", reflect.TypeOf((*MockIAttachmentStorage)(nil).DeleteUnusedChunks), arg0, arg1)
+}
+
+// GetAttachment mocks base method.
+func (m *MockIAttachmentStorage) GetAttachment(arg0 context.Context, arg1, arg2 string) (data.AttachmentInfo, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttachment", arg0, arg1, arg2)
+	ret0, _ := ret[0].(data.AttachmentInfo)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAttachment indicates an expected call of GetAttachment.
+func (mr *MockIAttachmentStorageMockRecorder) GetAttachment(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttachment", reflect.TypeOf((*MockIAttachmentStorage)(nil).GetAttachment), arg0, arg1, arg2)
+}
+
+// GetChunk mocks base method.
+func (m *MockIAttachmentStorage) GetChunk(arg0 context.Context, arg1, arg2 string) ([]byte, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChunk", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetChunk indicates an expected call of GetChunk.
+func (mr *MockIAttachmentStorageMockRecorder) GetChunk(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChunk", reflect.TypeOf((*MockIAttachmentStorage)(nil).GetChunk), arg0, arg1, arg2)
+}
+
+// GetMissingChunks mocks base method.
+func (m *MockIAttachmentStorage) GetMissingChunks(arg0 context.Context, arg1 string, arg2 []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMissingChunks", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMissingChunks indicates an expected call of GetMissingChunks.
+func (mr *MockIAttachmentStorageMockRecorder) GetMissingChunks(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMissingChunks", reflect.TypeOf((*MockIAttachmentStorage)(nil).GetMissingChunks), arg0, arg1, arg2)
+}
+
+// GetRecordUsage mocks base method.
+func (m *MockIAttachmentStorage) GetRecordUsage(arg0 context.Context, arg1, arg2 string) (data.RecordUsage, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecordUsage", arg0, arg1, arg2)
+	ret0, _ := ret[0].(data.RecordUsage)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRecordUsage indicates an expected call of GetRecordUsage.
+func (mr *MockIAttachmentStorageMockRecorder) GetRecordUsage(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecordUsage", reflect.TypeOf((*MockIAttachmentStorage)(nil).GetRecordUsage), arg0, arg1, arg2)
+}
+
+// GetUsage mocks base method.
+func (m *MockIAttachmentStorage) GetUsage(arg0 context.Context, arg1 string) (data.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsage", arg0, arg1)
+	ret0, _ := ret[0].(data.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsage indicates an expected call of GetUsage.
+func (mr *MockIAttachmentStorageMockRecorder) GetUsage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsage", reflect.TypeOf((*MockIAttachmentStorage)(nil).GetUsage), arg0, arg1)
+}
+
+// ReleaseAttachment mocks base method.
+func (m *MockIAttachmentStorage) ReleaseAttachment(arg0 context.Context, arg1, arg2, arg3 string) (int, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseAttachment", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ReleaseAttachment indicates an expected call of ReleaseAttachment.
+func (mr *MockIAttachmentStorageMockRecorder) ReleaseAttachment(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseAttachment", reflect.TypeOf((*MockIAttachmentStorage)(nil).ReleaseAttachment), arg0, arg1, arg2, arg3)
+}
+
+// SaveChunk mocks base method.
+func (m *MockIAttachmentStorage) SaveChunk(arg0 context.Context, arg1, arg2 string, arg3 []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveChunk", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveChunk indicates an expected call of SaveChunk.
+func (mr *MockIAttachmentStorageMockRecorder) SaveChunk(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveChunk", reflect.TypeOf((*MockIAttachmentStorage)(nil).SaveChunk), arg0, arg1, arg2, arg3)
+}
@@ -95,6 +95,37 @@ func (mr *MockIEncryptedServerStorageMockRecorder) GetAllEncryptedData(arg0, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllEncryptedData", reflect.TypeOf((*MockIEncryptedServerStorage)(nil).GetAllEncryptedData), arg0, arg1)
 }
 
+// GetRecordUsage mocks base method.
+func (m *MockIEncryptedServerStorage) GetRecordUsage(arg0 context.Context, arg1, arg2 string) (data.RecordUsage, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecordUsage", arg0, arg1, arg2)
+	ret0, _ := ret[0].(data.RecordUsage)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRecordUsage indicates an expected call of GetRecordUsage.
+func (mr *MockIEncryptedServerStorageMockRecorder) GetRecordUsage(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecordUsage", reflect.TypeOf((*MockIEncryptedServerStorage)(nil).GetRecordUsage), arg0, arg1, arg2)
+}
+
+// GetUsage mocks base method.
+func (m *MockIEncryptedServerStorage) GetUsage(arg0 context.Context, arg1 string) (data.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsage", arg0, arg1)
+	ret0, _ := ret[0].(data.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsage indicates an expected call of GetUsage.
+func (mr *MockIEncryptedServerStorageMockRecorder) GetUsage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsage", reflect.TypeOf((*MockIEncryptedServerStorage)(nil).GetUsage), arg0, arg1)
+}
+
 // ReplaceEncryptedData mocks base method.
 func (m *MockIEncryptedServerStorage) ReplaceEncryptedData(arg0 context.Context, arg1 string, arg2 data.EncryptedData, arg3 int) (bool, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,98 @@
+// Пакет org содержит сведения об организациях, их участниках и общей коллекции записей организации.
+// Записи коллекции шифруются ключом коллекции, а ключ коллекции - открытым ключом каждого участника, поэтому сервер
+// не может прочитать ни ключ, ни записи. При удалении участника ключ коллекции заменяется новым, и все записи
+// коллекции шифруются заново.
+package org
+
+import "time"
+
+// Роли участников организации в порядке убывания прав.
+const (
+	Owner  = "owner"  // создатель организации, управляет всеми участниками и может удалить организацию
+	Admin  = "admin"  // управляет редакторами и читателями, изменяет записи
+	Editor = "editor" // изменяет записи
+	Viewer = "viewer" // только читает записи
+)
+
+// rank - функция для получения уровня прав роли. Неизвестная роль не имеет прав.
+func rank(role string) int {
+	switch role {
+	case Owner:
+		return 4
+	case Admin:
+		return 3
+	case Editor:
+		return 2
+	case Viewer:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// ValidRole - функция для проверки, что role является допустимой ролью участника.
+func ValidRole(role string) bool {
+	return rank(role) > 0
+}
+
+// CanWrite - функция для проверки, что участник с ролью role может изменять записи коллекции.
+func CanWrite(role string) bool {
+	return rank(role) >= rank(Editor)
+}
+
+// CanManage - функция для проверки, что участник с ролью role может управлять участником с ролью target
+// или назначить ему роль target: управлять можно только участниками с меньшими правами.
+func CanManage(role, target string) bool {
+	return rank(role) >= rank(Admin) && ValidRole(target) && rank(target) < rank(role)
+}
+
+// Org - организация с ролью и ключом коллекции пользователя, который её запрашивает.
+type Org struct {
+	ID         string    `json:"id"`          // id организации
+	Name       string    `json:"name"`        // название организации
+	KeyVersion int64     `json:"key_version"` // версия ключа коллекции, увеличивается при каждой замене ключа
+	CreatedAt  time.Time `json:"created_at"`  // время создания
+	Role       string    `json:"role"`        // роль пользователя
+	Key        []byte    `json:"key"`         // ключ коллекции, зашифрованный открытым ключом пользователя
+}
+
+// Member - участник организации.
+type Member struct {
+	Login      string `json:"login"`         // логин участника
+	Role       string `json:"role"`          // роль участника
+	Key        []byte `json:"key,omitempty"` // ключ коллекции, зашифрованный открытым ключом участника
+	KeyVersion int64  `json:"key_version"`   // версия ключа коллекции
+	UserID     string `json:"-"`             // id участника
+}
+
+// New - запрос на создание организации. Создатель становится её владельцем.
+type New struct {
+	Name string `json:"name"` // название организации
+	Key  []byte `json:"key"`  // ключ коллекции, зашифрованный открытым ключом создателя
+}
+
+// Role - запрос на изменение роли участника.
+type Role struct {
+	Role string `json:"role"` // новая роль участника
+}
+
+// Item - запись коллекции организации. В запросе на изменение или удаление записи Version - версия, от которой
+// сделано изменение, 0 для новой записи; в ответе - новая версия.
+type Item struct {
+	Name       string    `json:"name"`           // имя записи
+	Data       []byte    `json:"data,omitempty"` // содержимое, зашифрованное ключом коллекции
+	KeyVersion int64     `json:"key_version"`    // версия ключа коллекции, которым зашифровано содержимое
+	Version    int64     `json:"version"`        // версия записи
+	UpdatedAt  time.Time `json:"updated_at"`     // время последнего изменения
+}
+
+// Rotation - запрос на замену ключа коллекции, при котором из организации может быть удален участник. Запрос
+// содержит новый ключ, зашифрованный открытыми ключами всех оставшихся участников, и все записи коллекции,
+// зашифрованные новым ключом, с версиями, от которых они зашифрованы. Если участники или записи изменились после
+// получения их клиентом, замена ключа отклоняется.
+type Rotation struct {
+	Remove     string            `json:"remove,omitempty"` // логин удаляемого участника
+	KeyVersion int64             `json:"key_version"`      // новая версия ключа коллекции
+	Keys       map[string][]byte `json:"keys"`             // новый ключ коллекции, зашифрованный для участников, по логину
+	Items      []Item            `json:"items"`            // записи коллекции, зашифрованные новым ключом
+}
@@ -0,0 +1,47 @@
+package org
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidRole(t *testing.T) {
+	for _, role := range []string{Owner, Admin, Editor, Viewer} {
+		assert.True(t, ValidRole(role), role)
+	}
+	assert.False(t, ValidRole(""))
+	assert.False(t, ValidRole("guest"))
+}
+
+func TestCanWrite(t *testing.T) {
+	assert.True(t, CanWrite(Owner))
+	assert.True(t, CanWrite(Admin))
+	assert.True(t, CanWrite(Editor))
+	assert.False(t, CanWrite(Viewer))
+	assert.False(t, CanWrite(""))
+}
+
+func TestCanManage(t *testing.T) {
+	tests := []struct {
+		role   string
+		target string
+		want   bool
+	}{
+		{role: Owner, target: Admin, want: true},
+		{role: Owner, target: Viewer, want: true},
+		{role: Owner, target: Owner},
+		{role: Admin, target: Editor, want: true},
+		{role: Admin, target: Viewer, want: true},
+		{role: Admin, target: Admin},
+		{role: Admin, target: Owner},
+		{role: Editor, target: Viewer},
+		{role: Viewer, target: Viewer},
+		{role: Owner, target: "guest"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" manages "+tt.target, func(t *testing.T) {
+			assert.Equal(t, tt.want, CanManage(tt.role, tt.target))
+		})
+	}
+}
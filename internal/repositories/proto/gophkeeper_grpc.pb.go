@@ -0,0 +1,419 @@
+// gRPC API сервера GophKeeper. Сервис повторяет REST API: данные передаются в зашифрованном виде,
+// а токен пользователя передается в метаданных запроса "authorization" в виде "Bearer <token>".
+//
+// Код генерируется командой:
+//   protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//     internal/repositories/proto/gophkeeper.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: internal/repositories/proto/gophkeeper.proto
+
+package proto
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	GophKeeper_Register_FullMethodName           = "/gophkeeper.v1.GophKeeper/Register"
+	GophKeeper_Authorize_FullMethodName          = "/gophkeeper.v1.GophKeeper/Authorize"
+	GophKeeper_AddData_FullMethodName            = "/gophkeeper.v1.GophKeeper/AddData"
+	GophKeeper_ReplaceData_FullMethodName        = "/gophkeeper.v1.GophKeeper/ReplaceData"
+	GophKeeper_AppendConflictData_FullMethodName = "/gophkeeper.v1.GophKeeper/AppendConflictData"
+	GophKeeper_GetAllData_FullMethodName         = "/gophkeeper.v1.GophKeeper/GetAllData"
+	GophKeeper_DeleteData_FullMethodName         = "/gophkeeper.v1.GophKeeper/DeleteData"
+	GophKeeper_WatchChanges_FullMethodName       = "/gophkeeper.v1.GophKeeper/WatchChanges"
+)
+
+// GophKeeperClient is the client API for GophKeeper service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// GophKeeper - сервис регистрации и авторизации пользователей и хранения их зашифрованных данных.
+type GophKeeperClient interface {
+	// Register - регистрация нового пользователя. Возвращает токен пользователя.
+	Register(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*Token, error)
+	// Authorize - авторизация пользователя. Возвращает токен пользователя.
+	Authorize(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*Token, error)
+	// AddData - добавление новых данных. Если данные с таким именем уже существуют, возвращается ALREADY_EXISTS.
+	AddData(ctx context.Context, in *EncryptedData, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ReplaceData - замена всех версий существующих данных новой версией.
+	ReplaceData(ctx context.Context, in *EncryptedData, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// AppendConflictData - добавление новой версии к существующим данным в случае конфликта.
+	AppendConflictData(ctx context.Context, in *EncryptedData, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// GetAllData - получение всех данных пользователя со всеми версиями.
+	GetAllData(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*AllData, error)
+	// DeleteData - удаление данных по имени.
+	DeleteData(ctx context.Context, in *DataName, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// WatchChanges - поток уведомлений об изменении данных пользователя, в том числе сделанных через REST API.
+	WatchChanges(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Change], error)
+}
+
+type gophKeeperClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGophKeeperClient(cc grpc.ClientConnInterface) GophKeeperClient {
+	return &gophKeeperClient{cc}
+}
+
+func (c *gophKeeperClient) Register(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*Token, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Token)
+	err := c.cc.Invoke(ctx, GophKeeper_Register_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperClient) Authorize(ctx context.Context, in *Credentials, opts ...grpc.CallOption) (*Token, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Token)
+	err := c.cc.Invoke(ctx, GophKeeper_Authorize_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperClient) AddData(ctx context.Context, in *EncryptedData, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GophKeeper_AddData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperClient) ReplaceData(ctx context.Context, in *EncryptedData, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GophKeeper_ReplaceData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperClient) AppendConflictData(ctx context.Context, in *EncryptedData, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GophKeeper_AppendConflictData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperClient) GetAllData(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*AllData, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AllData)
+	err := c.cc.Invoke(ctx, GophKeeper_GetAllData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperClient) DeleteData(ctx context.Context, in *DataName, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GophKeeper_DeleteData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gophKeeperClient) WatchChanges(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Change], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GophKeeper_ServiceDesc.Streams[0], GophKeeper_WatchChanges_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[emptypb.Empty, Change]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GophKeeper_WatchChangesClient = grpc.ServerStreamingClient[Change]
+
+// GophKeeperServer is the server API for GophKeeper service.
+// All implementations must embed UnimplementedGophKeeperServer
+// for forward compatibility.
+//
+// GophKeeper - сервис регистрации и авторизации пользователей и хранения их зашифрованных данных.
+type GophKeeperServer interface {
+	// Register - регистрация нового пользователя. Возвращает токен пользователя.
+	Register(context.Context, *Credentials) (*Token, error)
+	// Authorize - авторизация пользователя. Возвращает токен пользователя.
+	Authorize(context.Context, *Credentials) (*Token, error)
+	// AddData - добавление новых данных. Если данные с таким именем уже существуют, возвращается ALREADY_EXISTS.
+	AddData(context.Context, *EncryptedData) (*emptypb.Empty, error)
+	// ReplaceData - замена всех версий существующих данных новой версией.
+	ReplaceData(context.Context, *EncryptedData) (*emptypb.Empty, error)
+	// AppendConflictData - добавление новой версии к существующим данным в случае конфликта.
+	AppendConflictData(context.Context, *EncryptedData) (*emptypb.Empty, error)
+	// GetAllData - получение всех данных пользователя со всеми версиями.
+	GetAllData(context.Context, *emptypb.Empty) (*AllData, error)
+	// DeleteData - удаление данных по имени.
+	DeleteData(context.Context, *DataName) (*emptypb.Empty, error)
+	// WatchChanges - поток уведомлений об изменении данных пользователя, в том числе сделанных через REST API.
+	WatchChanges(*emptypb.Empty, grpc.ServerStreamingServer[Change]) error
+	mustEmbedUnimplementedGophKeeperServer()
+}
+
+// UnimplementedGophKeeperServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGophKeeperServer struct{}
+
+func (UnimplementedGophKeeperServer) Register(context.Context, *Credentials) (*Token, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedGophKeeperServer) Authorize(context.Context, *Credentials) (*Token, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Authorize not implemented")
+}
+func (UnimplementedGophKeeperServer) AddData(context.Context, *EncryptedData) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddData not implemented")
+}
+func (UnimplementedGophKeeperServer) ReplaceData(context.Context, *EncryptedData) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplaceData not implemented")
+}
+func (UnimplementedGophKeeperServer) AppendConflictData(context.Context, *EncryptedData) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AppendConflictData not implemented")
+}
+func (UnimplementedGophKeeperServer) GetAllData(context.Context, *emptypb.Empty) (*AllData, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAllData not implemented")
+}
+func (UnimplementedGophKeeperServer) DeleteData(context.Context, *DataName) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteData not implemented")
+}
+func (UnimplementedGophKeeperServer) WatchChanges(*emptypb.Empty, grpc.ServerStreamingServer[Change]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchChanges not implemented")
+}
+func (UnimplementedGophKeeperServer) mustEmbedUnimplementedGophKeeperServer() {}
+func (UnimplementedGophKeeperServer) testEmbeddedByValue()                    {}
+
+// UnsafeGophKeeperServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GophKeeperServer will
+// result in compilation errors.
+type UnsafeGophKeeperServer interface {
+	mustEmbedUnimplementedGophKeeperServer()
+}
+
+func RegisterGophKeeperServer(s grpc.ServiceRegistrar, srv GophKeeperServer) {
+	// If the following call pancis, it indicates UnimplementedGophKeeperServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GophKeeper_ServiceDesc, srv)
+}
+
+func _GophKeeper_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Credentials)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeper_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServer).Register(ctx, req.(*Credentials))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeper_Authorize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Credentials)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServer).Authorize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeper_Authorize_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServer).Authorize(ctx, req.(*Credentials))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeper_AddData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedData)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServer).AddData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeper_AddData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServer).AddData(ctx, req.(*EncryptedData))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeper_ReplaceData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedData)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServer).ReplaceData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeper_ReplaceData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServer).ReplaceData(ctx, req.(*EncryptedData))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeper_AppendConflictData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EncryptedData)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServer).AppendConflictData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeper_AppendConflictData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServer).AppendConflictData(ctx, req.(*EncryptedData))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeper_GetAllData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServer).GetAllData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeper_GetAllData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServer).GetAllData(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeper_DeleteData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DataName)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GophKeeperServer).DeleteData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GophKeeper_DeleteData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GophKeeperServer).DeleteData(ctx, req.(*DataName))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GophKeeper_WatchChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GophKeeperServer).WatchChanges(m, &grpc.GenericServerStream[emptypb.Empty, Change]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GophKeeper_WatchChangesServer = grpc.ServerStreamingServer[Change]
+
+// GophKeeper_ServiceDesc is the grpc.ServiceDesc for GophKeeper service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GophKeeper_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gophkeeper.v1.GophKeeper",
+	HandlerType: (*GophKeeperServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _GophKeeper_Register_Handler,
+		},
+		{
+			MethodName: "Authorize",
+			Handler:    _GophKeeper_Authorize_Handler,
+		},
+		{
+			MethodName: "AddData",
+			Handler:    _GophKeeper_AddData_Handler,
+		},
+		{
+			MethodName: "ReplaceData",
+			Handler:    _GophKeeper_ReplaceData_Handler,
+		},
+		{
+			MethodName: "AppendConflictData",
+			Handler:    _GophKeeper_AppendConflictData_Handler,
+		},
+		{
+			MethodName: "GetAllData",
+			Handler:    _GophKeeper_GetAllData_Handler,
+		},
+		{
+			MethodName: "DeleteData",
+			Handler:    _GophKeeper_DeleteData_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchChanges",
+			Handler:       _GophKeeper_WatchChanges_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "internal/repositories/proto/gophkeeper.proto",
+}
@@ -0,0 +1,80 @@
+// Пакет share содержит сведения о ключевых парах пользователей и записях, которыми пользователи делятся друг
+// с другом. Сервер хранит только открытые ключи, зашифрованные закрытые ключи и зашифрованное содержимое записей,
+// поэтому не может прочитать ни ключи, ни данные.
+package share
+
+import "time"
+
+// Права получателя на запись.
+const (
+	Read  = "read"  // получатель может только читать запись
+	Write = "write" // получатель может изменять запись
+)
+
+// ValidPermission - функция для проверки, что permission является допустимым правом получателя.
+func ValidPermission(permission string) bool {
+	return permission == Read || permission == Write
+}
+
+// KeyPair - ключевая пара пользователя. Закрытый ключ зашифрован ключом хранилища пользователя.
+type KeyPair struct {
+	PublicKey           []byte `json:"public_key"`            // открытый ключ
+	EncryptedPrivateKey []byte `json:"encrypted_private_key"` // зашифрованный закрытый ключ
+}
+
+// PublicKey - открытый ключ пользователя, с которым делятся записью.
+type PublicKey struct {
+	Login     string `json:"login"`      // логин пользователя
+	PublicKey []byte `json:"public_key"` // открытый ключ
+}
+
+// Share - запись, которой владелец поделился с получателем. Содержимое записи зашифровано ключом записи, а ключ
+// записи зашифрован открытыми ключами владельца и получателя.
+type Share struct {
+	ID           string    `json:"id"`            // id общей записи
+	Name         string    `json:"name"`          // имя записи у владельца
+	Owner        string    `json:"owner"`         // логин владельца
+	Recipient    string    `json:"recipient"`     // логин получателя
+	Permission   string    `json:"permission"`    // права получателя
+	Accepted     bool      `json:"accepted"`      // получатель принял запись
+	OwnerKey     []byte    `json:"owner_key"`     // ключ записи, зашифрованный открытым ключом владельца
+	RecipientKey []byte    `json:"recipient_key"` // ключ записи, зашифрованный открытым ключом получателя
+	Data         []byte    `json:"data"`          // содержимое записи, зашифрованное ключом записи
+	Version      int64     `json:"version"`       // версия содержимого, увеличивается при каждом изменении
+	CreatedAt    time.Time `json:"created_at"`    // время создания
+	UpdatedAt    time.Time `json:"updated_at"`    // время последнего изменения содержимого
+
+	OwnerID     string `json:"-"` // id владельца
+	RecipientID string `json:"-"` // id получателя
+}
+
+// New - запрос владельца на создание общей записи.
+type New struct {
+	Name         string `json:"name"`          // имя записи у владельца
+	Recipient    string `json:"recipient"`     // логин получателя
+	Permission   string `json:"permission"`    // права получателя
+	OwnerKey     []byte `json:"owner_key"`     // ключ записи, зашифрованный открытым ключом владельца
+	RecipientKey []byte `json:"recipient_key"` // ключ записи, зашифрованный открытым ключом получателя
+	Data         []byte `json:"data"`          // содержимое записи, зашифрованное ключом записи
+}
+
+// Update - запрос на изменение содержимого общей записи. Содержимое изменяется, только если версия на сервере
+// совпадает с версией, от которой сделано изменение. В ответ на изменение сервер возвращает новую версию без содержимого.
+type Update struct {
+	Data    []byte `json:"data,omitempty"` // новое содержимое, зашифрованное ключом записи
+	Version int64  `json:"version"`        // версия, от которой сделано изменение
+}
+
+// Visible - метод для проверки, что общая запись доступна пользователю idUser как владельцу или получателю.
+func (s Share) Visible(idUser string) bool {
+	return s.OwnerID == idUser || s.RecipientID == idUser
+}
+
+// Writable - метод для проверки, что пользователь idUser может изменять содержимое общей записи: владелец может
+// всегда, получатель - после принятия записи с правом на изменение.
+func (s Share) Writable(idUser string) bool {
+	if s.OwnerID == idUser {
+		return true
+	}
+	return s.RecipientID == idUser && s.Accepted && s.Permission == Write
+}
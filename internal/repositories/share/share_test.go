@@ -0,0 +1,36 @@
+package share
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidPermission(t *testing.T) {
+	assert.True(t, ValidPermission(Read))
+	assert.True(t, ValidPermission(Write))
+	assert.False(t, ValidPermission(""))
+	assert.False(t, ValidPermission("admin"))
+}
+
+func TestShareAccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		share    Share
+		user     string
+		visible  bool
+		writable bool
+	}{
+		{name: "owner", share: Share{OwnerID: "owner", RecipientID: "recipient", Permission: Read}, user: "owner", visible: true, writable: true},
+		{name: "recipient read", share: Share{OwnerID: "owner", RecipientID: "recipient", Permission: Read, Accepted: true}, user: "recipient", visible: true},
+		{name: "recipient write", share: Share{OwnerID: "owner", RecipientID: "recipient", Permission: Write, Accepted: true}, user: "recipient", visible: true, writable: true},
+		{name: "recipient write not accepted", share: Share{OwnerID: "owner", RecipientID: "recipient", Permission: Write}, user: "recipient", visible: true},
+		{name: "other user", share: Share{OwnerID: "owner", RecipientID: "recipient", Permission: Write, Accepted: true}, user: "other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.visible, tt.share.Visible(tt.user))
+			assert.Equal(t, tt.writable, tt.share.Writable(tt.user))
+		})
+	}
+}
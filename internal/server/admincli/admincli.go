@@ -0,0 +1,277 @@
+// Пакет admincli содержит подкоманды администратора сервера "server admin". Команды работают с запущенным сервером
+// через REST API администратора и токен администратора, поэтому не требуют доступа к базе данных и JWT пользователя.
+package admincli
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/abezemskiy/gophkeeper/internal/client/apiclient"
+	"github.com/abezemskiy/gophkeeper/internal/common/tlsconfig"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/account"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// TokenEnv - переменная окружения с токеном администратора, та же, из которой его читает сервер.
+const TokenEnv = "GOPHKEEPER_SERVER_ADMIN_TOKEN"
+
+// Форматы вывода результата команд.
+const (
+	Plain = "plain"
+	JSON  = "json"
+)
+
+// Usage - справка по командам администратора.
+const Usage = `usage: server admin [flags] <command> [user]
+
+commands:
+  users           список учетных записей с количеством записей и объемом данных
+  stats           статистика сервера
+  disable <user>  блокировка учетной записи, действующие токены пользователя отклоняются
+  enable <user>   разблокировка учетной записи
+  logout <user>   принудительный выход пользователя на всех устройствах
+  delete <user>   удаление учетной записи со всеми данными (требует флага -yes)
+
+Пользователь задается логином или id. Токен администратора читается из флага -admin-token
+или переменной окружения GOPHKEEPER_SERVER_ADMIN_TOKEN.
+
+flags:
+`
+
+var (
+	// ErrUnknownCommand - ошибка вызова неизвестной команды.
+	ErrUnknownCommand = errors.New("unknown command")
+	// ErrNotConfirmed - ошибка удаления учетной записи без подтверждения.
+	ErrNotConfirmed = errors.New("deletion is not confirmed, use -yes")
+	// ErrUserNotFound - ошибка поиска пользователя по логину или id.
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// options - флаги команд администратора.
+type options struct {
+	server  string
+	token   string
+	tlsCA   string
+	tlsCert string
+	tlsKey  string
+	format  string
+	yes     bool
+}
+
+// Admin - клиент администратора сервера.
+type Admin struct {
+	client *apiclient.Client
+	format string
+	out    io.Writer
+}
+
+// New - фабричная функция клиента администратора. client должен быть настроен на токен администратора,
+// format - формат вывода Plain или JSON, out - поток вывода команд.
+func New(client *apiclient.Client, format string, out io.Writer) *Admin {
+	return &Admin{client: client, format: format, out: out}
+}
+
+// Main - функция для выполнения подкоманды администратора с аргументами args, следующими за "admin".
+// Справка и ошибки разбора флагов выводятся в stderr.
+func Main(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var opts options
+	fs := flag.NewFlagSet("admin", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.server, "server", "http://localhost:8080", "address of the server REST API")
+	fs.StringVar(&opts.token, "admin-token", "", "token of server administrator, default from "+TokenEnv)
+	fs.StringVar(&opts.tlsCA, "tls-ca", "", "CA certificate file to verify the server certificate")
+	fs.StringVar(&opts.tlsCert, "tls-cert", "", "client TLS certificate file for mutual TLS")
+	fs.StringVar(&opts.tlsKey, "tls-key", "", "client TLS private key file for mutual TLS")
+	fs.StringVar(&opts.format, "o", Plain, "output format: plain or json")
+	fs.BoolVar(&opts.yes, "yes", false, "confirm deletion of the account")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, Usage)
+		fs.PrintDefaults()
+	}
+
+	positional, err := parse(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if opts.format != Plain && opts.format != JSON {
+		return fmt.Errorf("unknown output format %s", opts.format)
+	}
+	if opts.token == "" {
+		opts.token = os.Getenv(TokenEnv)
+	}
+	if opts.token == "" {
+		return fmt.Errorf("admin token is not set, use -admin-token or %s", TokenEnv)
+	}
+
+	client := resty.New()
+	if opts.tlsCA != "" || opts.tlsCert != "" || opts.tlsKey != "" {
+		var tlsCfg *tls.Config
+		tlsCfg, err = tlsconfig.ClientConfig(opts.tlsCA, opts.tlsCert, opts.tlsKey)
+		if err != nil {
+			return fmt.Errorf("failed to configure TLS, %w", err)
+		}
+		client.SetTLSClientConfig(tlsCfg)
+	}
+	adminAPI := apiclient.New(client, strings.TrimSuffix(opts.server, "/"))
+	adminAPI.SetAdminToken(opts.token)
+
+	err = New(adminAPI, opts.format, stdout).Run(ctx, positional, opts.yes)
+	if errors.Is(err, ErrUnknownCommand) {
+		fs.Usage()
+	}
+	return err
+}
+
+// Run - метод для выполнения команды администратора. args - имя команды и ее аргументы, confirmed - подтверждение
+// удаления учетной записи.
+func (a *Admin) Run(ctx context.Context, args []string, confirmed bool) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w, command is not set", ErrUnknownCommand)
+	}
+	switch args[0] {
+	case "users":
+		return a.users(ctx)
+	case "stats":
+		return a.stats(ctx)
+	}
+
+	actions := map[string]struct {
+		status string
+		do     func(ctx context.Context, id string) error
+	}{
+		"disable": {status: "disabled", do: a.client.DisableUser},
+		"enable":  {status: "enabled", do: a.client.EnableUser},
+		"logout":  {status: "logged out", do: a.client.LogoutUser},
+		"delete":  {status: "deleted", do: a.client.DeleteUser},
+	}
+	action, ok := actions[args[0]]
+	if !ok {
+		return fmt.Errorf("%w %s", ErrUnknownCommand, args[0])
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("%s: expected one user login or id", args[0])
+	}
+	if args[0] == "delete" && !confirmed {
+		return fmt.Errorf("%s: %w", args[0], ErrNotConfirmed)
+	}
+
+	user, err := a.findUser(ctx, args[1])
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	if err := action.do(ctx, user.ID); err != nil {
+		return fmt.Errorf("%s: failed to %s user %s, %w", args[0], args[0], user.Login, err)
+	}
+	return a.printResult(action.status, user)
+}
+
+// users - команда для вывода списка учетных записей.
+func (a *Admin) users(ctx context.Context) error {
+	users, err := a.client.ListUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("users: failed to get users, %w", err)
+	}
+	if a.format == JSON {
+		if users == nil {
+			users = []account.User{}
+		}
+		return json.NewEncoder(a.out).Encode(users)
+	}
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tLOGIN\tSTATUS\tRECORDS\tBYTES")
+	for _, u := range users {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n", u.ID, u.Login, status(u), u.Records, u.Bytes)
+	}
+	return w.Flush()
+}
+
+// stats - команда для вывода статистики сервера.
+func (a *Admin) stats(ctx context.Context) error {
+	stats, err := a.client.GetStats(ctx)
+	if err != nil {
+		return fmt.Errorf("stats: failed to get stats, %w", err)
+	}
+	if a.format == JSON {
+		return json.NewEncoder(a.out).Encode(stats)
+	}
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "users:\t%d\n", stats.Users)
+	fmt.Fprintf(w, "disabled users:\t%d\n", stats.DisabledUsers)
+	fmt.Fprintf(w, "records:\t%d\n", stats.Records)
+	fmt.Fprintf(w, "versions:\t%d\n", stats.Versions)
+	fmt.Fprintf(w, "attachments:\t%d\n", stats.Attachments)
+	fmt.Fprintf(w, "chunks:\t%d\n", stats.Chunks)
+	fmt.Fprintf(w, "bytes:\t%d\n", stats.Bytes)
+	fmt.Fprintf(w, "audit events:\t%d\n", stats.AuditEvents)
+	return w.Flush()
+}
+
+// findUser - метод для поиска учетной записи по id или логину. Совпадение по id имеет приоритет.
+func (a *Admin) findUser(ctx context.Context, idOrLogin string) (account.User, error) {
+	users, err := a.client.ListUsers(ctx)
+	if err != nil {
+		return account.User{}, fmt.Errorf("failed to get users, %w", err)
+	}
+	for _, u := range users {
+		if u.ID == idOrLogin {
+			return u, nil
+		}
+	}
+	for _, u := range users {
+		if u.Login == idOrLogin {
+			return u, nil
+		}
+	}
+	return account.User{}, fmt.Errorf("%w: %s", ErrUserNotFound, idOrLogin)
+}
+
+// result - результат команды, изменяющей учетную запись.
+type result struct {
+	Status string `json:"status"`
+	ID     string `json:"id"`
+	Login  string `json:"login"`
+}
+
+// printResult - метод для вывода результата команды, изменяющей учетную запись.
+func (a *Admin) printResult(status string, user account.User) error {
+	if a.format == JSON {
+		return json.NewEncoder(a.out).Encode(result{Status: status, ID: user.ID, Login: user.Login})
+	}
+	_, err := fmt.Fprintf(a.out, "user %s (%s) %s\n", user.Login, user.ID, status)
+	return err
+}
+
+// status - функция для получения состояния учетной записи для вывода.
+func status(u account.User) string {
+	if u.Disabled {
+		return "disabled"
+	}
+	return "active"
+}
+
+// parse - функция для разбора аргументов. Флаги могут располагаться как до, так и после позиционных аргументов.
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
@@ -0,0 +1,127 @@
+package admincli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/account"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/admin"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage/memory"
+	"github.com/abezemskiy/gophkeeper/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setup - запускает сервер с двумя пользователями и возвращает его адрес и хранилище.
+func setup(t *testing.T) (string, *memory.Store) {
+	t.Helper()
+	admin.SetToken("admin secret token")
+	t.Cleanup(func() { admin.SetToken("") })
+
+	srv := testutil.NewServer(t)
+	require.NoError(t, srv.Store.Register(context.Background(), "alice", "hash", "first"))
+	require.NoError(t, srv.Store.Register(context.Background(), "bob", "hash", "second"))
+	return srv.URL, srv.Store
+}
+
+func TestCommands(t *testing.T) {
+	addr, stor := setup(t)
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		args = append([]string{"-server", addr, "-admin-token", "admin secret token"}, args...)
+		err := Main(context.Background(), args, &out, &bytes.Buffer{})
+		return out.String(), err
+	}
+
+	out, err := run("users")
+	require.NoError(t, err)
+	assert.Contains(t, out, "ID")
+	assert.Contains(t, out, "alice")
+	assert.Contains(t, out, "active")
+
+	// Пользователь задается логином или id, флаги могут следовать за командой
+	out, err = run("disable", "alice", "-o", "json")
+	require.NoError(t, err)
+	var res result
+	require.NoError(t, json.Unmarshal([]byte(out), &res))
+	assert.Equal(t, result{Status: "disabled", ID: "first", Login: "alice"}, res)
+	session, _, err := stor.GetSession(context.Background(), "first")
+	require.NoError(t, err)
+	assert.True(t, session.Disabled)
+
+	out, err = run("-o", "json", "users")
+	require.NoError(t, err)
+	var users []account.User
+	require.NoError(t, json.Unmarshal([]byte(out), &users))
+	assert.Equal(t, []account.User{{ID: "first", Login: "alice", Disabled: true}, {ID: "second", Login: "bob"}}, users)
+
+	out, err = run("enable", "first")
+	require.NoError(t, err)
+	assert.Equal(t, "user alice (first) enabled\n", out)
+	_, err = run("logout", "bob")
+	require.NoError(t, err)
+
+	out, err = run("stats")
+	require.NoError(t, err)
+	assert.Contains(t, out, "users:")
+
+	// Удаление требует подтверждения
+	_, err = run("delete", "bob")
+	require.ErrorIs(t, err, ErrNotConfirmed)
+	_, err = run("delete", "bob", "-yes")
+	require.NoError(t, err)
+	_, ok, err := stor.GetSession(context.Background(), "second")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	out, err = run("-o", "json", "stats")
+	require.NoError(t, err)
+	var stats account.Stats
+	require.NoError(t, json.Unmarshal([]byte(out), &stats))
+	assert.Equal(t, 1, stats.Users)
+}
+
+func TestCommandErrors(t *testing.T) {
+	addr, _ := setup(t)
+	t.Setenv(TokenEnv, "")
+
+	tests := []struct {
+		name string
+		args []string
+		is   error
+		code string
+	}{
+		{name: "without command", args: []string{"-server", addr, "-admin-token", "admin secret token"}, is: ErrUnknownCommand},
+		{name: "unknown command", args: []string{"-server", addr, "-admin-token", "admin secret token", "purge"}, is: ErrUnknownCommand},
+		{name: "unknown user", args: []string{"-server", addr, "-admin-token", "admin secret token", "disable", "carol"}, is: ErrUserNotFound},
+		{name: "without user", args: []string{"-server", addr, "-admin-token", "admin secret token", "logout"}},
+		{name: "wrong token", args: []string{"-server", addr, "-admin-token", "wrong secret token", "users"}, code: api.CodeUnauthorized},
+		{name: "without token", args: []string{"-server", addr, "users"}},
+		{name: "unknown format", args: []string{"-server", addr, "-admin-token", "admin secret token", "-o", "xml", "users"}},
+		{name: "unknown flag", args: []string{"-force", "users"}},
+		{name: "missing CA file", args: []string{"-server", addr, "-admin-token", "admin secret token", "-tls-ca", "missing.pem", "users"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Main(context.Background(), tt.args, &bytes.Buffer{}, &bytes.Buffer{})
+			require.Error(t, err)
+			if tt.is != nil {
+				assert.ErrorIs(t, err, tt.is)
+			}
+			if tt.code != "" {
+				assert.True(t, api.IsCode(err, tt.code))
+			}
+		})
+	}
+
+	// Токен читается из переменной окружения, справка не является ошибкой
+	t.Setenv(TokenEnv, "admin secret token")
+	require.NoError(t, Main(context.Background(), []string{"-server", addr, "users"}, &bytes.Buffer{}, &bytes.Buffer{}))
+	var help bytes.Buffer
+	require.NoError(t, Main(context.Background(), []string{"-h"}, &bytes.Buffer{}, &help))
+	assert.Contains(t, help.String(), "usage: server admin")
+}
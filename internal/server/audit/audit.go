@@ -0,0 +1,156 @@
+// Пакет audit содержит запись журнала аудита сервера. Источник запроса (адрес клиента, устройство и хранилище
+// журнала) устанавливается в контекст запроса middleware REST API или интерцептором gRPC API, после чего события
+// учетной записи записываются хэндлерами, а события изменения данных - оберткой хранилища Storage.
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/token"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	repoAudit "github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"go.uber.org/zap"
+)
+
+// Максимальные длины полей события. Имя устройства и логин при неудачном входе передает клиент, поэтому
+// они обрезаются, чтобы не раздувать журнал.
+const (
+	maxDeviceLength = 256
+	maxLoginLength  = 128
+)
+
+type contextKey struct{}
+
+// source - источник запроса, для которого записываются события.
+type source struct {
+	log    storage.IAuditStorage
+	ip     string
+	device string
+}
+
+// WithSource - функция для установки в контекст источника запроса: хранилища журнала log, адреса ip и устройства
+// device клиента. Без источника в контексте события не записываются.
+func WithSource(ctx context.Context, log storage.IAuditStorage, ip, device string) context.Context {
+	return context.WithValue(ctx, contextKey{}, source{log: log, ip: ip, device: truncate(device, maxDeviceLength)})
+}
+
+// truncate - функция для обрезки строки s до n байт без нарушения кодировки UTF-8.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
+
+// Middleware - middleware REST API для установки источника запроса в контекст. Устройство определяется
+// по заголовку api.DeviceHeader, а если клиент его не передал - по заголовку User-Agent.
+func Middleware(log storage.IAuditStorage) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			device := req.Header.Get(api.DeviceHeader)
+			if device == "" {
+				device = req.UserAgent()
+			}
+			ctx := WithSource(req.Context(), log, RemoteIP(req.RemoteAddr), device)
+			next.ServeHTTP(res, req.WithContext(ctx))
+		})
+	}
+}
+
+// RemoteIP - функция для получения IP адреса из адреса клиента вида host:port.
+func RemoteIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// Record - функция для записи события event в журнал источника запроса из ctx. Время, адрес и устройство
+// клиента заполняются автоматически. Ошибка записи журнала не прерывает обработку запроса и только логируется.
+func Record(ctx context.Context, event repoAudit.Event) {
+	src, ok := ctx.Value(contextKey{}).(source)
+	if !ok || src.log == nil {
+		return
+	}
+	event.Time = time.Now().UTC()
+	event.Login = truncate(event.Login, maxLoginLength)
+	event.IP = src.ip
+	event.Device = src.device
+
+	// Действие уже выполнено, поэтому событие записывается, даже если клиент успел отменить запрос
+	if err := src.log.AppendAuditEvent(context.WithoutCancel(ctx), event); err != nil {
+		logger.ServerLog.Error("failed to record audit event", zap.String("type", event.Type),
+			zap.String("user id", event.UserID), zap.String("error", err.Error()))
+	}
+}
+
+// LoginType - функция для определения типа события успешного входа пользователя userID. Если запрос авторизации
+// содержит заголовок authorization с токеном этого пользователя, подписанным сервером, клиент обновляет истекший
+// токен, иначе пользователь входит заново.
+func LoginType(authorization, userID string) string {
+	jwt, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || jwt == "" {
+		return repoAudit.LoginSuccess
+	}
+	id, err := token.GetIDFromExpiredToken(jwt)
+	if err != nil || id != userID {
+		return repoAudit.LoginSuccess
+	}
+	return repoAudit.TokenRefresh
+}
+
+// Storage - хранилище зашифрованных данных, которое записывает в журнал аудита успешные изменения данных.
+// В журнал попадает только имя данных, зашифрованные данные не сохраняются.
+type Storage struct {
+	storage.IEncryptedServerStorage
+}
+
+// NewStorage - фабричная функция хранилища с журналом аудита изменений данных.
+func NewStorage(stor storage.IEncryptedServerStorage) *Storage {
+	return &Storage{IEncryptedServerStorage: stor}
+}
+
+// AddEncryptedData - метод для добавления новых данных с записью события record_create.
+func (s *Storage) AddEncryptedData(ctx context.Context, idUser string, encrData data.EncryptedData, status int) (bool, error) {
+	ok, err := s.IEncryptedServerStorage.AddEncryptedData(ctx, idUser, encrData, status)
+	record(ctx, repoAudit.RecordCreate, idUser, encrData.Name, ok, err)
+	return ok, err
+}
+
+// ReplaceEncryptedData - метод для замены данных с записью события record_replace.
+func (s *Storage) ReplaceEncryptedData(ctx context.Context, idUser string, encrData data.EncryptedData, status int) (bool, error) {
+	ok, err := s.IEncryptedServerStorage.ReplaceEncryptedData(ctx, idUser, encrData, status)
+	record(ctx, repoAudit.RecordReplace, idUser, encrData.Name, ok, err)
+	return ok, err
+}
+
+// AppendEncryptedData - метод для добавления версии данных при конфликте с записью события record_conflict.
+func (s *Storage) AppendEncryptedData(ctx context.Context, idUser string, encrData data.EncryptedData) (bool, error) {
+	ok, err := s.IEncryptedServerStorage.AppendEncryptedData(ctx, idUser, encrData)
+	record(ctx, repoAudit.RecordConflict, idUser, encrData.Name, ok, err)
+	return ok, err
+}
+
+// DeleteEncryptedData - метод для удаления данных с записью события record_delete.
+func (s *Storage) DeleteEncryptedData(ctx context.Context, idUser, dataName string) (bool, error) {
+	ok, err := s.IEncryptedServerStorage.DeleteEncryptedData(ctx, idUser, dataName)
+	record(ctx, repoAudit.RecordDelete, idUser, dataName, ok, err)
+	return ok, err
+}
+
+// record - функция для записи события изменения данных, если изменение выполнено.
+func record(ctx context.Context, eventType, idUser, dataName string, ok bool, err error) {
+	if err != nil || !ok {
+		return
+	}
+	Record(ctx, repoAudit.Event{Type: eventType, UserID: idUser, RecordID: dataName})
+}
@@ -0,0 +1,168 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/token"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	repoAudit "github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failLog - хранилище журнала, которое всегда возвращает ошибку.
+type failLog struct{}
+
+func (failLog) AppendAuditEvent(context.Context, repoAudit.Event) error {
+	return errors.New("some error")
+}
+
+func (failLog) GetAuditEvents(context.Context, repoAudit.Filter) ([]repoAudit.Event, error) {
+	return nil, errors.New("some error")
+}
+
+func TestRecord(t *testing.T) {
+	store := memory.NewStore()
+
+	// Без источника в контексте события не записываются
+	Record(context.Background(), repoAudit.Event{Type: repoAudit.Register, UserID: "user"})
+	events, err := store.GetAuditEvents(context.Background(), repoAudit.Filter{})
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	// Событие записывается, даже если запрос уже отменен
+	ctx, cancel := context.WithCancel(WithSource(context.Background(), store, "10.0.0.1", "laptop"))
+	cancel()
+	Record(ctx, repoAudit.Event{Type: repoAudit.Register, UserID: "user", Login: strings.Repeat("л", maxLoginLength)})
+	events, err = store.GetAuditEvents(context.Background(), repoAudit.Filter{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, repoAudit.Register, events[0].Type)
+	assert.Equal(t, "user", events[0].UserID)
+	assert.Equal(t, "10.0.0.1", events[0].IP)
+	assert.Equal(t, "laptop", events[0].Device)
+	assert.False(t, events[0].Time.IsZero())
+	assert.LessOrEqual(t, len(events[0].Login), maxLoginLength)
+	assert.True(t, utf8.ValidString(events[0].Login))
+
+	// Ошибка журнала не приводит к панике
+	Record(WithSource(context.Background(), failLog{}, "", ""), repoAudit.Event{Type: repoAudit.Register})
+}
+
+func TestMiddleware(t *testing.T) {
+	store := memory.NewStore()
+	h := Middleware(store)(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		Record(req.Context(), repoAudit.Event{Type: repoAudit.LoginSuccess, UserID: "user"})
+	}))
+
+	tests := []struct {
+		name   string
+		header http.Header
+		device string
+	}{
+		{name: "device header", header: http.Header{api.DeviceHeader: {"laptop"}, "User-Agent": {"agent"}}, device: "laptop"},
+		{name: "user agent", header: http.Header{"User-Agent": {"agent"}}, device: "agent"},
+		{name: "long device", header: http.Header{api.DeviceHeader: {strings.Repeat("d", 2*maxDeviceLength)}},
+			device: strings.Repeat("d", maxDeviceLength)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/", nil)
+			request.RemoteAddr = "192.168.1.1:4321"
+			request.Header = tt.header
+			h.ServeHTTP(httptest.NewRecorder(), request)
+
+			events, err := store.GetAuditEvents(context.Background(), repoAudit.Filter{Desc: true, Limit: 1})
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			assert.Equal(t, tt.device, events[0].Device)
+			assert.Equal(t, "192.168.1.1", events[0].IP)
+		})
+	}
+}
+
+func TestRemoteIP(t *testing.T) {
+	assert.Equal(t, "127.0.0.1", RemoteIP("127.0.0.1:8080"))
+	assert.Equal(t, "::1", RemoteIP("[::1]:8080"))
+	assert.Equal(t, "pipe", RemoteIP("pipe"))
+}
+
+func TestLoginType(t *testing.T) {
+	token.SetSecretKey("audit key")
+	defer token.SerExpireHour(0)
+
+	token.SerExpireHour(1)
+	valid, err := token.BuildJWT("user")
+	require.NoError(t, err)
+	token.SerExpireHour(-1)
+	expired, err := token.BuildJWT("user")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		authorization string
+		userID        string
+		want          string
+	}{
+		{name: "without token", authorization: "", userID: "user", want: repoAudit.LoginSuccess},
+		{name: "valid token", authorization: "Bearer " + valid, userID: "user", want: repoAudit.TokenRefresh},
+		{name: "expired token", authorization: "Bearer " + expired, userID: "user", want: repoAudit.TokenRefresh},
+		{name: "token of another user", authorization: "Bearer " + valid, userID: "another user", want: repoAudit.LoginSuccess},
+		{name: "without bearer prefix", authorization: valid, userID: "user", want: repoAudit.LoginSuccess},
+		{name: "invalid token", authorization: "Bearer invalid", userID: "user", want: repoAudit.LoginSuccess},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, LoginType(tt.authorization, tt.userID))
+		})
+	}
+}
+
+func TestStorage(t *testing.T) {
+	store := memory.NewStore()
+	stor := NewStorage(store)
+	ctx := WithSource(context.Background(), store, "127.0.0.1", "laptop")
+
+	record := data.EncryptedData{EncryptedData: []byte("ciphertext"), Name: "github"}
+	ok, err := stor.AddEncryptedData(ctx, "user", record, data.SAVED)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = stor.AppendEncryptedData(ctx, "user", record)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = stor.ReplaceEncryptedData(ctx, "user", record, data.SAVED)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = stor.DeleteEncryptedData(ctx, "user", "github")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Неуспешные изменения не записываются
+	ok, err = stor.DeleteEncryptedData(ctx, "user", "github")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	events, err := store.GetAuditEvents(context.Background(), repoAudit.Filter{UserID: "user"})
+	require.NoError(t, err)
+	want := []string{repoAudit.RecordCreate, repoAudit.RecordConflict, repoAudit.RecordReplace, repoAudit.RecordDelete}
+	require.Len(t, events, len(want))
+	for i, event := range events {
+		assert.Equal(t, want[i], event.Type)
+		assert.Equal(t, "github", event.RecordID)
+	}
+
+	// Зашифрованные данные не попадают в журнал
+	b, err := json.Marshal(events)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "ciphertext")
+}
@@ -9,11 +9,24 @@ import (
 
 // Configs представляет структуру конфигурации.
 type Configs struct {
-	Address     string `json:"address"`      // аналог переменной окружения GOPHKEEPER_SERVER_ADDRESS или флага -a
-	LogLevel    string `json:"log_level"`    // аналог переменной окружения GOPHKEEPER_SERVER_LOG_LEVEL или флага -l
-	DatabaseDSN string `json:"database_dsn"` // аналог переменной окружения GOPHKEEPER_SERVER_DATABASE_URL или флага -d
-	SecretKey   string `json:"secret_key"`   // аналог переменной окружения GOPHKEEPER_SERVER_SECRET_KEY или флага -secret_key
-	ExpireToken int    `json:"expire_token"` // аналог переменной окружения GOPHKEEPER_SERVER_EXPIRE_TOKEN или флага -expire-token
+	Address       string `json:"address"`        // аналог переменной окружения GOPHKEEPER_SERVER_ADDRESS или флага -a
+	GRPCAddress   string `json:"grpc_address"`   // аналог переменной окружения GOPHKEEPER_SERVER_GRPC_ADDRESS или флага -grpc-address
+	OpsAddress    string `json:"ops_address"`    // аналог переменной окружения GOPHKEEPER_SERVER_OPS_ADDRESS или флага -ops-address
+	LogLevel      string `json:"log_level"`      // аналог переменной окружения GOPHKEEPER_SERVER_LOG_LEVEL или флага -l
+	DatabaseDSN   string `json:"database_dsn"`   // аналог переменной окружения GOPHKEEPER_SERVER_DATABASE_URL или флага -d
+	SecretKey     string `json:"secret_key"`     // аналог переменной окружения GOPHKEEPER_SERVER_SECRET_KEY или флага -secret_key
+	ExpireToken   int    `json:"expire_token"`   // аналог переменной окружения GOPHKEEPER_SERVER_EXPIRE_TOKEN или флага -expire-token
+	Demo          bool   `json:"demo"`           // аналог переменной окружения GOPHKEEPER_SERVER_DEMO или флага -demo
+	MaxBytes      int64  `json:"max_bytes"`      // аналог переменной окружения GOPHKEEPER_SERVER_MAX_BYTES или флага -max-bytes
+	MaxRecords    int    `json:"max_records"`    // аналог переменной окружения GOPHKEEPER_SERVER_MAX_RECORDS или флага -max-records
+	MaxVersions   int    `json:"max_versions"`   // аналог переменной окружения GOPHKEEPER_SERVER_MAX_VERSIONS или флага -max-versions
+	MaxBodySize   int64  `json:"max_body_size"`  // аналог переменной окружения GOPHKEEPER_SERVER_MAX_BODY_SIZE или флага -max-body-size
+	TLSCert       string `json:"tls_cert"`       // аналог переменной окружения GOPHKEEPER_SERVER_TLS_CERT или флага -tls-cert
+	TLSKey        string `json:"tls_key"`        // аналог переменной окружения GOPHKEEPER_SERVER_TLS_KEY или флага -tls-key
+	TLSClientCA   string `json:"tls_client_ca"`  // аналог переменной окружения GOPHKEEPER_SERVER_TLS_CLIENT_CA или флага -tls-client-ca
+	TraceExporter string `json:"trace_exporter"` // аналог переменной окружения GOPHKEEPER_SERVER_TRACE_EXPORTER или флага -trace-exporter
+	TraceEndpoint string `json:"trace_endpoint"` // аналог переменной окружения GOPHKEEPER_SERVER_TRACE_ENDPOINT или флага -trace-endpoint
+	AdminToken    string `json:"admin_token"`    // аналог переменной окружения GOPHKEEPER_SERVER_ADMIN_TOKEN или флага -admin-token
 }
 
 // ParseConfigFile - функция для переопределения параметров конфигурации из файла конфигурации.
@@ -17,7 +17,7 @@ func TestParseConfigFile(t *testing.T) {
 	testExpireToken := 30
 
 	createFile := func(name string) {
-		data := fmt.Sprintf("{\"address\": \"%s\",\"database_dsn\": \"%s\",\"log_level\": \"%s\", \"secret_key\":\"%s\", \"expire_token\":%d}",
+		data := fmt.Sprintf("{\"address\": \"%s\",\"grpc_address\": \":3200\",\"database_dsn\": \"%s\",\"log_level\": \"%s\", \"secret_key\":\"%s\", \"expire_token\":%d, \"demo\":true, \"max_bytes\":1000, \"max_records\":10, \"max_versions\":3, \"max_body_size\":500, \"tls_cert\":\"server.crt\", \"tls_key\":\"server.key\", \"tls_client_ca\":\"ca.crt\", \"trace_exporter\":\"otlp\", \"trace_endpoint\":\"collector:4317\", \"admin_token\":\"admin secret token\"}",
 			testFlagNetAddr, testFlagDatabaseDsn, testFlagLogLevel, testSecretKey, testExpireToken)
 		f, err := os.Create(name)
 		require.NoError(t, err)
@@ -31,10 +31,22 @@ func TestParseConfigFile(t *testing.T) {
 	require.NoError(t, err)
 
 	assert.Equal(t, testFlagNetAddr, configs.Address)
+	assert.Equal(t, ":3200", configs.GRPCAddress)
 	assert.Equal(t, testFlagDatabaseDsn, configs.DatabaseDSN)
 	assert.Equal(t, testFlagLogLevel, configs.LogLevel)
 	assert.Equal(t, testSecretKey, configs.SecretKey)
 	assert.Equal(t, testExpireToken, configs.ExpireToken)
+	assert.Equal(t, true, configs.Demo)
+	assert.Equal(t, int64(1000), configs.MaxBytes)
+	assert.Equal(t, 10, configs.MaxRecords)
+	assert.Equal(t, 3, configs.MaxVersions)
+	assert.Equal(t, int64(500), configs.MaxBodySize)
+	assert.Equal(t, "server.crt", configs.TLSCert)
+	assert.Equal(t, "server.key", configs.TLSKey)
+	assert.Equal(t, "ca.crt", configs.TLSClientCA)
+	assert.Equal(t, "otlp", configs.TraceExporter)
+	assert.Equal(t, "collector:4317", configs.TraceEndpoint)
+	assert.Equal(t, "admin secret token", configs.AdminToken)
 
 	err = os.Remove(nameFile)
 	require.NoError(t, err)
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/checker"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	repoAudit "github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/identity"
+	"github.com/abezemskiy/gophkeeper/internal/server/audit"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/auth"
+	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/metrics"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"go.uber.org/zap"
+)
+
+// DeleteAccount - хэндлер для удаления пользователем своей учетной записи вместе со всеми данными, вложениями
+// и сессиями. Кроме токена запрос должен содержать логин и хэш от суммы логин+пароль пользователя: удаление
+// выполняется только после повторной аутентификации. События журнала аудита пользователя сохраняются.
+func DeleteAccount(res http.ResponseWriter, req *http.Request, ident identity.Identifier, accounts storage.IAccountStorage) {
+	// получаю id пользователя из контекста
+	id, ok := req.Context().Value(auth.UserIDKey).(string)
+	if !ok {
+		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
+		internalError(res)
+		return
+	}
+	defer req.Body.Close()
+
+	var regData identity.Data
+	if err := json.NewDecoder(limitBody(res, req.Body)).Decode(&regData); err != nil {
+		logger.ServerLog.Error("failed to parse identity data to structer", zap.String("address", req.URL.String()), zap.String("error", error.Error(err)))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "failed to parse identity data")
+		return
+	}
+	if !checker.CheckLogin(regData.Login) || !checker.CheckHash(regData.Hash) {
+		logger.ServerLog.Error("login or hash is not valid", zap.String("address", req.URL.String()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidCredentials, "login or password is wrong")
+		return
+	}
+
+	// Повторная аутентификация: логин должен принадлежать владельцу токена, а пароль - совпадать с сохраненным
+	data, ok, err := ident.Authorize(req.Context(), regData.Login)
+	if err != nil {
+		logger.ServerLog.Error("authorize user error", zap.String("address", req.URL.String()), zap.String("error", error.Error(err)))
+		internalError(res)
+		return
+	}
+	if !ok || data.ID != id || !checker.IsAuthorize(data.Hash, regData.Hash) {
+		logger.ServerLog.Error("re-authentication failed", zap.String("address", req.URL.String()), zap.String("user id", id))
+		metrics.AuthFailure(api.CodeInvalidCredentials)
+		audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.LoginFailure, UserID: id, Login: regData.Login})
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidCredentials, "login or password is wrong")
+		return
+	}
+
+	ok, err = accounts.DeleteUser(req.Context(), id)
+	if err != nil {
+		logger.ServerLog.Error("delete user from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		logger.ServerLog.Error("user not found", zap.String("address", req.URL.String()), zap.String("user id", id))
+		api.WriteError(res, http.StatusNotFound, api.CodeUserNotFound, "user not found")
+		return
+	}
+
+	audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.AccountDelete, UserID: id, Login: regData.Login})
+	logger.ServerLog.Info("user deleted account", zap.String("user id", id))
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteAccountHandler - обертка над DeleteAccount.
+func DeleteAccountHandler(ident identity.Identifier, accounts storage.IAccountStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		DeleteAccount(res, req, ident, accounts)
+	}
+	return fn
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	repoAudit "github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/auth"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteAccount(t *testing.T) {
+	stor := accountsStore(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		id     string
+		body   string
+		status int
+		code   string
+	}{
+		{name: "without user id", body: `{"login":"alice","hash":"hash"}`, status: http.StatusInternalServerError},
+		{name: "invalid body", id: "first", body: `{`, status: http.StatusBadRequest, code: api.CodeInvalidRequest},
+		{name: "empty hash", id: "first", body: `{"login":"alice"}`, status: http.StatusBadRequest, code: api.CodeInvalidCredentials},
+		{name: "wrong password", id: "first", body: `{"login":"alice","hash":"wrong"}`, status: http.StatusBadRequest, code: api.CodeInvalidCredentials},
+		{name: "login of other user", id: "first", body: `{"login":"bob","hash":"hash"}`, status: http.StatusBadRequest, code: api.CodeInvalidCredentials},
+		{name: "unknown login", id: "first", body: `{"login":"carol","hash":"hash"}`, status: http.StatusBadRequest, code: api.CodeInvalidCredentials},
+		{name: "success", id: "first", body: `{"login":"alice","hash":"hash"}`, status: http.StatusNoContent},
+		{name: "already deleted", id: "first", body: `{"login":"alice","hash":"hash"}`, status: http.StatusBadRequest, code: api.CodeInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveRequest(stor, DeleteAccountHandler(stor, stor), http.MethodDelete, api.AccountPattern, api.AccountPattern,
+				tt.id, tt.body)
+			res := w.Result()
+			defer res.Body.Close()
+
+			require.Equal(t, tt.status, res.StatusCode)
+			if tt.code != "" {
+				assert.Equal(t, tt.code, api.ParseError(res.StatusCode, w.Body.Bytes()).Code)
+			}
+		})
+	}
+
+	// Учетная запись удалена вместе с данными, другие пользователи не затронуты
+	_, ok, err := stor.GetSession(ctx, "first")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	all, err := stor.GetAllEncryptedData(ctx, "first")
+	require.NoError(t, err)
+	assert.Empty(t, all)
+	_, ok, err = stor.GetSession(ctx, "second")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Удаление записано в журнал аудита, события пользователя сохранены
+	events, err := stor.GetAuditEvents(ctx, repoAudit.Filter{UserID: "first"})
+	require.NoError(t, err)
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	assert.Contains(t, types, repoAudit.LoginFailure)
+	assert.Contains(t, types, repoAudit.AccountDelete)
+
+	// Ошибка хранилища учетных записей
+	request := httptest.NewRequest(http.MethodDelete, api.AccountPattern, bytes.NewBufferString(`{"login":"bob","hash":"hash"}`))
+	request = request.WithContext(context.WithValue(request.Context(), auth.UserIDKey, "second"))
+	w := httptest.NewRecorder()
+	DeleteAccountHandler(stor, failAccounts{})(w, request)
+	res := w.Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	repoAudit "github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/server/audit"
+	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// ListUsers - хэндлер для получения администратором списка учетных записей с количеством и объемом данных
+// пользователей. Содержимое данных пользователей в ответ не попадает.
+func ListUsers(res http.ResponseWriter, req *http.Request, accounts storage.IAccountStorage) {
+	defer req.Body.Close()
+
+	users, err := accounts.ListUsers(req.Context())
+	if err != nil {
+		logger.ServerLog.Error("get users from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	writeJSON(res, users)
+	logger.ServerLog.Debug("successful return users to admin")
+}
+
+// ListUsersHandler - обертка над ListUsers.
+func ListUsersHandler(accounts storage.IAccountStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		ListUsers(res, req, accounts)
+	}
+	return fn
+}
+
+// GetStats - хэндлер для получения администратором статистики сервера.
+func GetStats(res http.ResponseWriter, req *http.Request, accounts storage.IAccountStorage) {
+	defer req.Body.Close()
+
+	stats, err := accounts.GetStats(req.Context())
+	if err != nil {
+		logger.ServerLog.Error("get stats from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	writeJSON(res, stats)
+	logger.ServerLog.Debug("successful return stats to admin")
+}
+
+// GetStatsHandler - обертка над GetStats.
+func GetStatsHandler(accounts storage.IAccountStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		GetStats(res, req, accounts)
+	}
+	return fn
+}
+
+// DisableUserHandler - хэндлер для блокировки учетной записи пользователя с id из адреса запроса.
+// Заблокированный пользователь не может авторизоваться, а его действующие токены отклоняются.
+func DisableUserHandler(accounts storage.IAccountStorage) http.HandlerFunc {
+	return userAction(repoAudit.AccountDisable, func(req *http.Request, id string) (bool, error) {
+		return accounts.SetDisabled(req.Context(), id, true)
+	})
+}
+
+// EnableUserHandler - хэндлер для разблокировки учетной записи пользователя с id из адреса запроса.
+func EnableUserHandler(accounts storage.IAccountStorage) http.HandlerFunc {
+	return userAction(repoAudit.AccountEnable, func(req *http.Request, id string) (bool, error) {
+		return accounts.SetDisabled(req.Context(), id, false)
+	})
+}
+
+// LogoutUserHandler - хэндлер для принудительного выхода пользователя с id из адреса запроса на всех устройствах.
+// Токены, выданные до запроса, отклоняются; клиенту нужно авторизоваться повторно.
+func LogoutUserHandler(accounts storage.IAccountStorage) http.HandlerFunc {
+	return userAction(repoAudit.ForceLogout, func(req *http.Request, id string) (bool, error) {
+		return accounts.Logout(req.Context(), id, time.Now())
+	})
+}
+
+// DeleteUserHandler - хэндлер для удаления учетной записи пользователя с id из адреса запроса вместе со всеми
+// данными и вложениями пользователя. События журнала аудита пользователя сохраняются.
+func DeleteUserHandler(accounts storage.IAccountStorage) http.HandlerFunc {
+	return userAction(repoAudit.AccountDelete, func(req *http.Request, id string) (bool, error) {
+		return accounts.DeleteUser(req.Context(), id)
+	})
+}
+
+// userAction - функция для создания хэндлера действия администратора с учетной записью пользователя. Если
+// пользователь не найден, возвращается статус 404, при успешном действии - статус 204 и событие eventType
+// записывается в журнал аудита.
+func userAction(eventType string, action func(req *http.Request, id string) (bool, error)) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		defer req.Body.Close()
+
+		id := chi.URLParam(req, "id")
+		if id == "" {
+			logger.ServerLog.Error("user id is empty", zap.String("address", req.URL.String()))
+			api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "user id is empty")
+			return
+		}
+
+		ok, err := action(req, id)
+		if err != nil {
+			logger.ServerLog.Error("admin action error", zap.String("address", req.URL.String()), zap.String("action", eventType),
+				zap.String("error", err.Error()))
+			internalError(res)
+			return
+		}
+		if !ok {
+			logger.ServerLog.Error("user not found", zap.String("address", req.URL.String()), zap.String("user id", id))
+			api.WriteError(res, http.StatusNotFound, api.CodeUserNotFound, "user not found")
+			return
+		}
+
+		audit.Record(req.Context(), repoAudit.Event{Type: eventType, UserID: id})
+		logger.ServerLog.Info("admin action completed", zap.String("action", eventType), zap.String("user id", id))
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeJSON - функция для записи ответа со статусом 200 и телом v в формате JSON.
+func writeJSON(res http.ResponseWriter, v any) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(v); err != nil {
+		logger.ServerLog.Error("encoding response error", zap.String("error", error.Error(err)))
+	}
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/account"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	repoAudit "github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failAccounts - хранилище учетных записей, которое всегда возвращает ошибку.
+type failAccounts struct{}
+
+func (failAccounts) GetSession(context.Context, string) (account.Session, bool, error) {
+	return account.Session{}, false, errors.New("some error")
+}
+
+func (failAccounts) ListUsers(context.Context) ([]account.User, error) {
+	return nil, errors.New("some error")
+}
+
+func (failAccounts) SetDisabled(context.Context, string, bool) (bool, error) {
+	return false, errors.New("some error")
+}
+
+func (failAccounts) Logout(context.Context, string, time.Time) (bool, error) {
+	return false, errors.New("some error")
+}
+
+func (failAccounts) DeleteUser(context.Context, string) (bool, error) {
+	return false, errors.New("some error")
+}
+
+func (failAccounts) GetStats(context.Context) (account.Stats, error) {
+	return account.Stats{}, errors.New("some error")
+}
+
+// accountsStore - функция для создания хранилища с двумя пользователями, у первого из которых есть запись.
+func accountsStore(t *testing.T) *memory.Store {
+	t.Helper()
+	ctx := context.Background()
+	stor := memory.NewStore()
+	require.NoError(t, stor.Register(ctx, "bob", "hash", "second"))
+	require.NoError(t, stor.Register(ctx, "alice", "hash", "first"))
+	ok, err := stor.AddEncryptedData(ctx, "first", data.EncryptedData{EncryptedData: []byte("ciphertext"), Name: "github"}, data.SAVED)
+	require.NoError(t, err)
+	require.True(t, ok)
+	return stor
+}
+
+func TestListUsers(t *testing.T) {
+	stor := accountsStore(t)
+
+	res := serveRequest(nil, ListUsersHandler(stor), http.MethodGet, "/", "/", "", "").Result()
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var users []account.User
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&users))
+	require.Len(t, users, 2)
+	assert.Equal(t, account.User{ID: "first", Login: "alice", Records: 1, Bytes: int64(len("ciphertext"))}, users[0])
+	assert.Equal(t, account.User{ID: "second", Login: "bob"}, users[1])
+
+	// Ошибка хранилища
+	res = serveRequest(nil, ListUsersHandler(failAccounts{}), http.MethodGet, "/", "/", "", "").Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+}
+
+func TestGetStats(t *testing.T) {
+	stor := accountsStore(t)
+	ok, err := stor.SetDisabled(context.Background(), "second", true)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	res := serveRequest(nil, GetStatsHandler(stor), http.MethodGet, "/", "/", "", "").Result()
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var stats account.Stats
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&stats))
+	assert.Equal(t, 2, stats.Users)
+	assert.Equal(t, 1, stats.DisabledUsers)
+	assert.Equal(t, 1, stats.Records)
+	assert.Equal(t, 1, stats.Versions)
+
+	// Ошибка хранилища
+	res = serveRequest(nil, GetStatsHandler(failAccounts{}), http.MethodGet, "/", "/", "", "").Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+}
+
+func TestUserActions(t *testing.T) {
+	stor := accountsStore(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		id      string
+		status  int
+		event   string
+		check   func(t *testing.T)
+	}{
+		{name: "disable", handler: DisableUserHandler(stor), id: "first", status: http.StatusNoContent, event: repoAudit.AccountDisable,
+			check: func(t *testing.T) {
+				session, ok, err := stor.GetSession(ctx, "first")
+				require.NoError(t, err)
+				require.True(t, ok)
+				assert.True(t, session.Disabled)
+			}},
+		{name: "enable", handler: EnableUserHandler(stor), id: "first", status: http.StatusNoContent, event: repoAudit.AccountEnable,
+			check: func(t *testing.T) {
+				session, _, err := stor.GetSession(ctx, "first")
+				require.NoError(t, err)
+				assert.False(t, session.Disabled)
+			}},
+		{name: "logout", handler: LogoutUserHandler(stor), id: "first", status: http.StatusNoContent, event: repoAudit.ForceLogout,
+			check: func(t *testing.T) {
+				session, _, err := stor.GetSession(ctx, "first")
+				require.NoError(t, err)
+				assert.False(t, session.LogoutAt.IsZero())
+			}},
+		{name: "delete", handler: DeleteUserHandler(stor), id: "first", status: http.StatusNoContent, event: repoAudit.AccountDelete,
+			check: func(t *testing.T) {
+				_, ok, err := stor.GetSession(ctx, "first")
+				require.NoError(t, err)
+				assert.False(t, ok)
+				all, err := stor.GetAllEncryptedData(ctx, "first")
+				require.NoError(t, err)
+				assert.Empty(t, all)
+			}},
+		{name: "disable unknown user", handler: DisableUserHandler(stor), id: "first", status: http.StatusNotFound},
+		{name: "logout unknown user", handler: LogoutUserHandler(stor), id: "unknown", status: http.StatusNotFound},
+		{name: "delete unknown user", handler: DeleteUserHandler(stor), id: "first", status: http.StatusNotFound},
+		{name: "storage error", handler: DisableUserHandler(failAccounts{}), id: "second", status: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveRequest(stor, tt.handler, http.MethodPost, "/users/{id}", "/users/"+tt.id, "", "")
+			res := w.Result()
+			defer res.Body.Close()
+
+			require.Equal(t, tt.status, res.StatusCode)
+			if tt.status == http.StatusNotFound {
+				assert.Equal(t, api.CodeUserNotFound, api.ParseError(res.StatusCode, w.Body.Bytes()).Code)
+			}
+			if tt.check != nil {
+				tt.check(t)
+			}
+			if tt.event != "" {
+				events, err := stor.GetAuditEvents(ctx, repoAudit.Filter{Desc: true, Limit: 1})
+				require.NoError(t, err)
+				require.Len(t, events, 1)
+				assert.Equal(t, tt.event, events[0].Type)
+				assert.Equal(t, tt.id, events[0].UserID)
+			}
+		})
+	}
+	{
+		// id пользователя не указан
+		w := httptest.NewRecorder()
+		DeleteUserHandler(stor)(w, httptest.NewRequest(http.MethodDelete, "/", nil))
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	}
+}
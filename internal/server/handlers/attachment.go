@@ -0,0 +1,426 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/auth"
+	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/quota"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// Ограничения запросов вложений.
+const (
+	maxAttachmentIDLen = 128     // максимальная длина идентификатора вложения
+	maxRecordIDLen     = 64      // максимальная длина идентификатора записи, ссылающейся на вложение
+	maxChunkListSize   = 1 << 20 // максимальный размер тела запроса со списком хэшей частей в байтах
+)
+
+// SaveChunk - хэндлер для сохранения зашифрованной части вложения. Тело запроса содержит часть вложения в бинарном виде.
+// Адрес запроса содержит SHA-256 хэш зашифрованной части, сервер проверяет его соответствие телу запроса.
+func SaveChunk(res http.ResponseWriter, req *http.Request, stor storage.IAttachmentStorage) {
+	// получаю id пользователя из контекста
+	id, ok := req.Context().Value(auth.UserIDKey).(string)
+	if !ok {
+		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
+		internalError(res)
+		return
+	}
+	defer req.Body.Close()
+
+	hash, err := parseChunkHash(req)
+	if err != nil {
+		logger.ServerLog.Error("bad chunk address", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	// Ограничиваю размер части, чтобы клиент не мог передать в одном запросе произвольно большой объем данных
+	chunk, err := io.ReadAll(http.MaxBytesReader(res, req.Body, data.MaxEncryptedChunkSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			logger.ServerLog.Error("chunk is too large", zap.String("address", req.URL.String()))
+			api.WriteError(res, http.StatusRequestEntityTooLarge, api.CodePayloadTooLarge, "chunk is too large")
+			return
+		}
+		logger.ServerLog.Error("read chunk error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "failed to read chunk")
+		return
+	}
+	if len(chunk) == 0 {
+		logger.ServerLog.Error("chunk is empty", zap.String("address", req.URL.String()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "chunk is empty")
+		return
+	}
+	// Часть сохраняется по хэшу её содержимого, иначе клиент мог бы подменить часть другого вложения
+	if sum := sha256.Sum256(chunk); hex.EncodeToString(sum[:]) != hash {
+		logger.ServerLog.Error("chunk hash mismatch", zap.String("address", req.URL.String()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeChunkHashMismatch, "chunk hash mismatch")
+		return
+	}
+
+	err = stor.SaveChunk(req.Context(), id, hash, chunk)
+	if errors.Is(err, quota.ErrQuotaExceeded) {
+		quotaError(res, req, err)
+		return
+	}
+	if err != nil {
+		logger.ServerLog.Error("save chunk to storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+
+	res.WriteHeader(http.StatusOK)
+	logger.ServerLog.Debug("successful save chunk to storage", zap.String("hash", hash))
+}
+
+// SaveChunkHandler - обертка над SaveChunk.
+func SaveChunkHandler(stor storage.IAttachmentStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		SaveChunk(res, req, stor)
+	}
+	return fn
+}
+
+// GetChunk - хэндлер для отправки пользователю зашифрованной части вложения по её хэшу.
+func GetChunk(res http.ResponseWriter, req *http.Request, stor storage.IAttachmentStorage) {
+	// получаю id пользователя из контекста
+	id, ok := req.Context().Value(auth.UserIDKey).(string)
+	if !ok {
+		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
+		internalError(res)
+		return
+	}
+	defer req.Body.Close()
+
+	hash, err := parseChunkHash(req)
+	if err != nil {
+		logger.ServerLog.Error("bad chunk address", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	chunk, ok, err := stor.GetChunk(req.Context(), id, hash)
+	if err != nil {
+		logger.ServerLog.Error("get chunk from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		logger.ServerLog.Error("chunk does not exist", zap.String("address", req.URL.String()))
+		api.WriteError(res, http.StatusNotFound, api.CodeChunkNotFound, "chunk does not exist")
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/octet-stream")
+	res.Header().Set("Content-Length", strconv.Itoa(len(chunk)))
+	res.WriteHeader(http.StatusOK)
+	if _, err := res.Write(chunk); err != nil {
+		logger.ServerLog.Error("write chunk to response error", zap.String("error", err.Error()))
+		return
+	}
+	logger.ServerLog.Debug("successful return chunk to client", zap.String("hash", hash))
+}
+
+// GetChunkHandler - обертка над GetChunk.
+func GetChunkHandler(stor storage.IAttachmentStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		GetChunk(res, req, stor)
+	}
+	return fn
+}
+
+// GetMissingChunks - хэндлер для отправки пользователю хэшей частей, которые ещё не сохранены на сервере.
+// Клиент загружает только эти части, что позволяет не передавать повторно одинаковые части и возобновлять прерванную загрузку.
+func GetMissingChunks(res http.ResponseWriter, req *http.Request, stor storage.IAttachmentStorage) {
+	// получаю id пользователя из контекста
+	id, ok := req.Context().Value(auth.UserIDKey).(string)
+	if !ok {
+		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
+		internalError(res)
+		return
+	}
+	defer req.Body.Close()
+
+	list, status, err := decodeChunkList(res, req)
+	if err != nil {
+		logger.ServerLog.Error("bad chunk list", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		api.WriteError(res, status, requestErrorCode(status), err.Error())
+		return
+	}
+
+	missing, err := stor.GetMissingChunks(req.Context(), id, list.Chunks)
+	if err != nil {
+		logger.ServerLog.Error("get missing chunks from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(data.ChunkList{Chunks: missing}); err != nil {
+		logger.ServerLog.Error("encoding response error", zap.String("error", error.Error(err)))
+		return
+	}
+	logger.ServerLog.Debug("successful return missing chunks to client", zap.Int("missing", len(missing)))
+}
+
+// GetMissingChunksHandler - обертка над GetMissingChunks.
+func GetMissingChunksHandler(stor storage.IAttachmentStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		GetMissingChunks(res, req, stor)
+	}
+	return fn
+}
+
+// AddAttachmentRef - хэндлер для создания вложения из загруженных частей или добавления ссылки на существующее вложение.
+// Идентификатор ссылающейся записи передается в параметре record, поэтому повторный запрос той же записи
+// не увеличивает количество ссылок. Тело запроса содержит хэши частей вложения в порядке следования.
+// В ответе передается информация о вложении.
+func AddAttachmentRef(res http.ResponseWriter, req *http.Request, stor storage.IAttachmentStorage) {
+	// получаю id пользователя из контекста
+	id, ok := req.Context().Value(auth.UserIDKey).(string)
+	if !ok {
+		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
+		internalError(res)
+		return
+	}
+	defer req.Body.Close()
+
+	attachmentID, err := parseAttachmentID(req)
+	if err != nil {
+		logger.ServerLog.Error("bad attachment address", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	record, err := parseRecordID(req)
+	if err != nil {
+		logger.ServerLog.Error("bad attachment record", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	list, status, err := decodeChunkList(res, req)
+	if err != nil {
+		logger.ServerLog.Error("bad chunk list", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		api.WriteError(res, status, requestErrorCode(status), err.Error())
+		return
+	}
+	if len(list.Chunks) == 0 {
+		logger.ServerLog.Error("chunk list is empty", zap.String("address", req.URL.String()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "chunk list is empty")
+		return
+	}
+
+	refs, err := stor.AddAttachmentRef(req.Context(), id, attachmentID, record, list.Chunks)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrMissingChunks):
+			logger.ServerLog.Error("chunks of attachment are missing", zap.String("address", req.URL.String()))
+			api.WriteError(res, http.StatusPreconditionFailed, api.CodeChunksMissing, err.Error())
+		case errors.Is(err, storage.ErrAttachmentMismatch):
+			logger.ServerLog.Error("attachment mismatch", zap.String("address", req.URL.String()))
+			api.WriteError(res, http.StatusConflict, api.CodeAttachmentConflict, err.Error())
+		default:
+			logger.ServerLog.Error("add attachment ref to storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+			internalError(res)
+		}
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(data.AttachmentInfo{ID: attachmentID, Chunks: list.Chunks, Refs: refs}); err != nil {
+		logger.ServerLog.Error("encoding response error", zap.String("error", error.Error(err)))
+		return
+	}
+	logger.ServerLog.Debug("successful add attachment ref", zap.String("attachment", attachmentID), zap.Int("refs", refs))
+}
+
+// AddAttachmentRefHandler - обертка над AddAttachmentRef.
+func AddAttachmentRefHandler(stor storage.IAttachmentStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		AddAttachmentRef(res, req, stor)
+	}
+	return fn
+}
+
+// GetAttachment - хэндлер для отправки пользователю информации о вложении.
+func GetAttachment(res http.ResponseWriter, req *http.Request, stor storage.IAttachmentStorage) {
+	// получаю id пользователя из контекста
+	id, ok := req.Context().Value(auth.UserIDKey).(string)
+	if !ok {
+		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
+		internalError(res)
+		return
+	}
+	defer req.Body.Close()
+
+	attachmentID, err := parseAttachmentID(req)
+	if err != nil {
+		logger.ServerLog.Error("bad attachment address", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	info, ok, err := stor.GetAttachment(req.Context(), id, attachmentID)
+	if err != nil {
+		logger.ServerLog.Error("get attachment from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		logger.ServerLog.Error("attachment does not exist", zap.String("address", req.URL.String()))
+		api.WriteError(res, http.StatusNotFound, api.CodeAttachmentNotFound, "attachment does not exist")
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(info); err != nil {
+		logger.ServerLog.Error("encoding response error", zap.String("error", error.Error(err)))
+		return
+	}
+	logger.ServerLog.Debug("successful return attachment info to client", zap.String("attachment", attachmentID))
+}
+
+// GetAttachmentHandler - обертка над GetAttachment.
+func GetAttachmentHandler(stor storage.IAttachmentStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		GetAttachment(res, req, stor)
+	}
+	return fn
+}
+
+// ReleaseAttachment - хэндлер для удаления ссылки записи, переданной в параметре record, на вложение.
+// Вложение удаляется с сервера после удаления последней ссылки. В ответе передается количество оставшихся ссылок на вложение.
+func ReleaseAttachment(res http.ResponseWriter, req *http.Request, stor storage.IAttachmentStorage) {
+	// получаю id пользователя из контекста
+	id, ok := req.Context().Value(auth.UserIDKey).(string)
+	if !ok {
+		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
+		internalError(res)
+		return
+	}
+	defer req.Body.Close()
+
+	attachmentID, err := parseAttachmentID(req)
+	if err != nil {
+		logger.ServerLog.Error("bad attachment address", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	record, err := parseRecordID(req)
+	if err != nil {
+		logger.ServerLog.Error("bad attachment record", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	refs, ok, err := stor.ReleaseAttachment(req.Context(), id, attachmentID, record)
+	if err != nil {
+		logger.ServerLog.Error("release attachment in storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		logger.ServerLog.Error("attachment does not exist", zap.String("address", req.URL.String()))
+		api.WriteError(res, http.StatusNotFound, api.CodeAttachmentNotFound, "attachment does not exist")
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(data.AttachmentInfo{ID: attachmentID, Refs: refs}); err != nil {
+		logger.ServerLog.Error("encoding response error", zap.String("error", error.Error(err)))
+		return
+	}
+	logger.ServerLog.Debug("successful release attachment", zap.String("attachment", attachmentID), zap.Int("refs", refs))
+}
+
+// ReleaseAttachmentHandler - обертка над ReleaseAttachment.
+func ReleaseAttachmentHandler(stor storage.IAttachmentStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		ReleaseAttachment(res, req, stor)
+	}
+	return fn
+}
+
+// parseAttachmentID - функция для извлечения идентификатора вложения из адреса запроса.
+func parseAttachmentID(req *http.Request) (string, error) {
+	attachmentID := chi.URLParam(req, "id")
+	if attachmentID == "" || len(attachmentID) > maxAttachmentIDLen {
+		return "", fmt.Errorf("attachment id is not valid")
+	}
+	return attachmentID, nil
+}
+
+// parseRecordID - функция для извлечения идентификатора записи, ссылающейся на вложение, из параметра record запроса.
+func parseRecordID(req *http.Request) (string, error) {
+	record := req.URL.Query().Get("record")
+	if record == "" || len(record) > maxRecordIDLen {
+		return "", fmt.Errorf("record id is not valid")
+	}
+	return record, nil
+}
+
+// parseChunkHash - функция для извлечения хэша части вложения из адреса запроса.
+func parseChunkHash(req *http.Request) (string, error) {
+	hash := chi.URLParam(req, "hash")
+	if !validChunkHash(hash) {
+		return "", fmt.Errorf("chunk hash is not valid")
+	}
+	return hash, nil
+}
+
+// validChunkHash - функция для проверки, что строка является SHA-256 хэшем в шестнадцатеричном виде в нижнем регистре.
+func validChunkHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// requestErrorCode - функция для получения кода ошибки некорректного запроса по статусу ответа.
+func requestErrorCode(status int) string {
+	if status == http.StatusRequestEntityTooLarge {
+		return api.CodePayloadTooLarge
+	}
+	return api.CodeInvalidRequest
+}
+
+// decodeChunkList - функция для чтения списка хэшей частей из тела запроса. Возвращает статус ответа для случая ошибки.
+func decodeChunkList(res http.ResponseWriter, req *http.Request) (data.ChunkList, int, error) {
+	var list data.ChunkList
+	if err := json.NewDecoder(http.MaxBytesReader(res, req.Body, maxChunkListSize)).Decode(&list); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return data.ChunkList{}, http.StatusRequestEntityTooLarge, fmt.Errorf("chunk list is too large")
+		}
+		return data.ChunkList{}, http.StatusBadRequest, fmt.Errorf("decode chunk list error, %w", err)
+	}
+	for _, hash := range list.Chunks {
+		if !validChunkHash(hash) {
+			return data.ChunkList{}, http.StatusBadRequest, fmt.Errorf("chunk hash is not valid")
+		}
+	}
+	return list, http.StatusOK, nil
+}
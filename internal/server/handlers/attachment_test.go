@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/mocks"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chunkHash - вспомогательная функция для вычисления хэша части вложения.
+func chunkHash(chunk []byte) string {
+	sum := sha256.Sum256(chunk)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestSaveChunk(t *testing.T) {
+	// регистрирую мок хранилища вложений
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := mocks.NewMockIAttachmentStorage(ctrl)
+
+	chunk := []byte("chunk")
+	hash := chunkHash(chunk)
+	large := make([]byte, data.MaxEncryptedChunkSize+1)
+
+	m.EXPECT().SaveChunk(gomock.Any(), "success id", hash, chunk).Return(nil)
+	m.EXPECT().SaveChunk(gomock.Any(), "error id", hash, chunk).Return(errors.New("some error"))
+
+	type request struct {
+		target string
+		body   []byte
+		id     string
+	}
+	tests := []struct {
+		name   string
+		req    request
+		status int
+	}{
+		{
+			name:   "success save chunk",
+			req:    request{target: "/chunk/" + hash, body: chunk, id: "success id"},
+			status: 200,
+		},
+		{
+			name:   "error from storage",
+			req:    request{target: "/chunk/" + hash, body: chunk, id: "error id"},
+			status: 500,
+		},
+		{
+			name:   "hash does not match chunk",
+			req:    request{target: "/chunk/" + chunkHash([]byte("other")), body: chunk, id: "success id"},
+			status: 400,
+		},
+		{
+			name:   "bad chunk hash",
+			req:    request{target: "/chunk/first", body: chunk, id: "success id"},
+			status: 400,
+		},
+		{
+			name:   "upper case chunk hash",
+			req:    request{target: "/chunk/" + strings.ToUpper(hash), body: chunk, id: "success id"},
+			status: 400,
+		},
+		{
+			name:   "empty chunk",
+			req:    request{target: "/chunk/" + chunkHash(nil), body: nil, id: "success id"},
+			status: 400,
+		},
+		{
+			name:   "too large chunk",
+			req:    request{target: "/chunk/" + chunkHash(large), body: large, id: "success id"},
+			status: 413,
+		},
+		{
+			name:   "id does not set in context",
+			req:    request{target: "/chunk/" + hash, body: chunk},
+			status: 500,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := serveRequest(nil, SaveChunkHandler(m), http.MethodPut, "/chunk/{hash}", tt.req.target, tt.req.id,
+				string(tt.req.body)).Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.status, res.StatusCode)
+		})
+	}
+}
+
+func TestGetChunk(t *testing.T) {
+	// регистрирую мок хранилища вложений
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := mocks.NewMockIAttachmentStorage(ctrl)
+
+	hash := chunkHash([]byte("chunk"))
+	m.EXPECT().GetChunk(gomock.Any(), "success id", hash).Return([]byte("chunk"), true, nil)
+	m.EXPECT().GetChunk(gomock.Any(), "not found id", hash).Return(nil, false, nil)
+	m.EXPECT().GetChunk(gomock.Any(), "error id", hash).Return(nil, false, errors.New("some error"))
+
+	type request struct {
+		target string
+		id     string
+	}
+	type want struct {
+		status int
+		body   string
+	}
+	tests := []struct {
+		name string
+		req  request
+		want want
+	}{
+		{
+			name: "success get chunk",
+			req:  request{target: "/chunk/" + hash, id: "success id"},
+			want: want{status: 200, body: "chunk"},
+		},
+		{
+			name: "chunk not found",
+			req:  request{target: "/chunk/" + hash, id: "not found id"},
+			want: want{status: 404},
+		},
+		{
+			name: "error from storage",
+			req:  request{target: "/chunk/" + hash, id: "error id"},
+			want: want{status: 500},
+		},
+		{
+			name: "bad chunk hash",
+			req:  request{target: "/chunk/one", id: "success id"},
+			want: want{status: 400},
+		},
+		{
+			name: "id does not set in context",
+			req:  request{target: "/chunk/" + hash},
+			want: want{status: 500},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := serveRequest(nil, GetChunkHandler(m), http.MethodGet, "/chunk/{hash}", tt.req.target, tt.req.id,
+				"").Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.want.status, res.StatusCode)
+
+			if tt.want.status == http.StatusOK {
+				body, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want.body, string(body))
+				assert.Equal(t, "application/octet-stream", res.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestGetMissingChunks(t *testing.T) {
+	// регистрирую мок хранилища вложений
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := mocks.NewMockIAttachmentStorage(ctrl)
+
+	first, second := chunkHash([]byte("first")), chunkHash([]byte("second"))
+	m.EXPECT().GetMissingChunks(gomock.Any(), "success id", []string{first, second}).Return([]string{second}, nil)
+	m.EXPECT().GetMissingChunks(gomock.Any(), "error id", []string{first}).Return(nil, errors.New("some error"))
+
+	body := func(list data.ChunkList) []byte {
+		b, err := json.Marshal(list)
+		require.NoError(t, err)
+		return b
+	}
+
+	type request struct {
+		body []byte
+		id   string
+	}
+	type want struct {
+		status  int
+		missing []string
+	}
+	tests := []struct {
+		name string
+		req  request
+		want want
+	}{
+		{
+			name: "success get missing chunks",
+			req:  request{body: body(data.ChunkList{Chunks: []string{first, second}}), id: "success id"},
+			want: want{status: 200, missing: []string{second}},
+		},
+		{
+			name: "error from storage",
+			req:  request{body: body(data.ChunkList{Chunks: []string{first}}), id: "error id"},
+			want: want{status: 500},
+		},
+		{
+			name: "bad chunk hash",
+			req:  request{body: body(data.ChunkList{Chunks: []string{"first"}}), id: "success id"},
+			want: want{status: 400},
+		},
+		{
+			name: "bad body",
+			req:  request{body: []byte("not json"), id: "success id"},
+			want: want{status: 400},
+		},
+		{
+			name: "too large body",
+			req:  request{body: []byte(`{"chunks":["` + strings.Repeat("a", maxChunkListSize) + `"]}`), id: "success id"},
+			want: want{status: 413},
+		},
+		{
+			name: "id does not set in context",
+			req:  request{body: body(data.ChunkList{Chunks: []string{first}})},
+			want: want{status: 500},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := serveRequest(nil, GetMissingChunksHandler(m), http.MethodPost, "/chunk/missing", "/chunk/missing", tt.req.id,
+				string(tt.req.body)).Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.want.status, res.StatusCode)
+
+			if tt.want.status == http.StatusOK {
+				var list data.ChunkList
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&list))
+				assert.Equal(t, tt.want.missing, list.Chunks)
+			}
+		})
+	}
+}
+
+func TestAddAttachmentRef(t *testing.T) {
+	// регистрирую мок хранилища вложений
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := mocks.NewMockIAttachmentStorage(ctrl)
+
+	chunks := []string{chunkHash([]byte("first")), chunkHash([]byte("second"))}
+	m.EXPECT().AddAttachmentRef(gomock.Any(), "success id", "attachment", "record", chunks).Return(2, nil)
+	m.EXPECT().AddAttachmentRef(gomock.Any(), "missing id", "attachment", "record", chunks).Return(0, storage.ErrMissingChunks)
+	m.EXPECT().AddAttachmentRef(gomock.Any(), "mismatch id", "attachment", "record", chunks).Return(0, storage.ErrAttachmentMismatch)
+	m.EXPECT().AddAttachmentRef(gomock.Any(), "error id", "attachment", "record", chunks).Return(0, errors.New("some error"))
+
+	body, err := json.Marshal(data.ChunkList{Chunks: chunks})
+	require.NoError(t, err)
+	empty, err := json.Marshal(data.ChunkList{})
+	require.NoError(t, err)
+
+	type request struct {
+		target string
+		body   []byte
+		id     string
+	}
+	type want struct {
+		status int
+		info   data.AttachmentInfo
+	}
+	tests := []struct {
+		name string
+		req  request
+		want want
+	}{
+		{
+			name: "success add attachment ref",
+			req:  request{target: "/attachment/attachment?record=record", body: body, id: "success id"},
+			want: want{status: 200, info: data.AttachmentInfo{ID: "attachment", Chunks: chunks, Refs: 2}},
+		},
+		{
+			name: "chunks are missing",
+			req:  request{target: "/attachment/attachment?record=record", body: body, id: "missing id"},
+			want: want{status: 412},
+		},
+		{
+			name: "attachment mismatch",
+			req:  request{target: "/attachment/attachment?record=record", body: body, id: "mismatch id"},
+			want: want{status: 409},
+		},
+		{
+			name: "error from storage",
+			req:  request{target: "/attachment/attachment?record=record", body: body, id: "error id"},
+			want: want{status: 500},
+		},
+		{
+			name: "empty chunk list",
+			req:  request{target: "/attachment/attachment?record=record", body: empty, id: "success id"},
+			want: want{status: 400},
+		},
+		{
+			name: "too long attachment id",
+			req:  request{target: "/attachment/" + strings.Repeat("a", 129) + "?record=record", body: body, id: "success id"},
+			want: want{status: 400},
+		},
+		{
+			name: "record is not set",
+			req:  request{target: "/attachment/attachment", body: body, id: "success id"},
+			want: want{status: 400},
+		},
+		{
+			name: "too long record id",
+			req:  request{target: "/attachment/attachment?record=" + strings.Repeat("r", 65), body: body, id: "success id"},
+			want: want{status: 400},
+		},
+		{
+			name: "id does not set in context",
+			req:  request{target: "/attachment/attachment?record=record", body: body},
+			want: want{status: 500},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := serveRequest(nil, AddAttachmentRefHandler(m), http.MethodPost, "/attachment/{id}", tt.req.target, tt.req.id,
+				string(tt.req.body)).Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.want.status, res.StatusCode)
+
+			if tt.want.status == http.StatusOK {
+				var info data.AttachmentInfo
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&info))
+				assert.Equal(t, tt.want.info, info)
+			}
+		})
+	}
+}
+
+func TestGetAttachment(t *testing.T) {
+	// регистрирую мок хранилища вложений
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := mocks.NewMockIAttachmentStorage(ctrl)
+
+	info := data.AttachmentInfo{ID: "attachment", Chunks: []string{chunkHash([]byte("first"))}, Refs: 1}
+	m.EXPECT().GetAttachment(gomock.Any(), "success id", "attachment").Return(info, true, nil)
+	m.EXPECT().GetAttachment(gomock.Any(), "not found id", "attachment").Return(data.AttachmentInfo{}, false, nil)
+	m.EXPECT().GetAttachment(gomock.Any(), "error id", "attachment").Return(data.AttachmentInfo{}, false, errors.New("some error"))
+
+	type request struct {
+		id string
+	}
+	tests := []struct {
+		name   string
+		req    request
+		status int
+	}{
+		{name: "success get attachment", req: request{id: "success id"}, status: 200},
+		{name: "attachment not found", req: request{id: "not found id"}, status: 404},
+		{name: "error from storage", req: request{id: "error id"}, status: 500},
+		{name: "id does not set in context", req: request{}, status: 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := serveRequest(nil, GetAttachmentHandler(m), http.MethodGet, "/attachment/{id}", "/attachment/attachment", tt.req.id,
+				"").Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.status, res.StatusCode)
+
+			if tt.status == http.StatusOK {
+				var got data.AttachmentInfo
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+				assert.Equal(t, info, got)
+			}
+		})
+	}
+}
+
+func TestReleaseAttachment(t *testing.T) {
+	// регистрирую мок хранилища вложений
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := mocks.NewMockIAttachmentStorage(ctrl)
+
+	m.EXPECT().ReleaseAttachment(gomock.Any(), "success id", "attachment", "record").Return(1, true, nil)
+	m.EXPECT().ReleaseAttachment(gomock.Any(), "not found id", "attachment", "record").Return(0, false, nil)
+	m.EXPECT().ReleaseAttachment(gomock.Any(), "error id", "attachment", "record").Return(0, false, errors.New("some error"))
+
+	type request struct {
+		target string
+		id     string
+	}
+	tests := []struct {
+		name   string
+		req    request
+		status int
+	}{
+		{name: "success release attachment", req: request{target: "/attachment/attachment?record=record", id: "success id"}, status: 200},
+		{name: "attachment not found", req: request{target: "/attachment/attachment?record=record", id: "not found id"}, status: 404},
+		{name: "error from storage", req: request{target: "/attachment/attachment?record=record", id: "error id"}, status: 500},
+		{name: "record is not set", req: request{target: "/attachment/attachment", id: "success id"}, status: 400},
+		{name: "id does not set in context", req: request{target: "/attachment/attachment?record=record"}, status: 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := serveRequest(nil, ReleaseAttachmentHandler(m), http.MethodDelete, "/attachment/{id}", tt.req.target, tt.req.id,
+				"").Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.status, res.StatusCode)
+
+			if tt.status == http.StatusOK {
+				var info data.AttachmentInfo
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&info))
+				assert.Equal(t, data.AttachmentInfo{ID: "attachment", Refs: 1}, info)
+			}
+		})
+	}
+}
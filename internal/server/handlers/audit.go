@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	repoAudit "github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/auth"
+	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"go.uber.org/zap"
+)
+
+// GetAuditEvents - хэндлер для получения журнала аудита пользователя от новых событий к старым.
+// Параметры запроса since и until (RFC 3339) ограничивают время событий, limit - количество событий.
+func GetAuditEvents(res http.ResponseWriter, req *http.Request, log storage.IAuditStorage) {
+	// получаю id пользователя из контекста
+	id, ok := req.Context().Value(auth.UserIDKey).(string)
+	if !ok {
+		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
+		internalError(res)
+		return
+	}
+	defer req.Body.Close()
+
+	filter, err := auditFilter(req.URL.Query())
+	if err != nil {
+		logger.ServerLog.Error("invalid audit query", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	// Пользователь получает только свои события, ограниченными порциями
+	filter.UserID = id
+	filter.Desc = true
+	if filter.Limit == 0 {
+		filter.Limit = repoAudit.DefaultLimit
+	}
+	filter.Limit = min(filter.Limit, repoAudit.MaxLimit)
+
+	events, err := log.GetAuditEvents(req.Context(), filter)
+	if err != nil {
+		logger.ServerLog.Error("get audit events from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(events); err != nil {
+		logger.ServerLog.Error("encoding response error", zap.String("error", error.Error(err)))
+		return
+	}
+	logger.ServerLog.Debug("successful return audit events to client")
+}
+
+// GetAuditEventsHandler - обертка над GetAuditEvents.
+func GetAuditEventsHandler(log storage.IAuditStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		GetAuditEvents(res, req, log)
+	}
+	return fn
+}
+
+// ExportAuditEvents - хэндлер для выгрузки журнала аудита администратором в формате JSON Lines от старых событий
+// к новым. Параметр запроса user ограничивает выгрузку событиями пользователя, since, until и limit - как
+// в GetAuditEvents, но без ограничения количества событий по умолчанию.
+func ExportAuditEvents(res http.ResponseWriter, req *http.Request, log storage.IAuditStorage) {
+	defer req.Body.Close()
+
+	query := req.URL.Query()
+	filter, err := auditFilter(query)
+	if err != nil {
+		logger.ServerLog.Error("invalid audit query", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, err.Error())
+		return
+	}
+	filter.UserID = query.Get("user")
+
+	events, err := log.GetAuditEvents(req.Context(), filter)
+	if err != nil {
+		logger.ServerLog.Error("get audit events from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/x-ndjson")
+	res.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(res)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			logger.ServerLog.Error("encoding response error", zap.String("error", error.Error(err)))
+			return
+		}
+	}
+	logger.ServerLog.Info("audit events exported", zap.String("user id", filter.UserID), zap.Int("events", len(events)))
+}
+
+// ExportAuditEventsHandler - обертка над ExportAuditEvents.
+func ExportAuditEventsHandler(log storage.IAuditStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		ExportAuditEvents(res, req, log)
+	}
+	return fn
+}
+
+// auditFilter - функция для получения условий выборки журнала аудита из параметров запроса.
+func auditFilter(query url.Values) (repoAudit.Filter, error) {
+	var filter repoAudit.Filter
+	params := []struct {
+		name string
+		t    *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}}
+	for _, p := range params {
+		v := query.Get(p.name)
+		if v == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return repoAudit.Filter{}, fmt.Errorf("parameter %s must be a time in RFC 3339 format", p.name)
+		}
+		*p.t = parsed
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return repoAudit.Filter{}, fmt.Errorf("parameter limit must be a non-negative integer")
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	repoAudit "github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/auth"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failAuditLog - хранилище журнала аудита, которое всегда возвращает ошибку.
+type failAuditLog struct{}
+
+func (failAuditLog) AppendAuditEvent(context.Context, repoAudit.Event) error {
+	return errors.New("some error")
+}
+
+func (failAuditLog) GetAuditEvents(context.Context, repoAudit.Filter) ([]repoAudit.Event, error) {
+	return nil, errors.New("some error")
+}
+
+// auditLog - функция для создания журнала аудита с событиями двух пользователей.
+func auditLog(t *testing.T, start time.Time) *memory.Store {
+	t.Helper()
+	stor := memory.NewStore()
+	events := []repoAudit.Event{
+		{Time: start, Type: repoAudit.Register, UserID: "first"},
+		{Time: start.Add(time.Hour), Type: repoAudit.Register, UserID: "second"},
+		{Time: start.Add(2 * time.Hour), Type: repoAudit.RecordCreate, UserID: "first", RecordID: "card"},
+		{Time: start.Add(3 * time.Hour), Type: repoAudit.LoginSuccess, UserID: "first"},
+	}
+	for _, event := range events {
+		require.NoError(t, stor.AppendAuditEvent(context.Background(), event))
+	}
+	return stor
+}
+
+func TestGetAuditEvents(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stor := auditLog(t, start)
+
+	tests := []struct {
+		name   string
+		query  string
+		id     string
+		status int
+		want   []int64
+	}{
+		{name: "own events from newest", query: "", id: "first", status: http.StatusOK, want: []int64{4, 3, 1}},
+		{name: "limit", query: "?limit=1", id: "first", status: http.StatusOK, want: []int64{4}},
+		{name: "since and until", query: "?since=2024-01-01T01:00:00Z&until=2024-01-01T03:00:00Z", id: "first",
+			status: http.StatusOK, want: []int64{3}},
+		{name: "user without events", query: "", id: "third", status: http.StatusOK, want: []int64{}},
+		{name: "invalid since", query: "?since=yesterday", id: "first", status: http.StatusBadRequest},
+		{name: "invalid until", query: "?until=1", id: "first", status: http.StatusBadRequest},
+		{name: "negative limit", query: "?limit=-1", id: "first", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			request = request.WithContext(context.WithValue(request.Context(), auth.UserIDKey, tt.id))
+			w := httptest.NewRecorder()
+			GetAuditEventsHandler(stor)(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
+			require.Equal(t, tt.status, res.StatusCode)
+			if tt.status != http.StatusOK {
+				return
+			}
+			var events []repoAudit.Event
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&events))
+			ids := make([]int64, 0, len(events))
+			for _, event := range events {
+				assert.Equal(t, tt.id, event.UserID)
+				ids = append(ids, event.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+	{
+		// Ошибка хранилища
+		res := serveRequest(nil, GetAuditEventsHandler(failAuditLog{}), http.MethodGet, "/", "/", "first", "").Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	}
+	{
+		// id пользователя не установлен в контекст
+		w := httptest.NewRecorder()
+		GetAuditEventsHandler(stor)(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	}
+}
+
+func TestExportAuditEvents(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	stor := auditLog(t, start)
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		want   []int64
+	}{
+		{name: "all events from oldest", query: "", status: http.StatusOK, want: []int64{1, 2, 3, 4}},
+		{name: "events of user", query: "?user=second", status: http.StatusOK, want: []int64{2}},
+		{name: "since and limit", query: "?since=2024-01-01T01:00:00Z&limit=2", status: http.StatusOK, want: []int64{2, 3}},
+		{name: "invalid limit", query: "?limit=many", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ExportAuditEventsHandler(stor)(w, httptest.NewRequest(http.MethodGet, "/"+tt.query, nil))
+			res := w.Result()
+			defer res.Body.Close()
+
+			require.Equal(t, tt.status, res.StatusCode)
+			if tt.status != http.StatusOK {
+				return
+			}
+			assert.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+			ids := make([]int64, 0)
+			scanner := bufio.NewScanner(res.Body)
+			for scanner.Scan() {
+				var event repoAudit.Event
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+				ids = append(ids, event.ID)
+			}
+			require.NoError(t, scanner.Err())
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+	{
+		// Ошибка хранилища
+		w := httptest.NewRecorder()
+		ExportAuditEventsHandler(failAuditLog{})(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	}
+}
@@ -9,10 +9,15 @@ import (
 	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/checker"
 	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/id"
 	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/token"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	repoAudit "github.com/abezemskiy/gophkeeper/internal/repositories/audit"
 	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
 	"github.com/abezemskiy/gophkeeper/internal/repositories/identity"
+	"github.com/abezemskiy/gophkeeper/internal/server/audit"
 	"github.com/abezemskiy/gophkeeper/internal/server/identity/auth"
 	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/metrics"
+	"github.com/abezemskiy/gophkeeper/internal/server/quota"
 	"github.com/abezemskiy/gophkeeper/internal/server/storage"
 
 	"github.com/jackc/pgx/v5/pgconn"
@@ -28,20 +33,20 @@ func Register(res http.ResponseWriter, req *http.Request, ident identity.Identif
 	var regData identity.Data
 	if err := json.NewDecoder(req.Body).Decode(&regData); err != nil {
 		logger.ServerLog.Error("failed to parse identity data to structer", zap.String("address", req.URL.String()), zap.String("error", error.Error(err)))
-		http.Error(res, fmt.Errorf("failed to parse identity data to structer, %w", err).Error(), http.StatusBadRequest)
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "failed to parse identity data")
 		return
 	}
 
 	// Проверяю корректность логина
 	if ok := checker.CheckLogin(regData.Login); !ok {
 		logger.ServerLog.Error("login is not valid", zap.String("address", req.URL.String()))
-		http.Error(res, "login is not valid", http.StatusBadRequest)
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidLogin, "login is not valid")
 		return
 	}
 	// Проверяю корректность хэша от суммы логин+пароль
 	if ok := checker.CheckHash(regData.Hash); !ok {
 		logger.ServerLog.Error("hash is not valid", zap.String("address", req.URL.String()))
-		http.Error(res, "hash is not valid", http.StatusBadRequest)
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidHash, "hash is not valid")
 		return
 	}
 
@@ -49,7 +54,7 @@ func Register(res http.ResponseWriter, req *http.Request, ident identity.Identif
 	id, err := id.GenerateID()
 	if err != nil {
 		logger.ServerLog.Error("failed to generate id", zap.String("address", req.URL.String()), zap.String("error", error.Error(err)))
-		http.Error(res, fmt.Errorf("failed to generate id, %w", err).Error(), http.StatusInternalServerError)
+		internalError(res)
 		return
 	}
 
@@ -60,20 +65,22 @@ func Register(res http.ResponseWriter, req *http.Request, ident identity.Identif
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
 			// пользователь с данным логином уже зарегистрирован в системе
 			logger.ServerLog.Error(fmt.Sprintf("login %s already exists", regData.Login), zap.String("address", req.URL.String()))
-			http.Error(res, fmt.Errorf("login %s already exists, %w", regData.Login, err).Error(), http.StatusConflict)
+			api.WriteError(res, http.StatusConflict, api.CodeLoginExists, fmt.Sprintf("login %s already exists", regData.Login))
 		} else {
 			logger.ServerLog.Error("register user error", zap.String("address", req.URL.String()), zap.String("error", error.Error(err)))
-			http.Error(res, fmt.Errorf("register user error, %w", err).Error(), http.StatusInternalServerError)
+			internalError(res)
 		}
 		return
 	}
 
+	audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.Register, UserID: id, Login: regData.Login})
+
 	// При успешной регистрации создаю токен и устанавливаю токен в заголовок
 	// генерирую токен
 	token, err := token.BuildJWT(id)
 	if err != nil {
 		logger.ServerLog.Error("build JWT error", zap.String("address", req.URL.String()), zap.String("error", error.Error(err)))
-		http.Error(res, fmt.Errorf("build JWT error, %w", err).Error(), http.StatusInternalServerError)
+		internalError(res)
 		return
 	}
 	// устанавливаю токен в заголовок
@@ -99,20 +106,20 @@ func Authorize(res http.ResponseWriter, req *http.Request, ident identity.Identi
 	var regData identity.Data
 	if err := json.NewDecoder(req.Body).Decode(&regData); err != nil {
 		logger.ServerLog.Error("failed to parse identity data to structer", zap.String("address", req.URL.String()), zap.String("error", error.Error(err)))
-		http.Error(res, fmt.Errorf("failed to parse identity data to structer, %w", err).Error(), http.StatusBadRequest)
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "failed to parse identity data")
 		return
 	}
 
 	// Проверяю корректность логина
 	if ok := checker.CheckLogin(regData.Login); !ok {
 		logger.ServerLog.Error(fmt.Sprintf("login %s is not valid", regData.Login), zap.String("address", req.URL.String()))
-		http.Error(res, fmt.Sprintf("login %s is not valid", regData.Login), http.StatusBadRequest)
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidLogin, "login is not valid")
 		return
 	}
 	// Проверяю корректность хэша
 	if ok := checker.CheckHash(regData.Hash); !ok {
 		logger.ServerLog.Error(fmt.Sprintf("hash %s is not valid", regData.Hash), zap.String("address", req.URL.String()))
-		http.Error(res, fmt.Sprintf("hash %s is not valid", regData.Hash), http.StatusBadRequest)
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidHash, "hash is not valid")
 		return
 	}
 
@@ -121,29 +128,49 @@ func Authorize(res http.ResponseWriter, req *http.Request, ident identity.Identi
 	if err != nil {
 		// внутренняя ошибка сервера
 		logger.ServerLog.Error("authorize user error", zap.String("address", req.URL.String()), zap.String("error", error.Error(err)))
-		http.Error(res, fmt.Errorf("authorize user error, %w", err).Error(), http.StatusInternalServerError)
+		internalError(res)
 		return
 	}
 	if !ok {
 		// не найдено записей по представленному логину. Пользователь не зарегистрирован.
 		logger.ServerLog.Error(fmt.Sprintf("user %s not register", regData.Login), zap.String("address", req.URL.String()))
-		http.Error(res, fmt.Sprintf("user %s not register", regData.Login), http.StatusBadRequest)
+		metrics.AuthFailure(api.CodeInvalidCredentials)
+		audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.LoginFailure, Login: regData.Login})
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidCredentials, "login or password is wrong")
 		return
 	}
 
 	// проверяю что хэш пары логин+пароль отправленный пользователем для авторизации совпадает с тем, что хранится в хранилище.
 	if !checker.IsAuthorize(data.Hash, regData.Hash) {
 		logger.ServerLog.Error("password is wrong", zap.String("address", req.URL.String()))
-		http.Error(res, fmt.Errorf("password is wrong, %w", err).Error(), http.StatusBadRequest)
+		metrics.AuthFailure(api.CodeInvalidCredentials)
+		audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.LoginFailure, UserID: data.ID, Login: regData.Login})
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidCredentials, "login or password is wrong")
 		return
 	}
 
+	// Заблокированной учетной записи токен не выдается. Блокировка сообщается только после проверки пароля.
+	if data.Disabled {
+		logger.ServerLog.Error("account is disabled", zap.String("address", req.URL.String()), zap.String("login", regData.Login))
+		metrics.AuthFailure(api.CodeAccountDisabled)
+		audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.LoginFailure, UserID: data.ID, Login: regData.Login})
+		api.WriteError(res, http.StatusForbidden, api.CodeAccountDisabled, "account is disabled")
+		return
+	}
+
+	// Клиент с истекшим токеном авторизуется повторно, такой вход записывается в журнал как обновление токена
+	audit.Record(req.Context(), repoAudit.Event{
+		Type:   audit.LoginType(req.Header.Get("Authorization"), data.ID),
+		UserID: data.ID,
+		Login:  regData.Login,
+	})
+
 	// При успешной авторизации создаю токен и устанавливаю токен в заголовок
 	// генерирую токен
 	token, err := token.BuildJWT(data.ID)
 	if err != nil {
 		logger.ServerLog.Error("build JWT error", zap.String("address", req.URL.String()), zap.String("error", error.Error(err)))
-		http.Error(res, fmt.Errorf("build JWT error, %w", err).Error(), http.StatusInternalServerError)
+		internalError(res)
 		return
 	}
 	// устанавливаю токен в заголовок
@@ -165,29 +192,38 @@ func AddEncryptedData(res http.ResponseWriter, req *http.Request, stor storage.I
 	id, ok := req.Context().Value(auth.UserIDKey).(string)
 	if !ok {
 		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
-		http.Error(res, "user ID not found in context", http.StatusInternalServerError)
+		internalError(res)
 		return
 	}
 	defer req.Body.Close()
 
 	// Сериализую данные из запроса клиента
 	var encrData data.EncryptedData
-	if err := json.NewDecoder(req.Body).Decode(&encrData); err != nil {
+	if err := json.NewDecoder(limitBody(res, req.Body)).Decode(&encrData); err != nil {
+		if isTooLarge(err) {
+			logger.ServerLog.Error("request body is too large", zap.String("address", req.URL.String()))
+			api.WriteError(res, http.StatusRequestEntityTooLarge, api.CodePayloadTooLarge, "request body is too large")
+			return
+		}
 		logger.ServerLog.Error("can't parse data from request", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
-		http.Error(res, "can't parse data from request", http.StatusInternalServerError)
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "can't parse data from request")
 		return
 	}
 
 	// Добавляю новые данные в хранилище
 	ok, err := stor.AddEncryptedData(req.Context(), id, encrData, data.SAVED)
+	if errors.Is(err, quota.ErrQuotaExceeded) {
+		quotaError(res, req, err)
+		return
+	}
 	if err != nil {
 		logger.ServerLog.Error("adding data to storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
-		http.Error(res, fmt.Errorf("adding data to storage error, %w", err).Error(), http.StatusInternalServerError)
+		internalError(res)
 		return
 	}
 	if !ok {
 		logger.ServerLog.Error("data is already exist", zap.String("address", req.URL.String()))
-		http.Error(res, "data is already exist", http.StatusConflict)
+		api.WriteError(res, http.StatusConflict, api.CodeDataExists, "data is already exist")
 		return
 	}
 
@@ -211,29 +247,38 @@ func ReplaceEncryptedData(res http.ResponseWriter, req *http.Request, stor stora
 	id, ok := req.Context().Value(auth.UserIDKey).(string)
 	if !ok {
 		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
-		http.Error(res, "user ID not found in context", http.StatusInternalServerError)
+		internalError(res)
 		return
 	}
 	defer req.Body.Close()
 
 	// Сериализую данные из запроса клиента
 	var newData data.EncryptedData
-	if err := json.NewDecoder(req.Body).Decode(&newData); err != nil {
+	if err := json.NewDecoder(limitBody(res, req.Body)).Decode(&newData); err != nil {
+		if isTooLarge(err) {
+			logger.ServerLog.Error("request body is too large", zap.String("address", req.URL.String()))
+			api.WriteError(res, http.StatusRequestEntityTooLarge, api.CodePayloadTooLarge, "request body is too large")
+			return
+		}
 		logger.ServerLog.Error("can't parse data from request", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
-		http.Error(res, "can't parse data from request", http.StatusInternalServerError)
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "can't parse data from request")
 		return
 	}
 
 	// заменяю старые данные новыми в хранилище
 	ok, err := stor.ReplaceEncryptedData(req.Context(), id, newData, data.SAVED)
+	if errors.Is(err, quota.ErrQuotaExceeded) {
+		quotaError(res, req, err)
+		return
+	}
 	if err != nil {
 		logger.ServerLog.Error("replace data in storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
-		http.Error(res, fmt.Errorf("replace data in storage error, %w", err).Error(), http.StatusInternalServerError)
+		internalError(res)
 		return
 	}
 	if !ok {
 		logger.ServerLog.Error("data does not exist", zap.String("address", req.URL.String()))
-		http.Error(res, "data does not exist", http.StatusNotFound)
+		api.WriteError(res, http.StatusNotFound, api.CodeDataNotFound, "data does not exist")
 		return
 	}
 
@@ -255,7 +300,7 @@ func GetAllEncryptedData(res http.ResponseWriter, req *http.Request, stor storag
 	id, ok := req.Context().Value(auth.UserIDKey).(string)
 	if !ok {
 		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
-		http.Error(res, "user ID not found in context", http.StatusInternalServerError)
+		internalError(res)
 		return
 	}
 	defer req.Body.Close()
@@ -263,15 +308,16 @@ func GetAllEncryptedData(res http.ResponseWriter, req *http.Request, stor storag
 	allData, err := stor.GetAllEncryptedData(req.Context(), id)
 	if err != nil {
 		logger.ServerLog.Error("get all data from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
-		http.Error(res, fmt.Errorf("get all data from storage error, %w", err).Error(), http.StatusInternalServerError)
+		internalError(res)
 		return
 	}
+	res.Header().Set("Content-Type", "application/json")
 	res.WriteHeader(http.StatusOK)
 
+	// Статус ответа уже отправлен, поэтому ошибка кодирования только логируется
 	enc := json.NewEncoder(res)
 	if err := enc.Encode(allData); err != nil {
 		logger.ServerLog.Error("encoding response error", zap.String("error", error.Error(err)))
-		http.Error(res, fmt.Errorf("encoding response error, %w", err).Error(), http.StatusInternalServerError)
 		return
 	}
 	logger.ServerLog.Debug("successful return all encrypted data to client")
@@ -291,18 +337,23 @@ func DeleteEncryptedData(res http.ResponseWriter, req *http.Request, stor storag
 	id, ok := req.Context().Value(auth.UserIDKey).(string)
 	if !ok {
 		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
-		http.Error(res, "user ID not found in context", http.StatusInternalServerError)
+		internalError(res)
 		return
 	}
 	defer req.Body.Close()
 
 	// извлекаю информацию о данных из запроса клиента
 	var dataMetaInfo data.MetaInfo
-	dec := json.NewDecoder(req.Body)
+	dec := json.NewDecoder(limitBody(res, req.Body))
 	err := dec.Decode(&dataMetaInfo)
 	if err != nil {
+		if isTooLarge(err) {
+			logger.ServerLog.Error("request body is too large", zap.String("address", req.URL.String()))
+			api.WriteError(res, http.StatusRequestEntityTooLarge, api.CodePayloadTooLarge, "request body is too large")
+			return
+		}
 		logger.ServerLog.Error("decoding request error", zap.String("error", error.Error(err)))
-		http.Error(res, fmt.Errorf("decoding request error, %w", err).Error(), http.StatusBadRequest)
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "can't parse data from request")
 		return
 	}
 
@@ -310,12 +361,12 @@ func DeleteEncryptedData(res http.ResponseWriter, req *http.Request, stor storag
 	ok, err = stor.DeleteEncryptedData(req.Context(), id, dataMetaInfo.Name)
 	if err != nil {
 		logger.ServerLog.Error("delete data from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
-		http.Error(res, fmt.Errorf("delete data from storage error, %w", err).Error(), http.StatusInternalServerError)
+		internalError(res)
 		return
 	}
 	if !ok {
 		logger.ServerLog.Error("data does not exist", zap.String("address", req.URL.String()))
-		http.Error(res, "data does not exist", http.StatusNotFound)
+		api.WriteError(res, http.StatusNotFound, api.CodeDataNotFound, "data does not exist")
 		return
 	}
 
@@ -338,29 +389,38 @@ func HandleConflictData(res http.ResponseWriter, req *http.Request, stor storage
 	id, ok := req.Context().Value(auth.UserIDKey).(string)
 	if !ok {
 		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
-		http.Error(res, "user ID not found in context", http.StatusInternalServerError)
+		internalError(res)
 		return
 	}
 	defer req.Body.Close()
 
 	// Сериализую данные из запроса клиента
 	var appendData data.EncryptedData
-	if err := json.NewDecoder(req.Body).Decode(&appendData); err != nil {
+	if err := json.NewDecoder(limitBody(res, req.Body)).Decode(&appendData); err != nil {
+		if isTooLarge(err) {
+			logger.ServerLog.Error("request body is too large", zap.String("address", req.URL.String()))
+			api.WriteError(res, http.StatusRequestEntityTooLarge, api.CodePayloadTooLarge, "request body is too large")
+			return
+		}
 		logger.ServerLog.Error("can't parse data from request", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
-		http.Error(res, "can't parse data from request", http.StatusInternalServerError)
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "can't parse data from request")
 		return
 	}
 
 	// добавляю новую версию данных к уже существующим
 	ok, err := stor.AppendEncryptedData(req.Context(), id, appendData)
+	if errors.Is(err, quota.ErrQuotaExceeded) {
+		quotaError(res, req, err)
+		return
+	}
 	if err != nil {
 		logger.ServerLog.Error("append data to storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
-		http.Error(res, fmt.Errorf("append data to storage error, %w", err).Error(), http.StatusInternalServerError)
+		internalError(res)
 		return
 	}
 	if !ok {
 		logger.ServerLog.Error("data does not exist", zap.String("address", req.URL.String()))
-		http.Error(res, "data does not exist", http.StatusNotFound)
+		api.WriteError(res, http.StatusNotFound, api.CodeDataNotFound, "data does not exist")
 		return
 	}
 
@@ -378,8 +438,13 @@ func HandleConflictDataHandler(stor storage.IEncryptedServerStorage) http.Handle
 
 // HandleOtherRequest - обработка нераспознанных http запросов к сервису.
 func HandleOtherRequest() http.HandlerFunc {
-	return func(res http.ResponseWriter, _ *http.Request) {
-		res.Header().Set("Content-Type", "text/plain")
-		res.WriteHeader(http.StatusNotFound)
+	return func(res http.ResponseWriter, req *http.Request) {
+		api.WriteError(res, http.StatusNotFound, api.CodeNotFound, fmt.Sprintf("%s %s is not found", req.Method, req.URL.Path))
 	}
 }
+
+// internalError - функция для ответа о внутренней ошибке сервера. Подробности ошибки только логируются,
+// чтобы не раскрывать клиенту внутреннее устройство сервера.
+func internalError(res http.ResponseWriter) {
+	api.WriteError(res, http.StatusInternalServerError, api.CodeInternal, "internal server error")
+}
@@ -481,7 +481,7 @@ func TestAddEncryptedData(t *testing.T) {
 				id:    idSuccessful,
 			},
 			want: want{
-				status: 500,
+				status: 400,
 			},
 		},
 		{
@@ -630,7 +630,7 @@ func TestReplaceEncryptedData(t *testing.T) {
 				id:    idSuccessful,
 			},
 			want: want{
-				status: 500,
+				status: 400,
 			},
 		},
 		{
@@ -1005,7 +1005,7 @@ func TestHandleConflictData(t *testing.T) {
 				id:    idSuccessful,
 			},
 			want: want{
-				status: 500,
+				status: 400,
 			},
 		},
 		{
@@ -0,0 +1,519 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/id"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	repoAudit "github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/org"
+	"github.com/abezemskiy/gophkeeper/internal/server/audit"
+	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/quota"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// CreateOrg - хэндлер для создания организации. Создатель становится её владельцем, ключ коллекции передается
+// зашифрованным его открытым ключом. В ответ возвращается созданная организация со статусом 201.
+func CreateOrg(res http.ResponseWriter, req *http.Request, orgs storage.IOrgStorage) {
+	idUser, ok := userID(res, req)
+	if !ok {
+		return
+	}
+	defer req.Body.Close()
+
+	var newOrg org.New
+	if !decodeBody(res, req, &newOrg) {
+		return
+	}
+	if newOrg.Name == "" || len(newOrg.Key) == 0 {
+		logger.ServerLog.Error("org is not valid", zap.String("address", req.URL.String()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "name and key of org are required")
+		return
+	}
+
+	orgID, err := id.GenerateID()
+	if err != nil {
+		logger.ServerLog.Error("generate org id error", zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	ok, err = orgs.CreateOrg(req.Context(), org.Org{ID: orgID, Name: newOrg.Name}, org.Member{UserID: idUser, Key: newOrg.Key})
+	if err != nil {
+		logger.ServerLog.Error("create org in storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		logger.ServerLog.Error("org id already exists", zap.String("org id", orgID))
+		internalError(res)
+		return
+	}
+	created, _, err := orgs.GetOrg(req.Context(), orgID, idUser)
+	if err != nil {
+		logger.ServerLog.Error("get org from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+
+	audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.OrgCreate, UserID: idUser, RecordID: orgID})
+	logger.ServerLog.Info("user created org", zap.String("user id", idUser), zap.String("org id", orgID))
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(res).Encode(created); err != nil {
+		logger.ServerLog.Error("encoding response error", zap.String("error", error.Error(err)))
+	}
+}
+
+// CreateOrgHandler - обертка над CreateOrg.
+func CreateOrgHandler(orgs storage.IOrgStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		CreateOrg(res, req, orgs)
+	}
+	return fn
+}
+
+// GetOrgs - хэндлер для получения организаций, участником которых является пользователь.
+func GetOrgs(res http.ResponseWriter, req *http.Request, orgs storage.IOrgStorage) {
+	idUser, ok := userID(res, req)
+	if !ok {
+		return
+	}
+	defer req.Body.Close()
+
+	list, err := orgs.GetOrgs(req.Context(), idUser)
+	if err != nil {
+		logger.ServerLog.Error("get orgs from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	writeJSON(res, list)
+}
+
+// GetOrgsHandler - обертка над GetOrgs.
+func GetOrgsHandler(orgs storage.IOrgStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		GetOrgs(res, req, orgs)
+	}
+	return fn
+}
+
+// GetOrg - хэндлер для получения организации с id из адреса запроса с ролью и ключом коллекции пользователя.
+func GetOrg(res http.ResponseWriter, req *http.Request, orgs storage.IOrgStorage) {
+	_, o, ok := loadOrg(res, req, orgs)
+	if !ok {
+		return
+	}
+	writeJSON(res, o)
+}
+
+// GetOrgHandler - обертка над GetOrg.
+func GetOrgHandler(orgs storage.IOrgStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		GetOrg(res, req, orgs)
+	}
+	return fn
+}
+
+// DeleteOrg - хэндлер для удаления организации с id из адреса запроса вместе с коллекцией. Удалить организацию
+// может только владелец.
+func DeleteOrg(res http.ResponseWriter, req *http.Request, orgs storage.IOrgStorage) {
+	idUser, o, ok := loadOrg(res, req, orgs)
+	if !ok {
+		return
+	}
+	if o.Role != org.Owner {
+		api.WriteError(res, http.StatusForbidden, api.CodeOrgForbidden, "only owner can delete org")
+		return
+	}
+
+	ok, err := orgs.DeleteOrg(req.Context(), o.ID)
+	if err != nil {
+		logger.ServerLog.Error("delete org from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		api.WriteError(res, http.StatusNotFound, api.CodeOrgNotFound, "org not found")
+		return
+	}
+	audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.OrgDelete, UserID: idUser, RecordID: o.ID})
+	logger.ServerLog.Info("org deleted", zap.String("user id", idUser), zap.String("org id", o.ID))
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteOrgHandler - обертка над DeleteOrg.
+func DeleteOrgHandler(orgs storage.IOrgStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		DeleteOrg(res, req, orgs)
+	}
+	return fn
+}
+
+// GetMembers - хэндлер для получения участников организации с id из адреса запроса. Ключи коллекции других
+// участников в ответ не передаются.
+func GetMembers(res http.ResponseWriter, req *http.Request, orgs storage.IOrgStorage) {
+	_, o, ok := loadOrg(res, req, orgs)
+	if !ok {
+		return
+	}
+
+	members, err := orgs.GetMembers(req.Context(), o.ID)
+	if err != nil {
+		logger.ServerLog.Error("get members from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	for i := range members {
+		members[i].Key = nil
+	}
+	writeJSON(res, members)
+}
+
+// GetMembersHandler - обертка над GetMembers.
+func GetMembersHandler(orgs storage.IOrgStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		GetMembers(res, req, orgs)
+	}
+	return fn
+}
+
+// AddMember - хэндлер для добавления участника в организацию с id из адреса запроса. Ключ коллекции передается
+// зашифрованным открытым ключом нового участника, поэтому участник должен сохранить ключевую пару на сервере.
+// Добавить участника можно только с ролью ниже своей.
+func AddMember(res http.ResponseWriter, req *http.Request, orgs storage.IOrgStorage, shares storage.IShareStorage) {
+	var m org.Member
+	if !decodeBody(res, req, &m) {
+		return
+	}
+	if m.Login == "" || len(m.Key) == 0 || !org.ValidRole(m.Role) {
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "login, role and key of member are required")
+		return
+	}
+	idUser, o, ok := loadOrg(res, req, orgs)
+	if !ok {
+		return
+	}
+	if !org.CanManage(o.Role, m.Role) {
+		api.WriteError(res, http.StatusForbidden, api.CodeOrgForbidden, "role of member doesn't allow to add member with this role")
+		return
+	}
+	if m.KeyVersion != o.KeyVersion {
+		api.WriteError(res, http.StatusConflict, api.CodeOrgConflict, "org key has been rotated, get the latest version")
+		return
+	}
+
+	memberID, _, ok, err := shares.GetPublicKey(req.Context(), m.Login)
+	if err != nil {
+		logger.ServerLog.Error("get public key from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		api.WriteError(res, http.StatusNotFound, api.CodeKeyNotFound, "user not found or has no key pair")
+		return
+	}
+	m.UserID = memberID
+
+	ok, err = orgs.AddMember(req.Context(), o.ID, m)
+	if err != nil {
+		logger.ServerLog.Error("add member to storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		api.WriteError(res, http.StatusConflict, api.CodeMemberExists, "user is already a member of org")
+		return
+	}
+	audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.OrgMemberAdd, UserID: idUser, RecordID: o.ID + "/" + m.Login})
+	logger.ServerLog.Info("member added to org", zap.String("user id", idUser), zap.String("org id", o.ID), zap.String("member", m.Login))
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// AddMemberHandler - обертка над AddMember.
+func AddMemberHandler(orgs storage.IOrgStorage, shares storage.IShareStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		AddMember(res, req, orgs, shares)
+	}
+	return fn
+}
+
+// SetRole - хэндлер для изменения роли участника с логином из адреса запроса. Изменить можно только роль участника
+// с ролью ниже своей и только на роль ниже своей, роль владельца не изменяется.
+func SetRole(res http.ResponseWriter, req *http.Request, orgs storage.IOrgStorage) {
+	var role org.Role
+	if !decodeBody(res, req, &role) {
+		return
+	}
+	if !org.ValidRole(role.Role) {
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "role is not valid")
+		return
+	}
+	idUser, o, ok := loadOrg(res, req, orgs)
+	if !ok {
+		return
+	}
+	target, ok := loadMember(res, req, orgs, o.ID, chi.URLParam(req, "login"))
+	if !ok {
+		return
+	}
+	if !org.CanManage(o.Role, target.Role) || !org.CanManage(o.Role, role.Role) {
+		api.WriteError(res, http.StatusForbidden, api.CodeOrgForbidden, "role of member doesn't allow to change this role")
+		return
+	}
+
+	ok, err := orgs.SetRole(req.Context(), o.ID, target.UserID, role.Role)
+	if err != nil {
+		logger.ServerLog.Error("set role in storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		api.WriteError(res, http.StatusNotFound, api.CodeMemberNotFound, "member not found")
+		return
+	}
+	audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.OrgMemberRole, UserID: idUser, RecordID: o.ID + "/" + target.Login})
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// SetRoleHandler - обертка над SetRole.
+func SetRoleHandler(orgs storage.IOrgStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		SetRole(res, req, orgs)
+	}
+	return fn
+}
+
+// RotateKey - хэндлер для замены ключа коллекции организации с id из адреса запроса, при которой может быть удален
+// участник. Заменить ключ может администратор или владелец, а удалить - только участника с ролью ниже своей.
+// Если участники, ключ или записи коллекции изменены после их получения клиентом, возвращается статус 409.
+func RotateKey(res http.ResponseWriter, req *http.Request, orgs storage.IOrgStorage) {
+	var rotation org.Rotation
+	if !decodeBody(res, req, &rotation) {
+		return
+	}
+	idUser, o, ok := loadOrg(res, req, orgs)
+	if !ok {
+		return
+	}
+	if !org.CanManage(o.Role, org.Viewer) {
+		api.WriteError(res, http.StatusForbidden, api.CodeOrgForbidden, "role of member doesn't allow to rotate key")
+		return
+	}
+
+	members, err := orgs.GetMembers(req.Context(), o.ID)
+	if err != nil {
+		logger.ServerLog.Error("get members from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	// Ключи передаются по логинам участников, а хранилище принимает их по id
+	byLogin := make(map[string]org.Member, len(members))
+	for _, m := range members {
+		byLogin[m.Login] = m
+	}
+	var removeID string
+	if rotation.Remove != "" {
+		target, ok := byLogin[rotation.Remove]
+		if !ok {
+			api.WriteError(res, http.StatusNotFound, api.CodeMemberNotFound, "member not found")
+			return
+		}
+		if !org.CanManage(o.Role, target.Role) {
+			api.WriteError(res, http.StatusForbidden, api.CodeOrgForbidden, "role of member doesn't allow to remove this member")
+			return
+		}
+		removeID = target.UserID
+	}
+	keys := make(map[string][]byte, len(rotation.Keys))
+	for login, key := range rotation.Keys {
+		m, ok := byLogin[login]
+		if !ok || len(key) == 0 {
+			api.WriteError(res, http.StatusConflict, api.CodeOrgConflict, "members of org have been changed")
+			return
+		}
+		keys[m.UserID] = key
+	}
+	rotation.Keys = keys
+
+	ok, err = orgs.RotateKey(req.Context(), o.ID, removeID, rotation)
+	if err != nil {
+		logger.ServerLog.Error("rotate org key in storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		api.WriteError(res, http.StatusConflict, api.CodeOrgConflict, "members, key or items of org have been changed")
+		return
+	}
+	audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.OrgKeyRotate, UserID: idUser, RecordID: o.ID})
+	logger.ServerLog.Info("org key rotated", zap.String("user id", idUser), zap.String("org id", o.ID),
+		zap.String("removed", rotation.Remove))
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// RotateKeyHandler - обертка над RotateKey.
+func RotateKeyHandler(orgs storage.IOrgStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		RotateKey(res, req, orgs)
+	}
+	return fn
+}
+
+// GetItems - хэндлер для получения записей коллекции организации с id из адреса запроса.
+func GetItems(res http.ResponseWriter, req *http.Request, orgs storage.IOrgStorage) {
+	_, o, ok := loadOrg(res, req, orgs)
+	if !ok {
+		return
+	}
+
+	items, err := orgs.GetItems(req.Context(), o.ID)
+	if err != nil {
+		logger.ServerLog.Error("get org items from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	writeJSON(res, items)
+}
+
+// GetItemsHandler - обертка над GetItems.
+func GetItemsHandler(orgs storage.IOrgStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		GetItems(res, req, orgs)
+	}
+	return fn
+}
+
+// PutItem - хэндлер для добавления или изменения записи коллекции организации редактором, администратором
+// или владельцем. Если запись изменена после получения клиентом её версии или ключ коллекции заменен, возвращается
+// статус 409, при успешном изменении - новая версия записи. Записи коллекции учитываются в квоте владельца
+// организации, при превышении квоты возвращается статус 507.
+func PutItem(res http.ResponseWriter, req *http.Request, orgs storage.IOrgStorage) {
+	var item org.Item
+	if !decodeBody(res, req, &item) {
+		return
+	}
+	if item.Name == "" || len(item.Data) == 0 {
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "name and data of item are required")
+		return
+	}
+	idUser, o, ok := loadOrg(res, req, orgs)
+	if !ok {
+		return
+	}
+	if !org.CanWrite(o.Role) {
+		api.WriteError(res, http.StatusForbidden, api.CodeOrgForbidden, "org is read-only for member")
+		return
+	}
+
+	version, ok, err := orgs.PutItem(req.Context(), o.ID, item)
+	if errors.Is(err, quota.ErrQuotaExceeded) {
+		quotaError(res, req, err)
+		return
+	}
+	if err != nil {
+		logger.ServerLog.Error("put org item to storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		api.WriteError(res, http.StatusConflict, api.CodeOrgConflict, "item or org key has been changed, get the latest version")
+		return
+	}
+	audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.OrgItemUpdate, UserID: idUser, RecordID: o.ID + "/" + item.Name})
+	writeJSON(res, org.Item{Name: item.Name, KeyVersion: item.KeyVersion, Version: version})
+}
+
+// PutItemHandler - обертка над PutItem.
+func PutItemHandler(orgs storage.IOrgStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		PutItem(res, req, orgs)
+	}
+	return fn
+}
+
+// DeleteItem - хэндлер для удаления записи коллекции организации редактором, администратором или владельцем.
+// Если запись не найдена или изменена после получения клиентом её версии, возвращается статус 409.
+func DeleteItem(res http.ResponseWriter, req *http.Request, orgs storage.IOrgStorage) {
+	var item org.Item
+	if !decodeBody(res, req, &item) {
+		return
+	}
+	if item.Name == "" {
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "name of item is required")
+		return
+	}
+	idUser, o, ok := loadOrg(res, req, orgs)
+	if !ok {
+		return
+	}
+	if !org.CanWrite(o.Role) {
+		api.WriteError(res, http.StatusForbidden, api.CodeOrgForbidden, "org is read-only for member")
+		return
+	}
+
+	ok, err := orgs.DeleteItem(req.Context(), o.ID, item.Name, item.Version)
+	if err != nil {
+		logger.ServerLog.Error("delete org item from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		api.WriteError(res, http.StatusConflict, api.CodeOrgConflict, "item has been changed or deleted, get the latest version")
+		return
+	}
+	audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.OrgItemDelete, UserID: idUser, RecordID: o.ID + "/" + item.Name})
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteItemHandler - обертка над DeleteItem.
+func DeleteItemHandler(orgs storage.IOrgStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		DeleteItem(res, req, orgs)
+	}
+	return fn
+}
+
+// loadOrg - функция для получения организации с id из адреса запроса с ролью пользователя. Организация, участником
+// которой пользователь не является, не отличается от несуществующей.
+func loadOrg(res http.ResponseWriter, req *http.Request, orgs storage.IOrgStorage) (string, org.Org, bool) {
+	idUser, ok := userID(res, req)
+	if !ok {
+		return "", org.Org{}, false
+	}
+	defer req.Body.Close()
+
+	o, ok, err := orgs.GetOrg(req.Context(), chi.URLParam(req, "id"), idUser)
+	if err != nil {
+		logger.ServerLog.Error("get org from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return "", org.Org{}, false
+	}
+	if !ok {
+		api.WriteError(res, http.StatusNotFound, api.CodeOrgNotFound, "org not found")
+		return "", org.Org{}, false
+	}
+	return idUser, o, true
+}
+
+// loadMember - функция для получения участника организации idOrg по логину.
+func loadMember(res http.ResponseWriter, req *http.Request, orgs storage.IOrgStorage, idOrg, login string) (org.Member, bool) {
+	members, err := orgs.GetMembers(req.Context(), idOrg)
+	if err != nil {
+		logger.ServerLog.Error("get members from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return org.Member{}, false
+	}
+	for _, m := range members {
+		if m.Login == login {
+			return m, true
+		}
+	}
+	api.WriteError(res, http.StatusNotFound, api.CodeMemberNotFound, "member not found")
+	return org.Member{}, false
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	repoAudit "github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/org"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/share"
+	"github.com/abezemskiy/gophkeeper/internal/server/quota"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrgs(t *testing.T) {
+	stor := accountsStore(t)
+	ctx := context.Background()
+	require.NoError(t, stor.Register(ctx, "carol", "hash", "third"))
+	for _, user := range []string{"first", "second", "third"} {
+		require.NoError(t, stor.SetKeyPair(ctx, user, share.KeyPair{PublicKey: []byte(user), EncryptedPrivateKey: []byte("private")}))
+	}
+	marshal := func(v any) string {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return string(b)
+	}
+	assertCode := func(w *httptest.ResponseRecorder, status int, code string) {
+		t.Helper()
+		require.Equal(t, status, w.Code)
+		assert.Equal(t, code, api.ParseError(w.Code, w.Body.Bytes()).Code)
+	}
+
+	// Создание организации
+	w := serveRequest(stor, CreateOrgHandler(stor), http.MethodPost, "/", "/", "first", `{`)
+	assertCode(w, http.StatusBadRequest, api.CodeInvalidRequest)
+	w = serveRequest(stor, CreateOrgHandler(stor), http.MethodPost, "/", "/", "first", `{"name":"team"}`)
+	assertCode(w, http.StatusBadRequest, api.CodeInvalidRequest)
+	w = serveRequest(stor, CreateOrgHandler(stor), http.MethodPost, "/", "/", "first", marshal(org.New{Name: "team", Key: []byte("alice key")}))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created org.Org
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.NotEmpty(t, created.ID)
+	assert.Equal(t, org.Owner, created.Role)
+	assert.Equal(t, int64(1), created.KeyVersion)
+	assert.Equal(t, []byte("alice key"), created.Key)
+
+	target := "/" + created.ID
+	get := func(user string) *httptest.ResponseRecorder {
+		return serveRequest(stor, GetOrgHandler(stor), http.MethodGet, "/{id}", target, user, "")
+	}
+	addMember := func(user string, m org.Member) *httptest.ResponseRecorder {
+		return serveRequest(stor, AddMemberHandler(stor, stor), http.MethodPost, "/{id}/members", target+"/members", user, marshal(m))
+	}
+	setRole := func(user, login, role string) *httptest.ResponseRecorder {
+		return serveRequest(stor, SetRoleHandler(stor), http.MethodPut, "/{id}/members/{login}", target+"/members/"+login,
+			user, marshal(org.Role{Role: role}))
+	}
+	putItem := func(user string, item org.Item) *httptest.ResponseRecorder {
+		return serveRequest(stor, PutItemHandler(stor), http.MethodPut, "/{id}/items", target+"/items", user, marshal(item))
+	}
+	rotate := func(user string, r org.Rotation) *httptest.ResponseRecorder {
+		return serveRequest(stor, RotateKeyHandler(stor), http.MethodPost, "/{id}/rotate", target+"/rotate", user, marshal(r))
+	}
+
+	// Организация, участником которой пользователь не является, не отличается от несуществующей
+	require.Equal(t, http.StatusOK, get("first").Code)
+	assertCode(get("second"), http.StatusNotFound, api.CodeOrgNotFound)
+
+	// Добавление участников
+	tests := []struct {
+		name   string
+		user   string
+		member org.Member
+		status int
+		code   string
+	}{
+		{name: "invalid role", user: "first", member: org.Member{Login: "bob", Role: "guest", Key: []byte("k"), KeyVersion: 1},
+			status: http.StatusBadRequest, code: api.CodeInvalidRequest},
+		{name: "not a member", user: "second", member: org.Member{Login: "carol", Role: org.Viewer, Key: []byte("k"), KeyVersion: 1},
+			status: http.StatusNotFound, code: api.CodeOrgNotFound},
+		{name: "second owner", user: "first", member: org.Member{Login: "bob", Role: org.Owner, Key: []byte("k"), KeyVersion: 1},
+			status: http.StatusForbidden, code: api.CodeOrgForbidden},
+		{name: "stale key version", user: "first", member: org.Member{Login: "bob", Role: org.Editor, Key: []byte("k"), KeyVersion: 2},
+			status: http.StatusConflict, code: api.CodeOrgConflict},
+		{name: "unknown user", user: "first", member: org.Member{Login: "dave", Role: org.Editor, Key: []byte("k"), KeyVersion: 1},
+			status: http.StatusNotFound, code: api.CodeKeyNotFound},
+		{name: "editor", user: "first", member: org.Member{Login: "bob", Role: org.Editor, Key: []byte("bob key"), KeyVersion: 1},
+			status: http.StatusNoContent},
+		{name: "viewer", user: "first", member: org.Member{Login: "carol", Role: org.Viewer, Key: []byte("carol key"), KeyVersion: 1},
+			status: http.StatusNoContent},
+		{name: "already a member", user: "first", member: org.Member{Login: "bob", Role: org.Viewer, Key: []byte("k"), KeyVersion: 1},
+			status: http.StatusConflict, code: api.CodeMemberExists},
+		{name: "editor can't manage members", user: "second", member: org.Member{Login: "alice", Role: org.Viewer, Key: []byte("k"), KeyVersion: 1},
+			status: http.StatusForbidden, code: api.CodeOrgForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := addMember(tt.user, tt.member)
+			require.Equal(t, tt.status, w.Code)
+			if tt.code != "" {
+				assert.Equal(t, tt.code, api.ParseError(w.Code, w.Body.Bytes()).Code)
+			}
+		})
+	}
+
+	// Изменение ролей: участником можно управлять, только если его роль и новая роль ниже своей
+	assertCode(setRole("second", "carol", org.Editor), http.StatusForbidden, api.CodeOrgForbidden)
+	assertCode(setRole("first", "dave", org.Editor), http.StatusNotFound, api.CodeMemberNotFound)
+	require.Equal(t, http.StatusNoContent, setRole("first", "carol", org.Admin).Code)
+	assertCode(setRole("third", "bob", org.Admin), http.StatusForbidden, api.CodeOrgForbidden)
+	assertCode(setRole("third", "alice", org.Viewer), http.StatusForbidden, api.CodeOrgForbidden)
+	require.Equal(t, http.StatusNoContent, setRole("third", "bob", org.Viewer).Code)
+
+	w = serveRequest(stor, GetMembersHandler(stor), http.MethodGet, "/{id}/members", target+"/members", "second", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var members []org.Member
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &members))
+	assert.Equal(t, []org.Member{
+		{Login: "alice", Role: org.Owner, KeyVersion: 1},
+		{Login: "bob", Role: org.Viewer, KeyVersion: 1},
+		{Login: "carol", Role: org.Admin, KeyVersion: 1},
+	}, members)
+
+	// Записи коллекции изменяют редакторы, администраторы и владелец
+	assertCode(putItem("second", org.Item{Name: "db", Data: []byte("data"), KeyVersion: 1}), http.StatusForbidden, api.CodeOrgForbidden)
+	assertCode(putItem("third", org.Item{Name: "db"}), http.StatusBadRequest, api.CodeInvalidRequest)
+	w = putItem("third", org.Item{Name: "db", Data: []byte("data"), KeyVersion: 1})
+	require.Equal(t, http.StatusOK, w.Code)
+	var saved org.Item
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &saved))
+	assert.Equal(t, int64(1), saved.Version)
+	assertCode(putItem("first", org.Item{Name: "db", Data: []byte("data"), KeyVersion: 1}), http.StatusConflict, api.CodeOrgConflict)
+
+	w = serveRequest(stor, GetItemsHandler(stor), http.MethodGet, "/{id}/items", target+"/items", "second", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var items []org.Item
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &items))
+	require.Len(t, items, 1)
+	assert.Equal(t, []byte("data"), items[0].Data)
+
+	// Удаление участника с заменой ключа коллекции
+	rotation := org.Rotation{
+		Remove:     "bob",
+		KeyVersion: 2,
+		Keys:       map[string][]byte{"alice": []byte("alice key 2"), "carol": []byte("carol key 2")},
+		Items:      []org.Item{{Name: "db", Data: []byte("rotated"), Version: 1}},
+	}
+	assertCode(rotate("second", rotation), http.StatusForbidden, api.CodeOrgForbidden)
+	assertCode(rotate("third", org.Rotation{Remove: "alice"}), http.StatusForbidden, api.CodeOrgForbidden)
+	assertCode(rotate("third", org.Rotation{Remove: "dave"}), http.StatusNotFound, api.CodeMemberNotFound)
+	assertCode(rotate("third", org.Rotation{Remove: "bob", KeyVersion: 2, Keys: map[string][]byte{"dave": []byte("k")}}),
+		http.StatusConflict, api.CodeOrgConflict)
+	require.Equal(t, http.StatusNoContent, rotate("third", rotation).Code)
+	assertCode(rotate("third", rotation), http.StatusNotFound, api.CodeMemberNotFound)
+	assertCode(get("second"), http.StatusNotFound, api.CodeOrgNotFound)
+
+	w = get("third")
+	require.Equal(t, http.StatusOK, w.Code)
+	var rotated org.Org
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rotated))
+	assert.Equal(t, int64(2), rotated.KeyVersion)
+	assert.Equal(t, []byte("carol key 2"), rotated.Key)
+
+	// Удаление записи коллекции с проверкой версии
+	deleteItem := func(version int64) *httptest.ResponseRecorder {
+		return serveRequest(stor, DeleteItemHandler(stor), http.MethodDelete, "/{id}/items", target+"/items", "third",
+			marshal(org.Item{Name: "db", Version: version}))
+	}
+	assertCode(deleteItem(1), http.StatusConflict, api.CodeOrgConflict)
+	require.Equal(t, http.StatusNoContent, deleteItem(2).Code)
+
+	// Удалить организацию может только владелец
+	deleteOrg := func(user string) *httptest.ResponseRecorder {
+		return serveRequest(stor, DeleteOrgHandler(stor), http.MethodDelete, "/{id}", target, user, "")
+	}
+	assertCode(deleteOrg("third"), http.StatusForbidden, api.CodeOrgForbidden)
+	require.Equal(t, http.StatusNoContent, deleteOrg("first").Code)
+	assertCode(deleteOrg("first"), http.StatusNotFound, api.CodeOrgNotFound)
+
+	w = serveRequest(stor, GetOrgsHandler(stor), http.MethodGet, "/", "/", "first", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+
+	// Действия с организацией записаны в журнал аудита
+	events, err := stor.GetAuditEvents(ctx, repoAudit.Filter{})
+	require.NoError(t, err)
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []string{repoAudit.OrgCreate, repoAudit.OrgMemberAdd, repoAudit.OrgMemberAdd, repoAudit.OrgMemberRole,
+		repoAudit.OrgMemberRole, repoAudit.OrgItemUpdate, repoAudit.OrgKeyRotate, repoAudit.OrgItemDelete, repoAudit.OrgDelete}, types)
+}
+
+func TestOrgQuota(t *testing.T) {
+	defer quota.SetLimits(quota.GetLimits())
+	quota.SetLimits(quota.Limits{MaxBytes: 20})
+
+	// У владельца организации уже сохранена запись размером 10 байт
+	stor := accountsStore(t)
+	ctx := context.Background()
+	ok, err := stor.CreateOrg(ctx, org.Org{ID: "org", Name: "team"}, org.Member{UserID: "first", Key: []byte("alice key")})
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = stor.AddMember(ctx, "org", org.Member{UserID: "second", Role: org.Editor, Key: []byte("bob key"), KeyVersion: 1})
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	putItem := func(user string, item org.Item) *httptest.ResponseRecorder {
+		b, err := json.Marshal(item)
+		require.NoError(t, err)
+		return serveRequest(nil, PutItemHandler(stor), http.MethodPut, "/{id}/items", "/org/items", user, string(b))
+	}
+
+	// Запись, добавленная редактором, учитывается в квоте владельца
+	w := putItem("second", org.Item{Name: "card", Data: make([]byte, 11), KeyVersion: 1})
+	require.Equal(t, http.StatusInsufficientStorage, w.Code)
+	assert.Equal(t, api.CodeQuotaExceeded, api.ParseError(w.Code, w.Body.Bytes()).Code)
+	require.Equal(t, http.StatusOK, putItem("second", org.Item{Name: "card", Data: make([]byte, 5), KeyVersion: 1}).Code)
+	w = putItem("first", org.Item{Name: "card", Data: make([]byte, 11), KeyVersion: 1, Version: 1})
+	require.Equal(t, http.StatusInsufficientStorage, w.Code)
+	require.Equal(t, http.StatusOK, putItem("first", org.Item{Name: "card", Data: make([]byte, 10), KeyVersion: 1, Version: 1}).Code)
+
+	usage, err := stor.GetUsage(ctx, "first")
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), usage.Bytes)
+	assert.Equal(t, 2, usage.Records)
+	usage, err = stor.GetUsage(ctx, "second")
+	require.NoError(t, err)
+	assert.Zero(t, usage.Bytes)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/abezemskiy/gophkeeper/internal/server/audit"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/auth"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// serveRequest - вспомогательная функция для выполнения запроса пользователя user к адресу target с телом body.
+// Хэндлер h регистрируется в роутере по шаблону pattern. Пустой user означает запрос без авторизации, а события
+// аудита записываются в журнал log, если он задан.
+func serveRequest(log storage.IAuditStorage, h http.HandlerFunc, method, pattern, target, user, body string) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.MethodFunc(method, pattern, h)
+	request := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	ctx := request.Context()
+	if log != nil {
+		ctx = audit.WithSource(ctx, log, "127.0.0.1", "laptop")
+	}
+	if user != "" {
+		ctx = context.WithValue(ctx, auth.UserIDKey, user)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, request.WithContext(ctx))
+	return w
+}
@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/id"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	repoAudit "github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/share"
+	"github.com/abezemskiy/gophkeeper/internal/server/audit"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/auth"
+	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/quota"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// SetKeyPair - хэндлер для сохранения или замены ключевой пары пользователя. Закрытый ключ передается
+// зашифрованным ключом хранилища пользователя, поэтому сервер не может его прочитать.
+func SetKeyPair(res http.ResponseWriter, req *http.Request, shares storage.IShareStorage) {
+	idUser, ok := userID(res, req)
+	if !ok {
+		return
+	}
+	defer req.Body.Close()
+
+	var keys share.KeyPair
+	if !decodeBody(res, req, &keys) {
+		return
+	}
+	if len(keys.PublicKey) == 0 || len(keys.EncryptedPrivateKey) == 0 {
+		logger.ServerLog.Error("key pair is empty", zap.String("address", req.URL.String()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "public and private keys are required")
+		return
+	}
+
+	if err := shares.SetKeyPair(req.Context(), idUser, keys); err != nil {
+		logger.ServerLog.Error("set key pair to storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	logger.ServerLog.Debug("successful set key pair", zap.String("user id", idUser))
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// SetKeyPairHandler - обертка над SetKeyPair.
+func SetKeyPairHandler(shares storage.IShareStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		SetKeyPair(res, req, shares)
+	}
+	return fn
+}
+
+// GetKeyPair - хэндлер для получения пользователем своей ключевой пары.
+func GetKeyPair(res http.ResponseWriter, req *http.Request, shares storage.IShareStorage) {
+	idUser, ok := userID(res, req)
+	if !ok {
+		return
+	}
+	defer req.Body.Close()
+
+	keys, ok, err := shares.GetKeyPair(req.Context(), idUser)
+	if err != nil {
+		logger.ServerLog.Error("get key pair from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		api.WriteError(res, http.StatusNotFound, api.CodeKeyNotFound, "key pair not found")
+		return
+	}
+	writeJSON(res, keys)
+}
+
+// GetKeyPairHandler - обертка над GetKeyPair.
+func GetKeyPairHandler(shares storage.IShareStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		GetKeyPair(res, req, shares)
+	}
+	return fn
+}
+
+// GetPublicKey - хэндлер для получения открытого ключа пользователя с логином из адреса запроса, которому
+// владелец хочет передать запись.
+func GetPublicKey(res http.ResponseWriter, req *http.Request, shares storage.IShareStorage) {
+	if _, ok := userID(res, req); !ok {
+		return
+	}
+	defer req.Body.Close()
+
+	login := chi.URLParam(req, "login")
+	_, key, ok, err := shares.GetPublicKey(req.Context(), login)
+	if err != nil {
+		logger.ServerLog.Error("get public key from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		api.WriteError(res, http.StatusNotFound, api.CodeKeyNotFound, "user not found or has no key pair")
+		return
+	}
+	writeJSON(res, share.PublicKey{Login: login, PublicKey: key})
+}
+
+// GetPublicKeyHandler - обертка над GetPublicKey.
+func GetPublicKeyHandler(shares storage.IShareStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		GetPublicKey(res, req, shares)
+	}
+	return fn
+}
+
+// CreateShare - хэндлер для создания общей записи владельцем. Получатель должен сохранить ключевую пару на
+// сервере; запись становится доступна получателю после её принятия. В ответ возвращается созданная общая запись
+// со статусом 201. Общая запись учитывается в квоте владельца, при превышении квоты возвращается статус 507.
+func CreateShare(res http.ResponseWriter, req *http.Request, shares storage.IShareStorage) {
+	idUser, ok := userID(res, req)
+	if !ok {
+		return
+	}
+	defer req.Body.Close()
+
+	var newShare share.New
+	if !decodeBody(res, req, &newShare) {
+		return
+	}
+	if newShare.Name == "" || !share.ValidPermission(newShare.Permission) || len(newShare.OwnerKey) == 0 ||
+		len(newShare.RecipientKey) == 0 || len(newShare.Data) == 0 {
+		logger.ServerLog.Error("share is not valid", zap.String("address", req.URL.String()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "name, permission, keys and data of share are required")
+		return
+	}
+
+	recipientID, _, ok, err := shares.GetPublicKey(req.Context(), newShare.Recipient)
+	if err != nil {
+		logger.ServerLog.Error("get public key from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		api.WriteError(res, http.StatusNotFound, api.CodeKeyNotFound, "recipient not found or has no key pair")
+		return
+	}
+	if recipientID == idUser {
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "can't share record with yourself")
+		return
+	}
+
+	shareID, err := id.GenerateID()
+	if err != nil {
+		logger.ServerLog.Error("generate share id error", zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	ok, err = shares.CreateShare(req.Context(), share.Share{
+		ID:           shareID,
+		Name:         newShare.Name,
+		OwnerID:      idUser,
+		RecipientID:  recipientID,
+		Permission:   newShare.Permission,
+		OwnerKey:     newShare.OwnerKey,
+		RecipientKey: newShare.RecipientKey,
+		Data:         newShare.Data,
+	})
+	if errors.Is(err, quota.ErrQuotaExceeded) {
+		quotaError(res, req, err)
+		return
+	}
+	if err != nil {
+		logger.ServerLog.Error("create share in storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		api.WriteError(res, http.StatusConflict, api.CodeShareExists, "record is already shared with this user")
+		return
+	}
+	created, _, err := shares.GetShare(req.Context(), shareID)
+	if err != nil {
+		logger.ServerLog.Error("get share from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+
+	audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.ShareCreate, UserID: idUser, RecordID: shareID})
+	logger.ServerLog.Info("user shared record", zap.String("user id", idUser), zap.String("share id", shareID))
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(res).Encode(created); err != nil {
+		logger.ServerLog.Error("encoding response error", zap.String("error", error.Error(err)))
+	}
+}
+
+// CreateShareHandler - обертка над CreateShare.
+func CreateShareHandler(shares storage.IShareStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		CreateShare(res, req, shares)
+	}
+	return fn
+}
+
+// GetShares - хэндлер для получения общих записей, которыми пользователь поделился или которые переданы ему.
+func GetShares(res http.ResponseWriter, req *http.Request, shares storage.IShareStorage) {
+	idUser, ok := userID(res, req)
+	if !ok {
+		return
+	}
+	defer req.Body.Close()
+
+	list, err := shares.GetShares(req.Context(), idUser)
+	if err != nil {
+		logger.ServerLog.Error("get shares from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	writeJSON(res, list)
+}
+
+// GetSharesHandler - обертка над GetShares.
+func GetSharesHandler(shares storage.IShareStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		GetShares(res, req, shares)
+	}
+	return fn
+}
+
+// AcceptShare - хэндлер для принятия получателем общей записи с id из адреса запроса.
+func AcceptShare(res http.ResponseWriter, req *http.Request, shares storage.IShareStorage) {
+	idUser, sh, ok := loadShare(res, req, shares)
+	if !ok {
+		return
+	}
+	if sh.RecipientID != idUser {
+		api.WriteError(res, http.StatusForbidden, api.CodeShareForbidden, "only recipient can accept share")
+		return
+	}
+
+	ok, err := shares.AcceptShare(req.Context(), sh.ID)
+	if err != nil {
+		logger.ServerLog.Error("accept share in storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		api.WriteError(res, http.StatusNotFound, api.CodeShareNotFound, "share not found")
+		return
+	}
+	audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.ShareAccept, UserID: idUser, RecordID: sh.ID})
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// AcceptShareHandler - обертка над AcceptShare.
+func AcceptShareHandler(shares storage.IShareStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		AcceptShare(res, req, shares)
+	}
+	return fn
+}
+
+// DeleteShare - хэндлер для удаления общей записи с id из адреса запроса: владелец отзывает доступ получателя,
+// а получатель отказывается от записи. Запись владельца при этом не изменяется.
+func DeleteShare(res http.ResponseWriter, req *http.Request, shares storage.IShareStorage) {
+	idUser, sh, ok := loadShare(res, req, shares)
+	if !ok {
+		return
+	}
+
+	ok, err := shares.DeleteShare(req.Context(), sh.ID)
+	if err != nil {
+		logger.ServerLog.Error("delete share from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		api.WriteError(res, http.StatusNotFound, api.CodeShareNotFound, "share not found")
+		return
+	}
+	audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.ShareRevoke, UserID: idUser, RecordID: sh.ID})
+	logger.ServerLog.Info("share revoked", zap.String("user id", idUser), zap.String("share id", sh.ID))
+	res.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteShareHandler - обертка над DeleteShare.
+func DeleteShareHandler(shares storage.IShareStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		DeleteShare(res, req, shares)
+	}
+	return fn
+}
+
+// UpdateShare - хэндлер для изменения содержимого общей записи с id из адреса запроса владельцем или получателем
+// с правом на изменение. Если запись изменена после получения клиентом её версии, возвращается статус 409,
+// при успешном изменении - новая версия записи. Изменение учитывается в квоте владельца общей записи,
+// при превышении квоты возвращается статус 507.
+func UpdateShare(res http.ResponseWriter, req *http.Request, shares storage.IShareStorage) {
+	var update share.Update
+	if !decodeBody(res, req, &update) {
+		return
+	}
+	if len(update.Data) == 0 {
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "data of share is required")
+		return
+	}
+	idUser, sh, ok := loadShare(res, req, shares)
+	if !ok {
+		return
+	}
+	if !sh.Writable(idUser) {
+		api.WriteError(res, http.StatusForbidden, api.CodeShareForbidden, "share is read-only for user")
+		return
+	}
+
+	version, ok, err := shares.UpdateShare(req.Context(), sh.ID, update.Data, update.Version)
+	if errors.Is(err, quota.ErrQuotaExceeded) {
+		quotaError(res, req, err)
+		return
+	}
+	if err != nil {
+		logger.ServerLog.Error("update share in storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	if !ok {
+		api.WriteError(res, http.StatusConflict, api.CodeShareConflict, "share has been changed, get the latest version")
+		return
+	}
+	audit.Record(req.Context(), repoAudit.Event{Type: repoAudit.ShareUpdate, UserID: idUser, RecordID: sh.ID})
+	writeJSON(res, share.Update{Version: version})
+}
+
+// UpdateShareHandler - обертка над UpdateShare.
+func UpdateShareHandler(shares storage.IShareStorage) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		UpdateShare(res, req, shares)
+	}
+	return fn
+}
+
+// userID - функция для получения id пользователя из контекста запроса. Если id не найден, в ответ записывается
+// внутренняя ошибка сервера.
+func userID(res http.ResponseWriter, req *http.Request) (string, bool) {
+	idUser, ok := req.Context().Value(auth.UserIDKey).(string)
+	if !ok {
+		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
+		internalError(res)
+	}
+	return idUser, ok
+}
+
+// loadShare - функция для получения общей записи с id из адреса запроса, доступной пользователю как владельцу
+// или получателю. Недоступная запись не отличается от несуществующей.
+func loadShare(res http.ResponseWriter, req *http.Request, shares storage.IShareStorage) (string, share.Share, bool) {
+	idUser, ok := userID(res, req)
+	if !ok {
+		return "", share.Share{}, false
+	}
+	defer req.Body.Close()
+
+	sh, ok, err := shares.GetShare(req.Context(), chi.URLParam(req, "id"))
+	if err != nil {
+		logger.ServerLog.Error("get share from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return "", share.Share{}, false
+	}
+	if !ok || !sh.Visible(idUser) {
+		api.WriteError(res, http.StatusNotFound, api.CodeShareNotFound, "share not found")
+		return "", share.Share{}, false
+	}
+	return idUser, sh, true
+}
+
+// decodeBody - функция для разбора тела запроса в формате JSON в v. При ошибке в ответ записывается статус 400,
+// а при превышении размера тела - статус 413.
+func decodeBody(res http.ResponseWriter, req *http.Request, v any) bool {
+	if err := json.NewDecoder(limitBody(res, req.Body)).Decode(v); err != nil {
+		if isTooLarge(err) {
+			logger.ServerLog.Error("request body is too large", zap.String("address", req.URL.String()))
+			api.WriteError(res, http.StatusRequestEntityTooLarge, api.CodePayloadTooLarge, "request body is too large")
+			return false
+		}
+		logger.ServerLog.Error("can't parse data from request", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		api.WriteError(res, http.StatusBadRequest, api.CodeInvalidRequest, "can't parse data from request")
+		return false
+	}
+	return true
+}
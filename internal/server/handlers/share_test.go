@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	repoAudit "github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/share"
+	"github.com/abezemskiy/gophkeeper/internal/server/quota"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyPair(t *testing.T) {
+	stor := accountsStore(t)
+
+	// Ключевая пара не сохранена
+	w := serveRequest(stor, GetKeyPairHandler(stor), http.MethodGet, "/", "/", "second", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, api.CodeKeyNotFound, api.ParseError(w.Code, w.Body.Bytes()).Code)
+	w = serveRequest(stor, GetPublicKeyHandler(stor), http.MethodGet, "/{login}", "/bob", "first", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	// Некорректная ключевая пара
+	w = serveRequest(stor, SetKeyPairHandler(stor), http.MethodPut, "/", "/", "second", `{`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	w = serveRequest(stor, SetKeyPairHandler(stor), http.MethodPut, "/", "/", "second", `{"public_key":"cHVibGlj"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	w = serveRequest(stor, SetKeyPairHandler(stor), http.MethodPut, "/", "/", "", `{}`)
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	// Ключевая пара сохранена, открытый ключ доступен другому пользователю по логину
+	w = serveRequest(stor, SetKeyPairHandler(stor), http.MethodPut, "/", "/", "second",
+		`{"public_key":"cHVibGlj","encrypted_private_key":"cHJpdmF0ZQ=="}`)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serveRequest(stor, GetKeyPairHandler(stor), http.MethodGet, "/", "/", "second", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var keys share.KeyPair
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &keys))
+	assert.Equal(t, share.KeyPair{PublicKey: []byte("public"), EncryptedPrivateKey: []byte("private")}, keys)
+
+	w = serveRequest(stor, GetPublicKeyHandler(stor), http.MethodGet, "/{login}", "/bob", "first", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var key share.PublicKey
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &key))
+	assert.Equal(t, share.PublicKey{Login: "bob", PublicKey: []byte("public")}, key)
+}
+
+func TestShares(t *testing.T) {
+	stor := accountsStore(t)
+	ctx := context.Background()
+	require.NoError(t, stor.Register(ctx, "carol", "hash", "third"))
+	for _, user := range []string{"first", "second"} {
+		require.NoError(t, stor.SetKeyPair(ctx, user, share.KeyPair{PublicKey: []byte(user), EncryptedPrivateKey: []byte("private")}))
+	}
+
+	newShare := func(recipient, permission string) string {
+		b, err := json.Marshal(share.New{Name: "github", Recipient: recipient, Permission: permission,
+			OwnerKey: []byte("owner key"), RecipientKey: []byte("recipient key"), Data: []byte("data")})
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	// Создание общей записи
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{name: "invalid body", body: `{`, status: http.StatusBadRequest, code: api.CodeInvalidRequest},
+		{name: "invalid permission", body: newShare("bob", "admin"), status: http.StatusBadRequest, code: api.CodeInvalidRequest},
+		{name: "without data", body: `{"name":"github","recipient":"bob","permission":"read"}`, status: http.StatusBadRequest, code: api.CodeInvalidRequest},
+		{name: "recipient without key pair", body: newShare("carol", share.Read), status: http.StatusNotFound, code: api.CodeKeyNotFound},
+		{name: "unknown recipient", body: newShare("dave", share.Read), status: http.StatusNotFound, code: api.CodeKeyNotFound},
+		{name: "share with yourself", body: newShare("alice", share.Read), status: http.StatusBadRequest, code: api.CodeInvalidRequest},
+		{name: "success", body: newShare("bob", share.Read), status: http.StatusCreated},
+		{name: "already shared", body: newShare("bob", share.Write), status: http.StatusConflict, code: api.CodeShareExists},
+	}
+	var created share.Share
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serveRequest(stor, CreateShareHandler(stor), http.MethodPost, "/", "/", "first", tt.body)
+			require.Equal(t, tt.status, w.Code)
+			if tt.code != "" {
+				assert.Equal(t, tt.code, api.ParseError(w.Code, w.Body.Bytes()).Code)
+				return
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		})
+	}
+	require.NotEmpty(t, created.ID)
+	assert.Equal(t, "alice", created.Owner)
+	assert.Equal(t, "bob", created.Recipient)
+	assert.Equal(t, int64(1), created.Version)
+	assert.False(t, created.Accepted)
+
+	// Общая запись видна владельцу и получателю, но не другим пользователям
+	for user, count := range map[string]int{"first": 1, "second": 1, "third": 0} {
+		w := serveRequest(stor, GetSharesHandler(stor), http.MethodGet, "/", "/", user, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var list []share.Share
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		assert.Len(t, list, count, user)
+	}
+
+	target := "/" + created.ID
+	accept := func(user string) *httptest.ResponseRecorder {
+		return serveRequest(stor, AcceptShareHandler(stor), http.MethodPost, "/{id}/accept", target+"/accept", user, "")
+	}
+	update := func(user string, version int64) *httptest.ResponseRecorder {
+		b, err := json.Marshal(share.Update{Data: []byte("new data"), Version: version})
+		require.NoError(t, err)
+		return serveRequest(stor, UpdateShareHandler(stor), http.MethodPut, "/{id}/data", target+"/data", user, string(b))
+	}
+
+	// Принимает запись только получатель, недоступная запись не отличается от несуществующей
+	w := accept("first")
+	require.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, api.CodeShareForbidden, api.ParseError(w.Code, w.Body.Bytes()).Code)
+	w = accept("third")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, api.CodeShareNotFound, api.ParseError(w.Code, w.Body.Bytes()).Code)
+	require.Equal(t, http.StatusNoContent, accept("second").Code)
+
+	// Получатель с правом на чтение не может изменять запись, изменение устаревшей версии отклоняется
+	w = update("second", 1)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, api.CodeShareForbidden, api.ParseError(w.Code, w.Body.Bytes()).Code)
+	w = update("first", 1)
+	require.Equal(t, http.StatusOK, w.Code)
+	var updated share.Update
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, share.Update{Version: 2}, updated)
+	w = update("first", 1)
+	require.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, api.CodeShareConflict, api.ParseError(w.Code, w.Body.Bytes()).Code)
+	w = serveRequest(stor, UpdateShareHandler(stor), http.MethodPut, "/{id}/data", target+"/data", "first", `{"version":2}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Получатель отказывается от записи
+	remove := func(user string) int {
+		return serveRequest(stor, DeleteShareHandler(stor), http.MethodDelete, "/{id}", target, user, "").Code
+	}
+	require.Equal(t, http.StatusNotFound, remove("third"))
+	require.Equal(t, http.StatusNoContent, remove("second"))
+	require.Equal(t, http.StatusNotFound, remove("first"))
+
+	// Действия с общими записями записаны в журнал аудита
+	events, err := stor.GetAuditEvents(ctx, repoAudit.Filter{})
+	require.NoError(t, err)
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+		assert.Equal(t, created.ID, e.RecordID)
+	}
+	assert.Equal(t, []string{repoAudit.ShareCreate, repoAudit.ShareAccept, repoAudit.ShareUpdate, repoAudit.ShareRevoke}, types)
+}
+
+func TestShareQuota(t *testing.T) {
+	defer quota.SetLimits(quota.GetLimits())
+	quota.SetLimits(quota.Limits{MaxBytes: 20})
+
+	// У владельца уже сохранена запись размером 10 байт
+	stor := accountsStore(t)
+	require.NoError(t, stor.SetKeyPair(context.Background(), "second", share.KeyPair{PublicKey: []byte("public"), EncryptedPrivateKey: []byte("private")}))
+
+	create := func(name string, size int) *httptest.ResponseRecorder {
+		b, err := json.Marshal(share.New{Name: name, Recipient: "bob", Permission: share.Write,
+			OwnerKey: []byte("owner key"), RecipientKey: []byte("recipient key"), Data: make([]byte, size)})
+		require.NoError(t, err)
+		return serveRequest(nil, CreateShareHandler(stor), http.MethodPost, "/", "/", "first", string(b))
+	}
+	w := create("github", 8)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created share.Share
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	w = create("gitlab", 3)
+	require.Equal(t, http.StatusInsufficientStorage, w.Code)
+	assert.Equal(t, api.CodeQuotaExceeded, api.ParseError(w.Code, w.Body.Bytes()).Code)
+
+	// Изменение учитывается в квоте владельца, в том числе когда запись изменяет получатель
+	update := func(user string, size int, version int64) *httptest.ResponseRecorder {
+		b, err := json.Marshal(share.Update{Data: make([]byte, size), Version: version})
+		require.NoError(t, err)
+		return serveRequest(nil, UpdateShareHandler(stor), http.MethodPut, "/{id}/data", "/"+created.ID+"/data", user, string(b))
+	}
+	_, err := stor.AcceptShare(context.Background(), created.ID)
+	require.NoError(t, err)
+	w = update("second", 11, 1)
+	require.Equal(t, http.StatusInsufficientStorage, w.Code)
+	assert.Equal(t, api.CodeQuotaExceeded, api.ParseError(w.Code, w.Body.Bytes()).Code)
+	require.Equal(t, http.StatusOK, update("second", 10, 1).Code)
+
+	usage, err := stor.GetUsage(context.Background(), "first")
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), usage.Bytes)
+	assert.Equal(t, 2, usage.Records)
+}
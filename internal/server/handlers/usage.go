@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/auth"
+	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/quota"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"go.uber.org/zap"
+)
+
+// GetUsage - хэндлер для получения информации об использовании хранилища пользователем и его квотах.
+func GetUsage(res http.ResponseWriter, req *http.Request, stor storage.UsageReader) {
+	// получаю id пользователя из контекста
+	id, ok := req.Context().Value(auth.UserIDKey).(string)
+	if !ok {
+		logger.ServerLog.Error("user ID not found in context", zap.String("address", req.URL.String()))
+		internalError(res)
+		return
+	}
+	defer req.Body.Close()
+
+	usage, err := stor.GetUsage(req.Context(), id)
+	if err != nil {
+		logger.ServerLog.Error("get usage from storage error", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+		internalError(res)
+		return
+	}
+	quota.Fill(&usage)
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(res).Encode(usage); err != nil {
+		logger.ServerLog.Error("encoding response error", zap.String("error", error.Error(err)))
+		return
+	}
+	logger.ServerLog.Debug("successful return usage to client")
+}
+
+// GetUsageHandler - обертка над GetUsage.
+func GetUsageHandler(stor storage.UsageReader) http.HandlerFunc {
+	fn := func(res http.ResponseWriter, req *http.Request) {
+		GetUsage(res, req, stor)
+	}
+	return fn
+}
+
+// limitBody - функция для ограничения размера тела запроса с данными пользователя.
+func limitBody(res http.ResponseWriter, body io.ReadCloser) io.ReadCloser {
+	if maxSize := quota.GetLimits().MaxBodySize; maxSize > 0 {
+		return http.MaxBytesReader(res, body, maxSize)
+	}
+	return body
+}
+
+// isTooLarge - функция для проверки, что ошибка чтения тела запроса вызвана превышением его размера.
+func isTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// quotaError - функция для отправки ответа в случае превышения квоты пользователя, о котором сообщило хранилище.
+// Превышение квоты возвращается со статусом 507 Insufficient Storage.
+func quotaError(res http.ResponseWriter, req *http.Request, err error) {
+	logger.ServerLog.Error("quota exceeded", zap.String("address", req.URL.String()), zap.String("error", err.Error()))
+	api.WriteError(res, http.StatusInsufficientStorage, api.CodeQuotaExceeded, err.Error())
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/mocks"
+	"github.com/abezemskiy/gophkeeper/internal/server/quota"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage/memory"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encryptedBody - вспомогательная функция для сериализации зашифрованных данных.
+func encryptedBody(t *testing.T, name string, size int) []byte {
+	t.Helper()
+	body, err := json.Marshal(data.EncryptedData{Name: name, EncryptedData: bytes.Repeat([]byte{1}, size)})
+	require.NoError(t, err)
+	return body
+}
+
+func TestGetUsage(t *testing.T) {
+	defer quota.SetLimits(quota.GetLimits())
+	quota.SetLimits(quota.Limits{MaxBytes: 100, MaxRecords: 10, MaxVersions: 3})
+
+	// регистрирую мок хранилища
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	m := mocks.NewMockIEncryptedServerStorage(ctrl)
+
+	m.EXPECT().GetUsage(gomock.Any(), "success id").Return(data.Usage{Bytes: 50, Records: 2, Versions: 1}, nil)
+	m.EXPECT().GetUsage(gomock.Any(), "error id").Return(data.Usage{}, errors.New("some error"))
+
+	{
+		// Успешное получение информации об использовании хранилища
+		res := serveRequest(nil, GetUsageHandler(m), http.MethodGet, "/", "/", "success id", "").Result()
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		var usage data.Usage
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&usage))
+		assert.Equal(t, data.Usage{Bytes: 50, Records: 2, Versions: 1, MaxBytes: 100, MaxRecords: 10, MaxVersions: 3}, usage)
+	}
+	{
+		// Ошибка хранилища
+		res := serveRequest(nil, GetUsageHandler(m), http.MethodGet, "/", "/", "error id", "").Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	}
+	{
+		// id пользователя не установлен в контекст
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		GetUsageHandler(m)(w, request)
+		res := w.Result()
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	defer quota.SetLimits(quota.GetLimits())
+	quota.SetLimits(quota.Limits{MaxBytes: 100, MaxRecords: 2, MaxVersions: 2, MaxBodySize: 1024})
+
+	stor := memory.NewStore()
+	userID := "user id"
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		body    []byte
+		status  int
+	}{
+		{name: "add first record", handler: AddEncryptedDataHandler(stor), method: http.MethodPost,
+			body: encryptedBody(t, "first", 40), status: http.StatusOK},
+		{name: "add record over bytes quota", handler: AddEncryptedDataHandler(stor), method: http.MethodPost,
+			body: encryptedBody(t, "second", 61), status: http.StatusInsufficientStorage},
+		{name: "add second record", handler: AddEncryptedDataHandler(stor), method: http.MethodPost,
+			body: encryptedBody(t, "second", 10), status: http.StatusOK},
+		{name: "add record over records quota", handler: AddEncryptedDataHandler(stor), method: http.MethodPost,
+			body: encryptedBody(t, "third", 1), status: http.StatusInsufficientStorage},
+		{name: "replace record within bytes quota", handler: ReplaceEncryptedDataHandler(stor), method: http.MethodPost,
+			body: encryptedBody(t, "first", 90), status: http.StatusOK},
+		{name: "replace record over bytes quota", handler: ReplaceEncryptedDataHandler(stor), method: http.MethodPost,
+			body: encryptedBody(t, "first", 91), status: http.StatusInsufficientStorage},
+		{name: "replace missing record", handler: ReplaceEncryptedDataHandler(stor), method: http.MethodPost,
+			body: encryptedBody(t, "missing", 1), status: http.StatusNotFound},
+		{name: "append version", handler: HandleConflictDataHandler(stor), method: http.MethodPost,
+			body: encryptedBody(t, "second", 0), status: http.StatusOK},
+		{name: "append version over versions quota", handler: HandleConflictDataHandler(stor), method: http.MethodPost,
+			body: encryptedBody(t, "second", 0), status: http.StatusInsufficientStorage},
+		{name: "body too large", handler: AddEncryptedDataHandler(stor), method: http.MethodPost,
+			body: encryptedBody(t, "large", 1024), status: http.StatusRequestEntityTooLarge},
+		{name: "delete body too large", handler: DeleteEncryptedDataHandler(stor), method: http.MethodDelete,
+			body: []byte(`{"name":"` + strings.Repeat("a", 1024) + `"}`), status: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := serveRequest(nil, tt.handler, tt.method, "/", "/", userID, string(tt.body)).Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.status, res.StatusCode)
+		})
+	}
+
+	// Сохранение части вложения сверх квоты
+	usage, err := stor.GetUsage(context.Background(), userID)
+	require.NoError(t, err)
+	chunk := bytes.Repeat([]byte{2}, int(quota.GetLimits().MaxBytes-usage.Bytes)+1)
+	res := serveRequest(nil, SaveChunkHandler(stor), http.MethodPut, "/chunk/{hash}", "/chunk/"+chunkHash(chunk), userID,
+		string(chunk)).Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusInsufficientStorage, res.StatusCode)
+
+	// Уже сохраненную часть можно отправить повторно при превышении квоты
+	quota.SetLimits(quota.Limits{})
+	require.NoError(t, stor.SaveChunk(context.Background(), userID, chunkHash(chunk), chunk))
+	quota.SetLimits(quota.Limits{MaxBytes: 100})
+	res = serveRequest(nil, SaveChunkHandler(stor), http.MethodPut, "/chunk/{hash}", "/chunk/"+chunkHash(chunk), userID,
+		string(chunk)).Result()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
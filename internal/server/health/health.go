@@ -0,0 +1,79 @@
+// Пакет health содержит обработчики проверок живости и готовности сервера для оркестратора.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+
+	"go.uber.org/zap"
+)
+
+// CheckTimeout - ограничение времени одной проверки готовности.
+const CheckTimeout = 2 * time.Second
+
+// Статусы проверок.
+const (
+	StatusOK          = "ok"          // сервер или зависимость работает
+	StatusUnavailable = "unavailable" // сервер не готов обслуживать запросы
+	StatusFailed      = "failed"      // проверка зависимости не пройдена
+)
+
+// Check - проверка зависимости сервера, например соединения с базой данных.
+type Check struct {
+	Name string                          // имя зависимости в ответе
+	Ping func(ctx context.Context) error // функция проверки, возвращающая ошибку, если зависимость недоступна
+}
+
+// Response - тело ответа на проверку живости или готовности.
+type Response struct {
+	Status string            `json:"status"`           // общий статус
+	Checks map[string]string `json:"checks,omitempty"` // статусы проверенных зависимостей
+}
+
+// LiveHandler - обработчик проверки живости. Сервер жив, пока отвечает на запросы, поэтому зависимости
+// не проверяются, чтобы недоступность базы данных не приводила к перезапуску сервера.
+func LiveHandler() http.HandlerFunc {
+	return func(res http.ResponseWriter, _ *http.Request) {
+		writeResponse(res, http.StatusOK, Response{Status: StatusOK})
+	}
+}
+
+// ReadyHandler - обработчик проверки готовности. Сервер готов, если пройдены все проверки checks, иначе
+// возвращается статус 503. Причины отказа пишутся в лог и не раскрываются в ответе.
+func ReadyHandler(checks ...Check) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		resp := Response{Status: StatusOK, Checks: make(map[string]string, len(checks))}
+		for _, c := range checks {
+			ctx, cancel := context.WithTimeout(req.Context(), CheckTimeout)
+			err := c.Ping(ctx)
+			cancel()
+			if err != nil {
+				logger.ServerLog.Error("readiness check failed", zap.String("check", c.Name), zap.String("error", err.Error()))
+				resp.Status = StatusUnavailable
+				resp.Checks[c.Name] = StatusFailed
+				continue
+			}
+			resp.Checks[c.Name] = StatusOK
+		}
+
+		status := http.StatusOK
+		if resp.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		writeResponse(res, status, resp)
+	}
+}
+
+// writeResponse - функция для записи ответа проверки.
+func writeResponse(res http.ResponseWriter, status int, resp Response) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", "no-store")
+	res.WriteHeader(status)
+	if err := json.NewEncoder(res).Encode(resp); err != nil {
+		logger.ServerLog.Error("failed to encode health response", zap.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,64 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	LiveHandler()(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp Response
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, Response{Status: StatusOK}, resp)
+}
+
+func TestReadyHandler(t *testing.T) {
+	ok := Check{Name: "cache", Ping: func(context.Context) error { return nil }}
+	failed := Check{Name: "database", Ping: func(context.Context) error { return errors.New("connection refused") }}
+	slow := Check{Name: "slow", Ping: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	tests := []struct {
+		name   string
+		checks []Check
+		cancel bool // отмена контекста запроса прерывает проверку так же, как истечение CheckTimeout
+		status int
+		want   Response
+	}{
+		{name: "without checks", status: http.StatusOK, want: Response{Status: StatusOK}},
+		{name: "all checks passed", checks: []Check{ok}, status: http.StatusOK,
+			want: Response{Status: StatusOK, Checks: map[string]string{"cache": StatusOK}}},
+		{name: "check failed", checks: []Check{ok, failed}, status: http.StatusServiceUnavailable,
+			want: Response{Status: StatusUnavailable, Checks: map[string]string{"cache": StatusOK, "database": StatusFailed}}},
+		{name: "check timeout", checks: []Check{slow}, cancel: true, status: http.StatusServiceUnavailable,
+			want: Response{Status: StatusUnavailable, Checks: map[string]string{"slow": StatusFailed}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancel {
+				cancel()
+			}
+			defer cancel()
+			rec := httptest.NewRecorder()
+			ReadyHandler(tt.checks...)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil).WithContext(ctx))
+
+			assert.Equal(t, tt.status, rec.Code)
+			var resp Response
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			assert.Equal(t, tt.want, resp)
+		})
+	}
+}
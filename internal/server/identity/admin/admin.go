@@ -0,0 +1,63 @@
+// Пакет admin реализует аутентификацию администратора сервера. Администратор предъявляет токен, заданный
+// в конфигурации сервера, в заголовке api.AdminTokenHeader; JWT пользователей не дают доступа к адресам администратора.
+package admin
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"sync"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+
+	"go.uber.org/zap"
+)
+
+// MinTokenLength - минимальная длина токена администратора.
+const MinTokenLength = 16
+
+var (
+	mu        sync.RWMutex
+	tokenHash [sha256.Size]byte // хэш токена администратора
+	enabled   bool              // токен администратора задан
+)
+
+// SetToken - функция для установки токена администратора. Пустой токен отключает адреса администратора.
+func SetToken(token string) {
+	mu.Lock()
+	defer mu.Unlock()
+	tokenHash = sha256.Sum256([]byte(token))
+	enabled = token != ""
+}
+
+// check - функция для проверки токена администратора. Сравниваются хэши токенов за постоянное время,
+// чтобы время ответа не зависело от совпадающей части токена.
+func check(token string) (ok, isEnabled bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if !enabled {
+		return false, false
+	}
+	hash := sha256.Sum256([]byte(token))
+	return subtle.ConstantTimeCompare(hash[:], tokenHash[:]) == 1, true
+}
+
+// Middleware - проверяет токен администратора входящих запросов. Если токен администратора не задан,
+// адреса администратора не обслуживаются.
+func Middleware(h http.Handler) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		ok, isEnabled := check(req.Header.Get(api.AdminTokenHeader))
+		if !isEnabled {
+			api.WriteError(res, http.StatusNotFound, api.CodeNotFound, "admin API is disabled")
+			return
+		}
+		if !ok {
+			logger.ServerLog.Error("admin token is missing or invalid", zap.String("address", req.URL.String()),
+				zap.String("remote address", req.RemoteAddr))
+			api.WriteError(res, http.StatusUnauthorized, api.CodeUnauthorized, "admin token is missing or invalid")
+			return
+		}
+		h.ServeHTTP(res, req)
+	}
+}
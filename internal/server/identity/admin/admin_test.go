@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	defer SetToken("")
+	h := Middleware(http.HandlerFunc(func(res http.ResponseWriter, _ *http.Request) {
+		res.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name     string
+		adminTok string // токен администратора сервера
+		header   string // токен в запросе
+		status   int
+	}{
+		{name: "admin API is disabled", adminTok: "", header: "", status: http.StatusNotFound},
+		{name: "disabled with any token", adminTok: "", header: "any token", status: http.StatusNotFound},
+		{name: "missing token", adminTok: "admin secret token", header: "", status: http.StatusUnauthorized},
+		{name: "wrong token", adminTok: "admin secret token", header: "wrong secret token", status: http.StatusUnauthorized},
+		{name: "valid token", adminTok: "admin secret token", header: "admin secret token", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetToken(tt.adminTok)
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				request.Header.Set(api.AdminTokenHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			h(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.status, res.StatusCode)
+		})
+	}
+}
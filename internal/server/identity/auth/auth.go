@@ -3,12 +3,17 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/header"
 	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/token"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/account"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
 	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/metrics"
 
 	"go.uber.org/zap"
 )
@@ -18,6 +23,59 @@ type contextKey string
 // UserIDKey - ключ для установки ID пользователя в контекст.
 const UserIDKey = contextKey("userID")
 
+// sessionsKey - ключ для установки хранилища сессий в контекст.
+const sessionsKey = contextKey("sessions")
+
+var (
+	// ErrAccountDisabled - учетная запись пользователя заблокирована администратором.
+	ErrAccountDisabled = errors.New("account is disabled")
+	// ErrSessionRevoked - токен выдан до принудительного выхода пользователя или пользователь удален.
+	ErrSessionRevoked = errors.New("session is revoked")
+)
+
+// SessionReader - интерфейс хранилища, из которого читается состояние сессий пользователя.
+type SessionReader interface {
+	GetSession(ctx context.Context, idUser string) (account.Session, bool, error)
+}
+
+// WithSessions - функция для установки хранилища сессий в контекст. Токены проверяются по состоянию сессий
+// только если хранилище установлено в контекст.
+func WithSessions(ctx context.Context, sessions SessionReader) context.Context {
+	return context.WithValue(ctx, sessionsKey, sessions)
+}
+
+// Sessions - middleware, которое устанавливает хранилище сессий в контекст запроса.
+func Sessions(sessions SessionReader) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			h.ServeHTTP(res, req.WithContext(WithSessions(req.Context(), sessions)))
+		})
+	}
+}
+
+// CheckSession - функция для проверки, что токен пользователя idUser, выданный в момент issuedAt, еще принимается:
+// пользователь существует, не заблокирован и не был принудительно разлогинен после выдачи токена.
+func CheckSession(ctx context.Context, idUser string, issuedAt time.Time) error {
+	sessions, ok := ctx.Value(sessionsKey).(SessionReader)
+	if !ok || sessions == nil {
+		return nil
+	}
+	session, ok, err := sessions.GetSession(ctx, idUser)
+	if err != nil {
+		return fmt.Errorf("failed to get session of user, %w", err)
+	}
+	if !ok {
+		return ErrSessionRevoked
+	}
+	if session.Disabled {
+		return ErrAccountDisabled
+	}
+	if !session.Valid(issuedAt) {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
 // Middleware - проверяет JWT входящих запросов к серверу.
 // Позволит установить доступ к ресурсам только для аутентифицированных пользователей.
 // Из полученного токена извлекается ID пользователя и устанавливается в контекст.
@@ -28,15 +86,36 @@ func Middleware(h http.Handler) http.HandlerFunc {
 		// В случае ошибки получения токена возвращаю статус 401 - пользователь не аутентифицирован.
 		if err != nil {
 			logger.ServerLog.Error("failed to get token from request", zap.String("address", req.URL.String()), zap.String("error", error.Error(err)))
-			http.Error(res, fmt.Errorf("failed to get token from request, %w", err).Error(), http.StatusUnauthorized)
+			metrics.AuthFailure(api.CodeUnauthorized)
+			api.WriteError(res, http.StatusUnauthorized, api.CodeUnauthorized, "authorization token is missing")
 			return
 		}
-		id, err := token.GetIDFromToken(getToken)
+		claims, err := token.GetClaims(getToken)
 		if err != nil {
 			logger.ServerLog.Error("failed to get user id from token", zap.String("address", req.URL.String()), zap.String("error", error.Error(err)))
-			http.Error(res, fmt.Errorf("failed to get user id from token, %w", err).Error(), http.StatusUnauthorized)
+			metrics.AuthFailure(api.CodeUnauthorized)
+			api.WriteError(res, http.StatusUnauthorized, api.CodeUnauthorized, "authorization token is invalid or expired")
 			return
 		}
+		id := claims.UserID
+
+		// Проверяю, что токен не отозван администратором
+		if err := CheckSession(req.Context(), id, claims.IssueTime()); err != nil {
+			logger.ServerLog.Error("session of user is rejected", zap.String("address", req.URL.String()), zap.String("error", error.Error(err)))
+			switch {
+			case errors.Is(err, ErrAccountDisabled):
+				metrics.AuthFailure(api.CodeAccountDisabled)
+				api.WriteError(res, http.StatusForbidden, api.CodeAccountDisabled, "account is disabled")
+			case errors.Is(err, ErrSessionRevoked):
+				metrics.AuthFailure(api.CodeUnauthorized)
+				api.WriteError(res, http.StatusUnauthorized, api.CodeUnauthorized, "authorization token is revoked")
+			default:
+				api.WriteError(res, http.StatusInternalServerError, api.CodeInternal, "failed to check session")
+			}
+			return
+		}
+
+		metrics.UserSeen(id)
 
 		// В случае успешного получения id пользователя устанавливаю идентификатор в контекст для дальнейшей обработки.
 		ctx := context.WithValue(req.Context(), UserIDKey, id)
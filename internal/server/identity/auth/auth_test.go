@@ -1,11 +1,16 @@
 package auth
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/token"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/account"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -131,3 +136,63 @@ func TestMiddleware(t *testing.T) {
 		})
 	}
 }
+
+// sessionsMap - хранилище сессий пользователей для тестов.
+type sessionsMap map[string]account.Session
+
+func (m sessionsMap) GetSession(_ context.Context, idUser string) (account.Session, bool, error) {
+	if idUser == "fail" {
+		return account.Session{}, false, errors.New("some error")
+	}
+	session, ok := m[idUser]
+	return session, ok, nil
+}
+
+func TestSessions(t *testing.T) {
+	token.SetSecretKey("sessions secret key")
+	token.SerExpireHour(1)
+
+	sessions := sessionsMap{
+		"active":   {},
+		"disabled": {Disabled: true},
+		"revoked":  {LogoutAt: time.Now().Add(time.Hour)},
+		"relogin":  {LogoutAt: time.Now().Add(-time.Hour)},
+	}
+	h := Sessions(sessions)(Middleware(http.HandlerFunc(func(res http.ResponseWriter, _ *http.Request) {
+		res.WriteHeader(http.StatusOK)
+	})))
+
+	tests := []struct {
+		name   string
+		id     string
+		status int
+		code   string
+	}{
+		{name: "active user", id: "active", status: http.StatusOK},
+		{name: "token issued after logout", id: "relogin", status: http.StatusOK},
+		{name: "disabled user", id: "disabled", status: http.StatusForbidden, code: api.CodeAccountDisabled},
+		{name: "token issued before logout", id: "revoked", status: http.StatusUnauthorized, code: api.CodeUnauthorized},
+		{name: "deleted user", id: "deleted", status: http.StatusUnauthorized, code: api.CodeUnauthorized},
+		{name: "storage error", id: "fail", status: http.StatusInternalServerError, code: api.CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwt, err := token.BuildJWT(tt.id)
+			require.NoError(t, err)
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set("Authorization", "Bearer "+jwt)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, request)
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.status, res.StatusCode)
+			if tt.code != "" {
+				assert.Equal(t, tt.code, api.ParseError(res.StatusCode, w.Body.Bytes()).Code)
+			}
+		})
+	}
+
+	// Без хранилища в контексте состояние сессий не проверяется
+	assert.NoError(t, CheckSession(context.Background(), "deleted", time.Now()))
+}
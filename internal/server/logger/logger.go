@@ -1,9 +1,13 @@
 package logger
 
 import (
+	"io"
 	"net/http"
 	"time"
 
+	"github.com/abezemskiy/gophkeeper/internal/server/metrics"
+
+	"github.com/go-chi/chi/v5"
 	"go.uber.org/zap"
 )
 
@@ -21,6 +25,19 @@ type (
 	}
 )
 
+// countingReader - обертка над телом запроса для подсчета прочитанных байт.
+type countingReader struct {
+	io.ReadCloser
+	size int
+}
+
+// Read - обертка над оригинальным Read тела запроса.
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.size += n
+	return n, err
+}
+
 // loggingResponseWriter_Write - обертка над оригинальным http.ResponseWriter_Write
 func (r *loggingResponseWriter) Write(b []byte) (int, error) {
 	// записываем ответ, используя оригинальный http.ResponseWriter
@@ -74,11 +91,21 @@ func Initialize(level string) error {
 	return nil
 }
 
-// RequestLogger — middleware-логер для входящих HTTP-запросов.
+// unmatchedRoute - значение метки маршрута для запросов, не соответствующих ни одному маршруту.
+const unmatchedRoute = "unmatched"
+
+// RequestLogger — middleware-логер для входящих HTTP-запросов. Кроме записи в лог middleware учитывает запрос
+// в метриках сервера: количество и длительность запросов по маршруту и статусу, размеры тел запроса и ответа.
 func RequestLogger(h http.Handler) http.HandlerFunc {
 	logFn := func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		var body *countingReader
+		if r.Body != nil {
+			body = &countingReader{ReadCloser: r.Body}
+			r.Body = body
+		}
+
 		responseData := &responseData{
 			status: 0,
 			size:   0,
@@ -91,6 +118,18 @@ func RequestLogger(h http.Handler) http.HandlerFunc {
 
 		duration := time.Since(start)
 
+		// Обработчик, не вызвавший WriteHeader, отвечает статусом 200
+		status := responseData.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := routePattern(r)
+		metrics.ObserveRequest(route, r.Method, status, duration)
+		if body != nil {
+			metrics.ObservePayload(route, metrics.Request, body.size)
+		}
+		metrics.ObservePayload(route, metrics.Response, responseData.size)
+
 		sugar := ServerLog.Sugar()
 		sugar.Infoln(
 			"uri", r.RequestURI,
@@ -103,3 +142,14 @@ func RequestLogger(h http.Handler) http.HandlerFunc {
 	}
 	return logFn
 }
+
+// routePattern - функция для получения шаблона маршрута запроса. Шаблон вместо адреса запроса ограничивает
+// количество значений метки маршрута в метриках.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if pattern := rctx.RoutePattern(); pattern != "" {
+			return pattern
+		}
+	}
+	return unmatchedRoute
+}
@@ -0,0 +1,148 @@
+// Пакет metrics содержит метрики Prometheus сервера: количество и длительность запросов по маршрутам и статусам,
+// размеры передаваемых данных, отказы в аутентификации, количество активных пользователей и статистику пула
+// соединений с базой данных.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace - префикс имен метрик сервера.
+const namespace = "gophkeeper"
+
+// ActiveUsersWindow - период, в течение которого пользователь считается активным после последнего
+// аутентифицированного запроса.
+const ActiveUsersWindow = 15 * time.Minute
+
+// Направления передачи данных.
+const (
+	Request  = "request"  // данные, полученные от клиента
+	Response = "response" // данные, отправленные клиенту
+)
+
+// Registry - реестр метрик сервера. Метрики регистрируются в отдельном реестре, а не в глобальном реестре
+// Prometheus, чтобы тесты и сторонние пакеты не влияли на набор метрик.
+var Registry = prometheus.NewRegistry()
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	durations = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests by route, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	payloads = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "payload_bytes",
+		Help:      "Size of HTTP request and response bodies by route and direction.",
+		Buckets:   prometheus.ExponentialBuckets(64, 4, 10),
+	}, []string{"route", "direction"})
+
+	authFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_failures_total",
+		Help:      "Number of rejected authorizations and requests with invalid token by reason.",
+	}, []string{"reason"})
+
+	users = newActiveUsers(ActiveUsersWindow)
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requests,
+		durations,
+		payloads,
+		authFailures,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active_users",
+			Help:      "Number of users with authenticated requests during the last " + ActiveUsersWindow.String() + ".",
+		}, func() float64 {
+			return float64(users.count(time.Now()))
+		}),
+	)
+}
+
+// Handler - обработчик, возвращающий метрики сервера в формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveRequest - функция для учета обработанного HTTP запроса.
+func ObserveRequest(route, method string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	requests.WithLabelValues(route, method, code).Inc()
+	durations.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+// ObservePayload - функция для учета размера тела запроса или ответа в направлении direction.
+func ObservePayload(route, direction string, size int) {
+	payloads.WithLabelValues(route, direction).Observe(float64(size))
+}
+
+// AuthFailure - функция для учета отказа в аутентификации по причине reason.
+func AuthFailure(reason string) {
+	authFailures.WithLabelValues(reason).Inc()
+}
+
+// UserSeen - функция для учета аутентифицированного запроса пользователя с идентификатором id.
+func UserSeen(id string) {
+	users.seen(id, time.Now())
+}
+
+// RegisterDBStats - функция для регистрации метрик пула соединений с базой данных. Статистика пула
+// запрашивается функцией stats при каждом сборе метрик.
+func RegisterDBStats(stats func() sql.DBStats) error {
+	return Registry.Register(newDBStatsCollector(stats))
+}
+
+// activeUsers - структура для подсчета пользователей, выполнявших запросы в течение периода window.
+type activeUsers struct {
+	mu       sync.Mutex
+	window   time.Duration
+	lastSeen map[string]time.Time
+}
+
+// newActiveUsers - конструктор activeUsers.
+func newActiveUsers(window time.Duration) *activeUsers {
+	return &activeUsers{window: window, lastSeen: make(map[string]time.Time)}
+}
+
+// seen - метод для учета запроса пользователя id в момент now.
+func (u *activeUsers) seen(id string, now time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.lastSeen[id] = now
+}
+
+// count - метод для получения количества активных в момент now пользователей. Неактивные пользователи удаляются.
+func (u *activeUsers) count(now time.Time) int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for id, last := range u.lastSeen {
+		if now.Sub(last) > u.window {
+			delete(u.lastSeen, id)
+		}
+	}
+	return len(u.lastSeen)
+}
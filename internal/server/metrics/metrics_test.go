@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActiveUsers(t *testing.T) {
+	now := time.Now()
+	u := newActiveUsers(time.Minute)
+	u.seen("first", now.Add(-2*time.Minute))
+	u.seen("second", now.Add(-30*time.Second))
+	u.seen("third", now)
+	assert.Equal(t, 2, u.count(now))
+
+	// Повторный запрос пользователя не увеличивает количество активных пользователей
+	u.seen("third", now)
+	assert.Equal(t, 2, u.count(now))
+	assert.Equal(t, 0, u.count(now.Add(2*time.Minute)))
+}
+
+func TestObserve(t *testing.T) {
+	ObserveRequest("/api/v1/data/add", http.MethodPost, http.StatusOK, time.Millisecond)
+	ObserveRequest("/api/v1/data/add", http.MethodPost, http.StatusOK, time.Millisecond)
+	assert.Equal(t, 2.0, testutil.ToFloat64(requests.WithLabelValues("/api/v1/data/add", http.MethodPost, "200")))
+
+	AuthFailure("invalid_credentials")
+	assert.Equal(t, 1.0, testutil.ToFloat64(authFailures.WithLabelValues("invalid_credentials")))
+
+	ObservePayload("/api/v1/data/add", Request, 100)
+	require.NoError(t, RegisterDBStats(func() sql.DBStats { return sql.DBStats{MaxOpenConnections: 10, InUse: 3} }))
+	UserSeen("user")
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+
+	for _, want := range []string{
+		`gophkeeper_http_requests_total{method="POST",route="/api/v1/data/add",status="200"} 2`,
+		`gophkeeper_http_request_duration_seconds_count{method="POST",route="/api/v1/data/add",status="200"} 2`,
+		`gophkeeper_http_payload_bytes_count{direction="request",route="/api/v1/data/add"} 1`,
+		`gophkeeper_auth_failures_total{reason="invalid_credentials"} 1`,
+		`gophkeeper_active_users 1`,
+		`gophkeeper_db_max_open_connections 10`,
+		`gophkeeper_db_in_use_connections 3`,
+		`go_goroutines`,
+	} {
+		assert.Contains(t, string(body), want)
+	}
+}
@@ -0,0 +1,128 @@
+// Пакет notify содержит уведомления об изменении данных пользователей. Хранилище оборачивается так, что каждое
+// успешное изменение данных через REST или gRPC API рассылается подписчикам пользователя.
+package notify
+
+import (
+	"context"
+	"sync"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"go.uber.org/zap"
+)
+
+// Виды изменений данных.
+const (
+	Added    = iota + 1 // добавлены новые данные
+	Replaced            // все версии данных заменены новой версией
+	Appended            // добавлена новая версия данных при конфликте
+	Deleted             // данные удалены
+)
+
+// bufferSize - размер буфера уведомлений одного подписчика. Если подписчик не успевает получать уведомления,
+// лишние уведомления отбрасываются, чтобы изменение данных не блокировалось.
+const bufferSize = 16
+
+// Change - уведомление об изменении данных пользователя.
+type Change struct {
+	Kind int    // вид изменения
+	Name string // имя измененных данных
+}
+
+// Hub - рассылка уведомлений подписчикам пользователей.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[string]map[chan Change]struct{} // подписчики по id пользователя
+}
+
+// NewHub - фабричная функция рассылки уведомлений.
+func NewHub() *Hub {
+	return &Hub{subs: make(map[string]map[chan Change]struct{})}
+}
+
+// Subscribe - метод для подписки на изменения данных пользователя. Возвращает канал уведомлений и функцию отмены подписки,
+// после вызова которой канал закрывается.
+func (h *Hub) Subscribe(idUser string) (<-chan Change, func()) {
+	ch := make(chan Change, bufferSize)
+
+	h.mu.Lock()
+	if h.subs[idUser] == nil {
+		h.subs[idUser] = make(map[chan Change]struct{})
+	}
+	h.subs[idUser][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subs[idUser], ch)
+			if len(h.subs[idUser]) == 0 {
+				delete(h.subs, idUser)
+			}
+			close(ch)
+		})
+	}
+}
+
+// Publish - метод для рассылки уведомления подписчикам пользователя.
+func (h *Hub) Publish(idUser string, change Change) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[idUser] {
+		select {
+		case ch <- change:
+		default:
+			logger.ServerLog.Warn("change notification dropped", zap.String("name", change.Name))
+		}
+	}
+}
+
+// Storage - хранилище зашифрованных данных, которое рассылает уведомления об успешных изменениях данных.
+type Storage struct {
+	storage.IEncryptedServerStorage
+	hub *Hub
+}
+
+// NewStorage - фабричная функция хранилища с уведомлениями об изменениях.
+func NewStorage(stor storage.IEncryptedServerStorage, hub *Hub) *Storage {
+	return &Storage{IEncryptedServerStorage: stor, hub: hub}
+}
+
+// AddEncryptedData - метод для добавления новых данных с уведомлением подписчиков.
+func (s *Storage) AddEncryptedData(ctx context.Context, idUser string, encrData data.EncryptedData, status int) (bool, error) {
+	ok, err := s.IEncryptedServerStorage.AddEncryptedData(ctx, idUser, encrData, status)
+	s.publish(idUser, Added, encrData.Name, ok, err)
+	return ok, err
+}
+
+// ReplaceEncryptedData - метод для замены данных с уведомлением подписчиков.
+func (s *Storage) ReplaceEncryptedData(ctx context.Context, idUser string, encrData data.EncryptedData, status int) (bool, error) {
+	ok, err := s.IEncryptedServerStorage.ReplaceEncryptedData(ctx, idUser, encrData, status)
+	s.publish(idUser, Replaced, encrData.Name, ok, err)
+	return ok, err
+}
+
+// AppendEncryptedData - метод для добавления версии данных с уведомлением подписчиков.
+func (s *Storage) AppendEncryptedData(ctx context.Context, idUser string, encrData data.EncryptedData) (bool, error) {
+	ok, err := s.IEncryptedServerStorage.AppendEncryptedData(ctx, idUser, encrData)
+	s.publish(idUser, Appended, encrData.Name, ok, err)
+	return ok, err
+}
+
+// DeleteEncryptedData - метод для удаления данных с уведомлением подписчиков.
+func (s *Storage) DeleteEncryptedData(ctx context.Context, idUser, dataName string) (bool, error) {
+	ok, err := s.IEncryptedServerStorage.DeleteEncryptedData(ctx, idUser, dataName)
+	s.publish(idUser, Deleted, dataName, ok, err)
+	return ok, err
+}
+
+// publish - метод для рассылки уведомления, если изменение выполнено успешно.
+func (s *Storage) publish(idUser string, kind int, name string, ok bool, err error) {
+	if ok && err == nil {
+		s.hub.Publish(idUser, Change{Kind: kind, Name: name})
+	}
+}
@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	hub := NewHub()
+	stor := NewStorage(memory.NewStore(), hub)
+
+	changes, cancel := hub.Subscribe("user")
+	other, cancelOther := hub.Subscribe("other user")
+	defer cancelOther()
+
+	record := data.EncryptedData{EncryptedData: []byte("secret"), Name: "github"}
+	ok, err := stor.AddEncryptedData(ctx, "user", record, data.SAVED)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = stor.AppendEncryptedData(ctx, "user", record)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = stor.ReplaceEncryptedData(ctx, "user", record, data.SAVED)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = stor.DeleteEncryptedData(ctx, "user", "github")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Неуспешные изменения не рассылаются
+	ok, err = stor.DeleteEncryptedData(ctx, "user", "github")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	want := []Change{{Added, "github"}, {Appended, "github"}, {Replaced, "github"}, {Deleted, "github"}}
+	for _, w := range want {
+		assert.Equal(t, w, <-changes)
+	}
+	assert.Empty(t, changes)
+	assert.Empty(t, other)
+
+	// После отмены подписки канал закрывается, повторная отмена не паникует
+	cancel()
+	cancel()
+	_, open := <-changes
+	assert.False(t, open)
+	hub.Publish("user", Change{Kind: Added, Name: "gitlab"})
+}
+
+func TestHubSlowSubscriber(t *testing.T) {
+	hub := NewHub()
+	changes, cancel := hub.Subscribe("user")
+	defer cancel()
+
+	// Уведомления сверх буфера отбрасываются, публикация не блокируется
+	for range bufferSize + 5 {
+		hub.Publish("user", Change{Kind: Added, Name: "github"})
+	}
+	assert.Len(t, changes, bufferSize)
+}
@@ -0,0 +1,21 @@
+// Пакет openapi содержит спецификацию OpenAPI REST API сервера.
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// Spec - спецификация OpenAPI текущей версии REST API сервера.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// Handler - обработчик, возвращающий спецификацию OpenAPI.
+func Handler() http.HandlerFunc {
+	return func(res http.ResponseWriter, _ *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write(Spec)
+	}
+}
@@ -0,0 +1,97 @@
+// Пакет quota содержит ограничения объема данных, которые пользователь может хранить на сервере.
+// Ограничения проверяются хранилищами сервера в той же операции, что и изменение данных, поэтому одновременные
+// запросы пользователя не могут вместе превысить квоту. Нулевое значение ограничения означает отсутствие ограничения.
+package quota
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+)
+
+// DefaultMaxBodySize - ограничение размера тела запроса с данными пользователя по умолчанию.
+// Тело запроса содержит зашифрованные данные в base64, поэтому ограничение больше размера встроенного файла.
+const DefaultMaxBodySize = 4 << 20
+
+// Limits - ограничения хранилища для одного пользователя.
+type Limits struct {
+	MaxBytes    int64 // ограничение объема зашифрованных данных и частей вложений в байтах
+	MaxRecords  int   // ограничение количества записей
+	MaxVersions int   // ограничение количества версий одной записи
+	MaxBodySize int64 // ограничение размера тела запроса с данными в байтах
+}
+
+// limits - текущие ограничения сервера.
+var limits = Limits{MaxBodySize: DefaultMaxBodySize}
+
+// SetLimits - функция для установки ограничений хранилища.
+func SetLimits(newLimits Limits) {
+	limits = newLimits
+}
+
+// GetLimits - функция для получения текущих ограничений хранилища.
+func GetLimits() Limits {
+	return limits
+}
+
+// Enabled - функция для проверки, установлены ли ограничения объема хранилища.
+// Если ограничения не установлены, хранилищам не требуется получать информацию об использовании хранилища.
+func Enabled() bool {
+	return limits.MaxBytes > 0 || limits.MaxRecords > 0 || limits.MaxVersions > 0
+}
+
+// ErrQuotaExceeded - ошибка превышения квоты пользователя.
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// Fill - функция для заполнения ограничений в информации об использовании хранилища.
+func Fill(usage *data.Usage) {
+	usage.MaxBytes = limits.MaxBytes
+	usage.MaxRecords = limits.MaxRecords
+	usage.MaxVersions = limits.MaxVersions
+}
+
+// CheckBytes - функция для проверки, что добавление add байт не превысит квоту объема хранилища.
+func CheckBytes(usage data.Usage, add int64) error {
+	if limits.MaxBytes > 0 && add > 0 && usage.Bytes+add > limits.MaxBytes {
+		return fmt.Errorf("%w: used %d of %d bytes, request needs %d more", ErrQuotaExceeded, usage.Bytes, limits.MaxBytes, add)
+	}
+	return nil
+}
+
+// CheckRecords - функция для проверки, что добавление новой записи не превысит квоту количества записей.
+func CheckRecords(usage data.Usage) error {
+	if limits.MaxRecords > 0 && usage.Records >= limits.MaxRecords {
+		return fmt.Errorf("%w: used %d of %d records", ErrQuotaExceeded, usage.Records, limits.MaxRecords)
+	}
+	return nil
+}
+
+// CheckVersions - функция для проверки, что добавление версии к записи с versions версиями не превысит квоту версий.
+func CheckVersions(versions int) error {
+	if limits.MaxVersions > 0 && versions >= limits.MaxVersions {
+		return fmt.Errorf("%w: record already has %d of %d versions", ErrQuotaExceeded, versions, limits.MaxVersions)
+	}
+	return nil
+}
+
+// CheckAdd - функция для проверки квот перед добавлением новой записи размером size байт.
+func CheckAdd(usage data.Usage, size int64) error {
+	if err := CheckRecords(usage); err != nil {
+		return err
+	}
+	return CheckBytes(usage, size)
+}
+
+// CheckReplace - функция для проверки квот перед заменой всех версий записи record новой версией размером size байт.
+func CheckReplace(usage data.Usage, record data.RecordUsage, size int64) error {
+	return CheckBytes(usage, size-record.Bytes)
+}
+
+// CheckAppend - функция для проверки квот перед добавлением к записи record новой версии размером size байт.
+func CheckAppend(usage data.Usage, record data.RecordUsage, size int64) error {
+	if err := CheckVersions(record.Versions); err != nil {
+		return err
+	}
+	return CheckBytes(usage, size)
+}
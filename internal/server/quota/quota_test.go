@@ -0,0 +1,59 @@
+package quota
+
+import (
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecks(t *testing.T) {
+	defer SetLimits(GetLimits())
+
+	// Без ограничений любые изменения разрешены
+	SetLimits(Limits{})
+	assert.False(t, Enabled())
+	usage := data.Usage{Bytes: 1 << 30, Records: 1000}
+	assert.NoError(t, CheckBytes(usage, 1<<30))
+	assert.NoError(t, CheckRecords(usage))
+	assert.NoError(t, CheckVersions(1000))
+
+	SetLimits(Limits{MaxBytes: 100, MaxRecords: 2, MaxVersions: 3})
+	assert.True(t, Enabled())
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{name: "bytes within quota", err: CheckBytes(data.Usage{Bytes: 50}, 50), wantErr: false},
+		{name: "bytes over quota", err: CheckBytes(data.Usage{Bytes: 50}, 51), wantErr: true},
+		{name: "freeing bytes over quota", err: CheckBytes(data.Usage{Bytes: 150}, -10), wantErr: false},
+		{name: "records within quota", err: CheckRecords(data.Usage{Records: 1}), wantErr: false},
+		{name: "records over quota", err: CheckRecords(data.Usage{Records: 2}), wantErr: true},
+		{name: "versions within quota", err: CheckVersions(2), wantErr: false},
+		{name: "versions over quota", err: CheckVersions(3), wantErr: true},
+		{name: "add within quota", err: CheckAdd(data.Usage{Bytes: 50, Records: 1}, 50), wantErr: false},
+		{name: "add over records quota", err: CheckAdd(data.Usage{Records: 2}, 1), wantErr: true},
+		{name: "add over bytes quota", err: CheckAdd(data.Usage{Bytes: 50, Records: 1}, 51), wantErr: true},
+		{name: "replace within quota", err: CheckReplace(data.Usage{Bytes: 90}, data.RecordUsage{Bytes: 40}, 50), wantErr: false},
+		{name: "replace over bytes quota", err: CheckReplace(data.Usage{Bytes: 90}, data.RecordUsage{Bytes: 40}, 51), wantErr: true},
+		{name: "append within quota", err: CheckAppend(data.Usage{Bytes: 50}, data.RecordUsage{Versions: 2}, 50), wantErr: false},
+		{name: "append over versions quota", err: CheckAppend(data.Usage{}, data.RecordUsage{Versions: 3}, 1), wantErr: true},
+		{name: "append over bytes quota", err: CheckAppend(data.Usage{Bytes: 50}, data.RecordUsage{Versions: 1}, 51), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr {
+				assert.ErrorIs(t, tt.err, ErrQuotaExceeded)
+			} else {
+				assert.NoError(t, tt.err)
+			}
+		})
+	}
+
+	var usage2 data.Usage
+	Fill(&usage2)
+	assert.Equal(t, data.Usage{MaxBytes: 100, MaxRecords: 2, MaxVersions: 3}, usage2)
+}
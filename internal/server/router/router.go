@@ -0,0 +1,153 @@
+// Пакет router содержит маршрутизатор http запросов к серверу.
+package router
+
+import (
+	"net/http"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/tracing"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/identity"
+	"github.com/abezemskiy/gophkeeper/internal/server/audit"
+	"github.com/abezemskiy/gophkeeper/internal/server/handlers"
+	"github.com/abezemskiy/gophkeeper/internal/server/health"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/admin"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/auth"
+	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/metrics"
+	"github.com/abezemskiy/gophkeeper/internal/server/openapi"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// MetricRouter - дирежирует обработку http запросов к серверу.
+// Текущая версия API обслуживается по префиксу api.Prefix. Адреса без версии с префиксом api.LegacyPrefix
+// обслуживаются теми же обработчиками для совместимости со старыми клиентами и помечаются заголовком Deprecation.
+// События учетных записей записываются в журнал аудита events; чтобы в журнал попадали изменения данных,
+// хранилище stor должно быть обернуто в audit.Storage. Токены пользователей проверяются по состоянию сессий
+// в хранилище учетных записей accounts, которыми управляет администратор сервера. Ключевые пары пользователей
+// и общие записи хранятся в хранилище shares, а организации, их участники и коллекции - в хранилище orgs.
+func MetricRouter(ident identity.Identifier, stor storage.IEncryptedServerStorage, attach storage.IAttachmentStorage,
+	events storage.IAuditStorage, accounts storage.IAccountStorage, shares storage.IShareStorage,
+	orgs storage.IOrgStorage) chi.Router {
+	r := chi.NewRouter()
+	r.Use(tracing.Middleware)
+	r.Use(audit.Middleware(events))
+	r.Use(auth.Sessions(accounts))
+
+	r.Route(api.Prefix, func(r chi.Router) {
+		r.Get("/openapi.json", openapi.Handler())
+		routes(r, ident, stor, attach, events, accounts, shares, orgs)
+	})
+	r.Route(api.LegacyPrefix, func(r chi.Router) {
+		r.Use(deprecated)
+		routes(r, ident, stor, attach, events, accounts, shares, orgs)
+	})
+
+	// Определяем маршрут по умолчанию для некорректных запросов
+	r.NotFound(logger.RequestLogger(handlers.HandleOtherRequest()))
+
+	return r
+}
+
+// OpsRouter - маршрутизатор служебных адресов для оркестратора и Prometheus. Используется, когда служебные адреса
+// обслуживаются на отдельном порту.
+func OpsRouter(ready ...health.Check) chi.Router {
+	r := chi.NewRouter()
+	OpsRoutes(r, ready...)
+	return r
+}
+
+// OpsRoutes - функция для регистрации служебных адресов в маршрутизаторе r: проверки живости /healthz, проверки
+// готовности /readyz с проверками ready и метрик /metrics. Служебные адреса не логируются, так как запрашиваются
+// периодически.
+func OpsRoutes(r chi.Router, ready ...health.Check) {
+	r.Get("/healthz", health.LiveHandler())
+	r.Get("/readyz", health.ReadyHandler(ready...))
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
+}
+
+// routes - функция для регистрации обработчиков API в маршрутизаторе r.
+func routes(r chi.Router, ident identity.Identifier, stor storage.IEncryptedServerStorage, attach storage.IAttachmentStorage,
+	events storage.IAuditStorage, accounts storage.IAccountStorage, shares storage.IShareStorage, orgs storage.IOrgStorage) {
+	r.Post("/register", logger.RequestLogger(handlers.RegisterHandler(ident)))
+	r.Post("/authorize", logger.RequestLogger(handlers.AuthorizeHandler(ident)))
+
+	r.Route("/data", func(r chi.Router) {
+		r.Post("/add", logger.RequestLogger(auth.Middleware(handlers.AddEncryptedDataHandler(stor))))
+		r.Post("/replace", logger.RequestLogger(auth.Middleware(handlers.ReplaceEncryptedDataHandler(stor))))
+		r.Get("/get", logger.RequestLogger(auth.Middleware(handlers.GetAllEncryptedDataHandler(stor))))
+		r.Delete("/delete", logger.RequestLogger(auth.Middleware(handlers.DeleteEncryptedDataHandler(stor))))
+		r.Post("/conflict", logger.RequestLogger(auth.Middleware(handlers.HandleConflictDataHandler(stor))))
+	})
+
+	r.Get("/usage", logger.RequestLogger(auth.Middleware(handlers.GetUsageHandler(stor))))
+	r.Get("/audit", logger.RequestLogger(auth.Middleware(handlers.GetAuditEventsHandler(events))))
+	r.Delete("/account", logger.RequestLogger(auth.Middleware(handlers.DeleteAccountHandler(ident, accounts))))
+
+	// Ключевые пары пользователей и общие записи
+	r.Route("/keys", func(r chi.Router) {
+		r.Get("/", logger.RequestLogger(auth.Middleware(handlers.GetKeyPairHandler(shares))))
+		r.Put("/", logger.RequestLogger(auth.Middleware(handlers.SetKeyPairHandler(shares))))
+		r.Get("/{login}", logger.RequestLogger(auth.Middleware(handlers.GetPublicKeyHandler(shares))))
+	})
+	r.Route("/shares", func(r chi.Router) {
+		r.Get("/", logger.RequestLogger(auth.Middleware(handlers.GetSharesHandler(shares))))
+		r.Post("/", logger.RequestLogger(auth.Middleware(handlers.CreateShareHandler(shares))))
+		r.Delete("/{id}", logger.RequestLogger(auth.Middleware(handlers.DeleteShareHandler(shares))))
+		r.Post("/{id}/accept", logger.RequestLogger(auth.Middleware(handlers.AcceptShareHandler(shares))))
+		r.Put("/{id}/data", logger.RequestLogger(auth.Middleware(handlers.UpdateShareHandler(shares))))
+	})
+
+	// Организации и их общие коллекции
+	r.Route("/orgs", func(r chi.Router) {
+		r.Get("/", logger.RequestLogger(auth.Middleware(handlers.GetOrgsHandler(orgs))))
+		r.Post("/", logger.RequestLogger(auth.Middleware(handlers.CreateOrgHandler(orgs))))
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", logger.RequestLogger(auth.Middleware(handlers.GetOrgHandler(orgs))))
+			r.Delete("/", logger.RequestLogger(auth.Middleware(handlers.DeleteOrgHandler(orgs))))
+			r.Get("/members", logger.RequestLogger(auth.Middleware(handlers.GetMembersHandler(orgs))))
+			r.Post("/members", logger.RequestLogger(auth.Middleware(handlers.AddMemberHandler(orgs, shares))))
+			r.Put("/members/{login}", logger.RequestLogger(auth.Middleware(handlers.SetRoleHandler(orgs))))
+			r.Post("/rotate", logger.RequestLogger(auth.Middleware(handlers.RotateKeyHandler(orgs))))
+			r.Get("/items", logger.RequestLogger(auth.Middleware(handlers.GetItemsHandler(orgs))))
+			r.Put("/items", logger.RequestLogger(auth.Middleware(handlers.PutItemHandler(orgs))))
+			r.Delete("/items", logger.RequestLogger(auth.Middleware(handlers.DeleteItemHandler(orgs))))
+		})
+	})
+
+	// Адреса администратора сервера доступны только по токену администратора
+	r.Route("/admin", func(r chi.Router) {
+		r.Get("/audit", logger.RequestLogger(admin.Middleware(handlers.ExportAuditEventsHandler(events))))
+		r.Get("/stats", logger.RequestLogger(admin.Middleware(handlers.GetStatsHandler(accounts))))
+		r.Get("/users", logger.RequestLogger(admin.Middleware(handlers.ListUsersHandler(accounts))))
+		r.Route("/users/{id}", func(r chi.Router) {
+			r.Delete("/", logger.RequestLogger(admin.Middleware(handlers.DeleteUserHandler(accounts))))
+			r.Post("/disable", logger.RequestLogger(admin.Middleware(handlers.DisableUserHandler(accounts))))
+			r.Post("/enable", logger.RequestLogger(admin.Middleware(handlers.EnableUserHandler(accounts))))
+			r.Post("/logout", logger.RequestLogger(admin.Middleware(handlers.LogoutUserHandler(accounts))))
+		})
+	})
+
+	// Потоковая передача вложений частями
+	r.Route("/attachment/{id}", func(r chi.Router) {
+		r.Get("/", logger.RequestLogger(auth.Middleware(handlers.GetAttachmentHandler(attach))))
+		r.Post("/", logger.RequestLogger(auth.Middleware(handlers.AddAttachmentRefHandler(attach))))
+		r.Delete("/", logger.RequestLogger(auth.Middleware(handlers.ReleaseAttachmentHandler(attach))))
+	})
+	r.Route("/chunk", func(r chi.Router) {
+		r.Post("/missing", logger.RequestLogger(auth.Middleware(handlers.GetMissingChunksHandler(attach))))
+		r.Put("/{hash}", logger.RequestLogger(auth.Middleware(handlers.SaveChunkHandler(attach))))
+		r.Get("/{hash}", logger.RequestLogger(auth.Middleware(handlers.GetChunkHandler(attach))))
+	})
+}
+
+// deprecated - middleware для пометки ответов устаревшего API заголовками Deprecation и Link
+// с адресом текущей версии API.
+func deprecated(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Deprecation", "true")
+		res.Header().Set("Link", "<"+api.Prefix+">; rel=\"successor-version\"")
+		h.ServeHTTP(res, req)
+	})
+}
@@ -0,0 +1,422 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/header"
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/token"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/account"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/identity"
+	"github.com/abezemskiy/gophkeeper/internal/server/health"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/admin"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage/memory"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricRouterWithMemoryStorage(t *testing.T) {
+	token.SetSecretKey("test secret key")
+	token.SerExpireHour(1)
+
+	stor := memory.NewStore()
+	ts := httptest.NewServer(MetricRouter(stor, stor, stor, stor, stor, stor, stor))
+	defer ts.Close()
+
+	post := func(url, jwt string, body any) *http.Response {
+		b, err := json.Marshal(body)
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, ts.URL+url, bytes.NewReader(b))
+		require.NoError(t, err)
+		if jwt != "" {
+			req.Header.Set("Authorization", "Bearer "+jwt)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// Регистрирую пользователя
+	resp := post(api.RegisterPattern, "", identity.Data{Login: "login", Hash: "hash"})
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	jwt, err := header.GetTokenFromResponseHeader(resp)
+	require.NoError(t, err)
+
+	// Повторная регистрация
+	resp = post(api.RegisterPattern, "", identity.Data{Login: "login", Hash: "hash"})
+	resp.Body.Close()
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// Добавляю данные
+	resp = post(api.AddDataPattern, jwt, data.EncryptedData{EncryptedData: []byte("data"), Name: "name"})
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Получаю данные
+	req, err := http.NewRequest(http.MethodGet, ts.URL+api.GetDataPattern, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var get [][]data.EncryptedData
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&get))
+	require.Equal(t, 1, len(get))
+	assert.Equal(t, "name", get[0][0].Name)
+	assert.Equal(t, "data", string(get[0][0].EncryptedData))
+
+	// Получаю информацию об использовании хранилища
+	req, err = http.NewRequest(http.MethodGet, ts.URL+api.UsagePattern, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	usageResp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer usageResp.Body.Close()
+	require.Equal(t, http.StatusOK, usageResp.StatusCode)
+
+	var usage data.Usage
+	require.NoError(t, json.NewDecoder(usageResp.Body).Decode(&usage))
+	assert.Equal(t, int64(len("data")), usage.Bytes)
+	assert.Equal(t, 1, usage.Records)
+}
+
+func TestLegacyPrefix(t *testing.T) {
+	token.SetSecretKey("test secret key")
+	token.SerExpireHour(1)
+
+	stor := memory.NewStore()
+	ts := httptest.NewServer(MetricRouter(stor, stor, stor, stor, stor, stor, stor))
+	defer ts.Close()
+
+	b, err := json.Marshal(identity.Data{Login: "login", Hash: "hash"})
+	require.NoError(t, err)
+	resp, err := http.Post(ts.URL+api.LegacyPrefix+"/register", "application/json", bytes.NewReader(b))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get("Deprecation"))
+
+	// Пользователь, зарегистрированный через устаревший адрес, доступен в текущей версии API
+	resp, err = http.Post(ts.URL+api.AuthorizationPattern, "application/json", bytes.NewReader(b))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Deprecation"))
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	stor := memory.NewStore()
+	ts := httptest.NewServer(MetricRouter(stor, stor, stor, stor, stor, stor, stor))
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		code   string
+	}{
+		{name: "without token", method: http.MethodGet, path: api.GetDataPattern, status: http.StatusUnauthorized, code: api.CodeUnauthorized},
+		{name: "unknown path", method: http.MethodGet, path: api.Prefix + "/unknown", status: http.StatusNotFound, code: api.CodeNotFound},
+		{name: "bad body", method: http.MethodPost, path: api.RegisterPattern, status: http.StatusBadRequest, code: api.CodeInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path, bytes.NewReader([]byte("{")))
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.status, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			var body api.ErrorResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			require.NotNil(t, body.Error)
+			assert.Equal(t, tt.code, body.Error.Code)
+			assert.NotEmpty(t, body.Error.Message)
+		})
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	token.SetSecretKey("test secret key")
+	token.SerExpireHour(1)
+	defer admin.SetToken("")
+	admin.SetToken("admin secret token")
+
+	stor := memory.NewStore()
+	ts := httptest.NewServer(MetricRouter(stor, stor, stor, stor, stor, stor, stor))
+	defer ts.Close()
+
+	do := func(method, url string, headers map[string]string, body any) *http.Response {
+		b, err := json.Marshal(body)
+		require.NoError(t, err)
+		req, err := http.NewRequest(method, ts.URL+url, bytes.NewReader(b))
+		require.NoError(t, err)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+	device := map[string]string{api.DeviceHeader: "laptop"}
+
+	resp := do(http.MethodPost, api.RegisterPattern, device, identity.Data{Login: "login", Hash: "hash"})
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	jwt, err := header.GetTokenFromResponseHeader(resp)
+	require.NoError(t, err)
+
+	// Неудачные попытки входа: неверный пароль и незарегистрированный логин
+	resp = do(http.MethodPost, api.AuthorizationPattern, device, identity.Data{Login: "login", Hash: "wrong"})
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = do(http.MethodPost, api.AuthorizationPattern, device, identity.Data{Login: "unknown", Hash: "hash"})
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Вход с токеном пользователя - обновление токена, без токена - новый вход
+	resp = do(http.MethodPost, api.AuthorizationPattern, map[string]string{"Authorization": "Bearer " + jwt},
+		identity.Data{Login: "login", Hash: "hash"})
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = do(http.MethodPost, api.AuthorizationPattern, device, identity.Data{Login: "login", Hash: "hash"})
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Пользователь получает только свои события от новых к старым
+	auth := map[string]string{"Authorization": "Bearer " + jwt}
+	resp = do(http.MethodGet, api.AuditPattern, auth, nil)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var events []audit.Event
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&events))
+	types := make([]string, 0, len(events))
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []string{audit.LoginSuccess, audit.TokenRefresh, audit.LoginFailure, audit.Register}, types)
+	assert.Equal(t, "laptop", events[0].Device)
+	assert.Equal(t, "127.0.0.1", events[0].IP)
+
+	// Администратор выгружает журнал всех пользователей, токен пользователя не дает доступа
+	resp = do(http.MethodGet, api.AdminAuditPattern, auth, nil)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp = do(http.MethodGet, api.AdminAuditPattern, map[string]string{api.AdminTokenHeader: "admin secret token"}, nil)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(b)), "\n"), len(events)+1)
+}
+
+func TestAdminAccounts(t *testing.T) {
+	token.SetSecretKey("test secret key")
+	token.SerExpireHour(1)
+	defer admin.SetToken("")
+	admin.SetToken("admin secret token")
+
+	stor := memory.NewStore()
+	ts := httptest.NewServer(MetricRouter(stor, stor, stor, stor, stor, stor, stor))
+	defer ts.Close()
+
+	do := func(method, url, header, value string, body any) (*http.Response, []byte) {
+		b, err := json.Marshal(body)
+		require.NoError(t, err)
+		req, err := http.NewRequest(method, ts.URL+url, bytes.NewReader(b))
+		require.NoError(t, err)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err = io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, b
+	}
+	adminDo := func(method, url string) (*http.Response, []byte) {
+		return do(method, url, api.AdminTokenHeader, "admin secret token", nil)
+	}
+	login := func(pattern, login string) (string, string) {
+		resp, _ := do(http.MethodPost, pattern, "", "", identity.Data{Login: login, Hash: "hash"})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		jwt, err := header.GetTokenFromResponseHeader(resp)
+		require.NoError(t, err)
+		id, err := token.GetIDFromToken(jwt)
+		require.NoError(t, err)
+		return jwt, id
+	}
+	getData := func(jwt string) (int, string) {
+		resp, body := do(http.MethodGet, api.GetDataPattern, "Authorization", "Bearer "+jwt, nil)
+		return resp.StatusCode, api.ParseError(resp.StatusCode, body).Code
+	}
+
+	aliceJWT, aliceID := login(api.RegisterPattern, "alice")
+	bobJWT, bobID := login(api.RegisterPattern, "bob")
+
+	// Токен пользователя не дает доступа к адресам администратора
+	resp, _ := do(http.MethodGet, api.AdminUsersPattern, "Authorization", "Bearer "+aliceJWT, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, body := adminDo(http.MethodGet, api.AdminUsersPattern)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var users []account.User
+	require.NoError(t, json.Unmarshal(body, &users))
+	assert.Equal(t, []account.User{{ID: aliceID, Login: "alice"}, {ID: bobID, Login: "bob"}}, users)
+
+	// Заблокированный пользователь не может войти, а его токен отклоняется
+	resp, _ = adminDo(http.MethodPost, api.AdminUsersPattern+"/"+aliceID+"/disable")
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	status, code := getData(aliceJWT)
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, api.CodeAccountDisabled, code)
+	resp, body = do(http.MethodPost, api.AuthorizationPattern, "", "", identity.Data{Login: "alice", Hash: "hash"})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, api.CodeAccountDisabled, api.ParseError(resp.StatusCode, body).Code)
+
+	resp, _ = adminDo(http.MethodPost, api.AdminUsersPattern+"/"+aliceID+"/enable")
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	status, _ = getData(aliceJWT)
+	assert.Equal(t, http.StatusOK, status)
+
+	// После принудительного выхода старый токен отклоняется, а новый принимается. Время выдачи токена
+	// хранится с точностью до секунды, поэтому выход выполняется в следующую секунду после выдачи токена.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	resp, _ = adminDo(http.MethodPost, api.AdminUsersPattern+"/"+aliceID+"/logout")
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	status, code = getData(aliceJWT)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	assert.Equal(t, api.CodeUnauthorized, code)
+	aliceJWT, _ = login(api.AuthorizationPattern, "alice")
+	status, _ = getData(aliceJWT)
+	assert.Equal(t, http.StatusOK, status)
+
+	// Удаленный пользователь теряет доступ
+	resp, _ = adminDo(http.MethodDelete, api.AdminUsersPattern+"/"+bobID)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	status, _ = getData(bobJWT)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	resp, body = adminDo(http.MethodDelete, api.AdminUsersPattern+"/"+bobID)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, api.CodeUserNotFound, api.ParseError(resp.StatusCode, body).Code)
+
+	resp, body = adminDo(http.MethodGet, api.AdminStatsPattern)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var stats account.Stats
+	require.NoError(t, json.Unmarshal(body, &stats))
+	assert.Equal(t, 1, stats.Users)
+
+	// Действия администратора записываются в журнал аудита
+	events, err := stor.GetAuditEvents(context.Background(), audit.Filter{})
+	require.NoError(t, err)
+	want := []string{audit.AccountDisable, audit.AccountEnable, audit.ForceLogout, audit.AccountDelete}
+	types := make([]string, 0, len(want))
+	for _, event := range events {
+		if slices.Contains(want, event.Type) {
+			types = append(types, event.Type)
+		}
+	}
+	assert.Equal(t, want, types)
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	stor := memory.NewStore()
+	r := MetricRouter(stor, stor, stor, stor, stor, stor, stor)
+
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + api.OpenAPIPattern)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&spec))
+
+	// Каждый маршрут текущей версии API описан в спецификации
+	routes := 0
+	err = chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !strings.HasPrefix(route, api.Prefix+"/") {
+			return nil
+		}
+		routes++
+		path := strings.TrimSuffix(route, "/")
+		ops, ok := spec.Paths[path]
+		if assert.True(t, ok, "path %s is not documented", path) {
+			_, ok = ops[strings.ToLower(method)]
+			assert.True(t, ok, "%s %s is not documented", method, path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Positive(t, routes)
+}
+
+func TestHealthAndMetrics(t *testing.T) {
+	stor := memory.NewStore()
+	dbErr := errors.New("connection refused")
+	var failDB atomic.Bool
+	r := MetricRouter(stor, stor, stor, stor, stor, stor, stor)
+	OpsRoutes(r, health.Check{Name: "database", Ping: func(context.Context) error {
+		if failDB.Load() {
+			return dbErr
+		}
+		return nil
+	}})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(ts.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	status, _ := get("/healthz")
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = get("/readyz")
+	assert.Equal(t, http.StatusOK, status)
+
+	// Недоступность базы данных не влияет на живость сервера
+	failDB.Store(true)
+	status, _ = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	status, _ = get("/healthz")
+	assert.Equal(t, http.StatusOK, status)
+
+	// Запросы учитываются по шаблону маршрута, а не по адресу
+	status, _ = get(api.Prefix + "/attachment/some-id")
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, body := get("/metrics")
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body, `gophkeeper_http_requests_total{method="GET",route="/api/v1/attachment/{id}",status="401"} 1`)
+	assert.Contains(t, body, `gophkeeper_auth_failures_total{reason="unauthorized"}`)
+	assert.NotContains(t, body, "some-id")
+}
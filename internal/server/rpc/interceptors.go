@@ -0,0 +1,184 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/token"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	pb "github.com/abezemskiy/gophkeeper/internal/repositories/proto"
+	"github.com/abezemskiy/gophkeeper/internal/server/audit"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/auth"
+	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/metrics"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// AuthorizationMetadata - ключ метаданных запроса с токеном пользователя.
+const AuthorizationMetadata = "authorization"
+
+// DeviceMetadata - ключ метаданных запроса с именем устройства клиента, аналог заголовка api.DeviceHeader.
+const DeviceMetadata = "x-gophkeeper-device"
+
+// publicMethods - методы, которые не требуют авторизации пользователя.
+var publicMethods = map[string]bool{
+	pb.GophKeeper_Register_FullMethodName:  true,
+	pb.GophKeeper_Authorize_FullMethodName: true,
+}
+
+// AuthUnaryInterceptor - интерцептор, аналогичный auth.Middleware. Проверяет JWT запроса, извлекает из него
+// ID пользователя и устанавливает его в контекст. Методы регистрации и авторизации не проверяются.
+func AuthUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	ctx, err := authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// AuthStreamInterceptor - интерцептор потоковых методов, аналогичный AuthUnaryInterceptor.
+func AuthStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+}
+
+// authStream - поток с контекстом, в который установлен ID пользователя.
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context - метод для получения контекста потока.
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate - функция для получения ID пользователя из токена в метаданных запроса и установки его в контекст.
+// Отказы в аутентификации и активные пользователи учитываются в метриках так же, как в auth.Middleware.
+func authenticate(ctx context.Context, method string) (context.Context, error) {
+	claims, err := userClaims(ctx, method)
+	if err != nil {
+		metrics.AuthFailure(api.CodeUnauthorized)
+		return nil, err
+	}
+
+	// Проверяю, что токен не отозван администратором
+	if err := auth.CheckSession(ctx, claims.UserID, claims.IssueTime()); err != nil {
+		logger.ServerGRPCLog.Error("session of user is rejected", zap.String("method", method), zap.String("error", err.Error()))
+		switch {
+		case errors.Is(err, auth.ErrAccountDisabled):
+			metrics.AuthFailure(api.CodeAccountDisabled)
+			return nil, status.Error(codes.PermissionDenied, "account is disabled")
+		case errors.Is(err, auth.ErrSessionRevoked):
+			metrics.AuthFailure(api.CodeUnauthorized)
+			return nil, status.Error(codes.Unauthenticated, "authorization token is revoked")
+		default:
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+	metrics.UserSeen(claims.UserID)
+	return context.WithValue(ctx, auth.UserIDKey, claims.UserID), nil
+}
+
+// userClaims - функция для получения утверждений токена из метаданных запроса.
+func userClaims(ctx context.Context, method string) (*token.Claims, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(AuthorizationMetadata)
+	if len(values) == 0 {
+		logger.ServerGRPCLog.Error("missing authorization metadata", zap.String("method", method))
+		return nil, status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+
+	// Проверяю, что токен передан в виде "Bearer <token>"
+	jwt, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || jwt == "" {
+		logger.ServerGRPCLog.Error("invalid authorization metadata format", zap.String("method", method))
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization metadata format")
+	}
+	claims, err := token.GetClaims(jwt)
+	if err != nil {
+		logger.ServerGRPCLog.Error("failed to get user id from token", zap.String("method", method), zap.String("error", err.Error()))
+		return nil, status.Errorf(codes.Unauthenticated, "failed to get user id from token, %v", err)
+	}
+	return claims, nil
+}
+
+// SessionsUnaryInterceptor - интерцептор, аналогичный auth.Sessions. Устанавливает в контекст хранилище сессий,
+// по которому AuthUnaryInterceptor отклоняет токены заблокированных, удаленных и разлогиненных пользователей.
+func SessionsUnaryInterceptor(sessions auth.SessionReader) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(auth.WithSessions(ctx, sessions), req)
+	}
+}
+
+// SessionsStreamInterceptor - интерцептор потоковых методов, аналогичный SessionsUnaryInterceptor.
+func SessionsStreamInterceptor(sessions auth.SessionReader) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &authStream{ServerStream: ss, ctx: auth.WithSessions(ss.Context(), sessions)})
+	}
+}
+
+// AuditUnaryInterceptor - интерцептор, аналогичный audit.Middleware. Устанавливает в контекст источник запроса
+// для записи событий в журнал аудита events. Устройство определяется по метаданным DeviceMetadata,
+// а если клиент их не передал - по метаданным user-agent.
+func AuditUnaryInterceptor(events storage.IAuditStorage) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		device := firstValue(md, DeviceMetadata)
+		if device == "" {
+			device = firstValue(md, "user-agent")
+		}
+		var ip string
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			ip = audit.RemoteIP(p.Addr.String())
+		}
+		return handler(audit.WithSource(ctx, events, ip, device), req)
+	}
+}
+
+// firstValue - функция для получения первого значения метаданных запроса по ключу key.
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// LoggingUnaryInterceptor - интерцептор для логирования входящих запросов, аналогичный logger.RequestLogger.
+func LoggingUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logger.ServerGRPCLog.Info("got incoming gRPC request",
+		zap.String("method", info.FullMethod),
+		zap.String("code", status.Code(err).String()),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return resp, err
+}
+
+// LoggingStreamInterceptor - интерцептор для логирования потоковых запросов.
+func LoggingStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	logger.ServerGRPCLog.Info("gRPC stream closed",
+		zap.String("method", info.FullMethod),
+		zap.String("code", status.Code(err).String()),
+		zap.Duration("duration", time.Since(start)),
+	)
+	return err
+}
@@ -0,0 +1,275 @@
+// Пакет rpc содержит gRPC сервер GophKeeper. Сервер работает на отдельном порту рядом с REST API, использует
+// те же хранилища, проверки квот и JWT пользователей, поэтому клиент может работать с сервером через любой из API.
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/checker"
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/id"
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/token"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/api"
+	repoAudit "github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/identity"
+	pb "github.com/abezemskiy/gophkeeper/internal/repositories/proto"
+	"github.com/abezemskiy/gophkeeper/internal/server/audit"
+	"github.com/abezemskiy/gophkeeper/internal/server/identity/auth"
+	"github.com/abezemskiy/gophkeeper/internal/server/logger"
+	"github.com/abezemskiy/gophkeeper/internal/server/metrics"
+	"github.com/abezemskiy/gophkeeper/internal/server/notify"
+	"github.com/abezemskiy/gophkeeper/internal/server/quota"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// uniqueViolation - код ошибки PostgreSQL при нарушении уникальности, которым хранилища сообщают о повторной регистрации.
+const uniqueViolation = "23505"
+
+// Server - реализация gRPC сервиса GophKeeper.
+type Server struct {
+	pb.UnimplementedGophKeeperServer
+
+	ident identity.Identifier
+	stor  storage.IEncryptedServerStorage
+	hub   *notify.Hub
+}
+
+// NewServer - фабричная функция gRPC сервера. Изменения данных должны выполняться через хранилище notify.Storage
+// с той же рассылкой hub, чтобы подписчики получали уведомления об изменениях через любой API, и через хранилище
+// audit.Storage, чтобы изменения попадали в журнал аудита events. Токены пользователей проверяются по состоянию
+// сессий sessions. extra - дополнительные параметры сервера, например, TLS.
+func NewServer(ident identity.Identifier, stor storage.IEncryptedServerStorage, hub *notify.Hub, events storage.IAuditStorage,
+	sessions auth.SessionReader, extra ...grpc.ServerOption) *grpc.Server {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(LoggingUnaryInterceptor, AuditUnaryInterceptor(events), SessionsUnaryInterceptor(sessions),
+			AuthUnaryInterceptor),
+		grpc.ChainStreamInterceptor(LoggingStreamInterceptor, SessionsStreamInterceptor(sessions), AuthStreamInterceptor),
+	}
+	opts = append(opts, extra...)
+	if maxSize := quota.GetLimits().MaxBodySize; maxSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(int(maxSize)))
+	}
+	srv := grpc.NewServer(opts...)
+	pb.RegisterGophKeeperServer(srv, &Server{ident: ident, stor: stor, hub: hub})
+	return srv
+}
+
+// Register - метод для регистрации пользователя. Возвращает токен пользователя.
+func (s *Server) Register(ctx context.Context, req *pb.Credentials) (*pb.Token, error) {
+	if err := checkCredentials(req); err != nil {
+		return nil, err
+	}
+
+	// вычисляю идентификатор пользователя
+	userID, err := id.GenerateID()
+	if err != nil {
+		return nil, internalError("failed to generate id", err)
+	}
+
+	// Регистрирую пользователя в хранилище
+	if err := s.ident.Register(ctx, req.GetLogin(), req.GetHash(), userID); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			logger.ServerGRPCLog.Error("login already exists", zap.String("login", req.GetLogin()))
+			return nil, status.Errorf(codes.AlreadyExists, "login %s already exists", req.GetLogin())
+		}
+		return nil, internalError("register user error", err)
+	}
+	audit.Record(ctx, repoAudit.Event{Type: repoAudit.Register, UserID: userID, Login: req.GetLogin()})
+	return newToken(userID)
+}
+
+// Authorize - метод для авторизации пользователя. Возвращает токен пользователя.
+func (s *Server) Authorize(ctx context.Context, req *pb.Credentials) (*pb.Token, error) {
+	if err := checkCredentials(req); err != nil {
+		return nil, err
+	}
+
+	// Получаю авторизационные данные пользователя из хранилища
+	authData, ok, err := s.ident.Authorize(ctx, req.GetLogin())
+	if err != nil {
+		return nil, internalError("authorize user error", err)
+	}
+	if !ok {
+		logger.ServerGRPCLog.Error("user not register", zap.String("login", req.GetLogin()))
+		metrics.AuthFailure(api.CodeInvalidCredentials)
+		audit.Record(ctx, repoAudit.Event{Type: repoAudit.LoginFailure, Login: req.GetLogin()})
+		return nil, status.Errorf(codes.Unauthenticated, "user %s not register", req.GetLogin())
+	}
+	if !checker.IsAuthorize(authData.Hash, req.GetHash()) {
+		logger.ServerGRPCLog.Error("password is wrong", zap.String("login", req.GetLogin()))
+		metrics.AuthFailure(api.CodeInvalidCredentials)
+		audit.Record(ctx, repoAudit.Event{Type: repoAudit.LoginFailure, UserID: authData.ID, Login: req.GetLogin()})
+		return nil, status.Error(codes.Unauthenticated, "password is wrong")
+	}
+	if authData.Disabled {
+		logger.ServerGRPCLog.Error("account is disabled", zap.String("login", req.GetLogin()))
+		metrics.AuthFailure(api.CodeAccountDisabled)
+		audit.Record(ctx, repoAudit.Event{Type: repoAudit.LoginFailure, UserID: authData.ID, Login: req.GetLogin()})
+		return nil, status.Error(codes.PermissionDenied, "account is disabled")
+	}
+
+	// Клиент с истекшим токеном авторизуется повторно, такой вход записывается в журнал как обновление токена
+	md, _ := metadata.FromIncomingContext(ctx)
+	audit.Record(ctx, repoAudit.Event{
+		Type:   audit.LoginType(firstValue(md, AuthorizationMetadata), authData.ID),
+		UserID: authData.ID,
+		Login:  req.GetLogin(),
+	})
+	return newToken(authData.ID)
+}
+
+// AddData - метод для добавления новых зашифрованных данных.
+func (s *Server) AddData(ctx context.Context, req *pb.EncryptedData) (*emptypb.Empty, error) {
+	userID, encrData := userIDFromContext(ctx), fromProto(req)
+	ok, err := s.stor.AddEncryptedData(ctx, userID, encrData, data.SAVED)
+	if errors.Is(err, quota.ErrQuotaExceeded) {
+		return nil, quotaError(err)
+	}
+	if err != nil {
+		return nil, internalError("adding data to storage error", err)
+	}
+	if !ok {
+		return nil, status.Error(codes.AlreadyExists, "data is already exist")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// ReplaceData - метод для замены всех версий существующих данных новой версией.
+func (s *Server) ReplaceData(ctx context.Context, req *pb.EncryptedData) (*emptypb.Empty, error) {
+	userID, encrData := userIDFromContext(ctx), fromProto(req)
+	ok, err := s.stor.ReplaceEncryptedData(ctx, userID, encrData, data.SAVED)
+	if errors.Is(err, quota.ErrQuotaExceeded) {
+		return nil, quotaError(err)
+	}
+	if err != nil {
+		return nil, internalError("replace data in storage error", err)
+	}
+	if !ok {
+		return nil, status.Error(codes.NotFound, "data does not exist")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// AppendConflictData - метод для добавления новой версии к существующим данным в случае конфликта.
+func (s *Server) AppendConflictData(ctx context.Context, req *pb.EncryptedData) (*emptypb.Empty, error) {
+	userID, encrData := userIDFromContext(ctx), fromProto(req)
+	ok, err := s.stor.AppendEncryptedData(ctx, userID, encrData)
+	if errors.Is(err, quota.ErrQuotaExceeded) {
+		return nil, quotaError(err)
+	}
+	if err != nil {
+		return nil, internalError("append data to storage error", err)
+	}
+	if !ok {
+		return nil, status.Error(codes.NotFound, "data does not exist")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// GetAllData - метод для получения всех данных пользователя.
+func (s *Server) GetAllData(ctx context.Context, _ *emptypb.Empty) (*pb.AllData, error) {
+	allData, err := s.stor.GetAllEncryptedData(ctx, userIDFromContext(ctx))
+	if err != nil {
+		return nil, internalError("get all data from storage error", err)
+	}
+
+	resp := &pb.AllData{Data: make([]*pb.DataVersions, 0, len(allData))}
+	for _, versions := range allData {
+		d := &pb.DataVersions{Versions: make([]*pb.EncryptedData, 0, len(versions))}
+		for _, v := range versions {
+			d.Versions = append(d.Versions, toProto(v))
+		}
+		resp.Data = append(resp.Data, d)
+	}
+	return resp, nil
+}
+
+// DeleteData - метод для удаления данных пользователя по имени.
+func (s *Server) DeleteData(ctx context.Context, req *pb.DataName) (*emptypb.Empty, error) {
+	ok, err := s.stor.DeleteEncryptedData(ctx, userIDFromContext(ctx), req.GetName())
+	if err != nil {
+		return nil, internalError("delete data from storage error", err)
+	}
+	if !ok {
+		return nil, status.Error(codes.NotFound, "data does not exist")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// WatchChanges - метод для отправки уведомлений об изменении данных пользователя до завершения запроса.
+func (s *Server) WatchChanges(_ *emptypb.Empty, stream grpc.ServerStreamingServer[pb.Change]) error {
+	ctx := stream.Context()
+	changes, cancel := s.hub.Subscribe(userIDFromContext(ctx))
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case change := <-changes:
+			err := stream.Send(&pb.Change{Kind: pb.Change_Kind(change.Kind), Name: change.Name})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// toProto - функция для преобразования зашифрованных данных в сообщение gRPC.
+func toProto(d data.EncryptedData) *pb.EncryptedData {
+	return &pb.EncryptedData{EncryptedData: d.EncryptedData, Name: d.Name}
+}
+
+// fromProto - функция для преобразования сообщения gRPC в зашифрованные данные.
+func fromProto(d *pb.EncryptedData) data.EncryptedData {
+	return data.EncryptedData{EncryptedData: d.GetEncryptedData(), Name: d.GetName()}
+}
+
+// checkCredentials - функция для проверки корректности логина и хэша пользователя.
+func checkCredentials(req *pb.Credentials) error {
+	if !checker.CheckLogin(req.GetLogin()) {
+		return status.Errorf(codes.InvalidArgument, "login %s is not valid", req.GetLogin())
+	}
+	if !checker.CheckHash(req.GetHash()) {
+		return status.Error(codes.InvalidArgument, "hash is not valid")
+	}
+	return nil
+}
+
+// newToken - функция для создания токена пользователя.
+func newToken(userID string) (*pb.Token, error) {
+	jwt, err := token.BuildJWT(userID)
+	if err != nil {
+		return nil, internalError("build JWT error", err)
+	}
+	return &pb.Token{Token: jwt}, nil
+}
+
+// userIDFromContext - функция для получения id пользователя, установленного в контекст интерцептором авторизации.
+func userIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(auth.UserIDKey).(string)
+	return userID
+}
+
+// internalError - функция для логирования внутренней ошибки сервера. Клиенту возвращается общий статус без
+// подробностей, чтобы не раскрывать ошибки хранилища.
+func internalError(msg string, err error) error {
+	logger.ServerGRPCLog.Error(msg, zap.String("error", err.Error()))
+	return status.Error(codes.Internal, "internal server error")
+}
+
+// quotaError - функция для преобразования ошибки превышения квоты, которую вернуло хранилище, в статус gRPC.
+func quotaError(err error) error {
+	logger.ServerGRPCLog.Error("quota exceeded", zap.String("error", err.Error()))
+	return status.Error(codes.ResourceExhausted, err.Error())
+}
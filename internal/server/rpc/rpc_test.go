@@ -0,0 +1,255 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/token"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	pb "github.com/abezemskiy/gophkeeper/internal/repositories/proto"
+	"github.com/abezemskiy/gophkeeper/internal/server/notify"
+	"github.com/abezemskiy/gophkeeper/internal/server/quota"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// newClient - функция для запуска gRPC сервера с хранилищем в оперативной памяти и подключения к нему клиента.
+// Возвращает также хранилище журнала аудита сервера.
+func newClient(t *testing.T) (pb.GophKeeperClient, *notify.Storage, *memory.Store) {
+	t.Helper()
+	token.SetSecretKey("test key")
+	token.SerExpireHour(1)
+
+	store, hub := memory.NewStore(), notify.NewHub()
+	stor := notify.NewStorage(store, hub)
+	srv := NewServer(store, stor, hub, store, store)
+
+	listener := bufconn.Listen(1 << 20)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewGophKeeperClient(conn), stor, store
+}
+
+// withToken - функция для добавления токена пользователя в метаданные запроса.
+func withToken(ctx context.Context, jwt string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, AuthorizationMetadata, "Bearer "+jwt)
+}
+
+func TestIdentity(t *testing.T) {
+	client, _, _ := newClient(t)
+	ctx := context.Background()
+	creds := &pb.Credentials{Login: "user", Hash: "hash"}
+
+	registered, err := client.Register(ctx, creds)
+	require.NoError(t, err)
+	assert.NotEmpty(t, registered.GetToken())
+
+	tests := []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{name: "register again", call: func() error { _, err := client.Register(ctx, creds); return err }, code: codes.AlreadyExists},
+		{name: "empty login", call: func() error { _, err := client.Register(ctx, &pb.Credentials{Hash: "hash"}); return err }, code: codes.InvalidArgument},
+		{name: "authorize", call: func() error { _, err := client.Authorize(ctx, creds); return err }, code: codes.OK},
+		{name: "wrong password", call: func() error {
+			_, err := client.Authorize(ctx, &pb.Credentials{Login: "user", Hash: "other"})
+			return err
+		}, code: codes.Unauthenticated},
+		{name: "unknown user", call: func() error {
+			_, err := client.Authorize(ctx, &pb.Credentials{Login: "other", Hash: "hash"})
+			return err
+		}, code: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.code, status.Code(tt.call()))
+		})
+	}
+}
+
+func TestAudit(t *testing.T) {
+	client, _, store := newClient(t)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), DeviceMetadata, "laptop")
+	creds := &pb.Credentials{Login: "user", Hash: "hash"}
+
+	registered, err := client.Register(ctx, creds)
+	require.NoError(t, err)
+	_, err = client.Authorize(ctx, &pb.Credentials{Login: "user", Hash: "other"})
+	require.Error(t, err)
+	_, err = client.Authorize(withToken(ctx, registered.GetToken()), creds)
+	require.NoError(t, err)
+	_, err = client.Authorize(ctx, creds)
+	require.NoError(t, err)
+
+	events, err := store.GetAuditEvents(context.Background(), audit.Filter{})
+	require.NoError(t, err)
+	want := []string{audit.Register, audit.LoginFailure, audit.TokenRefresh, audit.LoginSuccess}
+	require.Len(t, events, len(want))
+	for i, event := range events {
+		assert.Equal(t, want[i], event.Type)
+		assert.Equal(t, "user", event.Login)
+		assert.Equal(t, "laptop", event.Device)
+	}
+}
+
+func TestData(t *testing.T) {
+	client, _, _ := newClient(t)
+	registered, err := client.Register(context.Background(), &pb.Credentials{Login: "user", Hash: "hash"})
+	require.NoError(t, err)
+	ctx := withToken(context.Background(), registered.GetToken())
+
+	// Запросы без токена отклоняются
+	_, err = client.GetAllData(context.Background(), &emptypb.Empty{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.GetAllData(withToken(context.Background(), "invalid"), &emptypb.Empty{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	record := &pb.EncryptedData{EncryptedData: []byte("v1"), Name: "github"}
+	_, err = client.AddData(ctx, record)
+	require.NoError(t, err)
+	_, err = client.AddData(ctx, record)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = client.AppendConflictData(ctx, &pb.EncryptedData{EncryptedData: []byte("v2"), Name: "github"})
+	require.NoError(t, err)
+	all, err := client.GetAllData(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, all.GetData(), 1)
+	assert.Len(t, all.GetData()[0].GetVersions(), 2)
+
+	_, err = client.ReplaceData(ctx, &pb.EncryptedData{EncryptedData: []byte("v3"), Name: "github"})
+	require.NoError(t, err)
+	all, err = client.GetAllData(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+	require.Len(t, all.GetData()[0].GetVersions(), 1)
+	assert.Equal(t, []byte("v3"), all.GetData()[0].GetVersions()[0].GetEncryptedData())
+
+	_, err = client.ReplaceData(ctx, &pb.EncryptedData{EncryptedData: []byte("v1"), Name: "gitlab"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.AppendConflictData(ctx, &pb.EncryptedData{EncryptedData: []byte("v1"), Name: "gitlab"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.DeleteData(ctx, &pb.DataName{Name: "github"})
+	require.NoError(t, err)
+	_, err = client.DeleteData(ctx, &pb.DataName{Name: "github"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSessions(t *testing.T) {
+	client, _, store := newClient(t)
+	creds := &pb.Credentials{Login: "user", Hash: "hash"}
+	registered, err := client.Register(context.Background(), creds)
+	require.NoError(t, err)
+	ctx := withToken(context.Background(), registered.GetToken())
+	id, err := token.GetIDFromToken(registered.GetToken())
+	require.NoError(t, err)
+
+	_, err = client.GetAllData(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+
+	// После принудительного выхода ранее выданный токен отклоняется, а новый принимается
+	ok, err := store.Logout(context.Background(), id, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, err = client.GetAllData(ctx, &emptypb.Empty{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	authorized, err := client.Authorize(context.Background(), creds)
+	require.NoError(t, err)
+	ctx = withToken(context.Background(), authorized.GetToken())
+	_, err = client.GetAllData(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+
+	// Заблокированный пользователь не может авторизоваться, а его токены отклоняются
+	ok, err = store.SetDisabled(context.Background(), id, true)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, err = client.GetAllData(ctx, &emptypb.Empty{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.Authorize(context.Background(), creds)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Токены удаленного пользователя отклоняются
+	ok, err = store.DeleteUser(context.Background(), id)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, err = client.GetAllData(ctx, &emptypb.Empty{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestQuota(t *testing.T) {
+	defer quota.SetLimits(quota.GetLimits())
+	quota.SetLimits(quota.Limits{MaxRecords: 1})
+
+	client, _, _ := newClient(t)
+	registered, err := client.Register(context.Background(), &pb.Credentials{Login: "user", Hash: "hash"})
+	require.NoError(t, err)
+	ctx := withToken(context.Background(), registered.GetToken())
+
+	_, err = client.AddData(ctx, &pb.EncryptedData{EncryptedData: []byte("v1"), Name: "github"})
+	require.NoError(t, err)
+	_, err = client.AddData(ctx, &pb.EncryptedData{EncryptedData: []byte("v1"), Name: "gitlab"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestWatchChanges(t *testing.T) {
+	client, stor, _ := newClient(t)
+	registered, err := client.Register(context.Background(), &pb.Credentials{Login: "user", Hash: "hash"})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(withToken(context.Background(), registered.GetToken()), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchChanges(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+
+	// Подписка оформляется асинхронно, поэтому данные меняются, пока не придет первое уведомление.
+	// Данные меняются в обход gRPC API, как при запросах к REST API.
+	userID, err := token.GetIDFromToken(registered.GetToken())
+	require.NoError(t, err)
+	go func() {
+		for ctx.Err() == nil {
+			stor.AddEncryptedData(ctx, userID, data.EncryptedData{EncryptedData: []byte("v1"), Name: "github"}, data.SAVED)
+			stor.DeleteEncryptedData(ctx, userID, "github")
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	change, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Contains(t, []pb.Change_Kind{pb.Change_KIND_ADDED, pb.Change_KIND_DELETED}, change.GetKind())
+	assert.Equal(t, "github", change.GetName())
+
+	// Поток без токена отклоняется
+	unauthorized, err := client.WatchChanges(context.Background(), &emptypb.Empty{})
+	require.NoError(t, err)
+	_, err = unauthorized.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestInternalError(t *testing.T) {
+	err := internalError("adding data to storage error", errors.New(`pq: relation "data" does not exist`))
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.Internal, st.Code())
+	assert.Equal(t, "internal server error", st.Message())
+}
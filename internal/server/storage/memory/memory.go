@@ -0,0 +1,1219 @@
+// Пакет memory реализует хранилище сервера в оперативной памяти.
+// Хранилище не требует СУБД и используется в тестах и в демонстрационном режиме сервера.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/account"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/identity"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/org"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/share"
+	"github.com/abezemskiy/gophkeeper/internal/server/quota"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// uniqueViolation - код ошибки PostgreSQL unique_violation.
+// Хранилище возвращает ту же ошибку, что и pg.Store, чтобы хэндлеры сервера обрабатывали её одинаково.
+const uniqueViolation = "23505"
+
+// record - все версии одних данных пользователя.
+type record struct {
+	versions [][]byte // зашифрованные версии данных
+	status   int      // статус данных
+	seq      uint64   // порядковый номер добавления, нужен для стабильного порядка выдачи
+}
+
+// chunkEntry - зашифрованная часть вложения.
+type chunkEntry struct {
+	data  []byte    // зашифрованная часть
+	refs  int       // количество ссылок вложений на часть
+	saved time.Time // время сохранения части без ссылок, нужно для удаления частей незавершенных загрузок
+}
+
+// attachmentEntry - вложение, составленное из частей.
+type attachmentEntry struct {
+	chunks  []string            // хэши частей в порядке следования
+	records map[string]struct{} // идентификаторы записей, ссылающихся на вложение
+}
+
+// orgEntry - организация с участниками и записями коллекции.
+type orgEntry struct {
+	org     org.Org                // организация без роли и ключа пользователя
+	members map[string]*org.Member // участники по id пользователя
+	items   map[string]*org.Item   // записи коллекции по имени
+}
+
+// Store - потокобезопасное хранилище в оперативной памяти.
+// Реализует интерфейсы identity.Identifier, storage.IEncryptedServerStorage, storage.IAttachmentStorage,
+// storage.IAuditStorage, storage.IAccountStorage, storage.IShareStorage и storage.IOrgStorage.
+type Store struct {
+	mu          sync.RWMutex
+	auth        map[string]identity.AuthorizationData  // авторизационные данные пользователей по логину
+	data        map[string]map[string]*record          // данные пользователей по id пользователя и имени данных
+	chunks      map[string]map[string]*chunkEntry      // части вложений по id пользователя и хэшу части
+	attachments map[string]map[string]*attachmentEntry // вложения по id пользователя и id вложения
+	events      []audit.Event                          // журнал аудита в порядке добавления событий
+	sessions    map[string]*account.Session            // состояние сессий пользователей по id пользователя
+	keys        map[string]share.KeyPair               // ключевые пары пользователей по id пользователя
+	shares      map[string]*share.Share                // общие записи по id общей записи
+	orgs        map[string]*orgEntry                   // организации по id организации
+	nextSeq     uint64
+}
+
+// NewStore - фабричная функция хранилища в оперативной памяти.
+func NewStore() *Store {
+	return &Store{
+		auth:        make(map[string]identity.AuthorizationData),
+		data:        make(map[string]map[string]*record),
+		chunks:      make(map[string]map[string]*chunkEntry),
+		attachments: make(map[string]map[string]*attachmentEntry),
+		sessions:    make(map[string]*account.Session),
+		keys:        make(map[string]share.KeyPair),
+		shares:      make(map[string]*share.Share),
+		orgs:        make(map[string]*orgEntry),
+	}
+}
+
+// Disable - очищает хранилище. Аналог pg.Store.Disable.
+func (s *Store) Disable(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.auth = make(map[string]identity.AuthorizationData)
+	s.data = make(map[string]map[string]*record)
+	s.chunks = make(map[string]map[string]*chunkEntry)
+	s.attachments = make(map[string]map[string]*attachmentEntry)
+	s.events = nil
+	s.sessions = make(map[string]*account.Session)
+	s.keys = make(map[string]share.KeyPair)
+	s.shares = make(map[string]*share.Share)
+	s.orgs = make(map[string]*orgEntry)
+	return nil
+}
+
+// Register - сохраняет данные нового пользователя.
+// Если пользователь с таким логином уже зарегистрирован, возвращается *pgconn.PgError с кодом 23505.
+func (s *Store) Register(ctx context.Context, login, hash, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.auth[login]; ok {
+		return &pgconn.PgError{Code: uniqueViolation, Message: "login already exists"}
+	}
+	s.auth[login] = identity.AuthorizationData{Hash: hash, ID: id}
+	s.sessions[id] = &account.Session{}
+	return nil
+}
+
+// Authorize - возвращает авторизационные данные пользователя по логину.
+// Если пользователь не найден, возвращается false.
+func (s *Store) Authorize(ctx context.Context, login string) (identity.AuthorizationData, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return identity.AuthorizationData{}, false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	d, ok := s.auth[login]
+	if ok {
+		d.Disabled = s.sessions[d.ID].Disabled
+	}
+	return d, ok, nil
+}
+
+// AddEncryptedData - добавляет уникальные зашифрованные данные пользователя.
+// В случае если данные не уникальны, возвращается false.
+// Если изменение превысит квоту пользователя, возвращается ошибка quota.ErrQuotaExceeded.
+func (s *Store) AddEncryptedData(ctx context.Context, idUser string, userData data.EncryptedData, status int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userRecords, ok := s.data[idUser]
+	if !ok {
+		userRecords = make(map[string]*record)
+		s.data[idUser] = userRecords
+	}
+	if quota.Enabled() {
+		if err := quota.CheckAdd(s.usage(idUser), int64(len(userData.EncryptedData))); err != nil {
+			return false, err
+		}
+	}
+	if _, ok := userRecords[userData.Name]; ok {
+		return false, nil
+	}
+
+	s.nextSeq++
+	userRecords[userData.Name] = &record{
+		versions: [][]byte{clone(userData.EncryptedData)},
+		status:   status,
+		seq:      s.nextSeq,
+	}
+	return true, nil
+}
+
+// ReplaceEncryptedData - заменяет все версии существующих данных одной новой версией.
+// Если данных не существует, возвращается false.
+// Если изменение превысит квоту пользователя, возвращается ошибка quota.ErrQuotaExceeded.
+func (s *Store) ReplaceEncryptedData(ctx context.Context, idUser string, userData data.EncryptedData, status int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.get(idUser, userData.Name)
+	if !ok {
+		return false, nil
+	}
+	if quota.Enabled() {
+		record := data.RecordUsage{Bytes: r.size(), Versions: len(r.versions)}
+		if err := quota.CheckReplace(s.usage(idUser), record, int64(len(userData.EncryptedData))); err != nil {
+			return false, err
+		}
+	}
+	r.versions = [][]byte{clone(userData.EncryptedData)}
+	r.status = status
+	return true, nil
+}
+
+// AppendEncryptedData - добавляет новую версию к существующим данным и переводит их в статус CONFLICT.
+// Если такая версия уже сохранена, данные не изменяются. Если данных не существует, возвращается false.
+// Если изменение превысит квоту пользователя, возвращается ошибка quota.ErrQuotaExceeded.
+func (s *Store) AppendEncryptedData(ctx context.Context, idUser string, userData data.EncryptedData) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.get(idUser, userData.Name)
+	if !ok {
+		return false, nil
+	}
+	if quota.Enabled() {
+		record := data.RecordUsage{Bytes: r.size(), Versions: len(r.versions)}
+		if err := quota.CheckAppend(s.usage(idUser), record, int64(len(userData.EncryptedData))); err != nil {
+			return false, err
+		}
+	}
+	for _, v := range r.versions {
+		if bytes.Equal(v, userData.EncryptedData) {
+			return true, nil
+		}
+	}
+	r.versions = append(r.versions, clone(userData.EncryptedData))
+	r.status = data.CONFLICT
+	return true, nil
+}
+
+// GetAllEncryptedData - возвращает все версии всех данных пользователя в порядке их добавления.
+func (s *Store) GetAllEncryptedData(ctx context.Context, idUser string) ([][]data.EncryptedData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.data[idUser]))
+	for name := range s.data[idUser] {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return s.data[idUser][names[i]].seq < s.data[idUser][names[j]].seq
+	})
+
+	result := make([][]data.EncryptedData, 0, len(names))
+	for _, name := range names {
+		r := s.data[idUser][name]
+		versions := make([]data.EncryptedData, 0, len(r.versions))
+		for _, v := range r.versions {
+			versions = append(versions, data.EncryptedData{EncryptedData: clone(v), Name: name})
+		}
+		result = append(result, versions)
+	}
+	return result, nil
+}
+
+// DeleteEncryptedData - удаляет данные пользователя по имени.
+// Если данных не существует, возвращается false.
+func (s *Store) DeleteEncryptedData(ctx context.Context, idUser, dataName string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.get(idUser, dataName); !ok {
+		return false, nil
+	}
+	delete(s.data[idUser], dataName)
+	return true, nil
+}
+
+// SaveChunk - сохраняет часть вложения по её хэшу. Если часть уже сохранена, данные не изменяются,
+// поэтому повторная отправка части при возобновлении загрузки безопасна.
+// Сохраненная часть не имеет ссылок, пока на неё не сошлется вложение. Повторная отправка части без ссылок
+// продлевает срок её хранения.
+// Если изменение превысит квоту пользователя, возвращается ошибка quota.ErrQuotaExceeded.
+func (s *Store) SaveChunk(ctx context.Context, idUser, hash string, chunk []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userChunks, ok := s.chunks[idUser]
+	if !ok {
+		userChunks = make(map[string]*chunkEntry)
+		s.chunks[idUser] = userChunks
+	}
+	c, ok := userChunks[hash]
+	if !ok {
+		// Повторное сохранение уже сохраненной части не увеличивает объем хранилища и разрешено при превышении квоты
+		if quota.Enabled() {
+			if err := quota.CheckBytes(s.usage(idUser), int64(len(chunk))); err != nil {
+				return err
+			}
+		}
+		c = &chunkEntry{data: clone(chunk)}
+		userChunks[hash] = c
+	}
+	if c.refs <= 0 {
+		c.saved = time.Now()
+	}
+	return nil
+}
+
+// GetChunk - возвращает часть вложения по хэшу. Если часть не найдена, возвращается false.
+func (s *Store) GetChunk(ctx context.Context, idUser, hash string) ([]byte, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	c, ok := s.chunks[idUser][hash]
+	if !ok {
+		return nil, false, nil
+	}
+	return clone(c.data), true, nil
+}
+
+// GetMissingChunks - возвращает хэши частей, которые не сохранены в хранилище, в порядке их передачи.
+func (s *Store) GetMissingChunks(ctx context.Context, idUser string, hashes []string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.missing(idUser, hashes), nil
+}
+
+// AddAttachmentRef - создает вложение из сохраненных частей или добавляет ссылку записи record на существующее вложение.
+// Повторное добавление ссылки той же записи не изменяет вложение. Возвращает количество ссылок на вложение.
+// Если часть вложения не сохранена, возвращается storage.ErrMissingChunks.
+// Если вложение уже существует с другим списком частей, возвращается storage.ErrAttachmentMismatch.
+func (s *Store) AddAttachmentRef(ctx context.Context, idUser, attachmentID, record string, chunks []string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a, ok := s.attachments[idUser][attachmentID]; ok {
+		if !slices.Equal(a.chunks, chunks) {
+			return 0, storage.ErrAttachmentMismatch
+		}
+		a.records[record] = struct{}{}
+		return len(a.records), nil
+	}
+
+	if len(s.missing(idUser, chunks)) > 0 {
+		return 0, storage.ErrMissingChunks
+	}
+	userAttachments, ok := s.attachments[idUser]
+	if !ok {
+		userAttachments = make(map[string]*attachmentEntry)
+		s.attachments[idUser] = userAttachments
+	}
+	userAttachments[attachmentID] = &attachmentEntry{
+		chunks:  slices.Clone(chunks),
+		records: map[string]struct{}{record: {}},
+	}
+	for _, hash := range chunks {
+		s.chunks[idUser][hash].refs++
+	}
+	return 1, nil
+}
+
+// GetAttachment - возвращает информацию о вложении. Если вложение не найдено, возвращается false.
+func (s *Store) GetAttachment(ctx context.Context, idUser, attachmentID string) (data.AttachmentInfo, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return data.AttachmentInfo{}, false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.attachments[idUser][attachmentID]
+	if !ok {
+		return data.AttachmentInfo{}, false, nil
+	}
+	return data.AttachmentInfo{ID: attachmentID, Chunks: slices.Clone(a.chunks), Refs: len(a.records)}, true, nil
+}
+
+// ReleaseAttachment - удаляет ссылку записи record на вложение и возвращает количество оставшихся ссылок.
+// Повторное удаление ссылки той же записи не изменяет вложение. Вложение удаляется вместе с частями,
+// на которые больше нет ссылок, когда удаляется последняя ссылка. Если вложение не найдено, возвращается false.
+func (s *Store) ReleaseAttachment(ctx context.Context, idUser, attachmentID, record string) (int, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.attachments[idUser][attachmentID]
+	if !ok {
+		return 0, false, nil
+	}
+	delete(a.records, record)
+	if len(a.records) > 0 {
+		return len(a.records), true, nil
+	}
+
+	delete(s.attachments[idUser], attachmentID)
+	for _, hash := range a.chunks {
+		c := s.chunks[idUser][hash]
+		c.refs--
+		if c.refs <= 0 {
+			delete(s.chunks[idUser], hash)
+		}
+	}
+	return 0, true, nil
+}
+
+// DeleteUnusedChunks - удаляет части всех пользователей, на которые не ссылается ни одно вложение и которые
+// сохранены до момента before. Возвращает количество удаленных частей.
+func (s *Store) DeleteUnusedChunks(ctx context.Context, before time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for _, userChunks := range s.chunks {
+		for hash, c := range userChunks {
+			if c.refs <= 0 && c.saved.Before(before) {
+				delete(userChunks, hash)
+				deleted++
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// GetUsage - возвращает объем зашифрованных данных и частей вложений пользователя, количество записей
+// и наибольшее количество версий одной записи.
+func (s *Store) GetUsage(ctx context.Context, idUser string) (data.Usage, error) {
+	if err := ctx.Err(); err != nil {
+		return data.Usage{}, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.usage(idUser), nil
+}
+
+// usage - возвращает информацию об использовании хранилища пользователем. Общие записи, которыми владеет
+// пользователь, и записи коллекций его организаций учитываются как записи пользователя. Вызывается под блокировкой
+// хранилища.
+func (s *Store) usage(idUser string) data.Usage {
+	usage := data.Usage{Records: len(s.data[idUser])}
+	for _, r := range s.data[idUser] {
+		usage.Bytes += r.size()
+		usage.Versions = max(usage.Versions, len(r.versions))
+	}
+	for _, c := range s.chunks[idUser] {
+		usage.Bytes += int64(len(c.data))
+	}
+	for _, sh := range s.shares {
+		if sh.OwnerID == idUser {
+			usage.Records++
+			usage.Bytes += int64(len(sh.Data))
+		}
+	}
+	for _, e := range s.orgs {
+		if e.owner() == idUser {
+			usage.Records += len(e.items)
+			for _, item := range e.items {
+				usage.Bytes += int64(len(item.Data))
+			}
+		}
+	}
+	return usage
+}
+
+// GetRecordUsage - возвращает объем и количество версий записи пользователя. Если записи не существует, возвращается false.
+func (s *Store) GetRecordUsage(ctx context.Context, idUser, dataName string) (data.RecordUsage, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return data.RecordUsage{}, false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.get(idUser, dataName)
+	if !ok {
+		return data.RecordUsage{}, false, nil
+	}
+	return data.RecordUsage{Bytes: r.size(), Versions: len(r.versions)}, true, nil
+}
+
+// AppendAuditEvent - сохраняет событие журнала аудита. Порядковый номер события назначается хранилищем,
+// время события задается сервером, если не задано.
+func (s *Store) AppendAuditEvent(ctx context.Context, event audit.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.ID = int64(len(s.events)) + 1
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	s.events = append(s.events, event)
+	return nil
+}
+
+// GetAuditEvents - возвращает события журнала аудита по условиям выборки filter.
+func (s *Store) GetAuditEvents(ctx context.Context, filter audit.Filter) ([]audit.Event, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]audit.Event, 0)
+	for i := range s.events {
+		event := s.events[i]
+		if filter.Desc {
+			event = s.events[len(s.events)-1-i]
+		}
+		if filter.UserID != "" && event.UserID != filter.UserID {
+			continue
+		}
+		if !filter.Since.IsZero() && event.Time.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && !event.Time.Before(filter.Until) {
+			continue
+		}
+		result = append(result, event)
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// size - возвращает объем всех версий записи в байтах.
+func (r *record) size() int64 {
+	var size int64
+	for _, v := range r.versions {
+		size += int64(len(v))
+	}
+	return size
+}
+
+// missing - возвращает хэши несохраненных частей. Вызывающий должен удерживать мьютекс.
+func (s *Store) missing(idUser string, hashes []string) []string {
+	result := make([]string, 0)
+	for _, hash := range hashes {
+		if _, ok := s.chunks[idUser][hash]; !ok && !slices.Contains(result, hash) {
+			result = append(result, hash)
+		}
+	}
+	return result
+}
+
+// get - возвращает запись пользователя по имени данных. Вызывающий должен удерживать мьютекс.
+func (s *Store) get(idUser, dataName string) (*record, bool) {
+	r, ok := s.data[idUser][dataName]
+	return r, ok
+}
+
+// clone - копирует слайс байт, чтобы хранилище не разделяло память с вызывающим кодом.
+func clone(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
+
+// GetSession - возвращает состояние сессий пользователя. Если пользователь не найден, возвращается false.
+func (s *Store) GetSession(ctx context.Context, idUser string) (account.Session, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return account.Session{}, false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[idUser]
+	if !ok {
+		return account.Session{}, false, nil
+	}
+	return *session, true, nil
+}
+
+// ListUsers - возвращает учетные записи пользователей, упорядоченные по логину.
+func (s *Store) ListUsers(ctx context.Context) ([]account.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]account.User, 0, len(s.auth))
+	for login, d := range s.auth {
+		usage := s.usage(d.ID)
+		users = append(users, account.User{ID: d.ID, Login: login, Disabled: s.sessions[d.ID].Disabled,
+			Records: usage.Records, Bytes: usage.Bytes})
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Login < users[j].Login })
+	return users, nil
+}
+
+// SetDisabled - блокирует или разблокирует учетную запись пользователя. Если пользователь не найден,
+// возвращается false.
+func (s *Store) SetDisabled(ctx context.Context, idUser string, disabled bool) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[idUser]
+	if !ok {
+		return false, nil
+	}
+	session.Disabled = disabled
+	return true, nil
+}
+
+// Logout - отзывает токены пользователя, выданные до момента at. Если пользователь не найден, возвращается false.
+func (s *Store) Logout(ctx context.Context, idUser string, at time.Time) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[idUser]
+	if !ok {
+		return false, nil
+	}
+	session.LogoutAt = at
+	return true, nil
+}
+
+// DeleteUser - удаляет учетную запись пользователя вместе с данными, вложениями, состоянием сессий, ключевой парой
+// и общими записями, в которых пользователь является владельцем или получателем. Пользователь удаляется из организаций,
+// а организации, владельцем которых он является, удаляются вместе с записями.
+// Журнал аудита не изменяется. Если пользователь не найден, возвращается false.
+func (s *Store) DeleteUser(ctx context.Context, idUser string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[idUser]; !ok {
+		return false, nil
+	}
+	for login, d := range s.auth {
+		if d.ID == idUser {
+			delete(s.auth, login)
+		}
+	}
+	delete(s.sessions, idUser)
+	delete(s.data, idUser)
+	delete(s.chunks, idUser)
+	delete(s.attachments, idUser)
+	delete(s.keys, idUser)
+	for id, sh := range s.shares {
+		if sh.Visible(idUser) {
+			delete(s.shares, id)
+		}
+	}
+	for id, e := range s.orgs {
+		if m, ok := e.members[idUser]; ok && m.Role == org.Owner {
+			delete(s.orgs, id)
+			continue
+		}
+		delete(e.members, idUser)
+	}
+	return true, nil
+}
+
+// GetStats - возвращает статистику хранилища.
+func (s *Store) GetStats(ctx context.Context) (account.Stats, error) {
+	if err := ctx.Err(); err != nil {
+		return account.Stats{}, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := account.Stats{Users: len(s.auth), AuditEvents: int64(len(s.events))}
+	for _, session := range s.sessions {
+		if session.Disabled {
+			stats.DisabledUsers++
+		}
+	}
+	for _, records := range s.data {
+		stats.Records += len(records)
+		for _, r := range records {
+			stats.Versions += len(r.versions)
+			stats.Bytes += r.size()
+		}
+	}
+	for _, attachments := range s.attachments {
+		stats.Attachments += len(attachments)
+	}
+	for _, chunks := range s.chunks {
+		stats.Chunks += len(chunks)
+		for _, c := range chunks {
+			stats.Bytes += int64(len(c.data))
+		}
+	}
+	// Общие записи и записи коллекций организаций хранятся в одной версии
+	for _, sh := range s.shares {
+		stats.Records++
+		stats.Versions++
+		stats.Bytes += int64(len(sh.Data))
+	}
+	for _, e := range s.orgs {
+		stats.Records += len(e.items)
+		stats.Versions += len(e.items)
+		for _, item := range e.items {
+			stats.Bytes += int64(len(item.Data))
+		}
+	}
+	return stats, nil
+}
+
+// SetKeyPair - сохраняет или заменяет ключевую пару пользователя.
+func (s *Store) SetKeyPair(ctx context.Context, idUser string, keys share.KeyPair) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[idUser] = share.KeyPair{PublicKey: clone(keys.PublicKey), EncryptedPrivateKey: clone(keys.EncryptedPrivateKey)}
+	return nil
+}
+
+// GetKeyPair - возвращает ключевую пару пользователя. Если ключевая пара не сохранена, возвращается false.
+func (s *Store) GetKeyPair(ctx context.Context, idUser string) (share.KeyPair, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return share.KeyPair{}, false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys, ok := s.keys[idUser]
+	if !ok {
+		return share.KeyPair{}, false, nil
+	}
+	return share.KeyPair{PublicKey: clone(keys.PublicKey), EncryptedPrivateKey: clone(keys.EncryptedPrivateKey)}, true, nil
+}
+
+// GetPublicKey - возвращает id пользователя и его открытый ключ по логину. Если пользователь не найден или не
+// сохранил ключевую пару, возвращается false.
+func (s *Store) GetPublicKey(ctx context.Context, login string) (string, []byte, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return "", nil, false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	d, ok := s.auth[login]
+	if !ok {
+		return "", nil, false, nil
+	}
+	keys, ok := s.keys[d.ID]
+	if !ok {
+		return "", nil, false, nil
+	}
+	return d.ID, clone(keys.PublicKey), true, nil
+}
+
+// CreateShare - создает общую запись. Если владелец уже поделился записью с тем же именем с этим получателем,
+// возвращается false. Если общая запись превысит квоту владельца, возвращается ошибка quota.ErrQuotaExceeded.
+func (s *Store) CreateShare(ctx context.Context, sh share.Share) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.shares[sh.ID]; ok {
+		return false, nil
+	}
+	for _, other := range s.shares {
+		if other.OwnerID == sh.OwnerID && other.RecipientID == sh.RecipientID && other.Name == sh.Name {
+			return false, nil
+		}
+	}
+	if quota.Enabled() {
+		if err := quota.CheckAdd(s.usage(sh.OwnerID), int64(len(sh.Data))); err != nil {
+			return false, err
+		}
+	}
+	now := time.Now().UTC()
+	sh.Accepted = false
+	sh.Version = 1
+	sh.CreatedAt, sh.UpdatedAt = now, now
+	sh.OwnerKey, sh.RecipientKey, sh.Data = clone(sh.OwnerKey), clone(sh.RecipientKey), clone(sh.Data)
+	s.shares[sh.ID] = &sh
+	return true, nil
+}
+
+// GetShares - возвращает общие записи, в которых пользователь является владельцем или получателем, в порядке
+// создания.
+func (s *Store) GetShares(ctx context.Context, idUser string) ([]share.Share, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]share.Share, 0)
+	for _, sh := range s.shares {
+		if sh.Visible(idUser) {
+			result = append(result, s.share(sh))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// GetShare - возвращает общую запись по id. Если общая запись не найдена, возвращается false.
+func (s *Store) GetShare(ctx context.Context, id string) (share.Share, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return share.Share{}, false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sh, ok := s.shares[id]
+	if !ok {
+		return share.Share{}, false, nil
+	}
+	return s.share(sh), true, nil
+}
+
+// AcceptShare - отмечает общую запись принятой получателем. Если общая запись не найдена, возвращается false.
+func (s *Store) AcceptShare(ctx context.Context, id string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sh, ok := s.shares[id]
+	if !ok {
+		return false, nil
+	}
+	sh.Accepted = true
+	return true, nil
+}
+
+// UpdateShare - изменяет содержимое общей записи, если её версия равна version, и возвращает новую версию.
+// Если общая запись не найдена или версия не совпадает, возвращается false.
+// Если изменение превысит квоту владельца общей записи, возвращается ошибка quota.ErrQuotaExceeded.
+func (s *Store) UpdateShare(ctx context.Context, id string, content []byte, version int64) (int64, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sh, ok := s.shares[id]
+	if !ok || sh.Version != version {
+		return 0, false, nil
+	}
+	if quota.Enabled() {
+		record := data.RecordUsage{Bytes: int64(len(sh.Data)), Versions: 1}
+		if err := quota.CheckReplace(s.usage(sh.OwnerID), record, int64(len(content))); err != nil {
+			return 0, false, err
+		}
+	}
+	sh.Data = clone(content)
+	sh.Version++
+	sh.UpdatedAt = time.Now().UTC()
+	return sh.Version, true, nil
+}
+
+// DeleteShare - удаляет общую запись. Если общая запись не найдена, возвращается false.
+func (s *Store) DeleteShare(ctx context.Context, id string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.shares[id]; !ok {
+		return false, nil
+	}
+	delete(s.shares, id)
+	return true, nil
+}
+
+// share - возвращает копию общей записи с логинами владельца и получателя.
+func (s *Store) share(sh *share.Share) share.Share {
+	c := *sh
+	c.OwnerKey, c.RecipientKey, c.Data = clone(sh.OwnerKey), clone(sh.RecipientKey), clone(sh.Data)
+	for login, d := range s.auth {
+		switch d.ID {
+		case sh.OwnerID:
+			c.Owner = login
+		case sh.RecipientID:
+			c.Recipient = login
+		}
+	}
+	return c
+}
+
+// CreateOrg - создает организацию с первой версией ключа коллекции и владельцем owner. Если организация с таким
+// id уже существует, возвращается false.
+func (s *Store) CreateOrg(ctx context.Context, o org.Org, owner org.Member) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.orgs[o.ID]; ok {
+		return false, nil
+	}
+	o.KeyVersion = 1
+	o.CreatedAt = time.Now().UTC()
+	o.Role, o.Key = "", nil
+	owner.Role, owner.KeyVersion, owner.Key = org.Owner, o.KeyVersion, clone(owner.Key)
+	s.orgs[o.ID] = &orgEntry{
+		org:     o,
+		members: map[string]*org.Member{owner.UserID: &owner},
+		items:   make(map[string]*org.Item),
+	}
+	return true, nil
+}
+
+// GetOrgs - возвращает организации, участником которых является пользователь, с его ролью и ключом коллекции
+// в порядке создания.
+func (s *Store) GetOrgs(ctx context.Context, idUser string) ([]org.Org, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]org.Org, 0)
+	for _, e := range s.orgs {
+		if m, ok := e.members[idUser]; ok {
+			result = append(result, e.orgOf(m))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// GetOrg - возвращает организацию с ролью и ключом коллекции пользователя. Если организация не найдена или
+// пользователь не является её участником, возвращается false.
+func (s *Store) GetOrg(ctx context.Context, idOrg, idUser string) (org.Org, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return org.Org{}, false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.orgs[idOrg]
+	if !ok {
+		return org.Org{}, false, nil
+	}
+	m, ok := e.members[idUser]
+	if !ok {
+		return org.Org{}, false, nil
+	}
+	return e.orgOf(m), true, nil
+}
+
+// DeleteOrg - удаляет организацию с участниками и записями коллекции. Если организация не найдена, возвращается false.
+func (s *Store) DeleteOrg(ctx context.Context, idOrg string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.orgs[idOrg]; !ok {
+		return false, nil
+	}
+	delete(s.orgs, idOrg)
+	return true, nil
+}
+
+// GetMembers - возвращает участников организации в порядке логинов.
+func (s *Store) GetMembers(ctx context.Context, idOrg string) ([]org.Member, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]org.Member, 0)
+	e, ok := s.orgs[idOrg]
+	if !ok {
+		return result, nil
+	}
+	for _, m := range e.members {
+		c := *m
+		c.Key = clone(m.Key)
+		for login, d := range s.auth {
+			if d.ID == m.UserID {
+				c.Login = login
+			}
+		}
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Login < result[j].Login })
+	return result, nil
+}
+
+// AddMember - добавляет участника организации. Если организация не найдена, пользователь уже является её
+// участником или ключ коллекции зашифрован для участника не текущей версией, возвращается false.
+func (s *Store) AddMember(ctx context.Context, idOrg string, m org.Member) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.orgs[idOrg]
+	if !ok || m.KeyVersion != e.org.KeyVersion {
+		return false, nil
+	}
+	if _, ok := e.members[m.UserID]; ok {
+		return false, nil
+	}
+	m.Key = clone(m.Key)
+	e.members[m.UserID] = &m
+	return true, nil
+}
+
+// SetRole - изменяет роль участника организации. Если организация или участник не найдены, возвращается false.
+func (s *Store) SetRole(ctx context.Context, idOrg, idUser, role string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.orgs[idOrg]
+	if !ok {
+		return false, nil
+	}
+	m, ok := e.members[idUser]
+	if !ok {
+		return false, nil
+	}
+	m.Role = role
+	return true, nil
+}
+
+// RotateKey - заменяет ключ коллекции организации и удаляет участника removeID, если он задан. Ключи в r.Keys
+// передаются по id участников. Если новая версия ключа не следует за текущей, ключ зашифрован не для всех
+// оставшихся участников или записи коллекции не совпадают с текущими по именам и версиям, возвращается false.
+func (s *Store) RotateKey(ctx context.Context, idOrg string, removeID string, r org.Rotation) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.orgs[idOrg]
+	if !ok || r.KeyVersion != e.org.KeyVersion+1 {
+		return false, nil
+	}
+	if _, ok := e.members[removeID]; removeID != "" && !ok {
+		return false, nil
+	}
+	remaining := len(e.members)
+	if removeID != "" {
+		remaining--
+	}
+	if len(r.Keys) != remaining || len(r.Items) != len(e.items) {
+		return false, nil
+	}
+	for idUser := range r.Keys {
+		if _, ok := e.members[idUser]; !ok || idUser == removeID {
+			return false, nil
+		}
+	}
+	for _, item := range r.Items {
+		current, ok := e.items[item.Name]
+		if !ok || current.Version != item.Version {
+			return false, nil
+		}
+	}
+
+	delete(e.members, removeID)
+	for idUser, key := range r.Keys {
+		e.members[idUser].Key = clone(key)
+		e.members[idUser].KeyVersion = r.KeyVersion
+	}
+	now := time.Now().UTC()
+	for _, item := range r.Items {
+		current := e.items[item.Name]
+		current.Data = clone(item.Data)
+		current.KeyVersion = r.KeyVersion
+		current.Version++
+		current.UpdatedAt = now
+	}
+	e.org.KeyVersion = r.KeyVersion
+	return true, nil
+}
+
+// GetItems - возвращает записи коллекции организации в порядке имен.
+func (s *Store) GetItems(ctx context.Context, idOrg string) ([]org.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]org.Item, 0)
+	e, ok := s.orgs[idOrg]
+	if !ok {
+		return result, nil
+	}
+	for _, item := range e.items {
+		c := *item
+		c.Data = clone(item.Data)
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// PutItem - добавляет запись в коллекцию организации, если item.Version равна 0, или изменяет запись, если её
+// версия равна item.Version, и возвращает новую версию записи. Если организация не найдена, версия записи
+// не совпадает или запись зашифрована не текущей версией ключа коллекции, возвращается false.
+// Записи коллекции учитываются в квоте владельца организации, при её превышении возвращается ошибка
+// quota.ErrQuotaExceeded.
+func (s *Store) PutItem(ctx context.Context, idOrg string, item org.Item) (int64, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.orgs[idOrg]
+	if !ok || item.KeyVersion != e.org.KeyVersion {
+		return 0, false, nil
+	}
+	var version int64
+	if current, ok := e.items[item.Name]; ok {
+		version = current.Version
+	}
+	if version != item.Version {
+		return 0, false, nil
+	}
+	if quota.Enabled() {
+		usage := s.usage(e.owner())
+		var err error
+		if current, ok := e.items[item.Name]; ok {
+			err = quota.CheckReplace(usage, data.RecordUsage{Bytes: int64(len(current.Data)), Versions: 1}, int64(len(item.Data)))
+		} else {
+			err = quota.CheckAdd(usage, int64(len(item.Data)))
+		}
+		if err != nil {
+			return 0, false, err
+		}
+	}
+	item.Data = clone(item.Data)
+	item.Version++
+	item.UpdatedAt = time.Now().UTC()
+	e.items[item.Name] = &item
+	return item.Version, true, nil
+}
+
+// DeleteItem - удаляет запись коллекции организации, если её версия равна version. Если организация или запись
+// не найдены или версия не совпадает, возвращается false.
+func (s *Store) DeleteItem(ctx context.Context, idOrg, name string, version int64) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.orgs[idOrg]
+	if !ok {
+		return false, nil
+	}
+	current, ok := e.items[name]
+	if !ok || current.Version != version {
+		return false, nil
+	}
+	delete(e.items, name)
+	return true, nil
+}
+
+// owner - возвращает id владельца организации.
+func (e *orgEntry) owner() string {
+	for id, m := range e.members {
+		if m.Role == org.Owner {
+			return id
+		}
+	}
+	return ""
+}
+
+// orgOf - возвращает копию организации с ролью и ключом коллекции участника m.
+func (e *orgEntry) orgOf(m *org.Member) org.Org {
+	o := e.org
+	o.Role, o.Key = m.Role, clone(m.Key)
+	return o
+}
@@ -0,0 +1,1072 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/account"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/audit"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/org"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/share"
+	"github.com/abezemskiy/gophkeeper/internal/server/quota"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterAndAuthorize(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+
+	{
+		// Успешная регистрация и авторизация пользователя
+		err := stor.Register(ctx, "login", "hash", "id")
+		require.NoError(t, err)
+
+		authData, ok, err := stor.Authorize(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, true, ok)
+		assert.Equal(t, "hash", authData.Hash)
+		assert.Equal(t, "id", authData.ID)
+	}
+	{
+		// Повторная регистрация возвращает ошибку unique_violation, как и PostgreSQL
+		err := stor.Register(ctx, "login", "new hash", "new id")
+		require.Error(t, err)
+		var pgErr *pgconn.PgError
+		require.True(t, errors.As(err, &pgErr))
+		assert.Equal(t, "23505", pgErr.Code)
+	}
+	{
+		// Пользователь не зарегистрирован
+		_, ok, err := stor.Authorize(ctx, "not register user")
+		require.NoError(t, err)
+		assert.Equal(t, false, ok)
+	}
+	{
+		// Контекст уже отменен
+		ctxExc, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := stor.Register(ctxExc, "exceeded login", "hash", "id")
+		require.Error(t, err)
+		_, _, err = stor.Authorize(ctxExc, "login")
+		require.Error(t, err)
+	}
+}
+
+func TestAddEncryptedData(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+
+	userData := data.EncryptedData{EncryptedData: []byte("some encrypted data"), Name: "first data"}
+
+	// добавляю новые данные в хранилище
+	ok, err := stor.AddEncryptedData(ctx, "user id", userData, data.SAVED)
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+
+	// попытка добавить уже существующие данные
+	ok, err = stor.AddEncryptedData(ctx, "user id", userData, data.SAVED)
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+
+	// данные с тем-же именем, но для другого пользователя
+	ok, err = stor.AddEncryptedData(ctx, "another user id", userData, data.SAVED)
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+
+	// хранилище не должно разделять память с вызывающим кодом
+	userData.EncryptedData[0] = 'X'
+	get, err := stor.GetAllEncryptedData(ctx, "user id")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(get))
+	assert.Equal(t, "some encrypted data", string(get[0][0].EncryptedData))
+
+	// контекст уже отменен
+	ctxExc, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = stor.AddEncryptedData(ctxExc, "user id", userData, data.SAVED)
+	require.Error(t, err)
+}
+
+func TestReplaceAndAppendEncryptedData(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+	userID := "user id"
+
+	// замена и дополнение несуществующих данных
+	ok, err := stor.ReplaceEncryptedData(ctx, userID, data.EncryptedData{Name: "not exist"}, data.SAVED)
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+	ok, err = stor.AppendEncryptedData(ctx, userID, data.EncryptedData{Name: "not exist"})
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+
+	ok, err = stor.AddEncryptedData(ctx, userID, data.EncryptedData{EncryptedData: []byte("v1"), Name: "name"}, data.SAVED)
+	require.NoError(t, err)
+	require.Equal(t, true, ok)
+
+	// дополняю данные новой версией
+	ok, err = stor.AppendEncryptedData(ctx, userID, data.EncryptedData{EncryptedData: []byte("v2"), Name: "name"})
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+
+	get, err := stor.GetAllEncryptedData(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(get))
+	require.Equal(t, 2, len(get[0]))
+	assert.Equal(t, "v1", string(get[0][0].EncryptedData))
+	assert.Equal(t, "v2", string(get[0][1].EncryptedData))
+
+	// повторное дополнение той же версией не создает новую версию
+	ok, err = stor.AppendEncryptedData(ctx, userID, data.EncryptedData{EncryptedData: []byte("v2"), Name: "name"})
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+	get, err = stor.GetAllEncryptedData(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(get[0]))
+
+	// замена оставляет единственную версию
+	ok, err = stor.ReplaceEncryptedData(ctx, userID, data.EncryptedData{EncryptedData: []byte("v3"), Name: "name"}, data.SAVED)
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+
+	get, err = stor.GetAllEncryptedData(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(get[0]))
+	assert.Equal(t, "v3", string(get[0][0].EncryptedData))
+}
+
+func TestGetAllEncryptedData(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+	userID := "user id"
+
+	// у пользователя нет данных
+	get, err := stor.GetAllEncryptedData(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(get))
+
+	// данные возвращаются в порядке добавления
+	count := 50
+	for i := 0; i < count; i++ {
+		ok, err := stor.AddEncryptedData(ctx, userID, data.EncryptedData{
+			EncryptedData: []byte(fmt.Sprintf("data %d", i)),
+			Name:          fmt.Sprintf("name %d", i),
+		}, data.SAVED)
+		require.NoError(t, err)
+		require.Equal(t, true, ok)
+	}
+	get, err = stor.GetAllEncryptedData(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, count, len(get))
+	for i, versions := range get {
+		assert.Equal(t, fmt.Sprintf("name %d", i), versions[0].Name)
+		assert.Equal(t, fmt.Sprintf("data %d", i), string(versions[0].EncryptedData))
+	}
+}
+
+func TestDeleteEncryptedData(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+
+	ok, err := stor.DeleteEncryptedData(ctx, "user id", "name")
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+
+	ok, err = stor.AddEncryptedData(ctx, "user id", data.EncryptedData{EncryptedData: []byte("data"), Name: "name"}, data.SAVED)
+	require.NoError(t, err)
+	require.Equal(t, true, ok)
+
+	ok, err = stor.DeleteEncryptedData(ctx, "user id", "name")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+
+	get, err := stor.GetAllEncryptedData(ctx, "user id")
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(get))
+
+	// после удаления данные с тем же именем можно добавить повторно
+	ok, err = stor.AddEncryptedData(ctx, "user id", data.EncryptedData{EncryptedData: []byte("data"), Name: "name"}, data.SAVED)
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+}
+
+func TestDisable(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+
+	require.NoError(t, stor.Register(ctx, "login", "hash", "id"))
+	_, err := stor.AddEncryptedData(ctx, "id", data.EncryptedData{EncryptedData: []byte("data"), Name: "name"}, data.SAVED)
+	require.NoError(t, err)
+
+	require.NoError(t, stor.Disable(ctx))
+
+	_, ok, err := stor.Authorize(ctx, "login")
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+	get, err := stor.GetAllEncryptedData(ctx, "id")
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(get))
+}
+
+func TestAttachments(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+	userID := "user id"
+
+	// части ещё не загружены
+	missing, err := stor.GetMissingChunks(ctx, userID, []string{"first", "second", "first"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, missing)
+	_, ok, err := stor.GetChunk(ctx, userID, "first")
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+
+	// вложение нельзя создать, пока не загружены все части
+	require.NoError(t, stor.SaveChunk(ctx, userID, "first", []byte("first")))
+	_, err = stor.AddAttachmentRef(ctx, userID, "attachment", "record", []string{"first", "second"})
+	assert.ErrorIs(t, err, storage.ErrMissingChunks)
+
+	// повторное сохранение части не изменяет её
+	require.NoError(t, stor.SaveChunk(ctx, userID, "second", []byte("second")))
+	require.NoError(t, stor.SaveChunk(ctx, userID, "second", []byte("second again")))
+	chunk, ok, err := stor.GetChunk(ctx, userID, "second")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "second", string(chunk))
+
+	missing, err = stor.GetMissingChunks(ctx, userID, []string{"first", "second"})
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	// две записи ссылаются на одно вложение, повторная ссылка записи не учитывается
+	refs, err := stor.AddAttachmentRef(ctx, userID, "attachment", "record", []string{"first", "second"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, refs)
+	refs, err = stor.AddAttachmentRef(ctx, userID, "attachment", "record", []string{"first", "second"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, refs)
+	refs, err = stor.AddAttachmentRef(ctx, userID, "attachment", "another record", []string{"first", "second"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, refs)
+	_, err = stor.AddAttachmentRef(ctx, userID, "attachment", "third record", []string{"second"})
+	assert.ErrorIs(t, err, storage.ErrAttachmentMismatch)
+
+	// другое вложение использует общую часть
+	refs, err = stor.AddAttachmentRef(ctx, userID, "another", "record", []string{"second", "second"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, refs)
+
+	info, ok, err := stor.GetAttachment(ctx, userID, "attachment")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, data.AttachmentInfo{ID: "attachment", Chunks: []string{"first", "second"}, Refs: 2}, info)
+
+	// вложение другого пользователя недоступно
+	_, ok, err = stor.GetChunk(ctx, "another user", "first")
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+	_, ok, err = stor.GetAttachment(ctx, "another user", "attachment")
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+
+	// вложение удаляется только после удаления последней ссылки, повторное удаление ссылки записи не учитывается
+	refs, ok, err = stor.ReleaseAttachment(ctx, userID, "attachment", "record")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, refs)
+	refs, ok, err = stor.ReleaseAttachment(ctx, userID, "attachment", "record")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 1, refs)
+	_, ok, err = stor.GetChunk(ctx, userID, "first")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+
+	refs, ok, err = stor.ReleaseAttachment(ctx, userID, "attachment", "another record")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, 0, refs)
+	_, ok, err = stor.GetAttachment(ctx, userID, "attachment")
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+	_, _, err = stor.ReleaseAttachment(ctx, userID, "attachment", "record")
+	require.NoError(t, err)
+
+	// часть без ссылок удалена, общая часть осталась
+	missing, err = stor.GetMissingChunks(ctx, userID, []string{"first", "second"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first"}, missing)
+
+	_, ok, err = stor.ReleaseAttachment(ctx, userID, "another", "record")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+	missing, err = stor.GetMissingChunks(ctx, userID, []string{"second"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"second"}, missing)
+
+	// контекст уже отменен
+	ctxExc, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Error(t, stor.SaveChunk(ctxExc, userID, "first", []byte("data")))
+	_, _, err = stor.GetChunk(ctxExc, userID, "first")
+	require.Error(t, err)
+	_, err = stor.GetMissingChunks(ctxExc, userID, []string{"first"})
+	require.Error(t, err)
+	_, err = stor.AddAttachmentRef(ctxExc, userID, "attachment", "record", []string{"first"})
+	require.Error(t, err)
+	_, _, err = stor.GetAttachment(ctxExc, userID, "attachment")
+	require.Error(t, err)
+	_, _, err = stor.ReleaseAttachment(ctxExc, userID, "attachment", "record")
+	require.Error(t, err)
+}
+
+func TestDeleteUnusedChunks(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+	userID := "user id"
+	var err error
+
+	// часть незавершенной загрузки и часть вложения
+	require.NoError(t, stor.SaveChunk(ctx, userID, "orphan", []byte("orphan")))
+	require.NoError(t, stor.SaveChunk(ctx, userID, "used", []byte("used")))
+	_, err = stor.AddAttachmentRef(ctx, userID, "attachment", "record", []string{"used"})
+	require.NoError(t, err)
+
+	// части, сохраненные позже момента before, не удаляются
+	deleted, err := stor.DeleteUnusedChunks(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+
+	// удаляются только части без ссылок, и они больше не учитываются в квоте
+	deleted, err = stor.DeleteUnusedChunks(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	missing, err := stor.GetMissingChunks(ctx, userID, []string{"orphan", "used"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orphan"}, missing)
+	usage, err := stor.GetUsage(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("used")), usage.Bytes)
+
+	// контекст уже отменен
+	ctxExc, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = stor.DeleteUnusedChunks(ctxExc, time.Now())
+	require.Error(t, err)
+}
+
+func TestUsage(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+	userID := "user id"
+
+	// у нового пользователя нет данных
+	usage, err := stor.GetUsage(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, data.Usage{}, usage)
+	_, ok, err := stor.GetRecordUsage(ctx, userID, "first")
+	require.NoError(t, err)
+	assert.Equal(t, false, ok)
+
+	_, err = stor.AddEncryptedData(ctx, userID, data.EncryptedData{Name: "first", EncryptedData: []byte("12345")}, data.SAVED)
+	require.NoError(t, err)
+	_, err = stor.AppendEncryptedData(ctx, userID, data.EncryptedData{Name: "first", EncryptedData: []byte("123")})
+	require.NoError(t, err)
+	_, err = stor.AddEncryptedData(ctx, userID, data.EncryptedData{Name: "second", EncryptedData: []byte("12")}, data.SAVED)
+	require.NoError(t, err)
+	require.NoError(t, stor.SaveChunk(ctx, userID, "chunk", []byte("1234567890")))
+	_, err = stor.AddEncryptedData(ctx, "another user", data.EncryptedData{Name: "first", EncryptedData: []byte("1")}, data.SAVED)
+	require.NoError(t, err)
+
+	usage, err = stor.GetUsage(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, data.Usage{Bytes: 20, Records: 2, Versions: 2}, usage)
+
+	record, ok, err := stor.GetRecordUsage(ctx, userID, "first")
+	require.NoError(t, err)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, data.RecordUsage{Bytes: 8, Versions: 2}, record)
+
+	// контекст уже отменен
+	ctxExc, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = stor.GetUsage(ctxExc, userID)
+	require.Error(t, err)
+	_, _, err = stor.GetRecordUsage(ctxExc, userID, "first")
+	require.Error(t, err)
+}
+
+func TestQuota(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+
+	defer quota.SetLimits(quota.GetLimits())
+	quota.SetLimits(quota.Limits{MaxBytes: 100, MaxRecords: 10, MaxVersions: 2})
+	userID := "user id"
+
+	// Одновременные запросы проверяют квоту вместе с изменением хранилища и не могут вместе превысить её
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	added := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			encrData := data.EncryptedData{Name: fmt.Sprintf("name %d", i), EncryptedData: make([]byte, 20)}
+			ok, err := stor.AddEncryptedData(ctx, userID, encrData, data.SAVED)
+			if err != nil {
+				assert.ErrorIs(t, err, quota.ErrQuotaExceeded)
+				return
+			}
+			assert.True(t, ok)
+			mu.Lock()
+			added++
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 5, added)
+	usage, err := stor.GetUsage(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), usage.Bytes)
+
+	// Замена, новая версия и новая часть вложения сверх квоты не сохраняются
+	all, err := stor.GetAllEncryptedData(ctx, userID)
+	require.NoError(t, err)
+	name := all[0][0].Name
+	_, err = stor.ReplaceEncryptedData(ctx, userID, data.EncryptedData{Name: name, EncryptedData: make([]byte, 21)}, data.SAVED)
+	require.ErrorIs(t, err, quota.ErrQuotaExceeded)
+	ok, err := stor.ReplaceEncryptedData(ctx, userID, data.EncryptedData{Name: name, EncryptedData: make([]byte, 10)}, data.SAVED)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, err = stor.AppendEncryptedData(ctx, userID, data.EncryptedData{Name: name, EncryptedData: make([]byte, 11)})
+	require.ErrorIs(t, err, quota.ErrQuotaExceeded)
+	ok, err = stor.AppendEncryptedData(ctx, userID, data.EncryptedData{Name: name, EncryptedData: []byte("0123456789")})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, err = stor.AppendEncryptedData(ctx, userID, data.EncryptedData{Name: name, EncryptedData: []byte("0")})
+	require.ErrorIs(t, err, quota.ErrQuotaExceeded)
+	require.ErrorIs(t, stor.SaveChunk(ctx, userID, "hash", []byte("chunk")), quota.ErrQuotaExceeded)
+
+	// Отсутствующая запись не проверяется квотой
+	ok, err = stor.ReplaceEncryptedData(ctx, userID, data.EncryptedData{Name: "missing", EncryptedData: make([]byte, 200)}, data.SAVED)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Общие записи учитываются в квоте владельца
+	quota.SetLimits(quota.Limits{MaxBytes: 10, MaxRecords: 2})
+	sh := share.Share{ID: "share", Name: "card", OwnerID: "owner", RecipientID: "recipient", Permission: share.Write,
+		OwnerKey: []byte("owner key"), RecipientKey: []byte("recipient key"), Data: make([]byte, 6)}
+	ok, err = stor.CreateShare(ctx, sh)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	sh.ID, sh.Name, sh.Data = "other", "note", make([]byte, 5)
+	_, err = stor.CreateShare(ctx, sh)
+	require.ErrorIs(t, err, quota.ErrQuotaExceeded)
+	_, _, err = stor.UpdateShare(ctx, "share", make([]byte, 11), 1)
+	require.ErrorIs(t, err, quota.ErrQuotaExceeded)
+	version, ok, err := stor.UpdateShare(ctx, "share", make([]byte, 10), 1)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), version)
+	_, err = stor.AddEncryptedData(ctx, "owner", data.EncryptedData{Name: "first", EncryptedData: []byte("1")}, data.SAVED)
+	require.ErrorIs(t, err, quota.ErrQuotaExceeded)
+	usage, err = stor.GetUsage(ctx, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, data.Usage{Bytes: 10, Records: 1}, usage)
+
+	// Записи коллекций организаций учитываются в квоте владельца организации
+	quota.SetLimits(quota.Limits{MaxBytes: 20})
+	ok, err = stor.CreateOrg(ctx, org.Org{ID: "org", Name: "team"}, org.Member{UserID: "owner", Key: []byte("owner key")})
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, _, err = stor.PutItem(ctx, "org", org.Item{Name: "card", Data: make([]byte, 11), KeyVersion: 1})
+	require.ErrorIs(t, err, quota.ErrQuotaExceeded)
+	version, ok, err = stor.PutItem(ctx, "org", org.Item{Name: "card", Data: make([]byte, 5), KeyVersion: 1})
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, _, err = stor.PutItem(ctx, "org", org.Item{Name: "card", Data: make([]byte, 11), KeyVersion: 1, Version: version})
+	require.ErrorIs(t, err, quota.ErrQuotaExceeded)
+	_, ok, err = stor.PutItem(ctx, "org", org.Item{Name: "card", Data: make([]byte, 10), KeyVersion: 1, Version: version})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	usage, err = stor.GetUsage(ctx, "owner")
+	require.NoError(t, err)
+	assert.Equal(t, data.Usage{Bytes: 20, Records: 2}, usage)
+
+	// Статистика хранилища учитывает общие записи и записи организаций
+	userUsage, err := stor.GetUsage(ctx, userID)
+	require.NoError(t, err)
+	stats, err := stor.GetStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, userUsage.Records+2, stats.Records)
+	assert.Equal(t, userUsage.Bytes+20, stats.Bytes)
+}
+
+func TestAuditEvents(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := []audit.Event{
+		{Time: start, Type: audit.Register, UserID: "first", Login: "first login"},
+		{Time: start.Add(time.Hour), Type: audit.LoginFailure, Login: "unknown"},
+		{Time: start.Add(2 * time.Hour), Type: audit.RecordCreate, UserID: "first", RecordID: "card"},
+		{Time: start.Add(3 * time.Hour), Type: audit.Register, UserID: "second", Login: "second login"},
+	}
+	for _, event := range events {
+		require.NoError(t, stor.AppendAuditEvent(ctx, event))
+	}
+	// время события задается хранилищем, если не задано
+	require.NoError(t, stor.AppendAuditEvent(ctx, audit.Event{Type: audit.LoginSuccess, UserID: "first"}))
+
+	tests := []struct {
+		name   string
+		filter audit.Filter
+		want   []int64
+	}{
+		{name: "all", filter: audit.Filter{}, want: []int64{1, 2, 3, 4, 5}},
+		{name: "user", filter: audit.Filter{UserID: "first"}, want: []int64{1, 3, 5}},
+		{name: "user desc", filter: audit.Filter{UserID: "first", Desc: true}, want: []int64{5, 3, 1}},
+		{name: "limit", filter: audit.Filter{UserID: "first", Desc: true, Limit: 2}, want: []int64{5, 3}},
+		{name: "since inclusive", filter: audit.Filter{Since: start.Add(time.Hour)}, want: []int64{2, 3, 4, 5}},
+		{name: "until exclusive", filter: audit.Filter{Until: start.Add(2 * time.Hour)}, want: []int64{1, 2}},
+		{name: "unknown user", filter: audit.Filter{UserID: "unknown"}, want: []int64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := stor.GetAuditEvents(ctx, tt.filter)
+			require.NoError(t, err)
+			ids := make([]int64, 0, len(got))
+			for _, event := range got {
+				ids = append(ids, event.ID)
+			}
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+
+	got, err := stor.GetAuditEvents(ctx, audit.Filter{UserID: "first", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, events[0].Login, got[0].Login)
+
+	// контекст уже отменен
+	ctxExc, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Error(t, stor.AppendAuditEvent(ctxExc, audit.Event{Type: audit.Register}))
+	_, err = stor.GetAuditEvents(ctxExc, audit.Filter{})
+	require.Error(t, err)
+
+	// журнал очищается вместе с остальными данными
+	require.NoError(t, stor.Disable(ctx))
+	got, err = stor.GetAuditEvents(ctx, audit.Filter{})
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestAccounts(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+
+	{
+		// Учетные записи упорядочены по логину, объем учитывает записи и части вложений
+		require.NoError(t, stor.Register(ctx, "bob", "hash", "second"))
+		require.NoError(t, stor.Register(ctx, "alice", "hash", "first"))
+		ok, err := stor.AddEncryptedData(ctx, "first", data.EncryptedData{EncryptedData: []byte("ciphertext"), Name: "card"}, data.SAVED)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.NoError(t, stor.SaveChunk(ctx, "first", "hash", []byte("chunk")))
+		_, err = stor.AddAttachmentRef(ctx, "first", "attachment", "record", []string{"hash"})
+		require.NoError(t, err)
+		require.NoError(t, stor.AppendAuditEvent(ctx, audit.Event{Type: audit.Register, UserID: "first"}))
+
+		users, err := stor.ListUsers(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []account.User{
+			{ID: "first", Login: "alice", Records: 1, Bytes: int64(len("ciphertext") + len("chunk"))},
+			{ID: "second", Login: "bob"},
+		}, users)
+
+		stats, err := stor.GetStats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, account.Stats{Users: 2, Records: 1, Versions: 1, Attachments: 1, Chunks: 1,
+			Bytes: int64(len("ciphertext") + len("chunk")), AuditEvents: 1}, stats)
+	}
+	{
+		// Блокировка и принудительный выход
+		session, ok, err := stor.GetSession(ctx, "first")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, account.Session{}, session)
+
+		ok, err = stor.SetDisabled(ctx, "first", true)
+		require.NoError(t, err)
+		require.True(t, ok)
+		authData, _, err := stor.Authorize(ctx, "alice")
+		require.NoError(t, err)
+		assert.True(t, authData.Disabled)
+
+		logoutAt := time.Now().Truncate(time.Second)
+		ok, err = stor.Logout(ctx, "first", logoutAt)
+		require.NoError(t, err)
+		require.True(t, ok)
+		session, _, err = stor.GetSession(ctx, "first")
+		require.NoError(t, err)
+		assert.True(t, session.Disabled)
+		assert.True(t, logoutAt.Equal(session.LogoutAt))
+
+		stats, err := stor.GetStats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, stats.DisabledUsers)
+
+		ok, err = stor.SetDisabled(ctx, "first", false)
+		require.NoError(t, err)
+		require.True(t, ok)
+		authData, _, err = stor.Authorize(ctx, "alice")
+		require.NoError(t, err)
+		assert.False(t, authData.Disabled)
+	}
+	{
+		// Удаление учетной записи со всеми данными, журнал аудита сохраняется
+		ok, err := stor.DeleteUser(ctx, "first")
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		_, ok, err = stor.Authorize(ctx, "alice")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		_, ok, err = stor.GetSession(ctx, "first")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		all, err := stor.GetAllEncryptedData(ctx, "first")
+		require.NoError(t, err)
+		assert.Empty(t, all)
+		_, ok, err = stor.GetChunk(ctx, "first", "hash")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		stats, err := stor.GetStats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, account.Stats{Users: 1, AuditEvents: 1}, stats)
+
+		// Логин удаленного пользователя можно зарегистрировать повторно
+		require.NoError(t, stor.Register(ctx, "alice", "hash", "third"))
+	}
+	{
+		// Пользователь не найден
+		_, ok, err := stor.GetSession(ctx, "unknown")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = stor.SetDisabled(ctx, "unknown", true)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = stor.Logout(ctx, "unknown", time.Now())
+		require.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = stor.DeleteUser(ctx, "unknown")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+	{
+		// Контекст уже отменен
+		ctxExc, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, _, err := stor.GetSession(ctxExc, "second")
+		require.Error(t, err)
+		_, err = stor.ListUsers(ctxExc)
+		require.Error(t, err)
+		_, err = stor.SetDisabled(ctxExc, "second", true)
+		require.Error(t, err)
+		_, err = stor.Logout(ctxExc, "second", time.Now())
+		require.Error(t, err)
+		_, err = stor.DeleteUser(ctxExc, "second")
+		require.Error(t, err)
+		_, err = stor.GetStats(ctxExc)
+		require.Error(t, err)
+	}
+}
+
+func TestShares(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+	require.NoError(t, stor.Register(ctx, "alice", "hash", "first"))
+	require.NoError(t, stor.Register(ctx, "bob", "hash", "second"))
+
+	{
+		// Ключевая пара сохраняется и заменяется, открытый ключ доступен по логину
+		_, _, ok, err := stor.GetPublicKey(ctx, "bob")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		require.NoError(t, stor.SetKeyPair(ctx, "second", share.KeyPair{PublicKey: []byte("old"), EncryptedPrivateKey: []byte("private")}))
+		require.NoError(t, stor.SetKeyPair(ctx, "second", share.KeyPair{PublicKey: []byte("public"), EncryptedPrivateKey: []byte("private")}))
+		keys, ok, err := stor.GetKeyPair(ctx, "second")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, share.KeyPair{PublicKey: []byte("public"), EncryptedPrivateKey: []byte("private")}, keys)
+
+		id, key, ok, err := stor.GetPublicKey(ctx, "bob")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "second", id)
+		assert.Equal(t, []byte("public"), key)
+
+		_, ok, err = stor.GetKeyPair(ctx, "first")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		_, _, ok, err = stor.GetPublicKey(ctx, "carol")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+	{
+		// Создание общей записи, повторно поделиться той же записью с тем же получателем нельзя
+		sh := share.Share{ID: "share", Name: "card", OwnerID: "first", RecipientID: "second", Permission: share.Write,
+			OwnerKey: []byte("owner key"), RecipientKey: []byte("recipient key"), Data: []byte("data")}
+		ok, err := stor.CreateShare(ctx, sh)
+		require.NoError(t, err)
+		require.True(t, ok)
+		sh.ID = "other"
+		ok, err = stor.CreateShare(ctx, sh)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		got, ok, err := stor.GetShare(ctx, "share")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "alice", got.Owner)
+		assert.Equal(t, "bob", got.Recipient)
+		assert.Equal(t, int64(1), got.Version)
+		assert.False(t, got.Accepted)
+		assert.Equal(t, []byte("data"), got.Data)
+
+		for _, user := range []string{"first", "second"} {
+			shares, err := stor.GetShares(ctx, user)
+			require.NoError(t, err)
+			require.Len(t, shares, 1)
+			assert.Equal(t, "share", shares[0].ID)
+		}
+		shares, err := stor.GetShares(ctx, "third")
+		require.NoError(t, err)
+		assert.Empty(t, shares)
+	}
+	{
+		// Принятие и изменение содержимого с проверкой версии
+		ok, err := stor.AcceptShare(ctx, "share")
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		version, ok, err := stor.UpdateShare(ctx, "share", []byte("new data"), 1)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, int64(2), version)
+		_, ok, err = stor.UpdateShare(ctx, "share", []byte("stale data"), 1)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		got, _, err := stor.GetShare(ctx, "share")
+		require.NoError(t, err)
+		assert.True(t, got.Accepted)
+		assert.Equal(t, []byte("new data"), got.Data)
+		assert.Equal(t, int64(2), got.Version)
+	}
+	{
+		// Удаление общей записи
+		ok, err := stor.DeleteShare(ctx, "share")
+		require.NoError(t, err)
+		require.True(t, ok)
+		_, ok, err = stor.GetShare(ctx, "share")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = stor.DeleteShare(ctx, "share")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = stor.AcceptShare(ctx, "share")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		_, ok, err = stor.UpdateShare(ctx, "share", []byte("data"), 2)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+	{
+		// Удаление учетной записи удаляет ключевую пару и общие записи пользователя
+		ok, err := stor.CreateShare(ctx, share.Share{ID: "share", Name: "card", OwnerID: "first", RecipientID: "second", Permission: share.Read,
+			OwnerKey: []byte("owner key"), RecipientKey: []byte("recipient key"), Data: []byte("data")})
+		require.NoError(t, err)
+		require.True(t, ok)
+		ok, err = stor.DeleteUser(ctx, "second")
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		_, ok, err = stor.GetKeyPair(ctx, "second")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		shares, err := stor.GetShares(ctx, "first")
+		require.NoError(t, err)
+		assert.Empty(t, shares)
+	}
+	{
+		// Контекст уже отменен
+		ctxExc, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.Error(t, stor.SetKeyPair(ctxExc, "first", share.KeyPair{}))
+		_, _, err := stor.GetKeyPair(ctxExc, "first")
+		require.Error(t, err)
+		_, _, _, err = stor.GetPublicKey(ctxExc, "alice")
+		require.Error(t, err)
+		_, err = stor.CreateShare(ctxExc, share.Share{ID: "share"})
+		require.Error(t, err)
+		_, err = stor.GetShares(ctxExc, "first")
+		require.Error(t, err)
+		_, _, err = stor.GetShare(ctxExc, "share")
+		require.Error(t, err)
+		_, err = stor.AcceptShare(ctxExc, "share")
+		require.Error(t, err)
+		_, _, err = stor.UpdateShare(ctxExc, "share", nil, 1)
+		require.Error(t, err)
+		_, err = stor.DeleteShare(ctxExc, "share")
+		require.Error(t, err)
+	}
+}
+
+func TestOrgs(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+	require.NoError(t, stor.Register(ctx, "alice", "hash", "first"))
+	require.NoError(t, stor.Register(ctx, "bob", "hash", "second"))
+	require.NoError(t, stor.Register(ctx, "carol", "hash", "third"))
+
+	{
+		// Создание организации, создатель становится владельцем с первой версией ключа коллекции
+		ok, err := stor.CreateOrg(ctx, org.Org{ID: "org", Name: "team"}, org.Member{UserID: "first", Key: []byte("alice key")})
+		require.NoError(t, err)
+		require.True(t, ok)
+		ok, err = stor.CreateOrg(ctx, org.Org{ID: "org", Name: "other"}, org.Member{UserID: "second", Key: []byte("bob key")})
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		got, ok, err := stor.GetOrg(ctx, "org", "first")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "team", got.Name)
+		assert.Equal(t, org.Owner, got.Role)
+		assert.Equal(t, int64(1), got.KeyVersion)
+		assert.Equal(t, []byte("alice key"), got.Key)
+		_, ok, err = stor.GetOrg(ctx, "org", "second")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+	{
+		// Участник добавляется только с текущей версией ключа и только один раз
+		ok, err := stor.AddMember(ctx, "org", org.Member{UserID: "second", Role: org.Editor, Key: []byte("bob key"), KeyVersion: 2})
+		require.NoError(t, err)
+		assert.False(t, ok)
+		for _, m := range []org.Member{
+			{UserID: "second", Role: org.Editor, Key: []byte("bob key"), KeyVersion: 1},
+			{UserID: "third", Role: org.Viewer, Key: []byte("carol key"), KeyVersion: 1},
+		} {
+			ok, err = stor.AddMember(ctx, "org", m)
+			require.NoError(t, err)
+			require.True(t, ok)
+		}
+		ok, err = stor.AddMember(ctx, "org", org.Member{UserID: "third", Role: org.Admin, Key: []byte("carol key"), KeyVersion: 1})
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		ok, err = stor.SetRole(ctx, "org", "third", org.Admin)
+		require.NoError(t, err)
+		require.True(t, ok)
+		ok, err = stor.SetRole(ctx, "org", "fourth", org.Admin)
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		members, err := stor.GetMembers(ctx, "org")
+		require.NoError(t, err)
+		require.Len(t, members, 3)
+		assert.Equal(t, org.Member{Login: "alice", Role: org.Owner, Key: []byte("alice key"), KeyVersion: 1, UserID: "first"}, members[0])
+		assert.Equal(t, org.Member{Login: "bob", Role: org.Editor, Key: []byte("bob key"), KeyVersion: 1, UserID: "second"}, members[1])
+		assert.Equal(t, org.Member{Login: "carol", Role: org.Admin, Key: []byte("carol key"), KeyVersion: 1, UserID: "third"}, members[2])
+
+		orgs, err := stor.GetOrgs(ctx, "second")
+		require.NoError(t, err)
+		require.Len(t, orgs, 1)
+		assert.Equal(t, org.Editor, orgs[0].Role)
+		assert.Equal(t, []byte("bob key"), orgs[0].Key)
+	}
+	{
+		// Записи коллекции изменяются с проверкой версии записи и версии ключа
+		version, ok, err := stor.PutItem(ctx, "org", org.Item{Name: "db", Data: []byte("data"), KeyVersion: 1})
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, int64(1), version)
+		_, ok, err = stor.PutItem(ctx, "org", org.Item{Name: "db", Data: []byte("data"), KeyVersion: 1})
+		require.NoError(t, err)
+		assert.False(t, ok)
+		version, ok, err = stor.PutItem(ctx, "org", org.Item{Name: "db", Data: []byte("new data"), KeyVersion: 1, Version: 1})
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, int64(2), version)
+		_, ok, err = stor.PutItem(ctx, "org", org.Item{Name: "card", Data: []byte("data"), KeyVersion: 2})
+		require.NoError(t, err)
+		assert.False(t, ok)
+		_, ok, err = stor.PutItem(ctx, "org", org.Item{Name: "card", Data: []byte("card"), KeyVersion: 1})
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = stor.DeleteItem(ctx, "org", "card", 2)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = stor.DeleteItem(ctx, "org", "card", 1)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		items, err := stor.GetItems(ctx, "org")
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "db", items[0].Name)
+		assert.Equal(t, []byte("new data"), items[0].Data)
+		assert.Equal(t, int64(2), items[0].Version)
+	}
+	{
+		// Замена ключа с удалением участника требует ключей всех оставшихся участников и всех записей коллекции
+		rotation := org.Rotation{
+			KeyVersion: 2,
+			Keys:       map[string][]byte{"first": []byte("alice key 2"), "third": []byte("carol key 2")},
+			Items:      []org.Item{{Name: "db", Data: []byte("rotated data"), Version: 2}},
+		}
+		tests := []struct {
+			name   string
+			remove string
+			change func(r *org.Rotation)
+		}{
+			{name: "key of removed member", remove: "second", change: func(r *org.Rotation) { r.Keys["second"] = []byte("bob key 2") }},
+			{name: "missing member key", remove: "", change: func(*org.Rotation) {}},
+			{name: "unknown removed member", remove: "fourth", change: func(*org.Rotation) {}},
+			{name: "stale key version", remove: "second", change: func(r *org.Rotation) { r.KeyVersion = 1 }},
+			{name: "stale item", remove: "second", change: func(r *org.Rotation) { r.Items = []org.Item{{Name: "db", Version: 1}} }},
+			{name: "missing item", remove: "second", change: func(r *org.Rotation) { r.Items = nil }},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				r := rotation
+				r.Keys = map[string][]byte{"first": []byte("alice key 2"), "third": []byte("carol key 2")}
+				tt.change(&r)
+				ok, err := stor.RotateKey(ctx, "org", tt.remove, r)
+				require.NoError(t, err)
+				assert.False(t, ok)
+			})
+		}
+
+		ok, err := stor.RotateKey(ctx, "org", "second", rotation)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		_, ok, err = stor.GetOrg(ctx, "org", "second")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		got, ok, err := stor.GetOrg(ctx, "org", "third")
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, int64(2), got.KeyVersion)
+		assert.Equal(t, []byte("carol key 2"), got.Key)
+		items, err := stor.GetItems(ctx, "org")
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, []byte("rotated data"), items[0].Data)
+		assert.Equal(t, int64(2), items[0].KeyVersion)
+		assert.Equal(t, int64(3), items[0].Version)
+	}
+	{
+		// Удаление учетной записи участника удаляет его из организации, а владельца - удаляет организацию
+		ok, err := stor.DeleteUser(ctx, "third")
+		require.NoError(t, err)
+		require.True(t, ok)
+		members, err := stor.GetMembers(ctx, "org")
+		require.NoError(t, err)
+		require.Len(t, members, 1)
+
+		ok, err = stor.DeleteUser(ctx, "first")
+		require.NoError(t, err)
+		require.True(t, ok)
+		orgs, err := stor.GetOrgs(ctx, "first")
+		require.NoError(t, err)
+		assert.Empty(t, orgs)
+		items, err := stor.GetItems(ctx, "org")
+		require.NoError(t, err)
+		assert.Empty(t, items)
+		ok, err = stor.DeleteOrg(ctx, "org")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+	{
+		// Удаление организации
+		ok, err := stor.CreateOrg(ctx, org.Org{ID: "org", Name: "team"}, org.Member{UserID: "second", Key: []byte("bob key")})
+		require.NoError(t, err)
+		require.True(t, ok)
+		ok, err = stor.DeleteOrg(ctx, "org")
+		require.NoError(t, err)
+		require.True(t, ok)
+		orgs, err := stor.GetOrgs(ctx, "second")
+		require.NoError(t, err)
+		assert.Empty(t, orgs)
+	}
+	{
+		// Контекст уже отменен
+		ctxExc, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := stor.CreateOrg(ctxExc, org.Org{ID: "org"}, org.Member{UserID: "second"})
+		require.Error(t, err)
+		_, err = stor.GetOrgs(ctxExc, "second")
+		require.Error(t, err)
+		_, _, err = stor.GetOrg(ctxExc, "org", "second")
+		require.Error(t, err)
+		_, err = stor.DeleteOrg(ctxExc, "org")
+		require.Error(t, err)
+		_, err = stor.GetMembers(ctxExc, "org")
+		require.Error(t, err)
+		_, err = stor.AddMember(ctxExc, "org", org.Member{})
+		require.Error(t, err)
+		_, err = stor.SetRole(ctxExc, "org", "second", org.Viewer)
+		require.Error(t, err)
+		_, err = stor.RotateKey(ctxExc, "org", "", org.Rotation{})
+		require.Error(t, err)
+		_, err = stor.GetItems(ctxExc, "org")
+		require.Error(t, err)
+		_, _, err = stor.PutItem(ctxExc, "org", org.Item{})
+		require.Error(t, err)
+		_, err = stor.DeleteItem(ctxExc, "org", "db", 1)
+		require.Error(t, err)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	stor := NewStore()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("name %d", i)
+			_, err := stor.AddEncryptedData(ctx, "user id", data.EncryptedData{EncryptedData: []byte("data"), Name: name}, data.SAVED)
+			assert.NoError(t, err)
+			_, err = stor.AppendEncryptedData(ctx, "user id", data.EncryptedData{EncryptedData: []byte("data"), Name: name})
+			assert.NoError(t, err)
+			_, err = stor.GetAllEncryptedData(ctx, "user id")
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	get, err := stor.GetAllEncryptedData(ctx, "user id")
+	require.NoError(t, err)
+	assert.Equal(t, 20, len(get))
+}
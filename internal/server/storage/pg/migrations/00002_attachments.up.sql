@@ -0,0 +1,36 @@
+BEGIN TRANSACTION;
+
+-- Создание таблицы chunks для хранения зашифрованных частей вложений. Части адресуются хэшем зашифрованного
+-- содержимого, поэтому одинаковые части вложений пользователя хранятся один раз. Время сохранения части без ссылок
+-- нужно для удаления частей незавершенных загрузок
+CREATE TABLE IF NOT EXISTS chunks (
+    user_id VARCHAR(256) NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+    chunk BYTEA NOT NULL,
+    refs INT NOT NULL DEFAULT 0,
+    saved_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, hash)
+);
+
+-- Создание индекса для поиска частей без ссылок
+CREATE INDEX IF NOT EXISTS chunks_unused ON chunks (saved_at) WHERE refs <= 0;
+
+-- Создание таблицы attachments для хранения списков частей вложений
+CREATE TABLE IF NOT EXISTS attachments (
+    user_id VARCHAR(256) NOT NULL,
+    attachment_id VARCHAR(128) NOT NULL,
+    chunks VARCHAR(64)[] NOT NULL,
+    PRIMARY KEY (user_id, attachment_id)
+);
+
+-- Создание таблицы attachment_refs для хранения ссылок записей на вложения. Ссылка одной записи хранится один раз,
+-- поэтому повторная передача ссылки не изменяет количество ссылок на вложение
+CREATE TABLE IF NOT EXISTS attachment_refs (
+    user_id VARCHAR(256) NOT NULL,
+    attachment_id VARCHAR(128) NOT NULL,
+    record_id VARCHAR(64) NOT NULL,
+    PRIMARY KEY (user_id, attachment_id, record_id),
+    FOREIGN KEY (user_id, attachment_id) REFERENCES attachments (user_id, attachment_id) ON DELETE CASCADE
+);
+
+COMMIT;
@@ -0,0 +1,29 @@
+BEGIN TRANSACTION;
+
+-- Создание таблицы audit_log для журнала аудита. Зашифрованные данные пользователей в журнал не попадают
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    event_type VARCHAR(32) NOT NULL,
+    user_id VARCHAR(256) NOT NULL DEFAULT '',
+    login VARCHAR(128) NOT NULL DEFAULT '',
+    record_id VARCHAR(128) NOT NULL DEFAULT '',
+    device VARCHAR(256) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+-- Индекс для выборки журнала пользователя по времени
+CREATE INDEX IF NOT EXISTS audit_log_user_time ON audit_log (user_id, created_at);
+
+-- Журнал только дополняется: изменение и удаление событий запрещены
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN TRANSACTION;
+
+-- Состояние сессий пользователя: блокировка учетной записи администратором и момент принудительного выхода,
+-- токены выданные до которого не принимаются
+ALTER TABLE auth ADD COLUMN IF NOT EXISTS disabled BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE auth ADD COLUMN IF NOT EXISTS logout_at TIMESTAMPTZ;
+
+-- Индекс по id для проверки сессий при каждом запросе пользователя
+CREATE UNIQUE INDEX IF NOT EXISTS auth_id ON auth (id);
+
+COMMIT;
@@ -0,0 +1,30 @@
+BEGIN TRANSACTION;
+
+-- Ключевые пары пользователей для обмена записями. Закрытый ключ зашифрован ключом хранилища пользователя
+CREATE TABLE IF NOT EXISTS key_pairs (
+    user_id VARCHAR(256) PRIMARY KEY,
+    public_key BYTEA NOT NULL,
+    encrypted_private_key BYTEA NOT NULL
+);
+
+-- Общие записи: содержимое зашифровано ключом записи, ключ записи - открытыми ключами владельца и получателя
+CREATE TABLE IF NOT EXISTS shares (
+    id VARCHAR(128) PRIMARY KEY,
+    name VARCHAR(128) NOT NULL,
+    owner_id VARCHAR(256) NOT NULL,
+    recipient_id VARCHAR(256) NOT NULL,
+    permission VARCHAR(16) NOT NULL,
+    accepted BOOLEAN NOT NULL DEFAULT false,
+    owner_key BYTEA NOT NULL,
+    recipient_key BYTEA NOT NULL,
+    data BYTEA NOT NULL,
+    version BIGINT NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT unique_share UNIQUE (owner_id, name, recipient_id)
+);
+
+-- Индекс для выборки записей, которыми поделились с пользователем
+CREATE INDEX IF NOT EXISTS shares_recipient ON shares (recipient_id);
+
+COMMIT;
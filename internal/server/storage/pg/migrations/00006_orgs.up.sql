@@ -0,0 +1,35 @@
+BEGIN TRANSACTION;
+
+-- Организации. key_version - текущая версия ключа коллекции организации
+CREATE TABLE IF NOT EXISTS orgs (
+    id VARCHAR(128) PRIMARY KEY,
+    name VARCHAR(128) NOT NULL,
+    key_version BIGINT NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Участники организаций: ключ коллекции зашифрован открытым ключом участника
+CREATE TABLE IF NOT EXISTS org_members (
+    org_id VARCHAR(128) NOT NULL,
+    user_id VARCHAR(256) NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    encrypted_key BYTEA NOT NULL,
+    key_version BIGINT NOT NULL,
+    PRIMARY KEY (org_id, user_id)
+);
+
+-- Индекс для выборки организаций пользователя
+CREATE INDEX IF NOT EXISTS org_members_user ON org_members (user_id);
+
+-- Записи коллекций организаций, зашифрованные ключом коллекции версии key_version
+CREATE TABLE IF NOT EXISTS org_items (
+    org_id VARCHAR(128) NOT NULL,
+    name VARCHAR(128) NOT NULL,
+    data BYTEA NOT NULL,
+    key_version BIGINT NOT NULL,
+    version BIGINT NOT NULL DEFAULT 1,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (org_id, name)
+);
+
+COMMIT;
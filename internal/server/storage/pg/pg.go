@@ -6,15 +6,26 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
+	"github.com/abezemskiy/gophkeeper/internal/common/tracing"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/account"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/audit"
 	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
 	"github.com/abezemskiy/gophkeeper/internal/repositories/identity"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/org"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/share"
+	"github.com/abezemskiy/gophkeeper/internal/server/quota"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Store - реализует интерфейс storage.IStorage и позволяет взаимодествовать с СУБД PostgreSQL.
@@ -46,6 +57,11 @@ func NewStore(ctx context.Context, dsn string) (*Store, error) {
 	}, nil
 }
 
+// startSpan - функция для создания спана запроса к БД operation.
+func startSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "pg."+operation, semconv.DBSystemPostgreSQL, semconv.DBOperationNameKey.String(operation))
+}
+
 //go:embed migrations/*.sql
 var migrationsDir embed.FS
 
@@ -67,9 +83,25 @@ func runMigrations(dsn string) error {
 	return nil
 }
 
+// Ping - проверяет соединение с БД. Используется при проверке готовности сервера.
+func (s Store) Ping(ctx context.Context) error {
+	if err := s.conn.PingContext(ctx); err != nil {
+		return fmt.Errorf("ping database error, %w", err)
+	}
+	return nil
+}
+
+// Stats - возвращает статистику пула соединений с БД.
+func (s Store) Stats() sql.DBStats {
+	return s.conn.Stats()
+}
+
 // Disable - очищает БД, удаляя записи из таблиц.
 // Метод необходим для тестирования, чтобы в процессе удалять тестовые записи.
 func (s Store) Disable(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "Disable")
+	defer span.End()
+
 	// запускаем транзакцию
 	tx, err := s.conn.BeginTx(ctx, nil)
 	if err != nil {
@@ -94,12 +126,47 @@ func (s Store) Disable(ctx context.Context) error {
 		return fmt.Errorf("truncate table user_data error, %w", err)
 	}
 
+	// удаляю все записи в таблицах chunks, attachments и attachment_refs----------------------
+	_, err = tx.ExecContext(ctx, `
+		TRUNCATE TABLE chunks, attachments, attachment_refs
+	`)
+	if err != nil {
+		return fmt.Errorf("truncate tables chunks, attachments and attachment_refs error, %w", err)
+	}
+
+	// удаляю ключевые пары и общие записи----------------------
+	_, err = tx.ExecContext(ctx, `
+		TRUNCATE TABLE key_pairs, shares
+	`)
+	if err != nil {
+		return fmt.Errorf("truncate tables key_pairs and shares error, %w", err)
+	}
+
+	// удаляю организации, их участников и записи коллекций----------------------
+	_, err = tx.ExecContext(ctx, `
+		TRUNCATE TABLE orgs, org_members, org_items
+	`)
+	if err != nil {
+		return fmt.Errorf("truncate tables orgs, org_members and org_items error, %w", err)
+	}
+
+	// удаляю все записи журнала аудита. Триггер журнала запрещает удаление отдельных событий, но не очистку таблицы
+	_, err = tx.ExecContext(ctx, `
+		TRUNCATE TABLE audit_log
+	`)
+	if err != nil {
+		return fmt.Errorf("truncate table audit_log error, %w", err)
+	}
+
 	// коммитим транзакцию
 	return tx.Commit()
 }
 
 // Register - сохраняет в базу данные нового пользователя.
 func (s Store) Register(ctx context.Context, login, hash, id string) error {
+	ctx, span := startSpan(ctx, "Register")
+	defer span.End()
+
 	query := `
 	INSERT INTO auth (login, hash, id)
 	VALUES ($1, $2, $3)
@@ -116,9 +183,13 @@ func (s Store) Register(ctx context.Context, login, hash, id string) error {
 // Authorize - получаю авторизационные данные пользователя (хэш) по логину.
 // В случае, если пользователь с переданным логином не найден, возвращается ошибка.
 func (s Store) Authorize(ctx context.Context, login string) (data identity.AuthorizationData, ok bool, err error) {
+	ctx, span := startSpan(ctx, "Authorize")
+	defer span.End()
+
 	query := `
 		SELECT  hash,
-				id
+				id,
+				disabled
 		FROM auth
 		WHERE login = $1
 	`
@@ -130,7 +201,7 @@ func (s Store) Authorize(ctx context.Context, login string) (data identity.Autho
 	defer stmt.Close()
 	row := stmt.QueryRowContext(ctx, login)
 
-	err = row.Scan(&data.Hash, &data.ID)
+	err = row.Scan(&data.Hash, &data.ID, &data.Disabled)
 	if err != nil {
 		// пользователь не найден
 		err = nil
@@ -143,18 +214,31 @@ func (s Store) Authorize(ctx context.Context, login string) (data identity.Autho
 
 // AddEncryptedData - метод для добавления уникальных зашифрованныч данных по id в хранилище.
 // В случае если данные не уникальны, возвращается false.
+// Если изменение превысит квоту пользователя, возвращается ошибка quota.ErrQuotaExceeded.
 func (s Store) AddEncryptedData(ctx context.Context, idUser string, userData data.EncryptedData, status int) (bool, error) {
-	query := `
-		INSERT INTO user_data (user_id, data_name, encrypted_data, status)
-		VALUES ($1, $2, $3, $4)
-	`
-	stmt, err := s.conn.PrepareContext(ctx, query)
+	ctx, span := startSpan(ctx, "AddEncryptedData")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
 	if err != nil {
-		return false, fmt.Errorf("prepare context error, %w", err)
+		return false, fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	if quota.Enabled() {
+		usage, err := lockUsage(ctx, tx, idUser)
+		if err != nil {
+			return false, err
+		}
+		if err := quota.CheckAdd(usage, int64(len(userData.EncryptedData))); err != nil {
+			return false, err
+		}
 	}
-	defer stmt.Close()
-	_, err = stmt.ExecContext(ctx, idUser, userData.Name, [][]byte{userData.EncryptedData}, status)
 
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_data (user_id, data_name, encrypted_data, status)
+		VALUES ($1, $2, $3, $4)
+	`, idUser, userData.Name, [][]byte{userData.EncryptedData}, status)
 	if err != nil {
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
 			// Код ошибки 23505 - unique_violation
@@ -163,25 +247,46 @@ func (s Store) AddEncryptedData(ctx context.Context, idUser string, userData dat
 		}
 		return false, fmt.Errorf("query execution error, %w", err)
 	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction error, %w", err)
+	}
 	return true, nil
 }
 
 // ReplaceEncryptedData - метод для замены старых данных значениями новых.
 // В случае попытки заменить данные, когда данные с текущим id полязователя и именем ещё не загружены в хранилище
 // возвращается false.
+// Если изменение превысит квоту пользователя, возвращается ошибка quota.ErrQuotaExceeded.
 func (s Store) ReplaceEncryptedData(ctx context.Context, idUser string, userData data.EncryptedData, status int) (bool, error) {
-	query := `
-	UPDATE user_data
-	SET encrypted_data = $3, status = $4
-	WHERE user_id = $1 AND data_name = $2
-`
-	stmt, err := s.conn.PrepareContext(ctx, query)
+	ctx, span := startSpan(ctx, "ReplaceEncryptedData")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
 	if err != nil {
-		return false, fmt.Errorf("prepare context error, %w", err)
+		return false, fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	if quota.Enabled() {
+		usage, err := lockUsage(ctx, tx, idUser)
+		if err != nil {
+			return false, err
+		}
+		record, ok, err := queryRecordUsage(ctx, tx, idUser, userData.Name)
+		if err != nil || !ok {
+			return false, err
+		}
+		if err := quota.CheckReplace(usage, record, int64(len(userData.EncryptedData))); err != nil {
+			return false, err
+		}
 	}
-	defer stmt.Close()
-	result, err := stmt.ExecContext(ctx, idUser, userData.Name, [][]byte{userData.EncryptedData}, status)
 
+	result, err := tx.ExecContext(ctx, `
+		UPDATE user_data
+		SET encrypted_data = $3, status = $4
+		WHERE user_id = $1 AND data_name = $2
+	`, idUser, userData.Name, [][]byte{userData.EncryptedData}, status)
 	if err != nil {
 		return false, fmt.Errorf("query execution error, %w", err)
 	}
@@ -191,11 +296,18 @@ func (s Store) ReplaceEncryptedData(ctx context.Context, idUser string, userData
 		// попытка обновить данные, которых не существует
 		return false, nil
 	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction error, %w", err)
+	}
 	return true, nil
 }
 
 // GetAllEncryptedData - метод для выгрузки всех зашифрованных данных конкретного пользователя.
 func (s Store) GetAllEncryptedData(ctx context.Context, idUser string) ([][]data.EncryptedData, error) {
+	ctx, span := startSpan(ctx, "GetAllEncryptedData")
+	defer span.End()
+
 	query := `
 	SELECT  data_name,
 			encrypted_data
@@ -246,6 +358,9 @@ func (s Store) GetAllEncryptedData(ctx context.Context, idUser string) ([][]data
 
 // GetEncryptedDataByStatus - метод для выгрузки всех зашифрованных данных конкретного пользователя с определенным статусом.
 func (s Store) GetEncryptedDataByStatus(ctx context.Context, idUser string, status int) ([][]data.EncryptedData, error) {
+	ctx, span := startSpan(ctx, "GetEncryptedDataByStatus")
+	defer span.End()
+
 	query := `
 	SELECT  data_name,
 			encrypted_data
@@ -297,6 +412,9 @@ func (s Store) GetEncryptedDataByStatus(ctx context.Context, idUser string, stat
 // DeleteEncryptedData - метод для удаления данных в хранилище по id пользователя и имени данных.
 // Если происходит попытка удалить несуществующие данные, возвращается false.
 func (s Store) DeleteEncryptedData(ctx context.Context, idUser, dataName string) (bool, error) {
+	ctx, span := startSpan(ctx, "DeleteEncryptedData")
+	defer span.End()
+
 	query := `
 	DELETE FROM user_data
 	WHERE user_id = $1 AND data_name = $2
@@ -320,20 +438,42 @@ func (s Store) DeleteEncryptedData(ctx context.Context, idUser, dataName string)
 }
 
 // AppendEncryptedData - метод для сохранения дополнительной версии существующих данных в случае конфликта.
+// Если такая версия данных уже сохранена, данные не изменяются. Это делает повторную отправку той же версии,
+// например после потери ответа сервера, безопасной и не приводит к ложному конфликту.
+// Если изменение превысит квоту пользователя, возвращается ошибка quota.ErrQuotaExceeded.
 func (s Store) AppendEncryptedData(ctx context.Context, idUser string, userData data.EncryptedData) (bool, error) {
-	query := `
+	ctx, span := startSpan(ctx, "AppendEncryptedData")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	if quota.Enabled() {
+		usage, err := lockUsage(ctx, tx, idUser)
+		if err != nil {
+			return false, err
+		}
+		record, ok, err := queryRecordUsage(ctx, tx, idUser, userData.Name)
+		if err != nil || !ok {
+			return false, err
+		}
+		if err := quota.CheckAppend(usage, record, int64(len(userData.EncryptedData))); err != nil {
+			return false, err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `
 	UPDATE user_data
 	SET 
-    	encrypted_data = array_append(encrypted_data, $3), -- Добавление новой версии данных в массив
-    	status = $4 									   -- Обновление статуса
+    	encrypted_data = CASE WHEN $3 = ANY(encrypted_data) THEN encrypted_data
+			ELSE array_append(encrypted_data, $3) END,    -- Добавление новой версии данных в массив
+    	status = CASE WHEN $3 = ANY(encrypted_data) THEN status
+			ELSE $4 END                                   -- Обновление статуса
 	WHERE user_id = $1 AND data_name = $2
-`
-	stmt, err := s.conn.PrepareContext(ctx, query)
-	if err != nil {
-		return false, fmt.Errorf("prepare context error, %w", err)
-	}
-	defer stmt.Close()
-	result, err := stmt.ExecContext(ctx, idUser, userData.Name, userData.EncryptedData, data.CONFLICT)
+`, idUser, userData.Name, userData.EncryptedData, data.CONFLICT)
 	if err != nil {
 		return false, fmt.Errorf("query execution error, %w", err)
 	}
@@ -343,5 +483,1430 @@ func (s Store) AppendEncryptedData(ctx context.Context, idUser string, userData
 		// Запись не найдена, попытка дополнить данные, которых не существует.
 		return false, nil
 	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction error, %w", err)
+	}
 	return true, nil
 }
+
+// SaveChunk - метод для сохранения части вложения по её хэшу. Если часть уже сохранена, данные не изменяются,
+// поэтому повторная отправка части при возобновлении загрузки безопасна. Повторная отправка части без ссылок
+// продлевает срок её хранения.
+// Если изменение превысит квоту пользователя, возвращается ошибка quota.ErrQuotaExceeded.
+func (s Store) SaveChunk(ctx context.Context, idUser, hash string, chunk []byte) error {
+	ctx, span := startSpan(ctx, "SaveChunk")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	if quota.Enabled() {
+		usage, err := lockUsage(ctx, tx, idUser)
+		if err != nil {
+			return err
+		}
+		// Повторное сохранение уже сохраненной части не увеличивает объем хранилища и разрешено при превышении квоты
+		missing, err := missingChunks(ctx, tx, idUser, []string{hash}, false)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			if err := quota.CheckBytes(usage, int64(len(chunk))); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO chunks (user_id, hash, chunk)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, hash) DO UPDATE
+		SET saved_at = now()
+		WHERE chunks.refs <= 0
+	`, idUser, hash, chunk)
+	if err != nil {
+		return fmt.Errorf("query execution error, %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction error, %w", err)
+	}
+	return nil
+}
+
+// GetChunk - метод для получения части вложения по хэшу. Если часть не найдена, возвращается false.
+func (s Store) GetChunk(ctx context.Context, idUser, hash string) ([]byte, bool, error) {
+	ctx, span := startSpan(ctx, "GetChunk")
+	defer span.End()
+
+	query := `
+	SELECT chunk
+	FROM chunks
+	WHERE user_id = $1 AND hash = $2
+`
+	stmt, err := s.conn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, false, fmt.Errorf("prepare context error, %w", err)
+	}
+	defer stmt.Close()
+
+	var chunk []byte
+	err = stmt.QueryRowContext(ctx, idUser, hash).Scan(&chunk)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("scan error, %w", err)
+	}
+	return chunk, true, nil
+}
+
+// GetMissingChunks - метод для получения хэшей частей, которые не сохранены в хранилище, в порядке их передачи.
+func (s Store) GetMissingChunks(ctx context.Context, idUser string, hashes []string) ([]string, error) {
+	ctx, span := startSpan(ctx, "GetMissingChunks")
+	defer span.End()
+
+	return missingChunks(ctx, s.conn, idUser, hashes, false)
+}
+
+// AddAttachmentRef - метод для создания вложения из сохраненных частей или добавления ссылки записи record
+// на существующее вложение. Повторное добавление ссылки той же записи не изменяет вложение. Возвращает количество
+// ссылок на вложение. Если часть вложения не сохранена, возвращается storage.ErrMissingChunks.
+// Если вложение уже существует с другим списком частей, возвращается storage.ErrAttachmentMismatch.
+func (s Store) AddAttachmentRef(ctx context.Context, idUser, attachmentID, record string, chunks []string) (int, error) {
+	ctx, span := startSpan(ctx, "AddAttachmentRef")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокирую существующее вложение до конца транзакции
+	var stored []string
+	err = tx.QueryRowContext(ctx, `
+		SELECT chunks
+		FROM attachments
+		WHERE user_id = $1 AND attachment_id = $2
+		FOR UPDATE
+	`, idUser, attachmentID).Scan(pq.Array(&stored))
+
+	switch {
+	case err == nil:
+		// Вложение уже существует, добавляю ссылку
+		if !slices.Equal(stored, chunks) {
+			return 0, storage.ErrAttachmentMismatch
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		// Создаю новое вложение из сохраненных частей. Части блокируются, чтобы сборщик частей без ссылок
+		// не удалил их до увеличения количества ссылок
+		missing, err := missingChunks(ctx, tx, idUser, chunks, true)
+		if err != nil {
+			return 0, err
+		}
+		if len(missing) > 0 {
+			return 0, storage.ErrMissingChunks
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO attachments (user_id, attachment_id, chunks)
+			VALUES ($1, $2, $3)
+		`, idUser, attachmentID, chunks)
+		if err != nil {
+			return 0, fmt.Errorf("insert attachment error, %w", err)
+		}
+		// Каждое вхождение части во вложение увеличивает количество ссылок на часть
+		_, err = tx.ExecContext(ctx, `
+			UPDATE chunks c
+			SET refs = c.refs + t.n
+			FROM (SELECT h, COUNT(*) AS n FROM unnest($2::VARCHAR[]) AS h GROUP BY h) t
+			WHERE c.user_id = $1 AND c.hash = t.h
+		`, idUser, chunks)
+		if err != nil {
+			return 0, fmt.Errorf("update chunk refs error, %w", err)
+		}
+	default:
+		return 0, fmt.Errorf("select attachment error, %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO attachment_refs (user_id, attachment_id, record_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, idUser, attachmentID, record)
+	if err != nil {
+		return 0, fmt.Errorf("insert attachment ref error, %w", err)
+	}
+	refs, err := attachmentRefs(ctx, tx, idUser, attachmentID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction error, %w", err)
+	}
+	return refs, nil
+}
+
+// attachmentRefs - функция для подсчета ссылок записей на вложение.
+func attachmentRefs(ctx context.Context, q querier, idUser, attachmentID string) (int, error) {
+	var refs int
+	err := q.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM attachment_refs
+		WHERE user_id = $1 AND attachment_id = $2
+	`, idUser, attachmentID).Scan(&refs)
+	if err != nil {
+		return 0, fmt.Errorf("count attachment refs error, %w", err)
+	}
+	return refs, nil
+}
+
+// GetAttachment - метод для получения информации о вложении. Если вложение не найдено, возвращается false.
+func (s Store) GetAttachment(ctx context.Context, idUser, attachmentID string) (data.AttachmentInfo, bool, error) {
+	ctx, span := startSpan(ctx, "GetAttachment")
+	defer span.End()
+
+	query := `
+	SELECT a.chunks, (SELECT COUNT(*) FROM attachment_refs r WHERE r.user_id = a.user_id AND r.attachment_id = a.attachment_id)
+	FROM attachments a
+	WHERE a.user_id = $1 AND a.attachment_id = $2
+`
+	stmt, err := s.conn.PrepareContext(ctx, query)
+	if err != nil {
+		return data.AttachmentInfo{}, false, fmt.Errorf("prepare context error, %w", err)
+	}
+	defer stmt.Close()
+
+	info := data.AttachmentInfo{ID: attachmentID}
+	err = stmt.QueryRowContext(ctx, idUser, attachmentID).Scan(pq.Array(&info.Chunks), &info.Refs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return data.AttachmentInfo{}, false, nil
+	}
+	if err != nil {
+		return data.AttachmentInfo{}, false, fmt.Errorf("scan error, %w", err)
+	}
+	return info, true, nil
+}
+
+// ReleaseAttachment - метод для удаления ссылки записи record на вложение. Возвращает количество оставшихся ссылок.
+// Повторное удаление ссылки той же записи не изменяет вложение. Вложение удаляется вместе с частями,
+// на которые больше нет ссылок, когда удаляется последняя ссылка. Если вложение не найдено, возвращается false.
+func (s Store) ReleaseAttachment(ctx context.Context, idUser, attachmentID, record string) (int, bool, error) {
+	ctx, span := startSpan(ctx, "ReleaseAttachment")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокирую вложение до конца транзакции, чтобы одновременное добавление ссылки не создало ссылку
+	// на удаляемое вложение
+	var chunks []string
+	err = tx.QueryRowContext(ctx, `
+		SELECT chunks
+		FROM attachments
+		WHERE user_id = $1 AND attachment_id = $2
+		FOR UPDATE
+	`, idUser, attachmentID).Scan(pq.Array(&chunks))
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("select attachment error, %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM attachment_refs
+		WHERE user_id = $1 AND attachment_id = $2 AND record_id = $3
+	`, idUser, attachmentID, record)
+	if err != nil {
+		return 0, false, fmt.Errorf("delete attachment ref error, %w", err)
+	}
+	refs, err := attachmentRefs(ctx, tx, idUser, attachmentID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if refs <= 0 {
+		// Удалена последняя ссылка, удаляю вложение и части без ссылок
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM attachments
+			WHERE user_id = $1 AND attachment_id = $2
+		`, idUser, attachmentID)
+		if err != nil {
+			return 0, false, fmt.Errorf("delete attachment error, %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE chunks c
+			SET refs = c.refs - t.n
+			FROM (SELECT h, COUNT(*) AS n FROM unnest($2::VARCHAR[]) AS h GROUP BY h) t
+			WHERE c.user_id = $1 AND c.hash = t.h
+		`, idUser, chunks)
+		if err != nil {
+			return 0, false, fmt.Errorf("update chunk refs error, %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM chunks
+			WHERE user_id = $1 AND hash = ANY($2) AND refs <= 0
+		`, idUser, chunks)
+		if err != nil {
+			return 0, false, fmt.Errorf("delete chunks error, %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("commit transaction error, %w", err)
+	}
+	return refs, true, nil
+}
+
+// DeleteUnusedChunks - метод для удаления частей всех пользователей, на которые не ссылается ни одно вложение
+// и которые сохранены до момента before. Возвращает количество удаленных частей.
+func (s Store) DeleteUnusedChunks(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := startSpan(ctx, "DeleteUnusedChunks")
+	defer span.End()
+
+	query := `
+	DELETE FROM chunks
+	WHERE refs <= 0 AND saved_at < $1
+`
+	stmt, err := s.conn.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("prepare context error, %w", err)
+	}
+	defer stmt.Close()
+	result, err := stmt.ExecContext(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("query execution error, %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected error, %w", err)
+	}
+	return deleted, nil
+}
+
+// GetUsage - метод для получения объема зашифрованных данных и частей вложений пользователя, количества записей
+// и наибольшего количества версий одной записи.
+func (s Store) GetUsage(ctx context.Context, idUser string) (data.Usage, error) {
+	ctx, span := startSpan(ctx, "GetUsage")
+	defer span.End()
+
+	return queryUsage(ctx, s.conn, idUser)
+}
+
+// GetRecordUsage - метод для получения объема и количества версий записи пользователя.
+// Если записи не существует, возвращается false.
+func (s Store) GetRecordUsage(ctx context.Context, idUser, dataName string) (data.RecordUsage, bool, error) {
+	ctx, span := startSpan(ctx, "GetRecordUsage")
+	defer span.End()
+
+	return queryRecordUsage(ctx, s.conn, idUser, dataName)
+}
+
+// AppendAuditEvent - метод для сохранения события журнала аудита. Если время события не задано, используется время СУБД.
+func (s Store) AppendAuditEvent(ctx context.Context, event audit.Event) error {
+	ctx, span := startSpan(ctx, "AppendAuditEvent")
+	defer span.End()
+
+	_, err := s.conn.ExecContext(ctx, `
+		INSERT INTO audit_log (created_at, event_type, user_id, login, record_id, device, ip)
+		VALUES (COALESCE($1, now()), $2, $3, $4, $5, $6, $7)
+	`, nullTime(event.Time), event.Type, event.UserID, event.Login, event.RecordID, event.Device, event.IP)
+	if err != nil {
+		return fmt.Errorf("insert audit event error, %w", err)
+	}
+	return nil
+}
+
+// GetAuditEvents - метод для получения событий журнала аудита по условиям выборки filter.
+func (s Store) GetAuditEvents(ctx context.Context, filter audit.Filter) ([]audit.Event, error) {
+	ctx, span := startSpan(ctx, "GetAuditEvents")
+	defer span.End()
+
+	order := "ASC"
+	if filter.Desc {
+		order = "DESC"
+	}
+	// LIMIT NULL не ограничивает количество строк
+	var limit sql.NullInt64
+	if filter.Limit > 0 {
+		limit = sql.NullInt64{Int64: int64(filter.Limit), Valid: true}
+	}
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT id, created_at, event_type, user_id, login, record_id, device, ip
+		FROM audit_log
+		WHERE ($1 = '' OR user_id = $1)
+			AND ($2::timestamptz IS NULL OR created_at >= $2)
+			AND ($3::timestamptz IS NULL OR created_at < $3)
+		ORDER BY id `+order+`
+		LIMIT $4
+	`, filter.UserID, nullTime(filter.Since), nullTime(filter.Until), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error, %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]audit.Event, 0)
+	for rows.Next() {
+		var e audit.Event
+		err := rows.Scan(&e.ID, &e.Time, &e.Type, &e.UserID, &e.Login, &e.RecordID, &e.Device, &e.IP)
+		if err != nil {
+			return nil, fmt.Errorf("scan error, %w", err)
+		}
+		e.Time = e.Time.UTC()
+		result = append(result, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetSession - метод для получения состояния сессий пользователя. Если пользователь не найден, возвращается false.
+func (s Store) GetSession(ctx context.Context, idUser string) (account.Session, bool, error) {
+	ctx, span := startSpan(ctx, "GetSession")
+	defer span.End()
+
+	var session account.Session
+	var logoutAt sql.NullTime
+	err := s.conn.QueryRowContext(ctx, `
+		SELECT disabled, logout_at
+		FROM auth
+		WHERE id = $1
+	`, idUser).Scan(&session.Disabled, &logoutAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return account.Session{}, false, nil
+	}
+	if err != nil {
+		return account.Session{}, false, fmt.Errorf("scan error, %w", err)
+	}
+	session.LogoutAt = logoutAt.Time
+	return session, true, nil
+}
+
+// ListUsers - метод для получения учетных записей пользователей, упорядоченных по логину, с количеством записей
+// и объемом данных каждого пользователя.
+func (s Store) ListUsers(ctx context.Context) ([]account.User, error) {
+	ctx, span := startSpan(ctx, "ListUsers")
+	defer span.End()
+
+	// Записи коллекций организаций учитываются у владельца организации, как в квоте пользователя
+	ownedBy := `SELECT org_id FROM org_members WHERE user_id = a.id AND role = '` + org.Owner + `'`
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT a.id, a.login, a.disabled,
+			(SELECT COUNT(*) FROM user_data WHERE user_id = a.id),
+			(SELECT COALESCE(SUM(octet_length(v)), 0) FROM user_data, unnest(encrypted_data) AS v WHERE user_id = a.id),
+			(SELECT COALESCE(SUM(octet_length(chunk)), 0) FROM chunks WHERE user_id = a.id),
+			(SELECT COUNT(*) FROM shares WHERE owner_id = a.id),
+			(SELECT COALESCE(SUM(octet_length(data)), 0) FROM shares WHERE owner_id = a.id),
+			(SELECT COUNT(*) FROM org_items WHERE org_id IN (`+ownedBy+`)),
+			(SELECT COALESCE(SUM(octet_length(data)), 0) FROM org_items WHERE org_id IN (`+ownedBy+`))
+		FROM auth a
+		ORDER BY a.login
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error, %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]account.User, 0)
+	for rows.Next() {
+		var u account.User
+		var shares, items int
+		var dataBytes, chunkBytes, shareBytes, itemBytes int64
+		if err := rows.Scan(&u.ID, &u.Login, &u.Disabled, &u.Records, &dataBytes, &chunkBytes, &shares, &shareBytes,
+			&items, &itemBytes); err != nil {
+			return nil, fmt.Errorf("scan error, %w", err)
+		}
+		u.Records += shares + items
+		u.Bytes = dataBytes + chunkBytes + shareBytes + itemBytes
+		result = append(result, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetDisabled - метод для блокировки или разблокировки учетной записи пользователя. Если пользователь не найден,
+// возвращается false.
+func (s Store) SetDisabled(ctx context.Context, idUser string, disabled bool) (bool, error) {
+	ctx, span := startSpan(ctx, "SetDisabled")
+	defer span.End()
+
+	res, err := s.conn.ExecContext(ctx, `
+		UPDATE auth
+		SET disabled = $2
+		WHERE id = $1
+	`, idUser, disabled)
+	if err != nil {
+		return false, fmt.Errorf("query execution error, %w", err)
+	}
+	return affected(res)
+}
+
+// Logout - метод для отзыва токенов пользователя, выданных до момента at. Если пользователь не найден,
+// возвращается false.
+func (s Store) Logout(ctx context.Context, idUser string, at time.Time) (bool, error) {
+	ctx, span := startSpan(ctx, "Logout")
+	defer span.End()
+
+	res, err := s.conn.ExecContext(ctx, `
+		UPDATE auth
+		SET logout_at = $2
+		WHERE id = $1
+	`, idUser, at)
+	if err != nil {
+		return false, fmt.Errorf("query execution error, %w", err)
+	}
+	return affected(res)
+}
+
+// DeleteUser - метод для удаления учетной записи пользователя вместе с данными, вложениями, ключевой парой и общими
+// записями в одной транзакции. Пользователь удаляется из организаций, а организации, владельцем которых он является,
+// удаляются вместе с записями.
+// Журнал аудита не изменяется. Если пользователь не найден, возвращается false.
+func (s Store) DeleteUser(ctx context.Context, idUser string) (bool, error) {
+	ctx, span := startSpan(ctx, "DeleteUser")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM auth
+		WHERE id = $1
+	`, idUser)
+	if err != nil {
+		return false, fmt.Errorf("delete user error, %w", err)
+	}
+	if ok, err := affected(res); err != nil || !ok {
+		return false, err
+	}
+	tables := []string{"user_data", "attachment_refs", "attachments", "chunks", "key_pairs"}
+	for _, table := range tables {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE user_id = $1`, idUser); err != nil {
+			return false, fmt.Errorf("delete from %s error, %w", table, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM shares WHERE owner_id = $1 OR recipient_id = $1`, idUser); err != nil {
+		return false, fmt.Errorf("delete from shares error, %w", err)
+	}
+	owned := `SELECT org_id FROM org_members WHERE user_id = $1 AND role = '` + org.Owner + `'`
+	if _, err := tx.ExecContext(ctx, `DELETE FROM org_items WHERE org_id IN (`+owned+`)`, idUser); err != nil {
+		return false, fmt.Errorf("delete from org_items error, %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM orgs WHERE id IN (`+owned+`)`, idUser); err != nil {
+		return false, fmt.Errorf("delete from orgs error, %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM org_members WHERE user_id = $1 OR org_id IN (`+owned+`)`, idUser); err != nil {
+		return false, fmt.Errorf("delete from org_members error, %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction error, %w", err)
+	}
+	return true, nil
+}
+
+// GetStats - метод для получения статистики хранилища.
+func (s Store) GetStats(ctx context.Context) (account.Stats, error) {
+	ctx, span := startSpan(ctx, "GetStats")
+	defer span.End()
+
+	var stats account.Stats
+	var shares, items int
+	var dataBytes, chunkBytes, shareBytes, itemBytes int64
+	err := s.conn.QueryRowContext(ctx, `
+	SELECT
+		(SELECT COUNT(*) FROM auth),
+		(SELECT COUNT(*) FROM auth WHERE disabled),
+		(SELECT COUNT(*) FROM user_data),
+		(SELECT COALESCE(SUM(COALESCE(array_length(encrypted_data, 1), 0)), 0) FROM user_data),
+		(SELECT COUNT(*) FROM attachments),
+		(SELECT COUNT(*) FROM chunks),
+		(SELECT COALESCE(SUM(octet_length(v)), 0) FROM user_data, unnest(encrypted_data) AS v),
+		(SELECT COALESCE(SUM(octet_length(chunk)), 0) FROM chunks),
+		(SELECT COUNT(*) FROM audit_log),
+		(SELECT COUNT(*) FROM shares),
+		(SELECT COALESCE(SUM(octet_length(data)), 0) FROM shares),
+		(SELECT COUNT(*) FROM org_items),
+		(SELECT COALESCE(SUM(octet_length(data)), 0) FROM org_items)
+	`).Scan(&stats.Users, &stats.DisabledUsers, &stats.Records, &stats.Versions, &stats.Attachments, &stats.Chunks,
+		&dataBytes, &chunkBytes, &stats.AuditEvents, &shares, &shareBytes, &items, &itemBytes)
+	if err != nil {
+		return account.Stats{}, fmt.Errorf("scan error, %w", err)
+	}
+	// Общие записи и записи коллекций организаций хранятся в одной версии
+	stats.Records += shares + items
+	stats.Versions += shares + items
+	stats.Bytes = dataBytes + chunkBytes + shareBytes + itemBytes
+	return stats, nil
+}
+
+// SetKeyPair - метод для сохранения или замены ключевой пары пользователя.
+func (s Store) SetKeyPair(ctx context.Context, idUser string, keys share.KeyPair) error {
+	ctx, span := startSpan(ctx, "SetKeyPair")
+	defer span.End()
+
+	_, err := s.conn.ExecContext(ctx, `
+		INSERT INTO key_pairs (user_id, public_key, encrypted_private_key)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET public_key = EXCLUDED.public_key,
+			encrypted_private_key = EXCLUDED.encrypted_private_key
+	`, idUser, keys.PublicKey, keys.EncryptedPrivateKey)
+	if err != nil {
+		return fmt.Errorf("upsert key pair error, %w", err)
+	}
+	return nil
+}
+
+// GetKeyPair - метод для получения ключевой пары пользователя. Если ключевая пара не сохранена, возвращается false.
+func (s Store) GetKeyPair(ctx context.Context, idUser string) (share.KeyPair, bool, error) {
+	ctx, span := startSpan(ctx, "GetKeyPair")
+	defer span.End()
+
+	var keys share.KeyPair
+	err := s.conn.QueryRowContext(ctx, `
+		SELECT public_key, encrypted_private_key
+		FROM key_pairs
+		WHERE user_id = $1
+	`, idUser).Scan(&keys.PublicKey, &keys.EncryptedPrivateKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return share.KeyPair{}, false, nil
+	}
+	if err != nil {
+		return share.KeyPair{}, false, fmt.Errorf("scan error, %w", err)
+	}
+	return keys, true, nil
+}
+
+// GetPublicKey - метод для получения id пользователя и его открытого ключа по логину. Если пользователь не найден
+// или не сохранил ключевую пару, возвращается false.
+func (s Store) GetPublicKey(ctx context.Context, login string) (string, []byte, bool, error) {
+	ctx, span := startSpan(ctx, "GetPublicKey")
+	defer span.End()
+
+	var idUser string
+	var key []byte
+	err := s.conn.QueryRowContext(ctx, `
+		SELECT a.id, k.public_key
+		FROM auth a
+		JOIN key_pairs k ON k.user_id = a.id
+		WHERE a.login = $1
+	`, login).Scan(&idUser, &key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil, false, nil
+	}
+	if err != nil {
+		return "", nil, false, fmt.Errorf("scan error, %w", err)
+	}
+	return idUser, key, true, nil
+}
+
+// CreateShare - метод для создания общей записи. Если общая запись с таким id уже существует или владелец уже
+// поделился записью с тем же именем с этим получателем, возвращается false.
+// Если общая запись превысит квоту владельца, возвращается ошибка quota.ErrQuotaExceeded.
+func (s Store) CreateShare(ctx context.Context, sh share.Share) (bool, error) {
+	ctx, span := startSpan(ctx, "CreateShare")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	if quota.Enabled() {
+		usage, err := lockUsage(ctx, tx, sh.OwnerID)
+		if err != nil {
+			return false, err
+		}
+		if err := quota.CheckAdd(usage, int64(len(sh.Data))); err != nil {
+			return false, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO shares (id, name, owner_id, recipient_id, permission, owner_key, recipient_key, data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT DO NOTHING
+	`, sh.ID, sh.Name, sh.OwnerID, sh.RecipientID, sh.Permission, sh.OwnerKey, sh.RecipientKey, sh.Data)
+	if err != nil {
+		return false, fmt.Errorf("insert share error, %w", err)
+	}
+	ok, err := affected(res)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction error, %w", err)
+	}
+	return true, nil
+}
+
+// shareColumns - столбцы общей записи с логинами владельца и получателя в порядке сканирования scanShare.
+const shareColumns = `
+	s.id, s.name, s.owner_id, s.recipient_id, COALESCE(o.login, ''), COALESCE(r.login, ''), s.permission, s.accepted,
+	s.owner_key, s.recipient_key, s.data, s.version, s.created_at, s.updated_at
+	FROM shares s
+	LEFT JOIN auth o ON o.id = s.owner_id
+	LEFT JOIN auth r ON r.id = s.recipient_id`
+
+// scanner - общий интерфейс *sql.Row и *sql.Rows для сканирования строки.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanShare - функция для сканирования общей записи, выбранной по столбцам shareColumns.
+func scanShare(row scanner) (share.Share, error) {
+	var sh share.Share
+	err := row.Scan(&sh.ID, &sh.Name, &sh.OwnerID, &sh.RecipientID, &sh.Owner, &sh.Recipient, &sh.Permission, &sh.Accepted,
+		&sh.OwnerKey, &sh.RecipientKey, &sh.Data, &sh.Version, &sh.CreatedAt, &sh.UpdatedAt)
+	sh.CreatedAt, sh.UpdatedAt = sh.CreatedAt.UTC(), sh.UpdatedAt.UTC()
+	return sh, err
+}
+
+// GetShares - метод для получения общих записей, в которых пользователь является владельцем или получателем,
+// в порядке создания.
+func (s Store) GetShares(ctx context.Context, idUser string) ([]share.Share, error) {
+	ctx, span := startSpan(ctx, "GetShares")
+	defer span.End()
+
+	rows, err := s.conn.QueryContext(ctx, `SELECT `+shareColumns+`
+		WHERE s.owner_id = $1 OR s.recipient_id = $1
+		ORDER BY s.created_at, s.id
+	`, idUser)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error, %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]share.Share, 0)
+	for rows.Next() {
+		sh, err := scanShare(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan error, %w", err)
+		}
+		result = append(result, sh)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetShare - метод для получения общей записи по id. Если общая запись не найдена, возвращается false.
+func (s Store) GetShare(ctx context.Context, id string) (share.Share, bool, error) {
+	ctx, span := startSpan(ctx, "GetShare")
+	defer span.End()
+
+	sh, err := scanShare(s.conn.QueryRowContext(ctx, `SELECT `+shareColumns+`
+		WHERE s.id = $1
+	`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return share.Share{}, false, nil
+	}
+	if err != nil {
+		return share.Share{}, false, fmt.Errorf("scan error, %w", err)
+	}
+	return sh, true, nil
+}
+
+// AcceptShare - метод для отметки общей записи принятой получателем. Если общая запись не найдена,
+// возвращается false.
+func (s Store) AcceptShare(ctx context.Context, id string) (bool, error) {
+	ctx, span := startSpan(ctx, "AcceptShare")
+	defer span.End()
+
+	res, err := s.conn.ExecContext(ctx, `
+		UPDATE shares
+		SET accepted = true
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("query execution error, %w", err)
+	}
+	return affected(res)
+}
+
+// UpdateShare - метод для изменения содержимого общей записи, если её версия равна version. Возвращает новую
+// версию. Если общая запись не найдена или версия не совпадает, возвращается false.
+// Если изменение превысит квоту владельца общей записи, возвращается ошибка quota.ErrQuotaExceeded.
+func (s Store) UpdateShare(ctx context.Context, id string, content []byte, version int64) (int64, bool, error) {
+	ctx, span := startSpan(ctx, "UpdateShare")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	if quota.Enabled() {
+		// Владелец общей записи не меняется, поэтому его можно получить до блокировки хранилища
+		var ownerID string
+		err := tx.QueryRowContext(ctx, `SELECT owner_id FROM shares WHERE id = $1`, id).Scan(&ownerID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		if err != nil {
+			return 0, false, fmt.Errorf("scan error, %w", err)
+		}
+		usage, err := lockUsage(ctx, tx, ownerID)
+		if err != nil {
+			return 0, false, err
+		}
+		var record data.RecordUsage
+		err = tx.QueryRowContext(ctx, `SELECT 1, octet_length(data) FROM shares WHERE id = $1`, id).
+			Scan(&record.Versions, &record.Bytes)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		if err != nil {
+			return 0, false, fmt.Errorf("scan error, %w", err)
+		}
+		if err := quota.CheckReplace(usage, record, int64(len(content))); err != nil {
+			return 0, false, err
+		}
+	}
+
+	var newVersion int64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE shares
+		SET data = $2, version = version + 1, updated_at = now()
+		WHERE id = $1 AND version = $3
+		RETURNING version
+	`, id, content, version).Scan(&newVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("scan error, %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("commit transaction error, %w", err)
+	}
+	return newVersion, true, nil
+}
+
+// DeleteShare - метод для удаления общей записи. Если общая запись не найдена, возвращается false.
+func (s Store) DeleteShare(ctx context.Context, id string) (bool, error) {
+	ctx, span := startSpan(ctx, "DeleteShare")
+	defer span.End()
+
+	res, err := s.conn.ExecContext(ctx, `
+		DELETE FROM shares
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("query execution error, %w", err)
+	}
+	return affected(res)
+}
+
+// CreateOrg - метод для создания организации с первой версией ключа коллекции и владельцем owner. Если организация
+// с таким id уже существует, возвращается false.
+func (s Store) CreateOrg(ctx context.Context, o org.Org, owner org.Member) (bool, error) {
+	ctx, span := startSpan(ctx, "CreateOrg")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO orgs (id, name)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, o.ID, o.Name)
+	if err != nil {
+		return false, fmt.Errorf("insert org error, %w", err)
+	}
+	if ok, err := affected(res); err != nil || !ok {
+		return false, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO org_members (org_id, user_id, role, encrypted_key, key_version)
+		VALUES ($1, $2, $3, $4, 1)
+	`, o.ID, owner.UserID, org.Owner, owner.Key)
+	if err != nil {
+		return false, fmt.Errorf("insert org owner error, %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction error, %w", err)
+	}
+	return true, nil
+}
+
+// orgColumns - столбцы организации с ролью и ключом коллекции участника в порядке сканирования scanOrg.
+const orgColumns = `
+	o.id, o.name, o.key_version, o.created_at, m.role, m.encrypted_key
+	FROM orgs o
+	JOIN org_members m ON m.org_id = o.id`
+
+// scanOrg - функция для сканирования организации, выбранной по столбцам orgColumns.
+func scanOrg(row scanner) (org.Org, error) {
+	var o org.Org
+	err := row.Scan(&o.ID, &o.Name, &o.KeyVersion, &o.CreatedAt, &o.Role, &o.Key)
+	o.CreatedAt = o.CreatedAt.UTC()
+	return o, err
+}
+
+// GetOrgs - метод для получения организаций, участником которых является пользователь, с его ролью и ключом
+// коллекции в порядке создания.
+func (s Store) GetOrgs(ctx context.Context, idUser string) ([]org.Org, error) {
+	ctx, span := startSpan(ctx, "GetOrgs")
+	defer span.End()
+
+	rows, err := s.conn.QueryContext(ctx, `SELECT `+orgColumns+`
+		WHERE m.user_id = $1
+		ORDER BY o.created_at, o.id
+	`, idUser)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error, %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]org.Org, 0)
+	for rows.Next() {
+		o, err := scanOrg(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan error, %w", err)
+		}
+		result = append(result, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetOrg - метод для получения организации с ролью и ключом коллекции пользователя. Если организация не найдена
+// или пользователь не является её участником, возвращается false.
+func (s Store) GetOrg(ctx context.Context, idOrg, idUser string) (org.Org, bool, error) {
+	ctx, span := startSpan(ctx, "GetOrg")
+	defer span.End()
+
+	o, err := scanOrg(s.conn.QueryRowContext(ctx, `SELECT `+orgColumns+`
+		WHERE o.id = $1 AND m.user_id = $2
+	`, idOrg, idUser))
+	if errors.Is(err, sql.ErrNoRows) {
+		return org.Org{}, false, nil
+	}
+	if err != nil {
+		return org.Org{}, false, fmt.Errorf("scan error, %w", err)
+	}
+	return o, true, nil
+}
+
+// DeleteOrg - метод для удаления организации с участниками и записями коллекции в одной транзакции. Если
+// организация не найдена, возвращается false.
+func (s Store) DeleteOrg(ctx context.Context, idOrg string) (bool, error) {
+	ctx, span := startSpan(ctx, "DeleteOrg")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM orgs
+		WHERE id = $1
+	`, idOrg)
+	if err != nil {
+		return false, fmt.Errorf("delete org error, %w", err)
+	}
+	if ok, err := affected(res); err != nil || !ok {
+		return false, err
+	}
+	for _, table := range []string{"org_members", "org_items"} {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE org_id = $1`, idOrg); err != nil {
+			return false, fmt.Errorf("delete from %s error, %w", table, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction error, %w", err)
+	}
+	return true, nil
+}
+
+// GetMembers - метод для получения участников организации в порядке логинов.
+func (s Store) GetMembers(ctx context.Context, idOrg string) ([]org.Member, error) {
+	ctx, span := startSpan(ctx, "GetMembers")
+	defer span.End()
+
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT m.user_id, COALESCE(a.login, ''), m.role, m.encrypted_key, m.key_version
+		FROM org_members m
+		LEFT JOIN auth a ON a.id = m.user_id
+		WHERE m.org_id = $1
+		ORDER BY 2
+	`, idOrg)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error, %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]org.Member, 0)
+	for rows.Next() {
+		var m org.Member
+		if err := rows.Scan(&m.UserID, &m.Login, &m.Role, &m.Key, &m.KeyVersion); err != nil {
+			return nil, fmt.Errorf("scan error, %w", err)
+		}
+		result = append(result, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AddMember - метод для добавления участника организации. Если организация не найдена, пользователь уже является
+// её участником или ключ коллекции зашифрован для участника не текущей версией, возвращается false.
+func (s Store) AddMember(ctx context.Context, idOrg string, m org.Member) (bool, error) {
+	ctx, span := startSpan(ctx, "AddMember")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	keyVersion, ok, err := lockOrg(ctx, tx, idOrg, "SHARE")
+	if err != nil || !ok || keyVersion != m.KeyVersion {
+		return false, err
+	}
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO org_members (org_id, user_id, role, encrypted_key, key_version)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING
+	`, idOrg, m.UserID, m.Role, m.Key, m.KeyVersion)
+	if err != nil {
+		return false, fmt.Errorf("insert org member error, %w", err)
+	}
+	if ok, err := affected(res); err != nil || !ok {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction error, %w", err)
+	}
+	return true, nil
+}
+
+// SetRole - метод для изменения роли участника организации. Если организация или участник не найдены,
+// возвращается false.
+func (s Store) SetRole(ctx context.Context, idOrg, idUser, role string) (bool, error) {
+	ctx, span := startSpan(ctx, "SetRole")
+	defer span.End()
+
+	res, err := s.conn.ExecContext(ctx, `
+		UPDATE org_members
+		SET role = $3
+		WHERE org_id = $1 AND user_id = $2
+	`, idOrg, idUser, role)
+	if err != nil {
+		return false, fmt.Errorf("query execution error, %w", err)
+	}
+	return affected(res)
+}
+
+// RotateKey - метод для замены ключа коллекции организации и удаления участника removeID, если он задан, в одной
+// транзакции. Ключи в r.Keys передаются по id участников. Если новая версия ключа не следует за текущей, ключ
+// зашифрован не для всех оставшихся участников или записи коллекции не совпадают с текущими по именам и версиям,
+// возвращается false.
+func (s Store) RotateKey(ctx context.Context, idOrg string, removeID string, r org.Rotation) (bool, error) {
+	ctx, span := startSpan(ctx, "RotateKey")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокирую организацию, чтобы участники и записи не изменились до замены ключа
+	keyVersion, ok, err := lockOrg(ctx, tx, idOrg, "UPDATE")
+	if err != nil || !ok || r.KeyVersion != keyVersion+1 {
+		return false, err
+	}
+	members, err := queryVersions(ctx, tx, `SELECT user_id, key_version FROM org_members WHERE org_id = $1`, idOrg)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := members[removeID]; removeID != "" && !ok {
+		return false, nil
+	}
+	delete(members, removeID)
+	if len(r.Keys) != len(members) {
+		return false, nil
+	}
+	for idUser := range r.Keys {
+		if _, ok := members[idUser]; !ok {
+			return false, nil
+		}
+	}
+	items, err := queryVersions(ctx, tx, `SELECT name, version FROM org_items WHERE org_id = $1`, idOrg)
+	if err != nil {
+		return false, err
+	}
+	if len(r.Items) != len(items) {
+		return false, nil
+	}
+	for _, item := range r.Items {
+		if version, ok := items[item.Name]; !ok || version != item.Version {
+			return false, nil
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM org_members WHERE org_id = $1 AND user_id = $2`, idOrg, removeID); err != nil {
+		return false, fmt.Errorf("delete org member error, %w", err)
+	}
+	for idUser, key := range r.Keys {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE org_members
+			SET encrypted_key = $3, key_version = $4
+			WHERE org_id = $1 AND user_id = $2
+		`, idOrg, idUser, key, r.KeyVersion)
+		if err != nil {
+			return false, fmt.Errorf("update org member key error, %w", err)
+		}
+	}
+	for _, item := range r.Items {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE org_items
+			SET data = $3, key_version = $4, version = version + 1, updated_at = now()
+			WHERE org_id = $1 AND name = $2
+		`, idOrg, item.Name, item.Data, r.KeyVersion)
+		if err != nil {
+			return false, fmt.Errorf("update org item error, %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE orgs SET key_version = $2 WHERE id = $1`, idOrg, r.KeyVersion); err != nil {
+		return false, fmt.Errorf("update org key version error, %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction error, %w", err)
+	}
+	return true, nil
+}
+
+// GetItems - метод для получения записей коллекции организации в порядке имен.
+func (s Store) GetItems(ctx context.Context, idOrg string) ([]org.Item, error) {
+	ctx, span := startSpan(ctx, "GetItems")
+	defer span.End()
+
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT name, data, key_version, version, updated_at
+		FROM org_items
+		WHERE org_id = $1
+		ORDER BY name
+	`, idOrg)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error, %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]org.Item, 0)
+	for rows.Next() {
+		var item org.Item
+		if err := rows.Scan(&item.Name, &item.Data, &item.KeyVersion, &item.Version, &item.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan error, %w", err)
+		}
+		item.UpdatedAt = item.UpdatedAt.UTC()
+		result = append(result, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PutItem - метод для добавления записи в коллекцию организации, если item.Version равна 0, или изменения записи,
+// если её версия равна item.Version. Возвращает новую версию записи. Если организация не найдена, версия записи
+// не совпадает или запись зашифрована не текущей версией ключа коллекции, возвращается false.
+// Записи коллекции учитываются в квоте владельца организации, при её превышении возвращается ошибка
+// quota.ErrQuotaExceeded.
+func (s Store) PutItem(ctx context.Context, idOrg string, item org.Item) (int64, bool, error) {
+	ctx, span := startSpan(ctx, "PutItem")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	keyVersion, ok, err := lockOrg(ctx, tx, idOrg, "SHARE")
+	if err != nil || !ok || keyVersion != item.KeyVersion {
+		return 0, false, err
+	}
+	if quota.Enabled() {
+		if err := checkItemQuota(ctx, tx, idOrg, item); err != nil {
+			return 0, false, err
+		}
+	}
+	query := `
+		UPDATE org_items
+		SET data = $3, key_version = $4, version = version + 1, updated_at = now()
+		WHERE org_id = $1 AND name = $2 AND version = $5
+		RETURNING version`
+	args := []any{idOrg, item.Name, item.Data, item.KeyVersion, item.Version}
+	if item.Version == 0 {
+		query = `
+		INSERT INTO org_items (org_id, name, data, key_version)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+		RETURNING version`
+		args = args[:4]
+	}
+	var version int64
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("scan error, %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("commit transaction error, %w", err)
+	}
+	return version, true, nil
+}
+
+// DeleteItem - метод для удаления записи коллекции организации, если её версия равна version. Если организация
+// или запись не найдены или версия не совпадает, возвращается false.
+func (s Store) DeleteItem(ctx context.Context, idOrg, name string, version int64) (bool, error) {
+	ctx, span := startSpan(ctx, "DeleteItem")
+	defer span.End()
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction error, %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, ok, err := lockOrg(ctx, tx, idOrg, "SHARE"); err != nil || !ok {
+		return false, err
+	}
+	res, err := tx.ExecContext(ctx, `
+		DELETE FROM org_items
+		WHERE org_id = $1 AND name = $2 AND version = $3
+	`, idOrg, name, version)
+	if err != nil {
+		return false, fmt.Errorf("delete org item error, %w", err)
+	}
+	if ok, err := affected(res); err != nil || !ok {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction error, %w", err)
+	}
+	return true, nil
+}
+
+// lockOrg - функция для блокировки строки организации в транзакции tx в режиме mode (SHARE или UPDATE).
+// Изменения записей и участников блокируют организацию в режиме SHARE, а замена ключа коллекции - в режиме UPDATE,
+// поэтому запись не может быть сохранена со старой версией ключа во время его замены. Возвращает текущую версию
+// ключа коллекции; если организация не найдена, возвращается false.
+func lockOrg(ctx context.Context, tx *sql.Tx, idOrg, mode string) (int64, bool, error) {
+	var keyVersion int64
+	err := tx.QueryRowContext(ctx, `SELECT key_version FROM orgs WHERE id = $1 FOR `+mode, idOrg).Scan(&keyVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("lock org error, %w", err)
+	}
+	return keyVersion, true, nil
+}
+
+// checkItemQuota - функция для проверки квоты владельца организации перед сохранением записи коллекции item.
+// Хранилище владельца блокируется до конца транзакции tx так же, как при изменении его собственных записей.
+func checkItemQuota(ctx context.Context, tx *sql.Tx, idOrg string, item org.Item) error {
+	var ownerID string
+	err := tx.QueryRowContext(ctx, `
+		SELECT user_id FROM org_members WHERE org_id = $1 AND role = $2
+	`, idOrg, org.Owner).Scan(&ownerID)
+	if err != nil {
+		return fmt.Errorf("scan error, %w", err)
+	}
+	usage, err := lockUsage(ctx, tx, ownerID)
+	if err != nil {
+		return err
+	}
+	var record data.RecordUsage
+	err = tx.QueryRowContext(ctx, `
+		SELECT 1, octet_length(data) FROM org_items WHERE org_id = $1 AND name = $2
+	`, idOrg, item.Name).Scan(&record.Versions, &record.Bytes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quota.CheckAdd(usage, int64(len(item.Data)))
+	}
+	if err != nil {
+		return fmt.Errorf("scan error, %w", err)
+	}
+	return quota.CheckReplace(usage, record, int64(len(item.Data)))
+}
+
+// queryVersions - функция для выборки пар ключ-версия запросом query с параметром idOrg.
+func queryVersions(ctx context.Context, tx *sql.Tx, query, idOrg string) (map[string]int64, error) {
+	rows, err := tx.QueryContext(ctx, query, idOrg)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error, %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]int64)
+	for rows.Next() {
+		var key string
+		var version int64
+		if err := rows.Scan(&key, &version); err != nil {
+			return nil, fmt.Errorf("scan error, %w", err)
+		}
+		result[key] = version
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// affected - функция для проверки, что запрос изменил хотя бы одну строку.
+func affected(res sql.Result) (bool, error) {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected error, %w", err)
+	}
+	return n > 0, nil
+}
+
+// nullTime - функция для передачи в запрос нулевого времени как NULL.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// querier - общий интерфейс *sql.DB и *sql.Tx для выполнения запросов.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// lockUsage - функция для блокировки изменений хранилища пользователя до конца транзакции tx и получения информации
+// об использовании хранилища. Изменения, проверяющие квоту пользователя, выполняются последовательно, поэтому
+// одновременные запросы не могут вместе превысить квоту.
+func lockUsage(ctx context.Context, tx *sql.Tx, idUser string) (data.Usage, error) {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, idUser); err != nil {
+		return data.Usage{}, fmt.Errorf("lock user storage error, %w", err)
+	}
+	return queryUsage(ctx, tx, idUser)
+}
+
+// queryUsage - функция для получения объема зашифрованных данных и частей вложений пользователя, количества записей
+// и наибольшего количества версий одной записи. Общие записи, которыми владеет пользователь, и записи коллекций
+// его организаций учитываются как записи пользователя.
+func queryUsage(ctx context.Context, q querier, idUser string) (data.Usage, error) {
+	var usage data.Usage
+	var records, shares, items int
+	var dataBytes, chunkBytes, shareBytes, itemBytes int64
+	owned := `SELECT org_id FROM org_members WHERE user_id = $1 AND role = '` + org.Owner + `'`
+	err := q.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM user_data WHERE user_id = $1),
+			(SELECT COALESCE(MAX(COALESCE(array_length(encrypted_data, 1), 0)), 0) FROM user_data WHERE user_id = $1),
+			(SELECT COALESCE(SUM(octet_length(v)), 0) FROM user_data, unnest(encrypted_data) AS v WHERE user_id = $1),
+			(SELECT COALESCE(SUM(octet_length(chunk)), 0) FROM chunks WHERE user_id = $1),
+			(SELECT COUNT(*) FROM shares WHERE owner_id = $1),
+			(SELECT COALESCE(SUM(octet_length(data)), 0) FROM shares WHERE owner_id = $1),
+			(SELECT COUNT(*) FROM org_items WHERE org_id IN (`+owned+`)),
+			(SELECT COALESCE(SUM(octet_length(data)), 0) FROM org_items WHERE org_id IN (`+owned+`))
+	`, idUser).Scan(&records, &usage.Versions, &dataBytes, &chunkBytes, &shares, &shareBytes, &items, &itemBytes)
+	if err != nil {
+		return data.Usage{}, fmt.Errorf("scan error, %w", err)
+	}
+	usage.Records = records + shares + items
+	usage.Bytes = dataBytes + chunkBytes + shareBytes + itemBytes
+	return usage, nil
+}
+
+// queryRecordUsage - функция для получения объема и количества версий записи пользователя.
+// Если записи не существует, возвращается false.
+func queryRecordUsage(ctx context.Context, q querier, idUser, dataName string) (data.RecordUsage, bool, error) {
+	var record data.RecordUsage
+	err := q.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(array_length(encrypted_data, 1), 0),
+			(SELECT COALESCE(SUM(octet_length(v)), 0) FROM unnest(encrypted_data) AS v)
+		FROM user_data
+		WHERE user_id = $1 AND data_name = $2
+	`, idUser, dataName).Scan(&record.Versions, &record.Bytes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return data.RecordUsage{}, false, nil
+	}
+	if err != nil {
+		return data.RecordUsage{}, false, fmt.Errorf("scan error, %w", err)
+	}
+	return record, true, nil
+}
+
+// missingChunks - функция для получения хэшей несохраненных частей в порядке их передачи. Если lock установлен,
+// сохраненные части блокируются до конца транзакции.
+func missingChunks(ctx context.Context, q querier, idUser string, hashes []string, lock bool) ([]string, error) {
+	query := `
+		SELECT hash
+		FROM chunks
+		WHERE user_id = $1 AND hash = ANY($2)
+	`
+	if lock {
+		query += "FOR UPDATE"
+	}
+	rows, err := q.QueryContext(ctx, query, idUser, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("query execution error, %w", err)
+	}
+	defer rows.Close()
+
+	stored := make(map[string]bool, len(hashes))
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("scan error, %w", err)
+		}
+		stored[hash] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0)
+	for _, hash := range hashes {
+		if !stored[hash] && !slices.Contains(result, hash) {
+			result = append(result, hash)
+		}
+	}
+	return result, nil
+}
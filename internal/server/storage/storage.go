@@ -2,8 +2,14 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	"github.com/abezemskiy/gophkeeper/internal/repositories/account"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/audit"
 	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/org"
+	"github.com/abezemskiy/gophkeeper/internal/repositories/share"
 	repoStorage "github.com/abezemskiy/gophkeeper/internal/repositories/storage"
 )
 
@@ -14,9 +20,88 @@ type (
 		AppendEncryptedData(ctx context.Context, idUser string, data data.EncryptedData) (bool, error) // Для добавления зашифрованныч данных по id
 	}
 
+	// UsageReader - интерфейс для получения информации об использовании хранилища пользователем.
+	UsageReader interface {
+		GetUsage(ctx context.Context, idUser string) (data.Usage, error)                             // Возвращает объем и количество данных пользователя
+		GetRecordUsage(ctx context.Context, idUser, dataName string) (data.RecordUsage, bool, error) // Возвращает объем и количество версий записи
+	}
+
 	// IEncryptedServerStorage - интерфейс сервера для хранения зашифрованных данных пользователей.
 	IEncryptedServerStorage interface {
 		repoStorage.IEncryptedStorage
 		EncryptedDataAppender
+		UsageReader
 	}
 )
+
+// Ошибки хранилища вложений.
+var (
+	ErrMissingChunks      = errors.New("some chunks of attachment are not stored")        // часть вложения не сохранена на сервере
+	ErrAttachmentMismatch = errors.New("attachment already exists with different chunks") // вложение уже существует с другим списком частей
+)
+
+// IAttachmentStorage - интерфейс сервера для хранения зашифрованных вложений пользователей.
+// Части вложений адресуются хэшем зашифрованной части, поэтому одинаковые части хранятся один раз.
+// Вложение ссылается на список частей и удаляется вместе с частями, на которые больше нет ссылок,
+// только когда на него не остается ссылок записей пользователя. Ссылки учитываются по идентификаторам записей,
+// поэтому повторная передача ссылки одной записи не изменяет количество ссылок. Части незавершенных загрузок,
+// на которые так и не сослалось вложение, удаляются сборщиком через DeleteUnusedChunks.
+type IAttachmentStorage interface {
+	UsageReader
+	SaveChunk(ctx context.Context, idUser, hash string, chunk []byte) error                                  // Сохраняет часть, если она ещё не сохранена
+	GetChunk(ctx context.Context, idUser, hash string) ([]byte, bool, error)                                 // Возвращает часть по хэшу
+	GetMissingChunks(ctx context.Context, idUser string, hashes []string) ([]string, error)                  // Возвращает хэши несохраненных частей
+	AddAttachmentRef(ctx context.Context, idUser, attachmentID, record string, chunks []string) (int, error) // Создает вложение или добавляет ссылку записи
+	GetAttachment(ctx context.Context, idUser, attachmentID string) (data.AttachmentInfo, bool, error)       // Возвращает информацию о вложении
+	ReleaseAttachment(ctx context.Context, idUser, attachmentID, record string) (int, bool, error)           // Удаляет ссылку записи на вложение
+	DeleteUnusedChunks(ctx context.Context, before time.Time) (int64, error)                                 // Удаляет части без ссылок, сохраненные до before
+}
+
+// IAuditStorage - интерфейс сервера для хранения журнала аудита. Журнал только дополняется: сохраненные события
+// не изменяются и не удаляются.
+type IAuditStorage interface {
+	AppendAuditEvent(ctx context.Context, event audit.Event) error                  // Сохраняет событие
+	GetAuditEvents(ctx context.Context, filter audit.Filter) ([]audit.Event, error) // Возвращает события по условиям выборки
+}
+
+// IAccountStorage - интерфейс сервера для управления учетными записями пользователей администратором.
+// Учетные записи адресуются id пользователя; если пользователь не найден, методы возвращают false.
+type IAccountStorage interface {
+	GetSession(ctx context.Context, idUser string) (account.Session, bool, error) // Возвращает состояние сессий пользователя
+	ListUsers(ctx context.Context) ([]account.User, error)                        // Возвращает учетные записи, упорядоченные по логину
+	SetDisabled(ctx context.Context, idUser string, disabled bool) (bool, error)  // Блокирует или разблокирует учетную запись
+	Logout(ctx context.Context, idUser string, at time.Time) (bool, error)        // Отзывает токены, выданные до момента at
+	DeleteUser(ctx context.Context, idUser string) (bool, error)                  // Удаляет учетную запись со всеми данными и вложениями
+	GetStats(ctx context.Context) (account.Stats, error)                          // Возвращает статистику сервера
+}
+
+// IShareStorage - интерфейс сервера для хранения ключевых пар пользователей и общих записей. Права пользователей
+// на общие записи проверяются хэндлерами сервера; если общая запись не найдена, методы возвращают false.
+type IShareStorage interface {
+	SetKeyPair(ctx context.Context, idUser string, keys share.KeyPair) error                        // Сохраняет или заменяет ключевую пару
+	GetKeyPair(ctx context.Context, idUser string) (share.KeyPair, bool, error)                     // Возвращает ключевую пару пользователя
+	GetPublicKey(ctx context.Context, login string) (idUser string, key []byte, ok bool, err error) // Возвращает открытый ключ по логину
+	CreateShare(ctx context.Context, s share.Share) (bool, error)                                   // Создает общую запись, false - если уже существует
+	GetShares(ctx context.Context, idUser string) ([]share.Share, error)                            // Возвращает общие записи владельца и получателя
+	GetShare(ctx context.Context, id string) (share.Share, bool, error)                             // Возвращает общую запись по id
+	AcceptShare(ctx context.Context, id string) (bool, error)                                       // Отмечает общую запись принятой получателем
+	UpdateShare(ctx context.Context, id string, data []byte, version int64) (int64, bool, error)    // Изменяет содержимое, если версия совпадает
+	DeleteShare(ctx context.Context, id string) (bool, error)                                       // Удаляет общую запись
+}
+
+// IOrgStorage - интерфейс сервера для хранения организаций, их участников и записей коллекций организаций.
+// Роли участников проверяются хэндлерами сервера, а версии ключа коллекции и записей - хранилищем: если версия
+// не совпадает, методы возвращают false. Участники передаются и возвращаются по id пользователя.
+type IOrgStorage interface {
+	CreateOrg(ctx context.Context, o org.Org, owner org.Member) (bool, error)                   // Создает организацию с владельцем
+	GetOrgs(ctx context.Context, idUser string) ([]org.Org, error)                              // Возвращает организации пользователя
+	GetOrg(ctx context.Context, idOrg, idUser string) (org.Org, bool, error)                    // Возвращает организацию участника
+	DeleteOrg(ctx context.Context, idOrg string) (bool, error)                                  // Удаляет организацию с записями
+	GetMembers(ctx context.Context, idOrg string) ([]org.Member, error)                         // Возвращает участников организации
+	AddMember(ctx context.Context, idOrg string, m org.Member) (bool, error)                    // Добавляет участника с текущей версией ключа
+	SetRole(ctx context.Context, idOrg, idUser, role string) (bool, error)                      // Изменяет роль участника
+	RotateKey(ctx context.Context, idOrg string, removeID string, r org.Rotation) (bool, error) // Заменяет ключ коллекции и записи
+	GetItems(ctx context.Context, idOrg string) ([]org.Item, error)                             // Возвращает записи коллекции
+	PutItem(ctx context.Context, idOrg string, item org.Item) (int64, bool, error)              // Добавляет или изменяет запись
+	DeleteItem(ctx context.Context, idOrg, name string, version int64) (bool, error)            // Удаляет запись
+}
@@ -0,0 +1,22 @@
+package testutil
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/abezemskiy/gophkeeper/internal/repositories/data"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Date - дата создания и изменения тестовых данных.
+var Date = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+// NewData - функция для создания тестовых данных пользователя с сериализованной в JSON полезной нагрузкой payload.
+func NewData(t testing.TB, name string, dataType int, metainfo string, payload any) data.Data {
+	t.Helper()
+	b, err := json.Marshal(payload)
+	require.NoError(t, err)
+	return data.Data{Data: b, Type: dataType, Name: name, Metainfo: metainfo, CreateDate: Date, EditDate: Date}
+}
@@ -0,0 +1,62 @@
+// Пакет testutil содержит общие средства тестов клиента и сервера: тестовый сервер, который запускает настоящий
+// маршрутизатор REST API поверх httptest с хранилищем в оперативной памяти, и клиентов его пользователей.
+package testutil
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abezemskiy/gophkeeper/internal/common/identity/tools/token"
+	"github.com/abezemskiy/gophkeeper/internal/server/router"
+	"github.com/abezemskiy/gophkeeper/internal/server/storage/memory"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	secretKey  = "test secret key" // ключ для подписи JWT
+	expireHour = 1                 // время действия JWT в часах
+)
+
+// Server - тестовый сервер с хранилищем в оперативной памяти.
+type Server struct {
+	URL   string        // адрес сервера
+	Store *memory.Store // хранилище сервера
+
+	ts *httptest.Server
+}
+
+// StartServer - функция для запуска тестового сервера. Сервер необходимо остановить методом Close.
+func StartServer() *Server {
+	token.SetSecretKey(secretKey)
+	token.SerExpireHour(expireHour)
+
+	stor := memory.NewStore()
+	ts := httptest.NewServer(router.MetricRouter(stor, stor, stor, stor, stor, stor, stor))
+	return &Server{URL: ts.URL, Store: stor, ts: ts}
+}
+
+// NewServer - функция для запуска тестового сервера, который останавливается по завершении теста t.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := StartServer()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Close - метод для остановки тестового сервера.
+func (s *Server) Close() {
+	s.ts.Close()
+}
+
+// NewClient - метод для регистрации на сервере пользователя login с идентификатором id. Возвращает клиента,
+// который передает в запросах токен этого пользователя.
+func (s *Server) NewClient(t testing.TB, login, id string) *resty.Client {
+	t.Helper()
+	require.NoError(t, s.Store.Register(context.Background(), login, "hash", id))
+	jwt, err := token.BuildJWT(id)
+	require.NoError(t, err)
+	return resty.New().SetAuthToken(jwt)
+}